@@ -0,0 +1,168 @@
+// Package client calls the pharmacon api from go.
+// The methods in operations.go are generated from the openapi operations
+// with `make openapi`, don't edit them by hand.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+}
+
+// baseURL is the server address without the api prefix, e.g. http://localhost:19230
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/") + constants.API_PREFIX,
+		httpClient: httpClient,
+	}
+}
+
+// the token is sent on every request after this
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// the error returned by the api
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+// login and keep the token for the next requests
+func (c *Client) LoginAndSetToken(ctx context.Context, name string, password string) error {
+	tokens, err := c.Login(ctx, types.LoginUserPayload{Name: name, Password: password})
+	if err != nil {
+		return err
+	}
+
+	c.SetToken(tokens["token"])
+
+	return nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method string, path string, payload interface{}, result interface{}) error {
+	body, err := c.do(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+func (c *Client) doRaw(ctx context.Context, method string, path string, payload interface{}) ([]byte, error) {
+	return c.do(ctx, method, path, payload)
+}
+
+func (c *Client) doFormFile(ctx context.Context, path string, field string, fileName string, file io.Reader, result interface{}) error {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile(field, fileName)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	respBody, err := c.send(req)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.send(req)
+}
+
+func (c *Client) send(req *http.Request) ([]byte, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiError := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+
+		var errorBody map[string]string
+		if json.Unmarshal(body, &errorBody) == nil && errorBody["error"] != "" {
+			apiError.Message = errorBody["error"]
+		}
+
+		return nil, apiError
+	}
+
+	return body, nil
+}
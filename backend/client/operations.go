@@ -0,0 +1,987 @@
+// Code generated by cmd/openapi. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// Login calls POST /user/login, login and get the access token
+func (c *Client) Login(ctx context.Context, payload types.LoginUserPayload) (map[string]string, error) {
+	var result map[string]string
+	err := c.doJSON(ctx, http.MethodPost, "/user/login", payload, &result)
+	return result, err
+}
+
+// RegisterUser calls POST /user/register, register a new user
+func (c *Client) RegisterUser(ctx context.Context, payload types.RegisterUserPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/user/register", payload, &result)
+	return result, err
+}
+
+// GetUsers calls GET /user/{params}/{val}, get all users or search by params
+func (c *Client) GetUsers(ctx context.Context, params string, val string) ([]types.User, error) {
+	var result []types.User
+	err := c.doJSON(ctx, http.MethodGet, "/user/"+url.PathEscape(params)+"/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetCurrentUser calls GET /user/current, get the logged in user
+func (c *Client) GetCurrentUser(ctx context.Context) (types.User, error) {
+	var result types.User
+	err := c.doJSON(ctx, http.MethodGet, "/user/current", nil, &result)
+	return result, err
+}
+
+// GetUserDetail calls POST /user/detail
+func (c *Client) GetUserDetail(ctx context.Context, payload types.GetOneUserPayload) (types.User, error) {
+	var result types.User
+	err := c.doJSON(ctx, http.MethodPost, "/user/detail", payload, &result)
+	return result, err
+}
+
+// DeleteUser calls DELETE /user
+func (c *Client) DeleteUser(ctx context.Context, payload types.RemoveUserPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/user", payload, &result)
+	return result, err
+}
+
+// ModifyUser calls PATCH /user/modify
+func (c *Client) ModifyUser(ctx context.Context, payload types.ModifyUserPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/user/modify", payload, &result)
+	return result, err
+}
+
+// Logout calls GET /user/logout
+func (c *Client) Logout(ctx context.Context) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodGet, "/user/logout", nil, &result)
+	return result, err
+}
+
+// ChangeAdminStatus calls PATCH /user/admin
+func (c *Client) ChangeAdminStatus(ctx context.Context, payload types.ChangeAdminStatusPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/user/admin", payload, &result)
+	return result, err
+}
+
+// ChangePassword calls PATCH /user/password, change the password of the logged in user, the old password can be the reset code
+func (c *Client) ChangePassword(ctx context.Context, payload types.ChangePasswordPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/user/password", payload, &result)
+	return result, err
+}
+
+// GetPasswordPolicy calls GET /user/password/policy
+func (c *Client) GetPasswordPolicy(ctx context.Context) (types.PasswordPolicy, error) {
+	var result types.PasswordPolicy
+	err := c.doJSON(ctx, http.MethodGet, "/user/password/policy", nil, &result)
+	return result, err
+}
+
+// ResetPassword calls POST /user/password/reset, get a one-time code for the user to log in and change the password
+func (c *Client) ResetPassword(ctx context.Context, payload types.ResetPasswordPayload) (types.PasswordResetCodeReturnPayload, error) {
+	var result types.PasswordResetCodeReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/user/password/reset", payload, &result)
+	return result, err
+}
+
+// SetupTOTP calls POST /user/totp/setup, get a new totp secret, it is used after it is enabled
+func (c *Client) SetupTOTP(ctx context.Context) (types.TOTPSetupReturnPayload, error) {
+	var result types.TOTPSetupReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/user/totp/setup", nil, &result)
+	return result, err
+}
+
+// EnableTOTP calls PATCH /user/totp/enable
+func (c *Client) EnableTOTP(ctx context.Context, payload types.EnableTOTPPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/user/totp/enable", payload, &result)
+	return result, err
+}
+
+// DisableTOTP calls PATCH /user/totp/disable
+func (c *Client) DisableTOTP(ctx context.Context, payload types.DisableTOTPPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/user/totp/disable", payload, &result)
+	return result, err
+}
+
+// GetLoginAttempts calls POST /login/attempt, the failed logins
+func (c *Client) GetLoginAttempts(ctx context.Context, payload types.ViewLoginAttemptPayload) ([]types.LoginAttempt, error) {
+	var result []types.LoginAttempt
+	err := c.doJSON(ctx, http.MethodPost, "/login/attempt", payload, &result)
+	return result, err
+}
+
+// GetLoginLockouts calls GET /login/lockout, the user names and the ip addresses locked now
+func (c *Client) GetLoginLockouts(ctx context.Context) ([]types.LoginLockout, error) {
+	var result []types.LoginLockout
+	err := c.doJSON(ctx, http.MethodGet, "/login/lockout", nil, &result)
+	return result, err
+}
+
+// UnlockLogin calls PATCH /login/unlock
+func (c *Client) UnlockLogin(ctx context.Context, payload types.UnlockLoginPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/login/unlock", payload, &result)
+	return result, err
+}
+
+// RegisterCustomer calls POST /customer
+func (c *Client) RegisterCustomer(ctx context.Context, payload types.RegisterCustomerPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/customer", payload, &result)
+	return result, err
+}
+
+// GetCustomers calls GET /customer/{val}, get all customers or search by name
+func (c *Client) GetCustomers(ctx context.Context, val string) ([]types.Customer, error) {
+	var result []types.Customer
+	err := c.doJSON(ctx, http.MethodGet, "/customer/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetCustomerDetail calls POST /customer/detail
+func (c *Client) GetCustomerDetail(ctx context.Context, payload types.GetOneCustomerPayload) (types.Customer, error) {
+	var result types.Customer
+	err := c.doJSON(ctx, http.MethodPost, "/customer/detail", payload, &result)
+	return result, err
+}
+
+// DeleteCustomer calls DELETE /customer
+func (c *Client) DeleteCustomer(ctx context.Context, payload types.DeleteCustomerPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/customer", payload, &result)
+	return result, err
+}
+
+// ModifyCustomer calls PATCH /customer
+func (c *Client) ModifyCustomer(ctx context.Context, payload types.ModifyCustomerPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/customer", payload, &result)
+	return result, err
+}
+
+// RegisterSupplier calls POST /supplier
+func (c *Client) RegisterSupplier(ctx context.Context, payload types.RegisterSupplierPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/supplier", payload, &result)
+	return result, err
+}
+
+// GetSuppliers calls GET /supplier/{params}/{val}, get all suppliers or search by params
+func (c *Client) GetSuppliers(ctx context.Context, params string, val string) ([]types.SupplierInformationReturnPayload, error) {
+	var result []types.SupplierInformationReturnPayload
+	err := c.doJSON(ctx, http.MethodGet, "/supplier/"+url.PathEscape(params)+"/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetSupplierDetail calls POST /supplier/detail
+func (c *Client) GetSupplierDetail(ctx context.Context, payload types.GetOneSupplierPayload) (types.SupplierInformationReturnPayload, error) {
+	var result types.SupplierInformationReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/supplier/detail", payload, &result)
+	return result, err
+}
+
+// DeleteSupplier calls DELETE /supplier
+func (c *Client) DeleteSupplier(ctx context.Context, payload types.DeleteSupplierPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/supplier", payload, &result)
+	return result, err
+}
+
+// ModifySupplier calls PATCH /supplier
+func (c *Client) ModifySupplier(ctx context.Context, payload types.ModifySupplierPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/supplier", payload, &result)
+	return result, err
+}
+
+// RegisterMedicine calls POST /medicine
+func (c *Client) RegisterMedicine(ctx context.Context, payload types.RegisterMedicinePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/medicine", payload, &result)
+	return result, err
+}
+
+// GetMedicines calls GET /medicine/{params}/{val}, get all medicines or search by params
+func (c *Client) GetMedicines(ctx context.Context, params string, val string) ([]types.MedicineListsReturnPayload, error) {
+	var result []types.MedicineListsReturnPayload
+	err := c.doJSON(ctx, http.MethodGet, "/medicine/"+url.PathEscape(params)+"/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetMedicineDetail calls POST /medicine/detail
+func (c *Client) GetMedicineDetail(ctx context.Context, payload types.GetOneMedicinePayload) (types.MedicineListsReturnPayload, error) {
+	var result types.MedicineListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/medicine/detail", payload, &result)
+	return result, err
+}
+
+// DeleteMedicine calls DELETE /medicine
+func (c *Client) DeleteMedicine(ctx context.Context, payload types.DeleteMedicinePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/medicine", payload, &result)
+	return result, err
+}
+
+// ModifyMedicine calls PATCH /medicine
+func (c *Client) ModifyMedicine(ctx context.Context, payload types.ModifyMedicinePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/medicine", payload, &result)
+	return result, err
+}
+
+// RegisterTax calls POST /tax
+func (c *Client) RegisterTax(ctx context.Context, payload types.RegisterTaxPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/tax", payload, &result)
+	return result, err
+}
+
+// GetTaxes calls GET /tax/{val}, get all taxes or search by code
+func (c *Client) GetTaxes(ctx context.Context, val string) ([]types.Tax, error) {
+	var result []types.Tax
+	err := c.doJSON(ctx, http.MethodGet, "/tax/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetTaxDetail calls POST /tax/detail
+func (c *Client) GetTaxDetail(ctx context.Context, payload types.GetOneTaxPayload) (types.Tax, error) {
+	var result types.Tax
+	err := c.doJSON(ctx, http.MethodPost, "/tax/detail", payload, &result)
+	return result, err
+}
+
+// DeleteTax calls DELETE /tax
+func (c *Client) DeleteTax(ctx context.Context, payload types.DeleteTaxPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/tax", payload, &result)
+	return result, err
+}
+
+// ModifyTax calls PATCH /tax
+func (c *Client) ModifyTax(ctx context.Context, payload types.ModifyTaxPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/tax", payload, &result)
+	return result, err
+}
+
+// GetTaxReport calls POST /tax/report
+func (c *Client) GetTaxReport(ctx context.Context, payload types.ViewTaxReportPayload) (types.TaxReportReturnPayload, error) {
+	var result types.TaxReportReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/tax/report", payload, &result)
+	return result, err
+}
+
+// GetControlledSubstanceRegister calls POST /controlled-substance/register
+func (c *Client) GetControlledSubstanceRegister(ctx context.Context, payload types.ViewControlledSubstanceRegisterPayload) ([]types.ControlledSubstanceRegisterReturnPayload, error) {
+	var result []types.ControlledSubstanceRegisterReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/controlled-substance/register", payload, &result)
+	return result, err
+}
+
+// GetControlledSubstanceReport calls POST /controlled-substance/report
+func (c *Client) GetControlledSubstanceReport(ctx context.Context, payload types.ViewControlledSubstanceReportPayload) (types.ControlledSubstanceReportReturnPayload, error) {
+	var result types.ControlledSubstanceReportReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/controlled-substance/report", payload, &result)
+	return result, err
+}
+
+// PrintControlledSubstanceReport calls POST /controlled-substance/report/pdf
+func (c *Client) PrintControlledSubstanceReport(ctx context.Context, payload types.ViewControlledSubstanceReportPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/controlled-substance/report/pdf", payload)
+}
+
+// ExportSIPNAP calls POST /controlled-substance/report/sipnap
+func (c *Client) ExportSIPNAP(ctx context.Context, payload types.ViewControlledSubstanceReportPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/controlled-substance/report/sipnap", payload)
+}
+
+// RegisterDoctor calls POST /doctor
+func (c *Client) RegisterDoctor(ctx context.Context, payload types.RegisterDoctorPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/doctor", payload, &result)
+	return result, err
+}
+
+// GetDoctors calls GET /doctor/{val}, get all doctors or search by name
+func (c *Client) GetDoctors(ctx context.Context, val string) ([]types.Doctor, error) {
+	var result []types.Doctor
+	err := c.doJSON(ctx, http.MethodGet, "/doctor/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetDoctorDetail calls POST /doctor/detail
+func (c *Client) GetDoctorDetail(ctx context.Context, payload types.GetOneDoctorPayload) (types.Doctor, error) {
+	var result types.Doctor
+	err := c.doJSON(ctx, http.MethodPost, "/doctor/detail", payload, &result)
+	return result, err
+}
+
+// DeleteDoctor calls DELETE /doctor
+func (c *Client) DeleteDoctor(ctx context.Context, payload types.DeleteDoctorPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/doctor", payload, &result)
+	return result, err
+}
+
+// ModifyDoctor calls PATCH /doctor
+func (c *Client) ModifyDoctor(ctx context.Context, payload types.ModifyDoctorPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/doctor", payload, &result)
+	return result, err
+}
+
+// RegisterPatient calls POST /patient
+func (c *Client) RegisterPatient(ctx context.Context, payload types.RegisterPatientPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/patient", payload, &result)
+	return result, err
+}
+
+// GetPatients calls GET /patient/{val}, get all patients or search by name
+func (c *Client) GetPatients(ctx context.Context, val string) ([]types.Patient, error) {
+	var result []types.Patient
+	err := c.doJSON(ctx, http.MethodGet, "/patient/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetPatientDetail calls POST /patient/detail
+func (c *Client) GetPatientDetail(ctx context.Context, payload types.GetOnePatientPayload) (types.Patient, error) {
+	var result types.Patient
+	err := c.doJSON(ctx, http.MethodPost, "/patient/detail", payload, &result)
+	return result, err
+}
+
+// DeletePatient calls DELETE /patient
+func (c *Client) DeletePatient(ctx context.Context, payload types.DeletePatientPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/patient", payload, &result)
+	return result, err
+}
+
+// ModifyPatient calls PATCH /patient
+func (c *Client) ModifyPatient(ctx context.Context, payload types.ModifyPatientPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/patient", payload, &result)
+	return result, err
+}
+
+// RegisterPatientAllergy calls POST /patient/allergy
+func (c *Client) RegisterPatientAllergy(ctx context.Context, payload types.RegisterPatientAllergyPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/patient/allergy", payload, &result)
+	return result, err
+}
+
+// GetPatientAllergies calls GET /patient/allergy/{patientId}
+func (c *Client) GetPatientAllergies(ctx context.Context, patientId string) ([]types.PatientAllergy, error) {
+	var result []types.PatientAllergy
+	err := c.doJSON(ctx, http.MethodGet, "/patient/allergy/"+url.PathEscape(patientId), nil, &result)
+	return result, err
+}
+
+// DeletePatientAllergy calls DELETE /patient/allergy
+func (c *Client) DeletePatientAllergy(ctx context.Context, payload types.DeletePatientAllergyPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/patient/allergy", payload, &result)
+	return result, err
+}
+
+// RegisterPurchaseInvoice calls POST /invoice/purchase
+func (c *Client) RegisterPurchaseInvoice(ctx context.Context, payload types.RegisterPurchaseInvoicePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/purchase", payload, &result)
+	return result, err
+}
+
+// GetPurchaseInvoices calls POST /invoice/purchase/{params}/{val}, get purchase invoices in the date range, all or search by params
+func (c *Client) GetPurchaseInvoices(ctx context.Context, params string, val string, payload types.ViewPurchaseInvoicePayload) ([]types.PurchaseInvoiceListsReturnPayload, error) {
+	var result []types.PurchaseInvoiceListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/purchase/"+url.PathEscape(params)+"/"+url.PathEscape(val), payload, &result)
+	return result, err
+}
+
+// GetPurchaseInvoiceDetail calls POST /invoice/purchase/detail
+func (c *Client) GetPurchaseInvoiceDetail(ctx context.Context, payload types.ViewPurchaseInvoiceDetailPayload) (types.PurchaseInvoiceDetailPayload, error) {
+	var result types.PurchaseInvoiceDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/purchase/detail", payload, &result)
+	return result, err
+}
+
+// DeletePurchaseInvoice calls DELETE /invoice/purchase
+func (c *Client) DeletePurchaseInvoice(ctx context.Context, payload types.DeletePurchaseInvoice) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/invoice/purchase", payload, &result)
+	return result, err
+}
+
+// ModifyPurchaseInvoice calls PATCH /invoice/purchase
+func (c *Client) ModifyPurchaseInvoice(ctx context.Context, payload types.ModifyPurchaseInvoicePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/invoice/purchase", payload, &result)
+	return result, err
+}
+
+// PrintPurchaseInvoice calls POST /invoice/purchase/print
+func (c *Client) PrintPurchaseInvoice(ctx context.Context, payload types.ViewPurchaseInvoiceDetailPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/invoice/purchase/print", payload)
+}
+
+// RegisterPurchaseOrder calls POST /invoice/purchase-order
+func (c *Client) RegisterPurchaseOrder(ctx context.Context, payload types.RegisterPurchaseOrderPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/purchase-order", payload, &result)
+	return result, err
+}
+
+// GetPurchaseOrderNextNumber calls GET /invoice/purchase-order, get the next purchase order number for today
+func (c *Client) GetPurchaseOrderNextNumber(ctx context.Context) (map[string]int, error) {
+	var result map[string]int
+	err := c.doJSON(ctx, http.MethodGet, "/invoice/purchase-order", nil, &result)
+	return result, err
+}
+
+// GetPurchaseOrders calls POST /invoice/purchase-order/{params}/{val}, get purchase orders in the date range, all or search by params
+func (c *Client) GetPurchaseOrders(ctx context.Context, params string, val string, payload types.ViewPurchaseOrderPayload) ([]types.PurchaseOrderListsReturnPayload, error) {
+	var result []types.PurchaseOrderListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/purchase-order/"+url.PathEscape(params)+"/"+url.PathEscape(val), payload, &result)
+	return result, err
+}
+
+// GetPurchaseOrderDetail calls POST /invoice/purchase-order/detail
+func (c *Client) GetPurchaseOrderDetail(ctx context.Context, payload types.ViewPurchaseOrderDetailPayload) (types.PurchaseOrderDetailPayload, error) {
+	var result types.PurchaseOrderDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/purchase-order/detail", payload, &result)
+	return result, err
+}
+
+// DeletePurchaseOrder calls DELETE /invoice/purchase-order
+func (c *Client) DeletePurchaseOrder(ctx context.Context, payload types.DeletePurchaseOrder) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/invoice/purchase-order", payload, &result)
+	return result, err
+}
+
+// ModifyPurchaseOrder calls PATCH /invoice/purchase-order
+func (c *Client) ModifyPurchaseOrder(ctx context.Context, payload types.ModifyPurchaseOrderPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/invoice/purchase-order", payload, &result)
+	return result, err
+}
+
+// PrintPurchaseOrder calls POST /invoice/purchase-order/print
+func (c *Client) PrintPurchaseOrder(ctx context.Context, payload types.ViewPurchaseOrderDetailPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/invoice/purchase-order/print", payload)
+}
+
+// RegisterInvoice calls POST /invoice
+func (c *Client) RegisterInvoice(ctx context.Context, payload types.RegisterInvoicePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/invoice", payload, &result)
+	return result, err
+}
+
+// GetInvoiceNextNumber calls GET /invoice, get the next invoice number for today
+func (c *Client) GetInvoiceNextNumber(ctx context.Context) (map[string]int, error) {
+	var result map[string]int
+	err := c.doJSON(ctx, http.MethodGet, "/invoice", nil, &result)
+	return result, err
+}
+
+// GetInvoices calls POST /invoice/{params}/{val}, get invoices in the date range, all or search by params
+func (c *Client) GetInvoices(ctx context.Context, params string, val string, payload types.ViewInvoicePayload) ([]types.InvoiceListsReturnPayload, error) {
+	var result []types.InvoiceListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/"+url.PathEscape(params)+"/"+url.PathEscape(val), payload, &result)
+	return result, err
+}
+
+// GetInvoiceDetail calls POST /invoice/detail
+func (c *Client) GetInvoiceDetail(ctx context.Context, payload types.ViewInvoiceDetailPayload) (types.InvoiceDetailPayload, error) {
+	var result types.InvoiceDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/invoice/detail", payload, &result)
+	return result, err
+}
+
+// DeleteInvoice calls DELETE /invoice
+func (c *Client) DeleteInvoice(ctx context.Context, payload types.DeleteInvoicePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/invoice", payload, &result)
+	return result, err
+}
+
+// ModifyInvoice calls PATCH /invoice
+func (c *Client) ModifyInvoice(ctx context.Context, payload types.ModifyInvoicePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/invoice", payload, &result)
+	return result, err
+}
+
+// PrintInvoice calls POST /invoice/print
+func (c *Client) PrintInvoice(ctx context.Context, payload types.ViewInvoiceDetailPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/invoice/print", payload)
+}
+
+// PrintInvoiceReceipt calls POST /invoice/print-receipt
+func (c *Client) PrintInvoiceReceipt(ctx context.Context, payload types.PrintReceiptPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/invoice/print-receipt", payload)
+}
+
+// PrintInvoiceThermalReceipt calls POST /invoice/thermal-receipt, the raw ESC/POS bytes, or a message when the server prints it
+func (c *Client) PrintInvoiceThermalReceipt(ctx context.Context, payload types.PrintThermalReceiptPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/invoice/thermal-receipt", payload)
+}
+
+// RegisterPrescription calls POST /prescription
+func (c *Client) RegisterPrescription(ctx context.Context, payload types.RegisterPrescriptionPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.doJSON(ctx, http.MethodPost, "/prescription", payload, &result)
+	return result, err
+}
+
+// GetPatientHistory calls POST /prescription/patient-history
+func (c *Client) GetPatientHistory(ctx context.Context, payload types.ViewPatientMedicationHistoryPayload) (types.PatientMedicationHistoryReturn, error) {
+	var result types.PatientMedicationHistoryReturn
+	err := c.doJSON(ctx, http.MethodPost, "/prescription/patient-history", payload, &result)
+	return result, err
+}
+
+// PrintPatientHistory calls POST /prescription/patient-history/pdf
+func (c *Client) PrintPatientHistory(ctx context.Context, payload types.ViewPatientMedicationHistoryPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/prescription/patient-history/pdf", payload)
+}
+
+// GetPrescriptions calls POST /prescription/{params}/{val}, get prescriptions in the date range, all or search by params
+func (c *Client) GetPrescriptions(ctx context.Context, params string, val string, payload types.ViewPrescriptionsPayload) ([]types.PrescriptionListsReturnPayload, error) {
+	var result []types.PrescriptionListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/prescription/"+url.PathEscape(params)+"/"+url.PathEscape(val), payload, &result)
+	return result, err
+}
+
+// GetPrescriptionDetail calls POST /prescription/detail
+func (c *Client) GetPrescriptionDetail(ctx context.Context, payload types.ViewPrescriptionDetailPayload) (types.PrescriptionDetailPayload, error) {
+	var result types.PrescriptionDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/prescription/detail", payload, &result)
+	return result, err
+}
+
+// DeletePrescription calls DELETE /prescription
+func (c *Client) DeletePrescription(ctx context.Context, payload types.DeletePrescription) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/prescription", payload, &result)
+	return result, err
+}
+
+// ModifyPrescription calls PATCH /prescription
+func (c *Client) ModifyPrescription(ctx context.Context, payload types.ModifyPrescriptionPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.doJSON(ctx, http.MethodPatch, "/prescription", payload, &result)
+	return result, err
+}
+
+// PrintPrescription calls POST /prescription/print, the prescription and eticket pdf in a zip
+func (c *Client) PrintPrescription(ctx context.Context, payload types.ViewPrescriptionDetailPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/prescription/print", payload)
+}
+
+// ScreenPrescription calls POST /prescription/screening
+func (c *Client) ScreenPrescription(ctx context.Context, payload types.ScreenPrescriptionPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.doJSON(ctx, http.MethodPost, "/prescription/screening", payload, &result)
+	return result, err
+}
+
+// PrintPrescriptionCopy calls POST /prescription/copy
+func (c *Client) PrintPrescriptionCopy(ctx context.Context, payload types.ViewPrescriptionDetailPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/prescription/copy", payload)
+}
+
+// ParseSigna calls POST /prescription/signa
+func (c *Client) ParseSigna(ctx context.Context, payload types.ParseSignaPayload) (types.SignaReturn, error) {
+	var result types.SignaReturn
+	err := c.doJSON(ctx, http.MethodPost, "/prescription/signa", payload, &result)
+	return result, err
+}
+
+// UpdatePrescriptionStatus calls PATCH /prescription/status
+func (c *Client) UpdatePrescriptionStatus(ctx context.Context, payload types.UpdatePrescriptionStatusPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/prescription/status", payload, &result)
+	return result, err
+}
+
+// GetPrescriptionQueue calls GET /prescription/queue
+func (c *Client) GetPrescriptionQueue(ctx context.Context) ([]types.PrescriptionQueueGroupReturn, error) {
+	var result []types.PrescriptionQueueGroupReturn
+	err := c.doJSON(ctx, http.MethodGet, "/prescription/queue", nil, &result)
+	return result, err
+}
+
+// RegisterMainDoctorMedItem calls POST /main-doctor-prescription-item
+func (c *Client) RegisterMainDoctorMedItem(ctx context.Context, payload types.RegisterMainDoctorMedItemPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.doJSON(ctx, http.MethodPost, "/main-doctor-prescription-item", payload, &result)
+	return result, err
+}
+
+// GetMainDoctorMedItems calls GET /main-doctor-prescription-item/{val}
+func (c *Client) GetMainDoctorMedItems(ctx context.Context, val string) ([]types.MainDoctorMedItemReturn, error) {
+	var result []types.MainDoctorMedItemReturn
+	err := c.doJSON(ctx, http.MethodGet, "/main-doctor-prescription-item/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// GetMainDoctorMedItemDetail calls POST /main-doctor-prescription-item/detail
+func (c *Client) GetMainDoctorMedItemDetail(ctx context.Context, payload types.ViewMainDoctorMedItemPayload) (types.MainDoctorMedItemReturn, error) {
+	var result types.MainDoctorMedItemReturn
+	err := c.doJSON(ctx, http.MethodPost, "/main-doctor-prescription-item/detail", payload, &result)
+	return result, err
+}
+
+// ModifyMainDoctorMedItem calls PATCH /main-doctor-prescription-item
+func (c *Client) ModifyMainDoctorMedItem(ctx context.Context, payload types.ModifyMainDoctorMedItemPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.doJSON(ctx, http.MethodPatch, "/main-doctor-prescription-item", payload, &result)
+	return result, err
+}
+
+// TestMainDoctorMedItem calls POST /main-doctor-prescription-item/test
+func (c *Client) TestMainDoctorMedItem(ctx context.Context, payload types.RegisterMainDoctorMedItemPayload) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.doJSON(ctx, http.MethodPost, "/main-doctor-prescription-item/test", payload, &result)
+	return result, err
+}
+
+// GetInteractionRules calls GET /interaction-rule
+func (c *Client) GetInteractionRules(ctx context.Context) ([]types.InteractionRule, error) {
+	var result []types.InteractionRule
+	err := c.doJSON(ctx, http.MethodGet, "/interaction-rule", nil, &result)
+	return result, err
+}
+
+// ReloadInteractionRules calls POST /interaction-rule/reload
+func (c *Client) ReloadInteractionRules(ctx context.Context) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/interaction-rule/reload", nil, &result)
+	return result, err
+}
+
+// GetEticketTemplates calls GET /eticket-template
+func (c *Client) GetEticketTemplates(ctx context.Context) ([]types.EticketTemplate, error) {
+	var result []types.EticketTemplate
+	err := c.doJSON(ctx, http.MethodGet, "/eticket-template", nil, &result)
+	return result, err
+}
+
+// ReloadEticketTemplates calls POST /eticket-template/reload
+func (c *Client) ReloadEticketTemplates(ctx context.Context) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/eticket-template/reload", nil, &result)
+	return result, err
+}
+
+// RegisterProduction calls POST /production
+func (c *Client) RegisterProduction(ctx context.Context, payload types.RegisterProductionPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/production", payload, &result)
+	return result, err
+}
+
+// GetProductionNextNumber calls GET /production, get the next production number
+func (c *Client) GetProductionNextNumber(ctx context.Context) (map[string]int, error) {
+	var result map[string]int
+	err := c.doJSON(ctx, http.MethodGet, "/production", nil, &result)
+	return result, err
+}
+
+// GetProductions calls POST /production/{params}/{val}, get productions in the date range, all or search by params
+func (c *Client) GetProductions(ctx context.Context, params string, val string, payload types.ViewProductionsPayload) ([]types.ProductionListsReturnPayload, error) {
+	var result []types.ProductionListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/production/"+url.PathEscape(params)+"/"+url.PathEscape(val), payload, &result)
+	return result, err
+}
+
+// GetProductionDetail calls POST /production/detail
+func (c *Client) GetProductionDetail(ctx context.Context, payload types.ViewProductionMedicineItemPayload) (types.ProductionDetailPayload, error) {
+	var result types.ProductionDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/production/detail", payload, &result)
+	return result, err
+}
+
+// DeleteProduction calls DELETE /production
+func (c *Client) DeleteProduction(ctx context.Context, payload types.DeleteProduction) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/production", payload, &result)
+	return result, err
+}
+
+// ModifyProduction calls PATCH /production
+func (c *Client) ModifyProduction(ctx context.Context, payload types.ModifyProductionPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/production", payload, &result)
+	return result, err
+}
+
+// RegisterProductionRecipe calls POST /production-recipe
+func (c *Client) RegisterProductionRecipe(ctx context.Context, payload types.RegisterProductionRecipePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/production-recipe", payload, &result)
+	return result, err
+}
+
+// GetProductionRecipes calls GET /production-recipe
+func (c *Client) GetProductionRecipes(ctx context.Context) ([]types.ProductionRecipeListsReturnPayload, error) {
+	var result []types.ProductionRecipeListsReturnPayload
+	err := c.doJSON(ctx, http.MethodGet, "/production-recipe", nil, &result)
+	return result, err
+}
+
+// GetProductionRecipeDetail calls POST /production-recipe/detail
+func (c *Client) GetProductionRecipeDetail(ctx context.Context, payload types.ViewProductionRecipeDetailPayload) (types.ProductionRecipeDetailPayload, error) {
+	var result types.ProductionRecipeDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/production-recipe/detail", payload, &result)
+	return result, err
+}
+
+// ScaleProductionRecipe calls POST /production-recipe/scale
+func (c *Client) ScaleProductionRecipe(ctx context.Context, payload types.ScaleProductionRecipePayload) (types.ProductionRecipeScaleReturnPayload, error) {
+	var result types.ProductionRecipeScaleReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/production-recipe/scale", payload, &result)
+	return result, err
+}
+
+// ModifyProductionRecipe calls PATCH /production-recipe
+func (c *Client) ModifyProductionRecipe(ctx context.Context, payload types.ModifyProductionRecipePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/production-recipe", payload, &result)
+	return result, err
+}
+
+// DeleteProductionRecipe calls DELETE /production-recipe
+func (c *Client) DeleteProductionRecipe(ctx context.Context, payload types.DeleteProductionRecipePayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/production-recipe", payload, &result)
+	return result, err
+}
+
+// GetCompanySetting calls GET /setting/company
+func (c *Client) GetCompanySetting(ctx context.Context) (types.CompanySetting, error) {
+	var result types.CompanySetting
+	err := c.doJSON(ctx, http.MethodGet, "/setting/company", nil, &result)
+	return result, err
+}
+
+// ModifyCompanySetting calls PATCH /setting/company
+func (c *Client) ModifyCompanySetting(ctx context.Context, payload types.ModifyCompanySettingPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/setting/company", payload, &result)
+	return result, err
+}
+
+// GetLogo calls GET /setting/logo
+func (c *Client) GetLogo(ctx context.Context) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodGet, "/setting/logo", nil)
+}
+
+// UploadLogo calls POST /setting/logo
+func (c *Client) UploadLogo(ctx context.Context, fileName string, file io.Reader) (string, error) {
+	var result string
+	err := c.doFormFile(ctx, "/setting/logo", "logo", fileName, file, &result)
+	return result, err
+}
+
+// GetDocumentSettings calls GET /setting/document
+func (c *Client) GetDocumentSettings(ctx context.Context) ([]types.DocumentSetting, error) {
+	var result []types.DocumentSetting
+	err := c.doJSON(ctx, http.MethodGet, "/setting/document", nil, &result)
+	return result, err
+}
+
+// ModifyDocumentSetting calls PATCH /setting/document
+func (c *Client) ModifyDocumentSetting(ctx context.Context, payload types.ModifyDocumentSettingPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/setting/document", payload, &result)
+	return result, err
+}
+
+// RegisterBranch calls POST /branch
+func (c *Client) RegisterBranch(ctx context.Context, payload types.RegisterBranchPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/branch", payload, &result)
+	return result, err
+}
+
+// GetBranches calls GET /branch/{val}, get all branches, by id or by code
+func (c *Client) GetBranches(ctx context.Context, val string) ([]types.Branch, error) {
+	var result []types.Branch
+	err := c.doJSON(ctx, http.MethodGet, "/branch/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// DeleteBranch calls DELETE /branch
+func (c *Client) DeleteBranch(ctx context.Context, payload types.DeleteBranchPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/branch", payload, &result)
+	return result, err
+}
+
+// ModifyBranch calls PATCH /branch
+func (c *Client) ModifyBranch(ctx context.Context, payload types.ModifyBranchPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/branch", payload, &result)
+	return result, err
+}
+
+// RegisterStockTransfer calls POST /stock-transfer
+func (c *Client) RegisterStockTransfer(ctx context.Context, payload types.RegisterStockTransferPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPost, "/stock-transfer", payload, &result)
+	return result, err
+}
+
+// GetStockTransferNextNumber calls GET /stock-transfer, get the next stock transfer number
+func (c *Client) GetStockTransferNextNumber(ctx context.Context) (map[string]int, error) {
+	var result map[string]int
+	err := c.doJSON(ctx, http.MethodGet, "/stock-transfer", nil, &result)
+	return result, err
+}
+
+// GetStockTransfers calls POST /stock-transfer/{params}/{val}, get stock transfers in the date range, all or search by params
+func (c *Client) GetStockTransfers(ctx context.Context, params string, val string, payload types.ViewStockTransferPayload) ([]types.StockTransferListsReturnPayload, error) {
+	var result []types.StockTransferListsReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/stock-transfer/"+url.PathEscape(params)+"/"+url.PathEscape(val), payload, &result)
+	return result, err
+}
+
+// GetStockTransferDetail calls POST /stock-transfer/detail
+func (c *Client) GetStockTransferDetail(ctx context.Context, payload types.ViewStockTransferDetailPayload) (types.StockTransferDetailPayload, error) {
+	var result types.StockTransferDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/stock-transfer/detail", payload, &result)
+	return result, err
+}
+
+// DispatchStockTransfer calls PATCH /stock-transfer/dispatch
+func (c *Client) DispatchStockTransfer(ctx context.Context, payload types.DispatchStockTransferPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/stock-transfer/dispatch", payload, &result)
+	return result, err
+}
+
+// ReceiveStockTransfer calls PATCH /stock-transfer/receive
+func (c *Client) ReceiveStockTransfer(ctx context.Context, payload types.ReceiveStockTransferPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/stock-transfer/receive", payload, &result)
+	return result, err
+}
+
+// DeleteStockTransfer calls DELETE /stock-transfer
+func (c *Client) DeleteStockTransfer(ctx context.Context, payload types.DeleteStockTransferPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/stock-transfer", payload, &result)
+	return result, err
+}
+
+// PrintStockTransfer calls POST /stock-transfer/print
+func (c *Client) PrintStockTransfer(ctx context.Context, payload types.ViewStockTransferDetailPayload) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodPost, "/stock-transfer/print", payload)
+}
+
+// SyncPull calls POST /sync/pull, the master data changed since the cursor
+func (c *Client) SyncPull(ctx context.Context, payload types.SyncPullPayload) (types.SyncPullReturnPayload, error) {
+	var result types.SyncPullReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/sync/pull", payload, &result)
+	return result, err
+}
+
+// SyncPush calls POST /sync/push, push the invoices made offline
+func (c *Client) SyncPush(ctx context.Context, payload types.SyncPushPayload) ([]types.OfflineInvoiceResult, error) {
+	var result []types.OfflineInvoiceResult
+	err := c.doJSON(ctx, http.MethodPost, "/sync/push", payload, &result)
+	return result, err
+}
+
+// RegisterWebhook calls POST /webhook, the secret is only returned here
+func (c *Client) RegisterWebhook(ctx context.Context, payload types.RegisterWebhookPayload) (map[string]string, error) {
+	var result map[string]string
+	err := c.doJSON(ctx, http.MethodPost, "/webhook", payload, &result)
+	return result, err
+}
+
+// GetWebhooks calls GET /webhook/{val}, get all webhooks or by id
+func (c *Client) GetWebhooks(ctx context.Context, val string) ([]types.WebhookReturnPayload, error) {
+	var result []types.WebhookReturnPayload
+	err := c.doJSON(ctx, http.MethodGet, "/webhook/"+url.PathEscape(val), nil, &result)
+	return result, err
+}
+
+// DeleteWebhook calls DELETE /webhook
+func (c *Client) DeleteWebhook(ctx context.Context, payload types.DeleteWebhookPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodDelete, "/webhook", payload, &result)
+	return result, err
+}
+
+// ModifyWebhook calls PATCH /webhook
+func (c *Client) ModifyWebhook(ctx context.Context, payload types.ModifyWebhookPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/webhook", payload, &result)
+	return result, err
+}
+
+// GetWebhookDeliveries calls POST /webhook/delivery
+func (c *Client) GetWebhookDeliveries(ctx context.Context, payload types.ViewWebhookDeliveryPayload) ([]types.WebhookDeliveryReturnPayload, error) {
+	var result []types.WebhookDeliveryReturnPayload
+	err := c.doJSON(ctx, http.MethodPost, "/webhook/delivery", payload, &result)
+	return result, err
+}
+
+// GetWebhookDeliveryDetail calls POST /webhook/delivery/detail
+func (c *Client) GetWebhookDeliveryDetail(ctx context.Context, payload types.ViewWebhookDeliveryDetailPayload) (types.WebhookDeliveryDetailPayload, error) {
+	var result types.WebhookDeliveryDetailPayload
+	err := c.doJSON(ctx, http.MethodPost, "/webhook/delivery/detail", payload, &result)
+	return result, err
+}
+
+// RetryWebhookDelivery calls PATCH /webhook/delivery/retry
+func (c *Client) RetryWebhookDelivery(ctx context.Context, payload types.ViewWebhookDeliveryDetailPayload) (string, error) {
+	var result string
+	err := c.doJSON(ctx, http.MethodPatch, "/webhook/delivery/retry", payload, &result)
+	return result, err
+}
+
+// GetOpenAPIDocument calls GET /openapi.json, this document
+func (c *Client) GetOpenAPIDocument(ctx context.Context) (types.OpenAPIDocument, error) {
+	var result types.OpenAPIDocument
+	err := c.doJSON(ctx, http.MethodGet, "/openapi.json", nil, &result)
+	return result, err
+}
+
+// GetHealth calls GET /healthz, the process is up
+func (c *Client) GetHealth(ctx context.Context) (types.HealthStatus, error) {
+	var result types.HealthStatus
+	err := c.doJSON(ctx, http.MethodGet, "/healthz", nil, &result)
+	return result, err
+}
+
+// GetReadiness calls GET /readyz, the database and the storages are usable, 503 if not
+func (c *Client) GetReadiness(ctx context.Context) (types.HealthStatus, error) {
+	var result types.HealthStatus
+	err := c.doJSON(ctx, http.MethodGet, "/readyz", nil, &result)
+	return result, err
+}
+
+// GetMetrics calls GET /metrics, prometheus metrics, with METRICS_TOKEN as the bearer token if it is set
+func (c *Client) GetMetrics(ctx context.Context) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodGet, "/metrics", nil)
+}
@@ -1,21 +1,38 @@
 package api
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/config"
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/service/auth"
+	"github.com/nicolaics/pharmacon/service/branch"
+	"github.com/nicolaics/pharmacon/service/controlled"
 	"github.com/nicolaics/pharmacon/service/customer"
+	"github.com/nicolaics/pharmacon/service/eticket"
+	"github.com/nicolaics/pharmacon/service/event"
+	"github.com/nicolaics/pharmacon/service/health"
 	"github.com/nicolaics/pharmacon/service/invoice"
+	"github.com/nicolaics/pharmacon/service/login"
 	"github.com/nicolaics/pharmacon/service/medicine"
+	"github.com/nicolaics/pharmacon/service/openapi"
 	"github.com/nicolaics/pharmacon/service/payment"
 	"github.com/nicolaics/pharmacon/service/pi"
 	"github.com/nicolaics/pharmacon/service/poi"
 	"github.com/nicolaics/pharmacon/service/prescription"
+	"github.com/nicolaics/pharmacon/service/prescription/allergy"
 	"github.com/nicolaics/pharmacon/service/prescription/ct"
 	"github.com/nicolaics/pharmacon/service/prescription/det"
 	"github.com/nicolaics/pharmacon/service/prescription/doctor"
@@ -25,9 +42,18 @@ import (
 	"github.com/nicolaics/pharmacon/service/prescription/patient"
 	"github.com/nicolaics/pharmacon/service/prescription/su"
 	"github.com/nicolaics/pharmacon/service/production"
+	"github.com/nicolaics/pharmacon/service/production/recipe"
+	"github.com/nicolaics/pharmacon/service/screening"
+	"github.com/nicolaics/pharmacon/service/setting"
+	"github.com/nicolaics/pharmacon/service/storage/local"
+	"github.com/nicolaics/pharmacon/service/storage/s3"
 	"github.com/nicolaics/pharmacon/service/supplier"
+	"github.com/nicolaics/pharmacon/service/sync"
+	"github.com/nicolaics/pharmacon/service/tax"
+	"github.com/nicolaics/pharmacon/service/transfer"
 	"github.com/nicolaics/pharmacon/service/unit"
 	"github.com/nicolaics/pharmacon/service/user"
+	"github.com/nicolaics/pharmacon/types"
 )
 
 type APIServer struct {
@@ -45,12 +71,12 @@ func NewAPIServer(addr string, db *sql.DB, router *mux.Router) *APIServer {
 }
 
 func (s *APIServer) Run() error {
-	loggerVar := log.New(os.Stdout, "", log.LstdFlags)
-
-	subrouter := s.router.PathPrefix("/api/v1").Subrouter()
-	subrouterUnprotected := s.router.PathPrefix("/api/v1").Subrouter()
+	subrouter := s.router.PathPrefix(constants.API_PREFIX).Subrouter()
+	subrouterLogin := s.router.PathPrefix(constants.API_PREFIX).Subrouter()
+	subrouterUnprotected := s.router.PathPrefix(constants.API_PREFIX).Subrouter()
 
 	userStore := user.NewStore(s.db)
+	branchStore := branch.NewStore(s.db)
 	customerStore := customer.NewStore(s.db)
 	supplierStore := supplier.NewStore(s.db)
 	medicineStore := medicine.NewStore(s.db)
@@ -61,21 +87,50 @@ func (s *APIServer) Run() error {
 	doseStore := dose.NewStore(s.db)
 	mfStore := mf.NewStore(s.db)
 	prescSetUsageStore := su.NewStore(s.db)
+	allergyStore := allergy.NewStore(s.db)
+
+	interactionRuleStore := screening.NewStore(config.Envs.InteractionRulesPath)
+	err := interactionRuleStore.ReloadInteractionRules()
+	if err != nil {
+		slog.Error("error loading interaction rules", "error", err)
+	}
+
+	eticketTemplateStore := eticket.NewStore(config.Envs.EticketTemplatesPath)
+	err = eticketTemplateStore.ReloadEticketTemplates()
+	if err != nil {
+		slog.Error("error loading eticket templates", "error", err)
+	}
 
 	mainDoctorPrescMedItemStore := mdmi.NewStore(s.db)
 
+	documentStorage, err := newDocumentStorage()
+	if err != nil {
+		return fmt.Errorf("error initializing document storage: %v", err)
+	}
+
 	paymentMethodStore := payment.NewStore(s.db)
 	unitStore := unit.NewStore(s.db)
+	taxStore := tax.NewStore(s.db)
+	controlledSubstanceStore := controlled.NewStore(s.db)
 
 	purchaseInvoiceStore := pi.NewStore(s.db)
 	poInvoiceStore := poi.NewStore(s.db)
 	invoiceStore := invoice.NewStore(s.db)
 	prescriptionStore := prescription.NewStore(s.db)
 	productionStore := production.NewStore(s.db)
+	productionRecipeStore := recipe.NewStore(s.db)
+	settingStore := setting.NewStore(s.db)
+	stockTransferStore := transfer.NewStore(s.db)
+	syncStore := sync.NewStore(s.db)
+	eventStore := event.NewStore(s.db)
+	loginAttemptStore := login.NewStore(s.db)
 
-	userHandler := user.NewHandler(userStore)
+	userHandler := user.NewHandler(userStore, branchStore, loginAttemptStore)
 	userHandler.RegisterRoutes(subrouter)
-	userHandler.RegisterUnprotectedRoutes(subrouterUnprotected)
+	userHandler.RegisterUnprotectedRoutes(subrouterLogin)
+
+	loginHandler := login.NewHandler(loginAttemptStore, userStore)
+	loginHandler.RegisterRoutes(subrouter)
 
 	customerHandler := customer.NewHandler(customerStore, userStore)
 	customerHandler.RegisterRoutes(subrouter)
@@ -86,42 +141,185 @@ func (s *APIServer) Run() error {
 	medicineHandler := medicine.NewHandler(medicineStore, userStore, unitStore)
 	medicineHandler.RegisterRoutes(subrouter)
 
+	taxHandler := tax.NewHandler(taxStore, userStore)
+	taxHandler.RegisterRoutes(subrouter)
+
+	controlledSubstanceHandler := controlled.NewHandler(controlledSubstanceStore, userStore, branchStore, settingStore, documentStorage)
+	controlledSubstanceHandler.RegisterRoutes(subrouter)
+
 	doctorHandler := doctor.NewHandler(doctorStore, userStore)
 	doctorHandler.RegisterRoutes(subrouter)
 
 	patientHandler := patient.NewHandler(patientStore, userStore)
 	patientHandler.RegisterRoutes(subrouter)
 
-	purchaseInvoiceHandler := pi.NewHandler(purchaseInvoiceStore, userStore, supplierStore, medicineStore, unitStore, poInvoiceStore)
+	purchaseInvoiceHandler := pi.NewHandler(purchaseInvoiceStore, userStore, supplierStore, medicineStore, unitStore, poInvoiceStore, taxStore, controlledSubstanceStore,
+		branchStore, settingStore, documentStorage, eventStore)
 	purchaseInvoiceHandler.RegisterRoutes(subrouter)
 
 	poInvoiceHandler := poi.NewHandler(poInvoiceStore, userStore, supplierStore,
-		medicineStore, unitStore)
+		medicineStore, unitStore, branchStore, settingStore, documentStorage)
 	poInvoiceHandler.RegisterRoutes(subrouter)
 
 	invoiceHandler := invoice.NewHandler(invoiceStore, userStore, customerStore,
-		paymentMethodStore, medicineStore, unitStore)
+		paymentMethodStore, medicineStore, unitStore, taxStore, controlledSubstanceStore,
+		mainDoctorPrescMedItemStore, branchStore, settingStore, documentStorage, eventStore)
 	invoiceHandler.RegisterRoutes(subrouter)
 
 	prescriptionHandler := prescription.NewHandler(prescriptionStore, userStore, customerStore,
 		medicineStore, unitStore, invoiceStore,
 		doctorStore, patientStore, consumeTimeStore,
-		detStore, doseStore, mfStore, prescSetUsageStore)
+		detStore, doseStore, mfStore, prescSetUsageStore,
+		allergyStore, interactionRuleStore, controlledSubstanceStore,
+		mainDoctorPrescMedItemStore, eticketTemplateStore, branchStore, settingStore, documentStorage, eventStore)
 	prescriptionHandler.RegisterRoutes(subrouter)
 
-	productionHandler := production.NewHandler(productionStore, userStore, medicineStore, unitStore)
+	allergyHandler := allergy.NewHandler(allergyStore, patientStore, userStore)
+	allergyHandler.RegisterRoutes(subrouter)
+
+	screeningHandler := screening.NewHandler(interactionRuleStore, userStore)
+	screeningHandler.RegisterRoutes(subrouter)
+
+	eticketTemplateHandler := eticket.NewHandler(eticketTemplateStore, userStore)
+	eticketTemplateHandler.RegisterRoutes(subrouter)
+
+	productionHandler := production.NewHandler(productionStore, userStore, medicineStore, unitStore, controlledSubstanceStore,
+		productionRecipeStore, mainDoctorPrescMedItemStore)
 	productionHandler.RegisterRoutes(subrouter)
 
+	productionRecipeHandler := recipe.NewHandler(productionRecipeStore, productionStore, userStore, medicineStore, unitStore)
+	productionRecipeHandler.RegisterRoutes(subrouter)
+
 	mainDoctorPrescMedItemHandler := mdmi.NewHandler(mainDoctorPrescMedItemStore, userStore, medicineStore, unitStore)
 	mainDoctorPrescMedItemHandler.RegisterRoutes(subrouter)
 
-	log.Println("Listening on: ", s.addr)
+	settingHandler := setting.NewHandler(settingStore, userStore, documentStorage)
+	settingHandler.RegisterRoutes(subrouter)
+
+	branchHandler := branch.NewHandler(branchStore, userStore)
+	branchHandler.RegisterRoutes(subrouter)
+
+	stockTransferHandler := transfer.NewHandler(stockTransferStore, userStore, branchStore, medicineStore, unitStore,
+		controlledSubstanceStore, settingStore, documentStorage)
+	stockTransferHandler.RegisterRoutes(subrouter)
+
+	syncHandler := sync.NewHandler(syncStore, userStore, invoiceStore, customerStore, doctorStore,
+		paymentMethodStore, medicineStore, unitStore, taxStore, controlledSubstanceStore, mainDoctorPrescMedItemStore,
+		branchStore, settingStore, documentStorage, eventStore)
+	syncHandler.RegisterRoutes(subrouter)
+
+	eventHandler := event.NewHandler(eventStore, userStore)
+	eventHandler.RegisterRoutes(subrouter)
+
+	openAPIHandler := openapi.NewHandler(s.router)
+	openAPIHandler.RegisterRoutes(subrouterUnprotected)
+
+	healthHandler := health.NewHandler(s.db, documentStorage)
+	healthHandler.RegisterRoutes(subrouterUnprotected)
+
+	metricsHandler := metrics.NewHandler(s.db, config.Envs.MetricsToken)
+	metricsHandler.RegisterRoutes(subrouterUnprotected)
+
+	// cancelled by SIGTERM or ctrl+c
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	logMiddleware := logger.NewLogMiddleware(loggerVar)
+	// send the events in the outbox to the webhooks
+	eventWorker := event.NewWorker(eventStore, config.Envs.WebhookTimeout,
+		config.Envs.WebhookPollInterval, config.Envs.WebhookMaxAttempts)
+	eventWorkerDone := make(chan struct{})
+	go func() {
+		eventWorker.Run(ctx)
+		close(eventWorkerDone)
+	}()
+
+	logMiddleware := logger.NewLogMiddleware(slog.Default())
 	s.router.Use(logMiddleware.Func())
+	s.router.Use(metrics.Middleware())
 
 	s.router.Use(auth.CorsMiddleware())
+	s.router.Use(bodyLimitMiddleware(config.Envs.MaxRequestBodySize))
 	subrouter.Use(auth.AuthMiddleware())
 
-	return http.ListenAndServe(s.addr, s.router)
+	// the limits are per client ip address in each route group
+	subrouterLogin.Use(auth.RateLimitMiddleware(auth.NewRateLimiter("login", config.Envs.RateLimitLogin, config.Envs.TrustedProxyCount)))
+	subrouterUnprotected.Use(auth.RateLimitMiddleware(auth.NewRateLimiter("public", config.Envs.RateLimitPublic, config.Envs.TrustedProxyCount)))
+	subrouter.Use(auth.RateLimitMiddleware(auth.NewRateLimiter("api", config.Envs.RateLimitAPI, config.Envs.TrustedProxyCount)))
+
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.router,
+		ReadTimeout:       (time.Duration(config.Envs.ServerReadTimeout) * time.Second),
+		ReadHeaderTimeout: (time.Duration(config.Envs.ServerReadHeaderTimeout) * time.Second),
+		WriteTimeout:      (time.Duration(config.Envs.ServerWriteTimeout) * time.Second),
+		IdleTimeout:       (time.Duration(config.Envs.ServerIdleTimeout) * time.Second),
+	}
+
+	useTLS := (config.Envs.TLSCertFile != "" && config.Envs.TLSKeyFile != "")
+	if useTLS {
+		certificateReloader, err := newCertificateReloader(config.Envs.TLSCertFile, config.Envs.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certificateReloader.GetCertificate,
+		}
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "address", s.addr, "tls", useTLS)
+
+		if useTLS {
+			serverErr <- server.ListenAndServeTLS("", "")
+		} else {
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// stop taking new requests and wait for the ones being handled
+	slog.Info("shutting down the server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), (time.Duration(config.Envs.ShutdownTimeout) * time.Second))
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("error shutting down the server: %v", err)
+	}
+
+	select {
+	case <-eventWorkerDone:
+	case <-shutdownCtx.Done():
+		slog.Warn("event worker is not stopped before the shutdown timeout")
+	}
+
+	slog.Info("server stopped")
+
+	return nil
+}
+
+func newDocumentStorage() (types.DocumentStorage, error) {
+	switch config.Envs.DocumentStorage {
+	case constants.STORAGE_LOCAL:
+		return local.NewStore(config.Envs.DocumentStoragePath)
+	case constants.STORAGE_S3:
+		return s3.NewStore(s3.Config{
+			Endpoint:  config.Envs.S3Endpoint,
+			Region:    config.Envs.S3Region,
+			Bucket:    config.Envs.S3Bucket,
+			AccessKey: config.Envs.S3AccessKey,
+			SecretKey: config.Envs.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown document storage: %s", config.Envs.DocumentStorage)
+	}
 }
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// the certificate is loaded again when the files on the disk are changed,
+// so a renewed certificate is served without restarting the server
+type certificateReloader struct {
+	certFile    string
+	keyFile     string
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+}
+
+func newCertificateReloader(certFile string, keyFile string) (*certificateReloader, error) {
+	reloader := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	modTime, err := reloader.getModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	err = reloader.load(modTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+func (c *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modTime, err := c.getModTime()
+	if err != nil {
+		slog.Warn("error checking tls certificate, keep the loaded one", "error", err)
+		return c.certificate, nil
+	}
+
+	if modTime.After(c.modTime) {
+		// the old certificate is kept if the new one is not complete yet
+		err = c.load(modTime)
+		if err != nil {
+			slog.Warn("error reloading tls certificate, keep the loaded one", "error", err)
+		} else {
+			slog.Info("tls certificate reloaded")
+		}
+	}
+
+	return c.certificate, nil
+}
+
+func (c *certificateReloader) load(modTime time.Time) error {
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("error loading tls certificate: %v", err)
+	}
+
+	c.certificate = &certificate
+	c.modTime = modTime
+
+	return nil
+}
+
+// the latest change of the certificate and the key
+func (c *certificateReloader) getModTime() (time.Time, error) {
+	modTime := time.Time{}
+
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTime, err
+		}
+
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
+
+// the request body bigger than maxSize is rejected before the handler reads it
+func bodyLimitMiddleware(maxSize int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxSize {
+				http.Error(w, fmt.Sprintf("request body is larger than %d bytes", maxSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
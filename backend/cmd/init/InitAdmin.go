@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/nicolaics/pharmacon/config"
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/db"
 	"github.com/nicolaics/pharmacon/service/auth"
 	"github.com/nicolaics/pharmacon/utils"
@@ -47,7 +48,10 @@ func main() {
 		log.Fatal("initial admin already exist!")
 	}
 
-	password := utils.GenerateRandomCodeAlphanumeric(12)
+	password, err := utils.GenerateSecureCode(constants.INITIAL_ADMIN_PASSWORD_LENGTH)
+	if err != nil {
+		log.Fatalf("failed to generate password: %v", err)
+	}
 
 	// create new admin
 	hashedPassword, err := auth.HashPassword(password)
@@ -58,24 +62,39 @@ func main() {
 
 	args := os.Args
 
+	// the initial admin is the owner of all branches,
+	// the password is only shown once and must be changed on the first login
 	query := `INSERT INTO user (
-		name, password, admin, phone_number
-		) VALUES (?, ?, ?, ?)`
+		name, password, admin, phone_number, owner, must_change_password
+		) VALUES (?, ?, ?, ?, ?, ?)`
+
+	res, err := db.Exec(query, args[1], hashedPassword, true, "000", true, true)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	_, err = db.Exec(query, args[1], hashedPassword, true, "000")
+	id, err := res.LastInsertId()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fh, err := os.OpenFile("admin.txt", os.O_CREATE|os.O_WRONLY, 0644)
+	_, err = db.Exec("INSERT INTO password_history (user_id, password) VALUES (?, ?)", id, hashedPassword)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer fh.Close()
 
-	msg := fmt.Sprintf("Username: %s\nPassword: %s", args[1], password)
-	_, err = fh.WriteString(msg)
+	// the invoices can't be made without the tax
+	query = `INSERT INTO tax (
+		code, name, percentage, effective_date, description, last_modified_by_user_id
+		) SELECT ?, ?, ?, ?, ?, ? FROM DUAL
+		WHERE NOT EXISTS (SELECT 1 FROM tax WHERE code = ? AND deleted_at IS NULL)`
+
+	_, err = db.Exec(query, constants.DEFAULT_TAX_CODE, constants.DEFAULT_TAX_NAME, constants.DEFAULT_TAX_PERCENTAGE,
+		constants.DEFAULT_TAX_EFFECTIVE_DATE, constants.DEFAULT_TAX_DESCRIPTION, id, constants.DEFAULT_TAX_CODE)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	fmt.Printf("Username: %s\nPassword: %s\n", args[1], password)
+	fmt.Println("the password is not saved and must be changed on the first login")
 }
@@ -3,15 +3,23 @@ package main
 import (
 	"database/sql"
 	"log"
+	"log/slog"
+	"os"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 	"github.com/nicolaics/pharmacon/cmd/api"
 	"github.com/nicolaics/pharmacon/config"
 	"github.com/nicolaics/pharmacon/db"
+	"github.com/nicolaics/pharmacon/logger"
 )
 
 func main() {
+	err := logger.InitLogger(os.Stdout, config.Envs.LogLevel, config.Envs.LogFormat, config.Envs.LogRedactKeys)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	db, err := db.NewMySQLStorage(mysql.Config{
 		User:                 config.Envs.DBUser,
 		Passwd:               config.Envs.DBPassword,
@@ -34,6 +42,8 @@ func main() {
 	if err := server.Run(); err != nil {
 		log.Fatal(err)
 	}
+
+	db.Close()
 }
 
 func initStorage(db *sql.DB) {
@@ -42,7 +52,7 @@ func initStorage(db *sql.DB) {
 		log.Fatal(err)
 	}
 
-	log.Println("DB: Successfully connected!")
+	slog.Info("database connected", "address", config.Envs.DBAddress, "name", config.Envs.DBName)
 }
 
 // TODO: add static routes if the frontend is in the server already
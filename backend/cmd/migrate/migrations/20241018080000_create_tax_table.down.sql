@@ -0,0 +1,3 @@
+ALTER TABLE medicine DROP COLUMN is_taxable;
+
+DROP TABLE IF EXISTS tax;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS tax (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    percentage DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    effective_date DATETIME NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NOT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (deleted_by_user_id) REFERENCES user(id)
+);
+
+ALTER TABLE medicine ADD COLUMN is_taxable BOOLEAN NOT NULL DEFAULT TRUE;
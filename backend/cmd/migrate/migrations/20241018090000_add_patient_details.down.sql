@@ -0,0 +1,12 @@
+DROP INDEX idx_patient_medical_record_number ON patient;
+DROP INDEX idx_patient_national_id ON patient;
+
+ALTER TABLE patient
+    DROP COLUMN allergy_notes,
+    DROP COLUMN weight,
+    DROP COLUMN medical_record_number,
+    DROP COLUMN national_id,
+    DROP COLUMN address,
+    DROP COLUMN phone_number,
+    DROP COLUMN gender,
+    DROP COLUMN birth_date;
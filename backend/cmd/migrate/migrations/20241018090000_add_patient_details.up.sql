@@ -0,0 +1,12 @@
+ALTER TABLE patient
+    ADD COLUMN birth_date DATE NULL DEFAULT NULL,
+    ADD COLUMN gender VARCHAR(10) NOT NULL DEFAULT '',
+    ADD COLUMN phone_number VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN address VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN national_id VARCHAR(30) NOT NULL DEFAULT '',
+    ADD COLUMN medical_record_number VARCHAR(30) NOT NULL DEFAULT '',
+    ADD COLUMN weight DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN allergy_notes VARCHAR(500) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_patient_national_id ON patient (national_id);
+CREATE INDEX idx_patient_medical_record_number ON patient (medical_record_number);
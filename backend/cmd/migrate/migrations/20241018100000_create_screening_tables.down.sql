@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS prescription_screening;
+DROP TABLE IF EXISTS patient_allergy;
+
+ALTER TABLE medicine DROP COLUMN active_ingredients;
@@ -0,0 +1,30 @@
+ALTER TABLE medicine ADD COLUMN active_ingredients VARCHAR(500) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS patient_allergy (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    patient_id INT UNSIGNED NOT NULL,
+    allergen VARCHAR(255) NOT NULL,
+    reaction VARCHAR(255) NOT NULL DEFAULT '',
+    severity VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (patient_id) REFERENCES patient(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS prescription_screening (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    prescription_id INT UNSIGNED NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    blocking BOOLEAN NOT NULL DEFAULT FALSE,
+    medicines VARCHAR(500) NOT NULL DEFAULT '',
+    description TEXT NOT NULL,
+    override_reason VARCHAR(500) NOT NULL DEFAULT '',
+    acknowledged_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (prescription_id) REFERENCES prescription(id) ON DELETE CASCADE,
+    FOREIGN KEY (acknowledged_by_user_id) REFERENCES user(id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS controlled_substance_register;
+
+ALTER TABLE medicine DROP COLUMN controlled_class;
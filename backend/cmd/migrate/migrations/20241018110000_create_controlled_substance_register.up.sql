@@ -0,0 +1,25 @@
+ALTER TABLE medicine ADD COLUMN controlled_class VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS controlled_substance_register (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    medicine_id INT UNSIGNED NOT NULL,
+    transaction_type VARCHAR(20) NOT NULL,
+    reference_id INT UNSIGNED NOT NULL,
+    reference_number INT NOT NULL,
+    transaction_date DATETIME NOT NULL,
+    qty_in DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    qty_out DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    party_name VARCHAR(255) NOT NULL DEFAULT '',
+    patient_name VARCHAR(255) NOT NULL DEFAULT '',
+    patient_address VARCHAR(255) NOT NULL DEFAULT '',
+    doctor_name VARCHAR(255) NOT NULL DEFAULT '',
+    prescription_number INT NOT NULL DEFAULT 0,
+    user_id INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    INDEX (transaction_type, reference_id),
+    INDEX (transaction_date),
+    FOREIGN KEY (medicine_id) REFERENCES medicine(id),
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
@@ -0,0 +1,8 @@
+DROP INDEX idx_doctor_license_number ON doctor;
+
+ALTER TABLE doctor
+    DROP COLUMN license_number,
+    DROP COLUMN specialty,
+    DROP COLUMN clinic,
+    DROP COLUMN address,
+    DROP COLUMN phone_number;
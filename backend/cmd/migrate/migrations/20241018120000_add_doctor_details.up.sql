@@ -0,0 +1,8 @@
+ALTER TABLE doctor
+    ADD COLUMN license_number VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN specialty VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN clinic VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN address VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN phone_number VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_doctor_license_number ON doctor (license_number);
@@ -0,0 +1,9 @@
+ALTER TABLE prescription_medicine_item
+    DROP COLUMN prescribed_qty,
+    DROP COLUMN iteration;
+
+ALTER TABLE prescription DROP FOREIGN KEY fk_prescription_original_prescription_id;
+
+ALTER TABLE prescription
+    DROP COLUMN original_prescription_id,
+    DROP COLUMN iteration_number;
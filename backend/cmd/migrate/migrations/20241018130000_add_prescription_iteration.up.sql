@@ -0,0 +1,11 @@
+ALTER TABLE prescription
+    ADD COLUMN original_prescription_id INT UNSIGNED NULL DEFAULT NULL,
+    ADD COLUMN iteration_number INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT fk_prescription_original_prescription_id
+        FOREIGN KEY (original_prescription_id) REFERENCES prescription(id);
+
+ALTER TABLE prescription_medicine_item
+    ADD COLUMN prescribed_qty DOUBLE NOT NULL DEFAULT 0,
+    ADD COLUMN iteration INT NOT NULL DEFAULT 0;
+
+UPDATE prescription_medicine_item SET prescribed_qty = qty;
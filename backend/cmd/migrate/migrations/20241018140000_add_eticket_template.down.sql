@@ -0,0 +1,3 @@
+ALTER TABLE eticket
+    DROP COLUMN template,
+    DROP COLUMN shake_well;
@@ -0,0 +1,5 @@
+ALTER TABLE eticket
+    ADD COLUMN template VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN shake_well BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE eticket SET template = size;
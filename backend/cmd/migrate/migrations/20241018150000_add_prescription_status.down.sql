@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS prescription_status_history;
+
+ALTER TABLE prescription DROP COLUMN status;
+
+ALTER TABLE user DROP COLUMN pharmacist;
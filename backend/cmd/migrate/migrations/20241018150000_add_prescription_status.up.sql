@@ -0,0 +1,23 @@
+ALTER TABLE user
+    ADD COLUMN pharmacist BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE prescription
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'RECEIVED';
+
+-- the prescriptions made before the workflow are already handed over
+UPDATE prescription SET status = 'HANDED_OVER' WHERE deleted_at IS NULL;
+UPDATE prescription SET status = 'CANCELLED' WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS prescription_status_history (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    prescription_id INT UNSIGNED NOT NULL,
+    from_status VARCHAR(20) NOT NULL DEFAULT '',
+    to_status VARCHAR(20) NOT NULL,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    user_id INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (prescription_id) REFERENCES prescription(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE production
+    DROP FOREIGN KEY fk_production_production_recipe_id,
+    DROP COLUMN production_recipe_id,
+    DROP COLUMN planned_qty,
+    DROP COLUMN loss_qty;
+
+DROP TABLE IF EXISTS production_recipe_item;
+
+DROP TABLE IF EXISTS production_recipe;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS production_recipe (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    produced_medicine_id INT UNSIGNED NOT NULL,
+    yield_qty DOUBLE NOT NULL,
+    yield_unit_id INT UNSIGNED NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    user_id INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NOT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    INDEX (produced_medicine_id),
+    FOREIGN KEY (produced_medicine_id) REFERENCES medicine(id),
+    FOREIGN KEY (yield_unit_id) REFERENCES unit(id),
+    FOREIGN KEY (user_id) REFERENCES user(id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (deleted_by_user_id) REFERENCES user(id)
+);
+
+CREATE TABLE IF NOT EXISTS production_recipe_item (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    production_recipe_id INT UNSIGNED NOT NULL,
+    medicine_id INT UNSIGNED NOT NULL,
+    qty DOUBLE NOT NULL,
+    unit_id INT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (production_recipe_id) REFERENCES production_recipe(id) ON DELETE CASCADE,
+    FOREIGN KEY (medicine_id) REFERENCES medicine(id),
+    FOREIGN KEY (unit_id) REFERENCES unit(id)
+);
+
+ALTER TABLE production
+    ADD COLUMN production_recipe_id INT UNSIGNED NULL DEFAULT NULL,
+    ADD COLUMN planned_qty DOUBLE NOT NULL DEFAULT 0,
+    ADD COLUMN loss_qty DOUBLE NOT NULL DEFAULT 0,
+    ADD CONSTRAINT fk_production_production_recipe_id
+        FOREIGN KEY (production_recipe_id) REFERENCES production_recipe(id);
+
+-- the productions made before the recipe have no recorded loss
+UPDATE production SET planned_qty = produced_qty;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS document_setting;
+DROP TABLE IF EXISTS company_setting;
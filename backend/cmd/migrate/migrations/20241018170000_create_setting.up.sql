@@ -0,0 +1,29 @@
+-- only one row, the values from the environment are used until it is saved
+CREATE TABLE IF NOT EXISTS company_setting (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    address TEXT NOT NULL,
+    phone_number VARCHAR(50) NOT NULL DEFAULT '',
+    whatsapp_number VARCHAR(50) NOT NULL DEFAULT '',
+    business_registration_number VARCHAR(255) NOT NULL DEFAULT '',
+    pharmacist VARCHAR(255) NOT NULL DEFAULT '',
+    pharmacist_license_number VARCHAR(255) NOT NULL DEFAULT '',
+    logo_url VARCHAR(255) NOT NULL DEFAULT '',
+    slogan VARCHAR(255) NOT NULL DEFAULT '',
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id)
+);
+
+CREATE TABLE IF NOT EXISTS document_setting (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    document_type VARCHAR(50) NOT NULL UNIQUE,
+    footer VARCHAR(255) NOT NULL DEFAULT '',
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NOT NULL,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id)
+);
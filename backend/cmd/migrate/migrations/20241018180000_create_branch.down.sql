@@ -0,0 +1,34 @@
+ALTER TABLE controlled_substance_register
+    DROP FOREIGN KEY fk_controlled_substance_register_branch_id,
+    DROP COLUMN branch_id;
+
+ALTER TABLE production
+    DROP FOREIGN KEY fk_production_branch_id,
+    DROP COLUMN branch_id;
+
+ALTER TABLE prescription
+    DROP FOREIGN KEY fk_prescription_branch_id,
+    DROP COLUMN branch_id;
+
+ALTER TABLE purchase_order
+    DROP FOREIGN KEY fk_purchase_order_branch_id,
+    DROP COLUMN branch_id;
+
+ALTER TABLE purchase_invoice
+    DROP FOREIGN KEY fk_purchase_invoice_branch_id,
+    DROP COLUMN branch_id;
+
+ALTER TABLE invoice
+    DROP FOREIGN KEY fk_invoice_branch_id,
+    DROP INDEX branch_id,
+    DROP COLUMN branch_id;
+
+-- the total of all branches is already in medicine.qty
+DROP TABLE IF EXISTS medicine_stock;
+
+ALTER TABLE user
+    DROP FOREIGN KEY fk_user_branch_id,
+    DROP COLUMN owner,
+    DROP COLUMN branch_id;
+
+DROP TABLE IF EXISTS branch;
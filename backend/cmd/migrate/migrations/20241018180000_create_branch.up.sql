@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS branch (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255) NOT NULL DEFAULT '',
+    phone_number VARCHAR(20) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    UNIQUE (code),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (deleted_by_user_id) REFERENCES user(id)
+);
+
+-- everything made before the branches belongs to the first outlet
+INSERT INTO branch (id, code, name) VALUES (1, 'MAIN', 'Main');
+
+ALTER TABLE user
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD COLUMN owner BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT fk_user_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
+
+-- the admins could see everything before, they keep it
+UPDATE user SET owner = TRUE WHERE admin = TRUE;
+
+-- medicine.qty stays as the total of all branches
+CREATE TABLE IF NOT EXISTS medicine_stock (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    medicine_id INT UNSIGNED NOT NULL,
+    branch_id INT UNSIGNED NOT NULL,
+    qty DOUBLE NOT NULL DEFAULT 0,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    UNIQUE (medicine_id, branch_id),
+    FOREIGN KEY (medicine_id) REFERENCES medicine(id) ON DELETE CASCADE,
+    FOREIGN KEY (branch_id) REFERENCES branch(id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id)
+);
+
+INSERT INTO medicine_stock (medicine_id, branch_id, qty)
+    SELECT id, 1, qty FROM medicine;
+
+ALTER TABLE invoice
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD INDEX (branch_id, invoice_date),
+    ADD CONSTRAINT fk_invoice_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
+
+ALTER TABLE purchase_invoice
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_purchase_invoice_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
+
+ALTER TABLE purchase_order
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_purchase_order_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
+
+ALTER TABLE prescription
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_prescription_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
+
+ALTER TABLE production
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_production_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
+
+ALTER TABLE controlled_substance_register
+    ADD COLUMN branch_id INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD CONSTRAINT fk_controlled_substance_register_branch_id FOREIGN KEY (branch_id) REFERENCES branch(id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stock_transfer_item;
+
+DROP TABLE IF EXISTS stock_transfer;
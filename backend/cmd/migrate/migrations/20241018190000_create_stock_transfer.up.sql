@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS stock_transfer (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    number INT UNSIGNED NOT NULL,
+    from_branch_id INT UNSIGNED NOT NULL,
+    to_branch_id INT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    request_date TIMESTAMP NOT NULL,
+    dispatch_date TIMESTAMP NULL DEFAULT NULL,
+    receipt_date TIMESTAMP NULL DEFAULT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    user_id INT UNSIGNED NOT NULL,
+    dispatched_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+    received_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+    pdf_url VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NOT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    INDEX (to_branch_id, request_date),
+    INDEX (from_branch_id, request_date),
+    FOREIGN KEY (from_branch_id) REFERENCES branch(id),
+    FOREIGN KEY (to_branch_id) REFERENCES branch(id),
+    FOREIGN KEY (user_id) REFERENCES user(id),
+    FOREIGN KEY (dispatched_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (received_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (deleted_by_user_id) REFERENCES user(id)
+);
+
+-- the dispatched and received qty are in the same unit as the requested qty
+CREATE TABLE IF NOT EXISTS stock_transfer_item (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    stock_transfer_id INT UNSIGNED NOT NULL,
+    medicine_id INT UNSIGNED NOT NULL,
+    request_qty DOUBLE NOT NULL,
+    dispatch_qty DOUBLE NOT NULL DEFAULT 0,
+    received_qty DOUBLE NOT NULL DEFAULT 0,
+    unit_id INT UNSIGNED NOT NULL,
+    batch_number VARCHAR(255) NOT NULL DEFAULT '',
+    remarks VARCHAR(255) NOT NULL DEFAULT '',
+    discrepancy_note VARCHAR(255) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (stock_transfer_id) REFERENCES stock_transfer(id) ON DELETE CASCADE,
+    FOREIGN KEY (medicine_id) REFERENCES medicine(id),
+    FOREIGN KEY (unit_id) REFERENCES unit(id)
+);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS offline_invoice;
+
+DROP INDEX idx_medicine_stock_last_modified ON medicine_stock;
+DROP INDEX idx_medicine_last_modified ON medicine;
+
+ALTER TABLE doctor
+    DROP FOREIGN KEY fk_doctor_deleted_by_user_id,
+    DROP COLUMN deleted_by_user_id,
+    DROP COLUMN deleted_at,
+    DROP COLUMN last_modified;
+
+ALTER TABLE customer
+    DROP COLUMN last_modified;
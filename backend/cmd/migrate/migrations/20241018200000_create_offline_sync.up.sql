@@ -0,0 +1,34 @@
+-- the terminals pull the master data changed since their last sync,
+-- the unit is never modified so its created_at is used
+ALTER TABLE customer
+    ADD COLUMN last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+-- the deleted doctor is kept so the terminals know to drop it
+ALTER TABLE doctor
+    ADD COLUMN last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+    ADD CONSTRAINT fk_doctor_deleted_by_user_id FOREIGN KEY (deleted_by_user_id) REFERENCES user(id);
+
+CREATE INDEX idx_medicine_last_modified ON medicine (last_modified);
+CREATE INDEX idx_medicine_stock_last_modified ON medicine_stock (branch_id, last_modified);
+
+-- the invoice made offline is only applied once, the client uuid is the key
+CREATE TABLE IF NOT EXISTS offline_invoice (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    client_uuid CHAR(36) NOT NULL,
+    terminal_id VARCHAR(50) NOT NULL,
+    client_number INT UNSIGNED NOT NULL,
+    invoice_id INT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    conflict_note TEXT NOT NULL,
+    branch_id INT UNSIGNED NOT NULL,
+    user_id INT UNSIGNED NOT NULL,
+    synced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    UNIQUE (client_uuid),
+    FOREIGN KEY (invoice_id) REFERENCES invoice(id),
+    FOREIGN KEY (branch_id) REFERENCES branch(id),
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
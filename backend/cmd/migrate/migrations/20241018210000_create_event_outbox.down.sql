@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS webhook_delivery_log;
+
+DROP TABLE IF EXISTS webhook_delivery;
+
+DROP TABLE IF EXISTS event_outbox;
+
+DROP TABLE IF EXISTS webhook;
@@ -0,0 +1,71 @@
+CREATE TABLE IF NOT EXISTS webhook (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    -- comma separated, empty receives every event
+    event_types VARCHAR(500) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified_by_user_id INT UNSIGNED NOT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (last_modified_by_user_id) REFERENCES user(id),
+    FOREIGN KEY (deleted_by_user_id) REFERENCES user(id)
+);
+
+-- the event is saved together with the write, the worker sends it later
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    uuid CHAR(36) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INT UNSIGNED NOT NULL,
+    branch_id INT UNSIGNED NOT NULL,
+    user_id INT UNSIGNED NOT NULL,
+    payload JSON NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    UNIQUE (uuid),
+    INDEX (entity, entity_id),
+    FOREIGN KEY (branch_id) REFERENCES branch(id),
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+-- one delivery for every webhook that receives the event
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    event_id INT UNSIGNED NOT NULL,
+    webhook_id INT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    attempt INT UNSIGNED NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at TIMESTAMP NULL DEFAULT NULL,
+    delivered_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    UNIQUE (event_id, webhook_id),
+    INDEX (status, next_attempt_at),
+    FOREIGN KEY (event_id) REFERENCES event_outbox(id) ON DELETE CASCADE,
+    FOREIGN KEY (webhook_id) REFERENCES webhook(id)
+);
+
+-- every attempt of the delivery
+CREATE TABLE IF NOT EXISTS webhook_delivery_log (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    webhook_delivery_id INT UNSIGNED NOT NULL,
+    attempt INT UNSIGNED NOT NULL,
+    response_status INT NOT NULL DEFAULT 0,
+    response_body VARCHAR(1000) NOT NULL DEFAULT '',
+    error VARCHAR(1000) NOT NULL DEFAULT '',
+    duration_ms INT UNSIGNED NOT NULL DEFAULT 0,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    FOREIGN KEY (webhook_delivery_id) REFERENCES webhook_delivery(id) ON DELETE CASCADE
+);
@@ -0,0 +1,36 @@
+-- every failed login is kept for the audit
+CREATE TABLE IF NOT EXISTS login_attempt (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_name VARCHAR(255) NOT NULL,
+    -- null if there is no user with the name
+    user_id INT UNSIGNED NULL DEFAULT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    reason VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    INDEX (created_at),
+    INDEX (user_name),
+    INDEX (ip_address),
+    FOREIGN KEY (user_id) REFERENCES user(id)
+);
+
+-- the failed logins in a row of a user name or an ip address,
+-- the lockout gets longer every time the key is locked again
+CREATE TABLE IF NOT EXISTS login_lockout (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    lock_type VARCHAR(20) NOT NULL,
+    lock_key VARCHAR(255) NOT NULL,
+    failed_count INT UNSIGNED NOT NULL DEFAULT 0,
+    lockout_count INT UNSIGNED NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP NULL DEFAULT NULL,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    unlocked_at TIMESTAMP NULL DEFAULT NULL,
+    unlocked_by_user_id INT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (id),
+    UNIQUE (lock_type, lock_key),
+    INDEX (locked_until),
+    FOREIGN KEY (unlocked_by_user_id) REFERENCES user(id)
+);
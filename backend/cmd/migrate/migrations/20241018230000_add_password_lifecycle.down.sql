@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS password_reset_code;
+
+DROP TABLE IF EXISTS password_history;
+
+ALTER TABLE user
+    DROP COLUMN totp_enabled,
+    DROP COLUMN totp_secret,
+    DROP COLUMN must_change_password,
+    DROP COLUMN password_changed_at;
@@ -0,0 +1,3 @@
+DELETE FROM offline_invoice WHERE invoice_id IS NULL;
+
+ALTER TABLE offline_invoice MODIFY invoice_id INT UNSIGNED NOT NULL;
@@ -0,0 +1,2 @@
+-- the row is saved as PENDING before the invoice is made, so the uuid is claimed first
+ALTER TABLE offline_invoice MODIFY invoice_id INT UNSIGNED NULL;
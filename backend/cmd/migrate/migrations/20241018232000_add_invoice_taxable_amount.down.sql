@@ -0,0 +1,3 @@
+ALTER TABLE purchase_invoice DROP COLUMN taxable_amount;
+
+ALTER TABLE invoice DROP COLUMN taxable_amount;
@@ -0,0 +1,19 @@
+-- the base of the tax, only the taxable medicines after the invoice discount
+ALTER TABLE invoice ADD COLUMN taxable_amount DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE purchase_invoice ADD COLUMN taxable_amount DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+UPDATE invoice SET taxable_amount = (
+        SELECT COALESCE(SUM(mi.subtotal), 0)
+            FROM medicine_item AS mi
+            JOIN medicine ON medicine.id = mi.medicine_id
+            WHERE mi.invoice_id = invoice.id AND medicine.is_taxable = TRUE
+    ) * (1 - invoice.discount_amount / invoice.subtotal)
+    WHERE invoice.subtotal > 0 AND invoice.tax_percentage > 0;
+
+UPDATE purchase_invoice SET taxable_amount = (
+        SELECT COALESCE(SUM(pmi.subtotal), 0)
+            FROM purchase_medicine_item AS pmi
+            WHERE pmi.purchase_invoice_id = purchase_invoice.id AND pmi.tax_percentage > 0
+    ) * (1 - purchase_invoice.discount_amount / purchase_invoice.subtotal)
+    WHERE purchase_invoice.subtotal > 0 AND purchase_invoice.tax_percentage > 0;
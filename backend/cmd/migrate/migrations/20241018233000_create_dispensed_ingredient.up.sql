@@ -0,0 +1,18 @@
+-- the ingredients taken from the stock for the compounded medicines of an invoice or a prescription,
+-- so the same qty is given back even if the recipe was changed after
+CREATE TABLE IF NOT EXISTS dispensed_ingredient (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    reference_type VARCHAR(20) NOT NULL,
+    reference_id INT UNSIGNED NOT NULL,
+    compound_medicine_id INT UNSIGNED NOT NULL,
+    medicine_id INT UNSIGNED NOT NULL,
+    qty DOUBLE NOT NULL,
+    unit_id INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (id),
+    INDEX (reference_type, reference_id),
+    FOREIGN KEY (compound_medicine_id) REFERENCES medicine(id),
+    FOREIGN KEY (medicine_id) REFERENCES medicine(id),
+    FOREIGN KEY (unit_id) REFERENCES unit(id)
+);
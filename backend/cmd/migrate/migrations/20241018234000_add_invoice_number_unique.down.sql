@@ -0,0 +1 @@
+DROP INDEX idx_invoice_number ON invoice;
@@ -0,0 +1,2 @@
+-- the number restarts every day in each branch, it can only be given once
+CREATE UNIQUE INDEX idx_invoice_number ON invoice (branch_id, number, (DATE(invoice_date)));
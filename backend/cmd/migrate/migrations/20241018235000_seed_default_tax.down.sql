@@ -0,0 +1 @@
+DELETE FROM tax WHERE code = 'PPN' AND description = 'default';
@@ -0,0 +1,7 @@
+-- the invoices can't be made without the PPN, it is seeded by the first admin
+-- if it was not made yet. the new database gets it with the initial admin
+INSERT INTO tax (code, name, percentage, effective_date, description, last_modified_by_user_id)
+    SELECT 'PPN', 'PPN', 11, '2022-04-01', 'default', MIN(id) FROM user
+    WHERE admin = TRUE
+    HAVING MIN(id) IS NOT NULL
+    AND NOT EXISTS (SELECT 1 FROM tax WHERE code = 'PPN' AND deleted_at IS NULL);
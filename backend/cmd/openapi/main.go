@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/nicolaics/pharmacon/service/openapi"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// writes the go client and the openapi document from the operations table
+func main() {
+	clientPath := flag.String("client", "client/operations.go", "output of the generated go client")
+	specPath := flag.String("spec", "", "output of the openapi document, empty doesn't write it")
+	flag.Parse()
+
+	source, err := generateClient(openapi.Operations)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(*clientPath, source, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("client written to: ", *clientPath)
+
+	if *specPath == "" {
+		return
+	}
+
+	document, err := json.MarshalIndent(openapi.NewDocument(openapi.Operations), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(*specPath, document, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("openapi document written to: ", *specPath)
+}
+
+func generateClient(operations []types.APIOperation) ([]byte, error) {
+	var methods bytes.Buffer
+
+	for _, operation := range operations {
+		writeMethod(&methods, operation)
+	}
+
+	// only the packages used by the methods are imported
+	imports := []string{"context", "net/http"}
+	for _, pkg := range []string{"io", "net/url"} {
+		if strings.Contains(methods.String(), pkg[strings.LastIndex(pkg, "/")+1:]+".") {
+			imports = append(imports, pkg)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("// Code generated by cmd/openapi. DO NOT EDIT.\n\n")
+	buf.WriteString("package client\n\nimport (\n")
+	for _, pkg := range imports {
+		fmt.Fprintf(&buf, "%q\n", pkg)
+	}
+	buf.WriteString("\n\"github.com/nicolaics/pharmacon/types\"\n)\n")
+	buf.Write(methods.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+func writeMethod(buf *bytes.Buffer, operation types.APIOperation) {
+	name := exportName(operation.OperationID)
+	params := []string{"ctx context.Context"}
+
+	// the path params are put in the path with url escaping
+	path := fmt.Sprintf("%q", operation.Path)
+	for _, part := range strings.Split(operation.Path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			param := strings.Trim(part, "{}")
+			params = append(params, param+" string")
+			path = strings.Replace(path, part, `" + url.PathEscape(`+param+`) + "`, 1)
+		}
+	}
+	path = strings.TrimSuffix(path, ` + ""`)
+
+	payload := "nil"
+	if operation.Request != nil {
+		params = append(params, "payload "+typeName(reflect.TypeOf(operation.Request)))
+		payload = "payload"
+	}
+
+	if operation.FormFile != "" {
+		params = append(params, "fileName string", "file io.Reader")
+	}
+
+	fmt.Fprintf(buf, "\n// %s calls %s %s", name, operation.Method, operation.Path)
+	if operation.Summary != "" {
+		fmt.Fprintf(buf, ", %s", operation.Summary)
+	}
+	buf.WriteString("\n")
+
+	method := "http.Method" + methodName(operation.Method)
+
+	switch {
+	case operation.ContentType != "":
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) ([]byte, error) {\n", name, strings.Join(params, ", "))
+		fmt.Fprintf(buf, "return c.doRaw(ctx, %s, %s, %s)\n}\n", method, path, payload)
+	case operation.Response == nil:
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) error {\n", name, strings.Join(params, ", "))
+		fmt.Fprintf(buf, "return c.doJSON(ctx, %s, %s, %s, nil)\n}\n", method, path, payload)
+	default:
+		result := typeName(reflect.TypeOf(operation.Response))
+
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(params, ", "), result)
+		fmt.Fprintf(buf, "var result %s\n", result)
+
+		if operation.FormFile != "" {
+			fmt.Fprintf(buf, "err := c.doFormFile(ctx, %s, %q, fileName, file, &result)\n", path, operation.FormFile)
+		} else {
+			fmt.Fprintf(buf, "err := c.doJSON(ctx, %s, %s, %s, &result)\n", method, path, payload)
+		}
+
+		buf.WriteString("return result, err\n}\n")
+	}
+}
+
+func typeName(t reflect.Type) string {
+	return strings.ReplaceAll(t.String(), "interface {}", "interface{}")
+}
+
+func exportName(operationId string) string {
+	runes := []rune(operationId)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+func methodName(method string) string {
+	return string(method[0]) + strings.ToLower(method[1:])
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+// local stand-in for a webhook, prints every event it receives.
+// -fail answers 500 to the first requests to see the retries.
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	secret := flag.String("secret", "", "webhook secret to verify the signature, empty skips the check")
+	fail := flag.Int("fail", 0, "number of the first requests answered with 500")
+	flag.Parse()
+
+	var mutex sync.Mutex
+	received := 0
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		timestamp := r.Header.Get(constants.WEBHOOK_HEADER_TIMESTAMP)
+		signature := r.Header.Get(constants.WEBHOOK_HEADER_SIGNATURE)
+
+		if *secret != "" && !utils.VerifyWebhookSignature(*secret, timestamp, body, signature) {
+			log.Printf("invalid signature for delivery %s", r.Header.Get(constants.WEBHOOK_HEADER_DELIVERY_ID))
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		mutex.Lock()
+		received++
+		count := received
+		mutex.Unlock()
+
+		log.Printf("#%d %s event %s delivery %s", count,
+			r.Header.Get(constants.WEBHOOK_HEADER_EVENT),
+			r.Header.Get(constants.WEBHOOK_HEADER_EVENT_ID),
+			r.Header.Get(constants.WEBHOOK_HEADER_DELIVERY_ID))
+		fmt.Println(string(body))
+
+		if count <= *fail {
+			http.Error(w, "failing on purpose", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok")
+	})
+
+	log.Println("Webhook receiver listening on: ", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
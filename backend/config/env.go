@@ -20,14 +20,56 @@ type Config struct {
 	CompanyName                string
 	Pharmacist                 string
 	PharmacistLicenseNumber    string
-	MainDoctor                 string
-	MainDoctorLicenseNumber    string
 	BusinessRegistrationNumber string
 	CompanyAddress             string
 	CompanyPhoneNumber         string
 	CompanyWhatsAppNumber      string
 	CompanyLogoURL             string
 	CompanySlogan              string
+	InteractionRulesPath       string
+	EticketTemplatesPath       string
+	DocumentStorage            string
+	DocumentStoragePath        string
+	S3Endpoint                 string
+	S3Region                   string
+	S3Bucket                   string
+	S3AccessKey                string
+	S3SecretKey                string
+	ReceiptPrinterAddress      string
+	ReceiptPaperWidth          int64
+	WebhookTimeout             int64
+	WebhookPollInterval        int64
+	WebhookMaxAttempts         int64
+	ServerReadTimeout          int64
+	ServerReadHeaderTimeout    int64
+	ServerWriteTimeout         int64
+	ServerIdleTimeout          int64
+	ShutdownTimeout            int64
+	MaxRequestBodySize         int64
+	TLSCertFile                string
+	TLSKeyFile                 string
+	MetricsToken               string
+	LogLevel                   string
+	LogFormat                  string
+	LogRedactKeys              string
+	LoginMaxAttempts           int64
+	LoginMaxAttemptsPerIP      int64
+	LoginAttemptWindow         int64
+	LoginLockoutDuration       int64
+	LoginMaxLockoutDuration    int64
+	RateLimitLogin             int64
+	RateLimitPublic            int64
+	RateLimitAPI               int64
+	TrustedProxyCount          int64
+	PasswordMinLength          int64
+	PasswordRequireUpper       bool
+	PasswordRequireLower       bool
+	PasswordRequireDigit       bool
+	PasswordRequireSymbol      bool
+	PasswordHistory            int64
+	PasswordExpiryDays         int64
+	PasswordResetCodeExp       int64
+	TOTPIssuer                 string
 }
 
 var Envs = initConfig()
@@ -48,14 +90,56 @@ func initConfig() Config {
 		CompanyName:                getEnv("COMPANY_NAME", "Apotek"),
 		Pharmacist:                 getEnv("PHARMACIST", ""),
 		PharmacistLicenseNumber:    getEnv("PHARMACIST_LICENSE_NUMBER", ""),
-		MainDoctor:                 getEnv("MAIN_DOCTOR", ""),
-		MainDoctorLicenseNumber:    getEnv("MAIN_DOCTOR_LICENSE_NUMBER", ""),
 		BusinessRegistrationNumber: getEnv("BUSINESS_REGISTRATION_NUMBER", ""),
 		CompanyAddress:             getEnv("COMPANY_ADDRESS", ""),
 		CompanyPhoneNumber:         getEnv("COMPANY_PHONE_NUMBER", ""),
 		CompanyWhatsAppNumber:      getEnv("COMPANY_WHATSAPP_NUMBER", ""),
 		CompanyLogoURL:             getEnv("COMPANY_LOGO_URL", "static/assets/logo/Logo.png"),
 		CompanySlogan:              getEnv("COMPANY_SLOGAN", ""),
+		InteractionRulesPath:       getEnv("INTERACTION_RULES_PATH", "static/data/interaction_rules.json"),
+		EticketTemplatesPath:       getEnv("ETICKET_TEMPLATES_PATH", "static/data/eticket_templates"),
+		DocumentStorage:            getEnv("DOCUMENT_STORAGE", "local"), // local or s3
+		DocumentStoragePath:        getEnv("DOCUMENT_STORAGE_PATH", "static/pdf"),
+		S3Endpoint:                 getEnv("S3_ENDPOINT", "http://localhost:9000"),
+		S3Region:                   getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:                   getEnv("S3_BUCKET", "pharmacon"),
+		S3AccessKey:                getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:                getEnv("S3_SECRET_KEY", ""),
+		ReceiptPrinterAddress:      getEnv("RECEIPT_PRINTER_ADDRESS", ""),   // host:port, the port is 9100 if empty
+		ReceiptPaperWidth:          getEnvAsInt("RECEIPT_PAPER_WIDTH", 80),  // 58 or 80 mm
+		WebhookTimeout:             getEnvAsInt("WEBHOOK_TIMEOUT", 10),      // in seconds
+		WebhookPollInterval:        getEnvAsInt("WEBHOOK_POLL_INTERVAL", 5), // in seconds
+		WebhookMaxAttempts:         getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		ServerReadTimeout:          getEnvAsInt("SERVER_READ_TIMEOUT", 30),           // in seconds
+		ServerReadHeaderTimeout:    getEnvAsInt("SERVER_READ_HEADER_TIMEOUT", 10),    // in seconds
+		ServerWriteTimeout:         getEnvAsInt("SERVER_WRITE_TIMEOUT", 120),         // in seconds, the pdfs are made in the request
+		ServerIdleTimeout:          getEnvAsInt("SERVER_IDLE_TIMEOUT", 120),          // in seconds
+		ShutdownTimeout:            getEnvAsInt("SHUTDOWN_TIMEOUT", 30),              // in seconds
+		MaxRequestBodySize:         getEnvAsInt("MAX_REQUEST_BODY_SIZE", (10 << 20)), // in bytes
+		TLSCertFile:                getEnv("TLS_CERT_FILE", ""),                      // https is served if both files are set
+		TLSKeyFile:                 getEnv("TLS_KEY_FILE", ""),
+		MetricsToken:               getEnv("METRICS_TOKEN", ""),                     // the bearer token of /metrics, empty disables it
+		LogLevel:                   getEnv("LOG_LEVEL", "info"),                     // debug, info, warn or error
+		LogFormat:                  getEnv("LOG_FORMAT", "json"),                    // json or text
+		LogRedactKeys:              getEnv("LOG_REDACT_KEYS", ""),                   // comma separated, added to the default ones
+		LoginMaxAttempts:           getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),            // failed logins of a user name before it is locked
+		LoginMaxAttemptsPerIP:      getEnvAsInt("LOGIN_MAX_ATTEMPTS_PER_IP", 20),    // failed logins of an ip address before it is locked
+		LoginAttemptWindow:         getEnvAsInt("LOGIN_ATTEMPT_WINDOW", 900),        // in seconds, the failed count is cleared after this
+		LoginLockoutDuration:       getEnvAsInt("LOGIN_LOCKOUT_DURATION", 60),       // in seconds, doubled for every lockout in a row
+		LoginMaxLockoutDuration:    getEnvAsInt("LOGIN_MAX_LOCKOUT_DURATION", 3600), // in seconds
+		RateLimitLogin:             getEnvAsInt("RATE_LIMIT_LOGIN", 10),             // requests per minute of an ip address, 0 is no limit
+		RateLimitPublic:            getEnvAsInt("RATE_LIMIT_PUBLIC", 120),
+		RateLimitAPI:               getEnvAsInt("RATE_LIMIT_API", 600),
+		TrustedProxyCount:          getEnvAsInt("TRUSTED_PROXY_COUNT", 0), // the reverse proxies that add X-Forwarded-For, 0 doesn't use it
+		PasswordMinLength:          getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:       getEnvAsBool("PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:       getEnvAsBool("PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:       getEnvAsBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol:      getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordHistory:            getEnvAsInt("PASSWORD_HISTORY", 5),                  // the last passwords that can't be used again, 0 is no check
+		PasswordExpiryDays:         getEnvAsInt("PASSWORD_EXPIRY_DAYS", 0),              // 0 is never expired
+		PasswordResetCodeExp:       getEnvAsInt("PASSWORD_RESET_CODE_EXP", (3600 * 24)), // for 24 hours
+		TOTPIssuer:                 getEnv("TOTP_ISSUER", "Pharmacon"),                  // shown in the authenticator app
 	}
 }
 
@@ -67,6 +151,20 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return fallback
+		}
+
+		return b
+	}
+
+	return fallback
+}
+
 func getEnvAsInt(key string, fallback int64) int64 {
 	if value, ok := os.LookupEnv(key); ok {
 		i, err := strconv.ParseInt(value, 10, 64)
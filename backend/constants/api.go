@@ -0,0 +1,5 @@
+package constants
+
+// API
+const API_PREFIX = "/api/v1"
+const API_VERSION = "1.0.0"
@@ -0,0 +1,7 @@
+package constants
+
+// the outlet that has everything made before the branches
+const BRANCH_DEFAULT_ID = 1
+
+// only the owner can ask for all branches
+const BRANCH_ALL = 0
@@ -12,3 +12,12 @@ const BLACK_B = 0
 const RED_R = 255
 const RED_G = 0
 const RED_B = 0
+
+const WHITE_R = 255
+const WHITE_G = 255
+const WHITE_B = 255
+
+// background of the external use eticket
+const ETIX_BLUE_R = 135
+const ETIX_BLUE_G = 206
+const ETIX_BLUE_B = 250
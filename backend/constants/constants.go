@@ -1,3 +0,0 @@
-package constants
-
-const MAIN_DOCTOR = "JUSTUS KADIRMAN"
\ No newline at end of file
@@ -0,0 +1,36 @@
+package constants
+
+// CONTROLLED SUBSTANCE CLASS, medicine without class is not recorded in the register
+const CONTROLLED_CLASS_NARCOTIC = "NARKOTIKA"
+const CONTROLLED_CLASS_PSYCHOTROPIC = "PSIKOTROPIKA"
+
+// CONTROLLED SUBSTANCE TRANSACTION TYPE
+const CONTROLLED_TRANSACTION_SALE = "SALE"
+const CONTROLLED_TRANSACTION_DISPENSE = "DISPENSE"
+const CONTROLLED_TRANSACTION_PURCHASE = "PURCHASE"
+const CONTROLLED_TRANSACTION_PRODUCTION = "PRODUCTION"
+const CONTROLLED_TRANSACTION_TRANSFER = "TRANSFER"
+
+// CONTROLLED SUBSTANCE REPORT PDF, measurement in cm
+const CS_REPORT_WIDTH = 29.7
+const CS_REPORT_HEIGHT = 21
+const CS_REPORT_MARGIN = 1.0
+
+const CS_REPORT_LOGO_WIDTH = 1.9
+const CS_REPORT_LOGO_HEIGHT = 1.9
+
+const CS_REPORT_HEADER_HEIGHT = 0.45
+const CS_REPORT_TABLE_HEIGHT = 0.6
+const CS_REPORT_FOOTER_CELL_HEIGHT = 0.5
+
+const CS_REPORT_NO_COL_WIDTH = 1.0
+const CS_REPORT_ITEM_COL_WIDTH = 6.7
+const CS_REPORT_CLASS_COL_WIDTH = 2.6
+const CS_REPORT_UNIT_COL_WIDTH = 1.8
+const CS_REPORT_QTY_COL_WIDTH = 2.2
+
+const CS_REPORT_TITLE_FONT_SZ = 14
+const CS_REPORT_STD_FONT_SZ = 10
+const CS_REPORT_HEADER_FONT_SZ = 9
+const CS_REPORT_TABLE_HEADER_FONT_SZ = 9
+const CS_REPORT_TABLE_DATA_FONT_SZ = 9
@@ -0,0 +1,29 @@
+package constants
+
+// THERMAL RECEIPT PAPER WIDTH
+// in mm, with the number of characters per line of font A and the printable dots
+const ESCPOS_PAPER_58 = 58
+const ESCPOS_PAPER_80 = 80
+
+const ESCPOS_58_CHARS_PER_LINE = 32
+const ESCPOS_80_CHARS_PER_LINE = 48
+const ESCPOS_58_DOTS = 384
+const ESCPOS_80_DOTS = 576
+
+// the logo takes half of the paper width
+const ESCPOS_LOGO_WIDTH_RATIO = 0.5
+
+// the raster image is sent in bands, some printers can't take a big image at once
+const ESCPOS_RASTER_BAND_HEIGHT = 128
+
+// THERMAL RECEIPT CODE
+const ESCPOS_CODE_BARCODE = "barcode"
+const ESCPOS_CODE_QR = "qr"
+const ESCPOS_BARCODE_HEIGHT = 60
+const ESCPOS_QR_MODULE_SIZE = 6
+
+const ESCPOS_FEED_BEFORE_CUT = 3
+
+// RAW PRINTER
+const ESCPOS_PRINTER_PORT = "9100"
+const ESCPOS_PRINTER_TIMEOUT_SECONDS = 10
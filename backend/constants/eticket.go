@@ -1,22 +1,30 @@
 package constants
 
-// measurement in cm
+// measurement in cm, used when the template doesn't set it
 const ETIX_MARGIN = 0.1
+const ETIX_STD_CELL_HEIGHT = 0.6
+const ETIX_STD_FONT_SZ = 9
+const ETIX_LINE_WIDTH = 0.02
 
-// Etix 7x4
-const ETIX_7X4_WIDTH = 4
-const ETIX_7X4_HEIGHT = 7
+// ETICKET VARIANT
+// white is for internal use (oral), blue is for external use
+const ETIX_VARIANT_WHITE = "WHITE"
+const ETIX_VARIANT_BLUE = "BLUE"
 
-const ETIX_7X4_STD_CELL_HEIGHT = 0.6
-const ETIX_7X4_MAX_NAME_LEN = 25
+// ETICKET TEMPLATE FIELD
+const ETIX_FIELD_NUMBER = "number"
+const ETIX_FIELD_DATE = "date"
+const ETIX_FIELD_PATIENT_NAME = "patientName"
+const ETIX_FIELD_USAGE = "usage"
+const ETIX_FIELD_DOSE = "dose"
+const ETIX_FIELD_CONSUME_TIME = "consumeTime"
+const ETIX_FIELD_INSTRUCTION = "instruction"
+const ETIX_FIELD_BADGES = "badges"
+const ETIX_FIELD_QTY = "qty"
+const ETIX_FIELD_TEXT = "text"
+const ETIX_FIELD_FOOTER = "footer"
 
-const ETIX_7X4_STD_FONT_SZ = 9
-
-// Etix 7x5
-const ETIX_7X5_WIDTH = 5
-const ETIX_7X5_HEIGHT = 7
-
-const ETIX_7X5_STD_CELL_HEIGHT = 0.7
-const ETIX_7X5_MAX_NAME_LEN = 25
-
-const ETIX_7X5_STD_FONT_SZ = 10
+// ETICKET BADGE
+const ETIX_BADGE_SHAKE_WELL = "KOCOK DAHULU"
+const ETIX_BADGE_MUST_FINISH = "HABISKAN"
+const ETIX_BADGE_PADDING = 0.1
@@ -0,0 +1,38 @@
+package constants
+
+// EVENT TYPE, the webhook subscribes with these names
+const EVENT_INVOICE_CREATED = "invoice.created"
+const EVENT_INVOICE_MODIFIED = "invoice.modified"
+const EVENT_INVOICE_DELETED = "invoice.deleted"
+const EVENT_PRESCRIPTION_CREATED = "prescription.created"
+const EVENT_PRESCRIPTION_MODIFIED = "prescription.modified"
+const EVENT_PRESCRIPTION_DELETED = "prescription.deleted"
+const EVENT_PURCHASE_INVOICE_CREATED = "purchase-invoice.created"
+const EVENT_PURCHASE_INVOICE_MODIFIED = "purchase-invoice.modified"
+const EVENT_PURCHASE_INVOICE_DELETED = "purchase-invoice.deleted"
+
+// EVENT ENTITY
+const EVENT_ENTITY_INVOICE = "invoice"
+const EVENT_ENTITY_PRESCRIPTION = "prescription"
+const EVENT_ENTITY_PURCHASE_INVOICE = "purchase-invoice"
+
+// WEBHOOK DELIVERY STATUS
+const WEBHOOK_DELIVERY_PENDING = "PENDING"
+const WEBHOOK_DELIVERY_DELIVERED = "DELIVERED"
+const WEBHOOK_DELIVERY_FAILED = "FAILED"
+
+// WEBHOOK REQUEST HEADER
+// the signature is the hex HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret
+const WEBHOOK_HEADER_EVENT = "X-Pharmacon-Event"
+const WEBHOOK_HEADER_EVENT_ID = "X-Pharmacon-Event-ID"
+const WEBHOOK_HEADER_DELIVERY_ID = "X-Pharmacon-Delivery-ID"
+const WEBHOOK_HEADER_TIMESTAMP = "X-Pharmacon-Timestamp"
+const WEBHOOK_HEADER_SIGNATURE = "X-Pharmacon-Signature"
+
+// WEBHOOK RETRY, measurement in seconds
+// the wait is doubled after every failed attempt
+const WEBHOOK_RETRY_BASE_DELAY = 30
+const WEBHOOK_RETRY_MAX_DELAY = 3600
+const WEBHOOK_DELIVERY_LEASE = 120
+const WEBHOOK_DELIVERY_BATCH_SIZE = 50
+const WEBHOOK_RESPONSE_BODY_LIMIT = 1000
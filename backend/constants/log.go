@@ -0,0 +1,15 @@
+package constants
+
+// LOG FORMAT
+const LOG_FORMAT_JSON = "json"
+const LOG_FORMAT_TEXT = "text"
+
+// the request id is taken from the client if it is given, or made by the server
+const REQUEST_ID_HEADER = "X-Request-ID"
+const REQUEST_ID_MAX_LENGTH = 128
+
+// the value of the sensitive field in the log
+const LOG_REDACTED = "[REDACTED]"
+
+// the fields that are always redacted, compared without case, "_" and "-"
+const LOG_REDACT_KEYS = "password,newpassword,oldpassword,token,authorization,cookie,secret,apikey,accesskey,secretkey,otp,totp,resetcode"
@@ -0,0 +1,28 @@
+package constants
+
+// LOGIN LOCK TYPE
+const LOGIN_LOCK_USERNAME = "USERNAME"
+const LOGIN_LOCK_IP = "IP"
+
+// FAILED LOGIN REASON
+const LOGIN_FAIL_UNKNOWN_USER = "UNKNOWN_USER"
+const LOGIN_FAIL_WRONG_PASSWORD = "WRONG_PASSWORD"
+const LOGIN_FAIL_LOCKED = "LOCKED"
+const LOGIN_FAIL_INVALID_TOTP = "INVALID_TOTP"
+
+// the user agent is cut to the column size
+const LOGIN_USER_AGENT_MAX_LENGTH = 255
+
+// RATE LIMIT
+// the clients that didn't send a request for this long are forgotten
+const RATE_LIMIT_IDLE_TIMEOUT = 600 // in seconds
+
+// PASSWORD
+const PASSWORD_RESET_CODE_LENGTH = 10
+const INITIAL_ADMIN_PASSWORD_LENGTH = 16
+
+// TOTP
+const TOTP_SECRET_SIZE = 20 // in bytes
+const TOTP_DIGITS = 6
+const TOTP_PERIOD = 30 // in seconds
+const TOTP_SKEW = 1    // the steps accepted before and after the current one
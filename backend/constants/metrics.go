@@ -0,0 +1,12 @@
+package constants
+
+// METRICS
+const METRICS_NAMESPACE = "pharmacon"
+const METRICS_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// INVOICE SOURCE, the label of the created invoices
+const METRICS_INVOICE_ONLINE = "online"
+const METRICS_INVOICE_OFFLINE = "offline"
+
+// the route label of the request that doesn't match any route
+const METRICS_ROUTE_UNMATCHED = "unmatched"
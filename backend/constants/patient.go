@@ -0,0 +1,27 @@
+package constants
+
+// PATIENT MEDICATION HISTORY PDF, measurement in cm
+const PATIENT_HISTORY_WIDTH = 29.7
+const PATIENT_HISTORY_HEIGHT = 21
+const PATIENT_HISTORY_MARGIN = 1.0
+
+const PATIENT_HISTORY_LOGO_WIDTH = 1.9
+const PATIENT_HISTORY_LOGO_HEIGHT = 1.9
+
+const PATIENT_HISTORY_HEADER_HEIGHT = 0.45
+const PATIENT_HISTORY_TABLE_HEIGHT = 0.5
+
+const PATIENT_HISTORY_NO_COL_WIDTH = 1.0
+const PATIENT_HISTORY_DATE_COL_WIDTH = 2.2
+const PATIENT_HISTORY_NUMBER_COL_WIDTH = 2.0
+const PATIENT_HISTORY_DOCTOR_COL_WIDTH = 4.5
+const PATIENT_HISTORY_ITEM_COL_WIDTH = 6.2
+const PATIENT_HISTORY_QTY_COL_WIDTH = 2.4
+const PATIENT_HISTORY_SIGNA_COL_WIDTH = 7.4
+const PATIENT_HISTORY_ITER_COL_WIDTH = 2.0
+
+const PATIENT_HISTORY_TITLE_FONT_SZ = 14
+const PATIENT_HISTORY_STD_FONT_SZ = 10
+const PATIENT_HISTORY_HEADER_FONT_SZ = 9
+const PATIENT_HISTORY_TABLE_HEADER_FONT_SZ = 9
+const PATIENT_HISTORY_TABLE_DATA_FONT_SZ = 8
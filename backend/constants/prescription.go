@@ -21,3 +21,25 @@ const PRESC_MED_FONT_SZ = 12
 const PRESC_MED_QTY_UNIT_FONT_SZ = 16
 const PRESC_DET_FONT_SZ = 21
 const PRESC_MF_DOSE_FONT_SZ = 12
+
+const PRESC_COPY_ANNOTATION_FONT_SZ = 14
+const PRESC_COPY_SIGNATURE_Y = 17.2
+const PRESC_COPY_SIGNATURE_X = 5.6
+
+// small tolerance for comparing the fraction qty, e.g. 1/3 tablet
+const PRESC_QTY_TOLERANCE = 0.001
+
+// a compounded medicine can use another compounded medicine as its ingredient
+const MAX_COMPOUND_RECIPE_DEPTH = 3
+
+// DISPENSED INGREDIENT REFERENCE
+const DISPENSED_REFERENCE_INVOICE = "INVOICE"
+const DISPENSED_REFERENCE_PRESCRIPTION = "PRESCRIPTION"
+
+// PRESCRIPTION STATUS
+const PRESC_STATUS_RECEIVED = "RECEIVED"
+const PRESC_STATUS_IN_PREPARATION = "IN_PREPARATION"
+const PRESC_STATUS_COMPOUNDED = "COMPOUNDED"
+const PRESC_STATUS_VERIFIED = "VERIFIED"
+const PRESC_STATUS_HANDED_OVER = "HANDED_OVER"
+const PRESC_STATUS_CANCELLED = "CANCELLED"
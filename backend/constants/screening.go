@@ -0,0 +1,13 @@
+package constants
+
+// SCREENING RESULT TYPE
+const SCREENING_TYPE_ALLERGY = "ALLERGY"
+const SCREENING_TYPE_INTERACTION = "INTERACTION"
+const SCREENING_TYPE_SIGNA = "SIGNA"
+
+// SCREENING SEVERITY, MAJOR and CONTRAINDICATED block the prescription
+// unless the pharmacist gives an override reason
+const SEVERITY_MINOR = "MINOR"
+const SEVERITY_MODERATE = "MODERATE"
+const SEVERITY_MAJOR = "MAJOR"
+const SEVERITY_CONTRAINDICATED = "CONTRAINDICATED"
@@ -0,0 +1,19 @@
+package constants
+
+// HEALTH CHECK STATUS
+const HEALTH_OK = "ok"
+const HEALTH_FAIL = "fail"
+
+// HEALTH CHECK NAME
+const HEALTH_CHECK_DATABASE = "database"
+const HEALTH_CHECK_DOCUMENT_STORAGE = "documentStorage"
+const HEALTH_CHECK_LOG_STORAGE = "logStorage"
+
+// the probe document is saved and deleted on every readiness check
+const DOCUMENT_HEALTH = "health"
+const HEALTH_PROBE_FILE_NAME = "readyz.probe"
+
+// the log folder of logger.WriteLog
+const LOG_STORAGE_PATH = "static/log"
+
+const HEALTH_CHECK_TIMEOUT = 5 // in seconds
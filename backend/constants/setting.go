@@ -0,0 +1,16 @@
+package constants
+
+// DOCUMENT TYPE
+// the documents with their own footer
+const SETTING_DOCUMENT_INVOICE = "invoice"
+const SETTING_DOCUMENT_PURCHASE_INVOICE = "purchase-invoice"
+const SETTING_DOCUMENT_PURCHASE_ORDER = "purchase-order"
+const SETTING_DOCUMENT_PRESCRIPTION = "prescription"
+const SETTING_DOCUMENT_ETICKET = "eticket"
+const SETTING_DOCUMENT_STOCK_TRANSFER = "stock-transfer"
+
+// the footer is printed in the bottom margin of every page
+const SETTING_FOOTER_FONT_SZ = 5
+
+// max logo upload size in bytes
+const SETTING_LOGO_MAX_SIZE = 2 << 20
@@ -0,0 +1,12 @@
+package constants
+
+// SIGNA TIMING
+const SIGNA_TIMING_BEFORE_MEAL = "BEFORE_MEAL"
+const SIGNA_TIMING_AFTER_MEAL = "AFTER_MEAL"
+const SIGNA_TIMING_WITH_MEAL = "WITH_MEAL"
+const SIGNA_TIMING_BEDTIME = "BEDTIME"
+const SIGNA_TIMING_MORNING = "MORNING"
+const SIGNA_TIMING_NIGHT = "NIGHT"
+
+// tolerance of the leftover qty when the qty is checked against the signa
+const SIGNA_QTY_TOLERANCE = 0.01
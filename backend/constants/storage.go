@@ -0,0 +1,21 @@
+package constants
+
+// DOCUMENT STORAGE
+const STORAGE_LOCAL = "local"
+const STORAGE_S3 = "s3"
+
+// DOCUMENT CATEGORY
+// also the directory or the key prefix in the storage
+const DOCUMENT_INVOICE = "invoice"
+const DOCUMENT_RECEIPT = "invoice/receipt"
+const DOCUMENT_PRESCRIPTION = "prescription"
+const DOCUMENT_PRESCRIPTION_COPY = "prescription-copy"
+const DOCUMENT_ETICKET = "eticket"
+const DOCUMENT_PATIENT_HISTORY = "patient-history"
+const DOCUMENT_PURCHASE_INVOICE = "purchase-invoice"
+const DOCUMENT_PURCHASE_ORDER = "purchase-order"
+const DOCUMENT_CONTROLLED_SUBSTANCE = "controlled-substance"
+const DOCUMENT_STOCK_TRANSFER = "stock-transfer"
+const DOCUMENT_LOGO = "logo"
+
+const DOCUMENT_HASH_HEADER = "X-Content-Sha256"
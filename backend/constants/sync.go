@@ -0,0 +1,22 @@
+package constants
+
+// OFFLINE INVOICE SYNC STATUS
+// the invoice is always kept because the sale already happened,
+// conflict means some of the stock was not there anymore
+const OFFLINE_INVOICE_STATUS_SYNCED = "SYNCED"
+const OFFLINE_INVOICE_STATUS_CONFLICT = "CONFLICT"
+
+// pending claims the uuid while the invoice is made,
+// failed is kept if the sync stopped after the invoice was saved
+const OFFLINE_INVOICE_STATUS_PENDING = "PENDING"
+const OFFLINE_INVOICE_STATUS_FAILED = "FAILED"
+
+// only returned to the terminal, not saved
+const OFFLINE_INVOICE_STATUS_DUPLICATE = "DUPLICATE"
+const OFFLINE_INVOICE_STATUS_REJECTED = "REJECTED"
+
+// the offline invoice takes the next number again if another invoice took it first
+const OFFLINE_INVOICE_NUMBER_ATTEMPTS = 10
+
+// the pending claim older than this was left by a push that stopped, in seconds
+const OFFLINE_INVOICE_CLAIM_TIMEOUT = 600
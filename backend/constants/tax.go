@@ -0,0 +1,11 @@
+package constants
+
+// tax code used for sales and purchases (PPN / VAT)
+const DEFAULT_TAX_CODE = "PPN"
+
+// the tax seeded with the initial admin, so the invoices can be made before the tax is set.
+// PPN is 11% since 1 April 2022
+const DEFAULT_TAX_NAME = "PPN"
+const DEFAULT_TAX_PERCENTAGE = 11.0
+const DEFAULT_TAX_EFFECTIVE_DATE = "2022-04-01"
+const DEFAULT_TAX_DESCRIPTION = "default"
@@ -0,0 +1,39 @@
+package constants
+
+// STOCK TRANSFER STATUS
+// requested by the receiving branch, dispatched by the sending branch
+const TRANSFER_STATUS_REQUESTED = "REQUESTED"
+const TRANSFER_STATUS_DISPATCHED = "DISPATCHED"
+const TRANSFER_STATUS_RECEIVED = "RECEIVED"
+
+// TRANSFER NOTE PDF, measurement in cm
+const TRANSFER_WIDTH = 21
+const TRANSFER_HEIGHT = 14
+const TRANSFER_MARGIN = 0.2
+
+const TRANSFER_LOGO_WIDTH = 1.9
+const TRANSFER_LOGO_HEIGHT = 1.9
+
+const TRANSFER_STD_CELL_HEIGHT = 0.4
+const TRANSFER_INFO_HEIGHT = 0.5
+
+const TRANSFER_FOOTER_CELL_HEIGHT = 0.5
+
+const TRANSFER_INFO_NUMBER_WIDTH = 1.7
+const TRANSFER_INFO_DATE_WIDTH = 2.5
+const TRANSFER_INFO_STATUS_WIDTH = 2.6
+const TRANSFER_INFO_USER_WIDTH = 1.7
+
+const TRANSFER_HEADER_HEIGHT = 0.3
+const TRANSFER_TABLE_HEIGHT = 0.6
+const TRANSFER_NO_COL_WIDTH = 0.8
+const TRANSFER_ITEM_COL_WIDTH = 8.3
+const TRANSFER_BATCH_COL_WIDTH = 2.9
+const TRANSFER_QTY_COL_WIDTH = 2.2
+const TRANSFER_UNIT_COL_WIDTH = 0
+
+const TRANSFER_BRANCH_FONT_SZ = 9
+const TRANSFER_STD_FONT_SZ = 11
+const TRANSFER_HEADER_FONT_SZ = 8
+const TRANSFER_TABLE_HEADER_FONT_SZ = TRANSFER_STD_FONT_SZ - 1
+const TRANSFER_TABLE_DATA_FONT_SZ = TRANSFER_TABLE_HEADER_FONT_SZ
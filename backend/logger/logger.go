@@ -1,22 +1,25 @@
 package logger
 
 import (
-	"bytes"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
 )
 
+// the body is not kept, only the status and the size
 type LogResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
-	buf        bytes.Buffer
+	size       int
 }
 
 func NewLogResponseWriter(w http.ResponseWriter) *LogResponseWriter {
-	return &LogResponseWriter{ResponseWriter: w}
+	return &LogResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 }
 
 func (w *LogResponseWriter) WriteHeader(code int) {
@@ -25,31 +28,70 @@ func (w *LogResponseWriter) WriteHeader(code int) {
 }
 
 func (w *LogResponseWriter) Write(body []byte) (int, error) {
-	w.buf.Write(body)
-	return w.ResponseWriter.Write(body)
+	size, err := w.ResponseWriter.Write(body)
+	w.size += size
+	return size, err
 }
 
 type LogMiddleware struct {
-	logger *log.Logger
+	logger *slog.Logger
 }
 
-func NewLogMiddleware(logger *log.Logger) *LogMiddleware {
+func NewLogMiddleware(logger *slog.Logger) *LogMiddleware {
 	return &LogMiddleware{logger: logger}
 }
 
+// gives the request id to the request and writes the access log,
+// the request and the response bodies are never written
 func (m *LogMiddleware) Func() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			startTime := time.Now()
 
+			info := &RequestInfo{ID: getRequestID(r)}
+			r = r.WithContext(WithRequestInfo(r.Context(), info))
+			w.Header().Set(constants.REQUEST_ID_HEADER, info.ID)
+
 			logRespWriter := NewLogResponseWriter(w)
 			next.ServeHTTP(logRespWriter, r)
 
-			m.logger.Printf(
-				"duration=%s status=%d body=%s",
-				time.Since(startTime).String(),
-				logRespWriter.statusCode,
-				logRespWriter.buf.String())
+			// the route template, so the ids and the names in the path are not written
+			route := ""
+			if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+				route, _ = currentRoute.GetPathTemplate()
+			}
+
+			level := slog.LevelInfo
+			if logRespWriter.statusCode >= http.StatusInternalServerError {
+				level = slog.LevelError
+			} else if logRespWriter.statusCode >= http.StatusBadRequest {
+				level = slog.LevelWarn
+			}
+
+			m.logger.LogAttrs(r.Context(), level, "request",
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.Int("status", logRespWriter.statusCode),
+				slog.Int("size", logRespWriter.size),
+				slog.Float64("latencyMs", (float64(time.Since(startTime).Microseconds()) / 1000)))
 		})
 	}
 }
+
+// the request id from the client is used if it is safe to write in the log
+func getRequestID(r *http.Request) string {
+	requestId := r.Header.Get(constants.REQUEST_ID_HEADER)
+
+	if requestId == "" || len(requestId) > constants.REQUEST_ID_MAX_LENGTH {
+		return uuid.NewString()
+	}
+
+	isSafe := strings.IndexFunc(requestId, func(c rune) bool {
+		return !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.')
+	}) == -1
+	if !isSafe {
+		return uuid.NewString()
+	}
+
+	return requestId
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+type contextKey string
+
+const requestInfoKey contextKey = "requestInfo"
+
+// kept in the request context, the user id is set by the auth middleware
+type RequestInfo struct {
+	ID     string
+	UserID int
+}
+
+// the default slog logger, the std log package is written through it too.
+// level is debug, info, warn or error, format is json or text
+func InitLogger(w io.Writer, level string, format string, redactKeys string) error {
+	var logLevel slog.Level
+
+	err := logLevel.UnmarshalText([]byte(level))
+	if err != nil {
+		return fmt.Errorf("unknown log level %s", level)
+	}
+
+	redacted := make(map[string]bool)
+	for _, key := range strings.Split((constants.LOG_REDACT_KEYS + "," + redactKeys), ",") {
+		if key = normalizeKey(key); key != "" {
+			redacted[key] = true
+		}
+	}
+
+	options := &slog.HandlerOptions{
+		Level: logLevel,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if redacted[normalizeKey(attr.Key)] {
+				return slog.String(attr.Key, constants.LOG_REDACTED)
+			}
+
+			return attr
+		},
+	}
+
+	var handler slog.Handler
+
+	switch strings.ToLower(format) {
+	case constants.LOG_FORMAT_JSON:
+		handler = slog.NewJSONHandler(w, options)
+	case constants.LOG_FORMAT_TEXT:
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return fmt.Errorf("unknown log format %s", format)
+	}
+
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+
+	return nil
+}
+
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+func GetRequestInfo(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey).(*RequestInfo)
+	return info
+}
+
+// the access log is written after the handler, so the user id is known then
+func SetUserID(ctx context.Context, userId int) {
+	if info := GetRequestInfo(ctx); info != nil {
+		info.UserID = userId
+	}
+}
+
+// adds the request id and the user id to the log written with the request context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := GetRequestInfo(ctx); info != nil {
+		record.AddAttrs(slog.String("requestId", info.ID))
+
+		if info.UserID != 0 {
+			record.AddAttrs(slog.Int("userId", info.UserID))
+		}
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
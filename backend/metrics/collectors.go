@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+// in seconds
+var httpDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+var pdfDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	httpRequests = NewCounterVec(constants.METRICS_NAMESPACE+"_http_requests_total",
+		"The HTTP requests handled, by the route template and the status.", "method", "route", "status")
+	httpRequestDuration = NewHistogramVec(constants.METRICS_NAMESPACE+"_http_request_duration_seconds",
+		"The time to handle the HTTP request.", httpDurationBuckets, "method", "route", "status")
+	pdfDuration = NewHistogramVec(constants.METRICS_NAMESPACE+"_pdf_generation_duration_seconds",
+		"The time to make and save the PDF document.", pdfDurationBuckets, "document")
+
+	invoicesCreated = NewCounterVec(constants.METRICS_NAMESPACE+"_invoices_created_total",
+		"The invoices created, online or pushed by the offline terminals.", "source")
+	prescriptionsDispensed = NewCounterVec(constants.METRICS_NAMESPACE+"_prescriptions_dispensed_total",
+		"The prescriptions handed over to the patient.")
+	stockOuts = NewCounterVec(constants.METRICS_NAMESPACE+"_stock_outs_total",
+		"The times the stock of a medicine in a branch ran out.")
+	failedLogins = NewCounterVec(constants.METRICS_NAMESPACE+"_failed_logins_total",
+		"The logins with a wrong name or password, or refused by the lockout.")
+	loginLockouts = NewCounterVec(constants.METRICS_NAMESPACE+"_login_lockouts_total",
+		"The user names and the ip addresses locked by the failed logins.")
+)
+
+func ObserveRequest(method string, route string, status int, duration time.Duration) {
+	statusLabel := formatValue(float64(status))
+
+	httpRequests.Inc(method, route, statusLabel)
+	httpRequestDuration.Observe(duration.Seconds(), method, route, statusLabel)
+}
+
+// use with defer at the start of making the pdf
+func ObservePDFDuration(document string, startTime time.Time) {
+	pdfDuration.Observe(time.Since(startTime).Seconds(), document)
+}
+
+func IncInvoicesCreated(source string) {
+	invoicesCreated.Inc(source)
+}
+
+func IncPrescriptionsDispensed() {
+	prescriptionsDispensed.Inc()
+}
+
+func IncStockOuts() {
+	stockOuts.Inc()
+}
+
+func IncFailedLogins() {
+	failedLogins.Inc()
+}
+
+func IncLoginLockouts() {
+	loginLockouts.Inc()
+}
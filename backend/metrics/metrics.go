@@ -0,0 +1,246 @@
+// Package metrics keeps the counters and the histograms of the server and
+// writes them in the prometheus text format, see
+// https://prometheus.io/docs/instrumenting/exposition_formats/
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type collector interface {
+	write(w io.Writer)
+}
+
+// the metrics are written in the order they are made
+var (
+	collectorsMu sync.Mutex
+	collectors   []collector
+)
+
+func register(c collector) {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+
+	collectors = append(collectors, c)
+}
+
+// writes every counter and histogram
+func Write(w io.Writer) {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// the counter with the same label values is counted together
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*counterValue),
+	}
+
+	register(counter)
+
+	return counter
+}
+
+// the label values are in the order of the labels
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counter, ok := c.values[key]
+	if !ok {
+		counter = &counterValue{labelValues: labelValues}
+		c.values[key] = counter
+	}
+
+	counter.value += value
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+
+	// the counter without labels is shown as 0 before it is counted
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.name)
+		return
+	}
+
+	for _, key := range sortedKeys(c.values) {
+		counter := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, counter.labelValues), formatValue(counter.value))
+	}
+}
+
+// the buckets are the upper bounds in seconds, sorted from the smallest
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	histogram := &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+
+	register(histogram)
+
+	return histogram
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	histogram, ok := h.values[key]
+	if !ok {
+		histogram = &histogramValue{
+			labelValues: labelValues,
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = histogram
+	}
+
+	for i, bucket := range h.buckets {
+		if value <= bucket {
+			histogram.counts[i]++
+			break
+		}
+	}
+
+	histogram.count++
+	histogram.sum += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+
+	for _, key := range sortedKeys(h.values) {
+		histogram := h.values[key]
+
+		cumulative := uint64(0)
+		for i, bucket := range h.buckets {
+			cumulative += histogram.counts[i]
+
+			labelValues := append(append([]string{}, histogram.labelValues...), formatValue(bucket))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, labelValues), cumulative)
+		}
+
+		labelValues := append(append([]string{}, histogram.labelValues...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, labelValues), histogram.count)
+
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, histogram.labelValues), formatValue(histogram.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, histogram.labelValues), histogram.count)
+	}
+}
+
+// the gauge that is read when the metrics are written, e.g. the db pool stats
+func WriteGauge(w io.Writer, name string, help string, value float64) {
+	writeHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+}
+
+// the counter that is kept somewhere else and only read here
+func WriteCounter(w io.Writer, name string, help string, value float64) {
+	writeHeader(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+}
+
+func writeHeader(w io.Writer, name string, help string, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+func formatLabels(labels []string, labelValues []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0)
+	for i, label := range labels {
+		value := ""
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label, labelValueReplacer.Replace(value)))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0)
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
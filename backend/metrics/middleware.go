@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	w.statusCode = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// the route template is used as the label, so the ids in the path
+// don't make a new series for every request
+func Middleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			startTime := time.Now()
+
+			statusWriter := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(statusWriter, r)
+
+			route := constants.METRICS_ROUTE_UNMATCHED
+			if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+				template, err := currentRoute.GetPathTemplate()
+				if err == nil {
+					route = template
+				}
+			}
+
+			ObserveRequest(r.Method, route, statusWriter.statusCode, time.Since(startTime))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+type Handler struct {
+	db    *sql.DB
+	token string
+}
+
+// the token is asked as the bearer token, the metrics are not served without it
+func NewHandler(db *sql.DB, token string) *Handler {
+	return &Handler{
+		db:    db,
+		token: token,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/metrics", h.handleGetMetrics).Methods(http.MethodGet)
+
+	// utils can't be used here, utils counts the stock outs
+	router.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	// the business volumes are not shown to everyone by default
+	if h.token == "" {
+		http.Error(w, "metrics is disabled, set the metrics token", http.StatusNotFound)
+		return
+	}
+
+	token := []byte("Bearer " + h.token)
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), token) != 1 {
+		http.Error(w, "invalid metrics token", http.StatusUnauthorized)
+		return
+	}
+
+	var buf bytes.Buffer
+
+	Write(&buf)
+	writeDBStats(&buf, h.db.Stats())
+
+	w.Header().Set("Content-Type", constants.METRICS_CONTENT_TYPE)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func writeDBStats(buf *bytes.Buffer, stats sql.DBStats) {
+	prefix := constants.METRICS_NAMESPACE + "_db_"
+
+	WriteGauge(buf, prefix+"max_open_connections", "The maximum number of open connections to the database.", float64(stats.MaxOpenConnections))
+	WriteGauge(buf, prefix+"open_connections", "The connections in use and idle.", float64(stats.OpenConnections))
+	WriteGauge(buf, prefix+"in_use_connections", "The connections in use.", float64(stats.InUse))
+	WriteGauge(buf, prefix+"idle_connections", "The idle connections.", float64(stats.Idle))
+	WriteCounter(buf, prefix+"wait_count_total", "The connections waited for.", float64(stats.WaitCount))
+	WriteCounter(buf, prefix+"wait_duration_seconds_total", "The time blocked waiting for a new connection.", stats.WaitDuration.Seconds())
+	WriteCounter(buf, prefix+"max_idle_closed_total", "The connections closed by the max idle connections.", float64(stats.MaxIdleClosed))
+	WriteCounter(buf, prefix+"max_idle_time_closed_total", "The connections closed by the max idle time.", float64(stats.MaxIdleTimeClosed))
+	WriteCounter(buf, prefix+"max_lifetime_closed_total", "The connections closed by the max lifetime.", float64(stats.MaxLifetimeClosed))
+}
@@ -2,7 +2,7 @@ package auth
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -24,7 +24,7 @@ func CreateJWT(userId int, admin bool) (*types.TokenDetails, error) {
 	tokenExp := time.Second * time.Duration(config.Envs.JWTExpirationInSeconds)
 
 	tokenDetails.TokenExp = time.Now().Add(tokenExp).Unix()
-	log.Println("tokenExp:", tokenDetails.TokenExp)
+	slog.Debug("token created", "userId", userId, "tokenExp", tokenDetails.TokenExp)
 	// tokenDetails.TokenExp = time.Now().Add(tokenExp)
 
 	tempUUID, err := uuid.NewV7()
@@ -53,7 +53,7 @@ func CreateJWT(userId int, admin bool) (*types.TokenDetails, error) {
 func ExtractTokenFromClient(r *http.Request) (*types.AccessDetails, error) {
 	token, err := verifyToken(r)
 	if err != nil {
-		log.Println("verify token error")
+		slog.DebugContext(r.Context(), "verify token error", "error", err)
 		return nil, err
 	}
 
@@ -62,13 +62,13 @@ func ExtractTokenFromClient(r *http.Request) (*types.AccessDetails, error) {
 	if ok && token.Valid {
 		tokenUuid, ok := claims["tokenUuid"].(string)
 		if !ok {
-			log.Println("jwt token error")
+			slog.DebugContext(r.Context(), "jwt token uuid error")
 			return nil, err
 		}
 
 		userId, err := strconv.Atoi(fmt.Sprintf("%.f", claims["userId"]))
 		if err != nil {
-			log.Println("jwt user id error")
+			slog.DebugContext(r.Context(), "jwt user id error", "error", err)
 			return nil, err
 		}
 
@@ -1,21 +1,19 @@
 package auth
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/logger"
 )
 
 
 func AuthMiddleware() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Println("auth middleware ok!")
-
-			// log.Println(r.Header)
-
 			token, err := verifyToken(r)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusForbidden)
@@ -38,6 +36,12 @@ func AuthMiddleware() mux.MiddlewareFunc {
 					return
 				}
 
+				// written in the access log of the request
+				userId, err := strconv.Atoi(fmt.Sprintf("%.f", claims["userId"]))
+				if err == nil {
+					logger.SetUserID(r.Context(), userId)
+				}
+
 				next.ServeHTTP(w, r)
 			}
 		})
@@ -47,10 +51,8 @@ func AuthMiddleware() mux.MiddlewareFunc {
 func CorsMiddleware() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Println("cors middleware ok!")
-
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Response-Type")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Response-Type, X-Request-ID")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE, PATCH")
 
 			// Handle preflight (OPTIONS) request by returning 200 OK with the necessary headers
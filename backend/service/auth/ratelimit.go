@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+// token bucket per client ip address, one limiter for each route group.
+// the bucket is full with requestsPerMinute tokens and refilled in a minute
+type RateLimiter struct {
+	group             string
+	requestsPerMinute int64
+	trustedProxyCount int64
+	mu                sync.Mutex
+	clients           map[string]*rateLimitClient
+	lastCleanup       time.Time
+}
+
+type rateLimitClient struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// requestsPerMinute 0 is no limit
+func NewRateLimiter(group string, requestsPerMinute int64, trustedProxyCount int64) *RateLimiter {
+	return &RateLimiter{
+		group:             group,
+		requestsPerMinute: requestsPerMinute,
+		trustedProxyCount: trustedProxyCount,
+		clients:           make(map[string]*rateLimitClient),
+		lastCleanup:       time.Now(),
+	}
+}
+
+// takes a token of the client, the time to wait is returned if there is none
+func (l *RateLimiter) Allow(clientIp string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cleanup(now)
+
+	capacity := float64(l.requestsPerMinute)
+	tokensPerSecond := (capacity / 60)
+
+	client, ok := l.clients[clientIp]
+	if !ok {
+		client = &rateLimitClient{tokens: capacity, lastSeen: now}
+		l.clients[clientIp] = client
+	}
+
+	client.tokens = math.Min(capacity, (client.tokens + (now.Sub(client.lastSeen).Seconds() * tokensPerSecond)))
+	client.lastSeen = now
+
+	if client.tokens < 1 {
+		wait := ((1 - client.tokens) / tokensPerSecond)
+		return false, time.Duration(math.Ceil(wait)) * time.Second
+	}
+
+	client.tokens--
+
+	return true, 0
+}
+
+// the clients that are idle have a full bucket again, so they are removed
+func (l *RateLimiter) cleanup(now time.Time) {
+	idleTimeout := (constants.RATE_LIMIT_IDLE_TIMEOUT * time.Second)
+	if now.Sub(l.lastCleanup) < idleTimeout {
+		return
+	}
+
+	for clientIp, client := range l.clients {
+		if now.Sub(client.lastSeen) > idleTimeout {
+			delete(l.clients, clientIp)
+		}
+	}
+
+	l.lastCleanup = now
+}
+
+func RateLimitMiddleware(limiter *RateLimiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter.requestsPerMinute <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			clientIp := utils.GetClientIP(r, limiter.trustedProxyCount)
+
+			allowed, retryAfter := limiter.Allow(clientIp, time.Now())
+			if !allowed {
+				slog.WarnContext(r.Context(), "rate limited", "group", limiter.group, "ipAddress", clientIp)
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+				utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many requests, try again in %d seconds", int(retryAfter.Seconds())))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
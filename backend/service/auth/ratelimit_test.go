@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Date(2024, time.October, 18, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		after     time.Duration // since the previous request
+		allowed   bool
+		wait      time.Duration
+		clientIp  string
+		takeTimes int // the same request repeated
+	}{
+		{"the full bucket", 0, true, 0, "10.0.0.1", 3},
+		{"the empty bucket", 0, false, 20 * time.Second, "10.0.0.1", 1},
+		{"the partly refilled bucket", 10 * time.Second, false, 10 * time.Second, "10.0.0.1", 1},
+		{"one token refilled", 10 * time.Second, true, 0, "10.0.0.1", 1},
+		{"another client", 0, true, 0, "10.0.0.2", 3},
+		{"the refill is capped to the bucket", 10 * time.Minute, true, 0, "10.0.0.1", 3},
+		{"the capped bucket is empty", 0, false, 20 * time.Second, "10.0.0.1", 1},
+	}
+
+	// a token every 20 seconds
+	limiter := NewRateLimiter("test", 3, 0)
+
+	for _, test := range tests {
+		now = now.Add(test.after)
+
+		for i := 0; i < test.takeTimes; i++ {
+			allowed, wait := limiter.Allow(test.clientIp, now)
+			if allowed != test.allowed || wait != test.wait {
+				t.Errorf("%s: request %d = (%v, %v), want (%v, %v)", test.name, i+1, allowed, wait, test.allowed, test.wait)
+			}
+		}
+	}
+}
+
+func TestRateLimiterCleanup(t *testing.T) {
+	now := time.Date(2024, time.October, 18, 8, 0, 0, 0, time.UTC)
+	idleTimeout := constants.RATE_LIMIT_IDLE_TIMEOUT * time.Second
+
+	limiter := NewRateLimiter("test", 3, 0)
+	limiter.lastCleanup = now
+
+	limiter.Allow("10.0.0.1", now)
+	limiter.Allow("10.0.0.2", now.Add(idleTimeout/2))
+
+	// the first client is idle for longer than the timeout, the second one is not
+	limiter.Allow("10.0.0.2", now.Add(idleTimeout+time.Second))
+
+	if _, ok := limiter.clients["10.0.0.1"]; ok {
+		t.Errorf("idle client is not removed")
+	}
+
+	if _, ok := limiter.clients["10.0.0.2"]; !ok {
+		t.Errorf("active client is removed")
+	}
+}
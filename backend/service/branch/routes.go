@@ -0,0 +1,246 @@
+package branch
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	branchStore types.BranchStore
+	userStore   types.UserStore
+}
+
+func NewHandler(branchStore types.BranchStore, userStore types.UserStore) *Handler {
+	return &Handler{branchStore: branchStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/branch", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/branch/{val}", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/branch", h.handleDelete).Methods(http.MethodDelete)
+	router.HandleFunc("/branch", h.handleModify).Methods(http.MethodPatch)
+
+	router.HandleFunc("/branch", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/branch/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.RegisterBranchPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	if !user.Owner {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only the owner can manage the branches"))
+		return
+	}
+
+	_, err = h.branchStore.GetBranchByCode(payload.Code)
+	if err == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch %s already exists", payload.Code))
+		return
+	}
+
+	err = h.branchStore.CreateBranch(types.Branch{
+		Code:                 payload.Code,
+		Name:                 payload.Name,
+		Address:              payload.Address,
+		PhoneNumber:          payload.PhoneNumber,
+		LastModifiedByUserID: sql.NullInt64{Int64: int64(user.ID), Valid: true},
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("branch %s successfully created by %s", payload.Code, user.Name))
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	vars := mux.Vars(r)
+	val := vars["val"]
+
+	var branches []types.Branch
+
+	if val == "all" {
+		branches, err = h.branchStore.GetAllBranches()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		id, err := strconv.Atoi(val)
+		if err != nil {
+			branch, err := h.branchStore.GetBranchByCode(val)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch %s not found", val))
+				return
+			}
+
+			branches = append(branches, *branch)
+		} else {
+			branch, err := h.branchStore.GetBranchByID(id)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch id %d not found", id))
+				return
+			}
+
+			branches = append(branches, *branch)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, branches)
+}
+
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DeleteBranchPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	if !user.Owner {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only the owner can manage the branches"))
+		return
+	}
+
+	if payload.ID == constants.BRANCH_DEFAULT_ID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cannot delete the initial branch"))
+		return
+	}
+
+	branch, err := h.branchStore.GetBranchByID(payload.ID)
+	if branch == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("branch id %d doesn't exist", payload.ID))
+		return
+	}
+
+	// the users have to be moved first
+	users, err := h.userStore.GetAllUsers()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, branchUser := range users {
+		if branchUser.BranchID == branch.ID {
+			utils.WriteError(w, http.StatusBadRequest,
+				fmt.Errorf("branch %s still has user %s", branch.Code, branchUser.Name))
+			return
+		}
+	}
+
+	err = h.branchStore.DeleteBranch(branch, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("branch %s deleted by %s", branch.Code, user.Name))
+}
+
+func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ModifyBranchPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	if !user.Owner {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only the owner can manage the branches"))
+		return
+	}
+
+	branch, err := h.branchStore.GetBranchByID(payload.ID)
+	if err != nil || branch == nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("branch with id %d doesn't exists", payload.ID))
+		return
+	}
+
+	if branch.Code != payload.NewData.Code {
+		_, err = h.branchStore.GetBranchByCode(payload.NewData.Code)
+		if err == nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch %s already exists", payload.NewData.Code))
+			return
+		}
+	}
+
+	err = h.branchStore.ModifyBranch(branch.ID, types.Branch{
+		Code:        payload.NewData.Code,
+		Name:        payload.NewData.Name,
+		Address:     payload.NewData.Address,
+		PhoneNumber: payload.NewData.PhoneNumber,
+	}, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("branch %s modified by %s", payload.NewData.Code, user.Name))
+}
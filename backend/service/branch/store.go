@@ -0,0 +1,182 @@
+package branch
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetBranchByID(id int) (*types.Branch, error) {
+	query := "SELECT * FROM branch WHERE id = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	branch := new(types.Branch)
+
+	for rows.Next() {
+		branch, err = scanRowIntoBranch(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if branch.ID == 0 {
+		return nil, fmt.Errorf("branch not found")
+	}
+
+	return branch, nil
+}
+
+func (s *Store) GetBranchByCode(code string) (*types.Branch, error) {
+	query := "SELECT * FROM branch WHERE code = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	branch := new(types.Branch)
+
+	for rows.Next() {
+		branch, err = scanRowIntoBranch(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if branch.ID == 0 {
+		return nil, fmt.Errorf("branch not found")
+	}
+
+	return branch, nil
+}
+
+func (s *Store) GetAllBranches() ([]types.Branch, error) {
+	rows, err := s.db.Query("SELECT * FROM branch WHERE deleted_at IS NULL ORDER BY code ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	branches := make([]types.Branch, 0)
+
+	for rows.Next() {
+		branch, err := scanRowIntoBranch(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		branches = append(branches, *branch)
+	}
+
+	return branches, nil
+}
+
+func (s *Store) CreateBranch(branch types.Branch) error {
+	values := "?"
+	for i := 0; i < 4; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO branch (
+		code, name, address, phone_number, last_modified_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		branch.Code, branch.Name, branch.Address, branch.PhoneNumber,
+		branch.LastModifiedByUserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteBranch(branch *types.Branch, user *types.User) error {
+	data, err := s.GetBranchByID(branch.ID)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("delete", "branch", user.Name, data.ID, data)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := "UPDATE branch SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
+	_, err = s.db.Exec(query, time.Now(), user.ID, branch.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) ModifyBranch(id int, branch types.Branch, user *types.User) error {
+	data, err := s.GetBranchByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("modify", "branch", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE branch SET
+		code = ?, name = ?, address = ?, phone_number = ?,
+		last_modified = ?, last_modified_by_user_id = ?
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		branch.Code, branch.Name, branch.Address, branch.PhoneNumber,
+		time.Now(), user.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanRowIntoBranch(rows *sql.Rows) (*types.Branch, error) {
+	branch := new(types.Branch)
+
+	err := rows.Scan(
+		&branch.ID,
+		&branch.Code,
+		&branch.Name,
+		&branch.Address,
+		&branch.PhoneNumber,
+		&branch.CreatedAt,
+		&branch.LastModified,
+		&branch.LastModifiedByUserID,
+		&branch.DeletedAt,
+		&branch.DeletedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	branch.CreatedAt = branch.CreatedAt.Local()
+	branch.LastModified = branch.LastModified.Local()
+
+	return branch, nil
+}
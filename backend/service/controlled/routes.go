@@ -0,0 +1,252 @@
+package controlled
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+	"github.com/nicolaics/pharmacon/utils/pdf"
+)
+
+type Handler struct {
+	registerStore   types.ControlledSubstanceStore
+	userStore       types.UserStore
+	branchStore     types.BranchStore
+	settingStore    types.SettingStore
+	documentStorage types.DocumentStorage
+}
+
+func NewHandler(registerStore types.ControlledSubstanceStore, userStore types.UserStore, branchStore types.BranchStore, settingStore types.SettingStore, documentStorage types.DocumentStorage) *Handler {
+	return &Handler{registerStore: registerStore, userStore: userStore, branchStore: branchStore, settingStore: settingStore, documentStorage: documentStorage}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/controlled-substance/register", h.handleGetRegister).Methods(http.MethodPost)
+	router.HandleFunc("/controlled-substance/report", h.handleGetReport).Methods(http.MethodPost)
+	router.HandleFunc("/controlled-substance/report/pdf", h.handlePrintReport).Methods(http.MethodPost)
+	router.HandleFunc("/controlled-substance/report/sipnap", h.handleExportSIPNAP).Methods(http.MethodPost)
+
+	router.HandleFunc("/controlled-substance/register", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/controlled-substance/report", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/controlled-substance/report/pdf", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/controlled-substance/report/sipnap", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleGetRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewControlledSubstanceRegisterPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	startDate, err := utils.ParseStartDate(payload.StartDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error parsing start date: %v", err))
+		return
+	}
+
+	endDate, err := utils.ParseEndDate(payload.EndDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error parsing end date: %v", err))
+		return
+	}
+
+	entries, err := h.registerStore.GetRegisterEntries(*startDate, *endDate, payload.MedicineID, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, entries)
+}
+
+func (h *Handler) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewControlledSubstanceReportPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	report, err := getMonthlyReport(h, payload, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) handlePrintReport(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewControlledSubstanceReportPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	report, err := getMonthlyReport(h, payload, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	company, err := utils.GetBranchCompanySetting(h.settingStore, h.branchStore, h.documentStorage, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	fileName, err := pdf.CreateControlledSubstanceReportPDF(*report, payload.ControlledClass, company, h.documentStorage)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create controlled substance report pdf: %v", err))
+		return
+	}
+
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_CONTROLLED_SUBSTANCE, fileName)
+}
+
+func (h *Handler) handleExportSIPNAP(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewControlledSubstanceReportPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	report, err := getMonthlyReport(h, payload, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("sipnap-%d-%02d.csv", payload.Year, payload.Month)
+
+	attachment := fmt.Sprintf("attachment; filename=%s", fileName)
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", attachment)
+	w.WriteHeader(http.StatusOK)
+
+	err = utils.WriteSIPNAPReport(w, *report)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// the report covers the whole month, filtered by the class if given
+func getMonthlyReport(h *Handler, payload types.ViewControlledSubstanceReportPayload, branchId int) (*types.ControlledSubstanceReportReturnPayload, error) {
+	startDate := time.Date(payload.Year, time.Month(payload.Month), 1, 0, 0, 0, 0, time.Local)
+	endDate := startDate.AddDate(0, 1, 0)
+
+	items, err := h.registerStore.GetControlledSubstanceReport(startDate, endDate, branchId)
+	if err != nil {
+		return nil, err
+	}
+
+	filteredItems := make([]types.ControlledSubstanceReportItem, 0)
+
+	for _, item := range items {
+		if payload.ControlledClass == "" || item.ControlledClass == payload.ControlledClass {
+			filteredItems = append(filteredItems, item)
+		}
+	}
+
+	return &types.ControlledSubstanceReportReturnPayload{
+		Month:     payload.Month,
+		Year:      payload.Year,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Items:     filteredItems,
+	}, nil
+}
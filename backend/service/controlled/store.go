@@ -0,0 +1,210 @@
+package controlled
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateRegisterEntry(entry types.ControlledSubstanceRegister) error {
+	values := "?"
+	for i := 0; i < 13; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO controlled_substance_register (
+		medicine_id, transaction_type, reference_id, reference_number, transaction_date,
+		qty_in, qty_out, party_name, patient_name, patient_address,
+		doctor_name, prescription_number, user_id, branch_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		entry.MedicineID, entry.TransactionType, entry.ReferenceID, entry.ReferenceNumber,
+		entry.TransactionDate, entry.QtyIn, entry.QtyOut, entry.PartyName, entry.PatientName,
+		entry.PatientAddress, entry.DoctorName, entry.PrescriptionNumber, entry.UserID,
+		entry.BranchID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteRegisterEntriesByReference(transactionType string, referenceId int) error {
+	_, err := s.db.Exec("DELETE FROM controlled_substance_register WHERE transaction_type = ? AND reference_id = ?",
+		transactionType, referenceId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetRegisterEntries(startDate time.Time, endDate time.Time, medicineId int, branchId int) ([]types.ControlledSubstanceRegisterReturnPayload, error) {
+	query := `SELECT csr.id, med.barcode, med.name, med.controlled_class, unit.name,
+					csr.transaction_type, csr.reference_number, csr.transaction_date,
+					csr.qty_in, csr.qty_out, csr.party_name, csr.patient_name,
+					csr.patient_address, csr.doctor_name, csr.prescription_number,
+					user.name, csr.created_at
+					FROM controlled_substance_register AS csr
+					JOIN medicine AS med ON med.id = csr.medicine_id
+					JOIN unit ON unit.id = med.first_unit_id
+					JOIN user ON user.id = csr.user_id
+					WHERE csr.transaction_date >= ? AND csr.transaction_date < ? `
+
+	args := []interface{}{startDate, endDate}
+
+	if medicineId != 0 {
+		query += "AND csr.medicine_id = ? "
+		args = append(args, medicineId)
+	}
+
+	if branchId != constants.BRANCH_ALL {
+		query += "AND csr.branch_id = ? "
+		args = append(args, branchId)
+	}
+
+	query += "ORDER BY med.name ASC, csr.transaction_date ASC, csr.id ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]types.ControlledSubstanceRegisterReturnPayload, 0)
+
+	for rows.Next() {
+		entry, err := scanRowIntoRegisterEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// the opening balance is calculated back from the current stock,
+// so the stock before the register was used is still counted
+// the stock of the branch is used for the opening balance, the total stock for all branches
+func (s *Store) GetControlledSubstanceReport(startDate time.Time, endDate time.Time, branchId int) ([]types.ControlledSubstanceReportItem, error) {
+	query := `SELECT med.id, med.barcode, med.name, med.controlled_class, unit.name,
+					(CASE WHEN ? = 0 THEN med.qty ELSE COALESCE(ms.qty, 0) END)
+					- COALESCE(SUM(CASE WHEN csr.transaction_date >= ?
+						THEN csr.qty_in - csr.qty_out ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_in ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_in ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_out ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_out ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_out ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_in ELSE 0 END), 0),
+					COALESCE(SUM(CASE WHEN csr.transaction_date >= ? AND csr.transaction_date < ?
+						AND csr.transaction_type = ? THEN csr.qty_out ELSE 0 END), 0)
+					FROM medicine AS med
+					JOIN unit ON unit.id = med.first_unit_id
+					LEFT JOIN medicine_stock AS ms ON ms.medicine_id = med.id AND ms.branch_id = ?
+					LEFT JOIN controlled_substance_register AS csr ON csr.medicine_id = med.id
+						AND (? = 0 OR csr.branch_id = ?)
+					WHERE med.controlled_class <> ''
+					AND med.deleted_at IS NULL
+					GROUP BY med.id, med.barcode, med.name, med.controlled_class, unit.name, med.qty, ms.qty
+					ORDER BY med.controlled_class ASC, med.name ASC`
+
+	rows, err := s.db.Query(query, branchId, startDate,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_PURCHASE,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_PRODUCTION,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_DISPENSE,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_SALE,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_PRODUCTION,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_TRANSFER,
+		startDate, endDate, constants.CONTROLLED_TRANSACTION_TRANSFER,
+		branchId, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]types.ControlledSubstanceReportItem, 0)
+
+	for rows.Next() {
+		item := new(types.ControlledSubstanceReportItem)
+
+		err := rows.Scan(
+			&item.MedicineID,
+			&item.MedicineBarcode,
+			&item.MedicineName,
+			&item.ControlledClass,
+			&item.Unit,
+			&item.OpeningBalance,
+			&item.InPurchase,
+			&item.InProduction,
+			&item.OutPrescription,
+			&item.OutSale,
+			&item.OutProduction,
+			&item.InTransfer,
+			&item.OutTransfer,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		item.TotalIn = item.InPurchase + item.InProduction + item.InTransfer
+		item.TotalOut = item.OutPrescription + item.OutSale + item.OutProduction + item.OutTransfer
+		item.ClosingBalance = item.OpeningBalance + item.TotalIn - item.TotalOut
+
+		items = append(items, *item)
+	}
+
+	return items, nil
+}
+
+func scanRowIntoRegisterEntry(rows *sql.Rows) (*types.ControlledSubstanceRegisterReturnPayload, error) {
+	entry := new(types.ControlledSubstanceRegisterReturnPayload)
+
+	err := rows.Scan(
+		&entry.ID,
+		&entry.MedicineBarcode,
+		&entry.MedicineName,
+		&entry.ControlledClass,
+		&entry.Unit,
+		&entry.TransactionType,
+		&entry.ReferenceNumber,
+		&entry.TransactionDate,
+		&entry.QtyIn,
+		&entry.QtyOut,
+		&entry.PartyName,
+		&entry.PatientName,
+		&entry.PatientAddress,
+		&entry.DoctorName,
+		&entry.PrescriptionNumber,
+		&entry.UserName,
+		&entry.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry.TransactionDate = entry.TransactionDate.Local()
+	entry.CreatedAt = entry.CreatedAt.Local()
+
+	return entry, nil
+}
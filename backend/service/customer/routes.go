@@ -2,7 +2,7 @@ package customer
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -86,7 +86,7 @@ func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	val := vars["val"]
 
-	log.Println("customer val: ", val)
+	slog.DebugContext(r.Context(), "get customer", "val", val)
 
 	var customers []types.Customer
 
@@ -3,7 +3,6 @@ package customer
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/nicolaics/pharmacon/logger"
@@ -63,8 +62,6 @@ func (s *Store) GetCustomersBySearchName(name string) ([]types.Customer, error)
 		query = "SELECT * FROM customer WHERE name LIKE ? AND deleted_at IS NULL ORDER BY name ASC"
 		searchVal := "%"
 
-		log.Println("search val customer: ", searchVal)
-
 		for _, val := range name {
 			if string(val) != " " {
 				searchVal += (string(val) + "%")
@@ -169,13 +166,13 @@ func (s *Store) GetAllCustomers() ([]types.Customer, error) {
 }
 
 func (s *Store) DeleteCustomer(user *types.User, customer *types.Customer) error {
-	query := "UPDATE customer SET deleted_at ?, deleted_by_user_id = ? WHERE id = ?"
-	_, err := s.db.Exec(query, time.Now(), user.ID, customer.ID)
+	data, err := s.GetCustomerByID(customer.ID)
 	if err != nil {
 		return err
 	}
 
-	data, err := s.GetCustomerByID(customer.ID)
+	query := "UPDATE customer SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
+	_, err = s.db.Exec(query, time.Now(), user.ID, customer.ID)
 	if err != nil {
 		return err
 	}
@@ -208,6 +205,29 @@ func (s *Store) ModifyCustomer(id int, newName string, user *types.User) error {
 	return nil
 }
 
+func (s *Store) GetCustomersModifiedSince(since time.Time) ([]types.Customer, error) {
+	query := "SELECT * FROM customer WHERE last_modified >= ? OR deleted_at >= ? ORDER BY id ASC"
+	rows, err := s.db.Query(query, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]types.Customer, 0)
+
+	for rows.Next() {
+		customer, err := scanRowIntoCustomer(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		customers = append(customers, *customer)
+	}
+
+	return customers, nil
+}
+
 func scanRowIntoCustomer(rows *sql.Rows) (*types.Customer, error) {
 	customer := new(types.Customer)
 
@@ -217,6 +237,7 @@ func scanRowIntoCustomer(rows *sql.Rows) (*types.Customer, error) {
 		&customer.CreatedAt,
 		&customer.DeletedAt,
 		&customer.DeletedByUserID,
+		&customer.LastModified,
 	)
 
 	if err != nil {
@@ -224,6 +245,7 @@ func scanRowIntoCustomer(rows *sql.Rows) (*types.Customer, error) {
 	}
 
 	customer.CreatedAt = customer.CreatedAt.Local()
+	customer.LastModified = customer.LastModified.Local()
 
 	return customer, nil
 }
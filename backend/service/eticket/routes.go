@@ -0,0 +1,56 @@
+package eticket
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	templateStore types.EticketTemplateStore
+	userStore     types.UserStore
+}
+
+func NewHandler(templateStore types.EticketTemplateStore, userStore types.UserStore) *Handler {
+	return &Handler{templateStore: templateStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/eticket-template", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/eticket-template/reload", h.handleReload).Methods(http.MethodPost)
+
+	router.HandleFunc("/eticket-template", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/eticket-template/reload", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, h.templateStore.GetAllEticketTemplates())
+}
+
+func (h *Handler) handleReload(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	err = h.templateStore.ReloadEticketTemplates()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error reload eticket templates: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("%d eticket templates reloaded by %s",
+		len(h.templateStore.GetAllEticketTemplates()), user.Name))
+}
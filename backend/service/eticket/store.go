@@ -0,0 +1,193 @@
+package eticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// eticket templates are read from the json files in the template directory,
+// one template per file, keyed by the lower case name
+type Store struct {
+	path      string
+	mu        sync.RWMutex
+	templates map[string]types.EticketTemplate
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path, templates: make(map[string]types.EticketTemplate)}
+}
+
+func (s *Store) GetEticketTemplate(name string) (*types.EticketTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	template, ok := s.templates[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown eticket template: %s", name)
+	}
+
+	return &template, nil
+}
+
+func (s *Store) GetAllEticketTemplates() []types.EticketTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]types.EticketTemplate, 0)
+
+	for _, val := range s.templates {
+		templates = append(templates, val)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates
+}
+
+func (s *Store) ReloadEticketTemplates() error {
+	files, err := filepath.Glob(filepath.Join(s.path, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no eticket template found in %s", s.path)
+	}
+
+	newTemplates := make(map[string]types.EticketTemplate)
+
+	for _, fileName := range files {
+		template, err := readTemplate(fileName)
+		if err != nil {
+			return fmt.Errorf("%s: %v", filepath.Base(fileName), err)
+		}
+
+		key := strings.ToLower(template.Name)
+		if _, ok := newTemplates[key]; ok {
+			return fmt.Errorf("%s: duplicate eticket template %s", filepath.Base(fileName), template.Name)
+		}
+
+		newTemplates[key] = *template
+	}
+
+	s.mu.Lock()
+	s.templates = newTemplates
+	s.mu.Unlock()
+
+	return nil
+}
+
+func readTemplate(fileName string) (*types.EticketTemplate, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	template := new(types.EticketTemplate)
+
+	err = json.NewDecoder(file).Decode(template)
+	if err != nil {
+		return nil, err
+	}
+
+	// the file name is the template name if it is not given
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		template.Name = strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	}
+
+	err = setTemplateDefaults(template)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func setTemplateDefaults(template *types.EticketTemplate) error {
+	if template.Width <= 0 || template.Height <= 0 {
+		return fmt.Errorf("width and height must be greater than 0")
+	}
+
+	template.Variant = strings.ToUpper(strings.TrimSpace(template.Variant))
+	if template.Variant == "" {
+		template.Variant = constants.ETIX_VARIANT_WHITE
+	}
+	if template.Variant != constants.ETIX_VARIANT_WHITE && template.Variant != constants.ETIX_VARIANT_BLUE {
+		return fmt.Errorf("unknown variant %s", template.Variant)
+	}
+
+	if template.Margin <= 0 {
+		template.Margin = constants.ETIX_MARGIN
+	}
+	if template.FontSize <= 0 {
+		template.FontSize = constants.ETIX_STD_FONT_SZ
+	}
+	if template.CellHeight <= 0 {
+		template.CellHeight = constants.ETIX_STD_CELL_HEIGHT
+	}
+
+	if template.FontFamily == "" {
+		template.FontFamily = "Arial"
+	}
+	if len(template.Fonts) == 0 {
+		template.Fonts = []types.EticketTemplateFont{
+			{Style: constants.REGULAR, File: "Arial.TTF"},
+			{Style: constants.BOLD, File: "ArialBD.TTF"},
+		}
+	}
+
+	if len(template.Rows) == 0 {
+		return fmt.Errorf("template has no rows")
+	}
+
+	var totalHeight float64
+
+	for i := range template.Rows {
+		row := &template.Rows[i]
+
+		switch row.Field {
+		case constants.ETIX_FIELD_NUMBER, constants.ETIX_FIELD_DATE:
+			if row.Align == "" {
+				row.Align = "L"
+			}
+		case constants.ETIX_FIELD_PATIENT_NAME, constants.ETIX_FIELD_USAGE,
+			constants.ETIX_FIELD_DOSE, constants.ETIX_FIELD_CONSUME_TIME, constants.ETIX_FIELD_INSTRUCTION,
+			constants.ETIX_FIELD_BADGES, constants.ETIX_FIELD_QTY, constants.ETIX_FIELD_TEXT, constants.ETIX_FIELD_FOOTER:
+			if row.Align == "" {
+				row.Align = "C"
+			}
+		default:
+			return fmt.Errorf("row %d: unknown field %s", (i + 1), row.Field)
+		}
+
+		if row.FontSize <= 0 {
+			row.FontSize = template.FontSize
+		}
+		if row.CellHeight <= 0 {
+			row.CellHeight = template.CellHeight
+		}
+		if row.Lines <= 0 {
+			row.Lines = 1
+		}
+
+		totalHeight += row.CellHeight * float64(row.Lines)
+	}
+
+	if totalHeight > (template.Height - (2 * template.Margin)) {
+		return fmt.Errorf("rows height %.2f exceeds the label height", totalHeight)
+	}
+
+	return nil
+}
@@ -0,0 +1,415 @@
+package event
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	eventStore types.EventStore
+	userStore  types.UserStore
+}
+
+func NewHandler(eventStore types.EventStore, userStore types.UserStore) *Handler {
+	return &Handler{eventStore: eventStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/webhook", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/webhook/{val}", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/webhook", h.handleDelete).Methods(http.MethodDelete)
+	router.HandleFunc("/webhook", h.handleModify).Methods(http.MethodPatch)
+
+	router.HandleFunc("/webhook/delivery", h.handleGetDeliveries).Methods(http.MethodPost)
+	router.HandleFunc("/webhook/delivery/detail", h.handleGetDeliveryDetail).Methods(http.MethodPost)
+	router.HandleFunc("/webhook/delivery/retry", h.handleRetryDelivery).Methods(http.MethodPatch)
+
+	router.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/webhook/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/webhook/delivery/detail", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/webhook/delivery/retry", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.RegisterWebhookPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	err = checkEventTypes(payload.EventTypes)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err = h.eventStore.GetWebhookByURL(payload.URL)
+	if err == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("webhook %s already exists", payload.URL))
+		return
+	}
+
+	secret := payload.Secret
+	if secret == "" {
+		secret, err = utils.GenerateWebhookSecret()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generate webhook secret: %v", err))
+			return
+		}
+	}
+
+	err = h.eventStore.CreateWebhook(types.Webhook{
+		Name:                 payload.Name,
+		URL:                  payload.URL,
+		Secret:               secret,
+		EventTypes:           utils.JoinEventTypes(payload.EventTypes),
+		Active:               payload.Active,
+		LastModifiedByUserID: user.ID,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the secret can't be seen again after this
+	utils.WriteJSON(w, http.StatusCreated, map[string]string{
+		"message": fmt.Sprintf("webhook %s successfully created by %s", payload.Name, user.Name),
+		"secret":  secret,
+	})
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	vars := mux.Vars(r)
+	val := vars["val"]
+
+	var webhooks []types.Webhook
+
+	if val == "all" {
+		webhooks, err = h.eventStore.GetAllWebhooks()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		id, err := strconv.Atoi(val)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid webhook id %s", val))
+			return
+		}
+
+		webhook, err := h.eventStore.GetWebhookByID(id)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("webhook id %d not found", id))
+			return
+		}
+
+		webhooks = append(webhooks, *webhook)
+	}
+
+	returnPayload := make([]types.WebhookReturnPayload, 0)
+
+	for _, webhook := range webhooks {
+		lastModifiedUser, err := h.userStore.GetUserByID(webhook.LastModifiedByUserID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		returnPayload = append(returnPayload, types.WebhookReturnPayload{
+			ID:                     webhook.ID,
+			Name:                   webhook.Name,
+			URL:                    webhook.URL,
+			EventTypes:             utils.SplitEventTypes(webhook.EventTypes),
+			Active:                 webhook.Active,
+			CreatedAt:              webhook.CreatedAt,
+			LastModified:           webhook.LastModified,
+			LastModifiedByUserName: lastModifiedUser.Name,
+		})
+	}
+
+	utils.WriteJSON(w, http.StatusOK, returnPayload)
+}
+
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DeleteWebhookPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	webhook, err := h.eventStore.GetWebhookByID(payload.ID)
+	if webhook == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("webhook id %d doesn't exist", payload.ID))
+		return
+	}
+
+	err = h.eventStore.DeleteWebhook(webhook, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("webhook %s deleted by %s", webhook.Name, user.Name))
+}
+
+func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ModifyWebhookPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	err = checkEventTypes(payload.NewData.EventTypes)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	webhook, err := h.eventStore.GetWebhookByID(payload.ID)
+	if err != nil || webhook == nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("webhook with id %d doesn't exists", payload.ID))
+		return
+	}
+
+	if webhook.URL != payload.NewData.URL {
+		_, err = h.eventStore.GetWebhookByURL(payload.NewData.URL)
+		if err == nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("webhook %s already exists", payload.NewData.URL))
+			return
+		}
+	}
+
+	// empty secret keeps the old one
+	secret := webhook.Secret
+	if payload.NewData.Secret != "" {
+		secret = payload.NewData.Secret
+	}
+
+	err = h.eventStore.ModifyWebhook(webhook.ID, types.Webhook{
+		Name:       payload.NewData.Name,
+		URL:        payload.NewData.URL,
+		Secret:     secret,
+		EventTypes: utils.JoinEventTypes(payload.NewData.EventTypes),
+		Active:     payload.NewData.Active,
+	}, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("webhook %s modified by %s", payload.NewData.Name, user.Name))
+}
+
+func (h *Handler) handleGetDeliveries(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewWebhookDeliveryPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	startDate, err := utils.ParseStartDate(payload.StartDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	endDate, err := utils.ParseEndDate(payload.EndDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	deliveries, err := h.eventStore.GetDeliveriesByDate(*startDate, *endDate, payload.WebhookID, payload.Status)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *Handler) handleGetDeliveryDetail(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewWebhookDeliveryDetailPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	delivery, err := h.eventStore.GetDeliveryByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("webhook delivery id %d not found", payload.ID))
+		return
+	}
+
+	deliveryLogs, err := h.eventStore.GetDeliveryLogs(delivery.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.WebhookDeliveryDetailPayload{
+		Delivery: *delivery,
+		Logs:     deliveryLogs,
+	})
+}
+
+func (h *Handler) handleRetryDelivery(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewWebhookDeliveryDetailPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	delivery, err := h.eventStore.GetDeliveryByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("webhook delivery id %d not found", payload.ID))
+		return
+	}
+
+	if delivery.Status != constants.WEBHOOK_DELIVERY_FAILED {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("only failed delivery can be retried, status is %s", delivery.Status))
+		return
+	}
+
+	err = h.eventStore.RetryDelivery(delivery.ID, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("webhook delivery %d queued again by %s", delivery.ID, user.Name))
+}
+
+func checkEventTypes(eventTypes []string) error {
+	validTypes := map[string]bool{
+		constants.EVENT_INVOICE_CREATED:           true,
+		constants.EVENT_INVOICE_MODIFIED:          true,
+		constants.EVENT_INVOICE_DELETED:           true,
+		constants.EVENT_PRESCRIPTION_CREATED:      true,
+		constants.EVENT_PRESCRIPTION_MODIFIED:     true,
+		constants.EVENT_PRESCRIPTION_DELETED:      true,
+		constants.EVENT_PURCHASE_INVOICE_CREATED:  true,
+		constants.EVENT_PURCHASE_INVOICE_MODIFIED: true,
+		constants.EVENT_PURCHASE_INVOICE_DELETED:  true,
+	}
+
+	for _, eventType := range eventTypes {
+		if !validTypes[eventType] {
+			return fmt.Errorf("unknown event type %s", eventType)
+		}
+	}
+
+	return nil
+}
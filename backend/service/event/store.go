@@ -0,0 +1,541 @@
+package event
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateEvent(event types.Event) error {
+	values := "?"
+	for i := 0; i < 6; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO event_outbox (
+		uuid, event_type, entity, entity_id, branch_id, user_id, payload
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		event.UUID, event.EventType, event.Entity, event.EntityID,
+		event.BranchID, event.UserID, event.Payload)
+	if err != nil {
+		return err
+	}
+
+	// one delivery for every active webhook that subscribes to the event type
+	query = `INSERT INTO webhook_delivery (event_id, webhook_id, next_attempt_at)
+				SELECT e.id, wh.id, ?
+				FROM event_outbox AS e
+				JOIN webhook AS wh
+				WHERE e.uuid = ?
+				AND wh.active = TRUE AND wh.deleted_at IS NULL
+				AND (wh.event_types = '' OR FIND_IN_SET(e.event_type, wh.event_types) > 0)`
+
+	_, err = s.db.Exec(query, time.Now(), event.UUID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetWebhookByID(id int) (*types.Webhook, error) {
+	query := "SELECT * FROM webhook WHERE id = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhook := new(types.Webhook)
+
+	for rows.Next() {
+		webhook, err = scanRowIntoWebhook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if webhook.ID == 0 {
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	return webhook, nil
+}
+
+func (s *Store) GetWebhookByURL(url string) (*types.Webhook, error) {
+	query := "SELECT * FROM webhook WHERE url = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhook := new(types.Webhook)
+
+	for rows.Next() {
+		webhook, err = scanRowIntoWebhook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if webhook.ID == 0 {
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	return webhook, nil
+}
+
+func (s *Store) GetAllWebhooks() ([]types.Webhook, error) {
+	rows, err := s.db.Query("SELECT * FROM webhook WHERE deleted_at IS NULL ORDER BY name ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]types.Webhook, 0)
+
+	for rows.Next() {
+		webhook, err := scanRowIntoWebhook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, *webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Store) CreateWebhook(webhook types.Webhook) error {
+	values := "?"
+	for i := 0; i < 5; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO webhook (
+		name, url, secret, event_types, active, last_modified_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		webhook.Name, webhook.URL, webhook.Secret, webhook.EventTypes,
+		webhook.Active, webhook.LastModifiedByUserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) ModifyWebhook(id int, webhook types.Webhook, user *types.User) error {
+	data, err := s.GetWebhookByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("modify", "webhook", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE webhook SET
+		name = ?, url = ?, secret = ?, event_types = ?, active = ?,
+		last_modified = ?, last_modified_by_user_id = ?
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		webhook.Name, webhook.URL, webhook.Secret, webhook.EventTypes, webhook.Active,
+		time.Now(), user.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteWebhook(webhook *types.Webhook, user *types.User) error {
+	data, err := s.GetWebhookByID(webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("delete", "webhook", user.Name, data.ID, data)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := "UPDATE webhook SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
+	_, err = s.db.Exec(query, time.Now(), user.ID, webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetDueDeliveries(now time.Time, limit int) ([]types.WebhookDeliveryJob, error) {
+	query := `SELECT wd.id, wd.event_id, wd.webhook_id, wd.status, wd.attempt,
+					wd.next_attempt_at, wd.last_attempt_at, wd.delivered_at, wd.created_at,
+					e.id, e.uuid, e.event_type, e.entity, e.entity_id,
+					e.branch_id, e.user_id, e.payload, e.created_at,
+					wh.url, wh.secret
+					FROM webhook_delivery AS wd
+					JOIN event_outbox AS e ON wd.event_id = e.id
+					JOIN webhook AS wh ON wd.webhook_id = wh.id
+					WHERE wd.status = ? AND wd.next_attempt_at <= ?
+					AND wh.active = TRUE AND wh.deleted_at IS NULL
+					ORDER BY wd.next_attempt_at ASC, wd.id ASC
+					LIMIT ?`
+
+	rows, err := s.db.Query(query, constants.WEBHOOK_DELIVERY_PENDING, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]types.WebhookDeliveryJob, 0)
+
+	for rows.Next() {
+		job, err := scanRowIntoWebhookDeliveryJob(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, nil
+}
+
+func (s *Store) ClaimDelivery(deliveryId int, nextAttemptAt time.Time, leaseUntil time.Time) (bool, error) {
+	query := `UPDATE webhook_delivery SET next_attempt_at = ?
+				WHERE id = ? AND status = ? AND next_attempt_at <= ?`
+
+	result, err := s.db.Exec(query, leaseUntil, deliveryId, constants.WEBHOOK_DELIVERY_PENDING, nextAttemptAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return (affected == 1), nil
+}
+
+func (s *Store) UpdateDeliveryResult(deliveryId int, status string, attempt int, attemptedAt time.Time, nextAttemptAt time.Time) error {
+	var deliveredAt sql.NullTime
+	if status == constants.WEBHOOK_DELIVERY_DELIVERED {
+		deliveredAt = sql.NullTime{Time: attemptedAt, Valid: true}
+	}
+
+	query := `UPDATE webhook_delivery SET
+		status = ?, attempt = ?, last_attempt_at = ?,
+		next_attempt_at = ?, delivered_at = ?
+	WHERE id = ?`
+
+	_, err := s.db.Exec(query, status, attempt, attemptedAt, nextAttemptAt, deliveredAt, deliveryId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateDeliveryLog(deliveryLog types.WebhookDeliveryLog) error {
+	values := "?"
+	for i := 0; i < 6; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO webhook_delivery_log (
+		webhook_delivery_id, attempt, response_status, response_body,
+		error, duration_ms, attempted_at
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		deliveryLog.WebhookDeliveryID, deliveryLog.Attempt, deliveryLog.ResponseStatus,
+		deliveryLog.ResponseBody, deliveryLog.Error, deliveryLog.DurationMs,
+		deliveryLog.AttemptedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetDeliveriesByDate(startDate time.Time, endDate time.Time, webhookId int, status string) ([]types.WebhookDeliveryReturnPayload, error) {
+	query := `SELECT wd.id, e.uuid, e.event_type, e.entity_id,
+					wh.name, wd.status, wd.attempt,
+					wd.next_attempt_at, wd.last_attempt_at, wd.delivered_at, wd.created_at
+					FROM webhook_delivery AS wd
+					JOIN event_outbox AS e ON wd.event_id = e.id
+					JOIN webhook AS wh ON wd.webhook_id = wh.id
+					WHERE wd.created_at >= ? AND wd.created_at < ?
+					AND (? = 0 OR wd.webhook_id = ?)
+					AND (? = '' OR wd.status = ?)
+					ORDER BY wd.created_at DESC`
+
+	rows, err := s.db.Query(query, startDate, endDate, webhookId, webhookId, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]types.WebhookDeliveryReturnPayload, 0)
+
+	for rows.Next() {
+		delivery, err := scanRowIntoWebhookDeliveryLists(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Store) GetDeliveryByID(id int) (*types.WebhookDelivery, error) {
+	query := "SELECT * FROM webhook_delivery WHERE id = ?"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delivery := new(types.WebhookDelivery)
+
+	for rows.Next() {
+		delivery, err = scanRowIntoWebhookDelivery(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if delivery.ID == 0 {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+
+	return delivery, nil
+}
+
+func (s *Store) GetDeliveryLogs(deliveryId int) ([]types.WebhookDeliveryLog, error) {
+	query := "SELECT * FROM webhook_delivery_log WHERE webhook_delivery_id = ? ORDER BY attempted_at ASC, id ASC"
+	rows, err := s.db.Query(query, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveryLogs := make([]types.WebhookDeliveryLog, 0)
+
+	for rows.Next() {
+		deliveryLog, err := scanRowIntoWebhookDeliveryLog(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveryLogs = append(deliveryLogs, *deliveryLog)
+	}
+
+	return deliveryLogs, nil
+}
+
+func (s *Store) RetryDelivery(deliveryId int, user *types.User) error {
+	data, err := s.GetDeliveryByID(deliveryId)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("modify", "webhook-delivery", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	// the log of the previous attempts is kept
+	query := `UPDATE webhook_delivery SET
+		status = ?, attempt = 0, next_attempt_at = ?, delivered_at = NULL
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query, constants.WEBHOOK_DELIVERY_PENDING, time.Now(), deliveryId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanRowIntoWebhook(rows *sql.Rows) (*types.Webhook, error) {
+	webhook := new(types.Webhook)
+
+	err := rows.Scan(
+		&webhook.ID,
+		&webhook.Name,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.EventTypes,
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.LastModified,
+		&webhook.LastModifiedByUserID,
+		&webhook.DeletedAt,
+		&webhook.DeletedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.CreatedAt = webhook.CreatedAt.Local()
+	webhook.LastModified = webhook.LastModified.Local()
+
+	return webhook, nil
+}
+
+func scanRowIntoWebhookDelivery(rows *sql.Rows) (*types.WebhookDelivery, error) {
+	delivery := new(types.WebhookDelivery)
+
+	err := rows.Scan(
+		&delivery.ID,
+		&delivery.EventID,
+		&delivery.WebhookID,
+		&delivery.Status,
+		&delivery.Attempt,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.NextAttemptAt = delivery.NextAttemptAt.Local()
+	delivery.CreatedAt = delivery.CreatedAt.Local()
+
+	return delivery, nil
+}
+
+func scanRowIntoWebhookDeliveryJob(rows *sql.Rows) (*types.WebhookDeliveryJob, error) {
+	job := new(types.WebhookDeliveryJob)
+
+	err := rows.Scan(
+		&job.Delivery.ID,
+		&job.Delivery.EventID,
+		&job.Delivery.WebhookID,
+		&job.Delivery.Status,
+		&job.Delivery.Attempt,
+		&job.Delivery.NextAttemptAt,
+		&job.Delivery.LastAttemptAt,
+		&job.Delivery.DeliveredAt,
+		&job.Delivery.CreatedAt,
+		&job.Event.ID,
+		&job.Event.UUID,
+		&job.Event.EventType,
+		&job.Event.Entity,
+		&job.Event.EntityID,
+		&job.Event.BranchID,
+		&job.Event.UserID,
+		&job.Event.Payload,
+		&job.Event.CreatedAt,
+		&job.URL,
+		&job.Secret,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	job.Delivery.NextAttemptAt = job.Delivery.NextAttemptAt.Local()
+	job.Delivery.CreatedAt = job.Delivery.CreatedAt.Local()
+	job.Event.CreatedAt = job.Event.CreatedAt.Local()
+
+	return job, nil
+}
+
+func scanRowIntoWebhookDeliveryLists(rows *sql.Rows) (*types.WebhookDeliveryReturnPayload, error) {
+	delivery := new(types.WebhookDeliveryReturnPayload)
+
+	var lastAttemptAt sql.NullTime
+	var deliveredAt sql.NullTime
+
+	err := rows.Scan(
+		&delivery.ID,
+		&delivery.EventUUID,
+		&delivery.EventType,
+		&delivery.EntityID,
+		&delivery.WebhookName,
+		&delivery.Status,
+		&delivery.Attempt,
+		&delivery.NextAttemptAt,
+		&lastAttemptAt,
+		&deliveredAt,
+		&delivery.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.NextAttemptAt = delivery.NextAttemptAt.Local()
+	delivery.CreatedAt = delivery.CreatedAt.Local()
+
+	if lastAttemptAt.Valid {
+		localTime := lastAttemptAt.Time.Local()
+		delivery.LastAttemptAt = &localTime
+	}
+
+	if deliveredAt.Valid {
+		localTime := deliveredAt.Time.Local()
+		delivery.DeliveredAt = &localTime
+	}
+
+	return delivery, nil
+}
+
+func scanRowIntoWebhookDeliveryLog(rows *sql.Rows) (*types.WebhookDeliveryLog, error) {
+	deliveryLog := new(types.WebhookDeliveryLog)
+
+	err := rows.Scan(
+		&deliveryLog.ID,
+		&deliveryLog.WebhookDeliveryID,
+		&deliveryLog.Attempt,
+		&deliveryLog.ResponseStatus,
+		&deliveryLog.ResponseBody,
+		&deliveryLog.Error,
+		&deliveryLog.DurationMs,
+		&deliveryLog.AttemptedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryLog.AttemptedAt = deliveryLog.AttemptedAt.Local()
+
+	return deliveryLog, nil
+}
@@ -0,0 +1,192 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+// the worker sends the deliveries in the outbox to the webhooks
+type Worker struct {
+	eventStore   types.EventStore
+	client       *http.Client
+	pollInterval time.Duration
+	maxAttempts  int
+}
+
+// timeout and pollInterval are in seconds
+func NewWorker(eventStore types.EventStore, timeout int64, pollInterval int64, maxAttempts int64) *Worker {
+	return &Worker{
+		eventStore:   eventStore,
+		client:       &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		pollInterval: time.Duration(pollInterval) * time.Second,
+		maxAttempts:  int(maxAttempts),
+	}
+}
+
+// runs until the context is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) deliverDue(ctx context.Context) {
+	now := time.Now()
+
+	jobs, err := w.eventStore.GetDueDeliveries(now, constants.WEBHOOK_DELIVERY_BATCH_SIZE)
+	if err != nil {
+		slog.Error("error get due webhook deliveries", "error", err)
+		return
+	}
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// hold the delivery so it is not sent twice if the send takes long,
+		// the lease starts at the claim, the previous sends of the batch took some of it
+		claimedAt := time.Now()
+		leaseUntil := claimedAt.Add(time.Duration(constants.WEBHOOK_DELIVERY_LEASE) * time.Second)
+		claimed, err := w.eventStore.ClaimDelivery(job.Delivery.ID, claimedAt, leaseUntil)
+		if err != nil {
+			slog.Error("error claim webhook delivery", "deliveryId", job.Delivery.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		w.deliver(ctx, job)
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, job types.WebhookDeliveryJob) {
+	attempt := job.Delivery.Attempt + 1
+	attemptedAt := time.Now()
+
+	responseStatus, responseBody, err := w.send(ctx, job)
+
+	deliveryLog := types.WebhookDeliveryLog{
+		WebhookDeliveryID: job.Delivery.ID,
+		Attempt:           attempt,
+		ResponseStatus:    responseStatus,
+		ResponseBody:      responseBody,
+		DurationMs:        time.Since(attemptedAt).Milliseconds(),
+		AttemptedAt:       attemptedAt,
+	}
+	if err != nil {
+		deliveryLog.Error = truncate(err.Error(), constants.WEBHOOK_RESPONSE_BODY_LIMIT)
+	}
+
+	logErr := w.eventStore.CreateDeliveryLog(deliveryLog)
+	if logErr != nil {
+		slog.Error("error create webhook delivery log", "deliveryId", job.Delivery.ID, "error", logErr)
+	}
+
+	status := constants.WEBHOOK_DELIVERY_DELIVERED
+	nextAttemptAt := attemptedAt
+
+	if err != nil {
+		if attempt >= w.maxAttempts {
+			status = constants.WEBHOOK_DELIVERY_FAILED
+		} else {
+			status = constants.WEBHOOK_DELIVERY_PENDING
+			nextAttemptAt = attemptedAt.Add(retryDelay(attempt))
+		}
+	}
+
+	err = w.eventStore.UpdateDeliveryResult(job.Delivery.ID, status, attempt, attemptedAt, nextAttemptAt)
+	if err != nil {
+		slog.Error("error update webhook delivery", "deliveryId", job.Delivery.ID, "error", err)
+	}
+}
+
+// the error is not nil when the webhook doesn't answer with 2xx
+func (w *Worker) send(ctx context.Context, job types.WebhookDeliveryJob) (int, string, error) {
+	envelope := types.EventEnvelope{
+		ID:         job.Event.UUID,
+		Type:       job.Event.EventType,
+		Entity:     job.Event.Entity,
+		EntityID:   job.Event.EntityID,
+		BranchID:   job.Event.BranchID,
+		UserID:     job.Event.UserID,
+		OccurredAt: job.Event.CreatedAt,
+		Data:       json.RawMessage(job.Event.Payload),
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(constants.WEBHOOK_HEADER_EVENT, job.Event.EventType)
+	req.Header.Set(constants.WEBHOOK_HEADER_EVENT_ID, job.Event.UUID)
+	req.Header.Set(constants.WEBHOOK_HEADER_DELIVERY_ID, strconv.Itoa(job.Delivery.ID))
+	req.Header.Set(constants.WEBHOOK_HEADER_TIMESTAMP, timestamp)
+	req.Header.Set(constants.WEBHOOK_HEADER_SIGNATURE, utils.SignWebhookPayload(job.Secret, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, constants.WEBHOOK_RESPONSE_BODY_LIMIT))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// the wait is doubled after every failed attempt until the max delay
+func retryDelay(attempt int) time.Duration {
+	delay := constants.WEBHOOK_RETRY_BASE_DELAY
+	for i := 1; i < attempt && delay < constants.WEBHOOK_RETRY_MAX_DELAY; i++ {
+		delay *= 2
+	}
+
+	if delay > constants.WEBHOOK_RETRY_MAX_DELAY {
+		delay = constants.WEBHOOK_RETRY_MAX_DELAY
+	}
+
+	return time.Duration(delay) * time.Second
+}
+
+func truncate(str string, limit int) string {
+	if len(str) <= limit {
+		return str
+	}
+
+	return str[:limit]
+}
@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	db              *sql.DB
+	documentStorage types.DocumentStorage
+}
+
+func NewHandler(db *sql.DB, documentStorage types.DocumentStorage) *Handler {
+	return &Handler{
+		db:              db,
+		documentStorage: documentStorage,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/healthz", h.handleHealth).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", h.handleReady).Methods(http.MethodGet)
+
+	router.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+// the process is up, nothing else is checked
+func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, types.HealthStatus{Status: constants.HEALTH_OK})
+}
+
+// the server can take requests, the database and the storages are checked
+func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), (constants.HEALTH_CHECK_TIMEOUT * time.Second))
+	defer cancel()
+
+	status := types.HealthStatus{
+		Status: constants.HEALTH_OK,
+		Checks: make(map[string]string),
+	}
+
+	checks := map[string]func(context.Context) error{
+		constants.HEALTH_CHECK_DATABASE:         h.db.PingContext,
+		constants.HEALTH_CHECK_DOCUMENT_STORAGE: func(context.Context) error { return checkDocumentStorage(h) },
+		constants.HEALTH_CHECK_LOG_STORAGE:      func(context.Context) error { return checkLogStorage() },
+	}
+
+	for name, check := range checks {
+		err := check(ctx)
+		if err != nil {
+			status.Status = constants.HEALTH_FAIL
+			status.Checks[name] = err.Error()
+			continue
+		}
+
+		status.Checks[name] = constants.HEALTH_OK
+	}
+
+	if status.Status != constants.HEALTH_OK {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, status)
+}
+
+// the probe document is written and removed, so the storage is writable
+func checkDocumentStorage(h *Handler) error {
+	_, err := h.documentStorage.SaveDocument(constants.DOCUMENT_HEALTH, constants.HEALTH_PROBE_FILE_NAME,
+		[]byte(time.Now().Format(time.RFC3339)))
+	if err != nil {
+		return fmt.Errorf("error writing document: %v", err)
+	}
+
+	err = h.documentStorage.DeleteDocument(constants.DOCUMENT_HEALTH, constants.HEALTH_PROBE_FILE_NAME)
+	if err != nil {
+		return fmt.Errorf("error deleting document: %v", err)
+	}
+
+	return nil
+}
+
+// the modify and delete logs are written here
+func checkLogStorage() error {
+	err := os.MkdirAll(constants.LOG_STORAGE_PATH, 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(constants.LOG_STORAGE_PATH, ".readyz-*")
+	if err != nil {
+		return err
+	}
+
+	file.Close()
+
+	return os.Remove(file.Name())
+}
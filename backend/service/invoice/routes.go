@@ -2,17 +2,20 @@ package invoice
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
+	"github.com/nicolaics/pharmacon/config"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
+	"github.com/nicolaics/pharmacon/utils/escpos"
 	"github.com/nicolaics/pharmacon/utils/pdf"
 )
 
@@ -23,11 +26,21 @@ type Handler struct {
 	paymentMethodStore types.PaymentMethodStore
 	medStore           types.MedicineStore
 	unitStore          types.UnitStore
+	taxStore           types.TaxStore
+	registerStore      types.ControlledSubstanceStore
+	mdmiStore          types.MainDoctorMedItemStore
+	branchStore        types.BranchStore
+	settingStore       types.SettingStore
+	documentStorage    types.DocumentStorage
+	eventStore         types.EventStore
 }
 
 func NewHandler(invoiceStore types.InvoiceStore, userStore types.UserStore,
 	custStore types.CustomerStore, paymentMethodStore types.PaymentMethodStore,
-	medStore types.MedicineStore, unitStore types.UnitStore) *Handler {
+	medStore types.MedicineStore, unitStore types.UnitStore, taxStore types.TaxStore,
+	registerStore types.ControlledSubstanceStore, mdmiStore types.MainDoctorMedItemStore,
+	branchStore types.BranchStore, settingStore types.SettingStore, documentStorage types.DocumentStorage,
+	eventStore types.EventStore) *Handler {
 	return &Handler{
 		invoiceStore:       invoiceStore,
 		userStore:          userStore,
@@ -35,6 +48,13 @@ func NewHandler(invoiceStore types.InvoiceStore, userStore types.UserStore,
 		paymentMethodStore: paymentMethodStore,
 		medStore:           medStore,
 		unitStore:          unitStore,
+		taxStore:           taxStore,
+		registerStore:      registerStore,
+		mdmiStore:          mdmiStore,
+		branchStore:        branchStore,
+		settingStore:       settingStore,
+		documentStorage:    documentStorage,
+		eventStore:         eventStore,
 	}
 }
 
@@ -47,12 +67,14 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/invoice", h.handleModify).Methods(http.MethodPatch)
 	router.HandleFunc("/invoice/print", h.handlePrint).Methods(http.MethodPost)
 	router.HandleFunc("/invoice/print-receipt", h.handlePrintReceipt).Methods(http.MethodPost)
+	router.HandleFunc("/invoice/thermal-receipt", h.handlePrintThermalReceipt).Methods(http.MethodPost)
 
 	router.HandleFunc("/invoice", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/invoice/{params}/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/invoice/detail", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/invoice/print", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/invoice/print-receipt", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/invoice/thermal-receipt", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 }
 
 func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
@@ -79,7 +101,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check customerID
-	_, err = h.custStore.GetCustomerByID(payload.CustomerID)
+	customer, err := h.custStore.GetCustomerByID(payload.CustomerID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("customer id %d not found", payload.CustomerID))
 		return
@@ -107,6 +129,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// tax is calculated from the configured tax, not from the client
+	err = utils.CalculateInvoiceTax(h.taxStore, h.medStore, &payload, *invoiceDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// no need to check for duplicates, because number will be given
 
 	newInvoice := types.Invoice{
@@ -118,6 +147,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		DiscountAmount:       payload.DiscountAmount,
 		TaxPercentage:        payload.TaxPercentage,
 		TaxAmount:            payload.TaxAmount,
+		TaxableAmount:        payload.TaxableAmount,
 		TotalPrice:           payload.TotalPrice,
 		PaidAmount:           payload.PaidAmount,
 		ChangeAmount:         payload.ChangeAmount,
@@ -125,6 +155,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		Description:          payload.Description,
 		InvoiceDate:          *invoiceDate,
 		LastModifiedByUserID: user.ID,
+		BranchID:             user.BranchID,
 	}
 	err = h.invoiceStore.CreateInvoice(newInvoice)
 	if err != nil {
@@ -132,7 +163,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get invoice id
-	invoiceId, err := h.invoiceStore.GetInvoiceID(payload.Number, payload.CustomerID, *invoiceDate)
+	invoiceId, err := h.invoiceStore.GetInvoiceID(payload.Number, payload.CustomerID, *invoiceDate, user.BranchID)
 	if err != nil {
 		errDel := h.invoiceStore.AbsoluteDeleteInvoice(newInvoice)
 		if errDel != nil {
@@ -144,6 +175,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	neededStockItems := make([]types.MedicineStockItem, 0)
+
 	for _, medicine := range payload.MedicineLists {
 		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
 		if err != nil {
@@ -206,7 +239,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = utils.CheckStock(medData, unit, medicine.Qty)
+		// the compounded medicine uses the stock of its ingredients
+		stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.Qty)
 		if err != nil {
 			errDel := h.invoiceStore.AbsoluteDeleteInvoice(newInvoice)
 			if errDel != nil {
@@ -214,9 +248,23 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("stock for %s is not enough", medicine.MedicineName))
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
+			return
+		}
+
+		neededStockItems = append(neededStockItems, stockItems...)
+	}
+
+	err = utils.CheckStockItems(h.medStore, neededStockItems, user.BranchID, nil)
+	if err != nil {
+		errDel := h.invoiceStore.AbsoluteDeleteInvoice(newInvoice)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete invoice: %v", errDel))
 			return
 		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
 	invoicePDF := types.InvoicePDFPayload{
@@ -234,7 +282,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		InvoiceDate:        *invoiceDate,
 		MedicineLists:      payload.MedicineLists,
 	}
-	invoiceFileName, err := pdf.CreateInvoicePDF(invoicePDF, h.invoiceStore, "")
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_INVOICE, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	invoiceFileName, err := pdf.CreateInvoicePDF(invoicePDF, h.invoiceStore, "", branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create invoice pdf: %v", err))
 		return
@@ -247,6 +301,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// reduce the stock
+	dispensedIngredients := make([]types.DispensedIngredient, 0)
+
 	for _, medicine := range payload.MedicineLists {
 		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
 		if err != nil {
@@ -288,7 +344,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = utils.SubtractStock(h.medStore, medData, unit, medicine.Qty, user)
+		stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.Qty)
 		if err != nil {
 			errDel := h.invoiceStore.AbsoluteDeleteInvoice(newInvoice)
 			if errDel != nil {
@@ -296,18 +352,57 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 			return
 		}
+
+		for _, stockItem := range stockItems {
+			err = utils.SubtractStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, user.BranchID, user)
+			if err != nil {
+				errDel := h.invoiceStore.AbsoluteDeleteInvoice(newInvoice)
+				if errDel != nil {
+					utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete invoice: %v", errDel))
+					return
+				}
+
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+				return
+			}
+
+			err = utils.RecordControlledSubstanceOut(h.registerStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, types.ControlledSubstanceRegister{
+				TransactionType: constants.CONTROLLED_TRANSACTION_SALE,
+				ReferenceID:     invoiceId,
+				ReferenceNumber: payload.Number,
+				TransactionDate: *invoiceDate,
+				PartyName:       customer.Name,
+				UserID:          user.ID,
+				BranchID:        user.BranchID,
+			})
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+				return
+			}
+		}
+
+		dispensedIngredients = append(dispensedIngredients, utils.GetDispensedIngredients(constants.DISPENSED_REFERENCE_INVOICE, invoiceId, medData, stockItems)...)
+	}
+
+	err = h.mdmiStore.CreateDispensedIngredients(dispensedIngredients)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving dispensed ingredients: %v", err))
+		return
 	}
 
+	publishInvoiceEvent(h, constants.EVENT_INVOICE_CREATED, invoiceId, user)
+	metrics.IncInvoicesCreated(constants.METRICS_INVOICE_ONLINE)
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("invoice %d successfully created by %s", payload.Number, user.Name))
 }
 
 // beginning of invoice page, will request here
 func (h *Handler) handleGetInvoiceNumberForToday(w http.ResponseWriter, r *http.Request) {
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -324,7 +419,7 @@ func (h *Handler) handleGetInvoiceNumberForToday(w http.ResponseWriter, r *http.
 		return
 	}
 
-	numberOfInvoices, err := h.invoiceStore.GetNumberOfInvoices(*startDate, *endDate)
+	numberOfInvoices, err := h.invoiceStore.GetNumberOfInvoices(*startDate, *endDate, user.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -351,12 +446,18 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	startDate, err := utils.ParseStartDate(payload.StartDate)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
@@ -369,8 +470,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Println("start date:", *startDate)
-	log.Println("end date:", *endDate)
+	slog.DebugContext(r.Context(), "get invoices", "startDate", *startDate, "endDate", *endDate)
 
 	vars := mux.Vars(r)
 	params := vars["params"]
@@ -379,7 +479,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 	var invoices []types.InvoiceListsReturnPayload
 
 	if val == "all" {
-		invoices, err = h.invoiceStore.GetInvoicesByDate(*startDate, *endDate)
+		invoices, err = h.invoiceStore.GetInvoicesByDate(*startDate, *endDate, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -392,7 +492,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 		}
 
 		invoice, err := h.invoiceStore.GetInvoiceByID(id)
-		if err != nil {
+		if err != nil || (branchId != constants.BRANCH_ALL && invoice.BranchID != branchId) {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invoice id %d not exist", id))
 			return
 		}
@@ -437,7 +537,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		invoices, err = h.invoiceStore.GetInvoicesByDateAndNumber(*startDate, *endDate, number)
+		invoices, err = h.invoiceStore.GetInvoicesByDateAndNumber(*startDate, *endDate, number, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -449,7 +549,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		invoices, err = h.invoiceStore.GetInvoicesByDateAndUserID(*startDate, *endDate, user.ID)
+		invoices, err = h.invoiceStore.GetInvoicesByDateAndUserID(*startDate, *endDate, user.ID, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %s doesn't create any invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 			return
@@ -461,7 +561,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		invoices, err = h.invoiceStore.GetInvoicesByDateAndCustomerID(*startDate, *endDate, customer.ID)
+		invoices, err = h.invoiceStore.GetInvoicesByDateAndCustomerID(*startDate, *endDate, customer.ID, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("customer %s doesn't have any invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 			return
@@ -473,7 +573,7 @@ func (h *Handler) handleGetInvoices(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		invoices, err = h.invoiceStore.GetInvoicesByDateAndPaymentMethodID(*startDate, *endDate, paymentMethod.ID)
+		invoices, err = h.invoiceStore.GetInvoicesByDateAndPaymentMethodID(*startDate, *endDate, paymentMethod.ID, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("payment method %s doesn't have any invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 			return
@@ -503,7 +603,7 @@ func (h *Handler) handleGetInvoiceDetail(w http.ResponseWriter, r *http.Request)
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -516,6 +616,12 @@ func (h *Handler) handleGetInvoiceDetail(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// get customer data
 	customer, err := h.custStore.GetCustomerByID(invoice.CustomerID)
 	if err != nil {
@@ -628,6 +734,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	medicineItem, err := h.invoiceStore.GetMedicineItem(payload.InvoiceID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error finding medicine item: %v", err))
@@ -646,26 +758,21 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, medicineItem := range medicineItem {
-		medData, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName))
-			return
-		}
-
-		unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_SALE, invoice.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
+	}
 
-		err = utils.AddStock(h.medStore, medData, unit, medicineItem.Qty, user)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
-			return
-		}
+	err = addDispensedStock(h, invoice, medicineItem, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
+	utils.PublishEvent(h.eventStore, constants.EVENT_INVOICE_DELETED, constants.EVENT_ENTITY_INVOICE, invoice.ID, invoice.BranchID, user,
+		map[string]interface{}{"invoice": invoice, "medicineLists": medicineItem})
+
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("invoice number %d deleted by %s", invoice.Number, user.Name))
 }
 
@@ -707,12 +814,31 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	customer, err := h.custStore.GetCustomerByID(payload.NewData.CustomerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("customer id %d not found", payload.NewData.CustomerID))
+		return
+	}
+
 	invoiceDate, err := utils.ParseDate(payload.NewData.InvoiceDate)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
 		return
 	}
 
+	// tax is calculated from the configured tax, not from the client
+	err = utils.CalculateInvoiceTax(h.taxStore, h.medStore, &payload.NewData, *invoiceDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	oldMedicineItem, err := h.invoiceStore.GetMedicineItem(invoice.ID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error finding medicine item: %v", err))
@@ -727,6 +853,7 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		DiscountAmount:       payload.NewData.DiscountAmount,
 		TaxPercentage:        payload.NewData.TaxPercentage,
 		TaxAmount:            payload.NewData.TaxAmount,
+		TaxableAmount:        payload.NewData.TaxableAmount,
 		TotalPrice:           payload.NewData.TotalPrice,
 		PaidAmount:           payload.NewData.PaidAmount,
 		ChangeAmount:         payload.NewData.ChangeAmount,
@@ -749,27 +876,21 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// reset the stock
-	for _, medicineItem := range oldMedicineItem {
-		medData, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName))
-			return
-		}
-
-		unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
+	err = addDispensedStock(h, invoice, oldMedicineItem, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
 
-		err = utils.AddStock(h.medStore, medData, unit, medicineItem.Qty, user)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
-			return
-		}
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_SALE, invoice.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
 	}
 
 	// create new medicine items
+	neededStockItems := make([]types.MedicineStockItem, 0)
+
 	for _, medicine := range payload.NewData.MedicineLists {
 		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
 		if err != nil {
@@ -808,11 +929,19 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = utils.CheckStock(medData, unit, medicine.Qty)
+		stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.Qty)
 		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("stock for %s is not enough", medicine.MedicineName))
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 			return
 		}
+
+		neededStockItems = append(neededStockItems, stockItems...)
+	}
+
+	err = utils.CheckStockItems(h.medStore, neededStockItems, invoice.BranchID, nil)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
 	invoicePDF := types.InvoicePDFPayload{
@@ -830,13 +959,21 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		InvoiceDate:        *invoiceDate,
 		MedicineLists:      payload.NewData.MedicineLists,
 	}
-	_, err = pdf.CreateInvoicePDF(invoicePDF, h.invoiceStore, invoice.PDFUrl)
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_INVOICE, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	_, err = pdf.CreateInvoicePDF(invoicePDF, h.invoiceStore, invoice.PDFUrl, branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error update invoice pdf: %v", err))
 		return
 	}
 
 	// subtract the stock
+	dispensedIngredients := make([]types.DispensedIngredient, 0)
+
 	for _, medicine := range payload.NewData.MedicineLists {
 		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
 		if err != nil {
@@ -859,13 +996,45 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = utils.SubtractStock(h.medStore, medData, unit, medicine.Qty, user)
+		stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.Qty)
 		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 			return
 		}
+
+		for _, stockItem := range stockItems {
+			err = utils.SubtractStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, invoice.BranchID, user)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+				return
+			}
+
+			err = utils.RecordControlledSubstanceOut(h.registerStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, types.ControlledSubstanceRegister{
+				TransactionType: constants.CONTROLLED_TRANSACTION_SALE,
+				ReferenceID:     invoice.ID,
+				ReferenceNumber: payload.NewData.Number,
+				TransactionDate: *invoiceDate,
+				PartyName:       customer.Name,
+				UserID:          user.ID,
+				BranchID:        invoice.BranchID,
+			})
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+				return
+			}
+		}
+
+		dispensedIngredients = append(dispensedIngredients, utils.GetDispensedIngredients(constants.DISPENSED_REFERENCE_INVOICE, invoice.ID, medData, stockItems)...)
+	}
+
+	err = h.mdmiStore.CreateDispensedIngredients(dispensedIngredients)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving dispensed ingredients: %v", err))
+		return
 	}
 
+	publishInvoiceEvent(h, constants.EVENT_INVOICE_MODIFIED, invoice.ID, user)
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("invoice modified by %s", user.Name))
 }
 
@@ -886,7 +1055,7 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -900,21 +1069,13 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pdfFile := "static/pdf/invoice/" + invoice.PDFUrl
-
-	file, err := os.Open(pdfFile)
+	err = utils.CheckBranchAccess(user, invoice.BranchID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invoice id %d file not found", payload.InvoiceID))
+		utils.WriteError(w, http.StatusForbidden, err)
 		return
 	}
-	defer file.Close()
-
-	attachment := fmt.Sprintf("attachment; filename=%s", pdfFile)
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", attachment)
-	w.WriteHeader(http.StatusOK)
 
-	http.ServeFile(w, r, pdfFile)
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_INVOICE, invoice.PDFUrl)
 }
 
 func (h *Handler) handlePrintReceipt(w http.ResponseWriter, r *http.Request) {
@@ -934,7 +1095,7 @@ func (h *Handler) handlePrintReceipt(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -948,6 +1109,12 @@ func (h *Handler) handlePrintReceipt(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	if invoice.ReceiptPDFUrl.Valid {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("receipt for this invoice has been issued"))
 		return
@@ -955,21 +1122,199 @@ func (h *Handler) handlePrintReceipt(w http.ResponseWriter, r *http.Request) {
 
 	// TODO: create the pdf files here
 
-	pdfFile := "static/pdf/invoice/receipt/" + invoice.ReceiptPDFUrl.String
-
 	// TODO: update the database with the pdf file
 
-	file, err := os.Open(pdfFile)
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_RECEIPT, invoice.ReceiptPDFUrl.String)
+}
+
+func (h *Handler) handlePrintThermalReceipt(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.PrintThermalReceiptPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// check if the invoice exists
+	invoice, err := h.invoiceStore.GetInvoiceByID(payload.InvoiceID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invoice id %d file not found", payload.ID))
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("invoice with id %d doesn't exists", payload.InvoiceID))
+		return
+	}
+
+	err = utils.CheckBranchAccess(user, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	invoicePDF, err := getInvoicePDFPayload(h, invoice)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer file.Close()
 
-	attachment := fmt.Sprintf("attachment; filename=%s", pdfFile)
-	w.Header().Set("Content-Type", "application/pdf")
+	paperWidth := payload.PaperWidth
+	if paperWidth == 0 {
+		paperWidth = int(config.Envs.ReceiptPaperWidth)
+	}
+
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_INVOICE, invoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	receipt, err := escpos.CreateInvoiceReceipt(*invoicePDF, branding, paperWidth, payload.CodeType)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create thermal receipt: %v", err))
+		return
+	}
+
+	if payload.Print {
+		if config.Envs.ReceiptPrinterAddress == "" {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("receipt printer is not set"))
+			return
+		}
+
+		err = escpos.SendToPrinter(config.Envs.ReceiptPrinterAddress, receipt)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("receipt of invoice %d printed by %s", invoice.Number, user.Name))
+		return
+	}
+
+	attachment := fmt.Sprintf("attachment; filename=receipt-%d.bin", invoice.Number)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "Content-Length,Content-Disposition")
+	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Disposition", attachment)
+	w.Header().Set("Content-Length", strconv.Itoa(len(receipt)))
 	w.WriteHeader(http.StatusOK)
 
-	http.ServeFile(w, r, pdfFile)
+	w.Write(receipt)
+}
+
+// the invoice data as it is printed in the invoice pdf
+func getInvoicePDFPayload(h *Handler, invoice *types.Invoice) (*types.InvoicePDFPayload, error) {
+	lastModifiedUser, err := h.userStore.GetUserByID(invoice.LastModifiedByUserID)
+	if err != nil {
+		return nil, fmt.Errorf("user id %d doesn't exists", invoice.LastModifiedByUserID)
+	}
+
+	medicineItems, err := h.invoiceStore.GetMedicineItem(invoice.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting medicine item: %v", err)
+	}
+
+	medicineLists := make([]types.InvoiceMedicineListsPayload, 0)
+
+	for _, medicineItem := range medicineItems {
+		medicineLists = append(medicineLists, types.InvoiceMedicineListsPayload{
+			MedicineBarcode:    medicineItem.MedicineBarcode,
+			MedicineName:       medicineItem.MedicineName,
+			Qty:                medicineItem.Qty,
+			Unit:               medicineItem.Unit,
+			Price:              medicineItem.Price,
+			DiscountPercentage: medicineItem.DiscountPercentage,
+			DiscountAmount:     medicineItem.DiscountAmount,
+			Subtotal:           medicineItem.Subtotal,
+		})
+	}
+
+	return &types.InvoicePDFPayload{
+		Number:             invoice.Number,
+		UserName:           lastModifiedUser.Name,
+		Subtotal:           invoice.Subtotal,
+		DiscountPercentage: invoice.DiscountPercentage,
+		DiscountAmount:     invoice.DiscountAmount,
+		TaxPercentage:      invoice.TaxPercentage,
+		TaxAmount:          invoice.TaxAmount,
+		TotalPrice:         invoice.TotalPrice,
+		PaidAmount:         invoice.PaidAmount,
+		ChangeAmount:       invoice.ChangeAmount,
+		Description:        invoice.Description,
+		InvoiceDate:        invoice.InvoiceDate,
+		MedicineLists:      medicineLists,
+	}, nil
+}
+
+// the invoice is read again so the event has the saved data
+func publishInvoiceEvent(h *Handler, eventType string, invoiceId int, user *types.User) {
+	invoice, err := h.invoiceStore.GetInvoiceByID(invoiceId)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "invoiceId", invoiceId, "error", err)
+		return
+	}
+
+	medicineItems, err := h.invoiceStore.GetMedicineItem(invoiceId)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "invoiceId", invoiceId, "error", err)
+		return
+	}
+
+	utils.PublishEvent(h.eventStore, eventType, constants.EVENT_ENTITY_INVOICE, invoice.ID, invoice.BranchID, user,
+		map[string]interface{}{"invoice": invoice, "medicineLists": medicineItems})
+}
+
+// the stock taken by the invoice is given back, the compounded medicines use
+// the ingredients saved when the invoice was made
+func addDispensedStock(h *Handler, invoice *types.Invoice, medicineItems []types.InvoiceMedicineItemReturnPayload, user *types.User) error {
+	dispensedItems := make([]types.MedicineStockItem, 0)
+
+	for _, medicineItem := range medicineItems {
+		medData, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
+		if err != nil {
+			return fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName)
+		}
+
+		unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
+		if err != nil || unit == nil {
+			return fmt.Errorf("unit %s not found", medicineItem.Unit)
+		}
+
+		dispensedItems = append(dispensedItems, types.MedicineStockItem{
+			Medicine: medData,
+			Unit:     unit,
+			Qty:      medicineItem.Qty,
+		})
+	}
+
+	stockItems, err := utils.GetDispensedStockItems(h.mdmiStore, h.medStore, h.unitStore, constants.DISPENSED_REFERENCE_INVOICE, invoice.ID, dispensedItems)
+	if err != nil {
+		return fmt.Errorf("error get dispensed ingredients: %v", err)
+	}
+
+	for _, stockItem := range stockItems {
+		err = utils.AddStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, invoice.BranchID, user)
+		if err != nil {
+			return fmt.Errorf("error updating stock: %v", err)
+		}
+	}
+
+	err = h.mdmiStore.DeleteDispensedIngredients(constants.DISPENSED_REFERENCE_INVOICE, invoice.ID)
+	if err != nil {
+		return fmt.Errorf("error deleting dispensed ingredients: %v", err)
+	}
+
+	return nil
 }
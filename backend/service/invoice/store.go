@@ -2,12 +2,17 @@ package invoice
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/logger"
 	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
 )
 
 type Store struct {
@@ -43,13 +48,14 @@ func (s *Store) GetInvoiceByID(id int) (*types.Invoice, error) {
 	return invoice, nil
 }
 
-func (s *Store) GetInvoiceID(number int, customerId int, invoiceDate time.Time) (int, error) {
+func (s *Store) GetInvoiceID(number int, customerId int, invoiceDate time.Time, branchId int) (int, error) {
 	query := `SELECT id FROM invoice 
 				WHERE number = ? AND customer_id = ? 
 				AND invoice_date = ? AND deleted_at IS NULL 
+				AND (? = 0 OR invoice.branch_id = ?) 
 				ORDER BY invoice_date DESC`
 
-	rows, err := s.db.Query(query, number, customerId, invoiceDate)
+	rows, err := s.db.Query(query, number, customerId, invoiceDate, branchId, branchId)
 	if err != nil {
 		return 0, err
 	}
@@ -101,7 +107,7 @@ func (s *Store) GetInvoicesByNumber(number int) ([]types.Invoice, error) {
 	return invoices, nil
 }
 
-func (s *Store) GetInvoicesByDate(startDate time.Time, endDate time.Time) ([]types.InvoiceListsReturnPayload, error) {
+func (s *Store) GetInvoicesByDate(startDate time.Time, endDate time.Time, branchId int) ([]types.InvoiceListsReturnPayload, error) {
 	query := `SELECT invoice.id, invoice.number, 
 					user.name, customer.name, 
 					invoice.subtotal, 
@@ -116,8 +122,9 @@ func (s *Store) GetInvoicesByDate(startDate time.Time, endDate time.Time) ([]typ
 					JOIN payment_method ON payment_method.id = invoice.payment_method_id 
 					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ? 
 					AND invoice.deleted_at IS NULL 
+					AND (? = 0 OR invoice.branch_id = ?) 
 				ORDER BY invoice.invoice_date DESC`
-	rows, err := s.db.Query(query, startDate, endDate)
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -137,14 +144,15 @@ func (s *Store) GetInvoicesByDate(startDate time.Time, endDate time.Time) ([]typ
 	return invoices, nil
 }
 
-func (s *Store) GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]types.InvoiceListsReturnPayload, error) {
+func (s *Store) GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]types.InvoiceListsReturnPayload, error) {
 	query := `SELECT COUNT(*) 
 				FROM invoice 
 				WHERE invoice_date >= ? AND invoice_date < ? 
 				AND number = ? 
-				AND deleted_at IS NULL`
+				AND deleted_at IS NULL 
+				AND (? = 0 OR invoice.branch_id = ?)`
 
-	row := s.db.QueryRow(query, startDate, endDate, number)
+	row := s.db.QueryRow(query, startDate, endDate, number, branchId, branchId)
 	if row.Err() != nil {
 		return nil, row.Err()
 	}
@@ -174,6 +182,7 @@ func (s *Store) GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Tim
 					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ? 
 					AND invoice.number LIKE ? 
 					AND invoice.deleted_at IS NULL 
+					AND (? = 0 OR invoice.branch_id = ?) 
 					ORDER BY invoice.invoice_date DESC`
 
 		searchVal := "%"
@@ -183,7 +192,7 @@ func (s *Store) GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Tim
 			}
 		}
 
-		rows, err := s.db.Query(query, startDate, endDate, searchVal)
+		rows, err := s.db.Query(query, startDate, endDate, searchVal, branchId, branchId)
 		if err != nil {
 			return nil, err
 		}
@@ -216,9 +225,10 @@ func (s *Store) GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Tim
 					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ? 
 					AND invoice.number = ? 
 					AND invoice.deleted_at IS NULL 
+					AND (? = 0 OR invoice.branch_id = ?) 
 					ORDER BY invoice.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, number)
+	rows, err := s.db.Query(query, startDate, endDate, number, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +247,7 @@ func (s *Store) GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Tim
 	return invoices, nil
 }
 
-func (s *Store) GetInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]types.InvoiceListsReturnPayload, error) {
+func (s *Store) GetInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]types.InvoiceListsReturnPayload, error) {
 	query := `SELECT invoice.id, invoice.number, 
 					user.name, customer.name, 
 					invoice.subtotal, 
@@ -251,10 +261,12 @@ func (s *Store) GetInvoicesByDateAndUserID(startDate time.Time, endDate time.Tim
 					JOIN customer ON customer.id = invoice.customer_id 
 					JOIN payment_method ON payment_method.id = invoice.payment_method_id 
 					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ? 
+					AND invoice.user_id = ? 
 					AND invoice.deleted_at IS NULL 
+					AND (? = 0 OR invoice.branch_id = ?) 
 				ORDER BY invoice.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uid)
+	rows, err := s.db.Query(query, startDate, endDate, uid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -274,7 +286,7 @@ func (s *Store) GetInvoicesByDateAndUserID(startDate time.Time, endDate time.Tim
 	return invoices, nil
 }
 
-func (s *Store) GetInvoicesByDateAndCustomerID(startDate time.Time, endDate time.Time, cid int) ([]types.InvoiceListsReturnPayload, error) {
+func (s *Store) GetInvoicesByDateAndCustomerID(startDate time.Time, endDate time.Time, cid int, branchId int) ([]types.InvoiceListsReturnPayload, error) {
 	query := `SELECT invoice.id, invoice.number, 
 					user.name, customer.name, 
 					invoice.subtotal, 
@@ -290,9 +302,10 @@ func (s *Store) GetInvoicesByDateAndCustomerID(startDate time.Time, endDate time
 					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ? 
 					AND customer_id = ? 
 					AND invoice.deleted_at IS NULL 
+					AND (? = 0 OR invoice.branch_id = ?) 
 					ORDER BY invoice.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, cid)
+	rows, err := s.db.Query(query, startDate, endDate, cid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -312,7 +325,7 @@ func (s *Store) GetInvoicesByDateAndCustomerID(startDate time.Time, endDate time
 	return invoices, nil
 }
 
-func (s *Store) GetInvoicesByDateAndPaymentMethodID(startDate time.Time, endDate time.Time, pmid int) ([]types.InvoiceListsReturnPayload, error) {
+func (s *Store) GetInvoicesByDateAndPaymentMethodID(startDate time.Time, endDate time.Time, pmid int, branchId int) ([]types.InvoiceListsReturnPayload, error) {
 	query := `SELECT invoice.id, invoice.number, 
 					user.name, customer.name, 
 					invoice.subtotal, 
@@ -326,10 +339,12 @@ func (s *Store) GetInvoicesByDateAndPaymentMethodID(startDate time.Time, endDate
 					JOIN customer ON customer.id = invoice.customer_id 
 					JOIN payment_method ON payment_method.id = invoice.payment_method_id 
 					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ? 
+					AND invoice.payment_method_id = ? 
 					AND invoice.deleted_at IS NULL 
+					AND (? = 0 OR invoice.branch_id = ?) 
 				ORDER BY invoice.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, pmid)
+	rows, err := s.db.Query(query, startDate, endDate, pmid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -349,9 +364,9 @@ func (s *Store) GetInvoicesByDateAndPaymentMethodID(startDate time.Time, endDate
 	return invoices, nil
 }
 
-func (s *Store) GetNumberOfInvoices(startDate time.Time, endDate time.Time) (int, error) {
-	query := `SELECT COUNT(*) FROM invoice WHERE invoice_date >= ? AND invoice_date < ?`
-	row := s.db.QueryRow(query, startDate, endDate)
+func (s *Store) GetNumberOfInvoices(startDate time.Time, endDate time.Time, branchId int) (int, error) {
+	query := `SELECT COUNT(*) FROM invoice WHERE invoice_date >= ? AND invoice_date < ? AND branch_id = ?`
+	row := s.db.QueryRow(query, startDate, endDate, branchId)
 	if row.Err() != nil {
 		return -1, row.Err()
 	}
@@ -367,28 +382,80 @@ func (s *Store) GetNumberOfInvoices(startDate time.Time, endDate time.Time) (int
 }
 
 func (s *Store) CreateInvoice(invoice types.Invoice) error {
+	_, err := s.insertInvoice(invoice)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// the unique number of the day is checked by the insert, so the number is taken again
+// if another invoice took it between the select and the insert
+func (s *Store) CreateInvoiceWithNextNumber(invoice types.Invoice) (int, int, error) {
+	startDate, err := utils.ParseStartDate(invoice.InvoiceDate.Format("2006-01-02 -0700MST"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parse start date: %v", err)
+	}
+
+	endDate, err := utils.ParseEndDate(invoice.InvoiceDate.Format("2006-01-02 -0700MST"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parse end date: %v", err)
+	}
+
+	for i := 0; i < constants.OFFLINE_INVOICE_NUMBER_ATTEMPTS; i++ {
+		query := `SELECT COALESCE(MAX(number), 0) FROM invoice WHERE invoice_date >= ? AND invoice_date < ? AND branch_id = ?`
+		err = s.db.QueryRow(query, *startDate, *endDate, invoice.BranchID).Scan(&invoice.Number)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		invoice.Number++
+
+		id, err := s.insertInvoice(invoice)
+		if isDuplicateEntry(err) {
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return id, invoice.Number, nil
+	}
+
+	return 0, 0, fmt.Errorf("no free invoice number after %d attempts", constants.OFFLINE_INVOICE_NUMBER_ATTEMPTS)
+}
+
+func (s *Store) insertInvoice(invoice types.Invoice) (int, error) {
 	values := "?"
-	for i := 0; i < 14; i++ {
+	for i := 0; i < 16; i++ {
 		values += ", ?"
 	}
 
 	query := `INSERT INTO invoice (
 			number, user_id, customer_id, subtotal, discount_percentage, discount_amount, 
 			tax_percentage, tax_amount, total_price, paid_amount, change_amount, 
-			payment_method_id, description, invoice_date, last_modified_by_user_id
+			payment_method_id, description, invoice_date, last_modified_by_user_id, branch_id,
+			taxable_amount
 	) VALUES (` + values + `)`
 
-	_, err := s.db.Exec(query,
+	res, err := s.db.Exec(query,
 		invoice.Number, invoice.UserID, invoice.CustomerID,
 		invoice.Subtotal, invoice.DiscountPercentage, invoice.DiscountAmount,
 		invoice.TaxPercentage, invoice.TaxAmount, invoice.TotalPrice,
 		invoice.PaidAmount, invoice.ChangeAmount, invoice.PaymentMethodID,
-		invoice.Description, invoice.InvoiceDate, invoice.LastModifiedByUserID)
+		invoice.Description, invoice.InvoiceDate, invoice.LastModifiedByUserID,
+		invoice.BranchID, invoice.TaxableAmount)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
 }
 
 func (s *Store) CreateMedicineItem(medicineItem types.InvoiceMedicineItem) error {
@@ -508,7 +575,7 @@ func (s *Store) ModifyInvoice(invoiceId int, invoice types.Invoice, user *types.
 	query := `UPDATE invoice SET 
 			number = ?, user_id = ?, customer_id = ?, subtotal = ?, 
 			discount_percentage = ?, discount_amount = ?, 
-			tax_percentage = ?, tax_amount = ?, 
+			tax_percentage = ?, tax_amount = ?, taxable_amount = ?, 
 			total_price = ?, paid_amount = ?, change_amount = ?, 
 			payment_method_id = ?, description = ?, invoice_date = ?, last_modified = ?,
 			last_modified_by_user_id = ? 
@@ -517,7 +584,7 @@ func (s *Store) ModifyInvoice(invoiceId int, invoice types.Invoice, user *types.
 	_, err = s.db.Exec(query,
 		invoice.Number, invoice.UserID, invoice.CustomerID,
 		invoice.Subtotal, invoice.DiscountPercentage, invoice.DiscountAmount,
-		invoice.TaxPercentage, invoice.TaxAmount,
+		invoice.TaxPercentage, invoice.TaxAmount, invoice.TaxableAmount,
 		invoice.TotalPrice, invoice.PaidAmount, invoice.ChangeAmount,
 		invoice.PaymentMethodID, invoice.Description, invoice.InvoiceDate,
 		time.Now(), invoice.LastModifiedByUserID, invoiceId)
@@ -635,6 +702,8 @@ func scanRowIntoInvoice(rows *sql.Rows) (*types.Invoice, error) {
 		&invoice.ReceiptPDFUrl,
 		&invoice.DeletedAt,
 		&invoice.DeletedByUserID,
+		&invoice.BranchID,
+		&invoice.TaxableAmount,
 	)
 
 	if err != nil {
@@ -697,3 +766,11 @@ func scanRowIntoMedicineItem(rows *sql.Rows) (*types.InvoiceMedicineItemReturnPa
 
 	return medicineItem, nil
 }
+
+// the number of the mysql error of a duplicate unique key
+const mysqlDuplicateEntry = 1062
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
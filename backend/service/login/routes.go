@@ -0,0 +1,131 @@
+package login
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	loginAttemptStore types.LoginAttemptStore
+	userStore         types.UserStore
+}
+
+func NewHandler(loginAttemptStore types.LoginAttemptStore, userStore types.UserStore) *Handler {
+	return &Handler{loginAttemptStore: loginAttemptStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/login/attempt", h.handleGetAttempts).Methods(http.MethodPost)
+	router.HandleFunc("/login/lockout", h.handleGetLockouts).Methods(http.MethodGet)
+	router.HandleFunc("/login/unlock", h.handleUnlock).Methods(http.MethodPatch)
+
+	router.HandleFunc("/login/attempt", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/login/lockout", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/login/unlock", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+// the failed logins for the audit
+func (h *Handler) handleGetAttempts(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewLoginAttemptPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	startDate, err := utils.ParseStartDate(payload.StartDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	endDate, err := utils.ParseEndDate(payload.EndDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	attempts, err := h.loginAttemptStore.GetLoginAttemptsByDate(*startDate, *endDate, payload.UserName, payload.IPAddress)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, attempts)
+}
+
+// the user names and the ip addresses that are locked now
+func (h *Handler) handleGetLockouts(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	lockouts, err := h.loginAttemptStore.GetActiveLoginLockouts()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, lockouts)
+}
+
+func (h *Handler) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.UnlockLoginPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	lockKey := payload.LockKey
+	if payload.LockType == constants.LOGIN_LOCK_USERNAME {
+		lockKey = utils.GetLoginLockKey(lockKey)
+	}
+
+	err = h.loginAttemptStore.UnlockLogin(payload.LockType, lockKey, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error unlock login: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("%s %s unlocked by %s", payload.LockType, lockKey, user.Name))
+}
@@ -0,0 +1,248 @@
+package login
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateLoginAttempt(attempt types.LoginAttempt) error {
+	values := "?"
+	for i := 0; i < 4; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO login_attempt (
+		user_name, user_id, ip_address, user_agent, reason
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		attempt.UserName, attempt.UserID, attempt.IPAddress,
+		attempt.UserAgent, attempt.Reason)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetLoginAttemptsByDate(startDate time.Time, endDate time.Time, userName string, ipAddress string) ([]types.LoginAttempt, error) {
+	query := `SELECT * FROM login_attempt
+				WHERE created_at >= ? AND created_at < ?
+				AND (? = '' OR user_name = ?)
+				AND (? = '' OR ip_address = ?)
+				ORDER BY created_at DESC`
+
+	rows, err := s.db.Query(query, startDate, endDate, userName, userName, ipAddress, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]types.LoginAttempt, 0)
+
+	for rows.Next() {
+		attempt, err := scanRowIntoLoginAttempt(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, *attempt)
+	}
+
+	return attempts, nil
+}
+
+func (s *Store) GetLoginLockout(lockType string, lockKey string) (*types.LoginLockout, error) {
+	query := "SELECT * FROM login_lockout WHERE lock_type = ? AND lock_key = ?"
+	rows, err := s.db.Query(query, lockType, lockKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockout := new(types.LoginLockout)
+
+	for rows.Next() {
+		lockout, err = scanRowIntoLoginLockout(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if lockout.ID == 0 {
+		return nil, nil
+	}
+
+	return lockout, nil
+}
+
+// made at the first failed login of the key, updated after that.
+// the failures are forgotten after window seconds without a failure or a lockout,
+// the lockout count is kept until then, so every lockout in a row is longer
+func (s *Store) AddLoginFailure(lockType string, lockKey string, now time.Time, window int64, maxAttempts int64) (bool, error) {
+	windowStart := now.Add(-time.Duration(window) * time.Second)
+
+	// mysql sets the columns in order, so last_failed_at is changed last
+	query := `INSERT INTO login_lockout (
+		lock_type, lock_key, failed_count, lockout_count, last_failed_at
+	) VALUES (?, ?, 1, 0, ?)
+	ON DUPLICATE KEY UPDATE
+		lockout_count = IF(GREATEST(last_failed_at, COALESCE(locked_until, last_failed_at)) < ?, 0, lockout_count),
+		failed_count = IF(GREATEST(last_failed_at, COALESCE(locked_until, last_failed_at)) < ?, 1, failed_count + 1),
+		last_failed_at = VALUES(last_failed_at)`
+
+	_, err := s.db.Exec(query, lockType, lockKey, now, windowStart, windowStart)
+	if err != nil {
+		return false, err
+	}
+
+	// only one of the parallel failures takes the lockout
+	query = `UPDATE login_lockout SET failed_count = 0, lockout_count = lockout_count + 1
+				WHERE lock_type = ? AND lock_key = ? AND failed_count >= ?`
+
+	result, err := s.db.Exec(query, lockType, lockKey, maxAttempts)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return (affected == 1), nil
+}
+
+func (s *Store) SetLoginLockedUntil(id int, lockedUntil time.Time) error {
+	_, err := s.db.Exec("UPDATE login_lockout SET locked_until = ? WHERE id = ?", lockedUntil, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetActiveLoginLockouts() ([]types.LoginLockout, error) {
+	query := "SELECT * FROM login_lockout WHERE locked_until > ? ORDER BY locked_until DESC"
+
+	rows, err := s.db.Query(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := make([]types.LoginLockout, 0)
+
+	for rows.Next() {
+		lockout, err := scanRowIntoLoginLockout(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lockouts = append(lockouts, *lockout)
+	}
+
+	return lockouts, nil
+}
+
+func (s *Store) ResetLoginLockout(lockType string, lockKey string) error {
+	query := `UPDATE login_lockout SET
+				failed_count = 0, lockout_count = 0, locked_until = NULL
+				WHERE lock_type = ? AND lock_key = ?`
+
+	_, err := s.db.Exec(query, lockType, lockKey)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) UnlockLogin(lockType string, lockKey string, user *types.User) error {
+	data, err := s.GetLoginLockout(lockType, lockKey)
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return fmt.Errorf("%s %s is not locked", lockType, lockKey)
+	}
+
+	err = logger.WriteLog("modify", "login-lockout", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE login_lockout SET
+				failed_count = 0, lockout_count = 0, locked_until = NULL,
+				unlocked_at = ?, unlocked_by_user_id = ?
+				WHERE id = ?`
+
+	_, err = s.db.Exec(query, time.Now(), user.ID, data.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanRowIntoLoginAttempt(rows *sql.Rows) (*types.LoginAttempt, error) {
+	attempt := new(types.LoginAttempt)
+
+	err := rows.Scan(
+		&attempt.ID,
+		&attempt.UserName,
+		&attempt.UserID,
+		&attempt.IPAddress,
+		&attempt.UserAgent,
+		&attempt.Reason,
+		&attempt.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	attempt.CreatedAt = attempt.CreatedAt.Local()
+
+	return attempt, nil
+}
+
+func scanRowIntoLoginLockout(rows *sql.Rows) (*types.LoginLockout, error) {
+	lockout := new(types.LoginLockout)
+
+	err := rows.Scan(
+		&lockout.ID,
+		&lockout.LockType,
+		&lockout.LockKey,
+		&lockout.FailedCount,
+		&lockout.LockoutCount,
+		&lockout.LockedUntil,
+		&lockout.LastFailedAt,
+		&lockout.UnlockedAt,
+		&lockout.UnlockedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lockout.LastFailedAt = lockout.LastFailedAt.Local()
+
+	return lockout, nil
+}
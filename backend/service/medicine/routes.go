@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 )
@@ -145,23 +147,51 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		ThirdDiscountAmount:        payload.ThirdDiscountAmount,
 		ThirdPrice:                 payload.ThirdPrice,
 		Description:                payload.Description,
+		IsTaxable:                  payload.IsTaxable,
+		ActiveIngredients:          strings.ToUpper(payload.ActiveIngredients),
+		ControlledClass:            payload.ControlledClass,
 	}, user.ID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create medicine %s: %v", payload.Name, err))
 		return
 	}
 
+	// the initial stock is in the branch of the user
+	medicine, err := h.medStore.GetMedicineByName(payload.Name)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.medStore.UpdateMedicineStock(medicine.ID, user.BranchID, payload.Qty, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error update stock %s: %v", payload.Name, err))
+		return
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("medicine %s successfully created by %s", payload.Name, user.Name))
 }
 
 func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
+	branchId, err := utils.ParseBranchIDQuery(r.URL.Query().Get("branchId"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	branchId, err = utils.GetBranchScope(user, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	vars := mux.Vars(r)
 	params := vars["params"]
 	val := vars["val"]
@@ -211,6 +241,19 @@ func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the qty is the total of all branches, change it into the stock of the branch
+	if branchId != constants.BRANCH_ALL {
+		stocks, err := h.medStore.GetBranchStocks(branchId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		for i := range medicines {
+			medicines[i].Qty = stocks[medicines[i].ID]
+		}
+	}
+
 	utils.WriteJSON(w, http.StatusOK, medicines)
 }
 
@@ -231,7 +274,7 @@ func (h *Handler) handleGetOne(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -245,6 +288,21 @@ func (h *Handler) handleGetOne(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the owner sees the stock of every branch
+	if user.Owner {
+		medicine.Stocks, err = h.medStore.GetMedicineStocks(medicine.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		medicine.Qty, err = h.medStore.GetMedicineStock(medicine.ID, user.BranchID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	utils.WriteJSON(w, http.StatusOK, medicine)
 }
 
@@ -422,12 +480,22 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		ThirdDiscountAmount:        payload.NewData.ThirdDiscountPercentage,
 		ThirdPrice:                 payload.NewData.ThirdPrice,
 		Description:                payload.NewData.Description,
+		IsTaxable:                  payload.NewData.IsTaxable,
+		ActiveIngredients:          strings.ToUpper(payload.NewData.ActiveIngredients),
+		ControlledClass:            payload.NewData.ControlledClass,
 	}, user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	// the qty is the stock of the branch of the user
+	err = h.medStore.UpdateMedicineStock(medicine.ID, user.BranchID, payload.NewData.Qty, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("medicine modified into %s by %s",
 		payload.NewData.Name, user.Name))
 }
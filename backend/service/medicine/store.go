@@ -58,7 +58,7 @@ func (s *Store) GetMedicineByID(id int) (*types.MedicineListsReturnPayload, erro
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -148,7 +148,7 @@ func (s *Store) GetMedicinesBySearchName(name string) ([]types.MedicineListsRetu
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -200,7 +200,7 @@ func (s *Store) GetMedicinesBySearchName(name string) ([]types.MedicineListsRetu
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -261,7 +261,7 @@ func (s *Store) GetMedicinesBySearchBarcode(barcode string) ([]types.MedicineLis
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -313,7 +313,7 @@ func (s *Store) GetMedicinesBySearchBarcode(barcode string) ([]types.MedicineLis
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -358,7 +358,7 @@ func (s *Store) GetMedicinesByDescription(description string) ([]types.MedicineL
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -398,7 +398,7 @@ func (s *Store) GetMedicinesByDescription(description string) ([]types.MedicineL
 
 func (s *Store) CreateMedicine(med types.Medicine, userId int) error {
 	values := "?"
-	for i := 0; i < 20; i++ {
+	for i := 0; i < 23; i++ {
 		values += ", ?"
 	}
 
@@ -408,7 +408,7 @@ func (s *Store) CreateMedicine(med types.Medicine, userId int) error {
 		second_subtotal, second_discount_percentage, second_discount_amount, second_price, 
 		third_unit_id, third_unit_to_first_unit_ratio, third_subtotal, 
 		third_discount_percentage, third_discount_amount, third_price, description, 
-		last_modified_by_user_id
+		is_taxable, active_ingredients, controlled_class, last_modified_by_user_id
 	) VALUES (` + values + `)`
 
 	_, err := s.db.Exec(query,
@@ -419,7 +419,7 @@ func (s *Store) CreateMedicine(med types.Medicine, userId int) error {
 		med.SecondDiscountPercentage, med.SecondDiscountAmount, med.SecondPrice,
 		med.ThirdUnitID, med.ThirdUnitToFirstUnitRatio, med.ThirdSubtotal,
 		med.ThirdDiscountPercentage, med.ThirdDiscountAmount, med.ThirdPrice,
-		med.Description, userId)
+		med.Description, med.IsTaxable, med.ActiveIngredients, med.ControlledClass, userId)
 	if err != nil {
 		return err
 	}
@@ -443,7 +443,7 @@ func (s *Store) GetAllMedicines() ([]types.MedicineListsReturnPayload, error) {
 					med.third_discount_percentage, 
 					med.third_discount_amount, 
 					med.third_price, 
-					med.description, med.created_at, 
+					med.description, med.is_taxable, med.active_ingredients, med.controlled_class, med.created_at, 
 					med.last_modified, user.name 
 					FROM medicine AS med 
 					JOIN unit AS uot ON med.first_unit_id = uot.id 
@@ -520,25 +520,25 @@ func (s *Store) ModifyMedicine(mid int, med types.Medicine, user *types.User) er
 	}
 
 	query := `UPDATE medicine SET 
-		barcode = ?, name = ?, qty = ?, 
+		barcode = ?, name = ?, 
 		first_unit_id = ?, first_subtotal = ?, first_discount_percentage = ?, 
 		first_discount_amount = ?, first_price = ?, second_unit_id = ?, 
 		second_unit_to_first_unit_ratio = ?, second_subtotal = ?, 
 		second_discount_percentage = ?, second_discount_amount = ?, second_price = ?, 
 		third_unit_id = ?, third_unit_to_first_unit_ratio = ?, third_subtotal = ?, 
 		third_discount_percentage = ?, third_discount_amount = ?, third_price = ?, 
-		description = ?, last_modified = ?, last_modified_by_user_id = ?
+		description = ?, is_taxable = ?, active_ingredients = ?, controlled_class = ?, last_modified = ?, last_modified_by_user_id = ?
 	WHERE id = ?`
 
 	_, err = s.db.Exec(query,
-		med.Barcode, med.Name, med.Qty,
+		med.Barcode, med.Name,
 		med.FirstUnitID, med.FirstSubtotal, med.FirstDiscountPercentage,
 		med.FirstDiscountAmount, med.FirstPrice, med.SecondUnitID,
 		med.SecondUnitToFirstUnitRatio, med.SecondSubtotal,
 		med.SecondDiscountPercentage, med.SecondDiscountAmount, med.SecondPrice,
 		med.ThirdUnitID, med.ThirdUnitToFirstUnitRatio, med.ThirdSubtotal,
 		med.ThirdDiscountPercentage, med.ThirdDiscountAmount, med.ThirdPrice,
-		med.Description, time.Now(), user.ID, mid)
+		med.Description, med.IsTaxable, med.ActiveIngredients, med.ControlledClass, time.Now(), user.ID, mid)
 	if err != nil {
 		return err
 	}
@@ -546,14 +546,106 @@ func (s *Store) ModifyMedicine(mid int, med types.Medicine, user *types.User) er
 	return nil
 }
 
-func (s *Store) UpdateMedicineStock(mid int, newStock float64, user *types.User) error {
+func (s *Store) GetMedicineStock(mid int, branchId int) (float64, error) {
+	query := "SELECT qty FROM medicine_stock WHERE medicine_id = ? AND branch_id = ?"
+	rows, err := s.db.Query(query, mid, branchId)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	// the branch that never had the medicine has no stock
+	var qty float64
+
+	for rows.Next() {
+		err = rows.Scan(&qty)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return qty, nil
+}
+
+func (s *Store) GetMedicineStocks(mid int) ([]types.MedicineStock, error) {
+	query := `SELECT branch.id, branch.code, branch.name, 
+					COALESCE(ms.qty, 0), COALESCE(ms.last_modified, branch.created_at) 
+					FROM branch 
+					LEFT JOIN medicine_stock AS ms ON ms.branch_id = branch.id AND ms.medicine_id = ? 
+					WHERE branch.deleted_at IS NULL 
+					ORDER BY branch.id ASC`
+
+	rows, err := s.db.Query(query, mid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make([]types.MedicineStock, 0)
+
+	for rows.Next() {
+		var stock types.MedicineStock
+
+		err = rows.Scan(
+			&stock.BranchID,
+			&stock.BranchCode,
+			&stock.BranchName,
+			&stock.Qty,
+			&stock.LastModified,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		stock.LastModified = stock.LastModified.Local()
+
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, nil
+}
+
+// map of medicine id to the qty in the branch
+func (s *Store) GetBranchStocks(branchId int) (map[int]float64, error) {
+	query := "SELECT medicine_id, qty FROM medicine_stock WHERE branch_id = ?"
+	rows, err := s.db.Query(query, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make(map[int]float64)
+
+	for rows.Next() {
+		var medicineId int
+		var qty float64
+
+		err = rows.Scan(&medicineId, &qty)
+		if err != nil {
+			return nil, err
+		}
+
+		stocks[medicineId] = qty
+	}
+
+	return stocks, nil
+}
+
+func (s *Store) UpdateMedicineStock(mid int, branchId int, newStock float64, user *types.User) error {
 	data, err := s.GetMedicineByID(mid)
 	if err != nil {
 		return err
 	}
 
+	previousStock, err := s.GetMedicineStock(mid, branchId)
+	if err != nil {
+		return err
+	}
+
 	writeData := map[string]interface{}{
-		"previous_data": data,
+		"previous_data":       data,
+		"branch_id":           branchId,
+		"previous_branch_qty": previousStock,
 	}
 
 	err = logger.WriteLog("modify", "medicine", user.Name, data.ID, writeData)
@@ -561,11 +653,24 @@ func (s *Store) UpdateMedicineStock(mid int, newStock float64, user *types.User)
 		return fmt.Errorf("error write log file")
 	}
 
-	query := `UPDATE medicine SET 
-		qty = ?, last_modified = ?, last_modified_by_user_id = ?
+	query := `INSERT INTO medicine_stock (
+		medicine_id, branch_id, qty, last_modified, last_modified_by_user_id
+	) VALUES (?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		qty = VALUES(qty), last_modified = VALUES(last_modified),
+		last_modified_by_user_id = VALUES(last_modified_by_user_id)`
+
+	_, err = s.db.Exec(query, mid, branchId, newStock, time.Now(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `UPDATE medicine SET 
+		qty = (SELECT COALESCE(SUM(qty), 0) FROM medicine_stock WHERE medicine_id = ?), 
+		last_modified = ?, last_modified_by_user_id = ?
 	WHERE id = ?`
 
-	_, err = s.db.Exec(query, newStock, time.Now(), user.ID, mid)
+	_, err = s.db.Exec(query, mid, time.Now(), user.ID, mid)
 	if err != nil {
 		return err
 	}
@@ -573,6 +678,55 @@ func (s *Store) UpdateMedicineStock(mid int, newStock float64, user *types.User)
 	return nil
 }
 
+func (s *Store) GetMedicinesModifiedSince(since time.Time) ([]types.Medicine, error) {
+	query := "SELECT * FROM medicine WHERE last_modified >= ? OR deleted_at >= ? ORDER BY id ASC"
+	rows, err := s.db.Query(query, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	medicines := make([]types.Medicine, 0)
+
+	for rows.Next() {
+		medicine, err := scanRowIntoMedicine(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		medicines = append(medicines, *medicine)
+	}
+
+	return medicines, nil
+}
+
+// map of medicine id to the qty in the branch, only the stock changed since the given time
+func (s *Store) GetBranchStocksModifiedSince(branchId int, since time.Time) (map[int]float64, error) {
+	query := "SELECT medicine_id, qty FROM medicine_stock WHERE branch_id = ? AND last_modified >= ?"
+	rows, err := s.db.Query(query, branchId, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocks := make(map[int]float64)
+
+	for rows.Next() {
+		var medicineId int
+		var qty float64
+
+		err = rows.Scan(&medicineId, &qty)
+		if err != nil {
+			return nil, err
+		}
+
+		stocks[medicineId] = qty
+	}
+
+	return stocks, nil
+}
+
 func scanRowIntoMedicine(rows *sql.Rows) (*types.Medicine, error) {
 	medicine := new(types.Medicine)
 
@@ -604,6 +758,9 @@ func scanRowIntoMedicine(rows *sql.Rows) (*types.Medicine, error) {
 		&medicine.LastModifiedByUserID,
 		&medicine.DeletedAt,
 		&medicine.DeletedByUserID,
+		&medicine.IsTaxable,
+		&medicine.ActiveIngredients,
+		&medicine.ControlledClass,
 	)
 
 	if err != nil {
@@ -639,6 +796,9 @@ func scanRowIntoMedicineLists(rows *sql.Rows) (*types.MedicineListsReturnPayload
 		&medicine.ThirdDiscountAmount,
 		&medicine.ThirdPrice,
 		&medicine.Description,
+		&medicine.IsTaxable,
+		&medicine.ActiveIngredients,
+		&medicine.ControlledClass,
 		&medicine.CreatedAt,
 		&medicine.LastModified,
 		&medicine.LastModifiedByUserName,
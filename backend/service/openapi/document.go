@@ -0,0 +1,421 @@
+package openapi
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+var operationIdReplacer = strings.NewReplacer("/", "_", "-", "_", "{", "", "}", "", ".", "_")
+
+// the operations that are registered in the router, in the order of the table.
+// the route that is not in the table is still listed without the payload
+func RegisteredOperations(router *mux.Router) ([]types.APIOperation, error) {
+	documented := make(map[string]types.APIOperation)
+	for _, operation := range Operations {
+		documented[operation.Method+" "+operation.Path] = operation
+	}
+
+	registered := make(map[string]bool)
+	undocumented := make([]types.APIOperation, 0)
+
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path = strings.TrimPrefix(path, constants.API_PREFIX)
+
+		for _, method := range methods {
+			if method == http.MethodOptions {
+				continue
+			}
+
+			key := method + " " + path
+			if registered[key] {
+				continue
+			}
+			registered[key] = true
+
+			if _, ok := documented[key]; !ok {
+				undocumented = append(undocumented, types.APIOperation{
+					Method:      method,
+					Path:        path,
+					OperationID: strings.ToLower(method) + operationIdReplacer.Replace(path),
+					Tag:         "undocumented",
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	operations := make([]types.APIOperation, 0)
+	for _, operation := range Operations {
+		if registered[operation.Method+" "+operation.Path] {
+			operations = append(operations, operation)
+		}
+	}
+
+	return append(operations, undocumented...), nil
+}
+
+func NewDocument(operations []types.APIOperation) types.OpenAPIDocument {
+	builder := &schemaBuilder{schemas: make(map[string]*types.OpenAPISchema)}
+
+	document := types.OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: types.OpenAPIInfo{
+			Title:   "Pharmacon API",
+			Version: constants.API_VERSION,
+		},
+		Servers: []types.OpenAPIServer{{URL: constants.API_PREFIX}},
+		Paths:   make(map[string]map[string]types.OpenAPIOperation),
+		Components: types.OpenAPIComponents{
+			Schemas: builder.schemas,
+			SecuritySchemes: map[string]types.OpenAPISecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	for _, operation := range operations {
+		apiOperation := types.OpenAPIOperation{
+			OperationID: operation.OperationID,
+			Summary:     operation.Summary,
+			Tags:        []string{operation.Tag},
+			Responses:   make(map[string]types.OpenAPIResponse),
+		}
+
+		if operation.Admin {
+			apiOperation.Description = "needs an admin token"
+		}
+
+		if operation.Public {
+			apiOperation.Security = &[]map[string][]string{}
+		} else {
+			apiOperation.Security = &[]map[string][]string{{"bearerAuth": {}}}
+		}
+
+		for _, param := range pathParams(operation.Path) {
+			apiOperation.Parameters = append(apiOperation.Parameters, types.OpenAPIParameter{
+				Name:     param,
+				In:       "path",
+				Required: true,
+				Schema:   &types.OpenAPISchema{Type: "string"},
+			})
+		}
+
+		if operation.Request != nil {
+			apiOperation.RequestBody = &types.OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]types.OpenAPIMediaType{
+					"application/json": {Schema: builder.schemaOf(reflect.TypeOf(operation.Request))},
+				},
+			}
+		} else if operation.FormFile != "" {
+			apiOperation.RequestBody = &types.OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]types.OpenAPIMediaType{
+					"multipart/form-data": {Schema: &types.OpenAPISchema{
+						Type:       "object",
+						Properties: map[string]*types.OpenAPISchema{operation.FormFile: {Type: "string", Format: "binary"}},
+						Required:   []string{operation.FormFile},
+					}},
+				},
+			}
+		}
+
+		if operation.ContentType != "" {
+			apiOperation.Responses["200"] = types.OpenAPIResponse{
+				Description: "the file",
+				Content: map[string]types.OpenAPIMediaType{
+					operation.ContentType: {Schema: &types.OpenAPISchema{Type: "string", Format: "binary"}},
+				},
+			}
+		} else {
+			response := types.OpenAPIResponse{Description: "success"}
+			if operation.Response != nil {
+				response.Content = map[string]types.OpenAPIMediaType{
+					"application/json": {Schema: builder.schemaOf(reflect.TypeOf(operation.Response))},
+				}
+			}
+
+			apiOperation.Responses["200"] = response
+		}
+
+		apiOperation.Responses["default"] = types.OpenAPIResponse{
+			Description: "error",
+			Content: map[string]types.OpenAPIMediaType{
+				"application/json": {Schema: &types.OpenAPISchema{
+					Type:       "object",
+					Properties: map[string]*types.OpenAPISchema{"error": {Type: "string"}},
+				}},
+			},
+		}
+
+		if document.Paths[operation.Path] == nil {
+			document.Paths[operation.Path] = make(map[string]types.OpenAPIOperation)
+		}
+		document.Paths[operation.Path][strings.ToLower(operation.Method)] = apiOperation
+	}
+
+	return document
+}
+
+func pathParams(path string) []string {
+	params := make([]string, 0)
+
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params = append(params, strings.Trim(part, "{}"))
+		}
+	}
+
+	return params
+}
+
+// the named struct is put in the components once and referenced after
+type schemaBuilder struct {
+	schemas map[string]*types.OpenAPISchema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	nullTypes      = map[reflect.Type]*types.OpenAPISchema{
+		reflect.TypeOf(sql.NullTime{}):    {Type: "string", Format: "date-time", Nullable: true},
+		reflect.TypeOf(sql.NullInt64{}):   {Type: "integer", Format: "int64", Nullable: true},
+		reflect.TypeOf(sql.NullInt32{}):   {Type: "integer", Format: "int32", Nullable: true},
+		reflect.TypeOf(sql.NullFloat64{}): {Type: "number", Format: "double", Nullable: true},
+		reflect.TypeOf(sql.NullString{}):  {Type: "string", Nullable: true},
+		reflect.TypeOf(sql.NullBool{}):    {Type: "boolean", Nullable: true},
+	}
+)
+
+func (b *schemaBuilder) schemaOf(t reflect.Type) *types.OpenAPISchema {
+	if t.Kind() == reflect.Pointer {
+		schema := b.schemaOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+
+		return schema
+	}
+
+	if nullSchema, ok := nullTypes[t]; ok {
+		copySchema := *nullSchema
+		return &copySchema
+	}
+
+	switch t {
+	case timeType:
+		return &types.OpenAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &types.OpenAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &types.OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &types.OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &types.OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &types.OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &types.OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &types.OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &types.OpenAPISchema{Type: "string", Format: "byte"}
+		}
+
+		return &types.OpenAPISchema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &types.OpenAPISchema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &types.OpenAPISchema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+
+		if _, ok := b.schemas[t.Name()]; !ok {
+			// set first so the struct that refers to itself doesn't loop
+			b.schemas[t.Name()] = &types.OpenAPISchema{}
+			*b.schemas[t.Name()] = *b.structSchema(t)
+		}
+
+		return &types.OpenAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	return &types.OpenAPISchema{}
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *types.OpenAPISchema {
+	schema := &types.OpenAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*types.OpenAPISchema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omit := jsonFieldName(field)
+		if omit {
+			continue
+		}
+
+		// the embedded struct without a json name is flattened
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := b.structSchema(field.Type)
+			for propName, prop := range embedded.Properties {
+				schema.Properties[propName] = prop
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := b.schemaOf(field.Type)
+		if applyValidateTag(fieldSchema, field.Type, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = fieldSchema
+	}
+
+	sort.Strings(schema.Required)
+
+	return schema
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	return strings.Split(tag, ",")[0], false
+}
+
+// the validate tag of go-playground/validator into the schema,
+// returns true when the field is required
+func applyValidateTag(schema *types.OpenAPISchema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	// the rules after dive are for the items
+	tag = strings.Split(tag, ",dive")[0]
+
+	// the referenced schema is shared, the rules can't be put on it
+	if schema.Ref != "" {
+		return strings.Contains(","+tag+",", ",required,")
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max", "len":
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+
+			applyLength(schema, t.Kind(), name, number)
+		case "gt", "gte", "lt", "lte":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			switch name {
+			case "gt":
+				schema.Minimum = &number
+				schema.ExclusiveMinimum = true
+			case "gte":
+				schema.Minimum = &number
+			case "lt":
+				schema.Maximum = &number
+				schema.ExclusiveMaximum = true
+			case "lte":
+				schema.Maximum = &number
+			}
+		}
+	}
+
+	return required
+}
+
+// min and max are the length for string and slice, the value for number
+func applyLength(schema *types.OpenAPISchema, kind reflect.Kind, name string, number int) {
+	switch kind {
+	case reflect.String:
+		if name == "min" || name == "len" {
+			schema.MinLength = &number
+		}
+		if name == "max" || name == "len" {
+			schema.MaxLength = &number
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if name == "min" || name == "len" {
+			schema.MinItems = &number
+		}
+		if name == "max" || name == "len" {
+			schema.MaxItems = &number
+		}
+	default:
+		value := float64(number)
+		if name == "min" || name == "len" {
+			schema.Minimum = &value
+		}
+		if name == "max" || name == "len" {
+			schema.Maximum = &value
+		}
+	}
+}
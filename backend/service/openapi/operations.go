@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// every route registered in cmd/api, a new route must be added here as well
+// so it is documented and can be called from the go client
+var Operations = []types.APIOperation{
+	// USER
+	{Method: http.MethodPost, Path: "/user/login", OperationID: "login", Tag: "user", Summary: "login and get the access token", Request: types.LoginUserPayload{}, Response: map[string]string{}, Public: true},
+	{Method: http.MethodPost, Path: "/user/register", OperationID: "registerUser", Tag: "user", Summary: "register a new user", Request: types.RegisterUserPayload{}, Response: "", Admin: true},
+	{Method: http.MethodGet, Path: "/user/{params}/{val}", OperationID: "getUsers", Tag: "user", Summary: "get all users or search by params", Response: []types.User{}, Admin: true},
+	{Method: http.MethodGet, Path: "/user/current", OperationID: "getCurrentUser", Tag: "user", Summary: "get the logged in user", Response: types.User{}, Admin: true},
+	{Method: http.MethodPost, Path: "/user/detail", OperationID: "getUserDetail", Tag: "user", Request: types.GetOneUserPayload{}, Response: types.User{}, Admin: true},
+	{Method: http.MethodDelete, Path: "/user", OperationID: "deleteUser", Tag: "user", Request: types.RemoveUserPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/user/modify", OperationID: "modifyUser", Tag: "user", Request: types.ModifyUserPayload{}, Response: "", Admin: true},
+	{Method: http.MethodGet, Path: "/user/logout", OperationID: "logout", Tag: "user", Response: ""},
+	{Method: http.MethodPatch, Path: "/user/admin", OperationID: "changeAdminStatus", Tag: "user", Request: types.ChangeAdminStatusPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/user/password", OperationID: "changePassword", Tag: "user", Summary: "change the password of the logged in user, the old password can be the reset code", Request: types.ChangePasswordPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/user/password/policy", OperationID: "getPasswordPolicy", Tag: "user", Response: types.PasswordPolicy{}},
+	{Method: http.MethodPost, Path: "/user/password/reset", OperationID: "resetPassword", Tag: "user", Summary: "get a one-time code for the user to log in and change the password", Request: types.ResetPasswordPayload{}, Response: types.PasswordResetCodeReturnPayload{}, Admin: true},
+	{Method: http.MethodPost, Path: "/user/totp/setup", OperationID: "setupTOTP", Tag: "user", Summary: "get a new totp secret, it is used after it is enabled", Response: types.TOTPSetupReturnPayload{}, Admin: true},
+	{Method: http.MethodPatch, Path: "/user/totp/enable", OperationID: "enableTOTP", Tag: "user", Request: types.EnableTOTPPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/user/totp/disable", OperationID: "disableTOTP", Tag: "user", Request: types.DisableTOTPPayload{}, Response: ""},
+
+	// LOGIN
+	{Method: http.MethodPost, Path: "/login/attempt", OperationID: "getLoginAttempts", Tag: "login", Summary: "the failed logins", Request: types.ViewLoginAttemptPayload{}, Response: []types.LoginAttempt{}, Admin: true},
+	{Method: http.MethodGet, Path: "/login/lockout", OperationID: "getLoginLockouts", Tag: "login", Summary: "the user names and the ip addresses locked now", Response: []types.LoginLockout{}, Admin: true},
+	{Method: http.MethodPatch, Path: "/login/unlock", OperationID: "unlockLogin", Tag: "login", Request: types.UnlockLoginPayload{}, Response: "", Admin: true},
+
+	// CUSTOMER
+	{Method: http.MethodPost, Path: "/customer", OperationID: "registerCustomer", Tag: "customer", Request: types.RegisterCustomerPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/customer/{val}", OperationID: "getCustomers", Tag: "customer", Summary: "get all customers or search by name", Response: []types.Customer{}},
+	{Method: http.MethodPost, Path: "/customer/detail", OperationID: "getCustomerDetail", Tag: "customer", Request: types.GetOneCustomerPayload{}, Response: types.Customer{}},
+	{Method: http.MethodDelete, Path: "/customer", OperationID: "deleteCustomer", Tag: "customer", Request: types.DeleteCustomerPayload{}, Response: ""},
+	{Method: http.MethodPatch, Path: "/customer", OperationID: "modifyCustomer", Tag: "customer", Request: types.ModifyCustomerPayload{}, Response: ""},
+
+	// SUPPLIER
+	{Method: http.MethodPost, Path: "/supplier", OperationID: "registerSupplier", Tag: "supplier", Request: types.RegisterSupplierPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/supplier/{params}/{val}", OperationID: "getSuppliers", Tag: "supplier", Summary: "get all suppliers or search by params", Response: []types.SupplierInformationReturnPayload{}},
+	{Method: http.MethodPost, Path: "/supplier/detail", OperationID: "getSupplierDetail", Tag: "supplier", Request: types.GetOneSupplierPayload{}, Response: types.SupplierInformationReturnPayload{}},
+	{Method: http.MethodDelete, Path: "/supplier", OperationID: "deleteSupplier", Tag: "supplier", Request: types.DeleteSupplierPayload{}, Response: ""},
+	{Method: http.MethodPatch, Path: "/supplier", OperationID: "modifySupplier", Tag: "supplier", Request: types.ModifySupplierPayload{}, Response: ""},
+
+	// MEDICINE
+	{Method: http.MethodPost, Path: "/medicine", OperationID: "registerMedicine", Tag: "medicine", Request: types.RegisterMedicinePayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/medicine/{params}/{val}", OperationID: "getMedicines", Tag: "medicine", Summary: "get all medicines or search by params", Response: []types.MedicineListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/medicine/detail", OperationID: "getMedicineDetail", Tag: "medicine", Request: types.GetOneMedicinePayload{}, Response: types.MedicineListsReturnPayload{}},
+	{Method: http.MethodDelete, Path: "/medicine", OperationID: "deleteMedicine", Tag: "medicine", Request: types.DeleteMedicinePayload{}, Response: ""},
+	{Method: http.MethodPatch, Path: "/medicine", OperationID: "modifyMedicine", Tag: "medicine", Request: types.ModifyMedicinePayload{}, Response: ""},
+
+	// TAX
+	{Method: http.MethodPost, Path: "/tax", OperationID: "registerTax", Tag: "tax", Request: types.RegisterTaxPayload{}, Response: "", Admin: true},
+	{Method: http.MethodGet, Path: "/tax/{val}", OperationID: "getTaxes", Tag: "tax", Summary: "get all taxes or search by code", Response: []types.Tax{}},
+	{Method: http.MethodPost, Path: "/tax/detail", OperationID: "getTaxDetail", Tag: "tax", Request: types.GetOneTaxPayload{}, Response: types.Tax{}},
+	{Method: http.MethodDelete, Path: "/tax", OperationID: "deleteTax", Tag: "tax", Request: types.DeleteTaxPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/tax", OperationID: "modifyTax", Tag: "tax", Request: types.ModifyTaxPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPost, Path: "/tax/report", OperationID: "getTaxReport", Tag: "tax", Request: types.ViewTaxReportPayload{}, Response: types.TaxReportReturnPayload{}, Admin: true},
+
+	// CONTROLLED SUBSTANCE
+	{Method: http.MethodPost, Path: "/controlled-substance/register", OperationID: "getControlledSubstanceRegister", Tag: "controlled-substance", Request: types.ViewControlledSubstanceRegisterPayload{}, Response: []types.ControlledSubstanceRegisterReturnPayload{}, Admin: true},
+	{Method: http.MethodPost, Path: "/controlled-substance/report", OperationID: "getControlledSubstanceReport", Tag: "controlled-substance", Request: types.ViewControlledSubstanceReportPayload{}, Response: types.ControlledSubstanceReportReturnPayload{}, Admin: true},
+	{Method: http.MethodPost, Path: "/controlled-substance/report/pdf", OperationID: "printControlledSubstanceReport", Tag: "controlled-substance", Request: types.ViewControlledSubstanceReportPayload{}, Admin: true, ContentType: "application/pdf"},
+	{Method: http.MethodPost, Path: "/controlled-substance/report/sipnap", OperationID: "exportSIPNAP", Tag: "controlled-substance", Request: types.ViewControlledSubstanceReportPayload{}, Admin: true, ContentType: "text/csv"},
+
+	// DOCTOR
+	{Method: http.MethodPost, Path: "/doctor", OperationID: "registerDoctor", Tag: "doctor", Request: types.RegisterDoctorPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/doctor/{val}", OperationID: "getDoctors", Tag: "doctor", Summary: "get all doctors or search by name", Response: []types.Doctor{}},
+	{Method: http.MethodPost, Path: "/doctor/detail", OperationID: "getDoctorDetail", Tag: "doctor", Request: types.GetOneDoctorPayload{}, Response: types.Doctor{}},
+	{Method: http.MethodDelete, Path: "/doctor", OperationID: "deleteDoctor", Tag: "doctor", Request: types.DeleteDoctorPayload{}, Response: ""},
+	{Method: http.MethodPatch, Path: "/doctor", OperationID: "modifyDoctor", Tag: "doctor", Request: types.ModifyDoctorPayload{}, Response: ""},
+
+	// PATIENT
+	{Method: http.MethodPost, Path: "/patient", OperationID: "registerPatient", Tag: "patient", Request: types.RegisterPatientPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/patient/{val}", OperationID: "getPatients", Tag: "patient", Summary: "get all patients or search by name", Response: []types.Patient{}},
+	{Method: http.MethodPost, Path: "/patient/detail", OperationID: "getPatientDetail", Tag: "patient", Request: types.GetOnePatientPayload{}, Response: types.Patient{}},
+	{Method: http.MethodDelete, Path: "/patient", OperationID: "deletePatient", Tag: "patient", Request: types.DeletePatientPayload{}, Response: ""},
+	{Method: http.MethodPatch, Path: "/patient", OperationID: "modifyPatient", Tag: "patient", Request: types.ModifyPatientPayload{}, Response: ""},
+	{Method: http.MethodPost, Path: "/patient/allergy", OperationID: "registerPatientAllergy", Tag: "patient", Request: types.RegisterPatientAllergyPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/patient/allergy/{patientId}", OperationID: "getPatientAllergies", Tag: "patient", Response: []types.PatientAllergy{}},
+	{Method: http.MethodDelete, Path: "/patient/allergy", OperationID: "deletePatientAllergy", Tag: "patient", Request: types.DeletePatientAllergyPayload{}, Response: ""},
+
+	// PURCHASE INVOICE
+	{Method: http.MethodPost, Path: "/invoice/purchase", OperationID: "registerPurchaseInvoice", Tag: "purchase-invoice", Request: types.RegisterPurchaseInvoicePayload{}, Response: ""},
+	{Method: http.MethodPost, Path: "/invoice/purchase/{params}/{val}", OperationID: "getPurchaseInvoices", Tag: "purchase-invoice", Summary: "get purchase invoices in the date range, all or search by params", Request: types.ViewPurchaseInvoicePayload{}, Response: []types.PurchaseInvoiceListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/invoice/purchase/detail", OperationID: "getPurchaseInvoiceDetail", Tag: "purchase-invoice", Request: types.ViewPurchaseInvoiceDetailPayload{}, Response: types.PurchaseInvoiceDetailPayload{}},
+	{Method: http.MethodDelete, Path: "/invoice/purchase", OperationID: "deletePurchaseInvoice", Tag: "purchase-invoice", Request: types.DeletePurchaseInvoice{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/invoice/purchase", OperationID: "modifyPurchaseInvoice", Tag: "purchase-invoice", Request: types.ModifyPurchaseInvoicePayload{}, Response: ""},
+	{Method: http.MethodPost, Path: "/invoice/purchase/print", OperationID: "printPurchaseInvoice", Tag: "purchase-invoice", Request: types.ViewPurchaseInvoiceDetailPayload{}, ContentType: "application/pdf"},
+
+	// PURCHASE ORDER
+	{Method: http.MethodPost, Path: "/invoice/purchase-order", OperationID: "registerPurchaseOrder", Tag: "purchase-order", Request: types.RegisterPurchaseOrderPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/invoice/purchase-order", OperationID: "getPurchaseOrderNextNumber", Tag: "purchase-order", Summary: "get the next purchase order number for today", Response: map[string]int{}},
+	{Method: http.MethodPost, Path: "/invoice/purchase-order/{params}/{val}", OperationID: "getPurchaseOrders", Tag: "purchase-order", Summary: "get purchase orders in the date range, all or search by params", Request: types.ViewPurchaseOrderPayload{}, Response: []types.PurchaseOrderListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/invoice/purchase-order/detail", OperationID: "getPurchaseOrderDetail", Tag: "purchase-order", Request: types.ViewPurchaseOrderDetailPayload{}, Response: types.PurchaseOrderDetailPayload{}},
+	{Method: http.MethodDelete, Path: "/invoice/purchase-order", OperationID: "deletePurchaseOrder", Tag: "purchase-order", Request: types.DeletePurchaseOrder{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/invoice/purchase-order", OperationID: "modifyPurchaseOrder", Tag: "purchase-order", Request: types.ModifyPurchaseOrderPayload{}, Response: ""},
+	{Method: http.MethodPost, Path: "/invoice/purchase-order/print", OperationID: "printPurchaseOrder", Tag: "purchase-order", Request: types.ViewPurchaseOrderDetailPayload{}, ContentType: "application/pdf"},
+
+	// INVOICE
+	{Method: http.MethodPost, Path: "/invoice", OperationID: "registerInvoice", Tag: "invoice", Request: types.RegisterInvoicePayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/invoice", OperationID: "getInvoiceNextNumber", Tag: "invoice", Summary: "get the next invoice number for today", Response: map[string]int{}},
+	{Method: http.MethodPost, Path: "/invoice/{params}/{val}", OperationID: "getInvoices", Tag: "invoice", Summary: "get invoices in the date range, all or search by params", Request: types.ViewInvoicePayload{}, Response: []types.InvoiceListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/invoice/detail", OperationID: "getInvoiceDetail", Tag: "invoice", Request: types.ViewInvoiceDetailPayload{}, Response: types.InvoiceDetailPayload{}},
+	{Method: http.MethodDelete, Path: "/invoice", OperationID: "deleteInvoice", Tag: "invoice", Request: types.DeleteInvoicePayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/invoice", OperationID: "modifyInvoice", Tag: "invoice", Request: types.ModifyInvoicePayload{}, Response: ""},
+	{Method: http.MethodPost, Path: "/invoice/print", OperationID: "printInvoice", Tag: "invoice", Request: types.ViewInvoiceDetailPayload{}, ContentType: "application/pdf"},
+	{Method: http.MethodPost, Path: "/invoice/print-receipt", OperationID: "printInvoiceReceipt", Tag: "invoice", Request: types.PrintReceiptPayload{}, ContentType: "application/pdf"},
+	{Method: http.MethodPost, Path: "/invoice/thermal-receipt", OperationID: "printInvoiceThermalReceipt", Tag: "invoice", Summary: "the raw ESC/POS bytes, or a message when the server prints it", Request: types.PrintThermalReceiptPayload{}, ContentType: "application/octet-stream"},
+
+	// PRESCRIPTION
+	{Method: http.MethodPost, Path: "/prescription", OperationID: "registerPrescription", Tag: "prescription", Request: types.RegisterPrescriptionPayload{}, Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/prescription/patient-history", OperationID: "getPatientHistory", Tag: "prescription", Request: types.ViewPatientMedicationHistoryPayload{}, Response: types.PatientMedicationHistoryReturn{}},
+	{Method: http.MethodPost, Path: "/prescription/patient-history/pdf", OperationID: "printPatientHistory", Tag: "prescription", Request: types.ViewPatientMedicationHistoryPayload{}, ContentType: "application/pdf"},
+	{Method: http.MethodPost, Path: "/prescription/{params}/{val}", OperationID: "getPrescriptions", Tag: "prescription", Summary: "get prescriptions in the date range, all or search by params", Request: types.ViewPrescriptionsPayload{}, Response: []types.PrescriptionListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/prescription/detail", OperationID: "getPrescriptionDetail", Tag: "prescription", Request: types.ViewPrescriptionDetailPayload{}, Response: types.PrescriptionDetailPayload{}},
+	{Method: http.MethodDelete, Path: "/prescription", OperationID: "deletePrescription", Tag: "prescription", Request: types.DeletePrescription{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/prescription", OperationID: "modifyPrescription", Tag: "prescription", Request: types.ModifyPrescriptionPayload{}, Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/prescription/print", OperationID: "printPrescription", Tag: "prescription", Summary: "the prescription and eticket pdf in a zip", Request: types.ViewPrescriptionDetailPayload{}, ContentType: "application/zip"},
+	{Method: http.MethodPost, Path: "/prescription/screening", OperationID: "screenPrescription", Tag: "prescription", Request: types.ScreenPrescriptionPayload{}, Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/prescription/copy", OperationID: "printPrescriptionCopy", Tag: "prescription", Request: types.ViewPrescriptionDetailPayload{}, ContentType: "application/pdf"},
+	{Method: http.MethodPost, Path: "/prescription/signa", OperationID: "parseSigna", Tag: "prescription", Request: types.ParseSignaPayload{}, Response: types.SignaReturn{}},
+	{Method: http.MethodPatch, Path: "/prescription/status", OperationID: "updatePrescriptionStatus", Tag: "prescription", Request: types.UpdatePrescriptionStatusPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/prescription/queue", OperationID: "getPrescriptionQueue", Tag: "prescription", Response: []types.PrescriptionQueueGroupReturn{}},
+
+	// MAIN DOCTOR PRESCRIPTION ITEM
+	{Method: http.MethodPost, Path: "/main-doctor-prescription-item", OperationID: "registerMainDoctorMedItem", Tag: "main-doctor-prescription-item", Request: types.RegisterMainDoctorMedItemPayload{}, Response: map[string]interface{}{}},
+	{Method: http.MethodGet, Path: "/main-doctor-prescription-item/{val}", OperationID: "getMainDoctorMedItems", Tag: "main-doctor-prescription-item", Response: []types.MainDoctorMedItemReturn{}},
+	{Method: http.MethodPost, Path: "/main-doctor-prescription-item/detail", OperationID: "getMainDoctorMedItemDetail", Tag: "main-doctor-prescription-item", Request: types.ViewMainDoctorMedItemPayload{}, Response: types.MainDoctorMedItemReturn{}},
+	{Method: http.MethodPatch, Path: "/main-doctor-prescription-item", OperationID: "modifyMainDoctorMedItem", Tag: "main-doctor-prescription-item", Request: types.ModifyMainDoctorMedItemPayload{}, Response: map[string]interface{}{}},
+	{Method: http.MethodPost, Path: "/main-doctor-prescription-item/test", OperationID: "testMainDoctorMedItem", Tag: "main-doctor-prescription-item", Request: types.RegisterMainDoctorMedItemPayload{}, Response: map[string]interface{}{}},
+
+	// SCREENING
+	{Method: http.MethodGet, Path: "/interaction-rule", OperationID: "getInteractionRules", Tag: "screening", Response: []types.InteractionRule{}},
+	{Method: http.MethodPost, Path: "/interaction-rule/reload", OperationID: "reloadInteractionRules", Tag: "screening", Response: "", Admin: true},
+
+	// ETICKET TEMPLATE
+	{Method: http.MethodGet, Path: "/eticket-template", OperationID: "getEticketTemplates", Tag: "eticket-template", Response: []types.EticketTemplate{}},
+	{Method: http.MethodPost, Path: "/eticket-template/reload", OperationID: "reloadEticketTemplates", Tag: "eticket-template", Response: "", Admin: true},
+
+	// PRODUCTION
+	{Method: http.MethodPost, Path: "/production", OperationID: "registerProduction", Tag: "production", Request: types.RegisterProductionPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/production", OperationID: "getProductionNextNumber", Tag: "production", Summary: "get the next production number", Response: map[string]int{}},
+	{Method: http.MethodPost, Path: "/production/{params}/{val}", OperationID: "getProductions", Tag: "production", Summary: "get productions in the date range, all or search by params", Request: types.ViewProductionsPayload{}, Response: []types.ProductionListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/production/detail", OperationID: "getProductionDetail", Tag: "production", Request: types.ViewProductionMedicineItemPayload{}, Response: types.ProductionDetailPayload{}},
+	{Method: http.MethodDelete, Path: "/production", OperationID: "deleteProduction", Tag: "production", Request: types.DeleteProduction{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/production", OperationID: "modifyProduction", Tag: "production", Request: types.ModifyProductionPayload{}, Response: ""},
+	{Method: http.MethodPost, Path: "/production-recipe", OperationID: "registerProductionRecipe", Tag: "production", Request: types.RegisterProductionRecipePayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/production-recipe", OperationID: "getProductionRecipes", Tag: "production", Response: []types.ProductionRecipeListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/production-recipe/detail", OperationID: "getProductionRecipeDetail", Tag: "production", Request: types.ViewProductionRecipeDetailPayload{}, Response: types.ProductionRecipeDetailPayload{}},
+	{Method: http.MethodPost, Path: "/production-recipe/scale", OperationID: "scaleProductionRecipe", Tag: "production", Request: types.ScaleProductionRecipePayload{}, Response: types.ProductionRecipeScaleReturnPayload{}},
+	{Method: http.MethodPatch, Path: "/production-recipe", OperationID: "modifyProductionRecipe", Tag: "production", Request: types.ModifyProductionRecipePayload{}, Response: ""},
+	{Method: http.MethodDelete, Path: "/production-recipe", OperationID: "deleteProductionRecipe", Tag: "production", Request: types.DeleteProductionRecipePayload{}, Response: ""},
+
+	// SETTING
+	{Method: http.MethodGet, Path: "/setting/company", OperationID: "getCompanySetting", Tag: "setting", Response: types.CompanySetting{}},
+	{Method: http.MethodPatch, Path: "/setting/company", OperationID: "modifyCompanySetting", Tag: "setting", Request: types.ModifyCompanySettingPayload{}, Response: "", Admin: true},
+	{Method: http.MethodGet, Path: "/setting/logo", OperationID: "getLogo", Tag: "setting", ContentType: "image/png"},
+	{Method: http.MethodPost, Path: "/setting/logo", OperationID: "uploadLogo", Tag: "setting", Response: "", Admin: true, FormFile: "logo"},
+	{Method: http.MethodGet, Path: "/setting/document", OperationID: "getDocumentSettings", Tag: "setting", Response: []types.DocumentSetting{}},
+	{Method: http.MethodPatch, Path: "/setting/document", OperationID: "modifyDocumentSetting", Tag: "setting", Request: types.ModifyDocumentSettingPayload{}, Response: "", Admin: true},
+
+	// BRANCH
+	{Method: http.MethodPost, Path: "/branch", OperationID: "registerBranch", Tag: "branch", Request: types.RegisterBranchPayload{}, Response: "", Admin: true},
+	{Method: http.MethodGet, Path: "/branch/{val}", OperationID: "getBranches", Tag: "branch", Summary: "get all branches, by id or by code", Response: []types.Branch{}},
+	{Method: http.MethodDelete, Path: "/branch", OperationID: "deleteBranch", Tag: "branch", Request: types.DeleteBranchPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/branch", OperationID: "modifyBranch", Tag: "branch", Request: types.ModifyBranchPayload{}, Response: "", Admin: true},
+
+	// STOCK TRANSFER
+	{Method: http.MethodPost, Path: "/stock-transfer", OperationID: "registerStockTransfer", Tag: "stock-transfer", Request: types.RegisterStockTransferPayload{}, Response: ""},
+	{Method: http.MethodGet, Path: "/stock-transfer", OperationID: "getStockTransferNextNumber", Tag: "stock-transfer", Summary: "get the next stock transfer number", Response: map[string]int{}},
+	{Method: http.MethodPost, Path: "/stock-transfer/{params}/{val}", OperationID: "getStockTransfers", Tag: "stock-transfer", Summary: "get stock transfers in the date range, all or search by params", Request: types.ViewStockTransferPayload{}, Response: []types.StockTransferListsReturnPayload{}},
+	{Method: http.MethodPost, Path: "/stock-transfer/detail", OperationID: "getStockTransferDetail", Tag: "stock-transfer", Request: types.ViewStockTransferDetailPayload{}, Response: types.StockTransferDetailPayload{}},
+	{Method: http.MethodPatch, Path: "/stock-transfer/dispatch", OperationID: "dispatchStockTransfer", Tag: "stock-transfer", Request: types.DispatchStockTransferPayload{}, Response: ""},
+	{Method: http.MethodPatch, Path: "/stock-transfer/receive", OperationID: "receiveStockTransfer", Tag: "stock-transfer", Request: types.ReceiveStockTransferPayload{}, Response: ""},
+	{Method: http.MethodDelete, Path: "/stock-transfer", OperationID: "deleteStockTransfer", Tag: "stock-transfer", Request: types.DeleteStockTransferPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPost, Path: "/stock-transfer/print", OperationID: "printStockTransfer", Tag: "stock-transfer", Request: types.ViewStockTransferDetailPayload{}, ContentType: "application/pdf"},
+
+	// SYNC
+	{Method: http.MethodPost, Path: "/sync/pull", OperationID: "syncPull", Tag: "sync", Summary: "the master data changed since the cursor", Request: types.SyncPullPayload{}, Response: types.SyncPullReturnPayload{}},
+	{Method: http.MethodPost, Path: "/sync/push", OperationID: "syncPush", Tag: "sync", Summary: "push the invoices made offline", Request: types.SyncPushPayload{}, Response: []types.OfflineInvoiceResult{}},
+
+	// WEBHOOK
+	{Method: http.MethodPost, Path: "/webhook", OperationID: "registerWebhook", Tag: "webhook", Summary: "the secret is only returned here", Request: types.RegisterWebhookPayload{}, Response: map[string]string{}, Admin: true},
+	{Method: http.MethodGet, Path: "/webhook/{val}", OperationID: "getWebhooks", Tag: "webhook", Summary: "get all webhooks or by id", Response: []types.WebhookReturnPayload{}, Admin: true},
+	{Method: http.MethodDelete, Path: "/webhook", OperationID: "deleteWebhook", Tag: "webhook", Request: types.DeleteWebhookPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPatch, Path: "/webhook", OperationID: "modifyWebhook", Tag: "webhook", Request: types.ModifyWebhookPayload{}, Response: "", Admin: true},
+	{Method: http.MethodPost, Path: "/webhook/delivery", OperationID: "getWebhookDeliveries", Tag: "webhook", Request: types.ViewWebhookDeliveryPayload{}, Response: []types.WebhookDeliveryReturnPayload{}, Admin: true},
+	{Method: http.MethodPost, Path: "/webhook/delivery/detail", OperationID: "getWebhookDeliveryDetail", Tag: "webhook", Request: types.ViewWebhookDeliveryDetailPayload{}, Response: types.WebhookDeliveryDetailPayload{}, Admin: true},
+	{Method: http.MethodPatch, Path: "/webhook/delivery/retry", OperationID: "retryWebhookDelivery", Tag: "webhook", Request: types.ViewWebhookDeliveryDetailPayload{}, Response: "", Admin: true},
+
+	// OPENAPI
+	{Method: http.MethodGet, Path: "/openapi.json", OperationID: "getOpenAPIDocument", Tag: "openapi", Summary: "this document", Response: types.OpenAPIDocument{}, Public: true},
+
+	// HEALTH
+	{Method: http.MethodGet, Path: "/healthz", OperationID: "getHealth", Tag: "health", Summary: "the process is up", Response: types.HealthStatus{}, Public: true},
+	{Method: http.MethodGet, Path: "/readyz", OperationID: "getReadiness", Tag: "health", Summary: "the database and the storages are usable, 503 if not", Response: types.HealthStatus{}, Public: true},
+
+	// METRICS
+	{Method: http.MethodGet, Path: "/metrics", OperationID: "getMetrics", Tag: "metrics", Summary: "prometheus metrics, with METRICS_TOKEN as the bearer token if it is set", Public: true, ContentType: "text/plain"},
+}
@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	router *mux.Router
+}
+
+// the router is the root router, so every registered route is found
+func NewHandler(router *mux.Router) *Handler {
+	return &Handler{router: router}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/openapi.json", h.handleGetDocument).Methods(http.MethodGet)
+
+	router.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleGetDocument(w http.ResponseWriter, r *http.Request) {
+	operations, err := RegisteredOperations(h.router)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, NewDocument(operations))
+}
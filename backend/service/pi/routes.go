@@ -2,13 +2,15 @@ package pi
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 	"github.com/nicolaics/pharmacon/utils/pdf"
@@ -21,11 +23,20 @@ type Handler struct {
 	medStore             types.MedicineStore
 	unitStore            types.UnitStore
 	poInvoiceStore       types.PurchaseOrderStore
+	taxStore             types.TaxStore
+	registerStore        types.ControlledSubstanceStore
+	branchStore          types.BranchStore
+	settingStore         types.SettingStore
+	documentStorage      types.DocumentStorage
+	eventStore           types.EventStore
 }
 
 func NewHandler(purchaseInvoiceStore types.PurchaseInvoiceStore, userStore types.UserStore,
 	supplierStore types.SupplierStore,
-	medStore types.MedicineStore, unitStore types.UnitStore, poInvoiceStore types.PurchaseOrderStore) *Handler {
+	medStore types.MedicineStore, unitStore types.UnitStore, poInvoiceStore types.PurchaseOrderStore,
+	taxStore types.TaxStore, registerStore types.ControlledSubstanceStore,
+	branchStore types.BranchStore, settingStore types.SettingStore, documentStorage types.DocumentStorage,
+	eventStore types.EventStore) *Handler {
 	return &Handler{
 		purchaseInvoiceStore: purchaseInvoiceStore,
 		userStore:            userStore,
@@ -33,6 +44,12 @@ func NewHandler(purchaseInvoiceStore types.PurchaseInvoiceStore, userStore types
 		medStore:             medStore,
 		unitStore:            unitStore,
 		poInvoiceStore:       poInvoiceStore,
+		taxStore:             taxStore,
+		registerStore:        registerStore,
+		branchStore:          branchStore,
+		settingStore:         settingStore,
+		documentStorage:      documentStorage,
+		eventStore:           eventStore,
 	}
 }
 
@@ -81,7 +98,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get purchase order
-	purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByNumber(payload.PurchaseOrderNumber)
+	purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByNumber(payload.PurchaseOrderNumber, user.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("po number %d not found", payload.PurchaseOrderNumber))
 		return
@@ -93,6 +110,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// tax is calculated from the configured tax, not from the client
+	err = calculateTax(h, &payload, supplier, *invoiceDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// check duplicate
 	purchaseInvoiceId, err := h.purchaseInvoiceStore.GetPurchaseInvoiceID(payload.Number, payload.SupplierID, payload.Subtotal, payload.TotalPrice, *invoiceDate)
 	if err == nil || purchaseInvoiceId != 0 {
@@ -109,11 +133,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		DiscountAmount:       payload.DiscountAmount,
 		TaxPercentage:        payload.TaxPercentage,
 		TaxAmount:            payload.TaxAmount,
+		TaxableAmount:        payload.TaxableAmount,
 		TotalPrice:           payload.TotalPrice,
 		Description:          payload.Description,
 		UserID:               user.ID,
 		InvoiceDate:          *invoiceDate,
 		LastModifiedByUserID: user.ID,
+		BranchID:             user.BranchID,
 	})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -253,8 +279,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 			Price:              medicine.Price,
 			DiscountPercentage: payload.DiscountPercentage,
 			DiscountAmount:     payload.DiscountAmount,
-			TaxPercentage:      payload.TaxPercentage,
-			TaxAmount:          payload.TaxAmount,
+			TaxPercentage:      medicine.TaxPercentage,
+			TaxAmount:          medicine.TaxAmount,
 			Subtotal:           medicine.Subtotal,
 			BatchNumber:        medicine.BatchNumber,
 			ExpDate:            *expDate,
@@ -284,7 +310,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// update stock
-		err = utils.AddStock(h.medStore, medData, unit, medicine.Qty, user)
+		err = utils.AddStock(h.medStore, medData, unit, medicine.Qty, user.BranchID, user)
 		if err != nil {
 			err = h.purchaseInvoiceStore.AbsoluteDeletePurchaseInvoice(types.PurchaseInvoice{
 				Number:              payload.Number,
@@ -308,9 +334,23 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		err = utils.RecordControlledSubstanceIn(h.registerStore, medData, unit, medicine.Qty, types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_PURCHASE,
+			ReferenceID:     purchaseInvoiceId,
+			ReferenceNumber: payload.Number,
+			TransactionDate: *invoiceDate,
+			PartyName:       supplier.Name,
+			UserID:          user.ID,
+			BranchID:        user.BranchID,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+			return
+		}
+
 		// update received qty
 		if payload.PurchaseOrderNumber != 0 {
-			err = updateReceivedQty(h, payload.PurchaseOrderNumber, medData, medicine.Qty, unit, user, 1)
+			err = updateReceivedQty(h, payload.PurchaseOrderNumber, medData, medicine.Qty, unit, user.BranchID, user, 1)
 			if err != nil {
 				err = h.purchaseInvoiceStore.AbsoluteDeletePurchaseInvoice(types.PurchaseInvoice{
 					Number:              payload.Number,
@@ -374,7 +414,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// create pdf
-	fileName, err := pdf.CreatePurchaseInvoicePDF(h.purchaseInvoiceStore, purchaseInvoicePdf, "")
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PURCHASE_INVOICE, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	fileName, err := pdf.CreatePurchaseInvoicePDF(h.purchaseInvoiceStore, purchaseInvoicePdf, "", branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating pdf: %v", err))
 		return
@@ -386,6 +432,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	publishPurchaseInvoiceEvent(h, constants.EVENT_PURCHASE_INVOICE_CREATED, purchaseInvoiceId, user)
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("purchase invoice %d successfully created by %s", payload.Number, user.Name))
 }
 
@@ -407,12 +455,18 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	vars := mux.Vars(r)
 	params := vars["params"]
 	val := vars["val"]
@@ -432,7 +486,7 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 	var purchaseInvoices []types.PurchaseInvoiceListsReturnPayload
 
 	if val == "all" {
-		purchaseInvoices, err = h.purchaseInvoiceStore.GetPurchaseInvoicesByDate(*startDate, *endDate)
+		purchaseInvoices, err = h.purchaseInvoiceStore.GetPurchaseInvoicesByDate(*startDate, *endDate, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -445,7 +499,7 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 		}
 
 		purchaseInvoice, err := h.purchaseInvoiceStore.GetPurchaseInvoiceByID(id)
-		if err != nil {
+		if err != nil || (branchId != constants.BRANCH_ALL && purchaseInvoice.BranchID != branchId) {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("purchase invoice id %d not exist", id))
 			return
 		}
@@ -478,7 +532,7 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		purchaseInvoices, err = h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndNumber(*startDate, *endDate, number)
+		purchaseInvoices, err = h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndNumber(*startDate, *endDate, number, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -491,7 +545,7 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 		}
 
 		for _, user := range users {
-			temp, err := h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndUserID(*startDate, *endDate, user.ID)
+			temp, err := h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndUserID(*startDate, *endDate, user.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %s doesn't create any purchase invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -507,7 +561,7 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 		}
 
 		for _, supplier := range suppliers {
-			temp, err := h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndSupplierID(*startDate, *endDate, supplier.ID)
+			temp, err := h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndSupplierID(*startDate, *endDate, supplier.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("supplier %s doesn't create any purchase invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -522,7 +576,7 @@ func (h *Handler) handleGetPurchaseInvoices(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		purchaseInvoices, err = h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndPONumber(*startDate, *endDate, poiNumber)
+		purchaseInvoices, err = h.purchaseInvoiceStore.GetPurchaseInvoicesByDateAndPONumber(*startDate, *endDate, poiNumber, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -553,7 +607,7 @@ func (h *Handler) handleGetPurchaseInvoiceDetail(w http.ResponseWriter, r *http.
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -566,6 +620,12 @@ func (h *Handler) handleGetPurchaseInvoiceDetail(w http.ResponseWriter, r *http.
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, purchaseInvoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// get medicine item of the purchase invoice
 	purchaseMedicineItem, err := h.purchaseInvoiceStore.GetPurchaseMedicineItem(purchaseInvoice.ID)
 	if err != nil {
@@ -675,6 +735,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, purchaseInvoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	purchaseMedicineItem, err := h.purchaseInvoiceStore.GetPurchaseMedicineItem(purchaseInvoice.ID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("purchase medicine item don't exist: %v", err))
@@ -687,6 +753,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_PURCHASE, purchaseInvoice.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
+	}
+
 	// subtract stock and received qty
 	for _, purchaseMedicine := range purchaseMedicineItem {
 		medData, err := h.medStore.GetMedicineByBarcode(purchaseMedicine.MedicineBarcode)
@@ -710,7 +782,7 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = utils.SubtractStock(h.medStore, medData, unit, purchaseMedicine.Qty, user)
+		err = utils.SubtractStock(h.medStore, medData, unit, purchaseMedicine.Qty, purchaseInvoice.BranchID, user)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
 			return
@@ -718,7 +790,7 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 
 		// update received qty
 		if purchaseInvoice.PurchaseOrderNumber != 0 {
-			err = updateReceivedQty(h, purchaseInvoice.PurchaseOrderNumber, medData, purchaseMedicine.Qty, unit, user, 0)
+			err = updateReceivedQty(h, purchaseInvoice.PurchaseOrderNumber, medData, purchaseMedicine.Qty, unit, purchaseInvoice.BranchID, user, 0)
 			if err != nil {
 				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error update received qty: %v", err))
 				return
@@ -732,6 +804,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	utils.PublishEvent(h.eventStore, constants.EVENT_PURCHASE_INVOICE_DELETED, constants.EVENT_ENTITY_PURCHASE_INVOICE, purchaseInvoice.ID, purchaseInvoice.BranchID, user,
+		map[string]interface{}{"purchaseInvoice": purchaseInvoice, "medicineLists": purchaseMedicineItem})
+
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("purchase invoice number %d deleted by %s", purchaseInvoice.Number, user.Name))
 }
 
@@ -766,6 +841,12 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, purchaseInvoice.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// check supplier
 	supplier, err := h.supplierStore.GetSupplierByID(payload.NewData.SupplierID)
 	if err != nil {
@@ -774,7 +855,7 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check purchase order
-	purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByNumber(payload.NewData.PurchaseOrderNumber)
+	purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByNumber(payload.NewData.PurchaseOrderNumber, purchaseInvoice.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("purchase order number %d not exist", payload.NewData.PurchaseOrderNumber))
 		return
@@ -786,6 +867,13 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// tax is calculated from the configured tax, not from the client
+	err = calculateTax(h, &payload.NewData, supplier, *invoiceDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	err = h.purchaseInvoiceStore.ModifyPurchaseInvoice(payload.ID, types.PurchaseInvoice{
 		Number:               payload.NewData.Number,
 		SupplierID:           payload.NewData.SupplierID,
@@ -794,6 +882,7 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		DiscountAmount:       payload.NewData.DiscountAmount,
 		TaxPercentage:        payload.NewData.TaxPercentage,
 		TaxAmount:            payload.NewData.TaxAmount,
+		TaxableAmount:        payload.NewData.TaxableAmount,
 		TotalPrice:           payload.NewData.TotalPrice,
 		Description:          payload.NewData.Description,
 		InvoiceDate:          *invoiceDate,
@@ -816,6 +905,12 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_PURCHASE, purchaseInvoice.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
+	}
+
 	// subtract the stock and received qty
 	for _, purchaseMedicine := range purchaseMedicineItem {
 		medData, err := h.medStore.GetMedicineByBarcode(purchaseMedicine.MedicineBarcode)
@@ -839,7 +934,7 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = utils.SubtractStock(h.medStore, medData, unit, purchaseMedicine.Qty, user)
+		err = utils.SubtractStock(h.medStore, medData, unit, purchaseMedicine.Qty, purchaseInvoice.BranchID, user)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
 			return
@@ -847,7 +942,7 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 
 		// update received qty
 		if purchaseInvoice.PurchaseOrderNumber != 0 {
-			err = updateReceivedQty(h, purchaseInvoice.PurchaseOrderNumber, medData, purchaseMedicine.Qty, unit, user, 0)
+			err = updateReceivedQty(h, purchaseInvoice.PurchaseOrderNumber, medData, purchaseMedicine.Qty, unit, purchaseInvoice.BranchID, user, 0)
 			if err != nil {
 				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error update received qty: %v", err))
 				return
@@ -892,7 +987,13 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// create pdf
-		fileName, err := pdf.CreatePurchaseInvoicePDF(h.purchaseInvoiceStore, purchaseInvoicePdf, purchaseInvoice.PdfURL)
+		branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PURCHASE_INVOICE, purchaseInvoice.BranchID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+			return
+		}
+
+		fileName, err := pdf.CreatePurchaseInvoicePDF(h.purchaseInvoiceStore, purchaseInvoicePdf, purchaseInvoice.PdfURL, branding, h.documentStorage)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating pdf: %v", err))
 			return
@@ -954,15 +1055,29 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// add the stock with the new value
-		err = utils.AddStock(h.medStore, medData, unit, medicine.Qty, user)
+		err = utils.AddStock(h.medStore, medData, unit, medicine.Qty, purchaseInvoice.BranchID, user)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
 			return
 		}
 
+		err = utils.RecordControlledSubstanceIn(h.registerStore, medData, unit, medicine.Qty, types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_PURCHASE,
+			ReferenceID:     purchaseInvoice.ID,
+			ReferenceNumber: payload.NewData.Number,
+			TransactionDate: *invoiceDate,
+			PartyName:       supplier.Name,
+			UserID:          user.ID,
+			BranchID:        purchaseInvoice.BranchID,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+			return
+		}
+
 		// update received qty
 		if purchaseInvoice.PurchaseOrderNumber != 0 {
-			err = updateReceivedQty(h, purchaseInvoice.PurchaseOrderNumber, medData, medicine.Qty, unit, user, 1)
+			err = updateReceivedQty(h, purchaseInvoice.PurchaseOrderNumber, medData, medicine.Qty, unit, purchaseInvoice.BranchID, user, 1)
 			if err != nil {
 				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error update received qty: %v", err))
 				return
@@ -970,6 +1085,8 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	publishPurchaseInvoiceEvent(h, constants.EVENT_PURCHASE_INVOICE_MODIFIED, purchaseInvoice.ID, user)
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("purchase invoice modified by %s", user.Name))
 }
 
@@ -990,7 +1107,7 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -1004,27 +1121,19 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pdfFile := "static/pdf/purchase-invoice/" + purchaseInvoice.PdfURL
-
-	file, err := os.Open(pdfFile)
+	err = utils.CheckBranchAccess(user, purchaseInvoice.BranchID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("purchase invoice id %d file not found", payload.ID))
+		utils.WriteError(w, http.StatusForbidden, err)
 		return
 	}
-	defer file.Close()
 
-	attachment := fmt.Sprintf("attachment; filename=%s", pdfFile)
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", attachment)
-	w.WriteHeader(http.StatusOK)
-
-	http.ServeFile(w, r, pdfFile)
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_PURCHASE_INVOICE, purchaseInvoice.PdfURL)
 }
 
 // req_type == 0, means subtract
 // req_typ == 1, means add
-func updateReceivedQty(h *Handler, poinn int, medData *types.Medicine, addQty float64, receivedPurchasedUnit *types.Unit, user *types.User, req_type int) error {
-	purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByNumber(poinn)
+func updateReceivedQty(h *Handler, poinn int, medData *types.Medicine, addQty float64, receivedPurchasedUnit *types.Unit, branchId int, user *types.User, req_type int) error {
+	purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByNumber(poinn, branchId)
 	if err != nil {
 		return fmt.Errorf("purchase order invoice %d not found: %v", poinn, err)
 	}
@@ -1144,3 +1253,69 @@ func subtractReceivedQty(h *Handler, medData *types.Medicine, purchaseOrderMed *
 
 	return nil
 }
+
+// only the taxable medicines from a taxable vendor are taxed, the total price is recalculated
+func calculateTax(h *Handler, payload *types.RegisterPurchaseInvoicePayload, supplier *types.SupplierInformationReturnPayload, invoiceDate time.Time) error {
+	var taxableSubtotal float64
+	taxableItems := make([]bool, len(payload.MedicineLists))
+
+	for i, medicine := range payload.MedicineLists {
+		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
+		if err != nil {
+			return fmt.Errorf("medicine %s doesn't exists", medicine.MedicineName)
+		}
+
+		taxableItems[i] = supplier.VendorIsTaxable && medData.IsTaxable
+		if taxableItems[i] {
+			taxableSubtotal += medicine.Subtotal
+		}
+	}
+
+	// the tax is only needed if something is taxed
+	taxPercentage := 0.0
+	if taxableSubtotal > 0 {
+		var err error
+		taxPercentage, err = utils.GetTaxPercentage(h.taxStore, invoiceDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, medicine := range payload.MedicineLists {
+		if !taxableItems[i] {
+			payload.MedicineLists[i].TaxPercentage = 0
+			payload.MedicineLists[i].TaxAmount = 0
+			continue
+		}
+
+		payload.MedicineLists[i].TaxPercentage = taxPercentage
+		payload.MedicineLists[i].TaxAmount = utils.CalculateTax(medicine.Subtotal, taxPercentage)
+	}
+
+	taxableAmount := utils.GetTaxableAmount(taxableSubtotal, payload.Subtotal, payload.DiscountAmount)
+
+	payload.TaxPercentage = taxPercentage
+	payload.TaxAmount = utils.CalculateTax(taxableAmount, taxPercentage)
+	payload.TaxableAmount = taxableAmount
+	payload.TotalPrice = payload.Subtotal - payload.DiscountAmount + payload.TaxAmount
+
+	return nil
+}
+
+// the purchase invoice is read again so the event has the saved data
+func publishPurchaseInvoiceEvent(h *Handler, eventType string, purchaseInvoiceId int, user *types.User) {
+	purchaseInvoice, err := h.purchaseInvoiceStore.GetPurchaseInvoiceByID(purchaseInvoiceId)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "purchaseInvoiceId", purchaseInvoiceId, "error", err)
+		return
+	}
+
+	medicineItems, err := h.purchaseInvoiceStore.GetPurchaseMedicineItem(purchaseInvoiceId)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "purchaseInvoiceId", purchaseInvoiceId, "error", err)
+		return
+	}
+
+	utils.PublishEvent(h.eventStore, eventType, constants.EVENT_ENTITY_PURCHASE_INVOICE, purchaseInvoice.ID, purchaseInvoice.BranchID, user,
+		map[string]interface{}{"purchaseInvoice": purchaseInvoice, "medicineLists": medicineItems})
+}
@@ -97,23 +97,24 @@ func (s *Store) GetPurchaseInvoiceID(number int, supplierId int, subtotal float6
 
 func (s *Store) CreatePurchaseInvoice(purchaseInvoice types.PurchaseInvoice) error {
 	values := "?"
-	for i := 0; i < 12; i++ {
+	for i := 0; i < 14; i++ {
 		values += ", ?"
 	}
 
 	query := `INSERT INTO purchase_invoice (
 		number, supplier_id, purchase_order_number, subtotal, discount_percentage, 
-		discount_amount, tax_percentage, tax_amount,  
-		total_price, description, user_id, invoice_date, last_modified_by_user_id
+		discount_amount, tax_percentage, tax_amount, taxable_amount, 
+		total_price, description, user_id, invoice_date, last_modified_by_user_id, branch_id
 	) VALUES (` + values + `)`
 
 	_, err := s.db.Exec(query,
 		purchaseInvoice.Number, purchaseInvoice.SupplierID,
 		purchaseInvoice.PurchaseOrderNumber, purchaseInvoice.Subtotal,
 		purchaseInvoice.DiscountPercentage, purchaseInvoice.DiscountAmount,
-		purchaseInvoice.TaxPercentage, purchaseInvoice.TaxAmount, purchaseInvoice.TotalPrice,
+		purchaseInvoice.TaxPercentage, purchaseInvoice.TaxAmount, purchaseInvoice.TaxableAmount,
+		purchaseInvoice.TotalPrice,
 		purchaseInvoice.Description, purchaseInvoice.UserID, purchaseInvoice.InvoiceDate,
-		purchaseInvoice.UserID)
+		purchaseInvoice.UserID, purchaseInvoice.BranchID)
 	if err != nil {
 		return err
 	}
@@ -183,7 +184,7 @@ func (s *Store) GetPurchaseMedicineItem(purchaseInvoiceId int) ([]types.Purchase
 	return purchaseMedicineItems, nil
 }
 
-func (s *Store) GetPurchaseInvoicesByDate(startDate time.Time, endDate time.Time) ([]types.PurchaseInvoiceListsReturnPayload, error) {
+func (s *Store) GetPurchaseInvoicesByDate(startDate time.Time, endDate time.Time, branchId int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
 	query := `SELECT pi.id, pi.number, 
 				supplier.name, 
 				pi.purchase_order_number, 
@@ -195,9 +196,10 @@ func (s *Store) GetPurchaseInvoicesByDate(startDate time.Time, endDate time.Time
 				JOIN user ON user.id = pi.user_id 
 				WHERE pi.invoice_date >= ? AND pi.invoice_date < ? 
 				AND pi.deleted_at IS NULL 
+				AND (? = 0 OR pi.branch_id = ?) 
 				ORDER BY pi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate)
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -218,14 +220,15 @@ func (s *Store) GetPurchaseInvoicesByDate(startDate time.Time, endDate time.Time
 	return purchaseInvoices, nil
 }
 
-func (s *Store) GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
+func (s *Store) GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
 	query := `SELECT COUNT(*) 
 				FROM purchase_invoice 
 				WHERE invoice_date >= ? AND invoice_date < ? 
 				AND number = ? 
-				AND deleted_at IS NULL`
+				AND deleted_at IS NULL 
+				AND (? = 0 OR purchase_invoice.branch_id = ?)`
 
-	row := s.db.QueryRow(query, startDate, endDate, number)
+	row := s.db.QueryRow(query, startDate, endDate, number, branchId, branchId)
 	if row.Err() != nil {
 		return nil, row.Err()
 	}
@@ -252,6 +255,7 @@ func (s *Store) GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate
 					WHERE pi.invoice_date >= ? AND pi.invoice_date < ? 
 					AND number LIKE ?
 					AND pi.deleted_at IS NULL 
+					AND (? = 0 OR pi.branch_id = ?) 
 					ORDER BY pi.invoice_date DESC`
 
 		searchVal := "%"
@@ -261,7 +265,7 @@ func (s *Store) GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate
 			}
 		}
 
-		rows, err := s.db.Query(query, startDate, endDate, searchVal)
+		rows, err := s.db.Query(query, startDate, endDate, searchVal, branchId, branchId)
 		if err != nil {
 			return nil, err
 		}
@@ -291,9 +295,10 @@ func (s *Store) GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate
 					WHERE pi.invoice_date >= ? AND pi.invoice_date < ? 
 					AND number = ?
 					AND pi.deleted_at IS NULL 
+					AND (? = 0 OR pi.branch_id = ?) 
 					ORDER BY pi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, number)
+	rows, err := s.db.Query(query, startDate, endDate, number, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -312,7 +317,7 @@ func (s *Store) GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate
 	return purchaseInvoices, nil
 }
 
-func (s *Store) GetPurchaseInvoicesByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
+func (s *Store) GetPurchaseInvoicesByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int, branchId int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
 	query := `SELECT pi.id, pi.number, 
 				supplier.name, 
 				pi.purchase_order_number, 
@@ -325,9 +330,10 @@ func (s *Store) GetPurchaseInvoicesByDateAndSupplierID(startDate time.Time, endD
 				WHERE pi.invoice_date >= ? AND pi.invoice_date < ? 
 				AND pi.supplier_id = ? 
 				AND pi.deleted_at IS NULL 
+				AND (? = 0 OR pi.branch_id = ?) 
 				ORDER BY pi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, sid)
+	rows, err := s.db.Query(query, startDate, endDate, sid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -348,7 +354,7 @@ func (s *Store) GetPurchaseInvoicesByDateAndSupplierID(startDate time.Time, endD
 	return purchaseInvoices, nil
 }
 
-func (s *Store) GetPurchaseInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
+func (s *Store) GetPurchaseInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
 	query := `SELECT pi.id, pi.number, 
 				supplier.name, 
 				pi.purchase_order_number, 
@@ -361,9 +367,10 @@ func (s *Store) GetPurchaseInvoicesByDateAndUserID(startDate time.Time, endDate
 				WHERE pi.invoice_date >= ? AND pi.invoice_date < ? 
 				AND pi.user_id = ? 
 				AND pi.deleted_at IS NULL 
+				AND (? = 0 OR pi.branch_id = ?) 
 				ORDER BY pi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uid)
+	rows, err := s.db.Query(query, startDate, endDate, uid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -384,7 +391,7 @@ func (s *Store) GetPurchaseInvoicesByDateAndUserID(startDate time.Time, endDate
 	return purchaseInvoices, nil
 }
 
-func (s *Store) GetPurchaseInvoicesByDateAndPONumber(startDate time.Time, endDate time.Time, poiNumber int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
+func (s *Store) GetPurchaseInvoicesByDateAndPONumber(startDate time.Time, endDate time.Time, poiNumber int, branchId int) ([]types.PurchaseInvoiceListsReturnPayload, error) {
 	query := `SELECT pi.id, pi.number, 
 				supplier.name, 
 				pi.purchase_order_number, 
@@ -397,9 +404,10 @@ func (s *Store) GetPurchaseInvoicesByDateAndPONumber(startDate time.Time, endDat
 				WHERE pi.invoice_date >= ? AND pi.invoice_date < ? 
 				AND pi.purchase_order_number = ? 
 				AND pi.deleted_at IS NULL 
+				AND (? = 0 OR pi.branch_id = ?) 
 				ORDER BY pi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, poiNumber)
+	rows, err := s.db.Query(query, startDate, endDate, poiNumber, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -482,7 +490,7 @@ func (s *Store) ModifyPurchaseInvoice(piid int, purchaseInvoice types.PurchaseIn
 	query := `UPDATE purchase_invoice SET 
 				number = ?, supplier_id = ?, purchase_order_number = ?, 
 				subtotal = ?, discount_percentage = ?, discount_amount = ?, 
-				tax_percentage = ?, tax_amount = ?, total_price = ?, description = ?, 
+				tax_percentage = ?, tax_amount = ?, taxable_amount = ?, total_price = ?, description = ?, 
 				invoice_date = ?, last_modified = ?, last_modified_by_user_id = ? 
 				 WHERE id = ?`
 
@@ -490,7 +498,7 @@ func (s *Store) ModifyPurchaseInvoice(piid int, purchaseInvoice types.PurchaseIn
 		purchaseInvoice.Number, purchaseInvoice.SupplierID,
 		purchaseInvoice.PurchaseOrderNumber, purchaseInvoice.Subtotal,
 		purchaseInvoice.DiscountPercentage, purchaseInvoice.DiscountAmount,
-		purchaseInvoice.TaxPercentage, purchaseInvoice.TaxAmount,
+		purchaseInvoice.TaxPercentage, purchaseInvoice.TaxAmount, purchaseInvoice.TaxableAmount,
 		purchaseInvoice.TotalPrice,
 		purchaseInvoice.Description, purchaseInvoice.InvoiceDate,
 		time.Now(), purchaseInvoice.LastModifiedByUserID, piid)
@@ -591,6 +599,8 @@ func scanRowIntoPurchaseInvoice(rows *sql.Rows) (*types.PurchaseInvoice, error)
 		&purchaseInvoice.PdfURL,
 		&purchaseInvoice.DeletedAt,
 		&purchaseInvoice.DeletedByUserID,
+		&purchaseInvoice.BranchID,
+		&purchaseInvoice.TaxableAmount,
 	)
 
 	if err != nil {
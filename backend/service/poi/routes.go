@@ -3,34 +3,41 @@ package poi
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 	"github.com/nicolaics/pharmacon/utils/pdf"
 )
 
 type Handler struct {
-	poInvoiceStore types.PurchaseOrderStore
-	userStore      types.UserStore
-	supplierStore  types.SupplierStore
-	medStore       types.MedicineStore
-	unitStore      types.UnitStore
+	poInvoiceStore  types.PurchaseOrderStore
+	userStore       types.UserStore
+	supplierStore   types.SupplierStore
+	medStore        types.MedicineStore
+	unitStore       types.UnitStore
+	branchStore     types.BranchStore
+	settingStore    types.SettingStore
+	documentStorage types.DocumentStorage
 }
 
 func NewHandler(poInvoiceStore types.PurchaseOrderStore, userStore types.UserStore,
 	supplierStore types.SupplierStore,
-	medStore types.MedicineStore, unitStore types.UnitStore) *Handler {
+	medStore types.MedicineStore, unitStore types.UnitStore,
+	branchStore types.BranchStore, settingStore types.SettingStore, documentStorage types.DocumentStorage) *Handler {
 	return &Handler{
-		poInvoiceStore: poInvoiceStore,
-		userStore:      userStore,
-		supplierStore:  supplierStore,
-		medStore:       medStore,
-		unitStore:      unitStore,
+		poInvoiceStore:  poInvoiceStore,
+		userStore:       userStore,
+		supplierStore:   supplierStore,
+		medStore:        medStore,
+		unitStore:       unitStore,
+		branchStore:     branchStore,
+		settingStore:    settingStore,
+		documentStorage: documentStorage,
 	}
 }
 
@@ -86,7 +93,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check duplicate
-	purchaseOrderId, err := h.poInvoiceStore.GetPurchaseOrderID(payload.Number, payload.SupplierID, payload.TotalItem, *invoiceDate)
+	purchaseOrderId, err := h.poInvoiceStore.GetPurchaseOrderID(payload.Number, payload.SupplierID, payload.TotalItem, *invoiceDate, user.BranchID)
 	if err == nil || purchaseOrderId != 0 {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("purchase order invoice number %d exists", payload.Number))
 		return
@@ -99,6 +106,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		TotalItem:            payload.TotalItem,
 		InvoiceDate:          *invoiceDate,
 		LastModifiedByUserID: user.ID,
+		BranchID:             user.BranchID,
 	})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -106,7 +114,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get purchaseInvoice ID
-	purchaseOrderId, err = h.poInvoiceStore.GetPurchaseOrderID(payload.Number, payload.SupplierID, payload.TotalItem, *invoiceDate)
+	purchaseOrderId, err = h.poInvoiceStore.GetPurchaseOrderID(payload.Number, payload.SupplierID, payload.TotalItem, *invoiceDate, user.BranchID)
 	if err != nil {
 		err = h.poInvoiceStore.AbsoluteDeletePurchaseOrder(types.PurchaseOrder{
 			Number:      payload.Number,
@@ -216,7 +224,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		MedicineLists: payload.MedicineLists,
 		Supplier:      *supplier,
 	}
-	fileName, err := pdf.CreatePurchaseOrderInvoicePDF(h.poInvoiceStore, poiPdf, "")
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PURCHASE_ORDER, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	fileName, err := pdf.CreatePurchaseOrderInvoicePDF(h.poInvoiceStore, poiPdf, "", branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("saved in database but failed to create pdf: %v", err))
 		return
@@ -234,13 +248,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 // beginning of po invoice page, will request here
 func (h *Handler) handleGetPOnvoiceNumberForToday(w http.ResponseWriter, r *http.Request) {
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
-	numberOfInvoices, err := h.poInvoiceStore.GetNumberOfPurchaseOrders()
+	numberOfInvoices, err := h.poInvoiceStore.GetNumberOfPurchaseOrders(user.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -267,12 +281,18 @@ func (h *Handler) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	vars := mux.Vars(r)
 	params := vars["params"]
 	val := vars["val"]
@@ -292,7 +312,7 @@ func (h *Handler) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request
 	var purchaseOrders []types.PurchaseOrderListsReturnPayload
 
 	if val == "all" {
-		purchaseOrders, err = h.poInvoiceStore.GetPurchaseOrdersByDate(*startDate, *endDate)
+		purchaseOrders, err = h.poInvoiceStore.GetPurchaseOrdersByDate(*startDate, *endDate, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -305,7 +325,7 @@ func (h *Handler) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request
 		}
 
 		purchaseOrder, err := h.poInvoiceStore.GetPurchaseOrderByID(id)
-		if err != nil {
+		if err != nil || (branchId != constants.BRANCH_ALL && purchaseOrder.BranchID != branchId) {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("purchase order id %d not exist", id))
 			return
 		}
@@ -337,7 +357,7 @@ func (h *Handler) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request
 			return
 		}
 
-		purchaseOrders, err = h.poInvoiceStore.GetPurchaseOrdersByDateAndNumber(*startDate, *endDate, number)
+		purchaseOrders, err = h.poInvoiceStore.GetPurchaseOrdersByDateAndNumber(*startDate, *endDate, number, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -350,7 +370,7 @@ func (h *Handler) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request
 		}
 
 		for _, user := range users {
-			temp, err := h.poInvoiceStore.GetPurchaseOrdersByDateAndUserID(*startDate, *endDate, user.ID)
+			temp, err := h.poInvoiceStore.GetPurchaseOrdersByDateAndUserID(*startDate, *endDate, user.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %s doesn't create any po invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -366,7 +386,7 @@ func (h *Handler) handleGetPurchaseOrders(w http.ResponseWriter, r *http.Request
 		}
 
 		for _, supplier := range suppliers {
-			temp, err := h.poInvoiceStore.GetPurchaseOrdersByDateAndSupplierID(*startDate, *endDate, supplier.ID)
+			temp, err := h.poInvoiceStore.GetPurchaseOrdersByDateAndSupplierID(*startDate, *endDate, supplier.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("supplier %s doesn't create any po invoice between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -400,7 +420,7 @@ func (h *Handler) handleGetPurchaseOrderDetail(w http.ResponseWriter, r *http.Re
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -413,6 +433,12 @@ func (h *Handler) handleGetPurchaseOrderDetail(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, purchaseOrder.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// get medicine item of the purchase invoice
 	purchaseOrderItem, err := h.poInvoiceStore.GetPurchaseOrderItem(payload.ID)
 	if err != nil {
@@ -515,6 +541,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, purchaseOrder.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	err = h.poInvoiceStore.DeletePurchaseOrderItem(purchaseOrder, user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -568,6 +600,12 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, purchaseOrder.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	invoiceDate, err := utils.ParseDate(payload.NewData.InvoiceDate)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
@@ -636,7 +674,13 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		MedicineLists: payload.NewData.MedicineLists,
 		Supplier:      *supplier,
 	}
-	fileName, err := pdf.CreatePurchaseOrderInvoicePDF(h.poInvoiceStore, poiPdf, purchaseOrder.PdfURL)
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PURCHASE_ORDER, purchaseOrder.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	fileName, err := pdf.CreatePurchaseOrderInvoicePDF(h.poInvoiceStore, poiPdf, purchaseOrder.PdfURL, branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("saved in database but failed to create pdf: %v", err))
 		return
@@ -668,7 +712,7 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -682,19 +726,11 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pdfFile := "static/pdf/purchase-order/" + purchaseInvoice.PdfURL
-
-	file, err := os.Open(pdfFile)
+	err = utils.CheckBranchAccess(user, purchaseInvoice.BranchID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("purchase order id %d file not found", payload.ID))
+		utils.WriteError(w, http.StatusForbidden, err)
 		return
 	}
-	defer file.Close()
-
-	attachment := fmt.Sprintf("attachment; filename=%s", pdfFile)
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", attachment)
-	w.WriteHeader(http.StatusOK)
 
-	http.ServeFile(w, r, pdfFile)
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_PURCHASE_ORDER, purchaseInvoice.PdfURL)
 }
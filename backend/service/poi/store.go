@@ -18,9 +18,9 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) GetPurchaseOrderByNumber(number int) (*types.PurchaseOrder, error) {
-	query := "SELECT * FROM purchase_order WHERE number = ? AND deleted_at IS NULL ORDER BY invoice_date DESC"
-	rows, err := s.db.Query(query, number)
+func (s *Store) GetPurchaseOrderByNumber(number int, branchId int) (*types.PurchaseOrder, error) {
+	query := "SELECT * FROM purchase_order WHERE number = ? AND branch_id = ? AND deleted_at IS NULL ORDER BY invoice_date DESC"
+	rows, err := s.db.Query(query, number, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -68,14 +68,15 @@ func (s *Store) GetPurchaseOrderByID(id int) (*types.PurchaseOrder, error) {
 	return purchaseOrder, nil
 }
 
-func (s *Store) GetPurchaseOrderID(number int, supplierId int, totalItem int, invoiceDate time.Time) (int, error) {
+func (s *Store) GetPurchaseOrderID(number int, supplierId int, totalItem int, invoiceDate time.Time, branchId int) (int, error) {
 	query := `SELECT id FROM purchase_order 
 				WHERE number = ? 
 				AND supplier_id = ? AND total_item = ? 
-				AND invoice_date = ? AND deleted_at IS NULL 
+				AND invoice_date = ? AND branch_id = ? 
+				AND deleted_at IS NULL 
 				ORDER BY invoice_date DESC`
 
-	rows, err := s.db.Query(query, number, supplierId, totalItem, invoiceDate)
+	rows, err := s.db.Query(query, number, supplierId, totalItem, invoiceDate, branchId)
 	if err != nil {
 		return 0, err
 	}
@@ -97,9 +98,9 @@ func (s *Store) GetPurchaseOrderID(number int, supplierId int, totalItem int, in
 	return purchaseOrderId, nil
 }
 
-func (s *Store) GetNumberOfPurchaseOrders() (int, error) {
-	query := `SELECT COUNT(*) FROM purchase_order`
-	row := s.db.QueryRow(query)
+func (s *Store) GetNumberOfPurchaseOrders(branchId int) (int, error) {
+	query := `SELECT COUNT(*) FROM purchase_order WHERE branch_id = ?`
+	row := s.db.QueryRow(query, branchId)
 	if row.Err() != nil {
 		return -1, row.Err()
 	}
@@ -116,19 +117,19 @@ func (s *Store) GetNumberOfPurchaseOrders() (int, error) {
 
 func (s *Store) CreatePurchaseOrder(poInvoice types.PurchaseOrder) error {
 	values := "?"
-	for i := 0; i < 5; i++ {
+	for i := 0; i < 6; i++ {
 		values += ", ?"
 	}
 
 	query := `INSERT INTO purchase_order (
 		number, supplier_id, user_id, total_item, 
-		invoice_date, last_modified_by_user_id
+		invoice_date, last_modified_by_user_id, branch_id
 	) VALUES (` + values + `)`
 
 	_, err := s.db.Exec(query,
 		poInvoice.Number, poInvoice.SupplierID,
 		poInvoice.UserID, poInvoice.TotalItem, poInvoice.InvoiceDate,
-		poInvoice.LastModifiedByUserID)
+		poInvoice.LastModifiedByUserID, poInvoice.BranchID)
 	if err != nil {
 		return err
 	}
@@ -156,7 +157,7 @@ func (s *Store) CreatePurchaseOrderItem(purchaseMedItem types.PurchaseOrderItem)
 	return nil
 }
 
-func (s *Store) GetPurchaseOrdersByDate(startDate time.Time, endDate time.Time) ([]types.PurchaseOrderListsReturnPayload, error) {
+func (s *Store) GetPurchaseOrdersByDate(startDate time.Time, endDate time.Time, branchId int) ([]types.PurchaseOrderListsReturnPayload, error) {
 	query := `SELECT poi.id, poi.number, 
 					supplier.name, user.name, 
 					poi.total_item, poi.invoice_date 
@@ -165,9 +166,10 @@ func (s *Store) GetPurchaseOrdersByDate(startDate time.Time, endDate time.Time)
 					JOIN user ON poi.user_id = user.id 
 					WHERE poi.invoice_date >= ? AND poi.invoice_date < ? 
 					AND poi.deleted_at IS NULL 
+					AND (? = 0 OR poi.branch_id = ?) 
 					ORDER BY poi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate)
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -188,14 +190,15 @@ func (s *Store) GetPurchaseOrdersByDate(startDate time.Time, endDate time.Time)
 	return purchaseOrders, nil
 }
 
-func (s *Store) GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]types.PurchaseOrderListsReturnPayload, error) {
+func (s *Store) GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]types.PurchaseOrderListsReturnPayload, error) {
 	query := `SELECT COUNT(*)
 					FROM purchase_order 
 					WHERE invoice_date >= ? AND invoice_date < ? 
 					AND number = ? 
-					AND deleted_at IS NULL`
+					AND deleted_at IS NULL 
+					AND (? = 0 OR purchase_order.branch_id = ?)`
 
-	row := s.db.QueryRow(query, startDate, endDate, number)
+	row := s.db.QueryRow(query, startDate, endDate, number, branchId, branchId)
 	if row.Err() != nil {
 		return nil, row.Err()
 	}
@@ -219,6 +222,7 @@ func (s *Store) GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate ti
 					WHERE poi.invoice_date >= ? AND poi.invoice_date < ? 
 					AND poi.number LIKE ? 
 					AND poi.deleted_at IS NULL 
+					AND (? = 0 OR poi.branch_id = ?) 
 					ORDER BY poi.invoice_date DESC`
 
 		searchVal := "%"
@@ -228,7 +232,7 @@ func (s *Store) GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate ti
 			}
 		}
 
-		rows, err := s.db.Query(query, startDate, endDate, searchVal)
+		rows, err := s.db.Query(query, startDate, endDate, searchVal, branchId, branchId)
 		if err != nil {
 			return nil, err
 		}
@@ -255,9 +259,10 @@ func (s *Store) GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate ti
 					WHERE poi.invoice_date >= ? AND poi.invoice_date < ? 
 					AND poi.number = ? 
 					AND poi.deleted_at IS NULL 
+					AND (? = 0 OR poi.branch_id = ?) 
 					ORDER BY poi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, number)
+	rows, err := s.db.Query(query, startDate, endDate, number, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -276,7 +281,7 @@ func (s *Store) GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate ti
 	return purchaseOrders, nil
 }
 
-func (s *Store) GetPurchaseOrdersByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]types.PurchaseOrderListsReturnPayload, error) {
+func (s *Store) GetPurchaseOrdersByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]types.PurchaseOrderListsReturnPayload, error) {
 	query := `SELECT poi.id, poi.number, 
 					supplier.name, user.name, 
 					poi.total_item, poi.invoice_date 
@@ -286,9 +291,10 @@ func (s *Store) GetPurchaseOrdersByDateAndUserID(startDate time.Time, endDate ti
 					WHERE poi.invoice_date >= ? AND poi.invoice_date < ? 
 					AND poi.user_id = ? 
 					AND poi.deleted_at IS NULL 
+					AND (? = 0 OR poi.branch_id = ?) 
 					ORDER BY poi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uid)
+	rows, err := s.db.Query(query, startDate, endDate, uid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -309,7 +315,7 @@ func (s *Store) GetPurchaseOrdersByDateAndUserID(startDate time.Time, endDate ti
 	return purchaseOrders, nil
 }
 
-func (s *Store) GetPurchaseOrdersByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int) ([]types.PurchaseOrderListsReturnPayload, error) {
+func (s *Store) GetPurchaseOrdersByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int, branchId int) ([]types.PurchaseOrderListsReturnPayload, error) {
 	query := `SELECT poi.id, poi.number, 
 					supplier.name, user.name, 
 					poi.total_item, poi.invoice_date 
@@ -319,9 +325,10 @@ func (s *Store) GetPurchaseOrdersByDateAndSupplierID(startDate time.Time, endDat
 					WHERE poi.invoice_date >= ? AND poi.invoice_date < ? 
 					AND poi.supplier_id = ? 
 					AND poi.deleted_at IS NULL 
+					AND (? = 0 OR poi.branch_id = ?) 
 					ORDER BY poi.invoice_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, sid)
+	rows, err := s.db.Query(query, startDate, endDate, sid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -571,6 +578,7 @@ func scanRowIntoPurchaseOrder(rows *sql.Rows) (*types.PurchaseOrder, error) {
 		&purchaseOrder.LastModifiedByUserID,
 		&purchaseOrder.DeletedAt,
 		&purchaseOrder.DeletedByUserID,
+		&purchaseOrder.BranchID,
 	)
 
 	if err != nil {
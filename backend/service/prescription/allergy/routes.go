@@ -0,0 +1,148 @@
+package allergy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	allergyStore types.PatientAllergyStore
+	patientStore types.PatientStore
+	userStore    types.UserStore
+}
+
+func NewHandler(allergyStore types.PatientAllergyStore, patientStore types.PatientStore, userStore types.UserStore) *Handler {
+	return &Handler{allergyStore: allergyStore, patientStore: patientStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/patient/allergy", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/patient/allergy/{patientId}", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/patient/allergy", h.handleDelete).Methods(http.MethodDelete)
+
+	router.HandleFunc("/patient/allergy", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/patient/allergy/{patientId}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.RegisterPatientAllergyPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// check if the patient exists
+	patient, err := h.patientStore.GetPatientByID(payload.PatientID)
+	if err != nil || patient == nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("patient with id %d doesn't exists", payload.PatientID))
+		return
+	}
+
+	// allergy without severity is treated as major
+	severity := strings.ToUpper(payload.Severity)
+	if severity == "" {
+		severity = constants.SEVERITY_MAJOR
+	}
+
+	err = h.allergyStore.CreateAllergy(types.PatientAllergy{
+		PatientID: patient.ID,
+		Allergen:  strings.ToUpper(payload.Allergen),
+		Reaction:  payload.Reaction,
+		Severity:  severity,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("allergy %s for %s successfully created by %s",
+		payload.Allergen, patient.Name, user.Name))
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	vars := mux.Vars(r)
+	patientId, err := strconv.Atoi(vars["patientId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid patient id %s", vars["patientId"]))
+		return
+	}
+
+	allergies, err := h.allergyStore.GetAllergiesByPatientID(patientId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, allergies)
+}
+
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DeletePatientAllergyPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	allergy, err := h.allergyStore.GetAllergyByID(payload.ID)
+	if allergy == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("allergy id %d doesn't exist", payload.ID))
+		return
+	}
+
+	err = h.allergyStore.DeleteAllergy(allergy, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("allergy %s deleted by %s", allergy.Allergen, user.Name))
+}
@@ -0,0 +1,116 @@
+package allergy
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetAllergyByID(id int) (*types.PatientAllergy, error) {
+	query := "SELECT * FROM patient_allergy WHERE id = ?"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergy := new(types.PatientAllergy)
+
+	for rows.Next() {
+		allergy, err = scanRowIntoAllergy(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if allergy.ID == 0 {
+		return nil, fmt.Errorf("allergy not found")
+	}
+
+	return allergy, nil
+}
+
+func (s *Store) GetAllergiesByPatientID(patientId int) ([]types.PatientAllergy, error) {
+	query := "SELECT * FROM patient_allergy WHERE patient_id = ? ORDER BY allergen ASC"
+	rows, err := s.db.Query(query, patientId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	allergies := make([]types.PatientAllergy, 0)
+
+	for rows.Next() {
+		allergy, err := scanRowIntoAllergy(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allergies = append(allergies, *allergy)
+	}
+
+	return allergies, nil
+}
+
+func (s *Store) CreateAllergy(allergy types.PatientAllergy) error {
+	_, err := s.db.Exec("INSERT INTO patient_allergy (patient_id, allergen, reaction, severity) VALUES (?, ?, ?, ?)",
+		allergy.PatientID, allergy.Allergen, allergy.Reaction, allergy.Severity)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteAllergy(allergy *types.PatientAllergy, user *types.User) error {
+	data, err := s.GetAllergyByID(allergy.ID)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("delete", "patient-allergy", user.Name, data.ID, data)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	_, err = s.db.Exec("DELETE FROM patient_allergy WHERE id = ?", allergy.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanRowIntoAllergy(rows *sql.Rows) (*types.PatientAllergy, error) {
+	allergy := new(types.PatientAllergy)
+
+	err := rows.Scan(
+		&allergy.ID,
+		&allergy.PatientID,
+		&allergy.Allergen,
+		&allergy.Reaction,
+		&allergy.Severity,
+		&allergy.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	allergy.CreatedAt = allergy.CreatedAt.Local()
+
+	return allergy, nil
+}
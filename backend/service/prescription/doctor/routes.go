@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -63,9 +64,14 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.doctorStore.CreateDoctor(types.Doctor{
-		Name: payload.Name,
-	})
+	_, err = h.doctorStore.GetDoctorByLicenseNumber(strings.TrimSpace(payload.LicenseNumber))
+	if err == nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("doctor with license number %s already exists", payload.LicenseNumber))
+		return
+	}
+
+	err = h.doctorStore.CreateDoctor(parseDoctor(payload))
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -220,14 +226,22 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.doctorStore.GetDoctorByName(payload.NewData.Name)
-	if err == nil {
+	// the same name or license number can only be kept by the same doctor
+	temp, err := h.doctorStore.GetDoctorByName(payload.NewData.Name)
+	if err == nil && temp.ID != doctor.ID {
 		utils.WriteError(w, http.StatusBadRequest,
 			fmt.Errorf("doctor with name %s already exist", payload.NewData.Name))
 		return
 	}
 
-	err = h.doctorStore.ModifyDoctor(doctor.ID, payload.NewData.Name, user)
+	temp, err = h.doctorStore.GetDoctorByLicenseNumber(strings.TrimSpace(payload.NewData.LicenseNumber))
+	if err == nil && temp.ID != doctor.ID {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("doctor with license number %s already exist", payload.NewData.LicenseNumber))
+		return
+	}
+
+	err = h.doctorStore.ModifyDoctor(doctor.ID, parseDoctor(payload.NewData), user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -236,3 +250,14 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("doctor modified into %s by %s",
 		payload.NewData.Name, user.Name))
 }
+
+func parseDoctor(payload types.RegisterDoctorPayload) types.Doctor {
+	return types.Doctor{
+		Name:          payload.Name,
+		LicenseNumber: strings.TrimSpace(payload.LicenseNumber),
+		Specialty:     payload.Specialty,
+		Clinic:        payload.Clinic,
+		Address:       payload.Address,
+		PhoneNumber:   payload.PhoneNumber,
+	}
+}
@@ -3,6 +3,7 @@ package doctor
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/nicolaics/pharmacon/logger"
 	"github.com/nicolaics/pharmacon/types"
@@ -17,7 +18,7 @@ func NewStore(db *sql.DB) *Store {
 }
 
 func (s *Store) GetDoctorByName(name string) (*types.Doctor, error) {
-	query := "SELECT * FROM doctor WHERE name = ? ORDER BY name ASC"
+	query := "SELECT * FROM doctor WHERE name = ? AND deleted_at IS NULL ORDER BY name ASC"
 	rows, err := s.db.Query(query, name)
 	if err != nil {
 		return nil, err
@@ -41,8 +42,33 @@ func (s *Store) GetDoctorByName(name string) (*types.Doctor, error) {
 	return doctor, nil
 }
 
+func (s *Store) GetDoctorByLicenseNumber(licenseNumber string) (*types.Doctor, error) {
+	query := "SELECT * FROM doctor WHERE license_number = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, licenseNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	doctor := new(types.Doctor)
+
+	for rows.Next() {
+		doctor, err = scanRowIntoDoctor(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if doctor.ID == 0 {
+		return nil, fmt.Errorf("doctor not found")
+	}
+
+	return doctor, nil
+}
+
 func (s *Store) GetDoctorsBySearchName(name string) ([]types.Doctor, error) {
-	query := "SELECT COUNT(*) FROM doctor WHERE name = ?"
+	query := "SELECT COUNT(*) FROM doctor WHERE name = ? AND deleted_at IS NULL"
 	row := s.db.QueryRow(query, name)
 	if row.Err() != nil {
 		return nil, row.Err()
@@ -58,7 +84,7 @@ func (s *Store) GetDoctorsBySearchName(name string) ([]types.Doctor, error) {
 	doctors := make([]types.Doctor, 0)
 
 	if count == 0 {
-		query = "SELECT * FROM doctor WHERE name LIKE ? ORDER BY name ASC"
+		query = "SELECT * FROM doctor WHERE name LIKE ? AND deleted_at IS NULL ORDER BY name ASC"
 		searchVal := "%"
 
 		for _, val := range name {
@@ -86,7 +112,7 @@ func (s *Store) GetDoctorsBySearchName(name string) ([]types.Doctor, error) {
 		return doctors, nil
 	}
 
-	query = "SELECT * FROM doctor WHERE name = ? ORDER BY name ASC"
+	query = "SELECT * FROM doctor WHERE name = ? AND deleted_at IS NULL ORDER BY name ASC"
 	rows, err := s.db.Query(query, name)
 	if err != nil {
 		return nil, err
@@ -107,6 +133,7 @@ func (s *Store) GetDoctorsBySearchName(name string) ([]types.Doctor, error) {
 }
 
 func (s *Store) GetDoctorByID(id int) (*types.Doctor, error) {
+	// the deleted doctor is still shown on the old prescriptions
 	query := "SELECT * FROM doctor WHERE id = ? ORDER BY name ASC"
 	rows, err := s.db.Query(query, id)
 	if err != nil {
@@ -132,8 +159,11 @@ func (s *Store) GetDoctorByID(id int) (*types.Doctor, error) {
 }
 
 func (s *Store) CreateDoctor(doctor types.Doctor) error {
-	_, err := s.db.Exec("INSERT INTO doctor (name) VALUES (?)",
-		doctor.Name)
+	_, err := s.db.Exec(`INSERT INTO doctor (
+		name, license_number, specialty, clinic, address, phone_number
+	) VALUES (?, ?, ?, ?, ?, ?)`,
+		doctor.Name, doctor.LicenseNumber, doctor.Specialty,
+		doctor.Clinic, doctor.Address, doctor.PhoneNumber)
 
 	if err != nil {
 		return err
@@ -143,7 +173,7 @@ func (s *Store) CreateDoctor(doctor types.Doctor) error {
 }
 
 func (s *Store) GetAllDoctors() ([]types.Doctor, error) {
-	rows, err := s.db.Query("SELECT * FROM doctor ORDER BY name ASC")
+	rows, err := s.db.Query("SELECT * FROM doctor WHERE deleted_at IS NULL ORDER BY name ASC")
 	if err != nil {
 		return nil, err
 	}
@@ -175,8 +205,8 @@ func (s *Store) DeleteDoctor(doctor *types.Doctor, user *types.User) error {
 		return fmt.Errorf("error write log file")
 	}
 
-	query := "DELETE FROM doctor WHERE id = ?"
-	_, err = s.db.Exec(query, doctor.ID)
+	query := "UPDATE doctor SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ? AND deleted_at IS NULL"
+	_, err = s.db.Exec(query, time.Now(), user.ID, doctor.ID)
 	if err != nil {
 		return err
 	}
@@ -184,7 +214,7 @@ func (s *Store) DeleteDoctor(doctor *types.Doctor, user *types.User) error {
 	return nil
 }
 
-func (s *Store) ModifyDoctor(id int, newName string, user *types.User) error {
+func (s *Store) ModifyDoctor(id int, doctor types.Doctor, user *types.User) error {
 	data, err := s.GetDoctorByID(id)
 	if err != nil {
 		return err
@@ -195,7 +225,14 @@ func (s *Store) ModifyDoctor(id int, newName string, user *types.User) error {
 		return fmt.Errorf("error write log file")
 	}
 
-	_, err = s.db.Exec("UPDATE doctor SET name = ? WHERE id = ? ", newName, id)
+	query := `UPDATE doctor SET 
+		name = ?, license_number = ?, specialty = ?, 
+		clinic = ?, address = ?, phone_number = ? 
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		doctor.Name, doctor.LicenseNumber, doctor.Specialty,
+		doctor.Clinic, doctor.Address, doctor.PhoneNumber, id)
 	if err != nil {
 		return err
 	}
@@ -203,6 +240,29 @@ func (s *Store) ModifyDoctor(id int, newName string, user *types.User) error {
 	return nil
 }
 
+func (s *Store) GetDoctorsModifiedSince(since time.Time) ([]types.Doctor, error) {
+	query := "SELECT * FROM doctor WHERE last_modified >= ? OR deleted_at >= ? ORDER BY id ASC"
+	rows, err := s.db.Query(query, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	doctors := make([]types.Doctor, 0)
+
+	for rows.Next() {
+		doctor, err := scanRowIntoDoctor(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		doctors = append(doctors, *doctor)
+	}
+
+	return doctors, nil
+}
+
 func scanRowIntoDoctor(rows *sql.Rows) (*types.Doctor, error) {
 	doctor := new(types.Doctor)
 
@@ -210,6 +270,14 @@ func scanRowIntoDoctor(rows *sql.Rows) (*types.Doctor, error) {
 		&doctor.ID,
 		&doctor.Name,
 		&doctor.CreatedAt,
+		&doctor.LicenseNumber,
+		&doctor.Specialty,
+		&doctor.Clinic,
+		&doctor.Address,
+		&doctor.PhoneNumber,
+		&doctor.LastModified,
+		&doctor.DeletedAt,
+		&doctor.DeletedByUserID,
 	)
 
 	if err != nil {
@@ -217,6 +285,7 @@ func scanRowIntoDoctor(rows *sql.Rows) (*types.Doctor, error) {
 	}
 
 	doctor.CreatedAt = doctor.CreatedAt.Local()
+	doctor.LastModified = doctor.LastModified.Local()
 
 	return doctor, nil
 }
@@ -20,7 +20,7 @@ func NewStore(db *sql.DB) *Store {
 func (s *Store) CreateMainDoctorMedItem(item types.MainDoctorMedItem) error {
 	query := `INSERT INTO main_doctor_presc_medicine_item (
 				medicine_id, medicine_content_id, qty, unit_id, user_id, last_modified_by_user_id) 
-				VALUES (?, ?, ?, ?)`
+				VALUES (?, ?, ?, ?, ?, ?)`
 	_, err := s.db.Exec(query, item.MedicineID, item.MedicineContentID, item.Qty, item.UnitID, item.UserID, item.LastModifiedByUserID)
 	if err != nil {
 		return err
@@ -221,6 +221,113 @@ func (s *Store) IsMedicineBarcodeExist(barcode string) (bool, error) {
 	return (count > 0), nil
 }
 
+func (s *Store) GetMainDoctorMedIngredients(medId int) ([]types.MainDoctorMedIngredient, error) {
+	query := `SELECT medicine.barcode, medicine.name, md.qty, unit.name 
+				FROM main_doctor_presc_medicine_item AS md 
+				JOIN medicine ON medicine.id = md.medicine_content_id 
+				JOIN unit ON unit.id = md.unit_id 
+				WHERE md.medicine_id = ?`
+	rows, err := s.db.Query(query, medId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ingredients := make([]types.MainDoctorMedIngredient, 0)
+
+	for rows.Next() {
+		var ingredient types.MainDoctorMedIngredient
+
+		err = rows.Scan(
+			&ingredient.MedicineBarcode,
+			&ingredient.MedicineName,
+			&ingredient.Qty,
+			&ingredient.Unit,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients, nil
+}
+
+func (s *Store) CreateDispensedIngredients(ingredients []types.DispensedIngredient) error {
+	if len(ingredients) == 0 {
+		return nil
+	}
+
+	values := "(?, ?, ?, ?, ?, ?)"
+	args := make([]interface{}, 0)
+
+	for i, ingredient := range ingredients {
+		if i > 0 {
+			values += ", (?, ?, ?, ?, ?, ?)"
+		}
+
+		args = append(args, ingredient.ReferenceType, ingredient.ReferenceID, ingredient.CompoundMedicineID,
+			ingredient.MedicineID, ingredient.Qty, ingredient.UnitID)
+	}
+
+	query := `INSERT INTO dispensed_ingredient (
+		reference_type, reference_id, compound_medicine_id, medicine_id, qty, unit_id
+	) VALUES ` + values
+
+	_, err := s.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetDispensedIngredients(referenceType string, referenceId int) ([]types.DispensedIngredientReturn, error) {
+	query := `SELECT di.compound_medicine_id, medicine.barcode, medicine.name, di.qty, unit.name 
+				FROM dispensed_ingredient AS di 
+				JOIN medicine ON medicine.id = di.medicine_id 
+				JOIN unit ON unit.id = di.unit_id 
+				WHERE di.reference_type = ? AND di.reference_id = ? 
+				ORDER BY di.id ASC`
+	rows, err := s.db.Query(query, referenceType, referenceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ingredients := make([]types.DispensedIngredientReturn, 0)
+
+	for rows.Next() {
+		var ingredient types.DispensedIngredientReturn
+
+		err = rows.Scan(
+			&ingredient.CompoundMedicineID,
+			&ingredient.MedicineBarcode,
+			&ingredient.MedicineName,
+			&ingredient.Qty,
+			&ingredient.Unit,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients, nil
+}
+
+func (s *Store) DeleteDispensedIngredients(referenceType string, referenceId int) error {
+	_, err := s.db.Exec("DELETE FROM dispensed_ingredient WHERE reference_type = ? AND reference_id = ?",
+		referenceType, referenceId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) DeleteMainDoctorMedItem(medId int, user *types.User) error {
 	data, err := s.GetMainDoctorMedItemByMedicineData(medId)
 	if err != nil {
@@ -55,18 +55,21 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	patient, err := utils.ParsePatient(payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// check if the patient exists
-	_, err = h.patientStore.GetPatientByName(payload.Name, payload.Age)
+	_, err = h.patientStore.GetPatientByIdentity(*patient)
 	if err == nil {
 		utils.WriteError(w, http.StatusBadRequest,
 			fmt.Errorf("patient with name %s already exists", payload.Name))
 		return
 	}
 
-	err = h.patientStore.CreatePatient(types.Patient{
-		Name: payload.Name,
-		Age:  payload.Age,
-	})
+	err = h.patientStore.CreatePatient(*patient)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -221,14 +224,20 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.patientStore.GetPatientByName(payload.NewData.Name, payload.NewData.Age)
-	if err == nil {
+	newPatient, err := utils.ParsePatient(payload.NewData)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existingPatient, err := h.patientStore.GetPatientByIdentity(*newPatient)
+	if err == nil && existingPatient.ID != patient.ID {
 		utils.WriteError(w, http.StatusBadRequest,
 			fmt.Errorf("patient with name %s already exist", payload.NewData.Name))
 		return
 	}
 
-	err = h.patientStore.ModifyPatient(patient.ID, payload.NewData, user)
+	err = h.patientStore.ModifyPatient(patient.ID, *newPatient, user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -16,29 +16,43 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) GetPatientByName(name string, age int) (*types.Patient, error) {
-	query := "SELECT * FROM patient WHERE name = ? AND age = ? ORDER BY name ASC"
-	rows, err := s.db.Query(query, name, age)
-	if err != nil {
-		return nil, err
+// the identity data is matched in order: medical record number, national id,
+// name and birth date, then name and phone number. the next one is tried
+// until one of them matches
+func (s *Store) GetPatientByIdentity(patient types.Patient) (*types.Patient, error) {
+	if patient.MedicalRecordNumber != "" {
+		query := "SELECT * FROM patient WHERE medical_record_number = ? ORDER BY name ASC"
+		existingPatient, err := s.getOnePatient(query, patient.MedicalRecordNumber)
+		if existingPatient != nil || err != nil {
+			return existingPatient, err
+		}
 	}
-	defer rows.Close()
-
-	patient := new(types.Patient)
 
-	for rows.Next() {
-		patient, err = scanRowIntoPatient(rows)
+	if patient.NationalID != "" {
+		query := "SELECT * FROM patient WHERE national_id = ? ORDER BY name ASC"
+		existingPatient, err := s.getOnePatient(query, patient.NationalID)
+		if existingPatient != nil || err != nil {
+			return existingPatient, err
+		}
+	}
 
-		if err != nil {
-			return nil, err
+	if patient.BirthDate.Valid {
+		query := "SELECT * FROM patient WHERE name = ? AND birth_date = ? ORDER BY name ASC"
+		existingPatient, err := s.getOnePatient(query, patient.Name, patient.BirthDate.Time.Format("2006-01-02"))
+		if existingPatient != nil || err != nil {
+			return existingPatient, err
 		}
 	}
 
-	if patient.ID == 0 {
-		return nil, fmt.Errorf("patient not found")
+	if patient.PhoneNumber != "" {
+		query := "SELECT * FROM patient WHERE name = ? AND phone_number = ? ORDER BY name ASC"
+		existingPatient, err := s.getOnePatient(query, patient.Name, patient.PhoneNumber)
+		if existingPatient != nil || err != nil {
+			return existingPatient, err
+		}
 	}
 
-	return patient, nil
+	return nil, fmt.Errorf("patient not found")
 }
 
 func (s *Store) GetPatientsBySearchName(name string) ([]types.Patient, error) {
@@ -132,8 +146,20 @@ func (s *Store) GetPatientByID(id int) (*types.Patient, error) {
 }
 
 func (s *Store) CreatePatient(patient types.Patient) error {
-	_, err := s.db.Exec("INSERT INTO patient (name, age) VALUES (?, ?)",
-		patient.Name, patient.Age)
+	values := "?"
+	for i := 0; i < 9; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO patient (
+		name, age, birth_date, gender, phone_number, address, 
+		national_id, medical_record_number, weight, allergy_notes
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		patient.Name, patient.Age, patient.BirthDate, patient.Gender,
+		patient.PhoneNumber, patient.Address, patient.NationalID,
+		patient.MedicalRecordNumber, patient.Weight, patient.AllergyNotes)
 
 	if err != nil {
 		return err
@@ -184,7 +210,7 @@ func (s *Store) DeletePatient(patient *types.Patient, user *types.User) error {
 	return nil
 }
 
-func (s *Store) ModifyPatient(id int, patient types.RegisterPatientPayload, user *types.User) error {
+func (s *Store) ModifyPatient(id int, patient types.Patient, user *types.User) error {
 	data, err := s.GetPatientByID(id)
 	if err != nil {
 		return err
@@ -199,7 +225,15 @@ func (s *Store) ModifyPatient(id int, patient types.RegisterPatientPayload, user
 		return fmt.Errorf("error write log file")
 	}
 
-	_, err = s.db.Exec("UPDATE patient SET name = ?, age = ? WHERE id = ? ", patient.Name, patient.Age, id)
+	query := `UPDATE patient SET 
+		name = ?, age = ?, birth_date = ?, gender = ?, phone_number = ?, address = ?, 
+		national_id = ?, medical_record_number = ?, weight = ?, allergy_notes = ? 
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		patient.Name, patient.Age, patient.BirthDate, patient.Gender,
+		patient.PhoneNumber, patient.Address, patient.NationalID,
+		patient.MedicalRecordNumber, patient.Weight, patient.AllergyNotes, id)
 
 	if err != nil {
 		return err
@@ -208,6 +242,31 @@ func (s *Store) ModifyPatient(id int, patient types.RegisterPatientPayload, user
 	return nil
 }
 
+func (s *Store) getOnePatient(query string, args ...interface{}) (*types.Patient, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	patient := new(types.Patient)
+
+	for rows.Next() {
+		patient, err = scanRowIntoPatient(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// nil if there is no match, so the next identity data can be tried
+	if patient.ID == 0 {
+		return nil, nil
+	}
+
+	return patient, nil
+}
+
 func scanRowIntoPatient(rows *sql.Rows) (*types.Patient, error) {
 	patient := new(types.Patient)
 
@@ -216,6 +275,14 @@ func scanRowIntoPatient(rows *sql.Rows) (*types.Patient, error) {
 		&patient.Name,
 		&patient.Age,
 		&patient.CreatedAt,
+		&patient.BirthDate,
+		&patient.Gender,
+		&patient.PhoneNumber,
+		&patient.Address,
+		&patient.NationalID,
+		&patient.MedicalRecordNumber,
+		&patient.Weight,
+		&patient.AllergyNotes,
 	)
 
 	if err != nil {
@@ -2,11 +2,11 @@ package prescription
 
 import (
 	"archive/zip"
+	"database/sql"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,7 +14,9 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
+	"github.com/nicolaics/pharmacon/metrics"
 	// "github.com/nicolaics/pharmacon/config"
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 	"github.com/nicolaics/pharmacon/utils/pdf"
@@ -34,6 +36,15 @@ type Handler struct {
 	doseStore         types.DoseStore
 	mfStore           types.MfStore
 	SetUsageStore     types.SetUsageStore
+	allergyStore      types.PatientAllergyStore
+	ruleStore         types.InteractionRuleStore
+	registerStore     types.ControlledSubstanceStore
+	mdmiStore         types.MainDoctorMedItemStore
+	templateStore     types.EticketTemplateStore
+	branchStore       types.BranchStore
+	settingStore      types.SettingStore
+	documentStorage   types.DocumentStorage
+	eventStore        types.EventStore
 }
 
 func NewHandler(prescriptionStore types.PrescriptionStore,
@@ -48,7 +59,14 @@ func NewHandler(prescriptionStore types.PrescriptionStore,
 	detStore types.DetStore,
 	doseStore types.DoseStore,
 	mfStore types.MfStore,
-	SetUsageStore types.SetUsageStore) *Handler {
+	SetUsageStore types.SetUsageStore,
+	allergyStore types.PatientAllergyStore,
+	ruleStore types.InteractionRuleStore,
+	registerStore types.ControlledSubstanceStore,
+	mdmiStore types.MainDoctorMedItemStore,
+	templateStore types.EticketTemplateStore,
+	branchStore types.BranchStore, settingStore types.SettingStore, documentStorage types.DocumentStorage,
+	eventStore types.EventStore) *Handler {
 	return &Handler{
 		prescriptionStore: prescriptionStore,
 		userStore:         userStore,
@@ -63,12 +81,25 @@ func NewHandler(prescriptionStore types.PrescriptionStore,
 		doseStore:         doseStore,
 		mfStore:           mfStore,
 		SetUsageStore:     SetUsageStore,
+		allergyStore:      allergyStore,
+		ruleStore:         ruleStore,
+		registerStore:     registerStore,
+		mdmiStore:         mdmiStore,
+		templateStore:     templateStore,
+		branchStore:       branchStore,
+		settingStore:      settingStore,
+		documentStorage:   documentStorage,
+		eventStore:        eventStore,
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/prescription", h.handleRegister).Methods(http.MethodPost)
 
+	// registered before the {params}/{val} route, otherwise it is matched there
+	router.HandleFunc("/prescription/patient-history", h.handleGetPatientHistory).Methods(http.MethodPost)
+	router.HandleFunc("/prescription/patient-history/pdf", h.handlePrintPatientHistory).Methods(http.MethodPost)
+
 	// TODO: add more get prescriptions
 	router.HandleFunc("/prescription/{params}/{val}", h.handleGetPrescriptions).Methods(http.MethodPost)
 
@@ -76,11 +107,23 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/prescription", h.handleDelete).Methods(http.MethodDelete)
 	router.HandleFunc("/prescription", h.handleModify).Methods(http.MethodPatch)
 	router.HandleFunc("/prescription/print", h.handlePrint).Methods(http.MethodPost)
+	router.HandleFunc("/prescription/screening", h.handleScreening).Methods(http.MethodPost)
+	router.HandleFunc("/prescription/copy", h.handlePrintCopy).Methods(http.MethodPost)
+	router.HandleFunc("/prescription/signa", h.handleParseSigna).Methods(http.MethodPost)
+	router.HandleFunc("/prescription/status", h.handleUpdateStatus).Methods(http.MethodPatch)
+	router.HandleFunc("/prescription/queue", h.handleGetQueue).Methods(http.MethodGet)
 
 	router.HandleFunc("/prescription", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/prescription/{params}/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/prescription/detail", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 	router.HandleFunc("/prescription/print", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/screening", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/copy", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/signa", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/status", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/queue", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/patient-history", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/prescription/patient-history/pdf", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 }
 
 func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
@@ -120,47 +163,90 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get invoice data
-	invoiceId, err := h.invoiceStore.GetInvoiceID(payload.Invoice.Number, invoiceCustomer.ID, *invoiceDate)
+	invoiceId, err := h.invoiceStore.GetInvoiceID(payload.Invoice.Number, invoiceCustomer.ID, *invoiceDate, user.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invoice number %d not found", payload.Invoice.Number))
 		return
 	}
 
-	doctor, err := h.doctorStore.GetDoctorByName(payload.DoctorName)
-	if doctor == nil {
-		err = h.doctorStore.CreateDoctor(types.Doctor{
-			Name: payload.DoctorName,
-		})
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error creating doctor: %v", err))
-			return
-		}
+	// doctor is not created here, so the typos don't end up as new doctors
+	doctor, err := h.doctorStore.GetDoctorByID(payload.DoctorID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("doctor id %d not found, register the doctor first", payload.DoctorID))
+		return
+	}
 
-		doctor, err = h.doctorStore.GetDoctorByName(payload.DoctorName)
+	patient, err := getPatient(h, payload.Patient)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error getting patient %s: %v", payload.Patient.Name, err))
+		return
 	}
+
+	// screen the medicines against the patient allergies and each other
+	screeningResults, err := screenPrescription(h, patient, payload.SetItems)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s not found", payload.DoctorName))
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error screening prescription: %v", err))
 		return
 	}
 
-	patient, err := h.patientStore.GetPatientByName(payload.PatientName, payload.PatientAge)
-	if patient == nil {
-		err = h.patientStore.CreatePatient(types.Patient{
-			Name: payload.PatientName,
-			Age:  payload.PatientAge,
+	if utils.HasBlockingScreeningResult(screeningResults) && payload.ScreeningOverrideReason == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":            "screening result must be acknowledged with an override reason",
+			"screeningResults": screeningResults,
 		})
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error creating patient: %v", err))
-			return
-		}
+		return
+	}
 
-		patient, err = h.patientStore.GetPatientByName(payload.PatientName, payload.PatientAge)
+	// only a pharmacist may override the blocking result
+	if utils.HasBlockingScreeningResult(screeningResults) && !user.Pharmacist {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]interface{}{
+			"error":            "only a pharmacist can override the screening result",
+			"screeningResults": screeningResults,
+		})
+		return
 	}
+
+	err = checkPrescribedQty(payload.SetItems)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s not found", payload.PatientName))
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
+	// follow-up dispense of an iter or partly dispensed prescription
+	originalPrescriptionId := sql.NullInt64{}
+	iterationNumber := 0
+
+	if payload.OriginalPrescriptionID != 0 {
+		original, err := getOriginalPrescription(h, payload.OriginalPrescriptionID)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("original prescription id %d not found", payload.OriginalPrescriptionID))
+			return
+		}
+
+		if original.PatientID != patient.ID {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("patient %s is not the patient of prescription %d", patient.Name, original.Number))
+			return
+		}
+
+		err = checkRemainingQty(h, original, 0, payload.SetItems)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		followUps, err := h.prescriptionStore.GetFollowUpPrescriptions(original.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		originalPrescriptionId = sql.NullInt64{Int64: int64(original.ID), Valid: true}
+		iterationNumber = 1
+		if len(followUps) > 0 {
+			iterationNumber = followUps[len(followUps)-1].IterationNumber + 1
+		}
+	}
+
 	prescriptionDate, err := utils.ParseDate(payload.PrescriptionDate)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
@@ -187,18 +273,21 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	presc := types.Prescription{
-		InvoiceID:            invoiceId,
-		Number:               payload.Number,
-		PrescriptionDate:     *prescriptionDate,
-		PatientID:            patient.ID,
-		DoctorID:             doctor.ID,
-		Qty:                  payload.Qty,
-		Price:                payload.Price,
-		TotalPrice:           payload.TotalPrice,
-		Description:          payload.Description,
-		UserID:               user.ID,
-		LastModifiedByUserID: user.ID,
-		PDFUrl:               "",
+		InvoiceID:              invoiceId,
+		Number:                 payload.Number,
+		PrescriptionDate:       *prescriptionDate,
+		PatientID:              patient.ID,
+		DoctorID:               doctor.ID,
+		Qty:                    payload.Qty,
+		Price:                  payload.Price,
+		TotalPrice:             payload.TotalPrice,
+		Description:            payload.Description,
+		UserID:                 user.ID,
+		LastModifiedByUserID:   user.ID,
+		PDFUrl:                 "",
+		OriginalPrescriptionID: originalPrescriptionId,
+		IterationNumber:        iterationNumber,
+		BranchID:               user.BranchID,
 	}
 
 	err = h.prescriptionStore.CreatePrescription(presc)
@@ -221,9 +310,40 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = saveScreeningResults(h, prescriptionId, screeningResults, payload.ScreeningOverrideReason, user)
+	if err != nil {
+		errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete prescription: %v", errDel))
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving screening results: %v", err))
+		return
+	}
+
+	// a new prescription starts as received in the dispensing queue
+	err = h.prescriptionStore.CreatePrescriptionStatusHistory(types.PrescriptionStatusHistory{
+		PrescriptionID: prescriptionId,
+		ToStatus:       constants.PRESC_STATUS_RECEIVED,
+		UserID:         user.ID,
+	})
+	if err != nil {
+		errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete prescription: %v", errDel))
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving prescription status: %v", err))
+		return
+	}
+
 	eticketFileNames := make([]string, 0)
 	setNumber := 1
 
+	neededStockItems := make([]types.MedicineStockItem, 0)
+
 	for _, setItem := range payload.SetItems {
 		// get consume time
 		consumeTime, err := h.consumeTimeStore.GetConsumeTimeByName(setItem.ConsumeTime)
@@ -377,13 +497,27 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 
 		// create eticket
 		if setItem.PrintEticket {
+			eticketTemplate, err := getEticketTemplate(h, setItem)
+			if err != nil {
+				errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
+				if errDel != nil {
+					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error absolute delete prescription: %v", errDel))
+					return
+				}
+
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+
 			eticket := types.Eticket{
 				PrescriptionID:        prescriptionId,
 				PrescriptionSetItemID: setItemStoreId,
 				Number:                setItem.Eticket.Number,
 				MedicineQty:           setItem.Eticket.MedicineQty,
-				Size:                  setItem.Eticket.Size,
+				Size:                  fmt.Sprintf("%gx%g", eticketTemplate.Height, eticketTemplate.Width),
 				PDFUrl:                "",
+				Template:              eticketTemplate.Name,
+				ShakeWell:             setItem.Eticket.ShakeWell,
 			}
 
 			err = h.prescriptionStore.CreateEticket(eticket)
@@ -431,24 +565,20 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 				SetUnit:     setUnit.Name,
 				ConsumeTime: consumeTime.Name,
 				MustFinish:  setItem.MustFinish,
+				ShakeWell:   setItem.Eticket.ShakeWell,
 				MedicineQty: setItem.Eticket.MedicineQty,
+				Instruction: getSignaInstruction(setItem),
 			}
 
-			var eticketFileName string
-			if setItem.Eticket.Size == "7x4" {
-				eticketFileName, err = pdf.CreateEticket7x4PDF(eticketPDF, setNumber, h.prescriptionStore)
-				if err != nil {
-					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating eticket pdf for number %d: %v", setItem.Eticket.Number, err))
-					return
-				}
-			} else if setItem.Eticket.Size == "7x5" {
-				eticketFileName, err = pdf.CreateEticket7x5PDF(eticketPDF, setNumber, h.prescriptionStore)
-				if err != nil {
-					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating eticket pdf for number %d: %v", setItem.Eticket.Number, err))
-					return
-				}
-			} else {
-				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown eticket size: %s", setItem.Eticket.Size))
+			eticketBranding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_ETICKET, user.BranchID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+				return
+			}
+
+			eticketFileName, err := pdf.CreateEticketPDF(eticketPDF, setNumber, eticketTemplate, h.prescriptionStore, eticketBranding, h.documentStorage)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating eticket pdf for number %d: %v", setItem.Eticket.Number, err))
 				return
 			}
 
@@ -520,6 +650,19 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 
 				medicineQty = numerator / denum
 			}
+
+			prescribedQty, err := getPrescribedQty(medicine, medicineQty)
+			if err != nil {
+				errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
+				if errDel != nil {
+					utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete prescription: %v", errDel))
+					return
+				}
+
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+
 			medicineItem := types.PrescriptionMedicineItem{
 				PrescriptionSetItemID: setItemStoreId,
 				MedicineID:            medData.ID,
@@ -529,6 +672,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 				DiscountPercentage:    medicine.DiscountPercentage,
 				DiscountAmount:        medicine.DiscountAmount,
 				Subtotal:              medicine.Subtotal,
+				PrescribedQty:         prescribedQty,
+				Iteration:             medicine.Iteration,
 			}
 			err = h.prescriptionStore.CreatePrescriptionMedicineItem(medicineItem)
 			if err != nil {
@@ -543,7 +688,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			err = utils.CheckStock(medData, unit, medicineQty)
+			// the compounded medicine is checked from its ingredients
+			stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicineQty)
 			if err != nil {
 				errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
 				if errDel != nil {
@@ -551,10 +697,24 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("stock for %s is not enough, need %.2f: %v", medicine.MedicineName, medicineQty, err))
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 				return
 			}
+
+			neededStockItems = append(neededStockItems, stockItems...)
+		}
+	}
+
+	err = utils.CheckStockItems(h.medStore, neededStockItems, user.BranchID, nil)
+	if err != nil {
+		errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete prescription: %v", errDel))
+			return
 		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
 	medicineSets, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(prescriptionId)
@@ -569,6 +729,9 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// age is printed as of the prescription date
+	patient.Age = utils.GetPatientAge(patient, *prescriptionDate)
+
 	prescPDF := types.PrescriptionPDFReturn{
 		Number:       payload.Number,
 		Date:         *prescriptionDate,
@@ -576,7 +739,13 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		Doctor:       *doctor,
 		MedicineSets: medicineSets,
 	}
-	prescFileName, err := pdf.CreatePrescriptionPDF(prescPDF, h.prescriptionStore, "")
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PRESCRIPTION, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	prescFileName, err := pdf.CreatePrescriptionPDF(prescPDF, h.prescriptionStore, "", branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create presc pdf: %v", err))
 		return
@@ -589,6 +758,8 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// subtract the stock
+	dispensedIngredients := make([]types.DispensedIngredient, 0)
+
 	for _, setItem := range medicineSets {
 		for _, medicine := range setItem.MedicineItems {
 			medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
@@ -621,7 +792,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			err = utils.SubtractStock(h.medStore, medData, unit, medicine.QtyFloat, user)
+			stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.QtyFloat)
 			if err != nil {
 				errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
 				if errDel != nil {
@@ -629,16 +800,58 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 				return
 			}
+
+			for _, stockItem := range stockItems {
+				err = utils.SubtractStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, user.BranchID, user)
+				if err != nil {
+					errDel := h.prescriptionStore.AbsoluteDeletePrescription(presc)
+					if errDel != nil {
+						utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete prescription: %v", errDel))
+						return
+					}
+
+					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+					return
+				}
+
+				err = utils.RecordControlledSubstanceOut(h.registerStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, types.ControlledSubstanceRegister{
+					TransactionType:    constants.CONTROLLED_TRANSACTION_DISPENSE,
+					ReferenceID:        prescriptionId,
+					ReferenceNumber:    payload.Number,
+					TransactionDate:    *prescriptionDate,
+					PatientName:        patient.Name,
+					PatientAddress:     patient.Address,
+					DoctorName:         doctor.Name,
+					PrescriptionNumber: payload.Number,
+					UserID:             user.ID,
+					BranchID:           user.BranchID,
+				})
+				if err != nil {
+					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+					return
+				}
+			}
+
+			dispensedIngredients = append(dispensedIngredients, utils.GetDispensedIngredients(constants.DISPENSED_REFERENCE_PRESCRIPTION, prescriptionId, medData, stockItems)...)
 		}
 	}
 
+	err = h.mdmiStore.CreateDispensedIngredients(dispensedIngredients)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving dispensed ingredients: %v", err))
+		return
+	}
+
+	publishPrescriptionEvent(h, constants.EVENT_PRESCRIPTION_CREATED, prescriptionId, user)
+
 	returnPayload := map[string]interface{}{
-		"success":         fmt.Sprintf("prescription %d successfully created by %s", payload.Number, user.Name),
-		"prescriptionPDF": prescFileName,
-		"eticketPDF":      eticketFileNames,
+		"success":          fmt.Sprintf("prescription %d successfully created by %s", payload.Number, user.Name),
+		"prescriptionPDF":  prescFileName,
+		"eticketPDF":       eticketFileNames,
+		"screeningResults": screeningResults,
 	}
 	utils.WriteJSON(w, http.StatusCreated, returnPayload)
 }
@@ -660,12 +873,18 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	vars := mux.Vars(r)
 	params := vars["params"]
 	val := vars["val"]
@@ -685,7 +904,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 	var prescriptions []types.PrescriptionListsReturnPayload
 
 	if val == "all" {
-		prescriptions, err = h.prescriptionStore.GetPrescriptionsByDate(*startDate, *endDate)
+		prescriptions, err = h.prescriptionStore.GetPrescriptionsByDate(*startDate, *endDate, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -698,7 +917,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 		}
 
 		prescription, err := h.prescriptionStore.GetPrescriptionByID(id)
-		if err != nil {
+		if err != nil || (branchId != constants.BRANCH_ALL && prescription.BranchID != branchId) {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription id %d not exist", id))
 			return
 		}
@@ -738,6 +957,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 			Number:           prescription.Number,
 			PrescriptionDate: prescription.PrescriptionDate,
 			PatientName:      patient.Name,
+			PatientAge:       utils.GetPatientAge(patient, prescription.PrescriptionDate),
 			DoctorName:       doctor.Name,
 			Qty:              prescription.Qty,
 			Price:            prescription.Price,
@@ -763,7 +983,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		prescriptions, err = h.prescriptionStore.GetPrescriptionsByDateAndNumber(*startDate, *endDate, number)
+		prescriptions, err = h.prescriptionStore.GetPrescriptionsByDateAndNumber(*startDate, *endDate, number, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -776,7 +996,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 		}
 
 		for _, user := range users {
-			temp, err := h.prescriptionStore.GetPrescriptionsByDateAndUserID(*startDate, *endDate, user.ID)
+			temp, err := h.prescriptionStore.GetPrescriptionsByDateAndUserID(*startDate, *endDate, user.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %s doesn't create any prescription between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -792,7 +1012,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 		}
 
 		for _, patient := range patients {
-			temp, err := h.prescriptionStore.GetPrescriptionsByDateAndPatientID(*startDate, *endDate, patient.ID)
+			temp, err := h.prescriptionStore.GetPrescriptionsByDateAndPatientID(*startDate, *endDate, patient.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("patient %s doesn't have any prescription between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -808,7 +1028,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 		}
 
 		for _, doctor := range doctors {
-			temp, err := h.prescriptionStore.GetPrescriptionsByDateAndDoctorID(*startDate, *endDate, doctor.ID)
+			temp, err := h.prescriptionStore.GetPrescriptionsByDateAndDoctorID(*startDate, *endDate, doctor.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("doctor %s doesn't have any prescription between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -823,7 +1043,7 @@ func (h *Handler) handleGetPrescriptions(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		prescriptions, err = h.prescriptionStore.GetPrescriptionsByDateAndInvoiceID(*startDate, *endDate, iid)
+		prescriptions, err = h.prescriptionStore.GetPrescriptionsByDateAndInvoiceID(*startDate, *endDate, iid, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -854,7 +1074,7 @@ func (h *Handler) handleGetPrescriptionDetail(w http.ResponseWriter, r *http.Req
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -867,6 +1087,12 @@ func (h *Handler) handleGetPrescriptionDetail(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// get the details of set items and medicine items of the prescription
 	items, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(prescription.ID)
 	if err != nil {
@@ -874,6 +1100,45 @@ func (h *Handler) handleGetPrescriptionDetail(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// show the ingredients used by the compounded medicines
+	err = setCompoundedMedicineIngredients(h, items)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe: %v", err))
+		return
+	}
+
+	// read the signa and the days supply of each set
+	err = setSetItemSignas(h, prescription.ID, items)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// get the allergy and interaction screening results
+	screenings, err := h.prescriptionStore.GetPrescriptionScreeningsByPrescriptionID(prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// dispensed qty is counted from the first dispense
+	originalPrescriptionId := prescription.ID
+	if prescription.OriginalPrescriptionID.Valid {
+		originalPrescriptionId = int(prescription.OriginalPrescriptionID.Int64)
+	}
+
+	dispensedQtys, err := h.prescriptionStore.GetDispensedQtys(originalPrescriptionId, 0)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	statusHistories, err := h.prescriptionStore.GetPrescriptionStatusHistories(prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	// get user data, the one who inputs the prescription
 	inputter, err := h.userStore.GetUserByID(prescription.UserID)
 	if err != nil {
@@ -924,6 +1189,9 @@ func (h *Handler) handleGetPrescriptionDetail(w http.ResponseWriter, r *http.Req
 		LastModified:           prescription.LastModified,
 		LastModifiedByUserName: lastModifiedUser.Name,
 		PDFUrl:                 prescription.PDFUrl,
+		OriginalPrescriptionID: int(prescription.OriginalPrescriptionID.Int64),
+		IterationNumber:        prescription.IterationNumber,
+		Status:                 prescription.Status,
 
 		Invoice: struct {
 			Number       int       "json:\"number\""
@@ -938,21 +1206,35 @@ func (h *Handler) handleGetPrescriptionDetail(w http.ResponseWriter, r *http.Req
 		},
 
 		Patient: struct {
-			ID   int    "json:\"id\""
-			Name string "json:\"name\""
-			Age  int    "json:\"age\""
+			ID                  int     "json:\"id\""
+			Name                string  "json:\"name\""
+			Age                 int     "json:\"age\""
+			Gender              string  "json:\"gender\""
+			MedicalRecordNumber string  "json:\"medicalRecordNumber\""
+			Weight              float64 "json:\"weight\""
+			AllergyNotes        string  "json:\"allergyNotes\""
 		}{
-			ID:   patient.ID,
-			Name: patient.Name,
-			Age:  patient.Age,
+			ID:                  patient.ID,
+			Name:                patient.Name,
+			Age:                 utils.GetPatientAge(patient, prescription.PrescriptionDate),
+			Gender:              patient.Gender,
+			MedicalRecordNumber: patient.MedicalRecordNumber,
+			Weight:              patient.Weight,
+			AllergyNotes:        patient.AllergyNotes,
 		},
 
 		Doctor: struct {
-			ID   int    "json:\"id\""
-			Name string "json:\"name\""
+			ID            int    "json:\"id\""
+			Name          string "json:\"name\""
+			LicenseNumber string "json:\"licenseNumber\""
+			Specialty     string "json:\"specialty\""
+			Clinic        string "json:\"clinic\""
 		}{
-			ID:   doctor.ID,
-			Name: doctor.Name,
+			ID:            doctor.ID,
+			Name:          doctor.Name,
+			LicenseNumber: doctor.LicenseNumber,
+			Specialty:     doctor.Specialty,
+			Clinic:        doctor.Clinic,
 		},
 
 		User: struct {
@@ -963,7 +1245,10 @@ func (h *Handler) handleGetPrescriptionDetail(w http.ResponseWriter, r *http.Req
 			Name: inputter.Name,
 		},
 
-		MedicineSets: items,
+		MedicineSets:    items,
+		Screenings:      screenings,
+		DispensedQtys:   dispensedQtys,
+		StatusHistories: statusHistories,
 	}
 
 	utils.WriteJSON(w, http.StatusOK, returnPayload)
@@ -1000,6 +1285,19 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// the handed over medicines are with the patient, so the stock can't be returned
+	if !utils.IsValidPrescriptionStatusTransition(prescription.Status, constants.PRESC_STATUS_CANCELLED) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d can't be cancelled from %s",
+			prescription.Number, prescription.Status))
+		return
+	}
+
 	// get set items
 	setItems, err := h.prescriptionStore.GetSetItemsByPrescriptionID(prescription.ID)
 	if setItems == nil || err != nil {
@@ -1008,6 +1306,19 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the prescription is cancelled by deleting it, so the stock is returned.
+	// the status is changed first, so only one request returns the stock
+	updated, err := updatePrescriptionStatus(h, prescription, constants.PRESC_STATUS_CANCELLED, "", user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving prescription status: %v", err))
+		return
+	}
+
+	if !updated {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d status was changed by another user", prescription.Number))
+		return
+	}
+
 	medicineItems := make([]types.PrescriptionMedicineItemReturn, 0)
 
 	for _, setItem := range setItems {
@@ -1038,26 +1349,21 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, medicineItem := range medicineItems {
-		medData, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName))
-			return
-		}
-
-		unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
-		}
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_DISPENSE, prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
+	}
 
-		err = utils.AddStock(h.medStore, medData, unit, medicineItem.QtyFloat, user)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
-			return
-		}
+	err = addDispensedStock(h, prescription, medicineItems, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
+	utils.PublishEvent(h.eventStore, constants.EVENT_PRESCRIPTION_DELETED, constants.EVENT_ENTITY_PRESCRIPTION, prescription.ID, prescription.BranchID, user,
+		map[string]interface{}{"prescription": prescription, "medicineLists": medicineItems})
+
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("prescription number %d deleted by %s", prescription.Number, user.Name))
 }
 
@@ -1092,6 +1398,19 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// the stock of the handed over prescription can't be taken again
+	if !utils.IsModifiablePrescriptionStatus(prescription.Status) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d can't be modified from %s",
+			prescription.Number, prescription.Status))
+		return
+	}
+
 	// get customerID info from invoice
 	invoiceCustomer, err := h.customerStore.GetCustomerByName(payload.NewData.Invoice.CustomerName)
 	if err != nil {
@@ -1106,44 +1425,46 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get invoice data
-	invoiceId, err := h.invoiceStore.GetInvoiceID(payload.NewData.Invoice.Number, invoiceCustomer.ID, *invoiceDate)
+	invoiceId, err := h.invoiceStore.GetInvoiceID(payload.NewData.Invoice.Number, invoiceCustomer.ID, *invoiceDate, prescription.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invoice number %d not found", payload.NewData.Invoice.Number))
 		return
 	}
 
-	doctor, err := h.doctorStore.GetDoctorByName(payload.NewData.DoctorName)
-	if doctor == nil {
-		err = h.doctorStore.CreateDoctor(types.Doctor{
-			Name: payload.NewData.DoctorName,
-		})
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error creating doctor: %v", err))
-			return
-		}
+	// doctor is not created here, so the typos don't end up as new doctors
+	doctor, err := h.doctorStore.GetDoctorByID(payload.NewData.DoctorID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("doctor id %d not found, register the doctor first", payload.NewData.DoctorID))
+		return
+	}
 
-		doctor, err = h.doctorStore.GetDoctorByName(payload.NewData.DoctorName)
+	patient, err := getPatient(h, payload.NewData.Patient)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error getting patient %s: %v", payload.NewData.Patient.Name, err))
+		return
 	}
+
+	// screen the medicines against the patient allergies and each other
+	screeningResults, err := screenPrescription(h, patient, payload.NewData.SetItems)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s not found", payload.NewData.DoctorName))
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error screening prescription: %v", err))
 		return
 	}
 
-	patient, err := h.patientStore.GetPatientByName(payload.NewData.PatientName, payload.NewData.PatientAge)
-	if patient == nil {
-		err = h.patientStore.CreatePatient(types.Patient{
-			Name: payload.NewData.PatientName,
-			Age:  payload.NewData.PatientAge,
+	if utils.HasBlockingScreeningResult(screeningResults) && payload.NewData.ScreeningOverrideReason == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":            "screening result must be acknowledged with an override reason",
+			"screeningResults": screeningResults,
 		})
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error creating patient: %v", err))
-			return
-		}
-
-		patient, err = h.patientStore.GetPatientByName(payload.NewData.PatientName, payload.NewData.PatientAge)
+		return
 	}
-	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s not found", payload.NewData.PatientName))
+
+	// only a pharmacist may override the blocking result
+	if utils.HasBlockingScreeningResult(screeningResults) && !user.Pharmacist {
+		utils.WriteJSON(w, http.StatusForbidden, map[string]interface{}{
+			"error":            "only a pharmacist can override the screening result",
+			"screeningResults": screeningResults,
+		})
 		return
 	}
 
@@ -1153,22 +1474,48 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	oldPrescriptionSetItems, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(prescription.ID)
+	err = checkPrescribedQty(payload.NewData.SetItems)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error finding prescription items: %v", err))
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	newPresc := types.Prescription{
-		InvoiceID:            invoiceId,
-		Number:               payload.NewData.Number,
-		PrescriptionDate:     *prescriptionDate,
-		PatientID:            patient.ID,
-		DoctorID:             doctor.ID,
-		Qty:                  payload.NewData.Qty,
-		Price:                payload.NewData.Price,
-		TotalPrice:           payload.NewData.TotalPrice,
-		Description:          payload.NewData.Description,
+	// the follow-up keeps its original prescription, only the remaining qty is checked again
+	if prescription.OriginalPrescriptionID.Valid {
+		original, err := h.prescriptionStore.GetPrescriptionByID(int(prescription.OriginalPrescriptionID.Int64))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("original prescription id %d not found", prescription.OriginalPrescriptionID.Int64))
+			return
+		}
+
+		if original.PatientID != patient.ID {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("patient %s is not the patient of prescription %d", patient.Name, original.Number))
+			return
+		}
+
+		err = checkRemainingQty(h, original, prescription.ID, payload.NewData.SetItems)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	oldPrescriptionSetItems, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error finding prescription items: %v", err))
+		return
+	}
+
+	newPresc := types.Prescription{
+		InvoiceID:            invoiceId,
+		Number:               payload.NewData.Number,
+		PrescriptionDate:     *prescriptionDate,
+		PatientID:            patient.ID,
+		DoctorID:             doctor.ID,
+		Qty:                  payload.NewData.Qty,
+		Price:                payload.NewData.Price,
+		TotalPrice:           payload.NewData.TotalPrice,
+		Description:          payload.NewData.Description,
 		LastModifiedByUserID: user.ID,
 	}
 	err = h.prescriptionStore.ModifyPrescription(payload.ID, newPresc, user)
@@ -1177,10 +1524,39 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// replace the screening results with the new medicines
+	err = h.prescriptionStore.DeletePrescriptionScreenings(prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting screening results: %v", err))
+		return
+	}
+
+	err = saveScreeningResults(h, prescription.ID, screeningResults, payload.NewData.ScreeningOverrideReason, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving screening results: %v", err))
+		return
+	}
+
+	// the changed medicines must be prepared and verified again
+	if prescription.Status == constants.PRESC_STATUS_COMPOUNDED || prescription.Status == constants.PRESC_STATUS_VERIFIED {
+		updated, err := updatePrescriptionStatus(h, prescription, constants.PRESC_STATUS_IN_PREPARATION, "prescription is modified", user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating prescription status: %v", err))
+			return
+		}
+
+		if !updated {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d status was changed by another user", prescription.Number))
+			return
+		}
+	}
+
 	eticketFileNames := make([]string, 0)
 	setNumber := 1
 
 	// delete set items
+	oldMedicineItems := make([]types.PrescriptionMedicineItemReturn, 0)
+
 	for _, setItem := range oldPrescriptionSetItems {
 		err = h.prescriptionStore.DeletePrescriptionMedicineItem(prescription, setItem.ID, user)
 		if err != nil {
@@ -1194,29 +1570,25 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		for _, medicineItem := range setItem.MedicineItems {
-			medData, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
-			if err != nil {
-				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName))
-				return
-			}
+		oldMedicineItems = append(oldMedicineItems, setItem.MedicineItems...)
+	}
 
-			unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
+	err = addDispensedStock(h, prescription, oldMedicineItems, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
 
-			err = utils.AddStock(h.medStore, medData, unit, medicineItem.QtyFloat, user)
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
-				return
-			}
-		}
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_DISPENSE, prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
 	}
 
 	// tODO: remove absolute delete
 	// create new set items
+	neededStockItems := make([]types.MedicineStockItem, 0)
+
 	for _, setItem := range payload.NewData.SetItems {
 		// get consume time
 		consumeTime, err := h.consumeTimeStore.GetConsumeTimeByName(setItem.ConsumeTime)
@@ -1328,13 +1700,21 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			eticketTemplate, err := getEticketTemplate(h, setItem)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+
 			eticket := types.Eticket{
 				PrescriptionID:        payload.ID,
 				PrescriptionSetItemID: setItemStoreId,
 				Number:                setItem.Eticket.Number,
 				MedicineQty:           setItem.Eticket.MedicineQty,
-				Size:                  setItem.Eticket.Size,
+				Size:                  fmt.Sprintf("%gx%g", eticketTemplate.Height, eticketTemplate.Width),
 				PDFUrl:                "",
+				Template:              eticketTemplate.Name,
+				ShakeWell:             setItem.Eticket.ShakeWell,
 			}
 			err = h.prescriptionStore.CreateEticket(eticket)
 			if err != nil {
@@ -1368,24 +1748,20 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 				SetUnit:     setUnit.Name,
 				ConsumeTime: consumeTime.Name,
 				MustFinish:  setItem.MustFinish,
+				ShakeWell:   setItem.Eticket.ShakeWell,
 				MedicineQty: setItem.Eticket.MedicineQty,
+				Instruction: getSignaInstruction(setItem),
 			}
 
-			var eticketFileName string
-			if setItem.Eticket.Size == "7x4" {
-				eticketFileName, err = pdf.CreateEticket7x4PDF(eticketPDF, setNumber, h.prescriptionStore)
-				if err != nil {
-					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating eticket pdf for number %d: %v", setItem.Eticket.Number, err))
-					return
-				}
-			} else if setItem.Eticket.Size == "7x5" {
-				eticketFileName, err = pdf.CreateEticket7x5PDF(eticketPDF, setNumber, h.prescriptionStore)
-				if err != nil {
-					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating eticket pdf for number %d: %v", setItem.Eticket.Number, err))
-					return
-				}
-			} else {
-				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown eticket size: %s", setItem.Eticket.Size))
+			eticketBranding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_ETICKET, prescription.BranchID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+				return
+			}
+
+			eticketFileName, err := pdf.CreateEticketPDF(eticketPDF, setNumber, eticketTemplate, h.prescriptionStore, eticketBranding, h.documentStorage)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error creating eticket pdf for number %d: %v", setItem.Eticket.Number, err))
 				return
 			}
 
@@ -1427,6 +1803,13 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 
 				medicineQty = numerator / denum
 			}
+
+			prescribedQty, err := getPrescribedQty(medicine, medicineQty)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+
 			medicineItem := types.PrescriptionMedicineItem{
 				PrescriptionSetItemID: setItemStoreId,
 				MedicineID:            medData.ID,
@@ -1436,6 +1819,8 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 				DiscountPercentage:    medicine.DiscountPercentage,
 				DiscountAmount:        medicine.DiscountAmount,
 				Subtotal:              medicine.Subtotal,
+				PrescribedQty:         prescribedQty,
+				Iteration:             medicine.Iteration,
 			}
 			err = h.prescriptionStore.CreatePrescriptionMedicineItem(medicineItem)
 			if err != nil {
@@ -1444,20 +1829,32 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			err = utils.CheckStock(medData, unit, medicineQty)
+			// the compounded medicine is checked from its ingredients
+			stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicineQty)
 			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("stock for %s is not enough", medicine.MedicineName))
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 				return
 			}
+
+			neededStockItems = append(neededStockItems, stockItems...)
 		}
 	}
 
+	err = utils.CheckStockItems(h.medStore, neededStockItems, prescription.BranchID, nil)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	medicineSets, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(prescription.ID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get medicine items: %v", err))
 		return
 	}
 
+	// age is printed as of the prescription date
+	patient.Age = utils.GetPatientAge(patient, *prescriptionDate)
+
 	prescPDF := types.PrescriptionPDFReturn{
 		Number:       payload.NewData.Number,
 		Date:         *prescriptionDate,
@@ -1465,13 +1862,21 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		Doctor:       *doctor,
 		MedicineSets: medicineSets,
 	}
-	prescFileName, err := pdf.CreatePrescriptionPDF(prescPDF, h.prescriptionStore, prescription.PDFUrl)
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PRESCRIPTION, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	prescFileName, err := pdf.CreatePrescriptionPDF(prescPDF, h.prescriptionStore, prescription.PDFUrl, branding, h.documentStorage)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create presc pdf: %v", err))
 		return
 	}
 
 	// subtract the stock
+	dispensedIngredients := make([]types.DispensedIngredient, 0)
+
 	for _, setItem := range medicineSets {
 		for _, medicine := range setItem.MedicineItems {
 			medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
@@ -1492,18 +1897,54 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			err = utils.SubtractStock(h.medStore, medData, unit, medicine.QtyFloat, user)
+			stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.QtyFloat)
 			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error expanding recipe of %s: %v", medicine.MedicineName, err))
 				return
 			}
+
+			for _, stockItem := range stockItems {
+				err = utils.SubtractStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, prescription.BranchID, user)
+				if err != nil {
+					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+					return
+				}
+
+				err = utils.RecordControlledSubstanceOut(h.registerStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, types.ControlledSubstanceRegister{
+					TransactionType:    constants.CONTROLLED_TRANSACTION_DISPENSE,
+					ReferenceID:        prescription.ID,
+					ReferenceNumber:    payload.NewData.Number,
+					TransactionDate:    *prescriptionDate,
+					PatientName:        patient.Name,
+					PatientAddress:     patient.Address,
+					DoctorName:         doctor.Name,
+					PrescriptionNumber: payload.NewData.Number,
+					UserID:             user.ID,
+					BranchID:           prescription.BranchID,
+				})
+				if err != nil {
+					utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+					return
+				}
+			}
+
+			dispensedIngredients = append(dispensedIngredients, utils.GetDispensedIngredients(constants.DISPENSED_REFERENCE_PRESCRIPTION, prescription.ID, medData, stockItems)...)
 		}
 	}
 
+	err = h.mdmiStore.CreateDispensedIngredients(dispensedIngredients)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving dispensed ingredients: %v", err))
+		return
+	}
+
+	publishPrescriptionEvent(h, constants.EVENT_PRESCRIPTION_MODIFIED, prescription.ID, user)
+
 	returnPayload := map[string]interface{}{
-		"success":         fmt.Sprintf("prescription modified by %s", user.Name),
-		"prescriptionPDF": prescFileName,
-		"eticketPDF":      eticketFileNames,
+		"success":          fmt.Sprintf("prescription modified by %s", user.Name),
+		"prescriptionPDF":  prescFileName,
+		"eticketPDF":       eticketFileNames,
+		"screeningResults": screeningResults,
 	}
 	utils.WriteJSON(w, http.StatusOK, returnPayload)
 }
@@ -1525,7 +1966,7 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
@@ -1539,14 +1980,11 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pdfFiles := []string{("static/pdf/prescription/" + prescription.PDFUrl)}
-
-	w.Header().Set("Content-Type", "application/zip")
-	w.Header().Set("Content-Disposition", "attachment; filename=pdfFiles.zip")
-	w.WriteHeader(http.StatusOK)
-
-	zipWriter := zip.NewWriter(w)
-	defer zipWriter.Close()
+	err = utils.CheckBranchAccess(user, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
 
 	etickets, err := h.prescriptionStore.GetEticketsByPrescriptionID(prescription.ID)
 	if err != nil {
@@ -1554,30 +1992,986 @@ func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(etickets) > 0 {
-		for _, eticket := range etickets {
-			pdfFiles = append(pdfFiles, ("static/pdf/eticket/" + eticket.PDFUrl))
-		}
+	// get all the documents first, so the missing file is reported before the zip is started
+	prescDocument, status, err := utils.GetDocument(h.documentStorage, constants.DOCUMENT_PRESCRIPTION, prescription.PDFUrl)
+	if err != nil {
+		utils.WriteError(w, status, err)
+		return
 	}
 
-	for _, fileName := range pdfFiles {
-		file, err := os.Open(fileName)
+	documents := []*types.Document{prescDocument}
+
+	for _, eticket := range etickets {
+		eticketDocument, status, err := utils.GetDocument(h.documentStorage, constants.DOCUMENT_ETICKET, eticket.PDFUrl)
 		if err != nil {
-			http.Error(w, "File not found: "+fileName, http.StatusNotFound)
+			utils.WriteError(w, status, err)
 			return
 		}
-		defer file.Close()
 
-		zipFile, err := zipWriter.Create(filepath.Base(fileName))
+		documents = append(documents, eticketDocument)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=pdfFiles.zip")
+	w.WriteHeader(http.StatusOK)
+
+	zipWriter := zip.NewWriter(w)
+	defer zipWriter.Close()
+
+	for _, document := range documents {
+		zipFile, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     document.FileName,
+			Method:   zip.Deflate,
+			Modified: document.LastModified,
+			Comment:  ("sha256:" + document.Hash),
+		})
 		if err != nil {
-			http.Error(w, "Could not create zip", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "could not create zip", "error", err)
 			return
 		}
 
-		_, err = io.Copy(zipFile, file)
+		_, err = zipFile.Write(document.Content)
 		if err != nil {
-			http.Error(w, "Could not copy file content", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "could not copy file content", "error", err)
+			return
+		}
+	}
+}
+
+func (h *Handler) handlePrintCopy(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewPrescriptionDetailPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// check if the prescription exists
+	prescription, err := h.prescriptionStore.GetPrescriptionByID(payload.PrescriptionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("prescription with id %d doesn't exists", payload.PrescriptionID))
+		return
+	}
+
+	err = utils.CheckBranchAccess(user, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// the copy is always of the original prescription
+	original, err := getOriginalPrescription(h, prescription.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("original prescription of %d not found", prescription.Number))
+		return
+	}
+
+	medicineSets, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(original.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get medicine items: %v", err))
+		return
+	}
+
+	dispensedQtys, err := h.prescriptionStore.GetDispensedQtys(original.ID, 0)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get dispensed qty: %v", err))
+		return
+	}
+
+	doctor, err := h.doctorStore.GetDoctorByID(original.DoctorID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("doctor id %d not found", original.DoctorID))
+		return
+	}
+
+	patient, err := h.patientStore.GetPatientByID(original.PatientID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("patient id %d not found", original.PatientID))
+		return
+	}
+
+	// age is printed as of the prescription date
+	patient.Age = utils.GetPatientAge(patient, original.PrescriptionDate)
+
+	prescCopyPDF := types.PrescriptionCopyPDFReturn{
+		PrescriptionID:  prescription.ID,
+		Number:          original.Number,
+		Date:            original.PrescriptionDate,
+		CopyDate:        time.Now(),
+		IterationNumber: prescription.IterationNumber,
+		Patient:         *patient,
+		Doctor:          *doctor,
+		MedicineSets:    medicineSets,
+		DispensedQtys:   dispensedQtys,
+	}
+
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_PRESCRIPTION, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	fileName, err := pdf.CreatePrescriptionCopyPDF(prescCopyPDF, branding, h.documentStorage)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create presc copy pdf: %v", err))
+		return
+	}
+
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_PRESCRIPTION_COPY, fileName)
+}
+
+func (h *Handler) handleScreening(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ScreenPrescriptionPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// the patient is not created here, only the existing allergies are used
+	var patient *types.Patient
+	if payload.Patient.ID != 0 {
+		patient, err = h.patientStore.GetPatientByID(payload.Patient.ID)
+	} else {
+		patientData, errParse := utils.ParsePatient(payload.Patient.RegisterPatientPayload)
+		if errParse != nil {
+			utils.WriteError(w, http.StatusBadRequest, errParse)
 			return
 		}
+
+		patient, _ = h.patientStore.GetPatientByIdentity(*patientData)
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("patient id %d doesn't exists", payload.Patient.ID))
+		return
+	}
+
+	screeningResults, err := screenPrescription(h, patient, payload.SetItems)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error screening prescription: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"blocking":         utils.HasBlockingScreeningResult(screeningResults),
+		"screeningResults": screeningResults,
+	})
+}
+
+// patient picked from the list is used directly, otherwise the patient is matched
+// by the identity data and only created if there is no match
+func getPatient(h *Handler, payload types.PrescriptionPatientPayload) (*types.Patient, error) {
+	if payload.ID != 0 {
+		return h.patientStore.GetPatientByID(payload.ID)
+	}
+
+	patientData, err := utils.ParsePatient(payload.RegisterPatientPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsPatientIdentifiable(patientData) {
+		return nil, fmt.Errorf("birth date, phone number, national id or medical record number is required")
+	}
+
+	patient, err := h.patientStore.GetPatientByIdentity(*patientData)
+	if patient == nil {
+		err = h.patientStore.CreatePatient(*patientData)
+		if err == nil {
+			patient, err = h.patientStore.GetPatientByIdentity(*patientData)
+		}
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return patient, nil
+}
+
+func (h *Handler) handleParseSigna(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ParseSignaPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	signa, err := utils.ParseSigna(payload.Dose, payload.ConsumeTime)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.SignaReturn{
+		Signa:       signa,
+		Instruction: utils.GetSignaInstruction(signa, payload.SetUnit),
+		DaysSupply:  utils.GetSignaDaysSupply(signa, payload.Qty),
+		Warning:     utils.CheckSignaQty(signa, payload.Qty),
+	})
+}
+
+func (h *Handler) handleUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.UpdatePrescriptionStatusPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	prescription, err := h.prescriptionStore.GetPrescriptionByID(payload.ID)
+	if prescription == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription id %d doesn't exist", payload.ID))
+		return
+	}
+
+	err = utils.CheckBranchAccess(user, prescription.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if prescription.DeletedAt.Valid {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d is already deleted", prescription.Number))
+		return
+	}
+
+	status := strings.ToUpper(strings.TrimSpace(payload.Status))
+	if !utils.IsValidPrescriptionStatus(status) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown prescription status %s", payload.Status))
+		return
+	}
+
+	if status == constants.PRESC_STATUS_CANCELLED {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cancel the prescription by deleting it, so the stock is returned"))
+		return
+	}
+
+	if !utils.IsValidPrescriptionStatusTransition(prescription.Status, status) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d can't be changed from %s to %s",
+			prescription.Number, prescription.Status, status))
+		return
+	}
+
+	// only a pharmacist may verify the prescription before it is handed over
+	if status == constants.PRESC_STATUS_VERIFIED && !user.Pharmacist {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("only a pharmacist can verify the prescription"))
+		return
+	}
+
+	updated, err := updatePrescriptionStatus(h, prescription, status, payload.Note, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating prescription status: %v", err))
+		return
+	}
+
+	if !updated {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prescription %d status was changed by another user", prescription.Number))
+		return
+	}
+
+	if status == constants.PRESC_STATUS_HANDED_OVER {
+		metrics.IncPrescriptionsDispensed()
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("prescription %d is %s", prescription.Number, status))
+}
+
+func (h *Handler) handleGetQueue(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	branchId, err := utils.ParseBranchIDQuery(r.URL.Query().Get("branchId"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	branchId, err = utils.GetBranchScope(user, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// show all the open prescriptions if the status is not given
+	statuses := utils.GetOpenPrescriptionStatuses()
+
+	params := r.URL.Query()
+	if params.Get("status") != "" {
+		statuses = make([]string, 0)
+
+		for _, status := range strings.Split(params.Get("status"), ",") {
+			status = strings.ToUpper(strings.TrimSpace(status))
+			if !utils.IsValidPrescriptionStatus(status) {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown prescription status %s", status))
+				return
+			}
+
+			statuses = append(statuses, status)
+		}
+	}
+
+	prescriptions, err := h.prescriptionStore.GetPrescriptionQueue(statuses, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	queue := make([]types.PrescriptionQueueGroupReturn, 0)
+
+	for _, status := range statuses {
+		group := types.PrescriptionQueueGroupReturn{
+			Status:        status,
+			Prescriptions: make([]types.PrescriptionQueueReturn, 0),
+		}
+
+		for _, prescription := range prescriptions {
+			if prescription.Status == status {
+				group.Prescriptions = append(group.Prescriptions, prescription)
+			}
+		}
+
+		queue = append(queue, group)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, queue)
+}
+
+func (h *Handler) handleGetPatientHistory(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewPatientMedicationHistoryPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	history, err := getPatientMedicationHistory(h, payload, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, history)
+}
+
+func (h *Handler) handlePrintPatientHistory(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewPatientMedicationHistoryPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	history, err := getPatientMedicationHistory(h, payload, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	company, err := utils.GetCompanySetting(h.settingStore, h.documentStorage)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get document setting: %v", err))
+		return
+	}
+
+	fileName, err := pdf.CreatePatientMedicationHistoryPDF(*history, company, h.documentStorage)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error create patient medication history pdf: %v", err))
+		return
+	}
+
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_PATIENT_HISTORY, fileName)
+}
+
+// patient can be nil when it is not registered yet, then only the interactions are checked
+func screenPrescription(h *Handler, patient *types.Patient, setItems []types.PrescriptionSetItemPayload) ([]types.ScreeningResult, error) {
+	medicines := make([]types.ScreeningMedicine, 0)
+
+	for _, setItem := range setItems {
+		for _, medicineItem := range setItem.MedicineLists {
+			medicine, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
+			if err != nil {
+				return nil, fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName)
+			}
+
+			unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
+			if err != nil || unit == nil {
+				return nil, fmt.Errorf("unit %s not found", medicineItem.Unit)
+			}
+
+			// only the ingredients are needed, not the qty
+			stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medicine, unit, 1)
+			if err != nil {
+				return nil, fmt.Errorf("error expanding recipe of %s: %v", medicine.Name, err)
+			}
+
+			ingredients := make([]types.Medicine, 0)
+			for _, stockItem := range stockItems {
+				ingredients = append(ingredients, *stockItem.Medicine)
+			}
+
+			medicines = append(medicines, types.ScreeningMedicine{
+				Medicine:    *medicine,
+				Ingredients: ingredients,
+			})
+		}
+	}
+
+	allergies := make([]types.PatientAllergy, 0)
+
+	if patient != nil {
+		var err error
+		allergies, err = h.allergyStore.GetAllergiesByPatientID(patient.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := utils.ScreenMedicines(h.ruleStore, medicines, allergies)
+
+	// the qty that doesn't match the signa is only a warning
+	results = append(results, utils.ScreenSigna(setItems)...)
+
+	return results, nil
+}
+
+// blocking results are saved with the override reason and the user who acknowledged it
+func saveScreeningResults(h *Handler, prescriptionId int, results []types.ScreeningResult, overrideReason string, user *types.User) error {
+	for _, result := range results {
+		screening := types.PrescriptionScreening{
+			PrescriptionID: prescriptionId,
+			Type:           result.Type,
+			Severity:       result.Severity,
+			Blocking:       result.Blocking,
+			Medicines:      strings.Join(result.Medicines, ", "),
+			Description:    result.Description,
+		}
+
+		if result.Blocking {
+			screening.OverrideReason = overrideReason
+			screening.AcknowledgedByUserID = sql.NullInt64{Int64: int64(user.ID), Valid: true}
+		}
+
+		err := h.prescriptionStore.CreatePrescriptionScreening(screening)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// a follow-up of a follow-up still points to the first dispense
+func getOriginalPrescription(h *Handler, prescriptionId int) (*types.Prescription, error) {
+	original, err := h.prescriptionStore.GetPrescriptionByID(prescriptionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.OriginalPrescriptionID.Valid {
+		return h.prescriptionStore.GetPrescriptionByID(int(original.OriginalPrescriptionID.Int64))
+	}
+
+	return original, nil
+}
+
+// prescribed qty is the qty written by the doctor, the same as the dispensed qty if empty
+func getPrescribedQty(medicine types.PrescriptionMedicineListPayload, medicineQty float64) (float64, error) {
+	if medicine.PrescribedQty == "" {
+		return medicineQty, nil
+	}
+
+	prescribedQty, err := utils.ParsePrescriptionQty(medicine.PrescribedQty)
+	if err != nil {
+		return 0, fmt.Errorf("error parse prescribed qty of %s: %v", medicine.MedicineName, err)
+	}
+
+	return prescribedQty, nil
+}
+
+// one dispense can't give more than the prescribed qty,
+// the rest is given with the iteration
+func checkPrescribedQty(setItems []types.PrescriptionSetItemPayload) error {
+	for _, setItem := range setItems {
+		for _, medicine := range setItem.MedicineLists {
+			medicineQty, err := utils.ParsePrescriptionQty(medicine.Qty)
+			if err != nil {
+				return fmt.Errorf("error parse qty of %s: %v", medicine.MedicineName, err)
+			}
+
+			prescribedQty, err := getPrescribedQty(medicine, medicineQty)
+			if err != nil {
+				return err
+			}
+
+			if (medicineQty - prescribedQty) > constants.PRESC_QTY_TOLERANCE {
+				return fmt.Errorf("qty of %s is more than the prescribed qty %s", medicine.MedicineName, utils.FormatPrescriptionQty(prescribedQty))
+			}
+		}
+	}
+
+	return nil
+}
+
+// the follow-up can only give what is left from the prescribed qty and its iterations
+func checkRemainingQty(h *Handler, original *types.Prescription, excludePrescriptionId int, setItems []types.PrescriptionSetItemPayload) error {
+	originalSetItems, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(original.ID)
+	if err != nil {
+		return err
+	}
+
+	dispensedQtys, err := h.prescriptionStore.GetDispensedQtys(original.ID, excludePrescriptionId)
+	if err != nil {
+		return err
+	}
+
+	// the same medicine can be in more than one set
+	requestedQtys := make(map[string]float64)
+
+	for _, setItem := range setItems {
+		for _, medicine := range setItem.MedicineLists {
+			medicineQty, err := utils.ParsePrescriptionQty(medicine.Qty)
+			if err != nil {
+				return fmt.Errorf("error parse qty of %s: %v", medicine.MedicineName, err)
+			}
+
+			key := medicine.MedicineBarcode + "|" + strings.ToLower(medicine.Unit)
+			requestedQtys[key] += medicineQty
+
+			totalQty := 0.0
+			found := false
+
+			for _, originalSetItem := range originalSetItems {
+				for _, originalMedicine := range originalSetItem.MedicineItems {
+					if originalMedicine.MedicineBarcode != medicine.MedicineBarcode || !strings.EqualFold(originalMedicine.Unit, medicine.Unit) {
+						continue
+					}
+
+					prescribedQty := originalMedicine.PrescribedQty
+					if prescribedQty <= 0 {
+						prescribedQty = originalMedicine.QtyFloat
+					}
+
+					totalQty += utils.GetPrescriptionTotalQty(prescribedQty, originalMedicine.Iteration)
+					found = true
+				}
+			}
+
+			if !found {
+				return fmt.Errorf("medicine %s with unit %s is not in prescription %d", medicine.MedicineName, medicine.Unit, original.Number)
+			}
+
+			remainingQty := totalQty - utils.GetDispensedQty(dispensedQtys, medicine.MedicineBarcode, medicine.Unit)
+			if remainingQty < 0 {
+				remainingQty = 0
+			}
+
+			if (requestedQtys[key] - remainingQty) > constants.PRESC_QTY_TOLERANCE {
+				return fmt.Errorf("qty of %s is more than the remaining qty %s of prescription %d",
+					medicine.MedicineName, utils.FormatPrescriptionQty(remainingQty), original.Number)
+			}
+		}
+	}
+
+	return nil
+}
+
+func setCompoundedMedicineIngredients(h *Handler, setItems []types.PrescriptionSetItemReturn) error {
+	for setIdx, setItem := range setItems {
+		for medIdx, medicine := range setItem.MedicineItems {
+			medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
+			if err != nil {
+				return fmt.Errorf("medicine %s doesn't exists", medicine.MedicineName)
+			}
+
+			unit, err := h.unitStore.GetUnitByName(medicine.Unit)
+			if err != nil || unit == nil {
+				return fmt.Errorf("unit %s not found", medicine.Unit)
+			}
+
+			ingredients, err := utils.GetCompoundedMedicineIngredients(h.mdmiStore, h.medStore, h.unitStore, medData, unit, medicine.QtyFloat)
+			if err != nil {
+				return err
+			}
+
+			setItems[setIdx].MedicineItems[medIdx].Ingredients = ingredients
+		}
+	}
+
+	return nil
+}
+
+// older clients only send the size, which is also the name of its template
+func getEticketTemplate(h *Handler, setItem types.PrescriptionSetItemPayload) (*types.EticketTemplate, error) {
+	templateName := setItem.Eticket.Template
+	if templateName == "" {
+		templateName = setItem.Eticket.Size
+	}
+
+	return h.templateStore.GetEticketTemplate(templateName)
+}
+
+// empty if the signa can't be read, then the eticket prints the dose as it is
+func getSignaInstruction(setItem types.PrescriptionSetItemPayload) string {
+	signa, err := utils.ParseSigna(setItem.Dose, setItem.ConsumeTime)
+	if err != nil {
+		return ""
+	}
+
+	return utils.GetSignaInstruction(signa, setItem.SetUnit)
+}
+
+func setSetItemSignas(h *Handler, prescriptionId int, setItems []types.PrescriptionSetItemReturn) error {
+	etickets, err := h.prescriptionStore.GetEticketsByPrescriptionID(prescriptionId)
+	if err != nil {
+		return err
+	}
+
+	eticketQtys := make(map[int]float64)
+	for _, eticket := range etickets {
+		eticketQtys[eticket.PrescriptionSetItemID] = eticket.MedicineQty
+	}
+
+	for idx, setItem := range setItems {
+		signa, err := utils.ParseSigna(setItem.Dose, setItem.ConsumeTime)
+		if err != nil {
+			continue
+		}
+
+		medicineQtys := make([]float64, 0)
+		for _, medicine := range setItem.MedicineItems {
+			medicineQtys = append(medicineQtys, medicine.QtyFloat)
+		}
+
+		qty := utils.GetSignaSetQty(eticketQtys[setItem.ID], setItem.Mf, medicineQtys)
+
+		setItems[idx].Signa = signa
+		setItems[idx].Instruction = utils.GetSignaInstruction(signa, setItem.SetUnit)
+		setItems[idx].DaysSupply = utils.GetSignaDaysSupply(signa, qty)
+	}
+
+	return nil
+}
+
+// false if the status was already changed by another request
+func updatePrescriptionStatus(h *Handler, prescription *types.Prescription, status string, note string, user *types.User) (bool, error) {
+	updated, err := h.prescriptionStore.UpdatePrescriptionStatus(prescription.ID, prescription.Status, status, user)
+	if err != nil || !updated {
+		return false, err
+	}
+
+	err = h.prescriptionStore.CreatePrescriptionStatusHistory(types.PrescriptionStatusHistory{
+		PrescriptionID: prescription.ID,
+		FromStatus:     prescription.Status,
+		ToStatus:       status,
+		Note:           note,
+		UserID:         user.ID,
+	})
+	if err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// only the prescriptions of the branch are shown, 0 is all branches
+func getPatientMedicationHistory(h *Handler, payload types.ViewPatientMedicationHistoryPayload, branchId int) (*types.PatientMedicationHistoryReturn, error) {
+	startDate, err := utils.ParseStartDate(payload.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing start date: %v", err)
+	}
+
+	endDate, err := utils.ParseEndDate(payload.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing end date: %v", err)
+	}
+
+	patient, err := h.patientStore.GetPatientByID(payload.PatientID)
+	if patient == nil || err != nil {
+		return nil, fmt.Errorf("patient id %d not found", payload.PatientID)
+	}
+
+	allergies, err := h.allergyStore.GetAllergiesByPatientID(patient.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error get allergies: %v", err)
+	}
+
+	items, err := h.prescriptionStore.GetPatientMedicationHistory(patient.ID, *startDate, *endDate, branchId)
+	if err != nil {
+		return nil, fmt.Errorf("error get medication history: %v", err)
+	}
+
+	// the iterations are counted from the original prescription
+	remainingIterations := make(map[int]map[string]int)
+
+	summaryIdx := make(map[string]int)
+	summaryPrescriptions := make(map[string]map[int]bool)
+	medicines := make([]types.PatientMedicationSummary, 0)
+
+	for idx, item := range items {
+		originalPrescriptionId := item.OriginalPrescriptionID
+		if originalPrescriptionId == 0 {
+			originalPrescriptionId = item.PrescriptionID
+		}
+
+		if _, ok := remainingIterations[originalPrescriptionId]; !ok {
+			remainingIterations[originalPrescriptionId], err = getRemainingIterations(h, originalPrescriptionId)
+			if err != nil {
+				return nil, fmt.Errorf("error get remaining iterations: %v", err)
+			}
+		}
+
+		key := item.MedicineBarcode + "|" + strings.ToLower(item.Unit)
+		items[idx].RemainingIteration = remainingIterations[originalPrescriptionId][key]
+
+		signa, err := utils.ParseSigna(item.Dose, item.ConsumeTime)
+		if err == nil {
+			items[idx].Instruction = utils.GetSignaInstruction(signa, item.SetUnit)
+		}
+
+		// the items are sorted by date, so the first one is the first dispense
+		sIdx, ok := summaryIdx[key]
+		if !ok {
+			medicines = append(medicines, types.PatientMedicationSummary{
+				MedicineBarcode: item.MedicineBarcode,
+				MedicineName:    item.MedicineName,
+				Unit:            item.Unit,
+				FirstDate:       item.PrescriptionDate,
+			})
+
+			sIdx = len(medicines) - 1
+			summaryIdx[key] = sIdx
+			summaryPrescriptions[key] = make(map[int]bool)
+		}
+
+		medicines[sIdx].TotalQty += item.QtyFloat
+		medicines[sIdx].LastDate = item.PrescriptionDate
+
+		if !summaryPrescriptions[key][item.PrescriptionID] {
+			summaryPrescriptions[key][item.PrescriptionID] = true
+			medicines[sIdx].DispenseCount++
+		}
+	}
+
+	sort.Slice(medicines, func(i, j int) bool {
+		return strings.ToLower(medicines[i].MedicineName) < strings.ToLower(medicines[j].MedicineName)
+	})
+
+	return &types.PatientMedicationHistoryReturn{
+		Patient:   *patient,
+		Age:       utils.GetPatientAge(patient, time.Now()),
+		Allergies: allergies,
+		StartDate: *startDate,
+		EndDate:   *endDate,
+		Items:     items,
+		Medicines: medicines,
+	}, nil
+}
+
+// remaining iterations of each medicine in the original prescription, keyed by barcode and unit
+func getRemainingIterations(h *Handler, originalPrescriptionId int) (map[string]int, error) {
+	setItems, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(originalPrescriptionId)
+	if err != nil {
+		return nil, err
+	}
+
+	dispensedQtys, err := h.prescriptionStore.GetDispensedQtys(originalPrescriptionId, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same medicine can be in more than one set
+	prescribedQtys := make(map[string]float64)
+	totalQtys := make(map[string]float64)
+	medicines := make(map[string]types.PrescriptionMedicineItemReturn)
+
+	for _, setItem := range setItems {
+		for _, medicine := range setItem.MedicineItems {
+			prescribedQty := medicine.PrescribedQty
+			if prescribedQty <= 0 {
+				prescribedQty = medicine.QtyFloat
+			}
+
+			key := medicine.MedicineBarcode + "|" + strings.ToLower(medicine.Unit)
+			prescribedQtys[key] += prescribedQty
+			totalQtys[key] += utils.GetPrescriptionTotalQty(prescribedQty, medicine.Iteration)
+			medicines[key] = medicine
+		}
+	}
+
+	remainingIterations := make(map[string]int)
+
+	for key, medicine := range medicines {
+		dispensedQty := utils.GetDispensedQty(dispensedQtys, medicine.MedicineBarcode, medicine.Unit)
+		remainingIterations[key] = utils.GetRemainingIteration(prescribedQtys[key], totalQtys[key], dispensedQty)
+	}
+
+	return remainingIterations, nil
+}
+
+// the stock taken by the prescription is given back, the compounded medicines use
+// the ingredients saved when the prescription was made
+func addDispensedStock(h *Handler, prescription *types.Prescription, medicineItems []types.PrescriptionMedicineItemReturn, user *types.User) error {
+	dispensedItems := make([]types.MedicineStockItem, 0)
+
+	for _, medicineItem := range medicineItems {
+		medData, err := h.medStore.GetMedicineByBarcode(medicineItem.MedicineBarcode)
+		if err != nil {
+			return fmt.Errorf("medicine %s doesn't exists", medicineItem.MedicineName)
+		}
+
+		unit, err := h.unitStore.GetUnitByName(medicineItem.Unit)
+		if err != nil || unit == nil {
+			return fmt.Errorf("unit %s not found", medicineItem.Unit)
+		}
+
+		dispensedItems = append(dispensedItems, types.MedicineStockItem{
+			Medicine: medData,
+			Unit:     unit,
+			Qty:      medicineItem.QtyFloat,
+		})
+	}
+
+	stockItems, err := utils.GetDispensedStockItems(h.mdmiStore, h.medStore, h.unitStore, constants.DISPENSED_REFERENCE_PRESCRIPTION, prescription.ID, dispensedItems)
+	if err != nil {
+		return fmt.Errorf("error get dispensed ingredients: %v", err)
+	}
+
+	for _, stockItem := range stockItems {
+		err = utils.AddStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, prescription.BranchID, user)
+		if err != nil {
+			return fmt.Errorf("error updating stock: %v", err)
+		}
+	}
+
+	err = h.mdmiStore.DeleteDispensedIngredients(constants.DISPENSED_REFERENCE_PRESCRIPTION, prescription.ID)
+	if err != nil {
+		return fmt.Errorf("error deleting dispensed ingredients: %v", err)
+	}
+
+	return nil
+}
+
+// the prescription is read again so the event has the saved data
+func publishPrescriptionEvent(h *Handler, eventType string, prescriptionId int, user *types.User) {
+	prescription, err := h.prescriptionStore.GetPrescriptionByID(prescriptionId)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "prescriptionId", prescriptionId, "error", err)
+		return
+	}
+
+	setItems, err := h.prescriptionStore.GetPrescriptionSetAndMedicineItems(prescriptionId)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "prescriptionId", prescriptionId, "error", err)
+		return
+	}
+
+	utils.PublishEvent(h.eventStore, eventType, constants.EVENT_ENTITY_PRESCRIPTION, prescription.ID, prescription.BranchID, user,
+		map[string]interface{}{"prescription": prescription, "setItems": setItems})
 }
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/logger"
 	"github.com/nicolaics/pharmacon/types"
 
@@ -68,9 +69,12 @@ func (s *Store) GetPrescriptionByID(id int) (*types.Prescription, error) {
 	return prescription, nil
 }
 
-func (s *Store) GetPrescriptionsByDate(startDate time.Time, endDate time.Time) ([]types.PrescriptionListsReturnPayload, error) {
+func (s *Store) GetPrescriptionsByDate(startDate time.Time, endDate time.Time, branchId int) ([]types.PrescriptionListsReturnPayload, error) {
 	query := `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -84,9 +88,10 @@ func (s *Store) GetPrescriptionsByDate(startDate time.Time, endDate time.Time) (
 					JOIN user ON user.id = p.user_id 
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate)
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -107,14 +112,15 @@ func (s *Store) GetPrescriptionsByDate(startDate time.Time, endDate time.Time) (
 	return prescriptions, nil
 }
 
-func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]types.PrescriptionListsReturnPayload, error) {
+func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]types.PrescriptionListsReturnPayload, error) {
 	query := `SELECT COUNT(*)
 					FROM prescription 
 					WHERE prescription_date >= ? AND prescription_date < ? 
 					AND number = ? 
-					AND deleted_at IS NULL`
+					AND deleted_at IS NULL 
+					AND (? = 0 OR prescription.branch_id = ?)`
 
-	row := s.db.QueryRow(query, startDate, endDate, number)
+	row := s.db.QueryRow(query, startDate, endDate, number, branchId, branchId)
 	if row.Err() != nil {
 		return nil, row.Err()
 	}
@@ -130,7 +136,10 @@ func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate tim
 
 	if count == 0 {
 		query := `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -144,6 +153,7 @@ func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate tim
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
 					AND p.number LIKE ? 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
 		searchVal := "%"
@@ -153,7 +163,7 @@ func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate tim
 			}
 		}
 
-		rows, err := s.db.Query(query, startDate, endDate, searchVal)
+		rows, err := s.db.Query(query, startDate, endDate, searchVal, branchId, branchId)
 		if err != nil {
 			return nil, err
 		}
@@ -172,7 +182,10 @@ func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate tim
 	}
 
 	query = `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -186,9 +199,10 @@ func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate tim
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
 					AND p.number = ? 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, number)
+	rows, err := s.db.Query(query, startDate, endDate, number, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -207,9 +221,12 @@ func (s *Store) GetPrescriptionsByDateAndNumber(startDate time.Time, endDate tim
 	return prescriptions, nil
 }
 
-func (s *Store) GetPrescriptionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]types.PrescriptionListsReturnPayload, error) {
+func (s *Store) GetPrescriptionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]types.PrescriptionListsReturnPayload, error) {
 	query := `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -223,9 +240,10 @@ func (s *Store) GetPrescriptionsByDateAndUserID(startDate time.Time, endDate tim
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
 					AND p.user_id = ? 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uid)
+	rows, err := s.db.Query(query, startDate, endDate, uid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -246,9 +264,12 @@ func (s *Store) GetPrescriptionsByDateAndUserID(startDate time.Time, endDate tim
 	return prescriptions, nil
 }
 
-func (s *Store) GetPrescriptionsByDateAndPatientID(startDate time.Time, endDate time.Time, pid int) ([]types.PrescriptionListsReturnPayload, error) {
+func (s *Store) GetPrescriptionsByDateAndPatientID(startDate time.Time, endDate time.Time, pid int, branchId int) ([]types.PrescriptionListsReturnPayload, error) {
 	query := `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -262,9 +283,10 @@ func (s *Store) GetPrescriptionsByDateAndPatientID(startDate time.Time, endDate
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
 					AND p.patient_id = ? 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, pid)
+	rows, err := s.db.Query(query, startDate, endDate, pid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -285,9 +307,12 @@ func (s *Store) GetPrescriptionsByDateAndPatientID(startDate time.Time, endDate
 	return prescriptions, nil
 }
 
-func (s *Store) GetPrescriptionsByDateAndDoctorID(startDate time.Time, endDate time.Time, did int) ([]types.PrescriptionListsReturnPayload, error) {
+func (s *Store) GetPrescriptionsByDateAndDoctorID(startDate time.Time, endDate time.Time, did int, branchId int) ([]types.PrescriptionListsReturnPayload, error) {
 	query := `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -301,9 +326,10 @@ func (s *Store) GetPrescriptionsByDateAndDoctorID(startDate time.Time, endDate t
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
 					AND p.doctor_id = ? 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, did)
+	rows, err := s.db.Query(query, startDate, endDate, did, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -324,9 +350,12 @@ func (s *Store) GetPrescriptionsByDateAndDoctorID(startDate time.Time, endDate t
 	return prescriptions, nil
 }
 
-func (s *Store) GetPrescriptionsByDateAndInvoiceID(startDate time.Time, endDate time.Time, iid int) ([]types.PrescriptionListsReturnPayload, error) {
+func (s *Store) GetPrescriptionsByDateAndInvoiceID(startDate time.Time, endDate time.Time, iid int, branchId int) ([]types.PrescriptionListsReturnPayload, error) {
 	query := `SELECT p.id, p.number, p.prescription_date, 
-					patient.name, doctor.name, 
+					patient.name, 
+					IF(patient.birth_date IS NULL, patient.age, 
+						TIMESTAMPDIFF(YEAR, patient.birth_date, p.prescription_date)), 
+					doctor.name, 
 					p.qty, p.price, p.total_price, p.description, 
 					user.name, 
 					i.number, 
@@ -340,9 +369,10 @@ func (s *Store) GetPrescriptionsByDateAndInvoiceID(startDate time.Time, endDate
 					WHERE p.prescription_date >= ? AND p.prescription_date < ? 
 					AND p.deleted_at IS NULL 
 					AND p.invoice_id = ? 
+					AND (? = 0 OR p.branch_id = ?) 
 					ORDER BY p.prescription_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, iid)
+	rows, err := s.db.Query(query, startDate, endDate, iid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -394,21 +424,23 @@ func (s *Store) GetPrescriptionID(invoiceId int, number int, date time.Time, pat
 
 func (s *Store) CreatePrescription(prescription types.Prescription) error {
 	values := "?"
-	for i := 0; i < 11; i++ {
+	for i := 0; i < 14; i++ {
 		values += ", ?"
 	}
 
 	query := `INSERT INTO prescription (
 		invoice_id, number, prescription_date, patient_id, doctor_id, qty, 
 		price, total_price, description, 
-		user_id, last_modified_by_user_id, pdf_url
+		user_id, last_modified_by_user_id, pdf_url, 
+		original_prescription_id, iteration_number, branch_id
 	) VALUES (` + values + `)`
 
 	_, err := s.db.Exec(query,
 		prescription.InvoiceID, prescription.Number, prescription.PrescriptionDate,
 		prescription.PatientID, prescription.DoctorID, prescription.Qty,
 		prescription.Price, prescription.TotalPrice, prescription.Description,
-		prescription.UserID, prescription.LastModifiedByUserID, prescription.PDFUrl)
+		prescription.UserID, prescription.LastModifiedByUserID, prescription.PDFUrl,
+		prescription.OriginalPrescriptionID, prescription.IterationNumber, prescription.BranchID)
 	if err != nil {
 		return err
 	}
@@ -439,19 +471,21 @@ func (s *Store) CreateSetItem(medicineSet types.PrescriptionSetItem) error {
 
 func (s *Store) CreatePrescriptionMedicineItem(prescMedItem types.PrescriptionMedicineItem) error {
 	values := "?"
-	for i := 0; i < 7; i++ {
+	for i := 0; i < 9; i++ {
 		values += ", ?"
 	}
 
 	query := `INSERT INTO prescription_medicine_item (
 				prescription_set_item_id, medicine_id, qty, unit_id, 
-				price, discount_percentage, discount_amount, subtotal
+				price, discount_percentage, discount_amount, subtotal, 
+				prescribed_qty, iteration
 	) VALUES (` + values + `)`
 
 	_, err := s.db.Exec(query,
 		prescMedItem.PrescriptionSetItemID, prescMedItem.MedicineID,
 		prescMedItem.Qty, prescMedItem.UnitID, prescMedItem.Price,
-		prescMedItem.DiscountPercentage, prescMedItem.DiscountAmount, prescMedItem.Subtotal)
+		prescMedItem.DiscountPercentage, prescMedItem.DiscountAmount, prescMedItem.Subtotal,
+		prescMedItem.PrescribedQty, prescMedItem.Iteration)
 	if err != nil {
 		return err
 	}
@@ -464,7 +498,8 @@ func (s *Store) GetPrescriptionMedicineItems(prescriptionSetItemId int) ([]types
 			medicine.barcode, medicine.name, 
 			pmi.qty, 
 			unit.name, 
-			pmi.price, pmi.discount_percentage, pmi.discount_amount, pmi.subtotal 
+			pmi.price, pmi.discount_percentage, pmi.discount_amount, pmi.subtotal, 
+			pmi.prescribed_qty, pmi.iteration 
 			
 			FROM prescription_medicine_item as pmi 
 			JOIN prescription_set_item as psi ON pmi.prescription_set_item_id = psi.id 
@@ -508,6 +543,8 @@ func (s *Store) GetPrescriptionMedicineItems(prescriptionSetItemId int) ([]types
 			DiscountPercentage: prescMedItem.DiscountPercentage,
 			DiscountAmount:     prescMedItem.DiscountAmount,
 			Subtotal:           prescMedItem.Subtotal,
+			PrescribedQty:      prescMedItem.PrescribedQty,
+			Iteration:          prescMedItem.Iteration,
 		})
 	}
 
@@ -515,8 +552,8 @@ func (s *Store) GetPrescriptionMedicineItems(prescriptionSetItemId int) ([]types
 }
 
 func (s *Store) DeletePrescription(prescription *types.Prescription, user *types.User) error {
-	query := "UPDATE prescription SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
-	_, err := s.db.Exec(query, time.Now(), user.ID, prescription.ID)
+	query := "UPDATE prescription SET deleted_at = ?, deleted_by_user_id = ?, status = ? WHERE id = ?"
+	_, err := s.db.Exec(query, time.Now(), user.ID, constants.PRESC_STATUS_CANCELLED, prescription.ID)
 	if err != nil {
 		return err
 	}
@@ -661,6 +698,11 @@ func (s *Store) AbsoluteDeletePrescription(presc types.Prescription) error {
 		return err
 	}
 
+	err = s.DeletePrescriptionScreenings(prescId)
+	if err != nil {
+		return err
+	}
+
 	query = `DELETE FROM prescription WHERE id = ?`
 	_, err = s.db.Exec(query, prescId)
 	if err != nil {
@@ -779,10 +821,12 @@ func (s *Store) GetSetItemID(medicineSet types.PrescriptionSetItem) (int, error)
 
 func (s *Store) CreateEticket(eticket types.Eticket) error {
 	query := `INSERT INTO eticket 
-				(prescription_id, prescription_set_item_id, number, medicine_qty, size, pdf_url) 
-				VALUES (?, ?, ?, ?, ?, ?)`
+				(prescription_id, prescription_set_item_id, number, medicine_qty, size, pdf_url, 
+				template, shake_well) 
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 	_, err := s.db.Exec(query, eticket.PrescriptionID, eticket.PrescriptionSetItemID,
-		eticket.Number, eticket.MedicineQty, eticket.Size, eticket.PDFUrl)
+		eticket.Number, eticket.MedicineQty, eticket.Size, eticket.PDFUrl,
+		eticket.Template, eticket.ShakeWell)
 	if err != nil {
 		return err
 	}
@@ -1039,6 +1083,73 @@ func (s *Store) IsValidPrescriptionNumber(number int, startDate time.Time, endDa
 	return count < 1, nil
 }
 
+func (s *Store) GetFollowUpPrescriptions(originalPrescriptionId int) ([]types.Prescription, error) {
+	query := `SELECT * FROM prescription 
+				WHERE original_prescription_id = ? AND deleted_at IS NULL 
+				ORDER BY iteration_number ASC`
+
+	rows, err := s.db.Query(query, originalPrescriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prescriptions := make([]types.Prescription, 0)
+
+	for rows.Next() {
+		prescription, err := scanRowIntoPrescription(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		prescriptions = append(prescriptions, *prescription)
+	}
+
+	return prescriptions, nil
+}
+
+func (s *Store) GetDispensedQtys(originalPrescriptionId int, excludePrescriptionId int) ([]types.PrescriptionDispensedQty, error) {
+	query := `SELECT medicine.barcode, medicine.name, unit.name, 
+					SUM(pmi.qty), 
+					COUNT(DISTINCT CASE WHEN pmi.qty > 0 THEN presc.id END) 
+					FROM prescription_medicine_item AS pmi 
+					JOIN prescription_set_item AS psi ON psi.id = pmi.prescription_set_item_id 
+					JOIN prescription AS presc ON presc.id = psi.prescription_id 
+					JOIN medicine ON medicine.id = pmi.medicine_id 
+					JOIN unit ON unit.id = pmi.unit_id 
+					WHERE (presc.id = ? OR presc.original_prescription_id = ?) 
+					AND presc.id <> ? 
+					AND presc.deleted_at IS NULL 
+					GROUP BY medicine.barcode, medicine.name, unit.name`
+
+	rows, err := s.db.Query(query, originalPrescriptionId, originalPrescriptionId, excludePrescriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dispensedQtys := make([]types.PrescriptionDispensedQty, 0)
+
+	for rows.Next() {
+		var dispensedQty types.PrescriptionDispensedQty
+
+		err = rows.Scan(
+			&dispensedQty.MedicineBarcode,
+			&dispensedQty.MedicineName,
+			&dispensedQty.Unit,
+			&dispensedQty.Qty,
+			&dispensedQty.DispenseCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		dispensedQtys = append(dispensedQtys, dispensedQty)
+	}
+
+	return dispensedQtys, nil
+}
+
 func scanRowIntoSetItem(rows *sql.Rows) (*types.PrescriptionSetItem, error) {
 	medicineSet := new(types.PrescriptionSetItem)
 
@@ -1062,6 +1173,273 @@ func scanRowIntoSetItem(rows *sql.Rows) (*types.PrescriptionSetItem, error) {
 	return medicineSet, nil
 }
 
+func (s *Store) CreatePrescriptionScreening(screening types.PrescriptionScreening) error {
+	values := "?"
+	for i := 0; i < 7; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO prescription_screening (
+		prescription_id, type, severity, blocking, medicines, 
+		description, override_reason, acknowledged_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		screening.PrescriptionID, screening.Type, screening.Severity,
+		screening.Blocking, screening.Medicines, screening.Description,
+		screening.OverrideReason, screening.AcknowledgedByUserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetPrescriptionScreeningsByPrescriptionID(prescriptionId int) ([]types.PrescriptionScreening, error) {
+	query := "SELECT * FROM prescription_screening WHERE prescription_id = ? ORDER BY id ASC"
+	rows, err := s.db.Query(query, prescriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	screenings := make([]types.PrescriptionScreening, 0)
+
+	for rows.Next() {
+		screening, err := scanRowIntoPrescriptionScreening(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		screenings = append(screenings, *screening)
+	}
+
+	return screenings, nil
+}
+
+func (s *Store) DeletePrescriptionScreenings(prescriptionId int) error {
+	_, err := s.db.Exec("DELETE FROM prescription_screening WHERE prescription_id = ?", prescriptionId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) UpdatePrescriptionStatus(prescriptionId int, fromStatus string, toStatus string, user *types.User) (bool, error) {
+	query := `UPDATE prescription SET status = ?, last_modified = ?, last_modified_by_user_id = ? 
+				WHERE id = ? AND status = ? AND deleted_at IS NULL`
+	result, err := s.db.Exec(query, toStatus, time.Now(), user.ID, prescriptionId, fromStatus)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return (affected == 1), nil
+}
+
+func (s *Store) CreatePrescriptionStatusHistory(history types.PrescriptionStatusHistory) error {
+	query := `INSERT INTO prescription_status_history 
+				(prescription_id, from_status, to_status, note, user_id) 
+				VALUES (?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, history.PrescriptionID, history.FromStatus,
+		history.ToStatus, history.Note, history.UserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetPrescriptionStatusHistories(prescriptionId int) ([]types.PrescriptionStatusHistoryReturn, error) {
+	query := `SELECT h.from_status, h.to_status, h.note, user.name, h.created_at 
+				FROM prescription_status_history AS h 
+				JOIN user ON user.id = h.user_id 
+				WHERE h.prescription_id = ? 
+				ORDER BY h.id ASC`
+	rows, err := s.db.Query(query, prescriptionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := make([]types.PrescriptionStatusHistoryReturn, 0)
+
+	for rows.Next() {
+		var history types.PrescriptionStatusHistoryReturn
+
+		err = rows.Scan(
+			&history.FromStatus,
+			&history.ToStatus,
+			&history.Note,
+			&history.UserName,
+			&history.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		history.CreatedAt = history.CreatedAt.Local()
+
+		histories = append(histories, history)
+	}
+
+	return histories, nil
+}
+
+func (s *Store) GetPrescriptionQueue(statuses []string, branchId int) ([]types.PrescriptionQueueReturn, error) {
+	if len(statuses) == 0 {
+		return []types.PrescriptionQueueReturn{}, nil
+	}
+
+	values := "?"
+	for i := 1; i < len(statuses); i++ {
+		values += ", ?"
+	}
+
+	args := make([]interface{}, 0)
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+	args = append(args, branchId, branchId)
+
+	// the last status change of each prescription
+	query := `SELECT p.id, p.number, p.prescription_date, 
+					patient.name, doctor.name, p.iteration_number, p.status, 
+					h.created_at, user.name 
+					FROM prescription AS p 
+					JOIN patient ON p.patient_id = patient.id 
+					JOIN doctor ON p.doctor_id = doctor.id 
+					JOIN prescription_status_history AS h ON h.id = (
+						SELECT MAX(id) FROM prescription_status_history 
+						WHERE prescription_id = p.id
+					) 
+					JOIN user ON user.id = h.user_id 
+					WHERE p.status IN (` + values + `) 
+					AND p.deleted_at IS NULL 
+					AND (? = 0 OR p.branch_id = ?) 
+					ORDER BY h.created_at ASC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue := make([]types.PrescriptionQueueReturn, 0)
+
+	for rows.Next() {
+		var prescription types.PrescriptionQueueReturn
+
+		err = rows.Scan(
+			&prescription.ID,
+			&prescription.Number,
+			&prescription.PrescriptionDate,
+			&prescription.PatientName,
+			&prescription.DoctorName,
+			&prescription.IterationNumber,
+			&prescription.Status,
+			&prescription.StatusUpdatedAt,
+			&prescription.StatusUpdatedByUserName,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		prescription.PrescriptionDate = prescription.PrescriptionDate.Local()
+		prescription.StatusUpdatedAt = prescription.StatusUpdatedAt.Local()
+
+		queue = append(queue, prescription)
+	}
+
+	return queue, nil
+}
+
+func (s *Store) GetPatientMedicationHistory(patientId int, startDate time.Time, endDate time.Time, branchId int) ([]types.PatientMedicationHistoryItem, error) {
+	query := `SELECT p.id, p.number, p.prescription_date, 
+					COALESCE(p.original_prescription_id, 0), p.iteration_number, p.status, 
+					doctor.name, doctor.license_number, 
+					invoice.number, invoice.invoice_date, 
+					mf.name, dose.name, set_unit.name, consume_time.name, psu.name, 
+					medicine.barcode, medicine.name, 
+					pmi.qty, unit.name 
+					FROM prescription_medicine_item AS pmi 
+					JOIN prescription_set_item AS psi ON psi.id = pmi.prescription_set_item_id 
+					JOIN prescription AS p ON p.id = psi.prescription_id 
+					JOIN doctor ON doctor.id = p.doctor_id 
+					JOIN invoice ON invoice.id = p.invoice_id 
+					JOIN mf ON mf.id = psi.mf_id 
+					JOIN dose ON dose.id = psi.dose_id 
+					JOIN unit AS set_unit ON set_unit.id = psi.set_unit_id 
+					JOIN consume_time ON consume_time.id = psi.consume_time_id 
+					JOIN prescription_set_usage AS psu ON psu.id = psi.prescription_set_usage_id 
+					JOIN medicine ON medicine.id = pmi.medicine_id 
+					JOIN unit ON unit.id = pmi.unit_id 
+					WHERE p.patient_id = ? 
+					AND p.prescription_date >= ? AND p.prescription_date < ? 
+					AND p.deleted_at IS NULL 
+					AND (? = 0 OR p.branch_id = ?) 
+					ORDER BY p.prescription_date ASC, p.id ASC, psi.id ASC, pmi.id ASC`
+
+	rows, err := s.db.Query(query, patientId, startDate, endDate, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]types.PatientMedicationHistoryItem, 0)
+
+	for rows.Next() {
+		var item types.PatientMedicationHistoryItem
+
+		err = rows.Scan(
+			&item.PrescriptionID,
+			&item.PrescriptionNumber,
+			&item.PrescriptionDate,
+			&item.OriginalPrescriptionID,
+			&item.IterationNumber,
+			&item.Status,
+			&item.DoctorName,
+			&item.DoctorLicenseNumber,
+			&item.InvoiceNumber,
+			&item.InvoiceDate,
+			&item.Mf,
+			&item.Dose,
+			&item.SetUnit,
+			&item.ConsumeTime,
+			&item.Usage,
+			&item.MedicineBarcode,
+			&item.MedicineName,
+			&item.QtyFloat,
+			&item.Unit,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if item.QtyFloat < 1.0 {
+			item.QtyString = dectofrac.NewRatP(item.QtyFloat, 0.01).String()
+		} else {
+			if item.QtyFloat == math.Trunc(item.QtyFloat) {
+				item.QtyString = fmt.Sprintf("%.0f", item.QtyFloat)
+			} else {
+				item.QtyString = fmt.Sprintf("%.1f", item.QtyFloat)
+			}
+		}
+
+		item.PrescriptionDate = item.PrescriptionDate.Local()
+		item.InvoiceDate = item.InvoiceDate.Local()
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
 func scanRowIntoPrescription(rows *sql.Rows) (*types.Prescription, error) {
 	prescription := new(types.Prescription)
 
@@ -1083,6 +1461,10 @@ func scanRowIntoPrescription(rows *sql.Rows) (*types.Prescription, error) {
 		&prescription.PDFUrl,
 		&prescription.DeletedAt,
 		&prescription.DeletedByUserID,
+		&prescription.OriginalPrescriptionID,
+		&prescription.IterationNumber,
+		&prescription.Status,
+		&prescription.BranchID,
 	)
 
 	if err != nil {
@@ -1104,6 +1486,7 @@ func scanRowIntoPrescriptionLists(rows *sql.Rows) (*types.PrescriptionListsRetur
 		&prescription.Number,
 		&prescription.PrescriptionDate,
 		&prescription.PatientName,
+		&prescription.PatientAge,
 		&prescription.DoctorName,
 		&prescription.Qty,
 		&prescription.Price,
@@ -1138,6 +1521,8 @@ func scanRowIntoPrescriptionMedicineItem(rows *sql.Rows) (*types.PrescriptionMed
 		&prescMedItem.DiscountPercentage,
 		&prescMedItem.DiscountAmount,
 		&prescMedItem.Subtotal,
+		&prescMedItem.PrescribedQty,
+		&prescMedItem.Iteration,
 	)
 
 	if err != nil {
@@ -1159,6 +1544,8 @@ func scanRowIntoEticket(rows *sql.Rows) (*types.Eticket, error) {
 		&eticket.Size,
 		&eticket.PDFUrl,
 		&eticket.CreatedAt,
+		&eticket.Template,
+		&eticket.ShakeWell,
 	)
 
 	if err != nil {
@@ -1169,3 +1556,28 @@ func scanRowIntoEticket(rows *sql.Rows) (*types.Eticket, error) {
 
 	return eticket, nil
 }
+
+func scanRowIntoPrescriptionScreening(rows *sql.Rows) (*types.PrescriptionScreening, error) {
+	screening := new(types.PrescriptionScreening)
+
+	err := rows.Scan(
+		&screening.ID,
+		&screening.PrescriptionID,
+		&screening.Type,
+		&screening.Severity,
+		&screening.Blocking,
+		&screening.Medicines,
+		&screening.Description,
+		&screening.OverrideReason,
+		&screening.AcknowledgedByUserID,
+		&screening.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	screening.CreatedAt = screening.CreatedAt.Local()
+
+	return screening, nil
+}
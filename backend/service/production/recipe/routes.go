@@ -0,0 +1,492 @@
+package recipe
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	recipeStore     types.ProductionRecipeStore
+	productionStore types.ProductionStore
+	userStore       types.UserStore
+	medStore        types.MedicineStore
+	unitStore       types.UnitStore
+}
+
+func NewHandler(recipeStore types.ProductionRecipeStore,
+	productionStore types.ProductionStore,
+	userStore types.UserStore,
+	medStore types.MedicineStore,
+	unitStore types.UnitStore) *Handler {
+	return &Handler{
+		recipeStore:     recipeStore,
+		productionStore: productionStore,
+		userStore:       userStore,
+		medStore:        medStore,
+		unitStore:       unitStore,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/production-recipe", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/production-recipe", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/production-recipe/detail", h.handleGetDetail).Methods(http.MethodPost)
+	router.HandleFunc("/production-recipe/scale", h.handleScale).Methods(http.MethodPost)
+	router.HandleFunc("/production-recipe", h.handleModify).Methods(http.MethodPatch)
+	router.HandleFunc("/production-recipe", h.handleDelete).Methods(http.MethodDelete)
+
+	router.HandleFunc("/production-recipe", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/production-recipe/detail", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/production-recipe/scale", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.RegisterProductionRecipePayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	producedMedicine, err := h.medStore.GetMedicineByBarcode(payload.ProducedMedicineBarcode)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("med %s not found, create the meds first", payload.ProducedMedicineName))
+		return
+	}
+
+	// one recipe per produced medicine
+	recipe, err := h.recipeStore.GetRecipeByProducedMedicineID(producedMedicine.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if recipe != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("recipe for %s exists already", producedMedicine.Name))
+		return
+	}
+
+	yieldUnit, err := getUnit(h, payload.YieldUnit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.recipeStore.CreateRecipe(types.ProductionRecipe{
+		ProducedMedicineID:   producedMedicine.ID,
+		YieldQty:             payload.YieldQty,
+		YieldUnitID:          yieldUnit.ID,
+		Description:          payload.Description,
+		UserID:               user.ID,
+		LastModifiedByUserID: user.ID,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	recipe, err = h.recipeStore.GetRecipeByProducedMedicineID(producedMedicine.ID)
+	if err != nil || recipe == nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error getting recipe of %s: %v", producedMedicine.Name, err))
+		return
+	}
+
+	err = createRecipeItems(h, recipe, producedMedicine, payload.Items)
+	if err != nil {
+		errDel := h.recipeStore.DeleteRecipe(recipe, user)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("%v\nerror deleting recipe: %v", err, errDel))
+			return
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("recipe for %s successfully created by %s", producedMedicine.Name, user.Name))
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	recipes, err := h.recipeStore.GetAllRecipes()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, recipes)
+}
+
+func (h *Handler) handleGetDetail(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewProductionRecipeDetailPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	recipe, err := h.recipeStore.GetRecipeByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("recipe id %d not found", payload.ID))
+		return
+	}
+
+	producedMedicine, err := h.medStore.GetMedicineByID(recipe.ProducedMedicineID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("medicine id %d not found", recipe.ProducedMedicineID))
+		return
+	}
+
+	yieldUnit, err := h.unitStore.GetUnitByID(recipe.YieldUnitID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("unit id %d not found", recipe.YieldUnitID))
+		return
+	}
+
+	lastModifiedUser, err := h.userStore.GetUserByID(recipe.LastModifiedByUserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("user id %d not found", recipe.LastModifiedByUserID))
+		return
+	}
+
+	items, err := h.recipeStore.GetRecipeItems(recipe.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	returnPayload := types.ProductionRecipeDetailPayload{
+		ID:                     recipe.ID,
+		YieldQty:               recipe.YieldQty,
+		YieldUnit:              yieldUnit.Name,
+		Description:            recipe.Description,
+		CreatedAt:              recipe.CreatedAt,
+		LastModified:           recipe.LastModified,
+		LastModifiedByUserName: lastModifiedUser.Name,
+		Items:                  items,
+	}
+
+	returnPayload.ProducedMedicine.Barcode = producedMedicine.Barcode
+	returnPayload.ProducedMedicine.Name = producedMedicine.Name
+
+	utils.WriteJSON(w, http.StatusOK, returnPayload)
+}
+
+func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ModifyProductionRecipePayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	recipe, err := h.recipeStore.GetRecipeByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("recipe id %d not found", payload.ID))
+		return
+	}
+
+	producedMedicine, err := h.medStore.GetMedicineByBarcode(payload.NewData.ProducedMedicineBarcode)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("med %s not found, create the meds first", payload.NewData.ProducedMedicineName))
+		return
+	}
+
+	// check duplicate
+	if producedMedicine.ID != recipe.ProducedMedicineID {
+		otherRecipe, err := h.recipeStore.GetRecipeByProducedMedicineID(producedMedicine.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if otherRecipe != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("recipe for %s exists already", producedMedicine.Name))
+			return
+		}
+	}
+
+	yieldUnit, err := getUnit(h, payload.NewData.YieldUnit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// check the items before changing anything
+	for _, item := range payload.NewData.Items {
+		_, err := getRecipeItem(h, recipe.ID, producedMedicine, item)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	err = h.recipeStore.ModifyRecipe(recipe.ID, types.ProductionRecipe{
+		ProducedMedicineID: producedMedicine.ID,
+		YieldQty:           payload.NewData.YieldQty,
+		YieldUnitID:        yieldUnit.ID,
+		Description:        payload.NewData.Description,
+	}, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.recipeStore.DeleteRecipeItems(recipe, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = createRecipeItems(h, recipe, producedMedicine, payload.NewData.Items)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("recipe for %s modified by %s", producedMedicine.Name, user.Name))
+}
+
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DeleteProductionRecipePayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	recipe, err := h.recipeStore.GetRecipeByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("recipe id %d not found", payload.ID))
+		return
+	}
+
+	err = h.recipeStore.DeleteRecipe(recipe, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("recipe id %d deleted by %s", payload.ID, user.Name))
+}
+
+func (h *Handler) handleScale(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ScaleProductionRecipePayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	producedMedicine, err := h.medStore.GetMedicineByBarcode(payload.ProducedMedicineBarcode)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine barcode %s not found", payload.ProducedMedicineBarcode))
+		return
+	}
+
+	recipe, err := h.recipeStore.GetRecipeByProducedMedicineID(producedMedicine.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if recipe == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("%s has no recipe", producedMedicine.Name))
+		return
+	}
+
+	unit, err := h.unitStore.GetUnitByName(payload.Unit)
+	if err != nil || unit == nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unit %s not found", payload.Unit))
+		return
+	}
+
+	items, err := h.recipeStore.GetRecipeItems(recipe.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	ingredients, err := utils.ScaleProductionRecipe(h.unitStore, producedMedicine, recipe, items, unit, payload.PlannedQty)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	totalCost := 0.0
+
+	for idx, ingredient := range ingredients {
+		medData, err := h.medStore.GetMedicineByBarcode(ingredient.MedicineBarcode)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("medicine %s doesn't exists", ingredient.MedicineName))
+			return
+		}
+
+		ingredientUnit, err := h.unitStore.GetUnitByName(ingredient.Unit)
+		if err != nil || ingredientUnit == nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("unit %s not found", ingredient.Unit))
+			return
+		}
+
+		cost, err := utils.GetMedicineCost(h.productionStore, h.unitStore, medData, ingredientUnit, ingredient.Qty)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error getting cost of %s: %v", ingredient.MedicineName, err))
+			return
+		}
+
+		ingredients[idx].Cost = cost
+		totalCost += cost
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ProductionRecipeScaleReturnPayload{
+		RecipeID:      recipe.ID,
+		PlannedQty:    payload.PlannedQty,
+		Unit:          unit.Name,
+		MedicineLists: ingredients,
+		TotalCost:     totalCost,
+	})
+}
+
+func getUnit(h *Handler, unitName string) (*types.Unit, error) {
+	unit, err := h.unitStore.GetUnitByName(unitName)
+	if unit == nil {
+		err = h.unitStore.CreateUnit(unitName)
+		if err != nil {
+			return nil, err
+		}
+
+		unit, err = h.unitStore.GetUnitByName(unitName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return unit, nil
+}
+
+func getRecipeItem(h *Handler, recipeId int, producedMedicine *types.Medicine, item types.ProductionRecipeItemPayload) (*types.ProductionRecipeItem, error) {
+	medData, err := h.medStore.GetMedicineByBarcode(item.MedicineBarcode)
+	if err != nil {
+		return nil, fmt.Errorf("medicine %s doesn't exists", item.MedicineName)
+	}
+
+	if medData.ID == producedMedicine.ID {
+		return nil, fmt.Errorf("%s can't be an ingredient of itself", medData.Name)
+	}
+
+	unit, err := h.unitStore.GetUnitByName(item.Unit)
+	if err != nil || unit == nil {
+		return nil, fmt.Errorf("unit %s not found", item.Unit)
+	}
+
+	if unit.ID != medData.FirstUnitID && unit.ID != medData.SecondUnitID && unit.ID != medData.ThirdUnitID {
+		return nil, fmt.Errorf("unit %s is not a unit of %s", item.Unit, medData.Name)
+	}
+
+	return &types.ProductionRecipeItem{
+		ProductionRecipeID: recipeId,
+		MedicineID:         medData.ID,
+		Qty:                item.Qty,
+		UnitID:             unit.ID,
+	}, nil
+}
+
+func createRecipeItems(h *Handler, recipe *types.ProductionRecipe, producedMedicine *types.Medicine, items []types.ProductionRecipeItemPayload) error {
+	for _, item := range items {
+		recipeItem, err := getRecipeItem(h, recipe.ID, producedMedicine, item)
+		if err != nil {
+			return err
+		}
+
+		err = h.recipeStore.CreateRecipeItem(*recipeItem)
+		if err != nil {
+			return fmt.Errorf("error creating recipe item %s: %v", item.MedicineName, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package recipe
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetRecipeByID(id int) (*types.ProductionRecipe, error) {
+	query := "SELECT * FROM production_recipe WHERE id = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipe := new(types.ProductionRecipe)
+
+	for rows.Next() {
+		recipe, err = scanRowIntoRecipe(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if recipe.ID == 0 {
+		return nil, fmt.Errorf("recipe not found")
+	}
+
+	return recipe, nil
+}
+
+func (s *Store) GetRecipeByProducedMedicineID(medicineId int) (*types.ProductionRecipe, error) {
+	query := "SELECT * FROM production_recipe WHERE produced_medicine_id = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, medicineId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipe := new(types.ProductionRecipe)
+
+	for rows.Next() {
+		recipe, err = scanRowIntoRecipe(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if recipe.ID == 0 {
+		return nil, nil
+	}
+
+	return recipe, nil
+}
+
+func (s *Store) GetAllRecipes() ([]types.ProductionRecipeListsReturnPayload, error) {
+	query := `SELECT pr.id,
+					med.name,
+					pr.yield_qty, unit.name,
+					pr.description,
+					(SELECT COUNT(*) FROM production_recipe_item AS pri WHERE pri.production_recipe_id = pr.id),
+					pr.last_modified
+					FROM production_recipe AS pr
+					JOIN medicine AS med ON med.id = pr.produced_medicine_id
+					JOIN unit ON unit.id = pr.yield_unit_id
+					WHERE pr.deleted_at IS NULL
+					ORDER BY med.name ASC`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := make([]types.ProductionRecipeListsReturnPayload, 0)
+
+	for rows.Next() {
+		var recipe types.ProductionRecipeListsReturnPayload
+
+		err = rows.Scan(
+			&recipe.ID,
+			&recipe.ProducedMedicineName,
+			&recipe.YieldQty,
+			&recipe.YieldUnit,
+			&recipe.Description,
+			&recipe.NumberOfItems,
+			&recipe.LastModified,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		recipe.LastModified = recipe.LastModified.Local()
+
+		recipes = append(recipes, recipe)
+	}
+
+	return recipes, nil
+}
+
+func (s *Store) GetRecipeItems(recipeId int) ([]types.ProductionRecipeItemRow, error) {
+	query := `SELECT pri.id,
+					medicine.barcode, medicine.name,
+					pri.qty,
+					unit.name
+					FROM production_recipe_item AS pri
+					JOIN medicine ON medicine.id = pri.medicine_id
+					JOIN unit ON unit.id = pri.unit_id
+					WHERE pri.production_recipe_id = ?
+					ORDER BY pri.id ASC`
+
+	rows, err := s.db.Query(query, recipeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]types.ProductionRecipeItemRow, 0)
+
+	for rows.Next() {
+		var item types.ProductionRecipeItemRow
+
+		err = rows.Scan(
+			&item.ID,
+			&item.MedicineBarcode,
+			&item.MedicineName,
+			&item.Qty,
+			&item.Unit,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *Store) CreateRecipe(recipe types.ProductionRecipe) error {
+	values := "?"
+	for i := 0; i < 5; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO production_recipe (
+		produced_medicine_id, yield_qty, yield_unit_id, description,
+		user_id, last_modified_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		recipe.ProducedMedicineID, recipe.YieldQty, recipe.YieldUnitID, recipe.Description,
+		recipe.UserID, recipe.LastModifiedByUserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateRecipeItem(item types.ProductionRecipeItem) error {
+	values := "?"
+	for i := 0; i < 3; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO production_recipe_item (
+		production_recipe_id, medicine_id, qty, unit_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query, item.ProductionRecipeID, item.MedicineID, item.Qty, item.UnitID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) ModifyRecipe(id int, recipe types.ProductionRecipe, user *types.User) error {
+	data, err := s.GetRecipeByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("modify", "production-recipe", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE production_recipe SET
+		produced_medicine_id = ?, yield_qty = ?, yield_unit_id = ?, description = ?,
+		last_modified = ?, last_modified_by_user_id = ?
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		recipe.ProducedMedicineID, recipe.YieldQty, recipe.YieldUnitID, recipe.Description,
+		time.Now(), user.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteRecipe(recipe *types.ProductionRecipe, user *types.User) error {
+	data, err := s.GetRecipeByID(recipe.ID)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("delete", "production-recipe", user.Name, data.ID, data)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := "UPDATE production_recipe SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
+	_, err = s.db.Exec(query, time.Now(), user.ID, recipe.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteRecipeItems(recipe *types.ProductionRecipe, user *types.User) error {
+	data, err := s.GetRecipeItems(recipe.ID)
+	if err != nil {
+		return err
+	}
+
+	writeData := map[string]interface{}{
+		"production_recipe": recipe,
+		"deleted_items":     data,
+	}
+
+	err = logger.WriteLog("delete", "production-recipe", user.Name, recipe.ID, writeData)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	_, err = s.db.Exec("DELETE FROM production_recipe_item WHERE production_recipe_id = ?", recipe.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanRowIntoRecipe(rows *sql.Rows) (*types.ProductionRecipe, error) {
+	recipe := new(types.ProductionRecipe)
+
+	err := rows.Scan(
+		&recipe.ID,
+		&recipe.ProducedMedicineID,
+		&recipe.YieldQty,
+		&recipe.YieldUnitID,
+		&recipe.Description,
+		&recipe.UserID,
+		&recipe.CreatedAt,
+		&recipe.LastModified,
+		&recipe.LastModifiedByUserID,
+		&recipe.DeletedAt,
+		&recipe.DeletedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recipe.CreatedAt = recipe.CreatedAt.Local()
+	recipe.LastModified = recipe.LastModified.Local()
+
+	return recipe, nil
+}
@@ -1,6 +1,7 @@
 package production
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 
+	"github.com/nicolaics/pharmacon/constants"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 )
@@ -17,17 +19,26 @@ type Handler struct {
 	userStore       types.UserStore
 	medStore        types.MedicineStore
 	unitStore       types.UnitStore
+	registerStore   types.ControlledSubstanceStore
+	recipeStore     types.ProductionRecipeStore
+	mdmiStore       types.MainDoctorMedItemStore
 }
 
 func NewHandler(productionStore types.ProductionStore,
 	userStore types.UserStore,
 	medStore types.MedicineStore,
-	unitStore types.UnitStore) *Handler {
+	unitStore types.UnitStore,
+	registerStore types.ControlledSubstanceStore,
+	recipeStore types.ProductionRecipeStore,
+	mdmiStore types.MainDoctorMedItemStore) *Handler {
 	return &Handler{
 		productionStore: productionStore,
 		userStore:       userStore,
 		medStore:        medStore,
 		unitStore:       unitStore,
+		registerStore:   registerStore,
+		recipeStore:     recipeStore,
+		mdmiStore:       mdmiStore,
 	}
 }
 
@@ -97,13 +108,34 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check duplicate
-	production, err := h.productionStore.GetProductionByNumber(payload.Number)
+	production, err := h.productionStore.GetProductionByNumber(payload.Number, user.BranchID)
 	if err == nil || production != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("production number %d exists", payload.Number))
 		return
 	}
 
-	err = h.productionStore.CreateProduction(types.Production{
+	plannedQty, err := getPlannedQty(payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ingredients, recipeId, totalCost, err := getProductionIngredients(h, payload.MedicineLists, producedMedicine, producedUnit, plannedQty)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// the ingredients are used when the produced medicine is added to the stock
+	if payload.UpdatedToStock {
+		err = checkIngredientStock(h, ingredients, user.BranchID, nil)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	newProduction := types.Production{
 		Number:               payload.Number,
 		ProducedMedicineID:   producedMedicine.ID,
 		ProducedQty:          payload.ProducedQty,
@@ -112,56 +144,27 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		Description:          payload.Description,
 		UpdatedToStock:       payload.UpdatedToStock,
 		UpdatedToAccount:     payload.UpdatedToAccount,
-		TotalCost:            payload.TotalCost,
+		TotalCost:            totalCost,
 		UserID:               user.ID,
 		LastModifiedByUserID: user.ID,
-	})
+		ProductionRecipeID:   recipeId,
+		PlannedQty:           plannedQty,
+		LossQty:              (plannedQty - float64(payload.ProducedQty)),
+		BranchID:             user.BranchID,
+	}
+
+	err = h.productionStore.CreateProduction(newProduction)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	// add to stock
-	if payload.UpdatedToStock {
-		err = utils.AddStock(h.medStore, producedMedicine, producedUnit, float64(payload.ProducedQty), user)
-		if err != nil {
-			err = h.productionStore.AbsoluteDeleteProduction(types.Production{
-				Number:             payload.Number,
-				ProducedMedicineID: producedMedicine.ID,
-				ProducedQty:        payload.ProducedQty,
-				ProducedUnitID:     producedUnit.ID,
-				ProductionDate:     *prodDate,
-				Description:        payload.Description,
-				UpdatedToStock:     payload.UpdatedToStock,
-				UpdatedToAccount:   payload.UpdatedToAccount,
-				TotalCost:          payload.TotalCost,
-			})
-			if err != nil {
-				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", err))
-				return
-			}
-
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
-			return
-		}
-	}
-
 	// get production ID
-	production, err = h.productionStore.GetProductionByNumber(payload.Number)
-	if err != nil {
-		err = h.productionStore.AbsoluteDeleteProduction(types.Production{
-			Number:             payload.Number,
-			ProducedMedicineID: producedMedicine.ID,
-			ProducedQty:        payload.ProducedQty,
-			ProducedUnitID:     producedUnit.ID,
-			ProductionDate:     *prodDate,
-			Description:        payload.Description,
-			UpdatedToStock:     payload.UpdatedToStock,
-			UpdatedToAccount:   payload.UpdatedToAccount,
-			TotalCost:          payload.TotalCost,
-		})
-		if err != nil {
-			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", err))
+	production, err = h.productionStore.GetProductionByNumber(payload.Number, user.BranchID)
+	if err != nil {
+		errDel := h.productionStore.AbsoluteDeleteProduction(newProduction)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", errDel))
 			return
 		}
 
@@ -169,22 +172,12 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, medicine := range payload.MedicineLists {
+	for _, medicine := range ingredients {
 		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
 		if err != nil {
-			err = h.productionStore.AbsoluteDeleteProduction(types.Production{
-				Number:             payload.Number,
-				ProducedMedicineID: producedMedicine.ID,
-				ProducedQty:        payload.ProducedQty,
-				ProducedUnitID:     producedUnit.ID,
-				ProductionDate:     *prodDate,
-				Description:        payload.Description,
-				UpdatedToStock:     payload.UpdatedToStock,
-				UpdatedToAccount:   payload.UpdatedToAccount,
-				TotalCost:          payload.TotalCost,
-			})
-			if err != nil {
-				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", err))
+			errDel := h.productionStore.AbsoluteDeleteProduction(newProduction)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", errDel))
 				return
 			}
 
@@ -193,33 +186,14 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		}
 
 		unit, err := h.unitStore.GetUnitByName(medicine.Unit)
-		if unit == nil {
-			err = h.unitStore.CreateUnit(medicine.Unit)
-			if err != nil {
-				err = h.productionStore.AbsoluteDeleteProduction(types.Production{
-					Number:             payload.Number,
-					ProducedMedicineID: producedMedicine.ID,
-					ProducedQty:        payload.ProducedQty,
-					ProducedUnitID:     producedUnit.ID,
-					ProductionDate:     *prodDate,
-					Description:        payload.Description,
-					UpdatedToStock:     payload.UpdatedToStock,
-					UpdatedToAccount:   payload.UpdatedToAccount,
-					TotalCost:          payload.TotalCost,
-				})
-				if err != nil {
-					utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", err))
-					return
-				}
-
-				utils.WriteError(w, http.StatusInternalServerError, err)
+		if err != nil || unit == nil {
+			errDel := h.productionStore.AbsoluteDeleteProduction(newProduction)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", errDel))
 				return
 			}
 
-			unit, err = h.unitStore.GetUnitByName(medicine.Unit)
-		}
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unit %s not found", medicine.Unit))
 			return
 		}
 
@@ -231,19 +205,9 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 			Cost:         medicine.Cost,
 		})
 		if err != nil {
-			err = h.productionStore.AbsoluteDeleteProduction(types.Production{
-				Number:             payload.Number,
-				ProducedMedicineID: producedMedicine.ID,
-				ProducedQty:        payload.ProducedQty,
-				ProducedUnitID:     producedUnit.ID,
-				ProductionDate:     *prodDate,
-				Description:        payload.Description,
-				UpdatedToStock:     payload.UpdatedToStock,
-				UpdatedToAccount:   payload.UpdatedToAccount,
-				TotalCost:          payload.TotalCost,
-			})
-			if err != nil {
-				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", err))
+			errDel := h.productionStore.AbsoluteDeleteProduction(newProduction)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", errDel))
 				return
 			}
 
@@ -253,19 +217,54 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if payload.UpdatedToStock {
+		// add to stock
+		err = utils.AddStock(h.medStore, producedMedicine, producedUnit, float64(payload.ProducedQty), user.BranchID, user)
+		if err != nil {
+			errDel := h.productionStore.AbsoluteDeleteProduction(newProduction)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete production: %v", errDel))
+				return
+			}
+
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+			return
+		}
+
+		err = utils.RecordControlledSubstanceIn(h.registerStore, producedMedicine, producedUnit, float64(payload.ProducedQty), types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_PRODUCTION,
+			ReferenceID:     production.ID,
+			ReferenceNumber: payload.Number,
+			TransactionDate: *prodDate,
+			UserID:          user.ID,
+			BranchID:        user.BranchID,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+			return
+		}
+
+		// reduce the stock of the ingredients
+		err = subtractIngredientStock(h, ingredients, production, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating ingredient stock: %v", err))
+			return
+		}
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("production number %d successfully created by %s", payload.Number, user.Name))
 }
 
 // beginning of production page, will request here
 func (h *Handler) handleGetNumberOfProductions(w http.ResponseWriter, r *http.Request) {
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
-	numberOfProductions, err := h.productionStore.GetNumberOfProductions()
+	numberOfProductions, err := h.productionStore.GetNumberOfProductions(user.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -292,12 +291,18 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	vars := mux.Vars(r)
 	params := vars["params"]
 	val := vars["val"]
@@ -317,7 +322,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 	var prods []types.ProductionListsReturnPayload
 
 	if val == "all" {
-		prods, err = h.productionStore.GetProductionsByDate(*startDate, *endDate)
+		prods, err = h.productionStore.GetProductionsByDate(*startDate, *endDate, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -330,7 +335,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 		}
 
 		prod, err := h.productionStore.GetProductionByID(id)
-		if err != nil {
+		if err != nil || (branchId != constants.BRANCH_ALL && prod.BranchID != branchId) {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("prod id %d not exist", id))
 			return
 		}
@@ -366,7 +371,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		prods, err = h.productionStore.GetProductionsByDateAndNumber(*startDate, *endDate, batchNumber)
+		prods, err = h.productionStore.GetProductionsByDateAndNumber(*startDate, *endDate, batchNumber, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -379,7 +384,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for _, user := range users {
-			temp, err := h.productionStore.GetProductionsByDateAndUserID(*startDate, *endDate, user.ID)
+			temp, err := h.productionStore.GetProductionsByDateAndUserID(*startDate, *endDate, user.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %s doesn't create any prod between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -395,7 +400,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for _, medicine := range medicines {
-			temp, err := h.productionStore.GetProductionsByDateAndMedicineID(*startDate, *endDate, medicine.ID)
+			temp, err := h.productionStore.GetProductionsByDateAndMedicineID(*startDate, *endDate, medicine.ID, branchId)
 			if err != nil {
 				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't have any production between %s and %s", val, payload.StartDate, payload.EndDate))
 				return
@@ -412,7 +417,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 			uts = false
 		}
 
-		prods, err = h.productionStore.GetProductionsByDateAndUpdatedToStock(*startDate, *endDate, uts)
+		prods, err = h.productionStore.GetProductionsByDateAndUpdatedToStock(*startDate, *endDate, uts, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -426,7 +431,7 @@ func (h *Handler) handleGetProductions(w http.ResponseWriter, r *http.Request) {
 			uta = false
 		}
 
-		prods, err = h.productionStore.GetProductionsByDateAndUpdatedToAccount(*startDate, *endDate, uta)
+		prods, err = h.productionStore.GetProductionsByDateAndUpdatedToAccount(*startDate, *endDate, uta, branchId)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
 			return
@@ -457,14 +462,14 @@ func (h *Handler) handleGetProductionDetail(w http.ResponseWriter, r *http.Reque
 	}
 
 	// validate token
-	_, err := h.userStore.ValidateUserToken(w, r, false)
+	user, err := h.userStore.ValidateUserToken(w, r, false)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
 		return
 	}
 
 	// get production data
-	production, err := h.productionStore.GetProductionByNumber(payload.Number)
+	production, err := h.productionStore.GetProductionByNumber(payload.Number, user.BranchID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("production number %d doesn't exists", payload.Number))
 		return
@@ -517,6 +522,11 @@ func (h *Handler) handleGetProductionDetail(w http.ResponseWriter, r *http.Reque
 		UpdatedToAccount: production.UpdatedToAccount,
 		TotalCost:        production.TotalCost,
 
+		RecipeID:        int(production.ProductionRecipeID.Int64),
+		PlannedQty:      production.PlannedQty,
+		LossQty:         production.LossQty,
+		YieldPercentage: utils.GetProductionYieldPercentage(float64(production.ProducedQty), production.PlannedQty),
+
 		User: struct {
 			ID   int    "json:\"id\""
 			Name string "json:\"name\""
@@ -566,6 +576,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, production.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// get produced medicine
 	tempProducedMedicine, err := h.medStore.GetMedicineByID(production.ProducedMedicineID)
 	if err != nil {
@@ -586,6 +602,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ingredients, err := getProductionMedicineLists(h, production.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	err = h.productionStore.DeleteProductionMedicineItem(production, user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -598,13 +620,25 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_PRODUCTION, production.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
+	}
+
 	// reset the previous stock
 	if production.UpdatedToStock {
-		err = utils.SubtractStock(h.medStore, producedMedicine, producedUnit, float64(production.ProducedQty), user)
+		err = utils.SubtractStock(h.medStore, producedMedicine, producedUnit, float64(production.ProducedQty), production.BranchID, user)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error subtracting stock: %v", err))
 			return
 		}
+
+		err = addIngredientStock(h, ingredients, production.BranchID, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error returning ingredient stock: %v", err))
+			return
+		}
 	}
 
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("production number %d deleted by %s", production.Number, user.Name))
@@ -641,6 +675,12 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(user, oldProduction.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	// get old produced medicine
 	tempOldProducedMedicine, err := h.medStore.GetMedicineByID(oldProduction.ProducedMedicineID)
 	if err != nil {
@@ -662,24 +702,33 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check duplicate Number
-	prod, err := h.productionStore.GetProductionByNumber(payload.NewData.Number)
-	if err == nil || prod != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("number %d exist already", payload.NewData.Number))
+	if payload.NewData.Number != oldProduction.Number {
+		prod, err := h.productionStore.GetProductionByNumber(payload.NewData.Number, oldProduction.BranchID)
+		if err == nil || prod != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("number %d exist already", payload.NewData.Number))
+			return
+		}
+	}
+
+	plannedQty, err := getPlannedQty(payload.NewData)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// reset the previous stock
-	if oldProduction.UpdatedToStock {
-		err = utils.SubtractStock(h.medStore, oldProducedMedicine, oldProducedUnit, float64(oldProduction.ProducedQty), user)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error subtracting stock: %v", err))
-			return
-		}
+	oldIngredients, err := getProductionMedicineLists(h, oldProduction.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
 
-	newProducedMedicine, err := h.medStore.GetMedicineByBarcode(payload.NewData.ProducedMedicineBarcode)
+	// the new data is checked before the previous stock is reset, so a refused
+	// modification doesn't leave the stock reset while the production is still in stock
+
+	// get produced medicine data
+	producedMedicine, err := h.medStore.GetMedicineByBarcode(payload.NewData.ProducedMedicineBarcode)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", payload.NewData.ProducedMedicineName))
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("med %s not found, create the meds first", payload.NewData.ProducedMedicineName))
 		return
 	}
 
@@ -699,19 +748,51 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// get produced medicine data
-	producedMedicine, err := h.medStore.GetMedicineByBarcode(payload.NewData.ProducedMedicineBarcode)
+	prodDate, err := utils.ParseDate(payload.NewData.ProductionDate)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("med %s not found, create the meds first", payload.NewData.ProducedMedicineName))
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
 		return
 	}
 
-	prodDate, err := utils.ParseDate(payload.NewData.ProductionDate)
+	ingredients, recipeId, totalCost, err := getProductionIngredients(h, payload.NewData.MedicineLists, producedMedicine, newProducedUnit, plannedQty)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
+	if payload.NewData.UpdatedToStock {
+		// the stock freed by the reset is counted too
+		reversedQtys := make(map[int]float64)
+		if oldProduction.UpdatedToStock {
+			reversedQtys, err = getProductionReversedQtys(h, oldIngredients, oldProducedMedicine, oldProducedUnit, float64(oldProduction.ProducedQty))
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		err = checkIngredientStock(h, ingredients, oldProduction.BranchID, reversedQtys)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// reset the previous stock
+	if oldProduction.UpdatedToStock {
+		err = utils.SubtractStock(h.medStore, oldProducedMedicine, oldProducedUnit, float64(oldProduction.ProducedQty), oldProduction.BranchID, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error subtracting stock: %v", err))
+			return
+		}
+
+		err = addIngredientStock(h, oldIngredients, oldProduction.BranchID, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error returning ingredient stock: %v", err))
+			return
+		}
+	}
+
 	err = h.productionStore.ModifyProduction(payload.ID, types.Production{
 		Number:               payload.NewData.Number,
 		ProducedMedicineID:   producedMedicine.ID,
@@ -721,37 +802,37 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		Description:          payload.NewData.Description,
 		UpdatedToStock:       payload.NewData.UpdatedToStock,
 		UpdatedToAccount:     payload.NewData.UpdatedToAccount,
-		TotalCost:            payload.NewData.TotalCost,
+		TotalCost:            totalCost,
 		LastModifiedByUserID: user.ID,
+		ProductionRecipeID:   recipeId,
+		PlannedQty:           plannedQty,
+		LossQty:              (plannedQty - float64(payload.NewData.ProducedQty)),
 	}, user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	// add to stock
-	if payload.NewData.UpdatedToStock {
-		err = utils.AddStock(h.medStore, newProducedMedicine, newProducedUnit, float64(payload.NewData.ProducedQty), user)
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
-			return
-		}
-	}
-
 	// get production
-	production, err := h.productionStore.GetProductionByNumber(payload.NewData.Number)
+	production, err := h.productionStore.GetProductionByID(payload.ID)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("production number %d doesn't exists", payload.NewData.Number))
 		return
 	}
 
+	err = h.registerStore.DeleteRegisterEntriesByReference(constants.CONTROLLED_TRANSACTION_PRODUCTION, oldProduction.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error deleting controlled substance register: %v", err))
+		return
+	}
+
 	err = h.productionStore.DeleteProductionMedicineItem(oldProduction, user)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	for _, medicine := range payload.NewData.MedicineLists {
+	for _, medicine := range ingredients {
 		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
 		if err != nil {
 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", medicine.MedicineName))
@@ -759,17 +840,8 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		}
 
 		unit, err := h.unitStore.GetUnitByName(medicine.Unit)
-		if unit == nil {
-			err = h.unitStore.CreateUnit(medicine.Unit)
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			unit, err = h.unitStore.GetUnitByName(medicine.Unit)
-		}
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
+		if err != nil || unit == nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unit %s not found", medicine.Unit))
 			return
 		}
 
@@ -787,5 +859,249 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if payload.NewData.UpdatedToStock {
+		// the produced medicine may be the same as the old one, so get the updated stock
+		producedMedicine, err = h.medStore.GetMedicineByBarcode(payload.NewData.ProducedMedicineBarcode)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", payload.NewData.ProducedMedicineName))
+			return
+		}
+
+		// add to stock
+		err = utils.AddStock(h.medStore, producedMedicine, newProducedUnit, float64(payload.NewData.ProducedQty), production.BranchID, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+			return
+		}
+
+		err = utils.RecordControlledSubstanceIn(h.registerStore, producedMedicine, newProducedUnit, float64(payload.NewData.ProducedQty), types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_PRODUCTION,
+			ReferenceID:     production.ID,
+			ReferenceNumber: payload.NewData.Number,
+			TransactionDate: *prodDate,
+			UserID:          user.ID,
+			BranchID:        production.BranchID,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+			return
+		}
+
+		// reduce the stock of the ingredients
+		err = subtractIngredientStock(h, ingredients, production, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating ingredient stock: %v", err))
+			return
+		}
+	}
+
 	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("production modified by %s", user.Name))
 }
+
+// the produced qty can't be more than what the ingredients are for
+func getPlannedQty(payload types.RegisterProductionPayload) (float64, error) {
+	if payload.PlannedQty <= 0 {
+		return float64(payload.ProducedQty), nil
+	}
+
+	if float64(payload.ProducedQty) > payload.PlannedQty {
+		return 0, fmt.Errorf("produced qty %d is more than the planned qty %g", payload.ProducedQty, payload.PlannedQty)
+	}
+
+	return payload.PlannedQty, nil
+}
+
+// the ingredients are from the payload, or scaled from the recipe if the payload has none.
+// the cost is always computed here from the latest cost of each ingredient
+func getProductionIngredients(h *Handler, medicineLists []types.ProductionMedicineListPayload, producedMedicine *types.Medicine, producedUnit *types.Unit, plannedQty float64) ([]types.ProductionMedicineListPayload, sql.NullInt64, float64, error) {
+	var recipeId sql.NullInt64
+
+	ingredients := medicineLists
+
+	if len(ingredients) == 0 {
+		recipe, err := h.recipeStore.GetRecipeByProducedMedicineID(producedMedicine.ID)
+		if err != nil {
+			return nil, recipeId, 0, err
+		}
+
+		if recipe == nil {
+			return nil, recipeId, 0, fmt.Errorf("%s has no recipe, fill in the ingredients", producedMedicine.Name)
+		}
+
+		items, err := h.recipeStore.GetRecipeItems(recipe.ID)
+		if err != nil {
+			return nil, recipeId, 0, err
+		}
+
+		ingredients, err = utils.ScaleProductionRecipe(h.unitStore, producedMedicine, recipe, items, producedUnit, plannedQty)
+		if err != nil {
+			return nil, recipeId, 0, err
+		}
+
+		recipeId = sql.NullInt64{Int64: int64(recipe.ID), Valid: true}
+	}
+
+	totalCost := 0.0
+
+	for idx, ingredient := range ingredients {
+		medData, err := h.medStore.GetMedicineByBarcode(ingredient.MedicineBarcode)
+		if err != nil {
+			return nil, recipeId, 0, fmt.Errorf("medicine %s doesn't exists", ingredient.MedicineName)
+		}
+
+		unit, err := h.unitStore.GetUnitByName(ingredient.Unit)
+		if err != nil || unit == nil {
+			return nil, recipeId, 0, fmt.Errorf("unit %s not found", ingredient.Unit)
+		}
+
+		if medData.ID == producedMedicine.ID {
+			return nil, recipeId, 0, fmt.Errorf("%s can't be an ingredient of itself", medData.Name)
+		}
+
+		cost, err := utils.GetMedicineCost(h.productionStore, h.unitStore, medData, unit, ingredient.Qty)
+		if err != nil {
+			return nil, recipeId, 0, fmt.Errorf("error getting cost of %s: %v", ingredient.MedicineName, err)
+		}
+
+		ingredients[idx].Cost = cost
+		totalCost += cost
+	}
+
+	return ingredients, recipeId, totalCost, nil
+}
+
+func getProductionMedicineLists(h *Handler, productionId int) ([]types.ProductionMedicineListPayload, error) {
+	items, err := h.productionStore.GetProductionMedicineItem(productionId)
+	if err != nil {
+		return nil, err
+	}
+
+	medicineLists := make([]types.ProductionMedicineListPayload, 0)
+
+	for _, item := range items {
+		medicineLists = append(medicineLists, types.ProductionMedicineListPayload{
+			MedicineBarcode: item.MedicineBarcode,
+			MedicineName:    item.MedicineName,
+			Qty:             item.Qty,
+			Unit:            item.Unit,
+			Cost:            item.Cost,
+		})
+	}
+
+	return medicineLists, nil
+}
+
+// reversedQtys is the stock in the first unit that is put back before the ingredients are used
+func checkIngredientStock(h *Handler, ingredients []types.ProductionMedicineListPayload, branchId int, reversedQtys map[int]float64) error {
+	stockItems, err := getIngredientStockItems(h, ingredients)
+	if err != nil {
+		return err
+	}
+
+	return utils.CheckStockItems(h.medStore, stockItems, branchId, reversedQtys)
+}
+
+// the stock change in the first unit when the production is taken out of the stock,
+// the ingredients are put back and the produced medicine is taken
+func getProductionReversedQtys(h *Handler, ingredients []types.ProductionMedicineListPayload, producedMedicine *types.Medicine, producedUnit *types.Unit, producedQty float64) (map[int]float64, error) {
+	stockItems, err := getIngredientStockItems(h, ingredients)
+	if err != nil {
+		return nil, err
+	}
+
+	reversedQtys, err := utils.GetFirstUnitQtys(stockItems)
+	if err != nil {
+		return nil, err
+	}
+
+	qty, err := utils.ConvertToFirstUnit(producedMedicine, producedUnit, producedQty)
+	if err != nil {
+		return nil, err
+	}
+
+	reversedQtys[producedMedicine.ID] -= qty
+
+	return reversedQtys, nil
+}
+
+func subtractIngredientStock(h *Handler, ingredients []types.ProductionMedicineListPayload, production *types.Production, user *types.User) error {
+	stockItems, err := getIngredientStockItems(h, ingredients)
+	if err != nil {
+		return err
+	}
+
+	for _, stockItem := range stockItems {
+		// the stock may be changed by the previous item
+		medData, err := h.medStore.GetMedicineByBarcode(stockItem.Medicine.Barcode)
+		if err != nil {
+			return fmt.Errorf("medicine %s doesn't exists", stockItem.Medicine.Name)
+		}
+
+		err = utils.SubtractStock(h.medStore, medData, stockItem.Unit, stockItem.Qty, production.BranchID, user)
+		if err != nil {
+			return err
+		}
+
+		err = utils.RecordControlledSubstanceOut(h.registerStore, medData, stockItem.Unit, stockItem.Qty, types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_PRODUCTION,
+			ReferenceID:     production.ID,
+			ReferenceNumber: production.Number,
+			TransactionDate: production.ProductionDate,
+			UserID:          user.ID,
+			BranchID:        production.BranchID,
+		})
+		if err != nil {
+			return fmt.Errorf("error recording controlled substance: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func addIngredientStock(h *Handler, ingredients []types.ProductionMedicineListPayload, branchId int, user *types.User) error {
+	stockItems, err := getIngredientStockItems(h, ingredients)
+	if err != nil {
+		return err
+	}
+
+	for _, stockItem := range stockItems {
+		// the stock may be changed by the previous item
+		medData, err := h.medStore.GetMedicineByBarcode(stockItem.Medicine.Barcode)
+		if err != nil {
+			return fmt.Errorf("medicine %s doesn't exists", stockItem.Medicine.Name)
+		}
+
+		err = utils.AddStock(h.medStore, medData, stockItem.Unit, stockItem.Qty, branchId, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// the compounded ingredient uses the stock of its own ingredients
+func getIngredientStockItems(h *Handler, ingredients []types.ProductionMedicineListPayload) ([]types.MedicineStockItem, error) {
+	stockItems := make([]types.MedicineStockItem, 0)
+
+	for _, ingredient := range ingredients {
+		medData, err := h.medStore.GetMedicineByBarcode(ingredient.MedicineBarcode)
+		if err != nil {
+			return nil, fmt.Errorf("medicine %s doesn't exists", ingredient.MedicineName)
+		}
+
+		unit, err := h.unitStore.GetUnitByName(ingredient.Unit)
+		if err != nil || unit == nil {
+			return nil, fmt.Errorf("unit %s not found", ingredient.Unit)
+		}
+
+		items, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medData, unit, ingredient.Qty)
+		if err != nil {
+			return nil, fmt.Errorf("error expanding recipe of %s: %v", ingredient.MedicineName, err)
+		}
+
+		stockItems = append(stockItems, items...)
+	}
+
+	return stockItems, nil
+}
@@ -18,9 +18,9 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) GetProductionByNumber(number int) (*types.Production, error) {
-	query := "SELECT * FROM production WHERE number = ? AND deleted_at IS NULL ORDER BY production_date DESC"
-	rows, err := s.db.Query(query, number)
+func (s *Store) GetProductionByNumber(number int, branchId int) (*types.Production, error) {
+	query := "SELECT * FROM production WHERE number = ? AND branch_id = ? AND deleted_at IS NULL ORDER BY production_date DESC"
+	rows, err := s.db.Query(query, number, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +68,7 @@ func (s *Store) GetProductionByID(id int) (*types.Production, error) {
 	return production, nil
 }
 
-func (s *Store) GetProductionsByDate(startDate time.Time, endDate time.Time) ([]types.ProductionListsReturnPayload, error) {
+func (s *Store) GetProductionsByDate(startDate time.Time, endDate time.Time, branchId int) ([]types.ProductionListsReturnPayload, error) {
 	query := `SELECT prod.id, prod.number, 
 					med.name AS produced_medicine_name, 
 					prod.produced_qty, 
@@ -83,9 +83,10 @@ func (s *Store) GetProductionsByDate(startDate time.Time, endDate time.Time) ([]
 					JOIN unit ON unit.id = prod.produced_unit_id 
 					WHERE prod.production_date >= ? AND prod.production_date < ? 
 					AND prod.deleted_at IS NULL 
+					AND (? = 0 OR prod.branch_id = ?) 
 					ORDER BY prod.production_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate)
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +107,7 @@ func (s *Store) GetProductionsByDate(startDate time.Time, endDate time.Time) ([]
 	return productions, nil
 }
 
-func (s *Store) GetProductionsByDateAndNumber(startDate time.Time, endDate time.Time, bn int) ([]types.ProductionListsReturnPayload, error) {
+func (s *Store) GetProductionsByDateAndNumber(startDate time.Time, endDate time.Time, bn int, branchId int) ([]types.ProductionListsReturnPayload, error) {
 	query := `SELECT prod.id, prod.number, 
 					med.name AS produced_medicine_name, 
 					prod.produced_qty, 
@@ -122,6 +123,7 @@ func (s *Store) GetProductionsByDateAndNumber(startDate time.Time, endDate time.
 					WHERE prod.production_date >= ? AND prod.production_date < ? 
 					AND prod.deleted_at IS NULL 
 					AND prod.number LIKE ? 
+					AND (? = 0 OR prod.branch_id = ?) 
 					ORDER BY prod.production_date DESC`
 
 	searchVal := "%"
@@ -131,7 +133,7 @@ func (s *Store) GetProductionsByDateAndNumber(startDate time.Time, endDate time.
 		}
 	}
 
-	rows, err := s.db.Query(query, startDate, endDate, searchVal)
+	rows, err := s.db.Query(query, startDate, endDate, searchVal, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -152,7 +154,7 @@ func (s *Store) GetProductionsByDateAndNumber(startDate time.Time, endDate time.
 	return productions, nil
 }
 
-func (s *Store) GetProductionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]types.ProductionListsReturnPayload, error) {
+func (s *Store) GetProductionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]types.ProductionListsReturnPayload, error) {
 	query := `SELECT prod.id, prod.number, 
 					med.name AS produced_medicine_name, 
 					prod.produced_qty, 
@@ -168,9 +170,10 @@ func (s *Store) GetProductionsByDateAndUserID(startDate time.Time, endDate time.
 					WHERE prod.production_date >= ? AND prod.production_date < ? 
 					AND prod.deleted_at IS NULL 
 					AND prod.user_id = ? 
+					AND (? = 0 OR prod.branch_id = ?) 
 					ORDER BY prod.production_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uid)
+	rows, err := s.db.Query(query, startDate, endDate, uid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +194,7 @@ func (s *Store) GetProductionsByDateAndUserID(startDate time.Time, endDate time.
 	return productions, nil
 }
 
-func (s *Store) GetProductionsByDateAndMedicineID(startDate time.Time, endDate time.Time, mid int) ([]types.ProductionListsReturnPayload, error) {
+func (s *Store) GetProductionsByDateAndMedicineID(startDate time.Time, endDate time.Time, mid int, branchId int) ([]types.ProductionListsReturnPayload, error) {
 	query := `SELECT prod.id, prod.number, 
 					med.name AS produced_medicine_name, 
 					prod.produced_qty, 
@@ -207,9 +210,10 @@ func (s *Store) GetProductionsByDateAndMedicineID(startDate time.Time, endDate t
 					WHERE prod.production_date >= ? AND prod.production_date < ? 
 					AND prod.deleted_at IS NULL 
 					AND prod.produced_medicine_id = ? 
+					AND (? = 0 OR prod.branch_id = ?) 
 					ORDER BY prod.production_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, mid)
+	rows, err := s.db.Query(query, startDate, endDate, mid, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -230,7 +234,7 @@ func (s *Store) GetProductionsByDateAndMedicineID(startDate time.Time, endDate t
 	return productions, nil
 }
 
-func (s *Store) GetProductionsByDateAndUpdatedToStock(startDate time.Time, endDate time.Time, uts bool) ([]types.ProductionListsReturnPayload, error) {
+func (s *Store) GetProductionsByDateAndUpdatedToStock(startDate time.Time, endDate time.Time, uts bool, branchId int) ([]types.ProductionListsReturnPayload, error) {
 	query := `SELECT prod.id, prod.number, 
 					med.name AS produced_medicine_name, 
 					prod.produced_qty, 
@@ -246,9 +250,10 @@ func (s *Store) GetProductionsByDateAndUpdatedToStock(startDate time.Time, endDa
 					WHERE prod.production_date >= ? AND prod.production_date < ? 
 					AND prod.deleted_at IS NULL 
 					AND prod.updated_to_stock = ? 
+					AND (? = 0 OR prod.branch_id = ?) 
 					ORDER BY prod.production_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uts)
+	rows, err := s.db.Query(query, startDate, endDate, uts, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +274,7 @@ func (s *Store) GetProductionsByDateAndUpdatedToStock(startDate time.Time, endDa
 	return productions, nil
 }
 
-func (s *Store) GetProductionsByDateAndUpdatedToAccount(startDate time.Time, endDate time.Time, uta bool) ([]types.ProductionListsReturnPayload, error) {
+func (s *Store) GetProductionsByDateAndUpdatedToAccount(startDate time.Time, endDate time.Time, uta bool, branchId int) ([]types.ProductionListsReturnPayload, error) {
 	query := `SELECT prod.id, prod.number, 
 					med.name AS produced_medicine_name, 
 					prod.produced_qty, 
@@ -285,9 +290,10 @@ func (s *Store) GetProductionsByDateAndUpdatedToAccount(startDate time.Time, end
 					WHERE prod.production_date >= ? AND prod.production_date < ? 
 					AND prod.deleted_at IS NULL 
 					AND prod.updated_to_account = ? 
+					AND (? = 0 OR prod.branch_id = ?) 
 					ORDER BY prod.production_date DESC`
 
-	rows, err := s.db.Query(query, startDate, endDate, uta)
+	rows, err := s.db.Query(query, startDate, endDate, uta, branchId, branchId)
 	if err != nil {
 		return nil, err
 	}
@@ -308,11 +314,12 @@ func (s *Store) GetProductionsByDateAndUpdatedToAccount(startDate time.Time, end
 	return productions, nil
 }
 
-func (s *Store) GetNumberOfProductions() (int, error) {
+func (s *Store) GetNumberOfProductions(branchId int) (int, error) {
 	query := `SELECT COUNT(*) FROM production 
-				WHERE deleted_at IS NULL`
+				WHERE deleted_at IS NULL 
+				AND branch_id = ?`
 
-	row := s.db.QueryRow(query)
+	row := s.db.QueryRow(query, branchId)
 	if row.Err() != nil {
 		return -1, row.Err()
 	}
@@ -329,19 +336,21 @@ func (s *Store) GetNumberOfProductions() (int, error) {
 
 func (s *Store) CreateProduction(production types.Production) error {
 	values := "?"
-	for i := 0; i < 10; i++ {
+	for i := 0; i < 14; i++ {
 		values += ", ?"
 	}
 
 	query := `INSERT INTO production (
 		number, produced_medicine_id, produced_qty, produced_unit_id, production_date, description,  
-		updated_to_stock, updated_to_account, total_cost, user_id, last_modified_by_user_id
+		updated_to_stock, updated_to_account, total_cost, user_id, last_modified_by_user_id, 
+		production_recipe_id, planned_qty, loss_qty, branch_id
 	) VALUES (` + values + `)`
 
 	_, err := s.db.Exec(query,
 		production.Number, production.ProducedMedicineID, production.ProducedQty, production.ProducedUnitID,
 		production.ProductionDate, production.Description, production.UpdatedToStock,
-		production.UpdatedToAccount, production.TotalCost, production.UserID, production.LastModifiedByUserID)
+		production.UpdatedToAccount, production.TotalCost, production.UserID, production.LastModifiedByUserID,
+		production.ProductionRecipeID, production.PlannedQty, production.LossQty, production.BranchID)
 	if err != nil {
 		return err
 	}
@@ -449,7 +458,7 @@ func (s *Store) DeleteProductionMedicineItem(production *types.Production, user
 }
 
 func (s *Store) ModifyProduction(id int, production types.Production, user *types.User) error {
-	data, err := s.GetProductionByID(production.ID)
+	data, err := s.GetProductionByID(id)
 	if err != nil {
 		return err
 	}
@@ -466,13 +475,15 @@ func (s *Store) ModifyProduction(id int, production types.Production, user *type
 	query := `UPDATE production SET 
 				number = ?, produced_medicine_id = ?, produced_qty = ?, produced_unit_id = ?, production_date = ?, 
 				description = ?, updated_to_stock = ?, updated_to_account = ?, total_cost = ?, 
-				last_modified = ?, last_modified_by_user_id = ? 
+				last_modified = ?, last_modified_by_user_id = ?, 
+				production_recipe_id = ?, planned_qty = ?, loss_qty = ? 
 				WHERE id = ?`
 
 	_, err = s.db.Exec(query,
 		production.Number, production.ProducedMedicineID, production.ProducedQty, production.ProducedUnitID,
 		production.ProductionDate, production.Description, production.UpdatedToStock,
-		production.UpdatedToAccount, production.TotalCost, time.Now(), production.LastModifiedByUserID, id)
+		production.UpdatedToAccount, production.TotalCost, time.Now(), production.LastModifiedByUserID,
+		production.ProductionRecipeID, production.PlannedQty, production.LossQty, id)
 	if err != nil {
 		return err
 	}
@@ -518,6 +529,42 @@ func (s *Store) AbsoluteDeleteProduction(prod types.Production) error {
 	return nil
 }
 
+func (s *Store) GetMedicineUnitCost(medicineId int) (*types.MedicineUnitCost, error) {
+	// the subtotal already has the discount of the item
+	query := `SELECT (pmi.subtotal / pmi.qty), pmi.unit_id 
+				FROM purchase_medicine_item AS pmi 
+				JOIN purchase_invoice AS pi ON pi.id = pmi.purchase_invoice_id 
+				WHERE pmi.medicine_id = ? AND pmi.qty > 0 
+				AND pi.deleted_at IS NULL 
+				ORDER BY pi.invoice_date DESC, pmi.id DESC LIMIT 1`
+
+	unitCost := new(types.MedicineUnitCost)
+
+	err := s.db.QueryRow(query, medicineId).Scan(&unitCost.Cost, &unitCost.UnitID)
+	if err == nil {
+		return unitCost, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	query = `SELECT (total_cost / produced_qty), produced_unit_id 
+				FROM production 
+				WHERE produced_medicine_id = ? AND produced_qty > 0 
+				AND deleted_at IS NULL 
+				ORDER BY production_date DESC, id DESC LIMIT 1`
+
+	err = s.db.QueryRow(query, medicineId).Scan(&unitCost.Cost, &unitCost.UnitID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return unitCost, nil
+}
+
 func scanRowIntoProduction(rows *sql.Rows) (*types.Production, error) {
 	production := new(types.Production)
 
@@ -538,6 +585,10 @@ func scanRowIntoProduction(rows *sql.Rows) (*types.Production, error) {
 		&production.LastModifiedByUserID,
 		&production.DeletedAt,
 		&production.DeletedByUserID,
+		&production.ProductionRecipeID,
+		&production.PlannedQty,
+		&production.LossQty,
+		&production.BranchID,
 	)
 
 	if err != nil {
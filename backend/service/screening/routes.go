@@ -0,0 +1,56 @@
+package screening
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	ruleStore types.InteractionRuleStore
+	userStore types.UserStore
+}
+
+func NewHandler(ruleStore types.InteractionRuleStore, userStore types.UserStore) *Handler {
+	return &Handler{ruleStore: ruleStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/interaction-rule", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/interaction-rule/reload", h.handleReload).Methods(http.MethodPost)
+
+	router.HandleFunc("/interaction-rule", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/interaction-rule/reload", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, h.ruleStore.GetAllInteractionRules())
+}
+
+func (h *Handler) handleReload(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	err = h.ruleStore.ReloadInteractionRules()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error reload interaction rules: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("%d interaction rules reloaded by %s",
+		len(h.ruleStore.GetAllInteractionRules()), user.Name))
+}
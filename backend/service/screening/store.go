@@ -0,0 +1,138 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// interaction rules are kept in memory, keyed by the pair of active ingredients
+type Store struct {
+	path  string
+	mu    sync.RWMutex
+	rules map[string][]types.InteractionRule
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path, rules: make(map[string][]types.InteractionRule)}
+}
+
+func (s *Store) GetInteractionRules(ingredientA string, ingredientB string) []types.InteractionRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rules[ruleKey(ingredientA, ingredientB)]
+}
+
+func (s *Store) GetAllInteractionRules() []types.InteractionRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]types.InteractionRule, 0)
+
+	for _, val := range s.rules {
+		rules = append(rules, val...)
+	}
+
+	return rules
+}
+
+func (s *Store) ReloadInteractionRules() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var rules []types.InteractionRule
+
+	switch strings.ToLower(filepath.Ext(s.path)) {
+	case ".json":
+		err = json.NewDecoder(file).Decode(&rules)
+	case ".csv":
+		rules, err = readCSVRules(file)
+	default:
+		err = fmt.Errorf("unknown interaction rules file type %s", s.path)
+	}
+	if err != nil {
+		return err
+	}
+
+	newRules := make(map[string][]types.InteractionRule)
+
+	for _, rule := range rules {
+		rule.IngredientA = strings.ToUpper(strings.TrimSpace(rule.IngredientA))
+		rule.IngredientB = strings.ToUpper(strings.TrimSpace(rule.IngredientB))
+		rule.Severity = strings.ToUpper(strings.TrimSpace(rule.Severity))
+
+		if rule.IngredientA == "" || rule.IngredientB == "" {
+			continue
+		}
+
+		key := ruleKey(rule.IngredientA, rule.IngredientB)
+		newRules[key] = append(newRules[key], rule)
+	}
+
+	s.mu.Lock()
+	s.rules = newRules
+	s.mu.Unlock()
+
+	return nil
+}
+
+// csv columns: ingredient_a, ingredient_b, severity, description
+func readCSVRules(file io.Reader) ([]types.InteractionRule, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]types.InteractionRule, 0)
+
+	for i, record := range records {
+		// skip the header
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "ingredient_a") {
+			continue
+		}
+
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 columns", i+1)
+		}
+
+		rule := types.InteractionRule{
+			IngredientA: record[0],
+			IngredientB: record[1],
+			Severity:    record[2],
+		}
+
+		if len(record) > 3 {
+			rule.Description = record[3]
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// the pair is sorted so A-B and B-A give the same key
+func ruleKey(ingredientA string, ingredientB string) string {
+	ingredientA = strings.ToUpper(strings.TrimSpace(ingredientA))
+	ingredientB = strings.ToUpper(strings.TrimSpace(ingredientB))
+
+	if ingredientA > ingredientB {
+		ingredientA, ingredientB = ingredientB, ingredientA
+	}
+
+	return ingredientA + "|" + ingredientB
+}
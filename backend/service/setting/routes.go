@@ -0,0 +1,242 @@
+package setting
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	settingStore    types.SettingStore
+	userStore       types.UserStore
+	documentStorage types.DocumentStorage
+}
+
+func NewHandler(settingStore types.SettingStore, userStore types.UserStore, documentStorage types.DocumentStorage) *Handler {
+	return &Handler{settingStore: settingStore, userStore: userStore, documentStorage: documentStorage}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/setting/company", h.handleGetCompanySetting).Methods(http.MethodGet)
+	router.HandleFunc("/setting/company", h.handleModifyCompanySetting).Methods(http.MethodPatch)
+	router.HandleFunc("/setting/logo", h.handleGetLogo).Methods(http.MethodGet)
+	router.HandleFunc("/setting/logo", h.handleUploadLogo).Methods(http.MethodPost)
+	router.HandleFunc("/setting/document", h.handleGetDocumentSettings).Methods(http.MethodGet)
+	router.HandleFunc("/setting/document", h.handleModifyDocumentSetting).Methods(http.MethodPatch)
+
+	router.HandleFunc("/setting/company", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/setting/logo", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/setting/document", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleGetCompanySetting(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	setting, err := h.settingStore.GetCompanySetting()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, setting)
+}
+
+func (h *Handler) handleModifyCompanySetting(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ModifyCompanySettingPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	admin, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	err = h.settingStore.SaveCompanySetting(types.CompanySetting{
+		Name:                       payload.Name,
+		Address:                    payload.Address,
+		PhoneNumber:                payload.PhoneNumber,
+		WhatsAppNumber:             payload.WhatsAppNumber,
+		BusinessRegistrationNumber: payload.BusinessRegistrationNumber,
+		Pharmacist:                 payload.Pharmacist,
+		PharmacistLicenseNumber:    payload.PharmacistLicenseNumber,
+		Slogan:                     payload.Slogan,
+	}, admin)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("company setting modified by %s", admin.Name))
+}
+
+func (h *Handler) handleGetLogo(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	setting, err := h.settingStore.GetCompanySetting()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	logo, err := utils.GetLogo(h.documentStorage, setting.LogoURL)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("logo file not found"))
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	http.ServeContent(w, r, filepath.Base(setting.LogoURL), setting.LastModified, bytes.NewReader(logo))
+}
+
+// the logo is saved as a new file, so the documents being made keep the old one
+func (h *Handler) handleUploadLogo(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	admin, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, (constants.SETTING_LOGO_MAX_SIZE + (1 << 20)))
+
+	err = r.ParseMultipartForm(constants.SETTING_LOGO_MAX_SIZE)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid logo upload: %v", err))
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("logo")
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("logo file is required: %v", err))
+		return
+	}
+	defer file.Close()
+
+	if fileHeader.Size > constants.SETTING_LOGO_MAX_SIZE {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("logo file is bigger than %d bytes", constants.SETTING_LOGO_MAX_SIZE))
+		return
+	}
+
+	logo, err := io.ReadAll(file)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// the pdf and the receipt can only use png and jpeg
+	_, format, err := image.DecodeConfig(bytes.NewReader(logo))
+	if err != nil || (format != "png" && format != "jpeg") {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("logo must be a png or jpeg image"))
+		return
+	}
+
+	extension := ".png"
+	if format == "jpeg" {
+		extension = ".jpg"
+	}
+
+	fileName := fmt.Sprintf("logo-%s-%s%s", time.Now().Format("20060102150405"), utils.GenerateRandomCodeAlphanumeric(6), extension)
+
+	_, err = h.documentStorage.SaveDocument(constants.DOCUMENT_LOGO, fileName, logo)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error saving logo: %v", err))
+		return
+	}
+
+	err = h.settingStore.UpdateLogoURL(fileName, admin)
+	if err != nil {
+		h.documentStorage.DeleteDocument(constants.DOCUMENT_LOGO, fileName)
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("logo uploaded by %s", admin.Name))
+}
+
+func (h *Handler) handleGetDocumentSettings(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	settings, err := h.settingStore.GetAllDocumentSettings()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) handleModifyDocumentSetting(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ModifyDocumentSettingPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	admin, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	err = h.settingStore.SaveDocumentSetting(types.DocumentSetting{
+		DocumentType: payload.DocumentType,
+		Footer:       payload.Footer,
+	}, admin)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("%s setting modified by %s", payload.DocumentType, admin.Name))
+}
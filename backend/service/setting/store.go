@@ -0,0 +1,259 @@
+package setting
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/config"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetCompanySetting() (*types.CompanySetting, error) {
+	rows, err := s.db.Query("SELECT * FROM company_setting ORDER BY id ASC LIMIT 1")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	setting := new(types.CompanySetting)
+
+	for rows.Next() {
+		setting, err = scanRowIntoCompanySetting(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.ID == 0 {
+		return getDefaultCompanySetting(), nil
+	}
+
+	return setting, nil
+}
+
+func (s *Store) SaveCompanySetting(setting types.CompanySetting, user *types.User) error {
+	data, err := s.GetCompanySetting()
+	if err != nil {
+		return err
+	}
+
+	// keep the logo, it is changed by uploading a new one
+	if data.ID == 0 {
+		setting.LogoURL = data.LogoURL
+		return s.createCompanySetting(setting, user)
+	}
+
+	err = logger.WriteLog("modify", "company-setting", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE company_setting SET
+		name = ?, address = ?, phone_number = ?, whatsapp_number = ?,
+		business_registration_number = ?, pharmacist = ?, pharmacist_license_number = ?,
+		slogan = ?, last_modified = ?, last_modified_by_user_id = ?
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		setting.Name, setting.Address, setting.PhoneNumber, setting.WhatsAppNumber,
+		setting.BusinessRegistrationNumber, setting.Pharmacist, setting.PharmacistLicenseNumber,
+		setting.Slogan, time.Now(), user.ID, data.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateLogoURL(logoUrl string, user *types.User) error {
+	data, err := s.GetCompanySetting()
+	if err != nil {
+		return err
+	}
+
+	if data.ID == 0 {
+		data.LogoURL = logoUrl
+		return s.createCompanySetting(*data, user)
+	}
+
+	err = logger.WriteLog("modify", "company-setting", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := "UPDATE company_setting SET logo_url = ?, last_modified = ?, last_modified_by_user_id = ? WHERE id = ?"
+	_, err = s.db.Exec(query, logoUrl, time.Now(), user.ID, data.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetDocumentSetting(documentType string) (*types.DocumentSetting, error) {
+	rows, err := s.db.Query("SELECT * FROM document_setting WHERE document_type = ?", documentType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	setting := new(types.DocumentSetting)
+
+	for rows.Next() {
+		setting, err = scanRowIntoDocumentSetting(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if setting.ID == 0 {
+		return &types.DocumentSetting{DocumentType: documentType}, nil
+	}
+
+	return setting, nil
+}
+
+func (s *Store) GetAllDocumentSettings() ([]types.DocumentSetting, error) {
+	documentTypes := []string{
+		constants.SETTING_DOCUMENT_INVOICE,
+		constants.SETTING_DOCUMENT_PURCHASE_INVOICE,
+		constants.SETTING_DOCUMENT_PURCHASE_ORDER,
+		constants.SETTING_DOCUMENT_PRESCRIPTION,
+		constants.SETTING_DOCUMENT_ETICKET,
+		constants.SETTING_DOCUMENT_STOCK_TRANSFER,
+	}
+
+	settings := make([]types.DocumentSetting, 0)
+
+	for _, documentType := range documentTypes {
+		setting, err := s.GetDocumentSetting(documentType)
+		if err != nil {
+			return nil, err
+		}
+
+		settings = append(settings, *setting)
+	}
+
+	return settings, nil
+}
+
+func (s *Store) SaveDocumentSetting(setting types.DocumentSetting, user *types.User) error {
+	data, err := s.GetDocumentSetting(setting.DocumentType)
+	if err != nil {
+		return err
+	}
+
+	if data.ID != 0 {
+		err = logger.WriteLog("modify", "document-setting", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+		if err != nil {
+			return fmt.Errorf("error write log file")
+		}
+	}
+
+	query := `INSERT INTO document_setting (
+		document_type, footer, last_modified, last_modified_by_user_id
+	) VALUES (?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		footer = VALUES(footer), last_modified = VALUES(last_modified),
+		last_modified_by_user_id = VALUES(last_modified_by_user_id)`
+
+	_, err = s.db.Exec(query, setting.DocumentType, setting.Footer, time.Now(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) createCompanySetting(setting types.CompanySetting, user *types.User) error {
+	values := "?"
+	for i := 0; i < 10; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO company_setting (
+		name, address, phone_number, whatsapp_number,
+		business_registration_number, pharmacist, pharmacist_license_number,
+		logo_url, slogan, last_modified, last_modified_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		setting.Name, setting.Address, setting.PhoneNumber, setting.WhatsAppNumber,
+		setting.BusinessRegistrationNumber, setting.Pharmacist, setting.PharmacistLicenseNumber,
+		setting.LogoURL, setting.Slogan, time.Now(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func getDefaultCompanySetting() *types.CompanySetting {
+	return &types.CompanySetting{
+		Name:                       config.Envs.CompanyName,
+		Address:                    config.Envs.CompanyAddress,
+		PhoneNumber:                config.Envs.CompanyPhoneNumber,
+		WhatsAppNumber:             config.Envs.CompanyWhatsAppNumber,
+		BusinessRegistrationNumber: config.Envs.BusinessRegistrationNumber,
+		Pharmacist:                 config.Envs.Pharmacist,
+		PharmacistLicenseNumber:    config.Envs.PharmacistLicenseNumber,
+		LogoURL:                    config.Envs.CompanyLogoURL,
+		Slogan:                     config.Envs.CompanySlogan,
+	}
+}
+
+func scanRowIntoCompanySetting(rows *sql.Rows) (*types.CompanySetting, error) {
+	setting := new(types.CompanySetting)
+
+	err := rows.Scan(
+		&setting.ID,
+		&setting.Name,
+		&setting.Address,
+		&setting.PhoneNumber,
+		&setting.WhatsAppNumber,
+		&setting.BusinessRegistrationNumber,
+		&setting.Pharmacist,
+		&setting.PharmacistLicenseNumber,
+		&setting.LogoURL,
+		&setting.Slogan,
+		&setting.LastModified,
+		&setting.LastModifiedByUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	setting.LastModified = setting.LastModified.Local()
+
+	return setting, nil
+}
+
+func scanRowIntoDocumentSetting(rows *sql.Rows) (*types.DocumentSetting, error) {
+	setting := new(types.DocumentSetting)
+
+	err := rows.Scan(
+		&setting.ID,
+		&setting.DocumentType,
+		&setting.Footer,
+		&setting.LastModified,
+		&setting.LastModifiedByUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	setting.LastModified = setting.LastModified.Local()
+
+	return setting, nil
+}
@@ -0,0 +1,189 @@
+package local
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the documents are saved in the root directory, one directory per category.
+// the hash of each document is saved next to it with the .sha256 extension
+type Store struct {
+	root string
+}
+
+func NewStore(root string) (*Store, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(root, 0744); err != nil {
+		return nil, err
+	}
+
+	return &Store{root: root}, nil
+}
+
+func (s *Store) SaveDocument(category string, fileName string, content []byte) (*types.Document, error) {
+	path, err := s.getPath(category, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0744); err != nil {
+		return nil, err
+	}
+
+	hash := getHash(content)
+
+	// write to a temporary file first, so the old document is kept if the writing fails
+	tempFile, err := os.CreateTemp(filepath.Dir(path), ("." + fileName + "-*"))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.Write(content)
+	if err != nil {
+		tempFile.Close()
+		return nil, err
+	}
+
+	err = tempFile.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Rename(tempFile.Name(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.WriteFile((path + ".sha256"), []byte(hash), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Document{
+		Category:     category,
+		FileName:     fileName,
+		ContentType:  getContentType(fileName),
+		Size:         info.Size(),
+		Hash:         hash,
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+func (s *Store) GetDocument(category string, fileName string) (*types.Document, error) {
+	path, err := s.getPath(category, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("document %s not found", fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := getHash(content)
+
+	// the documents saved before the hash was kept have no hash file
+	savedHash, err := os.ReadFile(path + ".sha256")
+	if err == nil && strings.TrimSpace(string(savedHash)) != hash {
+		return nil, fmt.Errorf("document %s is corrupted, the hash doesn't match", fileName)
+	}
+
+	return &types.Document{
+		Category:     category,
+		FileName:     fileName,
+		ContentType:  getContentType(fileName),
+		Size:         info.Size(),
+		Hash:         hash,
+		LastModified: info.ModTime(),
+		Content:      content,
+	}, nil
+}
+
+func (s *Store) IsDocumentExist(category string, fileName string) (bool, error) {
+	path, err := s.getPath(category, fileName)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return !info.IsDir(), nil
+}
+
+func (s *Store) DeleteDocument(category string, fileName string) error {
+	path, err := s.getPath(category, fileName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.Remove(path + ".sha256")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// the file name can't go out of its category directory
+func (s *Store) getPath(category string, fileName string) (string, error) {
+	if fileName == "" || fileName != filepath.Base(fileName) || fileName == "." || fileName == ".." {
+		return "", fmt.Errorf("invalid document name: %s", fileName)
+	}
+
+	directory := filepath.Join(s.root, filepath.FromSlash(category))
+	if !strings.HasPrefix(directory, s.root) {
+		return "", fmt.Errorf("invalid document category: %s", category)
+	}
+
+	return filepath.Join(directory, fileName), nil
+}
+
+func getHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func getContentType(fileName string) string {
+	contentType := mime.TypeByExtension(filepath.Ext(fileName))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+
+	return contentType
+}
@@ -0,0 +1,376 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the documents are saved in an S3 compatible object storage (AWS S3, MinIO, etc.),
+// with path style urls, e.g. http://localhost:9000/bucket/invoice/i-xxx.pdf.
+// the hash of each document is saved in the object metadata
+type Store struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+type Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+const hashMetadataHeader = "X-Amz-Meta-Sha256"
+
+func NewStore(config Config) (*Store, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %v", err)
+	}
+
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", config.Endpoint)
+	}
+
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is empty")
+	}
+
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	s := &Store{
+		endpoint:  endpoint,
+		region:    region,
+		bucket:    config.Bucket,
+		accessKey: config.AccessKey,
+		secretKey: config.SecretKey,
+		client:    &http.Client{Timeout: (30 * time.Second)},
+	}
+
+	err = s.createBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Store) SaveDocument(category string, fileName string, content []byte) (*types.Document, error) {
+	key, err := getKey(category, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := getHash(content)
+	contentType := getContentType(fileName)
+
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	header.Set(hashMetadataHeader, hash)
+
+	resp, err := s.do(http.MethodPut, key, header, content)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, getResponseError(resp, fileName)
+	}
+
+	return &types.Document{
+		Category:     category,
+		FileName:     fileName,
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		Hash:         hash,
+		LastModified: time.Now(),
+	}, nil
+}
+
+func (s *Store) GetDocument(category string, fileName string) (*types.Document, error) {
+	key, err := getKey(category, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("document %s not found", fileName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, getResponseError(resp, fileName)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := getHash(content)
+
+	savedHash := resp.Header.Get(hashMetadataHeader)
+	if savedHash != "" && savedHash != hash {
+		return nil, fmt.Errorf("document %s is corrupted, the hash doesn't match", fileName)
+	}
+
+	lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		lastModified = time.Now()
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = getContentType(fileName)
+	}
+
+	return &types.Document{
+		Category:     category,
+		FileName:     fileName,
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		Hash:         hash,
+		LastModified: lastModified.Local(),
+		Content:      content,
+	}, nil
+}
+
+func (s *Store) IsDocumentExist(category string, fileName string) (bool, error) {
+	key, err := getKey(category, fileName)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.do(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, getResponseError(resp, fileName)
+	}
+}
+
+func (s *Store) DeleteDocument(category string, fileName string) error {
+	key, err := getKey(category, fileName)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return getResponseError(resp, fileName)
+	}
+
+	return nil
+}
+
+func (s *Store) createBucket() error {
+	resp, err := s.do(http.MethodHead, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error connecting to s3: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("error checking bucket %s: %s", s.bucket, resp.Status)
+	}
+
+	var body []byte
+	if s.region != "us-east-1" {
+		body = []byte("<CreateBucketConfiguration><LocationConstraint>" + s.region + "</LocationConstraint></CreateBucketConfiguration>")
+	}
+
+	resp, err = s.do(http.MethodPut, "", nil, body)
+	if err != nil {
+		return fmt.Errorf("error creating bucket %s: %v", s.bucket, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return getResponseError(resp, s.bucket)
+	}
+
+	return nil
+}
+
+func (s *Store) do(method string, key string, header http.Header, body []byte) (*http.Response, error) {
+	objectPath := "/" + s.bucket
+	if key != "" {
+		objectPath += "/" + key
+	}
+
+	requestUrl := *s.endpoint
+	requestUrl.Path = s.endpoint.Path + objectPath
+	requestUrl.RawPath = s.endpoint.Path + encodePath(objectPath)
+
+	req, err := http.NewRequest(method, requestUrl.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	req.ContentLength = int64(len(body))
+	s.signRequest(req, body, time.Now())
+
+	return s.client.Do(req)
+}
+
+// the request is signed with AWS signature version 4, all of its headers are signed
+func (s *Store) signRequest(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := getHash(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaderNames := []string{"host"}
+	canonicalHeaders := map[string]string{"host": req.URL.Host}
+
+	for name, values := range req.Header {
+		lowerName := strings.ToLower(name)
+		signedHeaderNames = append(signedHeaderNames, lowerName)
+		canonicalHeaders[lowerName] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	sort.Strings(signedHeaderNames)
+
+	var headerBuilder strings.Builder
+	for _, name := range signedHeaderNames {
+		headerBuilder.WriteString(name + ":" + canonicalHeaders[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		headerBuilder.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + s.region + "/s3/aws4_request"
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		getHash([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := getHmac([]byte("AWS4"+s.secretKey), dateStamp)
+	signingKey = getHmac(signingKey, s.region)
+	signingKey = getHmac(signingKey, "s3")
+	signingKey = getHmac(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(getHmac(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// the file name can't be a path, so each document stays in its category
+func getKey(category string, fileName string) (string, error) {
+	if fileName == "" || strings.ContainsAny(fileName, "/\\") || fileName == "." || fileName == ".." {
+		return "", fmt.Errorf("invalid document name: %s", fileName)
+	}
+
+	category = strings.Trim(path.Clean("/"+category), "/")
+	if category == "" {
+		return "", fmt.Errorf("invalid document category")
+	}
+
+	return (category + "/" + fileName), nil
+}
+
+// every byte except the unreserved characters and the slash is percent encoded
+func encodePath(objectPath string) string {
+	var builder strings.Builder
+
+	for _, b := range []byte(objectPath) {
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || b == '/' {
+			builder.WriteByte(b)
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("%%%02X", b))
+	}
+
+	return builder.String()
+}
+
+func getResponseError(resp *http.Response, name string) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 error for %s: %s %s", name, resp.Status, strings.TrimSpace(string(message)))
+}
+
+func getHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func getHmac(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func getContentType(fileName string) string {
+	contentType := mime.TypeByExtension(path.Ext(fileName))
+	if contentType == "" {
+		return "application/octet-stream"
+	}
+
+	return contentType
+}
@@ -0,0 +1,626 @@
+package sync
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+	"github.com/nicolaics/pharmacon/utils/pdf"
+)
+
+type Handler struct {
+	syncStore          types.SyncStore
+	userStore          types.UserStore
+	invoiceStore       types.InvoiceStore
+	custStore          types.CustomerStore
+	doctorStore        types.DoctorStore
+	paymentMethodStore types.PaymentMethodStore
+	medStore           types.MedicineStore
+	unitStore          types.UnitStore
+	taxStore           types.TaxStore
+	registerStore      types.ControlledSubstanceStore
+	mdmiStore          types.MainDoctorMedItemStore
+	branchStore        types.BranchStore
+	settingStore       types.SettingStore
+	documentStorage    types.DocumentStorage
+	eventStore         types.EventStore
+}
+
+func NewHandler(syncStore types.SyncStore, userStore types.UserStore,
+	invoiceStore types.InvoiceStore, custStore types.CustomerStore, doctorStore types.DoctorStore,
+	paymentMethodStore types.PaymentMethodStore, medStore types.MedicineStore, unitStore types.UnitStore,
+	taxStore types.TaxStore, registerStore types.ControlledSubstanceStore, mdmiStore types.MainDoctorMedItemStore,
+	branchStore types.BranchStore, settingStore types.SettingStore, documentStorage types.DocumentStorage,
+	eventStore types.EventStore) *Handler {
+	return &Handler{
+		syncStore:          syncStore,
+		userStore:          userStore,
+		invoiceStore:       invoiceStore,
+		custStore:          custStore,
+		doctorStore:        doctorStore,
+		paymentMethodStore: paymentMethodStore,
+		medStore:           medStore,
+		unitStore:          unitStore,
+		taxStore:           taxStore,
+		registerStore:      registerStore,
+		mdmiStore:          mdmiStore,
+		branchStore:        branchStore,
+		settingStore:       settingStore,
+		documentStorage:    documentStorage,
+		eventStore:         eventStore,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/sync/pull", h.handlePull).Methods(http.MethodPost)
+	router.HandleFunc("/sync/push", h.handlePush).Methods(http.MethodPost)
+
+	router.HandleFunc("/sync/pull", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/sync/push", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+// the master data changed since the cursor, the terminal keeps the returned cursor for the next pull
+func (h *Handler) handlePull(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.SyncPullPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	since := time.Unix(0, 0)
+	if payload.Cursor != "" {
+		since, err = time.Parse(time.RFC3339, payload.Cursor)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid cursor %s", payload.Cursor))
+			return
+		}
+	}
+
+	// the db only keeps the seconds, so the data changed in the same second is sent again on the next pull
+	cursor := time.Now().Truncate(time.Second)
+
+	medicines, err := h.medStore.GetMedicinesModifiedSince(since)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get medicines: %v", err))
+		return
+	}
+
+	units, err := h.unitStore.GetUnitsCreatedSince(since)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get units: %v", err))
+		return
+	}
+
+	customers, err := h.custStore.GetCustomersModifiedSince(since)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get customers: %v", err))
+		return
+	}
+
+	doctors, err := h.doctorStore.GetDoctorsModifiedSince(since)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get doctors: %v", err))
+		return
+	}
+
+	branchStocks, err := h.medStore.GetBranchStocksModifiedSince(user.BranchID, since)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get stocks: %v", err))
+		return
+	}
+
+	stocks := make([]types.SyncStockReturnData, 0)
+	for medicineId, qty := range branchStocks {
+		stocks = append(stocks, types.SyncStockReturnData{
+			MedicineID: medicineId,
+			Qty:        qty,
+		})
+	}
+
+	sort.Slice(stocks, func(i, j int) bool {
+		return stocks[i].MedicineID < stocks[j].MedicineID
+	})
+
+	utils.WriteJSON(w, http.StatusOK, types.SyncPullReturnPayload{
+		Cursor:    cursor.Format(time.RFC3339),
+		Medicines: medicines,
+		Units:     units,
+		Customers: customers,
+		Doctors:   doctors,
+		Stocks:    stocks,
+	})
+}
+
+// the invoices made offline are applied by the invoice date, then the number given by the terminal,
+// then the client uuid, so the same push always gives the same result.
+// the sale already happened, so the invoice is always kept with the totals of the terminal. the stock is only
+// subtracted as far as it is available, the rest and a different tax are returned as the conflict to be checked by the pharmacy.
+// the invoice that is pushed again is not applied twice.
+func (h *Handler) handlePush(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.SyncPushPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	results := make([]types.OfflineInvoiceResult, 0)
+	offlineInvoices := make([]types.OfflineInvoicePayload, 0)
+	invoiceDates := make(map[string]time.Time)
+
+	for _, offlineInvoice := range payload.Invoices {
+		invoiceDate, err := utils.ParseDate(offlineInvoice.Invoice.InvoiceDate)
+		if err != nil {
+			results = append(results, types.OfflineInvoiceResult{
+				ClientUUID: offlineInvoice.ClientUUID,
+				Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+				Error:      "failed to parse date",
+			})
+			continue
+		}
+
+		offlineInvoice.ClientUUID = strings.ToLower(offlineInvoice.ClientUUID)
+		invoiceDates[offlineInvoice.ClientUUID] = *invoiceDate
+		offlineInvoices = append(offlineInvoices, offlineInvoice)
+	}
+
+	sort.SliceStable(offlineInvoices, func(i, j int) bool {
+		a := offlineInvoices[i]
+		b := offlineInvoices[j]
+
+		if !invoiceDates[a.ClientUUID].Equal(invoiceDates[b.ClientUUID]) {
+			return invoiceDates[a.ClientUUID].Before(invoiceDates[b.ClientUUID])
+		}
+
+		if a.Invoice.Number != b.Invoice.Number {
+			return a.Invoice.Number < b.Invoice.Number
+		}
+
+		return a.ClientUUID < b.ClientUUID
+	})
+
+	for _, offlineInvoice := range offlineInvoices {
+		// the uuid is claimed before anything is saved, so the same invoice
+		// pushed twice at once or retried is only applied once
+		claimed, err := h.syncStore.ClaimOfflineInvoice(types.OfflineInvoice{
+			ClientUUID:   offlineInvoice.ClientUUID,
+			TerminalID:   payload.TerminalID,
+			ClientNumber: offlineInvoice.Invoice.Number,
+			BranchID:     user.BranchID,
+			UserID:       user.ID,
+		})
+		if err != nil {
+			results = append(results, types.OfflineInvoiceResult{
+				ClientUUID: offlineInvoice.ClientUUID,
+				Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+				Error:      fmt.Sprintf("error claim offline invoice: %v", err),
+			})
+			continue
+		}
+
+		if !claimed {
+			results = append(results, getClaimedOfflineInvoiceResult(h, offlineInvoice.ClientUUID))
+			continue
+		}
+
+		result, invoiceId, err := syncOfflineInvoice(h, user, offlineInvoice, invoiceDates[offlineInvoice.ClientUUID])
+		if err != nil {
+			releaseOfflineInvoice(h, offlineInvoice.ClientUUID, invoiceId, err)
+
+			results = append(results, types.OfflineInvoiceResult{
+				ClientUUID: offlineInvoice.ClientUUID,
+				Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+				InvoiceID:  invoiceId,
+				Error:      err.Error(),
+			})
+			continue
+		}
+
+		results = append(results, *result)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, results)
+}
+
+// the result of the uuid claimed by the previous push
+func getClaimedOfflineInvoiceResult(h *Handler, clientUuid string) types.OfflineInvoiceResult {
+	synced, err := h.syncStore.GetOfflineInvoiceByClientUUID(clientUuid)
+	if err != nil {
+		return types.OfflineInvoiceResult{
+			ClientUUID: clientUuid,
+			Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+			Error:      fmt.Sprintf("error get offline invoice: %v", err),
+		}
+	}
+
+	switch synced.Status {
+	case constants.OFFLINE_INVOICE_STATUS_PENDING:
+		return types.OfflineInvoiceResult{
+			ClientUUID: clientUuid,
+			Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+			Error:      "invoice is being synced by another push, push it again later",
+		}
+	case constants.OFFLINE_INVOICE_STATUS_FAILED:
+		return types.OfflineInvoiceResult{
+			ClientUUID: clientUuid,
+			Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+			InvoiceID:  synced.InvoiceID,
+			Error:      fmt.Sprintf("previous sync failed after the invoice was saved, check it: %s", synced.ConflictNote),
+		}
+	}
+
+	invoice, err := h.invoiceStore.GetInvoiceByID(synced.InvoiceID)
+	if err != nil {
+		return types.OfflineInvoiceResult{
+			ClientUUID: clientUuid,
+			Status:     constants.OFFLINE_INVOICE_STATUS_REJECTED,
+			Error:      fmt.Sprintf("invoice id %d doesn't exists", synced.InvoiceID),
+		}
+	}
+
+	return types.OfflineInvoiceResult{
+		ClientUUID:   clientUuid,
+		Status:       constants.OFFLINE_INVOICE_STATUS_DUPLICATE,
+		InvoiceID:    invoice.ID,
+		Number:       invoice.Number,
+		ConflictNote: synced.ConflictNote,
+	}
+}
+
+// the invoice is removed from the claim first, the claim refers to it
+func deleteClaimedInvoice(h *Handler, clientUuid string, invoice types.Invoice) error {
+	err := h.syncStore.SetOfflineInvoiceID(clientUuid, 0)
+	if err != nil {
+		return err
+	}
+
+	return h.invoiceStore.AbsoluteDeleteInvoice(invoice)
+}
+
+// empty if the tax of the terminal is the same as the tax configured on the invoice date
+func getTaxConflictNote(h *Handler, payload types.RegisterInvoicePayload, invoiceDate time.Time) string {
+	serverPayload := payload
+
+	err := utils.SetInvoiceTax(h.taxStore, h.medStore, &serverPayload, invoiceDate)
+	if err != nil {
+		return fmt.Sprintf("tax not checked: %v", err)
+	}
+
+	// the tax is rounded to the rupiah
+	if math.Abs(serverPayload.TaxAmount-payload.TaxAmount) >= 1 {
+		return fmt.Sprintf("tax %.2f, configured tax %.2f (%.2f%%)", payload.TaxAmount, serverPayload.TaxAmount, serverPayload.TaxPercentage)
+	}
+
+	return ""
+}
+
+// the claim is removed if nothing was saved, so the terminal can push it again,
+// otherwise it is kept as failed so the saved changes are not applied twice
+func releaseOfflineInvoice(h *Handler, clientUuid string, invoiceId int, syncErr error) {
+	var err error
+
+	if invoiceId == 0 {
+		err = h.syncStore.DeleteOfflineInvoice(clientUuid)
+	} else {
+		err = h.syncStore.FinishOfflineInvoice(clientUuid, invoiceId, constants.OFFLINE_INVOICE_STATUS_FAILED, syncErr.Error())
+	}
+
+	if err != nil {
+		slog.Error("error release offline invoice", "clientUuid", clientUuid, "invoiceId", invoiceId, "error", err)
+	}
+}
+
+// creates the invoice the same way as the invoice page, but with the number given by the server.
+// the invoice id is returned with the error once the invoice can't be removed anymore
+func syncOfflineInvoice(h *Handler, user *types.User, offlineInvoice types.OfflineInvoicePayload, invoiceDate time.Time) (*types.OfflineInvoiceResult, int, error) {
+	payload := offlineInvoice.Invoice
+
+	// check customerID
+	customer, err := h.custStore.GetCustomerByID(payload.CustomerID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("customer id %d not found", payload.CustomerID)
+	}
+
+	// check paymentMethodName
+	paymentMethod, err := h.paymentMethodStore.GetPaymentMethodByName(payload.PaymentMethodName)
+	if paymentMethod == nil {
+		err = h.paymentMethodStore.CreatePaymentMethod(payload.PaymentMethodName)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error create payment method %s", payload.PaymentMethodName)
+		}
+
+		paymentMethod, err = h.paymentMethodStore.GetPaymentMethodByName(payload.PaymentMethodName)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("payment method %s not found", payload.PaymentMethodName)
+	}
+
+	// the terminal already took the money, so its totals are kept
+	// and the difference with the configured tax is returned as the conflict
+	taxConflictNote := getTaxConflictNote(h, payload, invoiceDate)
+
+	medicineItems, err := getOfflineMedicineItems(h, payload.MedicineLists)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the numbers given offline can collide with the other terminals, the server gives the next free one
+	newInvoice := types.Invoice{
+		UserID:               user.ID,
+		CustomerID:           payload.CustomerID,
+		Subtotal:             payload.Subtotal,
+		DiscountPercentage:   payload.DiscountPercentage,
+		DiscountAmount:       payload.DiscountAmount,
+		TaxPercentage:        payload.TaxPercentage,
+		TaxAmount:            payload.TaxAmount,
+		TaxableAmount:        payload.TaxableAmount,
+		TotalPrice:           payload.TotalPrice,
+		PaidAmount:           payload.PaidAmount,
+		ChangeAmount:         payload.ChangeAmount,
+		PaymentMethodID:      paymentMethod.ID,
+		Description:          payload.Description,
+		InvoiceDate:          invoiceDate,
+		LastModifiedByUserID: user.ID,
+		BranchID:             user.BranchID,
+	}
+	invoiceId, number, err := h.invoiceStore.CreateInvoiceWithNextNumber(newInvoice)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	newInvoice.ID = invoiceId
+	newInvoice.Number = number
+
+	// the claim keeps the invoice, so a push that stops from here is not applied again
+	err = h.syncStore.SetOfflineInvoiceID(offlineInvoice.ClientUUID, invoiceId)
+	if err != nil {
+		errDel := h.invoiceStore.AbsoluteDeleteInvoice(newInvoice)
+		if errDel != nil {
+			return nil, invoiceId, fmt.Errorf("error absolute delete invoice: %v", errDel)
+		}
+
+		return nil, 0, fmt.Errorf("error save offline invoice: %v", err)
+	}
+
+	for i, medicine := range payload.MedicineLists {
+		err = h.invoiceStore.CreateMedicineItem(types.InvoiceMedicineItem{
+			InvoiceID:          invoiceId,
+			MedicineID:         medicineItems[i].Medicine.ID,
+			Qty:                medicine.Qty,
+			UnitID:             medicineItems[i].Unit.ID,
+			Price:              medicine.Price,
+			DiscountPercentage: medicine.DiscountPercentage,
+			DiscountAmount:     medicine.DiscountAmount,
+			Subtotal:           medicine.Subtotal,
+		})
+		if err != nil {
+			errDel := deleteClaimedInvoice(h, offlineInvoice.ClientUUID, newInvoice)
+			if errDel != nil {
+				return nil, invoiceId, fmt.Errorf("error absolute delete invoice: %v", errDel)
+			}
+
+			return nil, 0, fmt.Errorf("invoice %d, med %s: %v", number, medicine.MedicineName, err)
+		}
+	}
+
+	// reduce the stock, only as far as it is available
+	conflicts := make([]types.OfflineStockConflict, 0)
+	dispensedIngredients := make([]types.DispensedIngredient, 0)
+
+	for _, medicineItem := range medicineItems {
+		stockItems, err := utils.ExpandMedicineStockItems(h.mdmiStore, h.medStore, h.unitStore, medicineItem.Medicine, medicineItem.Unit, medicineItem.Qty)
+		if err != nil {
+			return nil, invoiceId, fmt.Errorf("error expanding recipe of %s: %v", medicineItem.Medicine.Name, err)
+		}
+
+		for _, stockItem := range stockItems {
+			shortage, err := utils.SubtractAvailableStock(h.medStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, user.BranchID, user)
+			if err != nil {
+				return nil, invoiceId, fmt.Errorf("error updating stock: %v", err)
+			}
+
+			if shortage > 0 {
+				soldQty, err := utils.ConvertToFirstUnit(stockItem.Medicine, stockItem.Unit, stockItem.Qty)
+				if err != nil {
+					return nil, invoiceId, err
+				}
+
+				conflicts = append(conflicts, types.OfflineStockConflict{
+					MedicineBarcode: stockItem.Medicine.Barcode,
+					MedicineName:    stockItem.Medicine.Name,
+					SoldQty:         soldQty,
+					ShortageQty:     shortage,
+				})
+			}
+
+			err = utils.RecordControlledSubstanceOut(h.registerStore, stockItem.Medicine, stockItem.Unit, stockItem.Qty, types.ControlledSubstanceRegister{
+				TransactionType: constants.CONTROLLED_TRANSACTION_SALE,
+				ReferenceID:     invoiceId,
+				ReferenceNumber: number,
+				TransactionDate: invoiceDate,
+				PartyName:       customer.Name,
+				UserID:          user.ID,
+				BranchID:        user.BranchID,
+			})
+			if err != nil {
+				return nil, invoiceId, fmt.Errorf("error recording controlled substance: %v", err)
+			}
+		}
+
+		dispensedIngredients = append(dispensedIngredients, utils.GetDispensedIngredients(constants.DISPENSED_REFERENCE_INVOICE, invoiceId, medicineItem.Medicine, stockItems)...)
+	}
+
+	err = h.mdmiStore.CreateDispensedIngredients(dispensedIngredients)
+	if err != nil {
+		return nil, invoiceId, fmt.Errorf("error saving dispensed ingredients: %v", err)
+	}
+
+	status := constants.OFFLINE_INVOICE_STATUS_SYNCED
+	conflictNotes := make([]string, 0)
+
+	if len(conflicts) > 0 {
+		status = constants.OFFLINE_INVOICE_STATUS_CONFLICT
+
+		for _, conflict := range conflicts {
+			conflictNotes = append(conflictNotes, fmt.Sprintf("%s: sold %.2f, short %.2f", conflict.MedicineName, conflict.SoldQty, conflict.ShortageQty))
+		}
+	}
+
+	if taxConflictNote != "" {
+		status = constants.OFFLINE_INVOICE_STATUS_CONFLICT
+		conflictNotes = append(conflictNotes, taxConflictNote)
+	}
+
+	err = h.syncStore.FinishOfflineInvoice(offlineInvoice.ClientUUID, invoiceId, status, strings.Join(conflictNotes, "; "))
+	if err != nil {
+		return nil, invoiceId, fmt.Errorf("error save offline invoice: %v", err)
+	}
+
+	publishInvoiceCreated(h, invoiceId, user)
+	metrics.IncInvoicesCreated(constants.METRICS_INVOICE_OFFLINE)
+
+	result := &types.OfflineInvoiceResult{
+		ClientUUID:   offlineInvoice.ClientUUID,
+		Status:       status,
+		InvoiceID:    invoiceId,
+		Number:       number,
+		Conflicts:    conflicts,
+		ConflictNote: strings.Join(conflictNotes, "; "),
+	}
+
+	// the invoice is already synced, the pdf can be made again from the invoice page
+	err = createOfflineInvoicePDF(h, user, invoiceId, number, payload, invoiceDate)
+	if err != nil {
+		slog.Error("error create offline invoice pdf", "invoiceId", invoiceId, "error", err)
+		result.Error = err.Error()
+	}
+
+	return result, invoiceId, nil
+}
+
+func createOfflineInvoicePDF(h *Handler, user *types.User, invoiceId int, number int, payload types.RegisterInvoicePayload, invoiceDate time.Time) error {
+	invoicePDF := types.InvoicePDFPayload{
+		Number:             number,
+		UserName:           user.Name,
+		Subtotal:           payload.Subtotal,
+		DiscountPercentage: payload.DiscountPercentage,
+		DiscountAmount:     payload.DiscountAmount,
+		TaxPercentage:      payload.TaxPercentage,
+		TaxAmount:          payload.TaxAmount,
+		TotalPrice:         payload.TotalPrice,
+		PaidAmount:         payload.PaidAmount,
+		ChangeAmount:       payload.ChangeAmount,
+		Description:        payload.Description,
+		InvoiceDate:        invoiceDate,
+		MedicineLists:      payload.MedicineLists,
+	}
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_INVOICE, user.BranchID)
+	if err != nil {
+		return fmt.Errorf("error get document setting: %v", err)
+	}
+
+	invoiceFileName, err := pdf.CreateInvoicePDF(invoicePDF, h.invoiceStore, "", branding, h.documentStorage)
+	if err != nil {
+		return fmt.Errorf("error create invoice pdf: %v", err)
+	}
+
+	err = h.invoiceStore.UpdatePDFUrl(invoiceId, invoiceFileName)
+	if err != nil {
+		return fmt.Errorf("error update invoice pdf url: %v", err)
+	}
+
+	return nil
+}
+
+// the synced invoice is published the same way as the invoice made online
+func publishInvoiceCreated(h *Handler, invoiceId int, user *types.User) {
+	invoice, err := h.invoiceStore.GetInvoiceByID(invoiceId)
+	if err != nil {
+		slog.Error("error publish event", "event", constants.EVENT_INVOICE_CREATED, "invoiceId", invoiceId, "error", err)
+		return
+	}
+
+	medicineItems, err := h.invoiceStore.GetMedicineItem(invoiceId)
+	if err != nil {
+		slog.Error("error publish event", "event", constants.EVENT_INVOICE_CREATED, "invoiceId", invoiceId, "error", err)
+		return
+	}
+
+	utils.PublishEvent(h.eventStore, constants.EVENT_INVOICE_CREATED, constants.EVENT_ENTITY_INVOICE, invoice.ID, invoice.BranchID, user,
+		map[string]interface{}{"invoice": invoice, "medicineLists": medicineItems})
+}
+
+// the medicine and unit of every item, in the order of the payload
+func getOfflineMedicineItems(h *Handler, medicineLists []types.InvoiceMedicineListsPayload) ([]types.MedicineStockItem, error) {
+	medicineItems := make([]types.MedicineStockItem, 0)
+
+	for _, medicine := range medicineLists {
+		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
+		if err != nil {
+			return nil, fmt.Errorf("medicine %s doesn't exists", medicine.MedicineName)
+		}
+
+		unit, err := h.unitStore.GetUnitByName(medicine.Unit)
+		if unit == nil {
+			err = h.unitStore.CreateUnit(medicine.Unit)
+			if err != nil {
+				return nil, err
+			}
+
+			unit, err = h.unitStore.GetUnitByName(medicine.Unit)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		medicineItems = append(medicineItems, types.MedicineStockItem{
+			Medicine: medData,
+			Unit:     unit,
+			Qty:      medicine.Qty,
+		})
+	}
+
+	return medicineItems, nil
+}
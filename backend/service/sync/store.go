@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetOfflineInvoiceByClientUUID(clientUuid string) (*types.OfflineInvoice, error) {
+	query := "SELECT * FROM offline_invoice WHERE client_uuid = ?"
+	rows, err := s.db.Query(query, clientUuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offlineInvoice := new(types.OfflineInvoice)
+
+	for rows.Next() {
+		offlineInvoice, err = scanRowIntoOfflineInvoice(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if offlineInvoice.ID == 0 {
+		return nil, fmt.Errorf("offline invoice not found")
+	}
+
+	return offlineInvoice, nil
+}
+
+func (s *Store) ClaimOfflineInvoice(offlineInvoice types.OfflineInvoice) (bool, error) {
+	// synced_at is the claim time while it is pending
+	staleBefore := time.Now().Add(-time.Duration(constants.OFFLINE_INVOICE_CLAIM_TIMEOUT) * time.Second)
+
+	// the invoice was already made, it has to be checked by the pharmacy
+	query := `UPDATE offline_invoice SET status = ?, conflict_note = ? 
+				WHERE client_uuid = ? AND status = ? AND synced_at < ? AND invoice_id IS NOT NULL`
+	_, err := s.db.Exec(query, constants.OFFLINE_INVOICE_STATUS_FAILED, "sync stopped after the invoice was saved",
+		offlineInvoice.ClientUUID, constants.OFFLINE_INVOICE_STATUS_PENDING, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	// nothing was saved, the claim is removed so the insert below takes it again
+	query = `DELETE FROM offline_invoice 
+				WHERE client_uuid = ? AND status = ? AND synced_at < ? AND invoice_id IS NULL`
+	_, err = s.db.Exec(query, offlineInvoice.ClientUUID, constants.OFFLINE_INVOICE_STATUS_PENDING, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	values := "?"
+	for i := 0; i < 6; i++ {
+		values += ", ?"
+	}
+
+	// the unique client_uuid lets only one push make the invoice
+	query = `INSERT INTO offline_invoice (
+		client_uuid, terminal_id, client_number,
+		status, conflict_note, branch_id, user_id
+	) VALUES (` + values + `)
+	ON DUPLICATE KEY UPDATE id = id`
+
+	result, err := s.db.Exec(query,
+		offlineInvoice.ClientUUID, offlineInvoice.TerminalID, offlineInvoice.ClientNumber,
+		constants.OFFLINE_INVOICE_STATUS_PENDING, "",
+		offlineInvoice.BranchID, offlineInvoice.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return (affected == 1), nil
+}
+
+// invoiceId 0 is kept empty
+func (s *Store) SetOfflineInvoiceID(clientUuid string, invoiceId int) error {
+	var invoiceIdValue sql.NullInt64
+	if invoiceId != 0 {
+		invoiceIdValue = sql.NullInt64{Int64: int64(invoiceId), Valid: true}
+	}
+
+	query := "UPDATE offline_invoice SET invoice_id = ? WHERE client_uuid = ? AND status = ?"
+
+	result, err := s.db.Exec(query, invoiceIdValue, clientUuid, constants.OFFLINE_INVOICE_STATUS_PENDING)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("offline invoice %s is not pending", clientUuid)
+	}
+
+	return nil
+}
+
+// invoiceId 0 is kept empty
+func (s *Store) FinishOfflineInvoice(clientUuid string, invoiceId int, status string, conflictNote string) error {
+	var invoiceIdValue sql.NullInt64
+	if invoiceId != 0 {
+		invoiceIdValue = sql.NullInt64{Int64: int64(invoiceId), Valid: true}
+	}
+
+	query := `UPDATE offline_invoice SET invoice_id = ?, status = ?, conflict_note = ?, synced_at = ? 
+				WHERE client_uuid = ? AND status = ?`
+
+	result, err := s.db.Exec(query, invoiceIdValue, status, conflictNote, time.Now(),
+		clientUuid, constants.OFFLINE_INVOICE_STATUS_PENDING)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return fmt.Errorf("offline invoice %s is not pending", clientUuid)
+	}
+
+	return nil
+}
+
+// only the pending claim is removed, so the terminal can push it again
+func (s *Store) DeleteOfflineInvoice(clientUuid string) error {
+	query := "DELETE FROM offline_invoice WHERE client_uuid = ? AND status = ?"
+	_, err := s.db.Exec(query, clientUuid, constants.OFFLINE_INVOICE_STATUS_PENDING)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func scanRowIntoOfflineInvoice(rows *sql.Rows) (*types.OfflineInvoice, error) {
+	offlineInvoice := new(types.OfflineInvoice)
+
+	var invoiceId sql.NullInt64
+
+	err := rows.Scan(
+		&offlineInvoice.ID,
+		&offlineInvoice.ClientUUID,
+		&offlineInvoice.TerminalID,
+		&offlineInvoice.ClientNumber,
+		&invoiceId,
+		&offlineInvoice.Status,
+		&offlineInvoice.ConflictNote,
+		&offlineInvoice.BranchID,
+		&offlineInvoice.UserID,
+		&offlineInvoice.SyncedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	offlineInvoice.InvoiceID = int(invoiceId.Int64)
+	offlineInvoice.SyncedAt = offlineInvoice.SyncedAt.Local()
+
+	return offlineInvoice, nil
+}
@@ -0,0 +1,354 @@
+package tax
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+)
+
+type Handler struct {
+	taxStore  types.TaxStore
+	userStore types.UserStore
+}
+
+func NewHandler(taxStore types.TaxStore, userStore types.UserStore) *Handler {
+	return &Handler{taxStore: taxStore, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tax", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/tax/{val}", h.handleGetAll).Methods(http.MethodGet)
+	router.HandleFunc("/tax/detail", h.handleGetOne).Methods(http.MethodPost)
+	router.HandleFunc("/tax", h.handleDelete).Methods(http.MethodDelete)
+	router.HandleFunc("/tax", h.handleModify).Methods(http.MethodPatch)
+	router.HandleFunc("/tax/report", h.handleGetReport).Methods(http.MethodPost)
+
+	router.HandleFunc("/tax", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/tax/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/tax/detail", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/tax/report", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.RegisterTaxPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	effectiveDate, err := utils.ParseDate(payload.EffectiveDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	// check if the tax with the same effective date exists
+	taxes, err := h.taxStore.GetTaxesByCode(payload.Code)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, tax := range taxes {
+		if tax.EffectiveDate.Equal(*effectiveDate) {
+			utils.WriteError(w, http.StatusBadRequest,
+				fmt.Errorf("tax %s effective on %s already exists", payload.Code, payload.EffectiveDate))
+			return
+		}
+	}
+
+	err = h.taxStore.CreateTax(types.Tax{
+		Code:                 payload.Code,
+		Name:                 payload.Name,
+		Percentage:           payload.Percentage,
+		EffectiveDate:        *effectiveDate,
+		Description:          payload.Description,
+		LastModifiedByUserID: user.ID,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("tax %s successfully created by %s", payload.Code, user.Name))
+}
+
+func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	vars := mux.Vars(r)
+	val := vars["val"]
+
+	var taxes []types.Tax
+
+	if val == "all" {
+		taxes, err = h.taxStore.GetAllTaxes()
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		id, err := strconv.Atoi(val)
+		if err != nil {
+			taxes, err = h.taxStore.GetTaxesByCode(val)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("tax %s not found", val))
+				return
+			}
+		} else {
+			tax, err := h.taxStore.GetTaxByID(id)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("tax id %d not found", id))
+				return
+			}
+
+			taxes = append(taxes, *tax)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, taxes)
+}
+
+func (h *Handler) handleGetOne(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.GetOneTaxPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	_, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// get tax data
+	tax, err := h.taxStore.GetTaxByID(payload.ID)
+	if tax == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("tax id %d doesn't exist", payload.ID))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tax)
+}
+
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DeleteTaxPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	// check if the tax exists
+	tax, err := h.taxStore.GetTaxByID(payload.ID)
+	if tax == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("tax id %d doesn't exist", payload.ID))
+		return
+	}
+
+	err = h.taxStore.DeleteTax(tax, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("tax %s deleted by %s", tax.Code, user.Name))
+}
+
+func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ModifyTaxPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	// check if the tax exists
+	tax, err := h.taxStore.GetTaxByID(payload.ID)
+	if err != nil || tax == nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("tax with id %d doesn't exists", payload.ID))
+		return
+	}
+
+	effectiveDate, err := utils.ParseDate(payload.NewData.EffectiveDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	taxes, err := h.taxStore.GetTaxesByCode(payload.NewData.Code)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, existingTax := range taxes {
+		if existingTax.ID != tax.ID && existingTax.EffectiveDate.Equal(*effectiveDate) {
+			utils.WriteError(w, http.StatusBadRequest,
+				fmt.Errorf("tax %s effective on %s already exists", payload.NewData.Code, payload.NewData.EffectiveDate))
+			return
+		}
+	}
+
+	err = h.taxStore.ModifyTax(tax.ID, types.Tax{
+		Code:          payload.NewData.Code,
+		Name:          payload.NewData.Name,
+		Percentage:    payload.NewData.Percentage,
+		EffectiveDate: *effectiveDate,
+		Description:   payload.NewData.Description,
+	}, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("tax %s modified by %s",
+		payload.NewData.Code, user.Name))
+}
+
+func (h *Handler) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewTaxReportPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	startDate, err := utils.ParseStartDate(payload.StartDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	endDate, err := utils.ParseEndDate(payload.EndDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	outputTaxItems, err := h.taxStore.GetOutputTaxReport(*startDate, *endDate, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get output tax: %v", err))
+		return
+	}
+
+	inputTaxItems, err := h.taxStore.GetInputTaxReport(*startDate, *endDate, branchId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error get input tax: %v", err))
+		return
+	}
+
+	report := types.TaxReportReturnPayload{
+		StartDate: *startDate,
+		EndDate:   *endDate,
+		OutputTax: summarizeTaxReport(outputTaxItems),
+		InputTax:  summarizeTaxReport(inputTaxItems),
+	}
+	report.NetTaxAmount = report.OutputTax.TotalTaxAmount - report.InputTax.TotalTaxAmount
+
+	utils.WriteJSON(w, http.StatusOK, report)
+}
+
+func summarizeTaxReport(items []types.TaxReportItem) types.TaxReportSummary {
+	summary := types.TaxReportSummary{
+		Items: items,
+	}
+
+	for _, item := range items {
+		summary.TotalTaxableAmount += item.TaxableAmount
+		summary.TotalTaxAmount += item.TaxAmount
+	}
+
+	return summary
+}
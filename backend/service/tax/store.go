@@ -0,0 +1,292 @@
+package tax
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetTaxByID(id int) (*types.Tax, error) {
+	query := "SELECT * FROM tax WHERE id = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tax := new(types.Tax)
+
+	for rows.Next() {
+		tax, err = scanRowIntoTax(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tax.ID == 0 {
+		return nil, fmt.Errorf("tax not found")
+	}
+
+	return tax, nil
+}
+
+func (s *Store) GetTaxesByCode(code string) ([]types.Tax, error) {
+	query := "SELECT * FROM tax WHERE code = ? AND deleted_at IS NULL ORDER BY effective_date DESC"
+	rows, err := s.db.Query(query, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxes := make([]types.Tax, 0)
+
+	for rows.Next() {
+		tax, err := scanRowIntoTax(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		taxes = append(taxes, *tax)
+	}
+
+	return taxes, nil
+}
+
+func (s *Store) GetEffectiveTaxByCode(code string, date time.Time) (*types.Tax, error) {
+	query := `SELECT * FROM tax
+				WHERE code = ? AND effective_date <= ?
+				AND deleted_at IS NULL
+				ORDER BY effective_date DESC LIMIT 1`
+	rows, err := s.db.Query(query, code, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tax := new(types.Tax)
+
+	for rows.Next() {
+		tax, err = scanRowIntoTax(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tax.ID == 0 {
+		return nil, fmt.Errorf("tax not found")
+	}
+
+	return tax, nil
+}
+
+func (s *Store) CreateTax(tax types.Tax) error {
+	values := "?"
+	for i := 0; i < 5; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO tax (
+		code, name, percentage, effective_date, description, last_modified_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		tax.Code, tax.Name, tax.Percentage, tax.EffectiveDate, tax.Description,
+		tax.LastModifiedByUserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetAllTaxes() ([]types.Tax, error) {
+	rows, err := s.db.Query("SELECT * FROM tax WHERE deleted_at IS NULL ORDER BY code ASC, effective_date DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxes := make([]types.Tax, 0)
+
+	for rows.Next() {
+		tax, err := scanRowIntoTax(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		taxes = append(taxes, *tax)
+	}
+
+	return taxes, nil
+}
+
+func (s *Store) DeleteTax(tax *types.Tax, user *types.User) error {
+	data, err := s.GetTaxByID(tax.ID)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("delete", "tax", user.Name, data.ID, data)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := "UPDATE tax SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
+	_, err = s.db.Exec(query, time.Now(), user.ID, tax.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) ModifyTax(id int, tax types.Tax, user *types.User) error {
+	data, err := s.GetTaxByID(id)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("modify", "tax", user.Name, data.ID, map[string]interface{}{"previous_data": data})
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE tax SET
+		code = ?, name = ?, percentage = ?, effective_date = ?, description = ?,
+		last_modified = ?, last_modified_by_user_id = ?
+	WHERE id = ?`
+
+	_, err = s.db.Exec(query,
+		tax.Code, tax.Name, tax.Percentage, tax.EffectiveDate, tax.Description,
+		time.Now(), user.ID, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetOutputTaxReport(startDate time.Time, endDate time.Time, branchId int) ([]types.TaxReportItem, error) {
+	query := `SELECT invoice.id, invoice.number, customer.name, invoice.invoice_date,
+					invoice.taxable_amount,
+					invoice.tax_percentage, invoice.tax_amount
+					FROM invoice
+					JOIN customer ON customer.id = invoice.customer_id
+					WHERE invoice.invoice_date >= ? AND invoice.invoice_date < ?
+					AND invoice.deleted_at IS NULL
+					AND (? = 0 OR invoice.branch_id = ?)
+				ORDER BY invoice.invoice_date ASC`
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]types.TaxReportItem, 0)
+
+	for rows.Next() {
+		item, err := scanRowIntoTaxReportItem(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, *item)
+	}
+
+	return items, nil
+}
+
+func (s *Store) GetInputTaxReport(startDate time.Time, endDate time.Time, branchId int) ([]types.TaxReportItem, error) {
+	query := `SELECT pi.id, pi.number, supplier.name, pi.invoice_date,
+					pi.taxable_amount,
+					pi.tax_percentage, pi.tax_amount
+					FROM purchase_invoice AS pi
+					JOIN supplier ON supplier.id = pi.supplier_id
+					WHERE pi.invoice_date >= ? AND pi.invoice_date < ?
+					AND pi.deleted_at IS NULL
+					AND (? = 0 OR pi.branch_id = ?)
+				ORDER BY pi.invoice_date ASC`
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]types.TaxReportItem, 0)
+
+	for rows.Next() {
+		item, err := scanRowIntoTaxReportItem(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, *item)
+	}
+
+	return items, nil
+}
+
+func scanRowIntoTax(rows *sql.Rows) (*types.Tax, error) {
+	tax := new(types.Tax)
+
+	err := rows.Scan(
+		&tax.ID,
+		&tax.Code,
+		&tax.Name,
+		&tax.Percentage,
+		&tax.EffectiveDate,
+		&tax.Description,
+		&tax.CreatedAt,
+		&tax.LastModified,
+		&tax.LastModifiedByUserID,
+		&tax.DeletedAt,
+		&tax.DeletedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tax.EffectiveDate = tax.EffectiveDate.Local()
+	tax.CreatedAt = tax.CreatedAt.Local()
+	tax.LastModified = tax.LastModified.Local()
+
+	return tax, nil
+}
+
+func scanRowIntoTaxReportItem(rows *sql.Rows) (*types.TaxReportItem, error) {
+	item := new(types.TaxReportItem)
+
+	err := rows.Scan(
+		&item.ID,
+		&item.Number,
+		&item.PartyName,
+		&item.InvoiceDate,
+		&item.TaxableAmount,
+		&item.TaxPercentage,
+		&item.TaxAmount,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	item.InvoiceDate = item.InvoiceDate.Local()
+
+	return item, nil
+}
@@ -0,0 +1,946 @@
+package transfer
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+	"github.com/nicolaics/pharmacon/utils/pdf"
+)
+
+type Handler struct {
+	stockTransferStore types.StockTransferStore
+	userStore          types.UserStore
+	branchStore        types.BranchStore
+	medStore           types.MedicineStore
+	unitStore          types.UnitStore
+	registerStore      types.ControlledSubstanceStore
+	settingStore       types.SettingStore
+	documentStorage    types.DocumentStorage
+}
+
+func NewHandler(stockTransferStore types.StockTransferStore, userStore types.UserStore,
+	branchStore types.BranchStore, medStore types.MedicineStore, unitStore types.UnitStore,
+	registerStore types.ControlledSubstanceStore, settingStore types.SettingStore,
+	documentStorage types.DocumentStorage) *Handler {
+	return &Handler{
+		stockTransferStore: stockTransferStore,
+		userStore:          userStore,
+		branchStore:        branchStore,
+		medStore:           medStore,
+		unitStore:          unitStore,
+		registerStore:      registerStore,
+		settingStore:       settingStore,
+		documentStorage:    documentStorage,
+	}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/stock-transfer", h.handleRegister).Methods(http.MethodPost)
+	router.HandleFunc("/stock-transfer", h.handleGetStockTransferNumber).Methods(http.MethodGet)
+	router.HandleFunc("/stock-transfer/{params}/{val}", h.handleGetStockTransfers).Methods(http.MethodPost)
+	router.HandleFunc("/stock-transfer/detail", h.handleGetStockTransferDetail).Methods(http.MethodPost)
+	router.HandleFunc("/stock-transfer/dispatch", h.handleDispatch).Methods(http.MethodPatch)
+	router.HandleFunc("/stock-transfer/receive", h.handleReceive).Methods(http.MethodPatch)
+	router.HandleFunc("/stock-transfer", h.handleDelete).Methods(http.MethodDelete)
+	router.HandleFunc("/stock-transfer/print", h.handlePrint).Methods(http.MethodPost)
+
+	router.HandleFunc("/stock-transfer", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/stock-transfer/{params}/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/stock-transfer/detail", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/stock-transfer/dispatch", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/stock-transfer/receive", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+	router.HandleFunc("/stock-transfer/print", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+}
+
+// the caller's branch requests the stock from another branch
+func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.RegisterStockTransferPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	if payload.FromBranchID == user.BranchID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cannot request stock from the own branch"))
+		return
+	}
+
+	_, err = h.branchStore.GetBranchByID(payload.FromBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch id %d not found", payload.FromBranchID))
+		return
+	}
+
+	requestDate, err := utils.ParseDate(payload.RequestDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	// check duplicate
+	stockTransferId, err := h.stockTransferStore.GetStockTransferID(payload.Number, payload.FromBranchID, user.BranchID, *requestDate)
+	if err == nil || stockTransferId != 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d exists", payload.Number))
+		return
+	}
+
+	newStockTransfer := types.StockTransfer{
+		Number:               payload.Number,
+		FromBranchID:         payload.FromBranchID,
+		ToBranchID:           user.BranchID,
+		Status:               constants.TRANSFER_STATUS_REQUESTED,
+		RequestDate:          *requestDate,
+		Description:          payload.Description,
+		UserID:               user.ID,
+		LastModifiedByUserID: user.ID,
+	}
+
+	err = h.stockTransferStore.CreateStockTransfer(newStockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// get stock transfer ID
+	stockTransferId, err = h.stockTransferStore.GetStockTransferID(payload.Number, payload.FromBranchID, user.BranchID, *requestDate)
+	if err != nil {
+		errDel := h.stockTransferStore.AbsoluteDeleteStockTransfer(newStockTransfer)
+		if errDel != nil {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete stock transfer: %v", errDel))
+			return
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d doesn't exists: %v", payload.Number, err))
+		return
+	}
+
+	for _, medicine := range payload.MedicineLists {
+		medData, err := h.medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
+		if err != nil {
+			errDel := h.stockTransferStore.AbsoluteDeleteStockTransfer(newStockTransfer)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete stock transfer: %v", errDel))
+				return
+			}
+
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("medicine %s doesn't exists", medicine.MedicineName))
+			return
+		}
+
+		unit, err := h.unitStore.GetUnitByName(medicine.Unit)
+		if err != nil || unit == nil {
+			errDel := h.stockTransferStore.AbsoluteDeleteStockTransfer(newStockTransfer)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete stock transfer: %v", errDel))
+				return
+			}
+
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unit %s doesn't exists", medicine.Unit))
+			return
+		}
+
+		err = h.stockTransferStore.CreateStockTransferItem(types.StockTransferItem{
+			StockTransferID: stockTransferId,
+			MedicineID:      medData.ID,
+			RequestQty:      medicine.RequestQty,
+			UnitID:          unit.ID,
+			Remarks:         medicine.Remarks,
+		})
+		if err != nil {
+			errDel := h.stockTransferStore.AbsoluteDeleteStockTransfer(newStockTransfer)
+			if errDel != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("error absolute delete stock transfer: %v", errDel))
+				return
+			}
+
+			utils.WriteError(w, http.StatusInternalServerError,
+				fmt.Errorf("stock transfer %d, med %s: %v", payload.Number, medicine.MedicineName, err))
+			return
+		}
+	}
+
+	stockTransfer, err := h.stockTransferStore.GetStockTransferByID(stockTransferId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = createStockTransferPdf(h, stockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("saved in database but failed to create pdf: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, fmt.Sprintf("stock transfer %d successfully requested by %s", payload.Number, user.Name))
+}
+
+// beginning of stock transfer page, will request here
+func (h *Handler) handleGetStockTransferNumber(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	numberOfStockTransfers, err := h.stockTransferStore.GetNumberOfStockTransfers(user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]int{"nextNumber": (numberOfStockTransfers + 1)})
+}
+
+// only view the stock transfer list
+func (h *Handler) handleGetStockTransfers(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewStockTransferPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	branchId, err := utils.GetBranchScope(user, payload.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	params := vars["params"]
+	val := vars["val"]
+
+	startDate, err := utils.ParseStartDate(payload.StartDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	endDate, err := utils.ParseEndDate(payload.EndDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	var stockTransfers []types.StockTransferListsReturnPayload
+
+	if val == "all" {
+		stockTransfers, err = h.stockTransferStore.GetStockTransfersByDate(*startDate, *endDate, branchId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else if params == "id" {
+		id, err := strconv.Atoi(val)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		stockTransfer, err := h.stockTransferStore.GetStockTransferByID(id)
+		if err != nil || (branchId != constants.BRANCH_ALL && stockTransfer.FromBranchID != branchId && stockTransfer.ToBranchID != branchId) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer id %d not exist", id))
+			return
+		}
+
+		fromBranch, err := h.branchStore.GetBranchByID(stockTransfer.FromBranchID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("branch id %d not found", stockTransfer.FromBranchID))
+			return
+		}
+
+		toBranch, err := h.branchStore.GetBranchByID(stockTransfer.ToBranchID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("branch id %d not found", stockTransfer.ToBranchID))
+			return
+		}
+
+		user, err := h.userStore.GetUserByID(stockTransfer.UserID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("user id %d not found", stockTransfer.UserID))
+			return
+		}
+
+		stockTransfers = append(stockTransfers, types.StockTransferListsReturnPayload{
+			ID:             stockTransfer.ID,
+			Number:         stockTransfer.Number,
+			FromBranchName: fromBranch.Name,
+			ToBranchName:   toBranch.Name,
+			Status:         stockTransfer.Status,
+			UserName:       user.Name,
+			RequestDate:    stockTransfer.RequestDate,
+		})
+	} else if params == "number" {
+		number, err := strconv.Atoi(val)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		stockTransfers, err = h.stockTransferStore.GetStockTransfersByDateAndNumber(*startDate, *endDate, number, branchId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else if params == "status" {
+		stockTransfers, err = h.stockTransferStore.GetStockTransfersByDateAndStatus(*startDate, *endDate, strings.ToUpper(val), branchId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("params undefined"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, stockTransfers)
+}
+
+func (h *Handler) handleGetStockTransferDetail(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewStockTransferDetailPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// get stock transfer data
+	stockTransfer, err := h.stockTransferStore.GetStockTransferByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer id %d doesn't exists", payload.ID))
+		return
+	}
+
+	err = checkStockTransferAccess(user, stockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	stockTransferItems, err := getStockTransferItems(h, stockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	fromBranch, err := h.branchStore.GetBranchByID(stockTransfer.FromBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch id %d doesn't exists", stockTransfer.FromBranchID))
+		return
+	}
+
+	toBranch, err := h.branchStore.GetBranchByID(stockTransfer.ToBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("branch id %d doesn't exists", stockTransfer.ToBranchID))
+		return
+	}
+
+	// get the user who requests the transfer
+	requestedBy, err := h.userStore.GetUserByID(stockTransfer.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user id %d doesn't exists", stockTransfer.UserID))
+		return
+	}
+
+	// get last modified user
+	lastModifiedUser, err := h.userStore.GetUserByID(stockTransfer.LastModifiedByUserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user id %d doesn't exists", stockTransfer.LastModifiedByUserID))
+		return
+	}
+
+	dispatchedByUserName, err := getUserName(h, stockTransfer.DispatchedByUserID.Valid, int(stockTransfer.DispatchedByUserID.Int64))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	receivedByUserName, err := getUserName(h, stockTransfer.ReceivedByUserID.Valid, int(stockTransfer.ReceivedByUserID.Int64))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	returnPayload := types.StockTransferDetailPayload{
+		ID:                     stockTransfer.ID,
+		Number:                 stockTransfer.Number,
+		Status:                 stockTransfer.Status,
+		RequestDate:            stockTransfer.RequestDate,
+		DispatchDate:           getNullTime(stockTransfer.DispatchDate.Valid, stockTransfer.DispatchDate.Time),
+		ReceiptDate:            getNullTime(stockTransfer.ReceiptDate.Valid, stockTransfer.ReceiptDate.Time),
+		Description:            stockTransfer.Description,
+		CreatedAt:              stockTransfer.CreatedAt,
+		LastModified:           stockTransfer.LastModified,
+		LastModifiedByUserName: lastModifiedUser.Name,
+		PdfURL:                 stockTransfer.PdfURL,
+
+		FromBranch: *fromBranch,
+		ToBranch:   *toBranch,
+
+		RequestedByUserName:  requestedBy.Name,
+		DispatchedByUserName: dispatchedByUserName,
+		ReceivedByUserName:   receivedByUserName,
+
+		MedicineLists: stockTransferItems,
+	}
+
+	utils.WriteJSON(w, http.StatusOK, returnPayload)
+}
+
+// the sending branch dispatches the requested items, the stock is subtracted from the sending branch
+func (h *Handler) handleDispatch(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DispatchStockTransferPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	stockTransfer, err := h.stockTransferStore.GetStockTransferByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer id %d doesn't exists", payload.ID))
+		return
+	}
+
+	err = utils.CheckBranchAccess(user, stockTransfer.FromBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if stockTransfer.Status != constants.TRANSFER_STATUS_REQUESTED {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d is already %s", stockTransfer.Number, strings.ToLower(stockTransfer.Status)))
+		return
+	}
+
+	dispatchDate, err := utils.ParseDate(payload.DispatchDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	stockItems, err := getStockTransferStockItems(h, stockTransfer.ID, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// check all of the stock first, so nothing is subtracted if one of them is not enough.
+	// the same medicine can be requested in more than one item, so the stock is checked on the total
+	dispatchStockItems := make([]types.MedicineStockItem, 0)
+
+	for _, item := range payload.Items {
+		stockItem, ok := stockItems[item.ID]
+		if !ok {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer item id %d doesn't exists", item.ID))
+			return
+		}
+
+		if (item.DispatchQty - stockItem.Qty) > constants.PRESC_QTY_TOLERANCE {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("dispatch qty %g of %s is more than the requested qty %g",
+				item.DispatchQty, stockItem.Medicine.Name, stockItem.Qty))
+			return
+		}
+
+		dispatchStockItems = append(dispatchStockItems, types.MedicineStockItem{
+			Medicine: stockItem.Medicine,
+			Unit:     stockItem.Unit,
+			Qty:      item.DispatchQty,
+		})
+	}
+
+	err = utils.CheckStockItems(h.medStore, dispatchStockItems, stockTransfer.FromBranchID, nil)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	updated, err := h.stockTransferStore.UpdateDispatch(stockTransfer.ID, *dispatchDate, payload.Items, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !updated {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d is already dispatched", stockTransfer.Number))
+		return
+	}
+
+	toBranch, err := h.branchStore.GetBranchByID(stockTransfer.ToBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("branch id %d not found", stockTransfer.ToBranchID))
+		return
+	}
+
+	for _, item := range payload.Items {
+		stockItem := stockItems[item.ID]
+
+		err = utils.SubtractStock(h.medStore, stockItem.Medicine, stockItem.Unit, item.DispatchQty, stockTransfer.FromBranchID, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error subtracting stock: %v", err))
+			return
+		}
+
+		err = utils.RecordControlledSubstanceOut(h.registerStore, stockItem.Medicine, stockItem.Unit, item.DispatchQty, types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_TRANSFER,
+			ReferenceID:     stockTransfer.ID,
+			ReferenceNumber: stockTransfer.Number,
+			TransactionDate: *dispatchDate,
+			PartyName:       toBranch.Name,
+			UserID:          user.ID,
+			BranchID:        stockTransfer.FromBranchID,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+			return
+		}
+	}
+
+	stockTransfer, err = h.stockTransferStore.GetStockTransferByID(stockTransfer.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = createStockTransferPdf(h, stockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("saved in database but failed to create pdf: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("stock transfer number %d dispatched by %s", stockTransfer.Number, user.Name))
+}
+
+// the requesting branch receives the dispatched items, the stock is added to the requesting branch
+func (h *Handler) handleReceive(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ReceiveStockTransferPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	stockTransfer, err := h.stockTransferStore.GetStockTransferByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer id %d doesn't exists", payload.ID))
+		return
+	}
+
+	err = utils.CheckBranchAccess(user, stockTransfer.ToBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if stockTransfer.Status != constants.TRANSFER_STATUS_DISPATCHED {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d is not dispatched", stockTransfer.Number))
+		return
+	}
+
+	receiptDate, err := utils.ParseDate(payload.ReceiptDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error parsing date"))
+		return
+	}
+
+	stockItems, err := getStockTransferStockItems(h, stockTransfer.ID, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// only what was dispatched can be received, the rest is the discrepancy
+	for _, item := range payload.Items {
+		stockItem, ok := stockItems[item.ID]
+		if !ok {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer item id %d doesn't exists", item.ID))
+			return
+		}
+
+		if (item.ReceivedQty - stockItem.Qty) > constants.PRESC_QTY_TOLERANCE {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("received qty %g of %s is more than the dispatched qty %g",
+				item.ReceivedQty, stockItem.Medicine.Name, stockItem.Qty))
+			return
+		}
+	}
+
+	updated, err := h.stockTransferStore.UpdateReceipt(stockTransfer.ID, *receiptDate, payload.Items, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !updated {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d is already received", stockTransfer.Number))
+		return
+	}
+
+	fromBranch, err := h.branchStore.GetBranchByID(stockTransfer.FromBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("branch id %d not found", stockTransfer.FromBranchID))
+		return
+	}
+
+	for _, item := range payload.Items {
+		stockItem := stockItems[item.ID]
+
+		err = utils.AddStock(h.medStore, stockItem.Medicine, stockItem.Unit, item.ReceivedQty, stockTransfer.ToBranchID, user)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error updating stock: %v", err))
+			return
+		}
+
+		err = utils.RecordControlledSubstanceIn(h.registerStore, stockItem.Medicine, stockItem.Unit, item.ReceivedQty, types.ControlledSubstanceRegister{
+			TransactionType: constants.CONTROLLED_TRANSACTION_TRANSFER,
+			ReferenceID:     stockTransfer.ID,
+			ReferenceNumber: stockTransfer.Number,
+			TransactionDate: *receiptDate,
+			PartyName:       fromBranch.Name,
+			UserID:          user.ID,
+			BranchID:        stockTransfer.ToBranchID,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error recording controlled substance: %v", err))
+			return
+		}
+	}
+
+	stockTransfer, err = h.stockTransferStore.GetStockTransferByID(stockTransfer.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = createStockTransferPdf(h, stockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("saved in database but failed to create pdf: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("stock transfer number %d received by %s", stockTransfer.Number, user.Name))
+}
+
+// only the transfer that is not dispatched yet can be deleted
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DeleteStockTransferPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid or not admin: %v", err))
+		return
+	}
+
+	stockTransfer, err := h.stockTransferStore.GetStockTransferByID(payload.ID)
+	if stockTransfer == nil || err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("stock transfer id %d doesn't exist", payload.ID))
+		return
+	}
+
+	err = utils.CheckBranchAccess(user, stockTransfer.ToBranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if stockTransfer.Status != constants.TRANSFER_STATUS_REQUESTED {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("stock transfer number %d is already %s", stockTransfer.Number, strings.ToLower(stockTransfer.Status)))
+		return
+	}
+
+	err = h.stockTransferStore.DeleteStockTransfer(stockTransfer, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("stock transfer number %d deleted by %s", stockTransfer.Number, user.Name))
+}
+
+func (h *Handler) handlePrint(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ViewStockTransferDetailPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.userStore.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	// check if the stock transfer exists
+	stockTransfer, err := h.stockTransferStore.GetStockTransferByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest,
+			fmt.Errorf("stock transfer with id %d doesn't exists", payload.ID))
+		return
+	}
+
+	err = checkStockTransferAccess(user, stockTransfer)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	utils.WriteDocument(w, r, h.documentStorage, constants.DOCUMENT_STOCK_TRANSFER, stockTransfer.PdfURL)
+}
+
+// both the sending and the requesting branch can open the transfer
+func checkStockTransferAccess(user *types.User, stockTransfer *types.StockTransfer) error {
+	err := utils.CheckBranchAccess(user, stockTransfer.FromBranchID)
+	if err == nil {
+		return nil
+	}
+
+	return utils.CheckBranchAccess(user, stockTransfer.ToBranchID)
+}
+
+// the discrepancy is only known after the transfer is received
+func getStockTransferItems(h *Handler, stockTransfer *types.StockTransfer) ([]types.StockTransferItemReturn, error) {
+	stockTransferItems, err := h.stockTransferStore.GetStockTransferItem(stockTransfer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stockTransfer.Status == constants.TRANSFER_STATUS_RECEIVED {
+		for i := range stockTransferItems {
+			stockTransferItems[i].DiscrepancyQty = stockTransferItems[i].DispatchQty - stockTransferItems[i].ReceivedQty
+		}
+	}
+
+	return stockTransferItems, nil
+}
+
+// the medicine, unit and qty of every item, keyed by the stock transfer item id.
+// the qty is the requested qty before the dispatch, and the dispatched qty after it
+func getStockTransferStockItems(h *Handler, stockTransferId int, requested bool) (map[int]types.MedicineStockItem, error) {
+	stockTransferItems, err := h.stockTransferStore.GetStockTransferItem(stockTransferId)
+	if err != nil {
+		return nil, err
+	}
+
+	stockItems := make(map[int]types.MedicineStockItem)
+
+	for _, item := range stockTransferItems {
+		medData, err := h.medStore.GetMedicineByBarcode(item.MedicineBarcode)
+		if err != nil {
+			return nil, fmt.Errorf("medicine %s doesn't exists", item.MedicineName)
+		}
+
+		unit, err := h.unitStore.GetUnitByName(item.Unit)
+		if err != nil || unit == nil {
+			return nil, fmt.Errorf("unit %s doesn't exists", item.Unit)
+		}
+
+		qty := item.DispatchQty
+		if requested {
+			qty = item.RequestQty
+		}
+
+		stockItems[item.ID] = types.MedicineStockItem{
+			Medicine: medData,
+			Unit:     unit,
+			Qty:      qty,
+		}
+	}
+
+	return stockItems, nil
+}
+
+func getUserName(h *Handler, valid bool, userId int) (string, error) {
+	if !valid {
+		return "", nil
+	}
+
+	user, err := h.userStore.GetUserByID(userId)
+	if err != nil {
+		return "", fmt.Errorf("user id %d doesn't exists", userId)
+	}
+
+	return user.Name, nil
+}
+
+func getNullTime(valid bool, t time.Time) *time.Time {
+	if !valid {
+		return nil
+	}
+
+	return &t
+}
+
+func createStockTransferPdf(h *Handler, stockTransfer *types.StockTransfer) error {
+	stockTransferItems, err := getStockTransferItems(h, stockTransfer)
+	if err != nil {
+		return err
+	}
+
+	fromBranch, err := h.branchStore.GetBranchByID(stockTransfer.FromBranchID)
+	if err != nil {
+		return fmt.Errorf("branch id %d doesn't exists", stockTransfer.FromBranchID)
+	}
+
+	toBranch, err := h.branchStore.GetBranchByID(stockTransfer.ToBranchID)
+	if err != nil {
+		return fmt.Errorf("branch id %d doesn't exists", stockTransfer.ToBranchID)
+	}
+
+	requestedBy, err := h.userStore.GetUserByID(stockTransfer.UserID)
+	if err != nil {
+		return fmt.Errorf("user id %d doesn't exists", stockTransfer.UserID)
+	}
+
+	dispatchedByUserName, err := getUserName(h, stockTransfer.DispatchedByUserID.Valid, int(stockTransfer.DispatchedByUserID.Int64))
+	if err != nil {
+		return err
+	}
+
+	receivedByUserName, err := getUserName(h, stockTransfer.ReceivedByUserID.Valid, int(stockTransfer.ReceivedByUserID.Int64))
+	if err != nil {
+		return err
+	}
+
+	stockTransferPdf := types.StockTransferPDFPayload{
+		Number:               stockTransfer.Number,
+		Status:               stockTransfer.Status,
+		RequestDate:          stockTransfer.RequestDate,
+		DispatchDate:         getNullTime(stockTransfer.DispatchDate.Valid, stockTransfer.DispatchDate.Time),
+		ReceiptDate:          getNullTime(stockTransfer.ReceiptDate.Valid, stockTransfer.ReceiptDate.Time),
+		Description:          stockTransfer.Description,
+		RequestedByUserName:  requestedBy.Name,
+		DispatchedByUserName: dispatchedByUserName,
+		ReceivedByUserName:   receivedByUserName,
+		MedicineLists:        stockTransferItems,
+		FromBranch:           *fromBranch,
+		ToBranch:             *toBranch,
+	}
+
+	// the transfer note is issued under the sending branch
+	branding, err := utils.GetDocumentBranding(h.settingStore, h.branchStore, h.documentStorage, constants.SETTING_DOCUMENT_STOCK_TRANSFER, stockTransfer.FromBranchID)
+	if err != nil {
+		return fmt.Errorf("error get document setting: %v", err)
+	}
+
+	fileName, err := pdf.CreateStockTransferPDF(h.stockTransferStore, stockTransferPdf, stockTransfer.PdfURL, branding, h.documentStorage)
+	if err != nil {
+		return err
+	}
+
+	err = h.stockTransferStore.UpdatePDFUrl(stockTransfer.ID, fileName)
+	if err != nil {
+		return fmt.Errorf("error update pdf in database: %v", err)
+	}
+
+	return nil
+}
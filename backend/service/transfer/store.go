@@ -0,0 +1,539 @@
+package transfer
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/logger"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetStockTransferByID(id int) (*types.StockTransfer, error) {
+	query := "SELECT * FROM stock_transfer WHERE id = ? AND deleted_at IS NULL"
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTransfer := new(types.StockTransfer)
+
+	for rows.Next() {
+		stockTransfer, err = scanRowIntoStockTransfer(rows)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stockTransfer.ID == 0 {
+		return nil, fmt.Errorf("stock transfer not found")
+	}
+
+	return stockTransfer, nil
+}
+
+func (s *Store) GetStockTransferID(number int, fromBranchId int, toBranchId int, requestDate time.Time) (int, error) {
+	query := `SELECT id FROM stock_transfer
+				WHERE number = ?
+				AND from_branch_id = ? AND to_branch_id = ?
+				AND request_date = ? AND deleted_at IS NULL
+				ORDER BY request_date DESC`
+
+	rows, err := s.db.Query(query, number, fromBranchId, toBranchId, requestDate)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var stockTransferId int
+
+	for rows.Next() {
+		err = rows.Scan(&stockTransferId)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if stockTransferId == 0 {
+		return 0, fmt.Errorf("stock transfer not found")
+	}
+
+	return stockTransferId, nil
+}
+
+func (s *Store) GetNumberOfStockTransfers(toBranchId int) (int, error) {
+	query := `SELECT COUNT(*) FROM stock_transfer WHERE to_branch_id = ?`
+	row := s.db.QueryRow(query, toBranchId)
+	if row.Err() != nil {
+		return -1, row.Err()
+	}
+
+	var numberOfStockTransfers int
+
+	err := row.Scan(&numberOfStockTransfers)
+	if err != nil {
+		return -1, err
+	}
+
+	return numberOfStockTransfers, nil
+}
+
+func (s *Store) CreateStockTransfer(stockTransfer types.StockTransfer) error {
+	values := "?"
+	for i := 0; i < 7; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO stock_transfer (
+		number, from_branch_id, to_branch_id, status,
+		request_date, description, user_id, last_modified_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		stockTransfer.Number, stockTransfer.FromBranchID, stockTransfer.ToBranchID,
+		stockTransfer.Status, stockTransfer.RequestDate, stockTransfer.Description,
+		stockTransfer.UserID, stockTransfer.LastModifiedByUserID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateStockTransferItem(stockTransferItem types.StockTransferItem) error {
+	values := "?"
+	for i := 0; i < 4; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO stock_transfer_item (
+		stock_transfer_id, medicine_id, request_qty, unit_id, remarks
+	) VALUES (` + values + `)`
+
+	_, err := s.db.Exec(query,
+		stockTransferItem.StockTransferID, stockTransferItem.MedicineID,
+		stockTransferItem.RequestQty, stockTransferItem.UnitID, stockTransferItem.Remarks)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetStockTransfersByDate(startDate time.Time, endDate time.Time, branchId int) ([]types.StockTransferListsReturnPayload, error) {
+	query := `SELECT st.id, st.number,
+					fb.name, tb.name,
+					st.status, user.name, st.request_date
+					FROM stock_transfer AS st
+					JOIN branch AS fb ON st.from_branch_id = fb.id
+					JOIN branch AS tb ON st.to_branch_id = tb.id
+					JOIN user ON st.user_id = user.id
+					WHERE st.request_date >= ? AND st.request_date < ?
+					AND st.deleted_at IS NULL
+					AND (? = 0 OR st.from_branch_id = ? OR st.to_branch_id = ?)
+					ORDER BY st.request_date DESC`
+
+	rows, err := s.db.Query(query, startDate, endDate, branchId, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTransfers := make([]types.StockTransferListsReturnPayload, 0)
+
+	for rows.Next() {
+		stockTransfer, err := scanRowIntoStockTransferLists(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stockTransfers = append(stockTransfers, *stockTransfer)
+	}
+
+	return stockTransfers, nil
+}
+
+func (s *Store) GetStockTransfersByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]types.StockTransferListsReturnPayload, error) {
+	query := `SELECT st.id, st.number,
+					fb.name, tb.name,
+					st.status, user.name, st.request_date
+					FROM stock_transfer AS st
+					JOIN branch AS fb ON st.from_branch_id = fb.id
+					JOIN branch AS tb ON st.to_branch_id = tb.id
+					JOIN user ON st.user_id = user.id
+					WHERE st.request_date >= ? AND st.request_date < ?
+					AND st.number LIKE ?
+					AND st.deleted_at IS NULL
+					AND (? = 0 OR st.from_branch_id = ? OR st.to_branch_id = ?)
+					ORDER BY st.request_date DESC`
+
+	searchVal := "%"
+	for _, val := range strconv.Itoa(number) {
+		if string(val) != " " {
+			searchVal += (string(val) + "%")
+		}
+	}
+
+	rows, err := s.db.Query(query, startDate, endDate, searchVal, branchId, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTransfers := make([]types.StockTransferListsReturnPayload, 0)
+
+	for rows.Next() {
+		stockTransfer, err := scanRowIntoStockTransferLists(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stockTransfers = append(stockTransfers, *stockTransfer)
+	}
+
+	return stockTransfers, nil
+}
+
+func (s *Store) GetStockTransfersByDateAndStatus(startDate time.Time, endDate time.Time, status string, branchId int) ([]types.StockTransferListsReturnPayload, error) {
+	query := `SELECT st.id, st.number,
+					fb.name, tb.name,
+					st.status, user.name, st.request_date
+					FROM stock_transfer AS st
+					JOIN branch AS fb ON st.from_branch_id = fb.id
+					JOIN branch AS tb ON st.to_branch_id = tb.id
+					JOIN user ON st.user_id = user.id
+					WHERE st.request_date >= ? AND st.request_date < ?
+					AND st.status = ?
+					AND st.deleted_at IS NULL
+					AND (? = 0 OR st.from_branch_id = ? OR st.to_branch_id = ?)
+					ORDER BY st.request_date DESC`
+
+	rows, err := s.db.Query(query, startDate, endDate, status, branchId, branchId, branchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTransfers := make([]types.StockTransferListsReturnPayload, 0)
+
+	for rows.Next() {
+		stockTransfer, err := scanRowIntoStockTransferLists(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stockTransfers = append(stockTransfers, *stockTransfer)
+	}
+
+	return stockTransfers, nil
+}
+
+func (s *Store) GetStockTransferItem(stockTransferId int) ([]types.StockTransferItemReturn, error) {
+	query := `SELECT
+				sti.id,
+				medicine.barcode, medicine.name,
+				sti.request_qty, sti.dispatch_qty, sti.received_qty,
+				unit.name,
+				sti.batch_number, sti.remarks, sti.discrepancy_note
+				FROM stock_transfer_item AS sti
+				JOIN stock_transfer AS st
+					ON sti.stock_transfer_id = st.id
+				JOIN medicine ON sti.medicine_id = medicine.id
+				JOIN unit ON sti.unit_id = unit.id
+				WHERE st.id = ? AND st.deleted_at IS NULL
+				ORDER BY sti.id ASC`
+
+	rows, err := s.db.Query(query, stockTransferId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTransferItems := make([]types.StockTransferItemReturn, 0)
+
+	for rows.Next() {
+		stockTransferItem, err := scanRowIntoStockTransferItem(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stockTransferItems = append(stockTransferItems, *stockTransferItem)
+	}
+
+	return stockTransferItems, nil
+}
+
+func (s *Store) DeleteStockTransfer(stockTransfer *types.StockTransfer, user *types.User) error {
+	query := "UPDATE stock_transfer SET deleted_at = ?, deleted_by_user_id = ? WHERE id = ?"
+	_, err := s.db.Exec(query, time.Now(), user.ID, stockTransfer.ID)
+	if err != nil {
+		return err
+	}
+
+	err = logger.WriteLog("delete", "stock-transfer", user.Name, stockTransfer.ID, stockTransfer)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateDispatch(stockTransferId int, dispatchDate time.Time, items []types.StockTransferDispatchItemPayload, user *types.User) (bool, error) {
+	data, err := s.GetStockTransferItem(stockTransferId)
+	if err != nil {
+		return false, err
+	}
+
+	// the status is changed first, so only one request can move the stock
+	query := `UPDATE stock_transfer
+				SET status = ?, dispatch_date = ?, dispatched_by_user_id = ?,
+				last_modified = ?, last_modified_by_user_id = ?
+				WHERE id = ? AND status = ?`
+
+	result, err := s.db.Exec(query, constants.TRANSFER_STATUS_DISPATCHED, dispatchDate, user.ID,
+		time.Now(), user.ID, stockTransferId, constants.TRANSFER_STATUS_REQUESTED)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 1 {
+		return false, nil
+	}
+
+	writeData := map[string]interface{}{
+		"previous_data": data,
+		"dispatch_data": items,
+	}
+
+	err = logger.WriteLog("modify", "stock-transfer", user.Name, stockTransferId, writeData)
+	if err != nil {
+		return true, fmt.Errorf("error write log file")
+	}
+
+	for _, item := range items {
+		query = `UPDATE stock_transfer_item
+					SET dispatch_qty = ?, batch_number = ?
+					WHERE id = ? AND stock_transfer_id = ?`
+
+		_, err = s.db.Exec(query, item.DispatchQty, item.BatchNumber, item.ID, stockTransferId)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Store) UpdateReceipt(stockTransferId int, receiptDate time.Time, items []types.StockTransferReceiptItemPayload, user *types.User) (bool, error) {
+	data, err := s.GetStockTransferItem(stockTransferId)
+	if err != nil {
+		return false, err
+	}
+
+	// the status is changed first, so only one request can move the stock
+	query := `UPDATE stock_transfer
+				SET status = ?, receipt_date = ?, received_by_user_id = ?,
+				last_modified = ?, last_modified_by_user_id = ?
+				WHERE id = ? AND status = ?`
+
+	result, err := s.db.Exec(query, constants.TRANSFER_STATUS_RECEIVED, receiptDate, user.ID,
+		time.Now(), user.ID, stockTransferId, constants.TRANSFER_STATUS_DISPATCHED)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 1 {
+		return false, nil
+	}
+
+	writeData := map[string]interface{}{
+		"previous_data": data,
+		"receipt_data":  items,
+	}
+
+	err = logger.WriteLog("modify", "stock-transfer", user.Name, stockTransferId, writeData)
+	if err != nil {
+		return true, fmt.Errorf("error write log file")
+	}
+
+	for _, item := range items {
+		query = `UPDATE stock_transfer_item
+					SET received_qty = ?, discrepancy_note = ?
+					WHERE id = ? AND stock_transfer_id = ?`
+
+		_, err = s.db.Exec(query, item.ReceivedQty, item.DiscrepancyNote, item.ID, stockTransferId)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Store) AbsoluteDeleteStockTransfer(stockTransfer types.StockTransfer) error {
+	query := `SELECT id FROM stock_transfer
+				WHERE number = ?
+				AND from_branch_id = ? AND to_branch_id = ?
+				AND request_date = ?`
+
+	rows, err := s.db.Query(query, stockTransfer.Number, stockTransfer.FromBranchID, stockTransfer.ToBranchID, stockTransfer.RequestDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var id int
+
+	for rows.Next() {
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil
+		}
+	}
+
+	if id == 0 {
+		return nil
+	}
+
+	query = "DELETE FROM stock_transfer_item WHERE stock_transfer_id = ?"
+	_, _ = s.db.Exec(query, id)
+
+	query = `DELETE FROM stock_transfer WHERE id = ?`
+	_, _ = s.db.Exec(query, id)
+
+	return nil
+}
+
+func (s *Store) UpdatePDFUrl(stockTransferId int, pdfUrl string) error {
+	query := `UPDATE stock_transfer SET pdf_url = ? WHERE id = ?`
+	_, err := s.db.Exec(query, pdfUrl, stockTransferId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// false means doesn't exist
+func (s *Store) IsPDFUrlExist(pdfUrl string) (bool, error) {
+	query := `SELECT COUNT(*) FROM stock_transfer WHERE pdf_url = ?`
+	row := s.db.QueryRow(query, pdfUrl)
+	if row.Err() != nil {
+		return true, row.Err()
+	}
+
+	var count int
+
+	err := row.Scan(&count)
+	if err != nil {
+		return true, err
+	}
+
+	return (count > 0), nil
+}
+
+func scanRowIntoStockTransfer(rows *sql.Rows) (*types.StockTransfer, error) {
+	stockTransfer := new(types.StockTransfer)
+
+	err := rows.Scan(
+		&stockTransfer.ID,
+		&stockTransfer.Number,
+		&stockTransfer.FromBranchID,
+		&stockTransfer.ToBranchID,
+		&stockTransfer.Status,
+		&stockTransfer.RequestDate,
+		&stockTransfer.DispatchDate,
+		&stockTransfer.ReceiptDate,
+		&stockTransfer.Description,
+		&stockTransfer.UserID,
+		&stockTransfer.DispatchedByUserID,
+		&stockTransfer.ReceivedByUserID,
+		&stockTransfer.PdfURL,
+		&stockTransfer.CreatedAt,
+		&stockTransfer.LastModified,
+		&stockTransfer.LastModifiedByUserID,
+		&stockTransfer.DeletedAt,
+		&stockTransfer.DeletedByUserID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stockTransfer.RequestDate = stockTransfer.RequestDate.Local()
+	stockTransfer.CreatedAt = stockTransfer.CreatedAt.Local()
+	stockTransfer.LastModified = stockTransfer.LastModified.Local()
+
+	return stockTransfer, nil
+}
+
+func scanRowIntoStockTransferLists(rows *sql.Rows) (*types.StockTransferListsReturnPayload, error) {
+	stockTransfer := new(types.StockTransferListsReturnPayload)
+
+	err := rows.Scan(
+		&stockTransfer.ID,
+		&stockTransfer.Number,
+		&stockTransfer.FromBranchName,
+		&stockTransfer.ToBranchName,
+		&stockTransfer.Status,
+		&stockTransfer.UserName,
+		&stockTransfer.RequestDate,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stockTransfer.RequestDate = stockTransfer.RequestDate.Local()
+
+	return stockTransfer, nil
+}
+
+func scanRowIntoStockTransferItem(rows *sql.Rows) (*types.StockTransferItemReturn, error) {
+	stockTransferItem := new(types.StockTransferItemReturn)
+
+	err := rows.Scan(
+		&stockTransferItem.ID,
+		&stockTransferItem.MedicineBarcode,
+		&stockTransferItem.MedicineName,
+		&stockTransferItem.RequestQty,
+		&stockTransferItem.DispatchQty,
+		&stockTransferItem.ReceivedQty,
+		&stockTransferItem.Unit,
+		&stockTransferItem.BatchNumber,
+		&stockTransferItem.Remarks,
+		&stockTransferItem.DiscrepancyNote,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stockTransferItem, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/nicolaics/pharmacon/types"
 )
@@ -70,6 +71,28 @@ func (s *Store) CreateUnit(unitName string) error {
 	return nil
 }
 
+func (s *Store) GetUnitsCreatedSince(since time.Time) ([]types.Unit, error) {
+	rows, err := s.db.Query("SELECT * FROM unit WHERE created_at >= ? ORDER BY id ASC", since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make([]types.Unit, 0)
+
+	for rows.Next() {
+		unit, err := scanRowIntoUnit(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		units = append(units, *unit)
+	}
+
+	return units, nil
+}
+
 func scanRowIntoUnit(rows *sql.Rows) (*types.Unit, error) {
 	unit := new(types.Unit)
 
@@ -1,29 +1,56 @@
 package user
 
 import (
+	"database/sql"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/nicolaics/pharmacon/config"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/service/auth"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 )
 
 type Handler struct {
-	store types.UserStore
+	store             types.UserStore
+	branchStore       types.BranchStore
+	loginAttemptStore types.LoginAttemptStore
 }
 
-func NewHandler(store types.UserStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.UserStore, branchStore types.BranchStore, loginAttemptStore types.LoginAttemptStore) *Handler {
+	return &Handler{store: store, branchStore: branchStore, loginAttemptStore: loginAttemptStore}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/user/register", h.handleRegister).Methods(http.MethodPost)
 	router.HandleFunc("/user/register", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 
+	router.HandleFunc("/user/password", h.handleChangePassword).Methods(http.MethodPatch)
+	router.HandleFunc("/user/password", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+
+	// before /user/{params}/{val}, it matches the path too
+	router.HandleFunc("/user/password/policy", h.handleGetPasswordPolicy).Methods(http.MethodGet)
+	router.HandleFunc("/user/password/policy", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+
+	router.HandleFunc("/user/password/reset", h.handleResetPassword).Methods(http.MethodPost)
+	router.HandleFunc("/user/password/reset", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+
+	router.HandleFunc("/user/totp/setup", h.handleSetupTOTP).Methods(http.MethodPost)
+	router.HandleFunc("/user/totp/setup", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+
+	router.HandleFunc("/user/totp/enable", h.handleEnableTOTP).Methods(http.MethodPatch)
+	router.HandleFunc("/user/totp/enable", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+
+	router.HandleFunc("/user/totp/disable", h.handleDisableTOTP).Methods(http.MethodPatch)
+	router.HandleFunc("/user/totp/disable", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
+
 	router.HandleFunc("/user/{params}/{val}", h.handleGetAll).Methods(http.MethodGet)
 	router.HandleFunc("/user/{params}/{val}", func(w http.ResponseWriter, r *http.Request) { utils.WriteJSONForOptions(w, http.StatusOK, nil) }).Methods(http.MethodOptions)
 
@@ -67,18 +94,73 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ipAddress := utils.GetClientIP(r, config.Envs.TrustedProxyCount)
+
+	// the password is not checked while the name or the ip address is locked
+	lockedUntil, err := getLoginLockedUntil(h, payload.Name, ipAddress)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error checking login lockout: %v", err))
+		return
+	}
+
+	if lockedUntil != nil {
+		recordFailedLogin(h, r, payload.Name, nil, ipAddress, constants.LOGIN_FAIL_LOCKED)
+
+		retryAfter := int(time.Until(*lockedUntil).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed logins, try again in %d seconds", retryAfter))
+		return
+	}
+
 	user, err := h.store.GetUserByName(payload.Name)
 	if err != nil {
+		recordFailedLogin(h, r, payload.Name, nil, ipAddress, constants.LOGIN_FAIL_UNKNOWN_USER)
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("not found, invalid name: %v", err))
 		return
 	}
 
-	// check password match
-	if !(auth.ComparePassword(user.Password, []byte(payload.Password))) {
+	// check password match, the reset code is the password until it is changed
+	var resetCode *types.PasswordResetCode
+
+	validPassword := auth.ComparePassword(user.Password, []byte(payload.Password))
+	if !validPassword {
+		resetCode, err = findPasswordResetCode(h, user.ID, payload.Password)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error checking reset code: %v", err))
+			return
+		}
+
+		validPassword = (resetCode != nil)
+	}
+
+	if !validPassword {
+		recordFailedLogin(h, r, payload.Name, user, ipAddress, constants.LOGIN_FAIL_WRONG_PASSWORD)
 		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("not found, invalid password"))
 		return
 	}
 
+	if user.TOTPEnabled && !utils.VerifyTOTP(user.TOTPSecret, payload.TOTPCode, time.Now()) {
+		recordFailedLogin(h, r, payload.Name, user, ipAddress, constants.LOGIN_FAIL_INVALID_TOTP)
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid totp code"))
+		return
+	}
+
+	// the reset code can only log in once, the password is changed with the token
+	if resetCode != nil {
+		err = h.store.UsePasswordResetCode(resetCode.ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	// the ip address is not cleared, so one known account can't hide the guessing of the others
+	err = h.loginAttemptStore.ResetLoginLockout(constants.LOGIN_LOCK_USERNAME, utils.GetLoginLockKey(payload.Name))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error resetting login lockout: %v", err))
+		return
+	}
+
 	tokenDetails, err := auth.CreateJWT(user.ID, user.Admin)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -97,8 +179,13 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the token can only be used to change the password until it is changed
+	mustChangePassword := user.MustChangePassword ||
+		utils.IsPasswordExpired(user.PasswordChangedAt, config.Envs.PasswordExpiryDays, time.Now())
+
 	tokens := map[string]string{
-		"token": tokenDetails.Token,
+		"token":              tokenDetails.Token,
+		"mustChangePassword": strconv.FormatBool(mustChangePassword),
 	}
 
 	utils.WriteJSON(w, http.StatusOK, tokens)
@@ -141,6 +228,18 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	branchId, err := getUserBranchID(h, admin, payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = checkNewPassword(h, nil, payload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	// if it doesn't, we create new user
 	hashedPassword, err := auth.HashPassword(payload.Password)
 	if err != nil {
@@ -153,6 +252,9 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		Password:    hashedPassword,
 		PhoneNumber: payload.PhoneNumber,
 		Admin:       payload.Admin,
+		Pharmacist:  payload.Pharmacist,
+		BranchID:    branchId,
+		Owner:       payload.Owner,
 	})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -163,7 +265,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
 	// validate token
-	_, err := h.store.ValidateUserToken(w, r, true)
+	admin, err := h.store.ValidateUserToken(w, r, true)
 	if err != nil {
 		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid admin token or not admin: %v", err))
 		return
@@ -212,7 +314,15 @@ func (h *Handler) handleGetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, users)
+	// the admin of a branch only sees the users of the branch
+	branchUsers := make([]types.User, 0)
+	for _, user := range users {
+		if utils.CheckBranchAccess(admin, user.BranchID) == nil {
+			branchUsers = append(branchUsers, user)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, branchUsers)
 }
 
 func (h *Handler) handleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
@@ -306,6 +416,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(admin, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	err = h.store.DeleteUser(user, admin)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -349,6 +465,12 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = utils.CheckBranchAccess(admin, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
 	if user.Name != payload.NewData.Name {
 		_, err = h.store.GetUserByName(payload.NewData.Name)
 		if err == nil {
@@ -356,11 +478,36 @@ func (h *Handler) handleModify(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	branchId, err := getUserBranchID(h, admin, payload.NewData)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// the password is only changed if it is not the current one
+	password := user.Password
+	if !auth.ComparePassword(user.Password, []byte(payload.NewData.Password)) {
+		err = checkNewPassword(h, user, payload.NewData.Password)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		password, err = auth.HashPassword(payload.NewData.Password)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	err = h.store.ModifyUser(user.ID, types.User{
 		Name:        payload.NewData.Name,
-		Password:    payload.NewData.Password,
+		Password:    password,
 		Admin:       payload.NewData.Admin,
 		PhoneNumber: payload.NewData.PhoneNumber,
+		Pharmacist:  payload.NewData.Pharmacist,
+		BranchID:    branchId,
+		Owner:       payload.NewData.Owner,
 	}, admin)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -439,6 +586,9 @@ func (h *Handler) handleChangeAdminStatus(w http.ResponseWriter, r *http.Request
 		Password:    user.Password,
 		Admin:       payload.Admin,
 		PhoneNumber: user.PhoneNumber,
+		Pharmacist:  user.Pharmacist,
+		BranchID:    user.BranchID,
+		Owner:       user.Owner,
 	}, admin)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -447,3 +597,477 @@ func (h *Handler) handleChangeAdminStatus(w http.ResponseWriter, r *http.Request
 
 	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("%s updated into admin: %t", user.Name, payload.Admin))
 }
+
+func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ChangePasswordPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.store.ValidateUserTokenForPasswordChange(w, r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	ipAddress := utils.GetClientIP(r, config.Envs.TrustedProxyCount)
+
+	// the old password is guessed the same way as the login, so it is locked the same way
+	lockedUntil, err := getLoginLockedUntil(h, user.Name, ipAddress)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error checking login lockout: %v", err))
+		return
+	}
+
+	if lockedUntil != nil {
+		recordFailedLogin(h, r, user.Name, user, ipAddress, constants.LOGIN_FAIL_LOCKED)
+
+		retryAfter := int(time.Until(*lockedUntil).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed logins, try again in %d seconds", retryAfter))
+		return
+	}
+
+	// the reset password is empty, the reset code was already used to get the token
+	if user.Password != "" && !auth.ComparePassword(user.Password, []byte(payload.OldPassword)) {
+		recordFailedLogin(h, r, user.Name, user, ipAddress, constants.LOGIN_FAIL_WRONG_PASSWORD)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("old password wrong"))
+		return
+	}
+
+	err = checkNewPassword(h, user, payload.NewPassword)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(payload.NewPassword)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.store.ChangePassword(user.ID, hashedPassword, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("password of %s changed", user.Name))
+}
+
+func (h *Handler) handleGetPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	_, err := h.store.ValidateUserTokenForPasswordChange(w, r)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, getPasswordPolicy())
+}
+
+// the admin gives the code to the user, the user logs in with it and must change the password
+func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.ResetPasswordPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	admin, err := h.store.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid admin token or not admin: %v", err))
+		return
+	}
+
+	// validate admin password
+	if !(auth.ComparePassword(admin.Password, []byte(payload.AdminPassword))) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("admin password wrong"))
+		return
+	}
+
+	user, err := h.store.GetUserByID(payload.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user id %d doesn't exist", payload.ID))
+		return
+	}
+
+	if user.ID == admin.ID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("cannot reset own password, change it instead"))
+		return
+	}
+
+	err = utils.CheckBranchAccess(admin, user.BranchID)
+	if err != nil {
+		utils.WriteError(w, http.StatusForbidden, err)
+		return
+	}
+
+	if user.Owner && !admin.Owner {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("only the owner can reset the password of the owner"))
+		return
+	}
+
+	code, err := utils.GenerateSecureCode(constants.PASSWORD_RESET_CODE_LENGTH)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generating reset code: %v", err))
+		return
+	}
+
+	hashedCode, err := auth.HashPassword(code)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	expiredAt := time.Now().Add(time.Duration(config.Envs.PasswordResetCodeExp) * time.Second)
+
+	err = h.store.CreatePasswordResetCode(types.PasswordResetCode{
+		UserID:    user.ID,
+		Code:      hashedCode,
+		ExpiredAt: expiredAt,
+	}, payload.ResetTOTP, admin)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.PasswordResetCodeReturnPayload{
+		UserName:  user.Name,
+		Code:      code,
+		ExpiredAt: expiredAt,
+	})
+}
+
+// the secret is used after it is confirmed by handleEnableTOTP
+func (h *Handler) handleSetupTOTP(w http.ResponseWriter, r *http.Request) {
+	// validate token
+	user, err := h.store.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid admin token or not admin: %v", err))
+		return
+	}
+
+	if user.TOTPEnabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("totp already enabled, disable it first"))
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("error generating totp secret: %v", err))
+		return
+	}
+
+	err = h.store.SaveTOTPSecret(user.ID, secret)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.TOTPSetupReturnPayload{
+		Secret: secret,
+		URL:    utils.GetTOTPURL(config.Envs.TOTPIssuer, user.Name, secret),
+	})
+}
+
+func (h *Handler) handleEnableTOTP(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.EnableTOTPPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.store.ValidateUserToken(w, r, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid admin token or not admin: %v", err))
+		return
+	}
+
+	if user.TOTPEnabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("totp already enabled"))
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("totp is not set up"))
+		return
+	}
+
+	if !utils.VerifyTOTP(user.TOTPSecret, payload.Code, time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid totp code"))
+		return
+	}
+
+	err = h.store.SetTOTPEnabled(user.ID, true, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("totp of %s enabled", user.Name))
+}
+
+// not admin only, so the user that is not admin anymore can still disable it
+func (h *Handler) handleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	// get JSON Payload
+	var payload types.DisableTOTPPayload
+
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// validate the payload
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		return
+	}
+
+	// validate token
+	user, err := h.store.ValidateUserToken(w, r, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("user token invalid: %v", err))
+		return
+	}
+
+	if !user.TOTPEnabled {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("totp is not enabled"))
+		return
+	}
+
+	if !(auth.ComparePassword(user.Password, []byte(payload.Password))) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("password wrong"))
+		return
+	}
+
+	if !utils.VerifyTOTP(user.TOTPSecret, payload.Code, time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid totp code"))
+		return
+	}
+
+	err = h.store.SetTOTPEnabled(user.ID, false, user)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, fmt.Sprintf("totp of %s disabled", user.Name))
+}
+
+// only the owner can make another owner or put the user in another branch
+func getUserBranchID(h *Handler, admin *types.User, payload types.RegisterUserPayload) (int, error) {
+	branchId := payload.BranchID
+	if branchId == 0 {
+		branchId = admin.BranchID
+	}
+
+	if !admin.Owner {
+		if payload.Owner {
+			return 0, fmt.Errorf("only the owner can make another owner")
+		}
+
+		if branchId != admin.BranchID {
+			return 0, fmt.Errorf("only the owner can add user to another branch")
+		}
+	}
+
+	_, err := h.branchStore.GetBranchByID(branchId)
+	if err != nil {
+		return 0, fmt.Errorf("branch id %d not found", branchId)
+	}
+
+	return branchId, nil
+}
+
+// the latest end of the lockouts of the name and the ip address, nil if both are not locked
+func getLoginLockedUntil(h *Handler, userName string, ipAddress string) (*time.Time, error) {
+	now := time.Now()
+
+	var lockedUntil *time.Time
+
+	for lockType, lockKey := range map[string]string{
+		constants.LOGIN_LOCK_USERNAME: utils.GetLoginLockKey(userName),
+		constants.LOGIN_LOCK_IP:       ipAddress,
+	} {
+		lockout, err := h.loginAttemptStore.GetLoginLockout(lockType, lockKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if utils.IsLoginLocked(lockout, now) && (lockedUntil == nil || lockout.LockedUntil.Time.After(*lockedUntil)) {
+			lockedUntil = &lockout.LockedUntil.Time
+		}
+	}
+
+	return lockedUntil, nil
+}
+
+// saves the failed login for the audit and counts it to the lockouts,
+// the login refused by the lockout is not counted, so the lockout is not extended.
+// the errors are only logged, the login is refused anyway
+func recordFailedLogin(h *Handler, r *http.Request, userName string, user *types.User, ipAddress string, reason string) {
+	metrics.IncFailedLogins()
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > constants.LOGIN_USER_AGENT_MAX_LENGTH {
+		userAgent = userAgent[:constants.LOGIN_USER_AGENT_MAX_LENGTH]
+	}
+
+	attempt := types.LoginAttempt{
+		UserName:  userName,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Reason:    reason,
+	}
+	if user != nil {
+		attempt.UserID = sql.NullInt64{Int64: int64(user.ID), Valid: true}
+	}
+
+	err := h.loginAttemptStore.CreateLoginAttempt(attempt)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error create login attempt", "error", err)
+	}
+
+	slog.WarnContext(r.Context(), "failed login", "userName", userName, "ipAddress", ipAddress, "reason", reason)
+
+	if reason == constants.LOGIN_FAIL_LOCKED {
+		return
+	}
+
+	now := time.Now()
+
+	for _, limit := range []struct {
+		lockType    string
+		lockKey     string
+		maxAttempts int64
+	}{
+		{constants.LOGIN_LOCK_USERNAME, utils.GetLoginLockKey(userName), config.Envs.LoginMaxAttempts},
+		{constants.LOGIN_LOCK_IP, ipAddress, config.Envs.LoginMaxAttemptsPerIP},
+	} {
+		locked, err := h.loginAttemptStore.AddLoginFailure(limit.lockType, limit.lockKey, now, config.Envs.LoginAttemptWindow, limit.maxAttempts)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error save login failure", "lockType", limit.lockType, "error", err)
+			continue
+		}
+
+		if !locked {
+			continue
+		}
+
+		lockout, err := h.loginAttemptStore.GetLoginLockout(limit.lockType, limit.lockKey)
+		if lockout == nil || err != nil {
+			slog.ErrorContext(r.Context(), "error get login lockout", "lockType", limit.lockType, "error", err)
+			continue
+		}
+
+		lockedUntil := now.Add(utils.GetLoginLockoutDuration(lockout.LockoutCount, config.Envs.LoginLockoutDuration, config.Envs.LoginMaxLockoutDuration))
+
+		err = h.loginAttemptStore.SetLoginLockedUntil(lockout.ID, lockedUntil)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error save login lockout", "lockType", limit.lockType, "error", err)
+			continue
+		}
+
+		metrics.IncLoginLockouts()
+		slog.WarnContext(r.Context(), "login locked", "lockType", limit.lockType, "lockKey", limit.lockKey,
+			"lockedUntil", lockedUntil, "lockoutCount", lockout.LockoutCount)
+	}
+}
+
+func getPasswordPolicy() types.PasswordPolicy {
+	return types.PasswordPolicy{
+		MinLength:     int(config.Envs.PasswordMinLength),
+		RequireUpper:  config.Envs.PasswordRequireUpper,
+		RequireLower:  config.Envs.PasswordRequireLower,
+		RequireDigit:  config.Envs.PasswordRequireDigit,
+		RequireSymbol: config.Envs.PasswordRequireSymbol,
+		History:       int(config.Envs.PasswordHistory),
+		ExpiryDays:    int(config.Envs.PasswordExpiryDays),
+	}
+}
+
+// checks the policy and that the password is not the current one or in the history,
+// the user is nil for the new user
+func checkNewPassword(h *Handler, user *types.User, password string) error {
+	policy := getPasswordPolicy()
+
+	err := utils.CheckPasswordPolicy(password, policy)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	if auth.ComparePassword(user.Password, []byte(password)) {
+		return fmt.Errorf("new password must be different from the current one")
+	}
+
+	if policy.History <= 0 {
+		return nil
+	}
+
+	history, err := h.store.GetPasswordHistory(user.ID, policy.History)
+	if err != nil {
+		return fmt.Errorf("error checking password history: %v", err)
+	}
+
+	for _, hashedPassword := range history {
+		if auth.ComparePassword(hashedPassword, []byte(password)) {
+			return fmt.Errorf("password was used in the last %d passwords", policy.History)
+		}
+	}
+
+	return nil
+}
+
+// nil if the code is not a valid reset code of the user
+func findPasswordResetCode(h *Handler, userId int, code string) (*types.PasswordResetCode, error) {
+	resetCodes, err := h.store.GetValidPasswordResetCodes(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resetCode := range resetCodes {
+		if auth.ComparePassword(resetCode.Code, []byte(code)) {
+			return &resetCode, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -3,13 +3,14 @@ package user
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
+	"github.com/nicolaics/pharmacon/config"
 	"github.com/nicolaics/pharmacon/logger"
 	"github.com/nicolaics/pharmacon/service/auth"
 	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
 )
 
 type Store struct {
@@ -64,8 +65,6 @@ func (s *Store) GetUserBySearchName(name string) ([]types.User, error) {
 		query = "SELECT * FROM user WHERE name LIKE ?"
 		searchVal := "%"
 
-		log.Println("search val user: ", searchVal)
-
 		for _, val := range name {
 			if string(val) != " " {
 				searchVal += (string(val) + "%")
@@ -131,8 +130,6 @@ func (s *Store) GetUserBySearchPhoneNumber(phoneNumber string) ([]types.User, er
 		query = "SELECT * FROM user WHERE phone_number LIKE ?"
 		searchVal := "%"
 
-		log.Println("search val user: ", searchVal)
-
 		for _, val := range phoneNumber {
 			if string(val) != " " {
 				searchVal += (string(val) + "%")
@@ -203,9 +200,18 @@ func (s *Store) GetUserByID(id int) (*types.User, error) {
 }
 
 func (s *Store) CreateUser(user types.User) error {
-	_, err := s.db.Exec("INSERT INTO user (name, password, admin, phone_number) VALUES (?, ?, ?, ?)",
-		user.Name, user.Password, user.Admin, user.PhoneNumber)
+	res, err := s.db.Exec("INSERT INTO user (name, password, admin, phone_number, pharmacist, branch_id, owner) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		user.Name, user.Password, user.Admin, user.PhoneNumber, user.Pharmacist, user.BranchID, user.Owner)
+	if err != nil {
+		return err
+	}
 
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	err = s.addPasswordHistory(int(id), user.Password)
 	if err != nil {
 		return err
 	}
@@ -280,15 +286,29 @@ func (s *Store) ModifyUser(id int, user types.User, modifiedByUser *types.User)
 		return fmt.Errorf("error write log file")
 	}
 
-	query := `UPDATE user SET name = ?, password = ?, admin = ?, phone_number = ? 
+	query := `UPDATE user SET name = ?, password = ?, admin = ?, phone_number = ?, pharmacist = ?, 
+				branch_id = ?, owner = ? 
 				WHERE id = ?`
 	_, err = s.db.Exec(query,
-		user.Name, user.Password, user.Admin, user.PhoneNumber, id)
+		user.Name, user.Password, user.Admin, user.PhoneNumber, user.Pharmacist,
+		user.BranchID, user.Owner, id)
 
 	if err != nil {
 		return err
 	}
 
+	if user.Password != data.Password {
+		_, err = s.db.Exec("UPDATE user SET password_changed_at = ? WHERE id = ?", time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		err = s.addPasswordHistory(id, user.Password)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -314,8 +334,208 @@ func (s *Store) DeleteToken(userId int) error {
 	return nil
 }
 
-// TODO: think about whether need to verify count or not
 func (s *Store) ValidateUserToken(w http.ResponseWriter, r *http.Request, needAdmin bool) (*types.User, error) {
+	user, err := s.getUserFromToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the reset or expired password must be changed before anything else
+	if user.MustChangePassword || utils.IsPasswordExpired(user.PasswordChangedAt, config.Envs.PasswordExpiryDays, time.Now()) {
+		return nil, fmt.Errorf("password must be changed")
+	}
+
+	// if the account must be admin
+	if needAdmin {
+		if !user.Admin {
+			return nil, fmt.Errorf("unauthorized! not admin")
+		}
+	}
+
+	return user, nil
+}
+
+func (s *Store) ValidateUserTokenForPasswordChange(w http.ResponseWriter, r *http.Request) (*types.User, error) {
+	return s.getUserFromToken(r)
+}
+
+func (s *Store) ChangePassword(userId int, hashedPassword string, changedByUser *types.User) error {
+	writeData := map[string]interface{}{
+		"previous_data": "password changed",
+	}
+
+	err := logger.WriteLog("modify", "user", changedByUser.Name, userId, writeData)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := `UPDATE user SET password = ?, password_changed_at = ?, must_change_password = ? 
+				WHERE id = ?`
+	_, err = s.db.Exec(query, hashedPassword, time.Now(), false, userId)
+	if err != nil {
+		return err
+	}
+
+	err = s.addPasswordHistory(userId, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// the latest password hashes first
+func (s *Store) GetPasswordHistory(userId int, limit int) ([]string, error) {
+	query := "SELECT password FROM password_history WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?"
+	rows, err := s.db.Query(query, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passwords := make([]string, 0)
+
+	for rows.Next() {
+		var password string
+
+		err = rows.Scan(&password)
+		if err != nil {
+			return nil, err
+		}
+
+		passwords = append(passwords, password)
+	}
+
+	return passwords, nil
+}
+
+// the previous codes of the user are removed, so only the latest one can be used
+func (s *Store) CreatePasswordResetCode(resetCode types.PasswordResetCode, resetTOTP bool, createdByUser *types.User) error {
+	writeData := map[string]interface{}{
+		"previous_data": "password reset",
+		"reset_totp":    resetTOTP,
+	}
+
+	err := logger.WriteLog("modify", "user", createdByUser.Name, resetCode.UserID, writeData)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	_, err = s.db.Exec("DELETE FROM password_reset_code WHERE user_id = ? AND used_at IS NULL", resetCode.UserID)
+	if err != nil {
+		return err
+	}
+
+	values := "?"
+	for i := 0; i < 3; i++ {
+		values += ", ?"
+	}
+
+	query := `INSERT INTO password_reset_code (
+		user_id, code, expired_at, created_by_user_id
+	) VALUES (` + values + `)`
+
+	_, err = s.db.Exec(query, resetCode.UserID, resetCode.Code, resetCode.ExpiredAt, createdByUser.ID)
+	if err != nil {
+		return err
+	}
+
+	// the old password is unusable, it may be known by someone else
+	query = "UPDATE user SET password = ?, must_change_password = ? WHERE id = ?"
+	_, err = s.db.Exec(query, "", true, resetCode.UserID)
+	if err != nil {
+		return err
+	}
+
+	if resetTOTP {
+		_, err = s.db.Exec("UPDATE user SET totp_secret = ?, totp_enabled = ? WHERE id = ?", "", false, resetCode.UserID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.DeleteToken(resetCode.UserID)
+}
+
+func (s *Store) GetValidPasswordResetCodes(userId int) ([]types.PasswordResetCode, error) {
+	query := "SELECT * FROM password_reset_code WHERE user_id = ? AND used_at IS NULL AND expired_at > ?"
+	rows, err := s.db.Query(query, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resetCodes := make([]types.PasswordResetCode, 0)
+
+	for rows.Next() {
+		resetCode, err := scanRowIntoPasswordResetCode(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resetCodes = append(resetCodes, *resetCode)
+	}
+
+	return resetCodes, nil
+}
+
+func (s *Store) UsePasswordResetCode(id int) error {
+	_, err := s.db.Exec("UPDATE password_reset_code SET used_at = ? WHERE id = ?", time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// the secret is disabled until it is confirmed
+func (s *Store) SaveTOTPSecret(userId int, secret string) error {
+	_, err := s.db.Exec("UPDATE user SET totp_secret = ?, totp_enabled = ? WHERE id = ?", secret, false, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// the secret is removed when it is disabled
+func (s *Store) SetTOTPEnabled(userId int, enabled bool, user *types.User) error {
+	writeData := map[string]interface{}{
+		"previous_data": "totp changed",
+		"totp_enabled":  enabled,
+	}
+
+	err := logger.WriteLog("modify", "user", user.Name, userId, writeData)
+	if err != nil {
+		return fmt.Errorf("error write log file")
+	}
+
+	query := "UPDATE user SET totp_enabled = ? WHERE id = ?"
+	if !enabled {
+		query = "UPDATE user SET totp_enabled = ?, totp_secret = '' WHERE id = ?"
+	}
+
+	_, err = s.db.Exec(query, enabled, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) addPasswordHistory(userId int, hashedPassword string) error {
+	_, err := s.db.Exec("INSERT INTO password_history (user_id, password) VALUES (?, ?)", userId, hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// the user of the token without the password checks
+// TODO: think about whether need to verify count or not
+func (s *Store) getUserFromToken(r *http.Request) (*types.User, error) {
 	query := "DELETE FROM verify_token WHERE expired_at < ?"
 	_, err := s.db.Exec(query, time.Now().UTC().Format("2006-01-02 15:04:05"))
 	if err != nil {
@@ -370,13 +590,6 @@ func (s *Store) ValidateUserToken(w http.ResponseWriter, r *http.Request, needAd
 		return nil, fmt.Errorf("token expired, log in again")
 	}
 
-	// if the account must be admin
-	if needAdmin {
-		if !user.Admin {
-			return nil, fmt.Errorf("unauthorized! not admin")
-		}
-	}
-
 	return user, nil
 }
 
@@ -391,6 +604,13 @@ func scanRowIntoUser(rows *sql.Rows) (*types.User, error) {
 		&user.PhoneNumber,
 		&user.LastLoggedIn,
 		&user.CreatedAt,
+		&user.Pharmacist,
+		&user.BranchID,
+		&user.Owner,
+		&user.PasswordChangedAt,
+		&user.MustChangePassword,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
 	)
 
 	if err != nil {
@@ -399,6 +619,30 @@ func scanRowIntoUser(rows *sql.Rows) (*types.User, error) {
 
 	user.CreatedAt = user.CreatedAt.Local()
 	user.LastLoggedIn = user.LastLoggedIn.Local()
+	user.PasswordChangedAt = user.PasswordChangedAt.Local()
 
 	return user, nil
 }
+
+func scanRowIntoPasswordResetCode(rows *sql.Rows) (*types.PasswordResetCode, error) {
+	resetCode := new(types.PasswordResetCode)
+
+	err := rows.Scan(
+		&resetCode.ID,
+		&resetCode.UserID,
+		&resetCode.Code,
+		&resetCode.ExpiredAt,
+		&resetCode.UsedAt,
+		&resetCode.CreatedByUserID,
+		&resetCode.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resetCode.ExpiredAt = resetCode.ExpiredAt.Local()
+	resetCode.CreatedAt = resetCode.CreatedAt.Local()
+
+	return resetCode, nil
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+type BranchStore interface {
+	GetBranchByID(id int) (*Branch, error)
+	GetBranchByCode(code string) (*Branch, error)
+	GetAllBranches() ([]Branch, error)
+
+	CreateBranch(Branch) error
+	DeleteBranch(*Branch, *User) error
+	ModifyBranch(int, Branch, *User) error
+}
+
+type RegisterBranchPayload struct {
+	Code        string `json:"code" validate:"required,max=20"`
+	Name        string `json:"name" validate:"required"`
+	Address     string `json:"address"`
+	PhoneNumber string `json:"phoneNumber" validate:"max=20"`
+}
+
+type ModifyBranchPayload struct {
+	ID      int                   `json:"id" validate:"required"`
+	NewData RegisterBranchPayload `json:"newData" validate:"required"`
+}
+
+type DeleteBranchPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type Branch struct {
+	ID                   int           `json:"id"`
+	Code                 string        `json:"code"`
+	Name                 string        `json:"name"`
+	Address              string        `json:"address"`
+	PhoneNumber          string        `json:"phoneNumber"`
+	CreatedAt            time.Time     `json:"createdAt"`
+	LastModified         time.Time     `json:"lastModified"`
+	LastModifiedByUserID sql.NullInt64 `json:"lastModifiedByUserId"`
+	DeletedAt            sql.NullTime  `json:"deletedAt"`
+	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+}
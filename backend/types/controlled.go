@@ -0,0 +1,102 @@
+package types
+
+import (
+	"time"
+)
+
+type ControlledSubstanceStore interface {
+	CreateRegisterEntry(ControlledSubstanceRegister) error
+
+	// used when the invoice, prescription, purchase invoice or production is modified or deleted
+	DeleteRegisterEntriesByReference(transactionType string, referenceId int) error
+
+	// medicineId 0 means all controlled medicines, branchId 0 means all branches
+	GetRegisterEntries(startDate time.Time, endDate time.Time, medicineId int, branchId int) ([]ControlledSubstanceRegisterReturnPayload, error)
+
+	// balances are in the first unit of the medicine
+	GetControlledSubstanceReport(startDate time.Time, endDate time.Time, branchId int) ([]ControlledSubstanceReportItem, error)
+}
+
+type ViewControlledSubstanceRegisterPayload struct {
+	StartDate  string `json:"startDate" validate:"required"`
+	EndDate    string `json:"endDate" validate:"required"`
+	MedicineID int    `json:"medicineId"`
+	BranchID   int    `json:"branchId"` // only for the owner, empty is all branches
+}
+
+type ViewControlledSubstanceReportPayload struct {
+	Month int `json:"month" validate:"required,gte=1,lte=12"`
+	Year  int `json:"year" validate:"required,gte=2000"`
+
+	// SIPNAP reports the narcotics and the psychotropics separately, empty means both
+	ControlledClass string `json:"controlledClass" validate:"omitempty,oneof=NARKOTIKA PSIKOTROPIKA"`
+	BranchID        int    `json:"branchId"` // only for the owner, empty is all branches
+}
+
+type ControlledSubstanceRegisterReturnPayload struct {
+	ID                 int       `json:"id"`
+	MedicineBarcode    string    `json:"medicineBarcode"`
+	MedicineName       string    `json:"medicineName"`
+	ControlledClass    string    `json:"controlledClass"`
+	Unit               string    `json:"unit"`
+	TransactionType    string    `json:"transactionType"`
+	ReferenceNumber    int       `json:"referenceNumber"`
+	TransactionDate    time.Time `json:"transactionDate"`
+	QtyIn              float64   `json:"qtyIn"`
+	QtyOut             float64   `json:"qtyOut"`
+	PartyName          string    `json:"partyName"` // customer or supplier
+	PatientName        string    `json:"patientName"`
+	PatientAddress     string    `json:"patientAddress"`
+	DoctorName         string    `json:"doctorName"`
+	PrescriptionNumber int       `json:"prescriptionNumber"`
+	UserName           string    `json:"userName"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+type ControlledSubstanceReportItem struct {
+	MedicineID      int     `json:"medicineId"`
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	ControlledClass string  `json:"controlledClass"`
+	Unit            string  `json:"unit"`
+	OpeningBalance  float64 `json:"openingBalance"`
+	InPurchase      float64 `json:"inPurchase"`
+	InProduction    float64 `json:"inProduction"`
+	InTransfer      float64 `json:"inTransfer"`
+	OutPrescription float64 `json:"outPrescription"`
+	OutSale         float64 `json:"outSale"`
+	OutProduction   float64 `json:"outProduction"`
+	OutTransfer     float64 `json:"outTransfer"`
+	TotalIn         float64 `json:"totalIn"`
+	TotalOut        float64 `json:"totalOut"`
+	ClosingBalance  float64 `json:"closingBalance"`
+}
+
+type ControlledSubstanceReportReturnPayload struct {
+	Month     int                             `json:"month"`
+	Year      int                             `json:"year"`
+	StartDate time.Time                       `json:"startDate"`
+	EndDate   time.Time                       `json:"endDate"`
+	Items     []ControlledSubstanceReportItem `json:"items"`
+}
+
+// qty in and qty out are in the first unit of the medicine
+type ControlledSubstanceRegister struct {
+	ID                 int       `json:"id"`
+	MedicineID         int       `json:"medicineId"`
+	TransactionType    string    `json:"transactionType"`
+	ReferenceID        int       `json:"referenceId"`
+	ReferenceNumber    int       `json:"referenceNumber"`
+	TransactionDate    time.Time `json:"transactionDate"`
+	QtyIn              float64   `json:"qtyIn"`
+	QtyOut             float64   `json:"qtyOut"`
+	PartyName          string    `json:"partyName"`
+	PatientName        string    `json:"patientName"`
+	PatientAddress     string    `json:"patientAddress"`
+	DoctorName         string    `json:"doctorName"`
+	PrescriptionNumber int       `json:"prescriptionNumber"`
+	UserID             int       `json:"userId"`
+	CreatedAt          time.Time `json:"createdAt"`
+
+	BranchID int `json:"branchId"`
+}
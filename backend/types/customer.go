@@ -13,6 +13,9 @@ type CustomerStore interface {
 	GetAllCustomers() ([]Customer, error)
 	DeleteCustomer(*User, *Customer) error
 	ModifyCustomer(int, string, *User) error
+
+	// the deleted customers are included for the sync
+	GetCustomersModifiedSince(since time.Time) ([]Customer, error)
 }
 
 type RegisterCustomerPayload struct {
@@ -38,4 +41,5 @@ type Customer struct {
 	CreatedAt       time.Time     `json:"createdAt"`
 	DeletedAt       sql.NullTime  `json:"deletedAt"`
 	DeletedByUserID sql.NullInt64 `json:"deletedByUserId"`
+	LastModified    time.Time     `json:"lastModified"`
 }
@@ -1,21 +1,31 @@
 package types
 
 import (
+	"database/sql"
 	"time"
 )
 
 type DoctorStore interface {
 	GetDoctorByName(name string) (*Doctor, error)
+	GetDoctorByLicenseNumber(licenseNumber string) (*Doctor, error)
 	GetDoctorsBySearchName(name string) ([]Doctor, error)
 	GetDoctorByID(id int) (*Doctor, error)
 	CreateDoctor(Doctor) error
 	GetAllDoctors() ([]Doctor, error)
 	DeleteDoctor(*Doctor, *User) error
-	ModifyDoctor(int, string, *User) error
+	ModifyDoctor(int, Doctor, *User) error
+
+	// the deleted doctors are included for the sync
+	GetDoctorsModifiedSince(since time.Time) ([]Doctor, error)
 }
 
 type RegisterDoctorPayload struct {
-	Name string `json:"name" validate:"required"`
+	Name          string `json:"name" validate:"required"`
+	LicenseNumber string `json:"licenseNumber" validate:"required"` // No. SIP
+	Specialty     string `json:"specialty"`
+	Clinic        string `json:"clinic"` // clinic or hospital
+	Address       string `json:"address"`
+	PhoneNumber   string `json:"phoneNumber"`
 }
 type ModifyDoctorPayload struct {
 	ID      int                   `json:"id" validate:"required"`
@@ -32,7 +42,15 @@ type GetOneDoctorPayload struct {
 }
 
 type Doctor struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID              int           `json:"id"`
+	Name            string        `json:"name"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	LicenseNumber   string        `json:"licenseNumber"`
+	Specialty       string        `json:"specialty"`
+	Clinic          string        `json:"clinic"`
+	Address         string        `json:"address"`
+	PhoneNumber     string        `json:"phoneNumber"`
+	LastModified    time.Time     `json:"lastModified"`
+	DeletedAt       sql.NullTime  `json:"deletedAt"`
+	DeletedByUserID sql.NullInt64 `json:"deletedByUserId"`
 }
@@ -0,0 +1,42 @@
+package types
+
+type EticketTemplateStore interface {
+	GetEticketTemplate(name string) (*EticketTemplate, error)
+	GetAllEticketTemplates() []EticketTemplate
+	ReloadEticketTemplates() error
+}
+
+// measurement in cm, font size in pt
+type EticketTemplate struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Variant     string                `json:"variant"`
+	Width       float64               `json:"width"`
+	Height      float64               `json:"height"`
+	Margin      float64               `json:"margin"`
+	FontFamily  string                `json:"fontFamily"`
+	Fonts       []EticketTemplateFont `json:"fonts"`
+	FontSize    float64               `json:"fontSize"`
+	CellHeight  float64               `json:"cellHeight"`
+	Rows        []EticketTemplateRow  `json:"rows"`
+}
+
+type EticketTemplateFont struct {
+	Style string `json:"style"`
+	File  string `json:"file"`
+}
+
+// empty font size and cell height use the template ones,
+// lines is the number of cell heights reserved for the row
+type EticketTemplateRow struct {
+	Field      string  `json:"field"`
+	Label      string  `json:"label"`
+	LabelWidth float64 `json:"labelWidth"`
+	Text       string  `json:"text"`
+	FontSize   float64 `json:"fontSize"`
+	Style      string  `json:"style"`
+	Align      string  `json:"align"`
+	CellHeight float64 `json:"cellHeight"`
+	Lines      int     `json:"lines"`
+	NoLine     bool    `json:"noLine"`
+}
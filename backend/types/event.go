@@ -0,0 +1,162 @@
+package types
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type EventStore interface {
+	// the event is saved with a delivery for every active webhook that receives it
+	CreateEvent(Event) error
+
+	GetWebhookByID(int) (*Webhook, error)
+	GetWebhookByURL(url string) (*Webhook, error)
+	GetAllWebhooks() ([]Webhook, error)
+	CreateWebhook(Webhook) error
+	ModifyWebhook(int, Webhook, *User) error
+	DeleteWebhook(*Webhook, *User) error
+
+	// the deliveries that are due to be sent by the worker
+	GetDueDeliveries(now time.Time, limit int) ([]WebhookDeliveryJob, error)
+
+	// the delivery is held for the lease, false if another worker already took it
+	ClaimDelivery(deliveryId int, nextAttemptAt time.Time, leaseUntil time.Time) (bool, error)
+	UpdateDeliveryResult(deliveryId int, status string, attempt int, attemptedAt time.Time, nextAttemptAt time.Time) error
+	CreateDeliveryLog(WebhookDeliveryLog) error
+
+	GetDeliveriesByDate(startDate time.Time, endDate time.Time, webhookId int, status string) ([]WebhookDeliveryReturnPayload, error)
+	GetDeliveryByID(int) (*WebhookDelivery, error)
+	GetDeliveryLogs(deliveryId int) ([]WebhookDeliveryLog, error)
+
+	// send the failed delivery again from the first attempt
+	RetryDelivery(deliveryId int, user *User) error
+}
+
+type RegisterWebhookPayload struct {
+	Name       string   `json:"name" validate:"required"`
+	URL        string   `json:"url" validate:"required,url"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16"` // generated if empty
+	EventTypes []string `json:"eventTypes"`                         // empty receives every event
+	Active     bool     `json:"active"`
+}
+
+type ModifyWebhookPayload struct {
+	ID      int                    `json:"id" validate:"required"`
+	NewData RegisterWebhookPayload `json:"newData" validate:"required"`
+}
+
+type DeleteWebhookPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type ViewWebhookDeliveryPayload struct {
+	StartDate string `json:"startDate" validate:"required"`
+	EndDate   string `json:"endDate" validate:"required"`
+	WebhookID int    `json:"webhookId"` // empty is all webhooks
+	Status    string `json:"status"`    // empty is all status
+}
+
+type ViewWebhookDeliveryDetailPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+// the secret is only returned once when the webhook is registered
+type WebhookReturnPayload struct {
+	ID                     int       `json:"id"`
+	Name                   string    `json:"name"`
+	URL                    string    `json:"url"`
+	EventTypes             []string  `json:"eventTypes"`
+	Active                 bool      `json:"active"`
+	CreatedAt              time.Time `json:"createdAt"`
+	LastModified           time.Time `json:"lastModified"`
+	LastModifiedByUserName string    `json:"lastModifiedByUserName"`
+}
+
+type WebhookDeliveryReturnPayload struct {
+	ID            int        `json:"id"`
+	EventUUID     string     `json:"eventUuid"`
+	EventType     string     `json:"eventType"`
+	EntityID      int        `json:"entityId"`
+	WebhookName   string     `json:"webhookName"`
+	Status        string     `json:"status"`
+	Attempt       int        `json:"attempt"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastAttemptAt *time.Time `json:"lastAttemptAt"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+type WebhookDeliveryDetailPayload struct {
+	Delivery WebhookDelivery      `json:"delivery"`
+	Logs     []WebhookDeliveryLog `json:"logs"`
+}
+
+// the body sent to the webhook
+type EventEnvelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Entity     string          `json:"entity"`
+	EntityID   int             `json:"entityId"`
+	BranchID   int             `json:"branchId"`
+	UserID     int             `json:"userId"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// the delivery with the event and the webhook it is sent to
+type WebhookDeliveryJob struct {
+	Delivery WebhookDelivery
+	Event    Event
+	URL      string
+	Secret   string
+}
+
+type Event struct {
+	ID        int       `json:"id"`
+	UUID      string    `json:"uuid"`
+	EventType string    `json:"eventType"`
+	Entity    string    `json:"entity"`
+	EntityID  int       `json:"entityId"`
+	BranchID  int       `json:"branchId"`
+	UserID    int       `json:"userId"`
+	Payload   string    `json:"payload"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Webhook struct {
+	ID                   int           `json:"id"`
+	Name                 string        `json:"name"`
+	URL                  string        `json:"url"`
+	Secret               string        `json:"-"`
+	EventTypes           string        `json:"eventTypes"`
+	Active               bool          `json:"active"`
+	CreatedAt            time.Time     `json:"createdAt"`
+	LastModified         time.Time     `json:"lastModified"`
+	LastModifiedByUserID int           `json:"lastModifiedByUserId"`
+	DeletedAt            sql.NullTime  `json:"deletedAt"`
+	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+}
+
+type WebhookDelivery struct {
+	ID            int          `json:"id"`
+	EventID       int          `json:"eventId"`
+	WebhookID     int          `json:"webhookId"`
+	Status        string       `json:"status"`
+	Attempt       int          `json:"attempt"`
+	NextAttemptAt time.Time    `json:"nextAttemptAt"`
+	LastAttemptAt sql.NullTime `json:"lastAttemptAt"`
+	DeliveredAt   sql.NullTime `json:"deliveredAt"`
+	CreatedAt     time.Time    `json:"createdAt"`
+}
+
+type WebhookDeliveryLog struct {
+	ID                int       `json:"id"`
+	WebhookDeliveryID int       `json:"webhookDeliveryId"`
+	Attempt           int       `json:"attempt"`
+	ResponseStatus    int       `json:"responseStatus"`
+	ResponseBody      string    `json:"responseBody"`
+	Error             string    `json:"error"`
+	DurationMs        int64     `json:"durationMs"`
+	AttemptedAt       time.Time `json:"attemptedAt"`
+}
@@ -0,0 +1,7 @@
+package types
+
+// the failed check has the error as its value
+type HealthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
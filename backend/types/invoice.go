@@ -10,16 +10,18 @@ type InvoiceStore interface {
 
 	GetInvoicesByNumber(int) ([]Invoice, error)
 
-	GetInvoicesByDate(startDate time.Time, endDate time.Time) ([]InvoiceListsReturnPayload, error)
-	GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]InvoiceListsReturnPayload, error)
-	GetInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]InvoiceListsReturnPayload, error)
-	GetInvoicesByDateAndCustomerID(startDate time.Time, endDate time.Time, cid int) ([]InvoiceListsReturnPayload, error)
-	GetInvoicesByDateAndPaymentMethodID(startDate time.Time, endDate time.Time, pmid int) ([]InvoiceListsReturnPayload, error)
+	GetInvoicesByDate(startDate time.Time, endDate time.Time, branchId int) ([]InvoiceListsReturnPayload, error)
+	GetInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]InvoiceListsReturnPayload, error)
+	GetInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]InvoiceListsReturnPayload, error)
+	GetInvoicesByDateAndCustomerID(startDate time.Time, endDate time.Time, cid int, branchId int) ([]InvoiceListsReturnPayload, error)
+	GetInvoicesByDateAndPaymentMethodID(startDate time.Time, endDate time.Time, pmid int, branchId int) ([]InvoiceListsReturnPayload, error)
 
-	GetInvoiceID(number int, customerId int, invoiceDate time.Time) (int, error)
-	GetNumberOfInvoices(startDate time.Time, endDate time.Time) (int, error)
+	GetInvoiceID(number int, customerId int, invoiceDate time.Time, branchId int) (int, error)
+	GetNumberOfInvoices(startDate time.Time, endDate time.Time, branchId int) (int, error)
 
 	CreateInvoice(Invoice) error
+	// the number is the next one of the invoice date in the branch, returns the id and the number
+	CreateInvoiceWithNextNumber(Invoice) (int, int, error)
 	CreateMedicineItem(InvoiceMedicineItem) error
 	GetMedicineItem(int) ([]InvoiceMedicineItemReturnPayload, error)
 	DeleteMedicineItem(*Invoice, *User) error
@@ -47,7 +49,8 @@ type RegisterInvoicePayload struct {
 	DiscountAmount     float64 `json:"discountAmount"`
 	TaxPercentage      float64 `json:"taxPercentage"`
 	TaxAmount          float64 `json:"taxAmount"`
-	TotalPrice         float64 `json:"totalPrice" validate:"required"`
+	TaxableAmount      float64 `json:"taxableAmount"`
+	TotalPrice         float64 `json:"totalPrice"`
 	PaidAmount         float64 `json:"paidAmount" validate:"required"`
 	ChangeAmount       float64 `json:"changeAmount"`
 	PaymentMethodName  string  `json:"paymentMethodName" validate:"required"`
@@ -65,6 +68,7 @@ type ModifyInvoicePayload struct {
 type ViewInvoicePayload struct {
 	StartDate string `json:"startDate" validate:"required"` // if empty, just give today's date from morning
 	EndDate   string `json:"endDate" validate:"required"`   // if empty, just give today's date to current time
+	BranchID  int    `json:"branchId"`                      // only for the owner, empty is all branches
 }
 
 type InvoiceMedicineListsPayload struct {
@@ -149,6 +153,15 @@ type PrintReceiptPayload struct {
 	ID int `json:"id" validate:"required"`
 }
 
+// receipt for the thermal printer, the paper width is from the config if empty.
+// print sends it to the receipt printer instead of returning the printer data
+type PrintThermalReceiptPayload struct {
+	InvoiceID  int    `json:"invoiceId" validate:"required"`
+	PaperWidth int    `json:"paperWidth" validate:"omitempty,oneof=58 80"`
+	CodeType   string `json:"codeType" validate:"omitempty,oneof=barcode qr"`
+	Print      bool   `json:"print"`
+}
+
 type DeleteInvoicePayload ViewInvoiceDetailPayload
 
 type InvoiceMedicineItem struct {
@@ -186,6 +199,9 @@ type Invoice struct {
 	ReceiptPDFUrl        sql.NullString `json:"receiptPdfUrl"` // kwitansi
 	DeletedAt            sql.NullTime   `json:"deletedAt"`
 	DeletedByUserID      sql.NullInt64  `json:"deletedByUserId"`
+
+	BranchID      int     `json:"branchId"`
+	TaxableAmount float64 `json:"taxableAmount"`
 }
 
 type InvoicePDFPayload struct {
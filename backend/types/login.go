@@ -0,0 +1,59 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+type LoginAttemptStore interface {
+	CreateLoginAttempt(LoginAttempt) error
+	GetLoginAttemptsByDate(startDate time.Time, endDate time.Time, userName string, ipAddress string) ([]LoginAttempt, error)
+
+	// nil if the key has never failed to login
+	GetLoginLockout(lockType string, lockKey string) (*LoginLockout, error)
+
+	// counts the failure in the db, so the parallel logins are all counted.
+	// returns true if this failure reached maxAttempts, the lockout count is added then
+	AddLoginFailure(lockType string, lockKey string, now time.Time, window int64, maxAttempts int64) (bool, error)
+	SetLoginLockedUntil(id int, lockedUntil time.Time) error
+	GetActiveLoginLockouts() ([]LoginLockout, error)
+
+	// the failed count is cleared after the login succeeded
+	ResetLoginLockout(lockType string, lockKey string) error
+	UnlockLogin(lockType string, lockKey string, user *User) error
+}
+
+type ViewLoginAttemptPayload struct {
+	StartDate string `json:"startDate" validate:"required"`
+	EndDate   string `json:"endDate" validate:"required"`
+	UserName  string `json:"userName"`  // empty is all user names
+	IPAddress string `json:"ipAddress"` // empty is all ip addresses
+}
+
+type UnlockLoginPayload struct {
+	LockType string `json:"lockType" validate:"required,oneof=USERNAME IP"`
+	LockKey  string `json:"lockKey" validate:"required"`
+}
+
+type LoginAttempt struct {
+	ID        int           `json:"id"`
+	UserName  string        `json:"userName"`
+	UserID    sql.NullInt64 `json:"userId"`
+	IPAddress string        `json:"ipAddress"`
+	UserAgent string        `json:"userAgent"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// the key is the user name in lower case or the ip address
+type LoginLockout struct {
+	ID               int           `json:"id"`
+	LockType         string        `json:"lockType"`
+	LockKey          string        `json:"lockKey"`
+	FailedCount      int           `json:"failedCount"`
+	LockoutCount     int           `json:"lockoutCount"`
+	LockedUntil      sql.NullTime  `json:"lockedUntil"`
+	LastFailedAt     time.Time     `json:"lastFailedAt"`
+	UnlockedAt       sql.NullTime  `json:"unlockedAt"`
+	UnlockedByUserID sql.NullInt64 `json:"unlockedByUserId"`
+}
@@ -9,6 +9,14 @@ type MainDoctorMedItemStore interface {
 	IsMedicineContentsExist(medId int) (bool, error)
 	IsMedicineBarcodeExist(barcode string) (bool, error)
 
+	// the recipe of the compounded medicine, empty if it's not a compounded medicine
+	GetMainDoctorMedIngredients(medId int) ([]MainDoctorMedIngredient, error)
+
+	// the ingredients taken from the stock when the invoice or the prescription was made
+	CreateDispensedIngredients(ingredients []DispensedIngredient) error
+	GetDispensedIngredients(referenceType string, referenceId int) ([]DispensedIngredientReturn, error)
+	DeleteDispensedIngredients(referenceType string, referenceId int) error
+
 	DeleteMainDoctorMedItem(medId int, user *User) error
 }
 
@@ -50,3 +58,38 @@ type MainDoctorMedItem struct {
 	LastModified         time.Time `json:"lastModified"`
 	LastModifiedByUserID int       `json:"lastModifiedByUserId"`
 }
+
+// qty is for 1 first unit of the compounded medicine
+type MainDoctorMedIngredient struct {
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	Qty             float64 `json:"qty"`
+	Unit            string  `json:"unit"`
+}
+
+// the medicine whose stock is checked, subtracted or added back
+type MedicineStockItem struct {
+	Medicine *Medicine
+	Unit     *Unit
+	Qty      float64
+}
+
+// qty is the total taken for the dispensed compounded medicine
+type DispensedIngredient struct {
+	ID                 int       `json:"id"`
+	ReferenceType      string    `json:"referenceType"`
+	ReferenceID        int       `json:"referenceId"`
+	CompoundMedicineID int       `json:"compoundMedicineId"`
+	MedicineID         int       `json:"medicineId"`
+	Qty                float64   `json:"qty"`
+	UnitID             int       `json:"unitId"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+type DispensedIngredientReturn struct {
+	CompoundMedicineID int     `json:"compoundMedicineId"`
+	MedicineBarcode    string  `json:"medicineBarcode"`
+	MedicineName       string  `json:"medicineName"`
+	Qty                float64 `json:"qty"`
+	Unit               string  `json:"unit"`
+}
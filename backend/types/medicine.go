@@ -22,7 +22,15 @@ type MedicineStore interface {
 
 	ModifyMedicine(int, Medicine, *User) error
 
-	UpdateMedicineStock(mid int, newStock float64, user *User) error
+	// the stock is per branch, medicine qty is the total of all branches
+	GetMedicineStock(mid int, branchId int) (float64, error)
+	GetMedicineStocks(mid int) ([]MedicineStock, error)
+	GetBranchStocks(branchId int) (map[int]float64, error)
+	UpdateMedicineStock(mid int, branchId int, newStock float64, user *User) error
+
+	// the deleted medicines are included for the sync
+	GetMedicinesModifiedSince(since time.Time) ([]Medicine, error)
+	GetBranchStocksModifiedSince(branchId int, since time.Time) (map[int]float64, error)
 }
 
 type RegisterMedicinePayload struct {
@@ -47,6 +55,9 @@ type RegisterMedicinePayload struct {
 	ThirdDiscountAmount        float64 `json:"thirdDiscountAmount"`
 	ThirdPrice                 float64 `json:"thirdPrice"`
 	Description                string  `json:"description"`
+	IsTaxable                  bool    `json:"isTaxable"`
+	ActiveIngredients          string  `json:"activeIngredients"` // separated by comma
+	ControlledClass            string  `json:"controlledClass" validate:"omitempty,oneof=NARKOTIKA PSIKOTROPIKA"`
 }
 
 type DeleteMedicinePayload struct {
@@ -83,9 +94,15 @@ type MedicineListsReturnPayload struct {
 	ThirdDiscountAmount        float64   `json:"thirdDiscountAmount"`
 	ThirdPrice                 float64   `json:"thirdPrice"`
 	Description                string    `json:"description"`
+	IsTaxable                  bool      `json:"isTaxable"`
+	ActiveIngredients          string    `json:"activeIngredients"`
+	ControlledClass            string    `json:"controlledClass"`
 	CreatedAt                  time.Time `json:"createdAt"`
 	LastModified               time.Time `json:"lastModified"`
 	LastModifiedByUserName     string    `json:"lastModifiedByUserName"`
+
+	// only for the detail, the stock of each branch
+	Stocks []MedicineStock `json:"stocks,omitempty"`
 }
 
 type Medicine struct {
@@ -116,4 +133,15 @@ type Medicine struct {
 	LastModifiedByUserID       int           `json:"lastModifiedByUserId"`
 	DeletedAt                  sql.NullTime  `json:"deletedAt"`
 	DeletedByUserID            sql.NullInt64 `json:"deletedByUserId"`
+	IsTaxable                  bool          `json:"isTaxable"`
+	ActiveIngredients          string        `json:"activeIngredients"`
+	ControlledClass            string        `json:"controlledClass"`
+}
+
+type MedicineStock struct {
+	BranchID     int       `json:"branchId"`
+	BranchCode   string    `json:"branchCode"`
+	BranchName   string    `json:"branchName"`
+	Qty          float64   `json:"qty"`
+	LastModified time.Time `json:"lastModified"`
 }
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+// change the password of the current user
+type ChangePasswordPayload struct {
+	OldPassword string `json:"oldPassword"` // empty after the admin reset the password, the reset code is used at the login
+	NewPassword string `json:"newPassword" validate:"required,max=72"`
+}
+
+// the admin gives a one-time code to the user that forgot the password
+type ResetPasswordPayload struct {
+	AdminPassword string `json:"adminPassword" validate:"required"`
+	ID            int    `json:"id" validate:"required"`
+	ResetTOTP     bool   `json:"resetTotp"` // disable the totp too if the device is lost
+}
+
+// the code is only shown once
+type PasswordResetCodeReturnPayload struct {
+	UserName  string    `json:"userName"`
+	Code      string    `json:"code"`
+	ExpiredAt time.Time `json:"expiredAt"`
+}
+
+type PasswordPolicy struct {
+	MinLength     int  `json:"minLength"`
+	RequireUpper  bool `json:"requireUpper"`
+	RequireLower  bool `json:"requireLower"`
+	RequireDigit  bool `json:"requireDigit"`
+	RequireSymbol bool `json:"requireSymbol"`
+	History       int  `json:"history"`    // the last passwords that can't be used again
+	ExpiryDays    int  `json:"expiryDays"` // 0 is never expired
+}
+
+// the secret is saved disabled until it is confirmed with a code
+type TOTPSetupReturnPayload struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"` // otpauth url for the qr code
+}
+
+type EnableTOTPPayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTOTPPayload struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// the code is hashed like the password
+type PasswordResetCode struct {
+	ID              int          `json:"id"`
+	UserID          int          `json:"userId"`
+	Code            string       `json:"-"`
+	ExpiredAt       time.Time    `json:"expiredAt"`
+	UsedAt          sql.NullTime `json:"usedAt"`
+	CreatedByUserID int          `json:"createdByUserId"`
+	CreatedAt       time.Time    `json:"createdAt"`
+}
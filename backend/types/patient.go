@@ -1,26 +1,38 @@
 package types
 
 import (
+	"database/sql"
 	"time"
 )
 
 type PatientStore interface {
-	GetPatientByName(name string, age int) (*Patient, error)
 	GetPatientsBySearchName(name string) ([]Patient, error)
 	GetPatientByID(id int) (*Patient, error)
 
+	// match by medical record number, national id, name and birth date,
+	// or name and phone number, in that order
+	GetPatientByIdentity(Patient) (*Patient, error)
+
 	CreatePatient(Patient) error
 
 	GetAllPatients() ([]Patient, error)
 
 	DeletePatient(*Patient, *User) error
 
-	ModifyPatient(int, RegisterPatientPayload, *User) error
+	ModifyPatient(int, Patient, *User) error
 }
 
 type RegisterPatientPayload struct {
-	Name string `json:"name" validate:"required"`
-	Age  int    `json:"age"`
+	Name                string  `json:"name" validate:"required"`
+	Age                 int     `json:"age"` // only used when the birth date is unknown
+	BirthDate           string  `json:"birthDate"`
+	Gender              string  `json:"gender"`
+	PhoneNumber         string  `json:"phoneNumber"`
+	Address             string  `json:"address"`
+	NationalID          string  `json:"nationalId"`
+	MedicalRecordNumber string  `json:"medicalRecordNumber"`
+	Weight              float64 `json:"weight"`
+	AllergyNotes        string  `json:"allergyNotes"`
 }
 type ModifyPatientPayload struct {
 	ID      int                    `json:"id" validate:"required"`
@@ -36,9 +48,23 @@ type GetOnePatientPayload struct {
 	ID int `json:"id" validate:"required"`
 }
 
+// patient sent with the prescription, id is filled if the patient is picked from the list
+type PrescriptionPatientPayload struct {
+	ID int `json:"id"`
+	RegisterPatientPayload
+}
+
 type Patient struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Age       int       `json:"age"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID                  int          `json:"id"`
+	Name                string       `json:"name"`
+	Age                 int          `json:"age"`
+	CreatedAt           time.Time    `json:"createdAt"`
+	BirthDate           sql.NullTime `json:"birthDate"`
+	Gender              string       `json:"gender"`
+	PhoneNumber         string       `json:"phoneNumber"`
+	Address             string       `json:"address"`
+	NationalID          string       `json:"nationalId"`
+	MedicalRecordNumber string       `json:"medicalRecordNumber"`
+	Weight              float64      `json:"weight"`
+	AllergyNotes        string       `json:"allergyNotes"`
 }
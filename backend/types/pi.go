@@ -11,11 +11,11 @@ type PurchaseInvoiceStore interface {
 	GetPurchaseInvoiceID(number int, supplierId int, subtotal float64, totalPrice float64, invoiceDate time.Time) (int, error)
 	GetPurchaseMedicineItem(purchaseInvoiceId int) ([]PurchaseMedicineItemReturn, error)
 
-	GetPurchaseInvoicesByDate(startDate time.Time, endDate time.Time) ([]PurchaseInvoiceListsReturnPayload, error)
-	GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]PurchaseInvoiceListsReturnPayload, error)
-	GetPurchaseInvoicesByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int) ([]PurchaseInvoiceListsReturnPayload, error)
-	GetPurchaseInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]PurchaseInvoiceListsReturnPayload, error)
-	GetPurchaseInvoicesByDateAndPONumber(startDate time.Time, endDate time.Time, poiNumber int) ([]PurchaseInvoiceListsReturnPayload, error)
+	GetPurchaseInvoicesByDate(startDate time.Time, endDate time.Time, branchId int) ([]PurchaseInvoiceListsReturnPayload, error)
+	GetPurchaseInvoicesByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]PurchaseInvoiceListsReturnPayload, error)
+	GetPurchaseInvoicesByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int, branchId int) ([]PurchaseInvoiceListsReturnPayload, error)
+	GetPurchaseInvoicesByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]PurchaseInvoiceListsReturnPayload, error)
+	GetPurchaseInvoicesByDateAndPONumber(startDate time.Time, endDate time.Time, poiNumber int, branchId int) ([]PurchaseInvoiceListsReturnPayload, error)
 
 	CreatePurchaseInvoice(PurchaseInvoice) error
 	CreatePurchaseMedicineItem(PurchaseMedicineItem) error
@@ -39,9 +39,10 @@ type RegisterPurchaseInvoicePayload struct {
 	Subtotal            float64                       `json:"subtotal" validate:"required"`
 	DiscountPercentage  float64                       `json:"discountPercentage"`
 	DiscountAmount      float64                       `json:"discountAmount"`
-	TaxPercentage       float64                       `json:"taxPercentage" validate:"required"`
-	TaxAmount           float64                       `json:"taxAmount" validate:"required"`
-	TotalPrice          float64                       `json:"totalPrice" validate:"required"`
+	TaxPercentage       float64                       `json:"taxPercentage"`
+	TaxAmount           float64                       `json:"taxAmount"`
+	TaxableAmount       float64                       `json:"taxableAmount"`
+	TotalPrice          float64                       `json:"totalPrice"`
 	Description         string                        `json:"description"`
 	InvoiceDate         string                        `json:"invoiceDate" validate:"required"`
 	MedicineLists       []PurchaseMedicineListPayload `json:"purchaseMedicineList" validate:"required"`
@@ -66,6 +67,7 @@ type PurchaseMedicineListPayload struct {
 type ViewPurchaseInvoicePayload struct {
 	StartDate string `json:"startDate" validate:"required"` // if empty, just give today's date from morning
 	EndDate   string `json:"endDate" validate:"required"`   // if empty, just give today's date to current time
+	BranchID  int    `json:"branchId"`                      // only for the owner, empty is all branches
 }
 
 // view the detail of the purchase invoice
@@ -197,6 +199,9 @@ type PurchaseInvoice struct {
 	PdfURL               string        `json:"pdfUrl"`
 	DeletedAt            sql.NullTime  `json:"deletedAt"`
 	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+
+	BranchID      int     `json:"branchId"`
+	TaxableAmount float64 `json:"taxableAmount"`
 }
 
 type PurchaseMedicineItem struct {
@@ -6,18 +6,18 @@ import (
 )
 
 type PurchaseOrderStore interface {
-	GetPurchaseOrderByNumber(int) (*PurchaseOrder, error)
+	GetPurchaseOrderByNumber(int, int) (*PurchaseOrder, error)
 	GetPurchaseOrderByID(int) (*PurchaseOrder, error)
-	GetPurchaseOrderID(number int, supplierId int, totalItem int, invoiceDate time.Time) (int, error)
-	GetNumberOfPurchaseOrders() (int, error)
+	GetPurchaseOrderID(number int, supplierId int, totalItem int, invoiceDate time.Time, branchId int) (int, error)
+	GetNumberOfPurchaseOrders(branchId int) (int, error)
 
 	CreatePurchaseOrder(PurchaseOrder) error
 	CreatePurchaseOrderItem(PurchaseOrderItem) error
 
-	GetPurchaseOrdersByDate(startDate time.Time, endDate time.Time) ([]PurchaseOrderListsReturnPayload, error)
-	GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]PurchaseOrderListsReturnPayload, error)
-	GetPurchaseOrdersByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]PurchaseOrderListsReturnPayload, error)
-	GetPurchaseOrdersByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int) ([]PurchaseOrderListsReturnPayload, error)
+	GetPurchaseOrdersByDate(startDate time.Time, endDate time.Time, branchId int) ([]PurchaseOrderListsReturnPayload, error)
+	GetPurchaseOrdersByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]PurchaseOrderListsReturnPayload, error)
+	GetPurchaseOrdersByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]PurchaseOrderListsReturnPayload, error)
+	GetPurchaseOrdersByDateAndSupplierID(startDate time.Time, endDate time.Time, sid int, branchId int) ([]PurchaseOrderListsReturnPayload, error)
 
 	GetPurchaseOrderItem(purchaseOrderId int) ([]PurchaseOrderItemReturn, error)
 
@@ -58,6 +58,7 @@ type PurchaseOrderMedicineListPayload struct {
 type ViewPurchaseOrderPayload struct {
 	StartDate string `json:"startDate" validate:"required"` // if empty, just give today's date from morning
 	EndDate   string `json:"endDate" validate:"required"`   // if empty, just give today's date to current time
+	BranchID  int    `json:"branchId"`                      // only for the owner, empty is all branches
 }
 
 // view the detail of the purchase invoice
@@ -145,6 +146,8 @@ type PurchaseOrder struct {
 	PdfURL               string        `json:"pdfUrl"`
 	DeletedAt            sql.NullTime  `json:"deletedAt"`
 	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+
+	BranchID int `json:"branchId"`
 }
 
 type PurchaseOrderItem struct {
@@ -9,12 +9,12 @@ type PrescriptionStore interface {
 	GetPrescriptionsByNumber(int) ([]Prescription, error)
 	GetPrescriptionByID(int) (*Prescription, error)
 
-	GetPrescriptionsByDate(startDate time.Time, endDate time.Time) ([]PrescriptionListsReturnPayload, error)
-	GetPrescriptionsByDateAndNumber(startDate time.Time, endDate time.Time, number int) ([]PrescriptionListsReturnPayload, error)
-	GetPrescriptionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]PrescriptionListsReturnPayload, error)
-	GetPrescriptionsByDateAndPatientID(startDate time.Time, endDate time.Time, pid int) ([]PrescriptionListsReturnPayload, error)
-	GetPrescriptionsByDateAndDoctorID(startDate time.Time, endDate time.Time, did int) ([]PrescriptionListsReturnPayload, error)
-	GetPrescriptionsByDateAndInvoiceID(startDate time.Time, endDate time.Time, iid int) ([]PrescriptionListsReturnPayload, error)
+	GetPrescriptionsByDate(startDate time.Time, endDate time.Time, branchId int) ([]PrescriptionListsReturnPayload, error)
+	GetPrescriptionsByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]PrescriptionListsReturnPayload, error)
+	GetPrescriptionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]PrescriptionListsReturnPayload, error)
+	GetPrescriptionsByDateAndPatientID(startDate time.Time, endDate time.Time, pid int, branchId int) ([]PrescriptionListsReturnPayload, error)
+	GetPrescriptionsByDateAndDoctorID(startDate time.Time, endDate time.Time, did int, branchId int) ([]PrescriptionListsReturnPayload, error)
+	GetPrescriptionsByDateAndInvoiceID(startDate time.Time, endDate time.Time, iid int, branchId int) ([]PrescriptionListsReturnPayload, error)
 
 	GetPrescriptionID(invoiceId int, number int, date time.Time, patientId int, totalPrice float64, doctorId int) (int, error)
 
@@ -50,6 +50,28 @@ type PrescriptionStore interface {
 	UpdateEticketID(eticketId int, prescSetItemId int) error
 
 	IsValidPrescriptionNumber(number int, startDate time.Time, endDate time.Time) (bool, error)
+
+	// the follow-up dispenses of an iter prescription
+	GetFollowUpPrescriptions(originalPrescriptionId int) ([]Prescription, error)
+
+	// total dispensed qty of each medicine by the original prescription and its follow-ups,
+	// excludePrescriptionId is used when modifying a follow-up
+	GetDispensedQtys(originalPrescriptionId int, excludePrescriptionId int) ([]PrescriptionDispensedQty, error)
+
+	CreatePrescriptionScreening(PrescriptionScreening) error
+	GetPrescriptionScreeningsByPrescriptionID(prescriptionId int) ([]PrescriptionScreening, error)
+	DeletePrescriptionScreenings(prescriptionId int) error
+
+	// false if the status is not fromStatus anymore
+	UpdatePrescriptionStatus(prescriptionId int, fromStatus string, toStatus string, user *User) (bool, error)
+	CreatePrescriptionStatusHistory(PrescriptionStatusHistory) error
+	GetPrescriptionStatusHistories(prescriptionId int) ([]PrescriptionStatusHistoryReturn, error)
+
+	// open prescriptions with the given statuses, the oldest status change first
+	GetPrescriptionQueue(statuses []string, branchId int) ([]PrescriptionQueueReturn, error)
+
+	// every medicine given to the patient, the oldest prescription first
+	GetPatientMedicationHistory(patientId int, startDate time.Time, endDate time.Time, branchId int) ([]PatientMedicationHistoryItem, error)
 }
 
 type RegisterPrescriptionPayload struct {
@@ -61,14 +83,19 @@ type RegisterPrescriptionPayload struct {
 
 	Number           int                          `json:"number" validate:"required"`
 	PrescriptionDate string                       `json:"prescriptionDate" validate:"required"`
-	PatientName      string                       `json:"patientName" validate:"required"`
-	PatientAge       int                          `json:"patientAge"`
-	DoctorName       string                       `json:"doctorName" validate:"required"`
+	Patient          PrescriptionPatientPayload   `json:"patient" validate:"required"`
+	DoctorID         int                          `json:"doctorId" validate:"required"` // doctor must be registered first
 	Qty              float64                      `json:"qty" validate:"required"`
 	Price            float64                      `json:"price" validate:"required"`
 	TotalPrice       float64                      `json:"totalPrice" validate:"required"`
 	Description      string                       `json:"description"`
 	SetItems         []PrescriptionSetItemPayload `json:"setItems" validate:"required"`
+
+	// needed when the screening gives a blocking result
+	ScreeningOverrideReason string `json:"screeningOverrideReason"`
+
+	// filled when this is a follow-up dispense of an iter or partly dispensed prescription
+	OriginalPrescriptionID int `json:"originalPrescriptionId"`
 }
 
 type PrescriptionSetItemPayload struct {
@@ -85,6 +112,8 @@ type PrescriptionSetItemPayload struct {
 		Number      int     `json:"number"`
 		MedicineQty float64 `json:"medicineQty"`
 		Size        string  `json:"size"`
+		Template    string  `json:"template"`
+		ShakeWell   bool    `json:"shakeWell"`
 	} `json:"eticket"`
 }
 
@@ -97,6 +126,10 @@ type PrescriptionMedicineListPayload struct {
 	DiscountPercentage float64 `json:"discountPercentage"`
 	DiscountAmount     float64 `json:"discountAmount"`
 	Subtotal           float64 `json:"subtotal" validate:"required"`
+
+	// qty written by the doctor, empty means the same as the dispensed qty
+	PrescribedQty string `json:"prescribedQty"`
+	Iteration     int    `json:"iteration" validate:"gte=0"`
 }
 
 // prescription list payload returned to user after searching
@@ -125,6 +158,21 @@ type PrescriptionListsReturnPayload struct {
 type ViewPrescriptionsPayload struct {
 	StartDate string `json:"startDate" validate:"required"` // if empty, just give today's date from morning
 	EndDate   string `json:"endDate" validate:"required"`   // if empty, just give today's date to current time
+	BranchID  int    `json:"branchId"`                      // only for the owner, empty is all branches
+}
+
+// move the prescription to the next status of the workflow
+type UpdatePrescriptionStatusPayload struct {
+	ID     int    `json:"id" validate:"required"`
+	Status string `json:"status" validate:"required"`
+	Note   string `json:"note"`
+}
+
+type ViewPatientMedicationHistoryPayload struct {
+	PatientID int    `json:"patientId" validate:"required"`
+	StartDate string `json:"startDate" validate:"required"`
+	EndDate   string `json:"endDate" validate:"required"`
+	BranchID  int    `json:"branchId"` // only for the owner, empty is all branches
 }
 
 // view the detail of the prescription
@@ -150,6 +198,9 @@ type PrescriptionDetailPayload struct {
 	LastModified           time.Time `json:"lastModified"`
 	LastModifiedByUserName string    `json:"lastLastModifiedByUserName"`
 	PDFUrl                 string    `json:"prescPdfUrl"`
+	OriginalPrescriptionID int       `json:"originalPrescriptionId"`
+	IterationNumber        int       `json:"iterationNumber"`
+	Status                 string    `json:"status"`
 
 	Invoice struct {
 		Number       int       `json:"number"`
@@ -159,14 +210,21 @@ type PrescriptionDetailPayload struct {
 	} `json:"invoice"`
 
 	Patient struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
-		Age  int    `json:"age"`
+		ID                  int     `json:"id"`
+		Name                string  `json:"name"`
+		Age                 int     `json:"age"`
+		Gender              string  `json:"gender"`
+		MedicalRecordNumber string  `json:"medicalRecordNumber"`
+		Weight              float64 `json:"weight"`
+		AllergyNotes        string  `json:"allergyNotes"`
 	} `json:"patient"`
 
 	Doctor struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
+		ID            int    `json:"id"`
+		Name          string `json:"name"`
+		LicenseNumber string `json:"licenseNumber"`
+		Specialty     string `json:"specialty"`
+		Clinic        string `json:"clinic"`
 	} `json:"doctor"`
 
 	User struct {
@@ -175,6 +233,12 @@ type PrescriptionDetailPayload struct {
 	} `json:"user"`
 
 	MedicineSets []PrescriptionSetItemReturn `json:"medicineSets"`
+	Screenings   []PrescriptionScreening     `json:"screenings"`
+
+	// dispensed qty of the original prescription and all of its follow-ups
+	DispensedQtys []PrescriptionDispensedQty `json:"dispensedQtys"`
+
+	StatusHistories []PrescriptionStatusHistoryReturn `json:"statusHistories"`
 }
 
 type PrescriptionSetItemReturn struct {
@@ -188,6 +252,11 @@ type PrescriptionSetItemReturn struct {
 	MustFinish    bool                             `json:"mustFinish"`
 	PrintEticket  bool                             `json:"printEticket"`
 	MedicineItems []PrescriptionMedicineItemReturn `json:"medicineItems"`
+
+	// parsed from the dose and consume time, signa is nil if it can't be read
+	Signa       *Signa  `json:"signa"`
+	Instruction string  `json:"instruction"`
+	DaysSupply  float64 `json:"daysSupply"`
 }
 
 // data of the medicine per row in the prescription
@@ -201,6 +270,11 @@ type PrescriptionMedicineItemReturn struct {
 	DiscountPercentage float64 `json:"discountPercentage"`
 	DiscountAmount     float64 `json:"discountAmount"`
 	Subtotal           float64 `json:"subtotal"`
+	PrescribedQty      float64 `json:"prescribedQty"`
+	Iteration          int     `json:"iteration"`
+
+	// only for the compounded medicine, scaled by the dispensed qty
+	Ingredients []MainDoctorMedIngredient `json:"ingredients"`
 }
 
 type PrescriptionMedicineItemTemp struct {
@@ -212,6 +286,17 @@ type PrescriptionMedicineItemTemp struct {
 	DiscountPercentage float64 `json:"discountPercentage"`
 	DiscountAmount     float64 `json:"discountAmount"`
 	Subtotal           float64 `json:"subtotal"`
+	PrescribedQty      float64 `json:"prescribedQty"`
+	Iteration          int     `json:"iteration"`
+}
+
+// qty is the sum of every dispense, count is how many dispenses the medicine was given in
+type PrescriptionDispensedQty struct {
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	Unit            string  `json:"unit"`
+	Qty             float64 `json:"qty"`
+	DispenseCount   int     `json:"dispenseCount"`
 }
 
 type DeletePrescription struct {
@@ -228,6 +313,8 @@ type PrescriptionMedicineItem struct {
 	DiscountPercentage    float64 `json:"discountPercentage"`
 	DiscountAmount        float64 `json:"discountAmount"`
 	Subtotal              float64 `json:"subtotal"`
+	PrescribedQty         float64 `json:"prescribedQty"`
+	Iteration             int     `json:"iteration"`
 }
 
 type Prescription struct {
@@ -248,6 +335,101 @@ type Prescription struct {
 	PDFUrl               string        `json:"pdfUrl"`
 	DeletedAt            sql.NullTime  `json:"deletedAt"`
 	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+
+	// the follow-up dispense points to the first dispense, iteration number 0 is the first dispense
+	OriginalPrescriptionID sql.NullInt64 `json:"originalPrescriptionId"`
+	IterationNumber        int           `json:"iterationNumber"`
+
+	Status string `json:"status"`
+
+	BranchID int `json:"branchId"`
+}
+
+// from status is empty for the first status
+type PrescriptionStatusHistory struct {
+	ID             int       `json:"id"`
+	PrescriptionID int       `json:"prescriptionId"`
+	FromStatus     string    `json:"fromStatus"`
+	ToStatus       string    `json:"toStatus"`
+	Note           string    `json:"note"`
+	UserID         int       `json:"userId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type PrescriptionStatusHistoryReturn struct {
+	FromStatus string    `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	Note       string    `json:"note"`
+	UserName   string    `json:"userName"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type PrescriptionQueueReturn struct {
+	ID                      int       `json:"id"`
+	Number                  int       `json:"number"`
+	PrescriptionDate        time.Time `json:"prescriptionDate"`
+	PatientName             string    `json:"patientName"`
+	DoctorName              string    `json:"doctorName"`
+	IterationNumber         int       `json:"iterationNumber"`
+	Status                  string    `json:"status"`
+	StatusUpdatedAt         time.Time `json:"statusUpdatedAt"`
+	StatusUpdatedByUserName string    `json:"statusUpdatedByUserName"`
+}
+
+type PrescriptionQueueGroupReturn struct {
+	Status        string                    `json:"status"`
+	Prescriptions []PrescriptionQueueReturn `json:"prescriptions"`
+}
+
+// one row for each medicine given in a prescription
+type PatientMedicationHistoryItem struct {
+	PrescriptionID         int       `json:"prescriptionId"`
+	PrescriptionNumber     int       `json:"prescriptionNumber"`
+	PrescriptionDate       time.Time `json:"prescriptionDate"`
+	OriginalPrescriptionID int       `json:"originalPrescriptionId"`
+	IterationNumber        int       `json:"iterationNumber"`
+	Status                 string    `json:"status"`
+	DoctorName             string    `json:"doctorName"`
+	DoctorLicenseNumber    string    `json:"doctorLicenseNumber"`
+	InvoiceNumber          int       `json:"invoiceNumber"`
+	InvoiceDate            time.Time `json:"invoiceDate"`
+
+	Mf          string `json:"mf"`
+	Dose        string `json:"dose"`
+	SetUnit     string `json:"setUnit"`
+	ConsumeTime string `json:"consumeTime"`
+	Usage       string `json:"usage"`
+	Instruction string `json:"instruction"`
+
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	QtyString       string  `json:"qtyString"`
+	QtyFloat        float64 `json:"qtyFloat"`
+	Unit            string  `json:"unit"`
+
+	// iterations left from the original prescription after every dispense so far
+	RemainingIteration int `json:"remainingIteration"`
+}
+
+// total of each medicine in the period, used for the chronic medication review
+type PatientMedicationSummary struct {
+	MedicineBarcode string    `json:"medicineBarcode"`
+	MedicineName    string    `json:"medicineName"`
+	Unit            string    `json:"unit"`
+	TotalQty        float64   `json:"totalQty"`
+	DispenseCount   int       `json:"dispenseCount"`
+	FirstDate       time.Time `json:"firstDate"`
+	LastDate        time.Time `json:"lastDate"`
+}
+
+type PatientMedicationHistoryReturn struct {
+	Patient   Patient                        `json:"patient"`
+	Age       int                            `json:"age"`
+	Allergies []PatientAllergy               `json:"allergies"`
+	StartDate time.Time                      `json:"startDate"`
+	EndDate   time.Time                      `json:"endDate"`
+	Items     []PatientMedicationHistoryItem `json:"items"`
+	Medicines []PatientMedicationSummary     `json:"medicines"`
 }
 
 type PrescriptionSetItem struct {
@@ -272,6 +454,8 @@ type Eticket struct {
 	Size                  string    `json:"size"`
 	PDFUrl                string    `json:"pdfUrl"`
 	CreatedAt             time.Time `json:"createdAt"`
+	Template              string    `json:"template"`
+	ShakeWell             bool      `json:"shakeWell"`
 }
 
 type PrescriptionPDFReturn struct {
@@ -282,6 +466,19 @@ type PrescriptionPDFReturn struct {
 	MedicineSets []PrescriptionSetItemReturn
 }
 
+// the medicine sets are from the original prescription
+type PrescriptionCopyPDFReturn struct {
+	PrescriptionID  int
+	Number          int
+	Date            time.Time
+	CopyDate        time.Time
+	IterationNumber int
+	Patient         Patient
+	Doctor          Doctor
+	MedicineSets    []PrescriptionSetItemReturn
+	DispensedQtys   []PrescriptionDispensedQty
+}
+
 type EticketPDFReturnPayload struct {
 	Number      int     `json:"number"`
 	PatientName string  `json:"patientName"`
@@ -290,5 +487,7 @@ type EticketPDFReturnPayload struct {
 	SetUnit     string  `json:"setUnit"`
 	ConsumeTime string  `json:"consumeTime"`
 	MustFinish  bool    `json:"mustFinish"`
+	ShakeWell   bool    `json:"shakeWell"`
 	MedicineQty float64 `json:"medicineQty"`
+	Instruction string  `json:"instruction"`
 }
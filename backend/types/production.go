@@ -6,16 +6,16 @@ import (
 )
 
 type ProductionStore interface {
-	GetProductionByNumber(int) (*Production, error)
+	GetProductionByNumber(int, int) (*Production, error)
 	GetProductionByID(int) (*Production, error)
-	GetNumberOfProductions() (int, error)
+	GetNumberOfProductions(branchId int) (int, error)
 
-	GetProductionsByDate(startDate time.Time, endDate time.Time) ([]ProductionListsReturnPayload, error)
-	GetProductionsByDateAndNumber(startDate time.Time, endDate time.Time, bn int) ([]ProductionListsReturnPayload, error)
-	GetProductionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int) ([]ProductionListsReturnPayload, error)
-	GetProductionsByDateAndMedicineID(startDate time.Time, endDate time.Time, mid int) ([]ProductionListsReturnPayload, error)
-	GetProductionsByDateAndUpdatedToStock(startDate time.Time, endDate time.Time, uts bool) ([]ProductionListsReturnPayload, error)
-	GetProductionsByDateAndUpdatedToAccount(startDate time.Time, endDate time.Time, uta bool) ([]ProductionListsReturnPayload, error)
+	GetProductionsByDate(startDate time.Time, endDate time.Time, branchId int) ([]ProductionListsReturnPayload, error)
+	GetProductionsByDateAndNumber(startDate time.Time, endDate time.Time, bn int, branchId int) ([]ProductionListsReturnPayload, error)
+	GetProductionsByDateAndUserID(startDate time.Time, endDate time.Time, uid int, branchId int) ([]ProductionListsReturnPayload, error)
+	GetProductionsByDateAndMedicineID(startDate time.Time, endDate time.Time, mid int, branchId int) ([]ProductionListsReturnPayload, error)
+	GetProductionsByDateAndUpdatedToStock(startDate time.Time, endDate time.Time, uts bool, branchId int) ([]ProductionListsReturnPayload, error)
+	GetProductionsByDateAndUpdatedToAccount(startDate time.Time, endDate time.Time, uta bool, branchId int) ([]ProductionListsReturnPayload, error)
 
 	CreateProduction(Production) error
 	CreateProductionMedicineItem(ProductionMedicineItem) error
@@ -27,35 +27,98 @@ type ProductionStore interface {
 
 	// delete entirely from the db if there's error
 	AbsoluteDeleteProduction(prod Production) error
+
+	// cost of 1 unit of the medicine from its latest purchase, or from its latest
+	// production if it's never purchased. nil if the medicine has no cost yet
+	GetMedicineUnitCost(medicineId int) (*MedicineUnitCost, error)
+}
+
+type ProductionRecipeStore interface {
+	GetRecipeByID(id int) (*ProductionRecipe, error)
+
+	// nil if the medicine has no recipe
+	GetRecipeByProducedMedicineID(medicineId int) (*ProductionRecipe, error)
+
+	GetAllRecipes() ([]ProductionRecipeListsReturnPayload, error)
+	GetRecipeItems(recipeId int) ([]ProductionRecipeItemRow, error)
+
+	CreateRecipe(ProductionRecipe) error
+	CreateRecipeItem(ProductionRecipeItem) error
+	ModifyRecipe(int, ProductionRecipe, *User) error
+	DeleteRecipe(*ProductionRecipe, *User) error
+	DeleteRecipeItems(*ProductionRecipe, *User) error
 }
 
 type RegisterProductionPayload struct {
-	Number                  int     `json:"number"`
+	Number                  int    `json:"number"`
+	ProducedMedicineBarcode string `json:"producedMedicineBarcode" validate:"required"`
+	ProducedMedicineName    string `json:"producedMedicineName" validate:"required"`
+	ProducedQty             int    `json:"producedQty" validate:"required"`
+	ProducedUnit            string `json:"producedUnit" validate:"required"`
+	ProductionDate          string `json:"productionDate" validate:"required"`
+	Description             string `json:"description"`
+	UpdatedToStock          bool   `json:"updatedToStock"`
+	UpdatedToAccount        bool   `json:"updatedToAccount"`
+
+	// the qty the ingredients are for, the produced qty if empty.
+	// the difference with the produced qty is recorded as the loss
+	PlannedQty float64 `json:"plannedQty"`
+
+	// the ingredients are taken from the recipe of the produced medicine if empty
+	MedicineLists []ProductionMedicineListPayload `json:"productionMedicineList"`
+}
+
+// the cost is computed from the latest cost of the medicine
+type ProductionMedicineListPayload struct {
+	MedicineBarcode string  `json:"medicineBarcode" validate:"required"`
+	MedicineName    string  `json:"medicineName" validate:"required"`
+	Qty             float64 `json:"qty" validate:"required"`
+	Unit            string  `json:"unit" validate:"required"`
+	Cost            float64 `json:"cost"`
+}
+
+type RegisterProductionRecipePayload struct {
 	ProducedMedicineBarcode string  `json:"producedMedicineBarcode" validate:"required"`
 	ProducedMedicineName    string  `json:"producedMedicineName" validate:"required"`
-	ProducedQty             int     `json:"producedQty" validate:"required"`
-	ProducedUnit            string  `json:"producedUnit" validate:"required"`
-	ProductionDate          string  `json:"productionDate" validate:"required"`
+	YieldQty                float64 `json:"yieldQty" validate:"required,gt=0"`
+	YieldUnit               string  `json:"yieldUnit" validate:"required"`
 	Description             string  `json:"description"`
-	UpdatedToStock          bool    `json:"updatedToStock"`
-	UpdatedToAccount        bool    `json:"updatedToAccount"`
-	TotalCost               float64 `json:"totalCost" validate:"required"`
 
-	MedicineLists []ProductionMedicineListPayload `json:"productionMedicineList" validate:"required"`
+	Items []ProductionRecipeItemPayload `json:"items" validate:"required,min=1,dive"`
 }
 
-type ProductionMedicineListPayload struct {
+type ProductionRecipeItemPayload struct {
 	MedicineBarcode string  `json:"medicineBarcode" validate:"required"`
 	MedicineName    string  `json:"medicineName" validate:"required"`
-	Qty             float64 `json:"qty" validate:"required"`
+	Qty             float64 `json:"qty" validate:"required,gt=0"`
 	Unit            string  `json:"unit" validate:"required"`
-	Cost            float64 `json:"cost" validate:"required"`
+}
+
+type ModifyProductionRecipePayload struct {
+	ID      int                             `json:"id" validate:"required"`
+	NewData RegisterProductionRecipePayload `json:"newData" validate:"required"`
+}
+
+type DeleteProductionRecipePayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type ViewProductionRecipeDetailPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+// the ingredients and cost needed to produce the qty with the recipe
+type ScaleProductionRecipePayload struct {
+	ProducedMedicineBarcode string  `json:"producedMedicineBarcode" validate:"required"`
+	PlannedQty              float64 `json:"plannedQty" validate:"required,gt=0"`
+	Unit                    string  `json:"unit" validate:"required"`
 }
 
 // only view the production list
 type ViewProductionsPayload struct {
 	StartDate string `json:"startDate" validate:"required"` // if empty, just give today's date from morning
 	EndDate   string `json:"endDate" validate:"required"`   // if empty, just give today's date to current time
+	BranchID  int    `json:"branchId"`                      // only for the owner, empty is all branches
 }
 
 // view the detail of the production
@@ -96,6 +159,11 @@ type ProductionDetailPayload struct {
 	UpdatedToAccount bool      `json:"updatedToAccount"`
 	TotalCost        float64   `json:"totalCost"`
 
+	RecipeID        int     `json:"recipeId"`
+	PlannedQty      float64 `json:"plannedQty"`
+	LossQty         float64 `json:"lossQty"`
+	YieldPercentage float64 `json:"yieldPercentage"`
+
 	User struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
@@ -153,4 +221,82 @@ type Production struct {
 	LastModifiedByUserID int           `json:"lastLastModifiedByUserId"`
 	DeletedAt            sql.NullTime  `json:"deletedAt"`
 	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+
+	ProductionRecipeID sql.NullInt64 `json:"productionRecipeId"`
+	PlannedQty         float64       `json:"plannedQty"`
+	LossQty            float64       `json:"lossQty"`
+
+	BranchID int `json:"branchId"`
+}
+
+type MedicineUnitCost struct {
+	Cost   float64 `json:"cost"`
+	UnitID int     `json:"unitId"`
+}
+
+// data to be sent back to the client after scaling the recipe
+type ProductionRecipeScaleReturnPayload struct {
+	RecipeID      int                             `json:"recipeId"`
+	PlannedQty    float64                         `json:"plannedQty"`
+	Unit          string                          `json:"unit"`
+	MedicineLists []ProductionMedicineListPayload `json:"medicineLists"`
+	TotalCost     float64                         `json:"totalCost"`
+}
+
+type ProductionRecipeListsReturnPayload struct {
+	ID                   int       `json:"id"`
+	ProducedMedicineName string    `json:"producedMedicineName"`
+	YieldQty             float64   `json:"yieldQty"`
+	YieldUnit            string    `json:"yieldUnit"`
+	Description          string    `json:"description"`
+	NumberOfItems        int       `json:"numberOfItems"`
+	LastModified         time.Time `json:"lastModified"`
+}
+
+type ProductionRecipeDetailPayload struct {
+	ID int `json:"id"`
+
+	ProducedMedicine struct {
+		Barcode string `json:"barcode"`
+		Name    string `json:"name"`
+	} `json:"producedMedicine"`
+
+	YieldQty               float64                   `json:"yieldQty"`
+	YieldUnit              string                    `json:"yieldUnit"`
+	Description            string                    `json:"description"`
+	CreatedAt              time.Time                 `json:"createdAt"`
+	LastModified           time.Time                 `json:"lastModified"`
+	LastModifiedByUserName string                    `json:"lastModifiedByUserName"`
+	Items                  []ProductionRecipeItemRow `json:"items"`
+}
+
+type ProductionRecipeItemRow struct {
+	ID              int     `json:"id"`
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	Qty             float64 `json:"qty"`
+	Unit            string  `json:"unit"`
+}
+
+// the ingredients are for the yield qty of the produced medicine
+type ProductionRecipe struct {
+	ID                   int           `json:"id"`
+	ProducedMedicineID   int           `json:"producedMedicineId"`
+	YieldQty             float64       `json:"yieldQty"`
+	YieldUnitID          int           `json:"yieldUnitId"`
+	Description          string        `json:"description"`
+	UserID               int           `json:"userId"`
+	CreatedAt            time.Time     `json:"createdAt"`
+	LastModified         time.Time     `json:"lastModified"`
+	LastModifiedByUserID int           `json:"lastModifiedByUserId"`
+	DeletedAt            sql.NullTime  `json:"deletedAt"`
+	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+}
+
+type ProductionRecipeItem struct {
+	ID                 int     `json:"id"`
+	ProductionRecipeID int     `json:"productionRecipeId"`
+	MedicineID         int     `json:"medicineId"`
+	Qty                float64 `json:"qty"`
+	UnitID             int     `json:"unitId"`
 }
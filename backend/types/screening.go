@@ -0,0 +1,80 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+// interaction rules are loaded from a local json or csv file, not from the db
+type InteractionRuleStore interface {
+	GetInteractionRules(ingredientA string, ingredientB string) []InteractionRule
+	GetAllInteractionRules() []InteractionRule
+	ReloadInteractionRules() error
+}
+
+type PatientAllergyStore interface {
+	GetAllergyByID(id int) (*PatientAllergy, error)
+	GetAllergiesByPatientID(patientId int) ([]PatientAllergy, error)
+	CreateAllergy(PatientAllergy) error
+	DeleteAllergy(*PatientAllergy, *User) error
+}
+
+type RegisterPatientAllergyPayload struct {
+	PatientID int    `json:"patientId" validate:"required"`
+	Allergen  string `json:"allergen" validate:"required"`
+	Reaction  string `json:"reaction"`
+	Severity  string `json:"severity"`
+}
+
+type DeletePatientAllergyPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+// check the medicines before the prescription is registered
+type ScreenPrescriptionPayload struct {
+	Patient  PrescriptionPatientPayload   `json:"patient" validate:"required"`
+	SetItems []PrescriptionSetItemPayload `json:"setItems" validate:"required"`
+}
+
+type InteractionRule struct {
+	IngredientA string `json:"ingredientA"`
+	IngredientB string `json:"ingredientB"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// the compounded medicine is screened by its ingredients, the results name the compounded medicine
+type ScreeningMedicine struct {
+	Medicine    Medicine
+	Ingredients []Medicine // only the medicine itself if it is not compounded
+}
+
+type ScreeningResult struct {
+	Type        string   `json:"type"`
+	Severity    string   `json:"severity"`
+	Blocking    bool     `json:"blocking"`
+	Medicines   []string `json:"medicines"`
+	Description string   `json:"description"`
+}
+
+type PatientAllergy struct {
+	ID        int       `json:"id"`
+	PatientID int       `json:"patientId"`
+	Allergen  string    `json:"allergen"`
+	Reaction  string    `json:"reaction"`
+	Severity  string    `json:"severity"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PrescriptionScreening struct {
+	ID                   int           `json:"id"`
+	PrescriptionID       int           `json:"prescriptionId"`
+	Type                 string        `json:"type"`
+	Severity             string        `json:"severity"`
+	Blocking             bool          `json:"blocking"`
+	Medicines            string        `json:"medicines"`
+	Description          string        `json:"description"`
+	OverrideReason       string        `json:"overrideReason"`
+	AcknowledgedByUserID sql.NullInt64 `json:"acknowledgedByUserId"`
+	CreatedAt            time.Time     `json:"createdAt"`
+}
@@ -0,0 +1,63 @@
+package types
+
+import "time"
+
+type SettingStore interface {
+	// the values from the environment if it is not saved yet
+	GetCompanySetting() (*CompanySetting, error)
+	SaveCompanySetting(CompanySetting, *User) error
+	UpdateLogoURL(logoUrl string, user *User) error
+
+	// empty footer if it is not saved yet
+	GetDocumentSetting(documentType string) (*DocumentSetting, error)
+	GetAllDocumentSettings() ([]DocumentSetting, error)
+	SaveDocumentSetting(DocumentSetting, *User) error
+}
+
+type ModifyCompanySettingPayload struct {
+	Name                       string `json:"name" validate:"required"`
+	Address                    string `json:"address" validate:"required"`
+	PhoneNumber                string `json:"phoneNumber"`
+	WhatsAppNumber             string `json:"whatsAppNumber"`
+	BusinessRegistrationNumber string `json:"businessRegistrationNumber"`
+	Pharmacist                 string `json:"pharmacist"`
+	PharmacistLicenseNumber    string `json:"pharmacistLicenseNumber"`
+	Slogan                     string `json:"slogan" validate:"max=255"`
+}
+
+type ModifyDocumentSettingPayload struct {
+	DocumentType string `json:"documentType" validate:"required,oneof=invoice purchase-invoice purchase-order prescription eticket stock-transfer"`
+	Footer       string `json:"footer" validate:"max=255"`
+}
+
+// the company data and the footer printed on the document
+type DocumentBranding struct {
+	Company CompanySetting `json:"company"`
+	Footer  string         `json:"footer"`
+}
+
+type CompanySetting struct {
+	ID                         int       `json:"id"`
+	Name                       string    `json:"name"`
+	Address                    string    `json:"address"`
+	PhoneNumber                string    `json:"phoneNumber"`
+	WhatsAppNumber             string    `json:"whatsAppNumber"`
+	BusinessRegistrationNumber string    `json:"businessRegistrationNumber"`
+	Pharmacist                 string    `json:"pharmacist"`
+	PharmacistLicenseNumber    string    `json:"pharmacistLicenseNumber"`
+	LogoURL                    string    `json:"logoUrl"`
+	Slogan                     string    `json:"slogan"`
+	LastModified               time.Time `json:"lastModified"`
+	LastModifiedByUserID       int       `json:"lastModifiedByUserId"`
+
+	// the logo file for the documents, empty if it can't be read
+	Logo []byte `json:"-"`
+}
+
+type DocumentSetting struct {
+	ID                   int       `json:"id"`
+	DocumentType         string    `json:"documentType"`
+	Footer               string    `json:"footer"`
+	LastModified         time.Time `json:"lastModified"`
+	LastModifiedByUserID int       `json:"lastModifiedByUserId"`
+}
@@ -0,0 +1,27 @@
+package types
+
+type ParseSignaPayload struct {
+	Dose        string  `json:"dose" validate:"required"`
+	ConsumeTime string  `json:"consumeTime"`
+	Usage       string  `json:"usage"`
+	SetUnit     string  `json:"setUnit"`
+	Qty         float64 `json:"qty"`
+}
+
+// structured form of the dose and consume time written by the doctor,
+// either times per day or every interval hours is set
+type Signa struct {
+	TimesPerDay   float64 `json:"timesPerDay"`
+	IntervalHours int     `json:"intervalHours"`
+	AmountPerDose float64 `json:"amountPerDose"`
+	DailyAmount   float64 `json:"dailyAmount"`
+	Timing        string  `json:"timing"`
+	AsNeeded      bool    `json:"asNeeded"`
+}
+
+type SignaReturn struct {
+	Signa       *Signa  `json:"signa"`
+	Instruction string  `json:"instruction"`
+	DaysSupply  float64 `json:"daysSupply"`
+	Warning     string  `json:"warning"`
+}
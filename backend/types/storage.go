@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// the documents are grouped by category, e.g. invoice, prescription.
+// the file name is unique in its category
+type DocumentStorage interface {
+	SaveDocument(category string, fileName string, content []byte) (*Document, error)
+	GetDocument(category string, fileName string) (*Document, error)
+	IsDocumentExist(category string, fileName string) (bool, error)
+	DeleteDocument(category string, fileName string) error
+}
+
+// hash is the hex sha256 of the content
+type Document struct {
+	Category     string    `json:"category"`
+	FileName     string    `json:"fileName"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	Hash         string    `json:"hash"`
+	LastModified time.Time `json:"lastModified"`
+	Content      []byte    `json:"-"`
+}
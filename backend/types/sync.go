@@ -0,0 +1,77 @@
+package types
+
+import "time"
+
+type SyncStore interface {
+	// the invoice pushed by the terminal is found by the uuid made in the terminal
+	GetOfflineInvoiceByClientUUID(clientUuid string) (*OfflineInvoice, error)
+
+	// saved as pending, false if the uuid is already claimed.
+	// the pending claim left by a push that stopped is taken again if the invoice was not made yet
+	ClaimOfflineInvoice(OfflineInvoice) (bool, error)
+	SetOfflineInvoiceID(clientUuid string, invoiceId int) error
+	FinishOfflineInvoice(clientUuid string, invoiceId int, status string, conflictNote string) error
+	DeleteOfflineInvoice(clientUuid string) error
+}
+
+// empty cursor pulls all of the master data
+type SyncPullPayload struct {
+	Cursor string `json:"cursor"`
+}
+
+type SyncPushPayload struct {
+	TerminalID string                  `json:"terminalId" validate:"required"`
+	Invoices   []OfflineInvoicePayload `json:"invoices" validate:"required,min=1,dive"`
+}
+
+// the number is the one given offline by the terminal, the server gives a new one
+type OfflineInvoicePayload struct {
+	ClientUUID string                 `json:"clientUuid" validate:"required,uuid"`
+	Invoice    RegisterInvoicePayload `json:"invoice" validate:"required"`
+}
+
+// the deleted data is included, the terminal drops the ones with deletedAt
+type SyncPullReturnPayload struct {
+	Cursor    string                `json:"cursor"` // send on the next pull
+	Medicines []Medicine            `json:"medicines"`
+	Units     []Unit                `json:"units"`
+	Customers []Customer            `json:"customers"`
+	Doctors   []Doctor              `json:"doctors"`
+	Stocks    []SyncStockReturnData `json:"stocks"` // the stock of the terminal's branch
+}
+
+type SyncStockReturnData struct {
+	MedicineID int     `json:"medicineId"`
+	Qty        float64 `json:"qty"`
+}
+
+type OfflineInvoiceResult struct {
+	ClientUUID   string                 `json:"clientUuid"`
+	Status       string                 `json:"status"`
+	InvoiceID    int                    `json:"invoiceId"`
+	Number       int                    `json:"number"`
+	Conflicts    []OfflineStockConflict `json:"conflicts"`
+	ConflictNote string                 `json:"conflictNote"`
+	Error        string                 `json:"error"`
+}
+
+// the qty is in the first unit of the medicine
+type OfflineStockConflict struct {
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	SoldQty         float64 `json:"soldQty"`
+	ShortageQty     float64 `json:"shortageQty"`
+}
+
+type OfflineInvoice struct {
+	ID           int       `json:"id"`
+	ClientUUID   string    `json:"clientUuid"`
+	TerminalID   string    `json:"terminalId"`
+	ClientNumber int       `json:"clientNumber"`
+	InvoiceID    int       `json:"invoiceId"` // 0 until the invoice is made
+	Status       string    `json:"status"`
+	ConflictNote string    `json:"conflictNote"`
+	BranchID     int       `json:"branchId"`
+	UserID       int       `json:"userId"`
+	SyncedAt     time.Time `json:"syncedAt"`
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+type TaxStore interface {
+	GetTaxByID(id int) (*Tax, error)
+	GetTaxesByCode(code string) ([]Tax, error)
+
+	// get the tax that is in effect on the given date
+	GetEffectiveTaxByCode(code string, date time.Time) (*Tax, error)
+
+	CreateTax(Tax) error
+	GetAllTaxes() ([]Tax, error)
+	DeleteTax(*Tax, *User) error
+	ModifyTax(int, Tax, *User) error
+
+	// output tax comes from the sales invoices, input tax from the purchase invoices
+	GetOutputTaxReport(startDate time.Time, endDate time.Time, branchId int) ([]TaxReportItem, error)
+	GetInputTaxReport(startDate time.Time, endDate time.Time, branchId int) ([]TaxReportItem, error)
+}
+
+type RegisterTaxPayload struct {
+	Code          string  `json:"code" validate:"required"`
+	Name          string  `json:"name" validate:"required"`
+	Percentage    float64 `json:"percentage" validate:"gte=0,lte=100"`
+	EffectiveDate string  `json:"effectiveDate" validate:"required"`
+	Description   string  `json:"description"`
+}
+
+type ModifyTaxPayload struct {
+	ID      int                `json:"id" validate:"required"`
+	NewData RegisterTaxPayload `json:"newData" validate:"required"`
+}
+
+type DeleteTaxPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type GetOneTaxPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type ViewTaxReportPayload struct {
+	StartDate string `json:"startDate" validate:"required"`
+	EndDate   string `json:"endDate" validate:"required"`
+	BranchID  int    `json:"branchId"` // only for the owner, empty is all branches
+}
+
+type TaxReportItem struct {
+	ID            int       `json:"id"`
+	Number        int       `json:"number"`
+	PartyName     string    `json:"partyName"` // customer for output tax, supplier for input tax
+	InvoiceDate   time.Time `json:"invoiceDate"`
+	TaxableAmount float64   `json:"taxableAmount"`
+	TaxPercentage float64   `json:"taxPercentage"`
+	TaxAmount     float64   `json:"taxAmount"`
+}
+
+type TaxReportSummary struct {
+	Items              []TaxReportItem `json:"items"`
+	TotalTaxableAmount float64         `json:"totalTaxableAmount"`
+	TotalTaxAmount     float64         `json:"totalTaxAmount"`
+}
+
+type TaxReportReturnPayload struct {
+	StartDate time.Time        `json:"startDate"`
+	EndDate   time.Time        `json:"endDate"`
+	OutputTax TaxReportSummary `json:"outputTax"`
+	InputTax  TaxReportSummary `json:"inputTax"`
+
+	// output tax minus input tax, positive means the tax is payable
+	NetTaxAmount float64 `json:"netTaxAmount"`
+}
+
+type Tax struct {
+	ID                   int           `json:"id"`
+	Code                 string        `json:"code"`
+	Name                 string        `json:"name"`
+	Percentage           float64       `json:"percentage"`
+	EffectiveDate        time.Time     `json:"effectiveDate"`
+	Description          string        `json:"description"`
+	CreatedAt            time.Time     `json:"createdAt"`
+	LastModified         time.Time     `json:"lastModified"`
+	LastModifiedByUserID int           `json:"lastModifiedByUserId"`
+	DeletedAt            sql.NullTime  `json:"deletedAt"`
+	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+}
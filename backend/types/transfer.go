@@ -0,0 +1,194 @@
+package types
+
+import (
+	"database/sql"
+	"time"
+)
+
+type StockTransferStore interface {
+	GetStockTransferByID(int) (*StockTransfer, error)
+	GetStockTransferID(number int, fromBranchId int, toBranchId int, requestDate time.Time) (int, error)
+
+	// the transfer is numbered by the requesting branch
+	GetNumberOfStockTransfers(toBranchId int) (int, error)
+
+	CreateStockTransfer(StockTransfer) error
+	CreateStockTransferItem(StockTransferItem) error
+
+	// the transfers sent from or to the branch, branchId 0 means all branches
+	GetStockTransfersByDate(startDate time.Time, endDate time.Time, branchId int) ([]StockTransferListsReturnPayload, error)
+	GetStockTransfersByDateAndNumber(startDate time.Time, endDate time.Time, number int, branchId int) ([]StockTransferListsReturnPayload, error)
+	GetStockTransfersByDateAndStatus(startDate time.Time, endDate time.Time, status string, branchId int) ([]StockTransferListsReturnPayload, error)
+
+	GetStockTransferItem(stockTransferId int) ([]StockTransferItemReturn, error)
+
+	DeleteStockTransfer(*StockTransfer, *User) error
+
+	// false if the status was already changed by another request
+	UpdateDispatch(stockTransferId int, dispatchDate time.Time, items []StockTransferDispatchItemPayload, user *User) (bool, error)
+	UpdateReceipt(stockTransferId int, receiptDate time.Time, items []StockTransferReceiptItemPayload, user *User) (bool, error)
+
+	// delete entirely from the db if there's error
+	AbsoluteDeleteStockTransfer(StockTransfer) error
+
+	UpdatePDFUrl(stockTransferId int, pdfUrl string) error
+	IsPDFUrlExist(pdfUrl string) (bool, error)
+}
+
+// the transfer is requested by the caller's branch from another branch
+type RegisterStockTransferPayload struct {
+	Number       int    `json:"number" validate:"required"`
+	FromBranchID int    `json:"fromBranchId" validate:"required"`
+	RequestDate  string `json:"requestDate" validate:"required"`
+	Description  string `json:"description"`
+
+	MedicineLists []StockTransferMedicineListPayload `json:"medicineLists" validate:"required,min=1,dive"`
+}
+
+type StockTransferMedicineListPayload struct {
+	MedicineBarcode string  `json:"medicineBarcode" validate:"required"`
+	MedicineName    string  `json:"medicineName" validate:"required"`
+	RequestQty      float64 `json:"requestQty" validate:"required,gt=0"`
+	Unit            string  `json:"unit" validate:"required"`
+	Remarks         string  `json:"remarks"`
+}
+
+// the qty is in the unit of the request
+type DispatchStockTransferPayload struct {
+	ID           int    `json:"id" validate:"required"`
+	DispatchDate string `json:"dispatchDate" validate:"required"`
+
+	Items []StockTransferDispatchItemPayload `json:"items" validate:"required,min=1,unique=ID,dive"`
+}
+
+type StockTransferDispatchItemPayload struct {
+	ID          int     `json:"id" validate:"required"`       // stock transfer item id
+	DispatchQty float64 `json:"dispatchQty" validate:"gte=0"` // not more than the requested qty
+	BatchNumber string  `json:"batchNumber"`
+}
+
+// the difference between the dispatched and the received qty is recorded as the discrepancy
+type ReceiveStockTransferPayload struct {
+	ID          int    `json:"id" validate:"required"`
+	ReceiptDate string `json:"receiptDate" validate:"required"`
+
+	Items []StockTransferReceiptItemPayload `json:"items" validate:"required,min=1,unique=ID,dive"`
+}
+
+type StockTransferReceiptItemPayload struct {
+	ID              int     `json:"id" validate:"required"`       // stock transfer item id
+	ReceivedQty     float64 `json:"receivedQty" validate:"gte=0"` // not more than the dispatched qty
+	DiscrepancyNote string  `json:"discrepancyNote"`
+}
+
+// only view the stock transfer list
+type ViewStockTransferPayload struct {
+	StartDate string `json:"startDate" validate:"required"` // if empty, just give today's date from morning
+	EndDate   string `json:"endDate" validate:"required"`   // if empty, just give today's date to current time
+	BranchID  int    `json:"branchId"`                      // only for the owner, empty is all branches
+}
+
+type ViewStockTransferDetailPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type DeleteStockTransferPayload struct {
+	ID int `json:"id" validate:"required"`
+}
+
+type StockTransferItemReturn struct {
+	ID              int     `json:"id"`
+	MedicineBarcode string  `json:"medicineBarcode"`
+	MedicineName    string  `json:"medicineName"`
+	RequestQty      float64 `json:"requestQty"`
+	DispatchQty     float64 `json:"dispatchQty"`
+	ReceivedQty     float64 `json:"receivedQty"`
+	DiscrepancyQty  float64 `json:"discrepancyQty"` // dispatched but not received
+	Unit            string  `json:"unit"`
+	BatchNumber     string  `json:"batchNumber"`
+	Remarks         string  `json:"remarks"`
+	DiscrepancyNote string  `json:"discrepancyNote"`
+}
+
+type StockTransferListsReturnPayload struct {
+	ID             int       `json:"id"`
+	Number         int       `json:"number"`
+	FromBranchName string    `json:"fromBranchName"`
+	ToBranchName   string    `json:"toBranchName"`
+	Status         string    `json:"status"`
+	UserName       string    `json:"userName"`
+	RequestDate    time.Time `json:"requestDate"`
+}
+
+type StockTransferDetailPayload struct {
+	ID                     int        `json:"id"`
+	Number                 int        `json:"number"`
+	Status                 string     `json:"status"`
+	RequestDate            time.Time  `json:"requestDate"`
+	DispatchDate           *time.Time `json:"dispatchDate"`
+	ReceiptDate            *time.Time `json:"receiptDate"`
+	Description            string     `json:"description"`
+	CreatedAt              time.Time  `json:"createdAt"`
+	LastModified           time.Time  `json:"lastModified"`
+	LastModifiedByUserName string     `json:"lastModifiedByUserName"`
+	PdfURL                 string     `json:"pdfUrl"`
+
+	FromBranch Branch `json:"fromBranch"`
+	ToBranch   Branch `json:"toBranch"`
+
+	RequestedByUserName  string `json:"requestedByUserName"`
+	DispatchedByUserName string `json:"dispatchedByUserName"`
+	ReceivedByUserName   string `json:"receivedByUserName"`
+
+	MedicineLists []StockTransferItemReturn `json:"medicineLists"`
+}
+
+type StockTransferPDFPayload struct {
+	Number               int                       `json:"number"`
+	Status               string                    `json:"status"`
+	RequestDate          time.Time                 `json:"requestDate"`
+	DispatchDate         *time.Time                `json:"dispatchDate"`
+	ReceiptDate          *time.Time                `json:"receiptDate"`
+	Description          string                    `json:"description"`
+	RequestedByUserName  string                    `json:"requestedByUserName"`
+	DispatchedByUserName string                    `json:"dispatchedByUserName"`
+	ReceivedByUserName   string                    `json:"receivedByUserName"`
+	MedicineLists        []StockTransferItemReturn `json:"medicineLists"`
+
+	FromBranch Branch `json:"fromBranch"`
+	ToBranch   Branch `json:"toBranch"`
+}
+
+type StockTransfer struct {
+	ID                   int           `json:"id"`
+	Number               int           `json:"number"`
+	FromBranchID         int           `json:"fromBranchId"`
+	ToBranchID           int           `json:"toBranchId"`
+	Status               string        `json:"status"`
+	RequestDate          time.Time     `json:"requestDate"`
+	DispatchDate         sql.NullTime  `json:"dispatchDate"`
+	ReceiptDate          sql.NullTime  `json:"receiptDate"`
+	Description          string        `json:"description"`
+	UserID               int           `json:"userId"`
+	DispatchedByUserID   sql.NullInt64 `json:"dispatchedByUserId"`
+	ReceivedByUserID     sql.NullInt64 `json:"receivedByUserId"`
+	PdfURL               string        `json:"pdfUrl"`
+	CreatedAt            time.Time     `json:"createdAt"`
+	LastModified         time.Time     `json:"lastModified"`
+	LastModifiedByUserID int           `json:"lastModifiedByUserId"`
+	DeletedAt            sql.NullTime  `json:"deletedAt"`
+	DeletedByUserID      sql.NullInt64 `json:"deletedByUserId"`
+}
+
+type StockTransferItem struct {
+	ID              int     `json:"id"`
+	StockTransferID int     `json:"stockTransferId"`
+	MedicineID      int     `json:"medicineId"`
+	RequestQty      float64 `json:"requestQty"`
+	DispatchQty     float64 `json:"dispatchQty"`
+	ReceivedQty     float64 `json:"receivedQty"`
+	UnitID          int     `json:"unitId"`
+	BatchNumber     string  `json:"batchNumber"`
+	Remarks         string  `json:"remarks"`
+	DiscrepancyNote string  `json:"discrepancyNote"`
+}
@@ -20,6 +20,9 @@ type UnitStore interface {
 	GetUnitByName(string) (*Unit, error)
 	GetUnitByID(int) (*Unit, error)
 	CreateUnit(string) error
+
+	// the unit is never modified, so only the new ones are synced
+	GetUnitsCreatedSince(since time.Time) ([]Unit, error)
 }
 type Unit PaymentMethod
 
@@ -23,6 +23,20 @@ type UserStore interface {
 	SaveToken(int, *TokenDetails) error
 	DeleteToken(int) error
 	ValidateUserToken(http.ResponseWriter, *http.Request, bool) (*User, error)
+
+	// the user that must change the password is not refused
+	ValidateUserTokenForPasswordChange(http.ResponseWriter, *http.Request) (*User, error)
+
+	ChangePassword(userId int, hashedPassword string, changedByUser *User) error
+	GetPasswordHistory(userId int, limit int) ([]string, error)
+
+	// the password can't be used until it is changed with the code
+	CreatePasswordResetCode(resetCode PasswordResetCode, resetTOTP bool, createdByUser *User) error
+	GetValidPasswordResetCodes(userId int) ([]PasswordResetCode, error)
+	UsePasswordResetCode(id int) error
+
+	SaveTOTPSecret(userId int, secret string) error
+	SetTOTPEnabled(userId int, enabled bool, user *User) error
 }
 
 // register new user
@@ -32,6 +46,9 @@ type RegisterUserPayload struct {
 	Password      string `json:"password" validate:"required,min=3,max=130"`
 	PhoneNumber   string `json:"phoneNumber" validate:"required"`
 	Admin         bool   `json:"admin"`
+	Pharmacist    bool   `json:"pharmacist"`
+	BranchID      int    `json:"branchId"` // the admin's branch if it is empty
+	Owner         bool   `json:"owner"`    // can view all branches, only set by the owner
 }
 
 // delete user account
@@ -61,7 +78,8 @@ type GetOneUserPayload struct {
 // normal log-in
 type LoginUserPayload struct {
 	Name     string `json:"name" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Password string `json:"password" validate:"required"` // or the reset code given by the admin
+	TOTPCode string `json:"totpCode"`                     // required if the totp is enabled
 }
 
 // validate token request from client
@@ -78,4 +96,12 @@ type User struct {
 	PhoneNumber  string    `json:"phoneNumber"`
 	LastLoggedIn time.Time `json:"lastLoggedIn"`
 	CreatedAt    time.Time `json:"createdAt"`
+	Pharmacist   bool      `json:"pharmacist"`
+	BranchID     int       `json:"branchId"`
+	Owner        bool      `json:"owner"`
+
+	PasswordChangedAt  time.Time `json:"passwordChangedAt"`
+	MustChangePassword bool      `json:"mustChangePassword"`
+	TOTPSecret         string    `json:"-"`
+	TOTPEnabled        bool      `json:"totpEnabled"`
 }
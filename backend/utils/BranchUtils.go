@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the owner can view one branch or all of them (BRANCH_ALL),
+// the other users can only view their own branch
+func GetBranchScope(user *types.User, branchId int) (int, error) {
+	if user.Owner {
+		return branchId, nil
+	}
+
+	if branchId != constants.BRANCH_ALL && branchId != user.BranchID {
+		return 0, fmt.Errorf("user %s cannot view branch id %d", user.Name, branchId)
+	}
+
+	return user.BranchID, nil
+}
+
+// the data of the other branches can only be opened by the owner
+func CheckBranchAccess(user *types.User, branchId int) error {
+	if user.Owner || user.BranchID == branchId {
+		return nil
+	}
+
+	return fmt.Errorf("the data belongs to another branch")
+}
+
+// the branch from the "branchId" query, empty is all branches
+func ParseBranchIDQuery(value string) (int, error) {
+	if value == "" {
+		return constants.BRANCH_ALL, nil
+	}
+
+	branchId, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid branch id %s", value)
+	}
+
+	return branchId, nil
+}
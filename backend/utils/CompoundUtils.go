@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the compounded medicine (RO-xxx) has no stock of its own, so the stock is taken from its ingredients.
+// the recipe is for 1 first unit of the compounded medicine, so it is scaled by the qty.
+// the medicine itself is returned if it's not a compounded medicine
+func ExpandMedicineStockItems(mdmiStore types.MainDoctorMedItemStore, medStore types.MedicineStore, unitStore types.UnitStore, medData *types.Medicine, unit *types.Unit, qty float64) ([]types.MedicineStockItem, error) {
+	return expandMedicineStockItems(mdmiStore, medStore, unitStore, medData, unit, qty, 0)
+}
+
+func expandMedicineStockItems(mdmiStore types.MainDoctorMedItemStore, medStore types.MedicineStore, unitStore types.UnitStore, medData *types.Medicine, unit *types.Unit, qty float64, depth int) ([]types.MedicineStockItem, error) {
+	ingredients, err := mdmiStore.GetMainDoctorMedIngredients(medData.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ingredients) == 0 {
+		return []types.MedicineStockItem{{Medicine: medData, Unit: unit, Qty: qty}}, nil
+	}
+
+	if depth >= constants.MAX_COMPOUND_RECIPE_DEPTH {
+		return nil, fmt.Errorf("recipe of %s is nested too deep", medData.Name)
+	}
+
+	compoundQty, err := ConvertToFirstUnit(medData, unit, qty)
+	if err != nil {
+		return nil, err
+	}
+
+	stockItems := make([]types.MedicineStockItem, 0)
+
+	for _, ingredient := range ingredients {
+		ingredientData, err := medStore.GetMedicineByBarcode(ingredient.MedicineBarcode)
+		if err != nil {
+			return nil, fmt.Errorf("ingredient %s of %s doesn't exists", ingredient.MedicineName, medData.Name)
+		}
+
+		ingredientUnit, err := unitStore.GetUnitByName(ingredient.Unit)
+		if err != nil || ingredientUnit == nil {
+			return nil, fmt.Errorf("unit %s of ingredient %s not found", ingredient.Unit, ingredient.MedicineName)
+		}
+
+		items, err := expandMedicineStockItems(mdmiStore, medStore, unitStore, ingredientData, ingredientUnit, (ingredient.Qty * compoundQty), (depth + 1))
+		if err != nil {
+			return nil, err
+		}
+
+		stockItems = append(stockItems, items...)
+	}
+
+	return stockItems, nil
+}
+
+// ingredients used by the dispensed qty, empty if it's not a compounded medicine
+func GetCompoundedMedicineIngredients(mdmiStore types.MainDoctorMedItemStore, medStore types.MedicineStore, unitStore types.UnitStore, medData *types.Medicine, unit *types.Unit, qty float64) ([]types.MainDoctorMedIngredient, error) {
+	ingredients := make([]types.MainDoctorMedIngredient, 0)
+
+	stockItems, err := ExpandMedicineStockItems(mdmiStore, medStore, unitStore, medData, unit, qty)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stockItems) == 1 && stockItems[0].Medicine.ID == medData.ID {
+		return ingredients, nil
+	}
+
+	for _, stockItem := range stockItems {
+		ingredients = append(ingredients, types.MainDoctorMedIngredient{
+			MedicineBarcode: stockItem.Medicine.Barcode,
+			MedicineName:    stockItem.Medicine.Name,
+			Qty:             stockItem.Qty,
+			Unit:            stockItem.Unit.Name,
+		})
+	}
+
+	return ingredients, nil
+}
+
+// the ingredients of the compounded medicine to be saved with the invoice or the prescription,
+// empty if it's not a compounded medicine
+func GetDispensedIngredients(referenceType string, referenceId int, medData *types.Medicine, stockItems []types.MedicineStockItem) []types.DispensedIngredient {
+	ingredients := make([]types.DispensedIngredient, 0)
+
+	if len(stockItems) == 1 && stockItems[0].Medicine.ID == medData.ID {
+		return ingredients
+	}
+
+	for _, stockItem := range stockItems {
+		ingredients = append(ingredients, types.DispensedIngredient{
+			ReferenceType:      referenceType,
+			ReferenceID:        referenceId,
+			CompoundMedicineID: medData.ID,
+			MedicineID:         stockItem.Medicine.ID,
+			Qty:                stockItem.Qty,
+			UnitID:             stockItem.Unit.ID,
+		})
+	}
+
+	return ingredients
+}
+
+// the stock items taken by the invoice or the prescription. the compounded medicine uses the ingredients
+// saved when it was dispensed, so the stock given back doesn't change with the recipe.
+// the ones dispensed before the ingredients were saved use the current recipe
+func GetDispensedStockItems(mdmiStore types.MainDoctorMedItemStore, medStore types.MedicineStore, unitStore types.UnitStore, referenceType string, referenceId int, medicineItems []types.MedicineStockItem) ([]types.MedicineStockItem, error) {
+	dispensedIngredients, err := mdmiStore.GetDispensedIngredients(referenceType, referenceId)
+	if err != nil {
+		return nil, err
+	}
+
+	ingredients := make(map[int][]types.DispensedIngredientReturn)
+	for _, ingredient := range dispensedIngredients {
+		ingredients[ingredient.CompoundMedicineID] = append(ingredients[ingredient.CompoundMedicineID], ingredient)
+	}
+
+	stockItems := make([]types.MedicineStockItem, 0)
+
+	// the ingredients of every item of the same compounded medicine are added at once
+	isAdded := make(map[int]bool)
+
+	for _, medicineItem := range medicineItems {
+		compoundIngredients, ok := ingredients[medicineItem.Medicine.ID]
+		if !ok {
+			items, err := ExpandMedicineStockItems(mdmiStore, medStore, unitStore, medicineItem.Medicine, medicineItem.Unit, medicineItem.Qty)
+			if err != nil {
+				return nil, err
+			}
+
+			stockItems = append(stockItems, items...)
+			continue
+		}
+
+		if isAdded[medicineItem.Medicine.ID] {
+			continue
+		}
+		isAdded[medicineItem.Medicine.ID] = true
+
+		for _, ingredient := range compoundIngredients {
+			ingredientData, err := medStore.GetMedicineByBarcode(ingredient.MedicineBarcode)
+			if err != nil {
+				return nil, fmt.Errorf("ingredient %s of %s doesn't exists", ingredient.MedicineName, medicineItem.Medicine.Name)
+			}
+
+			ingredientUnit, err := unitStore.GetUnitByName(ingredient.Unit)
+			if err != nil || ingredientUnit == nil {
+				return nil, fmt.Errorf("unit %s of ingredient %s not found", ingredient.Unit, ingredient.MedicineName)
+			}
+
+			stockItems = append(stockItems, types.MedicineStockItem{
+				Medicine: ingredientData,
+				Unit:     ingredientUnit,
+				Qty:      ingredient.Qty,
+			})
+		}
+	}
+
+	return stockItems, nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+func IsControlledSubstance(medData *types.Medicine) bool {
+	return medData.ControlledClass != ""
+}
+
+// entry only needs the transaction and the patient/doctor data,
+// medicine and qty are filled from the stock movement
+func RecordControlledSubstanceIn(registerStore types.ControlledSubstanceStore, medData *types.Medicine, unit *types.Unit, qty float64, entry types.ControlledSubstanceRegister) error {
+	if !IsControlledSubstance(medData) {
+		return nil
+	}
+
+	qtyIn, err := ConvertToFirstUnit(medData, unit, qty)
+	if err != nil {
+		return err
+	}
+
+	entry.MedicineID = medData.ID
+	entry.QtyIn = qtyIn
+
+	return registerStore.CreateRegisterEntry(entry)
+}
+
+func RecordControlledSubstanceOut(registerStore types.ControlledSubstanceStore, medData *types.Medicine, unit *types.Unit, qty float64, entry types.ControlledSubstanceRegister) error {
+	// nothing is given out for the nedet medicine of a prescription
+	if !IsControlledSubstance(medData) || qty == 0 {
+		return nil
+	}
+
+	qtyOut, err := ConvertToFirstUnit(medData, unit, qty)
+	if err != nil {
+		return err
+	}
+
+	entry.MedicineID = medData.ID
+	entry.QtyOut = qtyOut
+
+	return registerStore.CreateRegisterEntry(entry)
+}
+
+// follows the column order of the SIPNAP monthly report spreadsheet,
+// the transfer between branches is counted as from and to another facility
+func WriteSIPNAPReport(w io.Writer, report types.ControlledSubstanceReportReturnPayload) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{
+		"No", "Kode Obat", "Nama Obat", "Satuan", "Stok Awal",
+		"Pemasukan Dari PBF", "Pemasukan Dari Sarana", "Penggunaan Resep",
+		"Penyaluran", "Penggunaan Produksi", "Stok Akhir", "Keterangan",
+	}
+
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range report.Items {
+		record := []string{
+			strconv.Itoa(i + 1),
+			item.MedicineBarcode,
+			item.MedicineName,
+			item.Unit,
+			formatSIPNAPQty(item.OpeningBalance),
+			formatSIPNAPQty(item.InPurchase),
+			formatSIPNAPQty(item.InProduction + item.InTransfer),
+			formatSIPNAPQty(item.OutPrescription),
+			formatSIPNAPQty(item.OutSale + item.OutTransfer),
+			formatSIPNAPQty(item.OutProduction),
+			formatSIPNAPQty(item.ClosingBalance),
+			item.ControlledClass,
+		}
+
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	if writer.Error() != nil {
+		return fmt.Errorf("error write sipnap report: %v", writer.Error())
+	}
+
+	return nil
+}
+
+func formatSIPNAPQty(qty float64) string {
+	return strconv.FormatFloat(qty, 'f', -1, 64)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the missing documents are checked first, so the client gets 404 instead of an empty file
+func GetDocument(storage types.DocumentStorage, category string, fileName string) (*types.Document, int, error) {
+	isExist, err := storage.IsDocumentExist(category, fileName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("error checking file %s: %v", fileName, err)
+	}
+
+	if !isExist {
+		return nil, http.StatusNotFound, fmt.Errorf("file %s not found", fileName)
+	}
+
+	document, err := storage.GetDocument(category, fileName)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("error reading file %s: %v", fileName, err)
+	}
+
+	return document, http.StatusOK, nil
+}
+
+// the hash is the etag, so the client can skip downloading the same document again
+func WriteDocument(w http.ResponseWriter, r *http.Request, storage types.DocumentStorage, category string, fileName string) {
+	document, status, err := GetDocument(storage, category, fileName)
+	if err != nil {
+		WriteError(w, status, err)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", ("Content-Length,Content-Disposition," + constants.DOCUMENT_HASH_HEADER))
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", document.FileName))
+	w.Header().Set("ETag", fmt.Sprintf("\"%s\"", document.Hash))
+	w.Header().Set(constants.DOCUMENT_HASH_HEADER, document.Hash)
+
+	http.ServeContent(w, r, document.FileName, document.LastModified, bytes.NewReader(document.Content))
+}
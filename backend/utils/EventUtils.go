@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// called after the write is successful, the error is only logged
+// because the data is already saved and the request must not fail
+func PublishEvent(eventStore types.EventStore, eventType string, entity string, entityId int, branchId int, user *types.User, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "entity", entity, "entityId", entityId, "error", err)
+		return
+	}
+
+	err = eventStore.CreateEvent(types.Event{
+		UUID:      uuid.NewString(),
+		EventType: eventType,
+		Entity:    entity,
+		EntityID:  entityId,
+		BranchID:  branchId,
+		UserID:    user.ID,
+		Payload:   string(payload),
+	})
+	if err != nil {
+		slog.Error("error publish event", "event", eventType, "entity", entity, "entityId", entityId, "error", err)
+	}
+}
+
+// the receiver computes the same signature with its copy of the secret
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyWebhookSignature(secret string, timestamp string, body []byte, signature string) bool {
+	expected := SignWebhookPayload(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// saved comma separated, empty receives every event
+func JoinEventTypes(eventTypes []string) string {
+	return strings.Join(eventTypes, ",")
+}
+
+func SplitEventTypes(eventTypes string) []string {
+	if eventTypes == "" {
+		return make([]string, 0)
+	}
+
+	return strings.Split(eventTypes, ",")
+}
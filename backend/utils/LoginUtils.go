@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the address of the client, the proxy headers are only used if the server
+// is behind trustedProxyCount reverse proxies that set them.
+// every proxy appends the address it got the request from, the entries on the
+// left are sent by the client and can be faked, so the entry added by the
+// outermost trusted proxy is counted from the right
+func GetClientIP(r *http.Request, trustedProxyCount int64) string {
+	if trustedProxyCount > 0 {
+		forwardedFor := make([]string, 0)
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			forwardedFor = append(forwardedFor, strings.Split(header, ",")...)
+		}
+
+		if len(forwardedFor) > 0 {
+			idx := len(forwardedFor) - int(trustedProxyCount)
+			if idx < 0 {
+				idx = 0
+			}
+
+			clientIp := strings.TrimSpace(forwardedFor[idx])
+			if clientIp != "" {
+				return clientIp
+			}
+		}
+
+		realIp := r.Header.Get("X-Real-IP")
+		if realIp != "" {
+			return strings.TrimSpace(realIp)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// the user name is locked in lower case, so the case can't be used to try more
+func GetLoginLockKey(userName string) string {
+	return strings.ToLower(strings.TrimSpace(userName))
+}
+
+func IsLoginLocked(lockout *types.LoginLockout, now time.Time) bool {
+	return lockout != nil && lockout.LockedUntil.Valid && lockout.LockedUntil.Time.After(now)
+}
+
+// lockoutDuration for the first lockout, doubled for every next one until maxLockoutDuration
+func GetLoginLockoutDuration(lockoutCount int, lockoutDuration int64, maxLockoutDuration int64) time.Duration {
+	duration := lockoutDuration
+	for i := 1; i < lockoutCount && duration < maxLockoutDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > maxLockoutDuration {
+		duration = maxLockoutDuration
+	}
+
+	return (time.Duration(duration) * time.Second)
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetClientIP(t *testing.T) {
+	tests := []struct {
+		name              string
+		remoteAddr        string
+		forwardedFor      []string
+		realIp            string
+		trustedProxyCount int64
+		want              string
+	}{
+		{"no proxy", "10.0.0.1:5000", nil, "", 0, "10.0.0.1"},
+		{"the header is ignored without proxy", "10.0.0.1:5000", []string{"1.1.1.1"}, "2.2.2.2", 0, "10.0.0.1"},
+		{"one proxy", "10.0.0.1:5000", []string{"1.1.1.1"}, "", 1, "1.1.1.1"},
+		{"the spoofed entry is skipped", "10.0.0.1:5000", []string{"6.6.6.6, 1.1.1.1"}, "", 1, "1.1.1.1"},
+		{"two proxies", "10.0.0.1:5000", []string{"6.6.6.6, 1.1.1.1, 10.0.0.2"}, "", 2, "1.1.1.1"},
+		{"the headers are joined", "10.0.0.1:5000", []string{"6.6.6.6", "1.1.1.1"}, "", 1, "1.1.1.1"},
+		{"fewer entries than proxies", "10.0.0.1:5000", []string{"1.1.1.1"}, "", 2, "1.1.1.1"},
+		{"real ip", "10.0.0.1:5000", nil, "1.1.1.1", 1, "1.1.1.1"},
+		{"remote addr without port", "10.0.0.1", nil, "", 0, "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		r, err := http.NewRequest(http.MethodGet, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.RemoteAddr = test.remoteAddr
+		for _, header := range test.forwardedFor {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		if test.realIp != "" {
+			r.Header.Set("X-Real-IP", test.realIp)
+		}
+
+		if got := GetClientIP(r, test.trustedProxyCount); got != test.want {
+			t.Errorf("%s: GetClientIP = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetLoginLockoutDuration(t *testing.T) {
+	tests := []struct {
+		lockoutCount int
+		want         time.Duration
+	}{
+		{1, 60 * time.Second},
+		{2, 120 * time.Second},
+		{3, 240 * time.Second},
+		{4, 300 * time.Second},
+		{10, 300 * time.Second},
+	}
+
+	for _, test := range tests {
+		if got := GetLoginLockoutDuration(test.lockoutCount, 60, 300); got != test.want {
+			t.Errorf("GetLoginLockoutDuration(%d, 60, 300) = %v, want %v", test.lockoutCount, got, test.want)
+		}
+	}
+}
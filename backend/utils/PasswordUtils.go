@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the reasons are all returned, so the user can fix them at once
+func CheckPasswordPolicy(password string, policy types.PasswordPolicy) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+			hasSymbol = true
+		}
+	}
+
+	reasons := make([]string, 0)
+
+	if len([]rune(password)) < policy.MinLength {
+		reasons = append(reasons, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if policy.RequireUpper && !hasUpper {
+		reasons = append(reasons, "an upper case letter")
+	}
+	if policy.RequireLower && !hasLower {
+		reasons = append(reasons, "a lower case letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		reasons = append(reasons, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		reasons = append(reasons, "a symbol")
+	}
+
+	if len(reasons) > 0 {
+		return fmt.Errorf("password must have %s", strings.Join(reasons, ", "))
+	}
+
+	return nil
+}
+
+// expiryDays 0 is never expired
+func IsPasswordExpired(passwordChangedAt time.Time, expiryDays int64, now time.Time) bool {
+	if expiryDays <= 0 {
+		return false
+	}
+
+	return now.After(passwordChangedAt.AddDate(0, 0, int(expiryDays)))
+}
+
+// for the codes that give access, unlike GenerateRandomCodeAlphanumeric
+func GenerateSecureCode(length int) (string, error) {
+	// without the characters that look the same
+	const charset = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+
+	result := make([]byte, length)
+
+	for i := range result {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+
+		result[i] = charset[index.Int64()]
+	}
+
+	return string(result), nil
+}
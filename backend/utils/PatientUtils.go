@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+func ParsePatient(payload types.RegisterPatientPayload) (*types.Patient, error) {
+	patient := types.Patient{
+		Name:                payload.Name,
+		Age:                 payload.Age,
+		Gender:              strings.ToUpper(payload.Gender),
+		PhoneNumber:         payload.PhoneNumber,
+		Address:             payload.Address,
+		NationalID:          payload.NationalID,
+		MedicalRecordNumber: payload.MedicalRecordNumber,
+		Weight:              payload.Weight,
+		AllergyNotes:        payload.AllergyNotes,
+	}
+
+	if payload.BirthDate != "" {
+		birthDate, err := ParseDate(payload.BirthDate)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing birth date")
+		}
+
+		patient.BirthDate = sql.NullTime{Time: *birthDate, Valid: true}
+		patient.Age = CalculateAge(*birthDate, time.Now())
+	}
+
+	return &patient, nil
+}
+
+// patient without any of these data can't be told apart from another patient with the same name
+func IsPatientIdentifiable(patient *types.Patient) bool {
+	return patient.MedicalRecordNumber != "" || patient.NationalID != "" ||
+		patient.BirthDate.Valid || patient.PhoneNumber != ""
+}
+
+func CalculateAge(birthDate time.Time, date time.Time) int {
+	age := date.Year() - birthDate.Year()
+
+	// birthday hasn't come yet this year
+	if date.Month() < birthDate.Month() ||
+		(date.Month() == birthDate.Month() && date.Day() < birthDate.Day()) {
+		age--
+	}
+
+	if age < 0 {
+		return 0
+	}
+
+	return age
+}
+
+// age at the given date, falls back to the recorded age if there's no birth date
+func GetPatientAge(patient *types.Patient, date time.Time) int {
+	if !patient.BirthDate.Valid {
+		return patient.Age
+	}
+
+	return CalculateAge(patient.BirthDate.Time, date)
+}
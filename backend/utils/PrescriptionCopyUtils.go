@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+
+	dectofrac "github.com/av-elier/go-decimal-to-rational"
+)
+
+// parse the qty written in the prescription, e.g. "10", "1.5" or "1/2"
+func ParsePrescriptionQty(qty string) (float64, error) {
+	fractionIdx := strings.Index(qty, "/")
+	if fractionIdx == -1 {
+		return strconv.ParseFloat(strings.TrimSpace(qty), 64)
+	}
+
+	fraction := strings.Split(qty, "/")
+
+	numerator, err := strconv.ParseFloat(strings.TrimSpace(fraction[0]), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	denum, err := strconv.ParseFloat(strings.TrimSpace(fraction[1]), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if denum == 0 {
+		return 0, fmt.Errorf("invalid qty %s", qty)
+	}
+
+	return numerator / denum, nil
+}
+
+// the total qty that may be dispensed, the first dispense and every iteration
+func GetPrescriptionTotalQty(prescribedQty float64, iteration int) float64 {
+	return prescribedQty * float64(iteration+1)
+}
+
+func GetDispensedQty(dispensedQtys []types.PrescriptionDispensedQty, medicineBarcode string, unit string) float64 {
+	for _, dispensedQty := range dispensedQtys {
+		if dispensedQty.MedicineBarcode == medicineBarcode && strings.EqualFold(dispensedQty.Unit, unit) {
+			return dispensedQty.Qty
+		}
+	}
+
+	return 0
+}
+
+// annotation written beside the medicine in the prescription copy:
+// nedet = not dispensed yet, det = dispensed, det <qty> = only <qty> is dispensed,
+// det orig = dispensed including every iteration, iter <n>x = the iterations left
+func GetPrescriptionCopyAnnotation(prescribedQty float64, iteration int, dispensedQty float64) string {
+	if prescribedQty <= 0 {
+		prescribedQty = dispensedQty
+	}
+
+	totalQty := GetPrescriptionTotalQty(prescribedQty, iteration)
+
+	if dispensedQty <= 0 {
+		if iteration > 0 {
+			return fmt.Sprintf("nedet, iter %dx", iteration)
+		}
+
+		return "nedet"
+	}
+
+	if iteration > 0 && dispensedQty >= (totalQty-constants.PRESC_QTY_TOLERANCE) {
+		return "det orig"
+	}
+
+	annotation := "det"
+
+	// the last dispense only gives part of the prescribed qty
+	partialQty := math.Mod(dispensedQty, prescribedQty)
+	if partialQty > constants.PRESC_QTY_TOLERANCE && (prescribedQty-partialQty) > constants.PRESC_QTY_TOLERANCE {
+		annotation = "det " + FormatPrescriptionQty(partialQty)
+	}
+
+	if iteration > 0 {
+		remainingIteration := GetRemainingIteration(prescribedQty, totalQty, dispensedQty)
+		if remainingIteration > 0 {
+			annotation += fmt.Sprintf(", iter %dx", remainingIteration)
+		}
+	}
+
+	return annotation
+}
+
+// how many more times the prescribed qty can be given from what is left
+func GetRemainingIteration(prescribedQty float64, totalQty float64, dispensedQty float64) int {
+	if prescribedQty <= 0 || dispensedQty >= totalQty {
+		return 0
+	}
+
+	return int(math.Floor(((totalQty - dispensedQty) / prescribedQty) + constants.PRESC_QTY_TOLERANCE))
+}
+
+// same format as the qty string of the prescription medicine item
+func FormatPrescriptionQty(qty float64) string {
+	if qty < 1.0 {
+		return dectofrac.NewRatP(qty, 0.01).String()
+	}
+
+	if qty == math.Trunc(qty) {
+		return fmt.Sprintf("%.0f", qty)
+	}
+
+	return fmt.Sprintf("%.1f", qty)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+// the next statuses allowed from each status, a medicine that doesn't need
+// compounding goes from in preparation straight to verified
+var prescriptionStatusTransitions = map[string][]string{
+	constants.PRESC_STATUS_RECEIVED: {
+		constants.PRESC_STATUS_IN_PREPARATION,
+		constants.PRESC_STATUS_CANCELLED,
+	},
+	constants.PRESC_STATUS_IN_PREPARATION: {
+		constants.PRESC_STATUS_COMPOUNDED,
+		constants.PRESC_STATUS_VERIFIED,
+		constants.PRESC_STATUS_CANCELLED,
+	},
+	constants.PRESC_STATUS_COMPOUNDED: {
+		constants.PRESC_STATUS_IN_PREPARATION,
+		constants.PRESC_STATUS_VERIFIED,
+		constants.PRESC_STATUS_CANCELLED,
+	},
+	constants.PRESC_STATUS_VERIFIED: {
+		constants.PRESC_STATUS_IN_PREPARATION,
+		constants.PRESC_STATUS_HANDED_OVER,
+		constants.PRESC_STATUS_CANCELLED,
+	},
+	constants.PRESC_STATUS_HANDED_OVER: {},
+	constants.PRESC_STATUS_CANCELLED:   {},
+}
+
+// the statuses shown in the dispensing queue, in the order of the workflow
+var openPrescriptionStatuses = []string{
+	constants.PRESC_STATUS_RECEIVED,
+	constants.PRESC_STATUS_IN_PREPARATION,
+	constants.PRESC_STATUS_COMPOUNDED,
+	constants.PRESC_STATUS_VERIFIED,
+}
+
+func IsValidPrescriptionStatus(status string) bool {
+	_, ok := prescriptionStatusTransitions[status]
+	return ok
+}
+
+func IsValidPrescriptionStatusTransition(fromStatus string, toStatus string) bool {
+	return containsString(prescriptionStatusTransitions[fromStatus], toStatus)
+}
+
+// only the open prescription can be modified, the handed over medicines are with the patient
+func IsModifiablePrescriptionStatus(status string) bool {
+	return containsString(openPrescriptionStatuses, status)
+}
+
+func GetOpenPrescriptionStatuses() []string {
+	return append([]string{}, openPrescriptionStatuses...)
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the recipe is for its yield qty, so the ingredients are scaled by the planned qty of the production
+func ScaleProductionRecipe(unitStore types.UnitStore, producedMedicine *types.Medicine, recipe *types.ProductionRecipe, items []types.ProductionRecipeItemRow, plannedUnit *types.Unit, plannedQty float64) ([]types.ProductionMedicineListPayload, error) {
+	yieldUnit, err := unitStore.GetUnitByID(recipe.YieldUnitID)
+	if err != nil || yieldUnit == nil {
+		return nil, fmt.Errorf("yield unit id %d of the recipe not found", recipe.YieldUnitID)
+	}
+
+	yieldQty, err := ConvertToFirstUnit(producedMedicine, yieldUnit, recipe.YieldQty)
+	if err != nil {
+		return nil, err
+	}
+
+	if yieldQty <= 0 {
+		return nil, fmt.Errorf("yield qty of the recipe of %s is 0", producedMedicine.Name)
+	}
+
+	qty, err := ConvertToFirstUnit(producedMedicine, plannedUnit, plannedQty)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := qty / yieldQty
+
+	ingredients := make([]types.ProductionMedicineListPayload, 0)
+
+	for _, item := range items {
+		ingredients = append(ingredients, types.ProductionMedicineListPayload{
+			MedicineBarcode: item.MedicineBarcode,
+			MedicineName:    item.MedicineName,
+			Qty:             (item.Qty * scale),
+			Unit:            item.Unit,
+		})
+	}
+
+	return ingredients, nil
+}
+
+// cost of the qty from the latest cost of the medicine, 0 if the medicine has no cost yet
+func GetMedicineCost(productionStore types.ProductionStore, unitStore types.UnitStore, medData *types.Medicine, unit *types.Unit, qty float64) (float64, error) {
+	unitCost, err := productionStore.GetMedicineUnitCost(medData.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if unitCost == nil {
+		return 0, nil
+	}
+
+	costUnit, err := unitStore.GetUnitByID(unitCost.UnitID)
+	if err != nil || costUnit == nil {
+		return 0, fmt.Errorf("unit id %d of the cost of %s not found", unitCost.UnitID, medData.Name)
+	}
+
+	// how many first units are in 1 unit of the cost
+	costUnitQty, err := ConvertToFirstUnit(medData, costUnit, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if costUnitQty <= 0 {
+		return 0, nil
+	}
+
+	firstUnitQty, err := ConvertToFirstUnit(medData, unit, qty)
+	if err != nil {
+		return 0, err
+	}
+
+	return (firstUnitQty * unitCost.Cost / costUnitQty), nil
+}
+
+func GetProductionYieldPercentage(producedQty float64, plannedQty float64) float64 {
+	if plannedQty <= 0 {
+		return 100
+	}
+
+	return (producedQty / plannedQty * 100)
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// medicine without active ingredients is screened by its own name
+func GetActiveIngredients(medicine *types.Medicine) []string {
+	ingredients := make([]string, 0)
+
+	for _, ingredient := range strings.Split(medicine.ActiveIngredients, ",") {
+		ingredient = strings.ToUpper(strings.TrimSpace(ingredient))
+		if ingredient != "" {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+
+	if len(ingredients) == 0 {
+		ingredients = append(ingredients, strings.ToUpper(medicine.Name))
+	}
+
+	return ingredients
+}
+
+func IsBlockingSeverity(severity string) bool {
+	severity = strings.ToUpper(severity)
+	return severity == constants.SEVERITY_MAJOR || severity == constants.SEVERITY_CONTRAINDICATED
+}
+
+func HasBlockingScreeningResult(results []types.ScreeningResult) bool {
+	for _, result := range results {
+		if result.Blocking {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check the medicines against the patient allergies and against each other,
+// the ingredients of a compounded medicine are also checked against each other
+func ScreenMedicines(ruleStore types.InteractionRuleStore, medicines []types.ScreeningMedicine, allergies []types.PatientAllergy) []types.ScreeningResult {
+	results := make([]types.ScreeningResult, 0)
+
+	// the same medicine can be in more than one set
+	uniqueMedicines := make([]types.ScreeningMedicine, 0)
+	isAdded := make(map[int]bool)
+
+	for _, medicine := range medicines {
+		if !isAdded[medicine.Medicine.ID] {
+			isAdded[medicine.Medicine.ID] = true
+			uniqueMedicines = append(uniqueMedicines, medicine)
+		}
+	}
+
+	for _, medicine := range uniqueMedicines {
+		names := []string{strings.ToUpper(medicine.Medicine.Name)}
+		ingredients := make([]string, 0)
+
+		for _, ingredient := range medicine.Ingredients {
+			names = append(names, strings.ToUpper(ingredient.Name))
+			ingredients = append(ingredients, GetActiveIngredients(&ingredient)...)
+		}
+
+		for _, allergy := range allergies {
+			allergen := strings.ToUpper(strings.TrimSpace(allergy.Allergen))
+
+			if !containsString(names, allergen) && !containsString(ingredients, allergen) {
+				continue
+			}
+
+			results = append(results, types.ScreeningResult{
+				Type:        constants.SCREENING_TYPE_ALLERGY,
+				Severity:    allergy.Severity,
+				Blocking:    IsBlockingSeverity(allergy.Severity),
+				Medicines:   []string{medicine.Medicine.Name},
+				Description: fmt.Sprintf("patient is allergic to %s (%s)", allergen, allergy.Reaction),
+			})
+		}
+	}
+
+	for i := 0; i < len(uniqueMedicines); i++ {
+		ingredientsA := uniqueMedicines[i].Ingredients
+
+		// the ingredients mixed in the same compounded medicine
+		for a := 0; a < len(ingredientsA); a++ {
+			for b := a + 1; b < len(ingredientsA); b++ {
+				results = append(results, screenInteractions(ruleStore, &ingredientsA[a], &ingredientsA[b],
+					[]string{uniqueMedicines[i].Medicine.Name})...)
+			}
+		}
+
+		for j := i + 1; j < len(uniqueMedicines); j++ {
+			ingredientsB := uniqueMedicines[j].Ingredients
+
+			for a := range ingredientsA {
+				for b := range ingredientsB {
+					results = append(results, screenInteractions(ruleStore, &ingredientsA[a], &ingredientsB[b],
+						[]string{uniqueMedicines[i].Medicine.Name, uniqueMedicines[j].Medicine.Name})...)
+				}
+			}
+		}
+	}
+
+	return results
+}
+
+// the results are reported against the prescribed medicines
+func screenInteractions(ruleStore types.InteractionRuleStore, medicineA *types.Medicine, medicineB *types.Medicine, medicineNames []string) []types.ScreeningResult {
+	results := make([]types.ScreeningResult, 0)
+
+	for _, ingredientA := range GetActiveIngredients(medicineA) {
+		for _, ingredientB := range GetActiveIngredients(medicineB) {
+			for _, rule := range ruleStore.GetInteractionRules(ingredientA, ingredientB) {
+				results = append(results, types.ScreeningResult{
+					Type:      constants.SCREENING_TYPE_INTERACTION,
+					Severity:  rule.Severity,
+					Blocking:  IsBlockingSeverity(rule.Severity),
+					Medicines: medicineNames,
+					Description: fmt.Sprintf("%s - %s: %s",
+						rule.IngredientA, rule.IngredientB, rule.Description),
+				})
+			}
+		}
+	}
+
+	return results
+}
+
+func containsString(values []string, val string) bool {
+	for _, v := range values {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
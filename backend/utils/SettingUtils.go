@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the company data with the footer of the document type, for the pdf generators
+func GetDocumentBranding(settingStore types.SettingStore, branchStore types.BranchStore, documentStorage types.DocumentStorage, documentType string, branchId int) (*types.DocumentBranding, error) {
+	company, err := GetBranchCompanySetting(settingStore, branchStore, documentStorage, branchId)
+	if err != nil {
+		return nil, err
+	}
+
+	documentSetting, err := settingStore.GetDocumentSetting(documentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.DocumentBranding{
+		Company: *company,
+		Footer:  documentSetting.Footer,
+	}, nil
+}
+
+// the document of a branch shows the address and the phone number of the branch,
+// BRANCH_ALL keeps the company setting
+func GetBranchCompanySetting(settingStore types.SettingStore, branchStore types.BranchStore, documentStorage types.DocumentStorage, branchId int) (*types.CompanySetting, error) {
+	company, err := GetCompanySetting(settingStore, documentStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	if branchId == constants.BRANCH_ALL {
+		return company, nil
+	}
+
+	branch, err := branchStore.GetBranchByID(branchId)
+	if err != nil {
+		return nil, err
+	}
+
+	if branch.Address != "" {
+		company.Address = branch.Address
+	}
+	if branch.PhoneNumber != "" {
+		company.PhoneNumber = branch.PhoneNumber
+	}
+
+	return company, nil
+}
+
+// the documents are still made without the logo if it can't be read
+func GetCompanySetting(settingStore types.SettingStore, documentStorage types.DocumentStorage) (*types.CompanySetting, error) {
+	company, err := settingStore.GetCompanySetting()
+	if err != nil {
+		return nil, err
+	}
+
+	logo, err := GetLogo(documentStorage, company.LogoURL)
+	if err == nil {
+		company.Logo = logo
+	}
+
+	return company, nil
+}
+
+// the uploaded logo is saved in the document storage by its file name,
+// the default logo from the config is a file path of the app
+func GetLogo(documentStorage types.DocumentStorage, logoUrl string) ([]byte, error) {
+	if filepath.Base(logoUrl) != logoUrl {
+		return os.ReadFile(logoUrl)
+	}
+
+	document, _, err := GetDocument(documentStorage, constants.DOCUMENT_LOGO, logoUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return document.Content, nil
+}
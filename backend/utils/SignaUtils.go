@@ -0,0 +1,355 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// amount per dose: "1", "1.5", "1/2", "1 1/2" or roman "ii"
+const signaAmount = `(\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?|[ivx]+)`
+
+var (
+	// "3 dd 1", "3 x 1/2", "3 kali sehari 1"
+	signaTimesRegex = regexp.MustCompile(`(\d+)\s*(?:dd|x|kali)\s*(?:sehari\s*)?` + signaAmount + `\b`)
+
+	// "sehari 3 x 1", "sehari 3 kali 1"
+	signaDailyRegex = regexp.MustCompile(`sehari\s*(\d+)\s*(?:x|kali)\s*` + signaAmount + `\b`)
+
+	// "s.d.d. 1", "t.d.d. ½"
+	signaLatinRegex = regexp.MustCompile(`\b([sbtq])dd\s*` + signaAmount + `\b`)
+
+	// "q.8.h. 1", "tiap 8 jam 1"
+	signaIntervalRegex = regexp.MustCompile(`(?:\bq\s*(\d+)\s*h|tiap\s*(\d+)\s*jam)\s*` + signaAmount + `?`)
+
+	signaFractionReplacer = strings.NewReplacer("½", " 1/2", "¼", " 1/4", "¾", " 3/4")
+)
+
+var signaLatinTimes = map[string]float64{"s": 1, "b": 2, "t": 3, "q": 4}
+
+// the first timing found is used
+var signaTimings = []struct {
+	timing string
+	regex  *regexp.Regexp
+}{
+	{constants.SIGNA_TIMING_BEFORE_MEAL, regexp.MustCompile(`\bac\b|sebelum makan`)},
+	{constants.SIGNA_TIMING_AFTER_MEAL, regexp.MustCompile(`\bpc\b|setelah makan|sesudah makan`)},
+	{constants.SIGNA_TIMING_WITH_MEAL, regexp.MustCompile(`\bdc\b|saat makan|bersama makan`)},
+	{constants.SIGNA_TIMING_BEDTIME, regexp.MustCompile(`\bhs\b|sebelum tidur`)},
+	{constants.SIGNA_TIMING_MORNING, regexp.MustCompile(`\bmane\b|\bpagi\b`)},
+	{constants.SIGNA_TIMING_NIGHT, regexp.MustCompile(`\bnocte\b|\bmalam\b`)},
+}
+
+var signaTimingTexts = map[string]string{
+	constants.SIGNA_TIMING_BEFORE_MEAL: "sebelum makan",
+	constants.SIGNA_TIMING_AFTER_MEAL:  "sesudah makan",
+	constants.SIGNA_TIMING_WITH_MEAL:   "saat makan",
+	constants.SIGNA_TIMING_BEDTIME:     "sebelum tidur",
+	constants.SIGNA_TIMING_MORNING:     "pagi hari",
+	constants.SIGNA_TIMING_NIGHT:       "malam hari",
+}
+
+var signaAsNeededRegex = regexp.MustCompile(`\bprn\b|bila perlu|jika perlu`)
+
+// the count of a compounded set, "m.f. pulv. dtd no. X"
+var signaMfCountRegex = regexp.MustCompile(`\bno\s*(\d+|[ivxlc]+)\b`)
+
+// parse the dose and consume time of a set item, e.g. "3 dd 1" and "a.c."
+func ParseSigna(dose string, consumeTime string) (*types.Signa, error) {
+	text := normalizeSigna(dose + " " + consumeTime)
+
+	signa := new(types.Signa)
+
+	if match := signaTimesRegex.FindStringSubmatch(text); match != nil {
+		signa.TimesPerDay, _ = strconv.ParseFloat(match[1], 64)
+		signa.AmountPerDose = parseSignaAmount(match[2])
+	} else if match := signaDailyRegex.FindStringSubmatch(text); match != nil {
+		signa.TimesPerDay, _ = strconv.ParseFloat(match[1], 64)
+		signa.AmountPerDose = parseSignaAmount(match[2])
+	} else if match := signaLatinRegex.FindStringSubmatch(text); match != nil {
+		signa.TimesPerDay = signaLatinTimes[match[1]]
+		signa.AmountPerDose = parseSignaAmount(match[2])
+	} else if match := signaIntervalRegex.FindStringSubmatch(text); match != nil {
+		interval := match[1]
+		if interval == "" {
+			interval = match[2]
+		}
+
+		signa.IntervalHours, _ = strconv.Atoi(interval)
+		if signa.IntervalHours <= 0 || signa.IntervalHours > 24 {
+			return nil, fmt.Errorf("invalid interval in signa %s", dose)
+		}
+
+		signa.TimesPerDay = 24 / float64(signa.IntervalHours)
+		signa.AmountPerDose = parseSignaAmount(match[3])
+	} else {
+		return nil, fmt.Errorf("unknown signa %s", dose)
+	}
+
+	if signa.TimesPerDay <= 0 || signa.AmountPerDose <= 0 {
+		return nil, fmt.Errorf("unknown signa %s", dose)
+	}
+
+	signa.DailyAmount = signa.TimesPerDay * signa.AmountPerDose
+
+	for _, val := range signaTimings {
+		if val.regex.MatchString(text) {
+			signa.Timing = val.timing
+			break
+		}
+	}
+
+	signa.AsNeeded = signaAsNeededRegex.MatchString(text)
+
+	return signa, nil
+}
+
+// the days the qty lasts, 0 if it is only taken when needed
+func GetSignaDaysSupply(signa *types.Signa, qty float64) float64 {
+	if signa == nil || signa.AsNeeded || signa.DailyAmount <= 0 {
+		return 0
+	}
+
+	return qty / signa.DailyAmount
+}
+
+// empty if the qty is a whole number of days of the signa
+func CheckSignaQty(signa *types.Signa, qty float64) string {
+	if signa == nil || signa.AsNeeded || signa.DailyAmount <= 0 || qty <= 0 {
+		return ""
+	}
+
+	if qty < (signa.DailyAmount - constants.SIGNA_QTY_TOLERANCE) {
+		return fmt.Sprintf("qty %s is less than one day of the signa (%s a day)",
+			FormatPrescriptionQty(qty), FormatPrescriptionQty(signa.DailyAmount))
+	}
+
+	leftover := math.Mod(qty, signa.DailyAmount)
+	if leftover > constants.SIGNA_QTY_TOLERANCE && (signa.DailyAmount-leftover) > constants.SIGNA_QTY_TOLERANCE {
+		return fmt.Sprintf("qty %s doesn't match the signa (%s a day), it lasts %.1f days",
+			FormatPrescriptionQty(qty), FormatPrescriptionQty(signa.DailyAmount), GetSignaDaysSupply(signa, qty))
+	}
+
+	return ""
+}
+
+// instruction for the patient, e.g. "3 kali sehari ½ tablet sesudah makan"
+func GetSignaInstruction(signa *types.Signa, setUnit string) string {
+	if signa == nil {
+		return ""
+	}
+
+	var instruction string
+	if signa.IntervalHours > 0 {
+		instruction = fmt.Sprintf("Tiap %d jam %s", signa.IntervalHours, FormatSignaAmount(signa.AmountPerDose))
+	} else {
+		instruction = fmt.Sprintf("%s kali sehari %s", FormatSignaAmount(signa.TimesPerDay), FormatSignaAmount(signa.AmountPerDose))
+	}
+
+	setUnit = strings.ToLower(strings.TrimSpace(setUnit))
+	if setUnit != "" {
+		instruction += " " + setUnit
+	}
+
+	if signa.Timing != "" {
+		instruction += " " + signaTimingTexts[signa.Timing]
+	}
+
+	if signa.AsNeeded {
+		instruction += " bila perlu"
+	}
+
+	return instruction
+}
+
+// halves and quarters are written as a fraction, e.g. "1½"
+func FormatSignaAmount(amount float64) string {
+	whole := math.Floor(amount)
+	part := amount - whole
+
+	var fraction string
+	switch {
+	case math.Abs(part) < constants.SIGNA_QTY_TOLERANCE:
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	case math.Abs(part-0.5) < constants.SIGNA_QTY_TOLERANCE:
+		fraction = "½"
+	case math.Abs(part-0.25) < constants.SIGNA_QTY_TOLERANCE:
+		fraction = "¼"
+	case math.Abs(part-0.75) < constants.SIGNA_QTY_TOLERANCE:
+		fraction = "¾"
+	default:
+		return FormatPrescriptionQty(amount)
+	}
+
+	if whole == 0 {
+		return fraction
+	}
+
+	return strconv.FormatFloat(whole, 'f', -1, 64) + fraction
+}
+
+// the qty of the set: the eticket qty, the count in the mf,
+// or the qty of the medicine if the set only has one
+func GetSignaSetQty(eticketQty float64, mf string, medicineQtys []float64) float64 {
+	if eticketQty > 0 {
+		return eticketQty
+	}
+
+	if match := signaMfCountRegex.FindStringSubmatch(normalizeSigna(mf)); match != nil {
+		count, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			count = float64(parseRoman(match[1]))
+		}
+
+		if count > 0 {
+			return count
+		}
+	}
+
+	if len(medicineQtys) == 1 {
+		return medicineQtys[0]
+	}
+
+	return 0
+}
+
+// warn about the sets whose qty doesn't match the signa, never blocking
+func ScreenSigna(setItems []types.PrescriptionSetItemPayload) []types.ScreeningResult {
+	results := make([]types.ScreeningResult, 0)
+
+	for _, setItem := range setItems {
+		if strings.TrimSpace(setItem.Dose) == "" {
+			continue
+		}
+
+		medicineNames := make([]string, 0)
+		medicineQtys := make([]float64, 0)
+
+		for _, medicine := range setItem.MedicineLists {
+			medicineNames = append(medicineNames, medicine.MedicineName)
+
+			qty, err := ParsePrescriptionQty(medicine.Qty)
+			if err == nil {
+				medicineQtys = append(medicineQtys, qty)
+			}
+		}
+
+		var description string
+
+		signa, err := ParseSigna(setItem.Dose, setItem.ConsumeTime)
+		if err != nil {
+			description = fmt.Sprintf("signa %s could not be read, check the qty manually", setItem.Dose)
+		} else {
+			qty := GetSignaSetQty(setItem.Eticket.MedicineQty, setItem.Mf, medicineQtys)
+			description = CheckSignaQty(signa, qty)
+		}
+
+		if description == "" {
+			continue
+		}
+
+		results = append(results, types.ScreeningResult{
+			Type:        constants.SCREENING_TYPE_SIGNA,
+			Severity:    constants.SEVERITY_MINOR,
+			Blocking:    false,
+			Medicines:   medicineNames,
+			Description: description,
+		})
+	}
+
+	return results
+}
+
+// lower case without the dots of the latin abbreviations, "a.c." becomes "ac",
+// the dot of a decimal number is kept
+func normalizeSigna(text string) string {
+	runes := []rune(signaFractionReplacer.Replace(strings.ToLower(text)))
+
+	var builder strings.Builder
+
+	for i, r := range runes {
+		if r != '.' {
+			builder.WriteRune(r)
+			continue
+		}
+
+		var prev, next rune
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i < len(runes)-1 {
+			next = runes[i+1]
+		}
+
+		switch {
+		case unicode.IsDigit(prev) && unicode.IsDigit(next):
+			builder.WriteRune(r)
+		case unicode.IsLetter(prev) && (unicode.IsLetter(next) || unicode.IsDigit(next)):
+			continue
+		default:
+			builder.WriteRune(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+func parseSignaAmount(amount string) float64 {
+	amount = strings.TrimSpace(amount)
+
+	if amount == "" {
+		return 0
+	}
+
+	// mixed number, "1 1/2"
+	if parts := strings.Fields(amount); len(parts) == 2 {
+		whole, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return 0
+		}
+
+		fraction, err := ParsePrescriptionQty(parts[1])
+		if err != nil {
+			return 0
+		}
+
+		return whole + fraction
+	}
+
+	qty, err := ParsePrescriptionQty(strings.ReplaceAll(amount, ",", "."))
+	if err != nil {
+		return float64(parseRoman(amount))
+	}
+
+	return qty
+}
+
+// 0 if it is not a roman number
+func parseRoman(roman string) int {
+	values := map[rune]int{'i': 1, 'v': 5, 'x': 10, 'l': 50, 'c': 100}
+
+	var total, prev int
+
+	runes := []rune(strings.ToLower(roman))
+	for i := len(runes) - 1; i >= 0; i-- {
+		val, ok := values[runes[i]]
+		if !ok {
+			return 0
+		}
+
+		if val < prev {
+			total -= val
+		} else {
+			total += val
+			prev = val
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+func TestParseSigna(t *testing.T) {
+	tests := []struct {
+		dose          string
+		consumeTime   string
+		timesPerDay   float64
+		amountPerDose float64
+		intervalHours int
+		timing        string
+		asNeeded      bool
+	}{
+		{"3 dd 1", "", 3, 1, 0, "", false},
+		{"3dd1", "", 3, 1, 0, "", false},
+		{"3 x 1/2", "", 3, 0.5, 0, "", false},
+		{"2 dd 1 1/2", "", 2, 1.5, 0, "", false},
+		{"3 dd 1,5", "", 3, 1.5, 0, "", false},
+		{"3 dd 1.5", "", 3, 1.5, 0, "", false},
+		{"3 kali sehari 1", "", 3, 1, 0, "", false},
+		{"sehari 3 x 1", "", 3, 1, 0, "", false},
+		{"sehari 2 kali 2", "", 2, 2, 0, "", false},
+		{"t.d.d. ½", "", 3, 0.5, 0, "", false},
+		{"s.d.d. 1", "", 1, 1, 0, "", false},
+		{"b.d.d. 1½", "", 2, 1.5, 0, "", false},
+		{"q.d.d. ¼", "", 4, 0.25, 0, "", false},
+		{"2 dd ii", "", 2, 2, 0, "", false},
+		{"t.d.d. i", "", 3, 1, 0, "", false},
+		{"q.8.h. 1", "", 3, 1, 8, "", false},
+		{"q 6 h 2", "", 4, 2, 6, "", false},
+		{"tiap 12 jam 1", "", 2, 1, 12, "", false},
+		{"3 dd 1", "a.c.", 3, 1, 0, constants.SIGNA_TIMING_BEFORE_MEAL, false},
+		{"3 dd 1", "p.c.", 3, 1, 0, constants.SIGNA_TIMING_AFTER_MEAL, false},
+		{"3 dd 1", "sesudah makan", 3, 1, 0, constants.SIGNA_TIMING_AFTER_MEAL, false},
+		{"1 dd 1", "h.s.", 1, 1, 0, constants.SIGNA_TIMING_BEDTIME, false},
+		{"1 dd 1", "mane", 1, 1, 0, constants.SIGNA_TIMING_MORNING, false},
+		{"3 dd 1", "p.r.n.", 3, 1, 0, "", true},
+		{"3 dd 1 bila perlu", "", 3, 1, 0, "", true},
+	}
+
+	for _, test := range tests {
+		signa, err := ParseSigna(test.dose, test.consumeTime)
+		if err != nil {
+			t.Errorf("ParseSigna(%q, %q) error: %v", test.dose, test.consumeTime, err)
+			continue
+		}
+
+		if !floatEquals(signa.TimesPerDay, test.timesPerDay) {
+			t.Errorf("ParseSigna(%q) times per day = %v, want %v", test.dose, signa.TimesPerDay, test.timesPerDay)
+		}
+		if !floatEquals(signa.AmountPerDose, test.amountPerDose) {
+			t.Errorf("ParseSigna(%q) amount per dose = %v, want %v", test.dose, signa.AmountPerDose, test.amountPerDose)
+		}
+		if !floatEquals(signa.DailyAmount, test.timesPerDay*test.amountPerDose) {
+			t.Errorf("ParseSigna(%q) daily amount = %v, want %v", test.dose, signa.DailyAmount, test.timesPerDay*test.amountPerDose)
+		}
+		if signa.IntervalHours != test.intervalHours {
+			t.Errorf("ParseSigna(%q) interval = %d, want %d", test.dose, signa.IntervalHours, test.intervalHours)
+		}
+		if signa.Timing != test.timing {
+			t.Errorf("ParseSigna(%q, %q) timing = %q, want %q", test.dose, test.consumeTime, signa.Timing, test.timing)
+		}
+		if signa.AsNeeded != test.asNeeded {
+			t.Errorf("ParseSigna(%q, %q) as needed = %v, want %v", test.dose, test.consumeTime, signa.AsNeeded, test.asNeeded)
+		}
+	}
+}
+
+func TestParseSignaInvalid(t *testing.T) {
+	for _, dose := range []string{"", "sesuai petunjuk", "3 dd", "q.0.h. 1", "q.48.h. 1", "0 dd 1"} {
+		if signa, err := ParseSigna(dose, ""); err == nil {
+			t.Errorf("ParseSigna(%q) = %+v, want error", dose, signa)
+		}
+	}
+}
+
+func TestNormalizeSigna(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"t.d.d. 1", "tdd 1"},
+		{"a.c.", "ac"},
+		{"q.8.h. 1", "q8 h 1"},
+		{"3 dd 1.5", "3 dd 1.5"},
+		{"T.D.D.  ½", "tdd 1/2"},
+		{"3 dd 1. p.c.", "3 dd 1 pc"},
+	}
+
+	for _, test := range tests {
+		if got := normalizeSigna(test.text); got != test.want {
+			t.Errorf("normalizeSigna(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParseSignaAmount(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   float64
+	}{
+		{"1", 1},
+		{"1.5", 1.5},
+		{"1,5", 1.5},
+		{"1/2", 0.5},
+		{"1 1/2", 1.5},
+		{"i", 1},
+		{"ii", 2},
+		{"iv", 4},
+		{"x", 10},
+		{"", 0},
+		{"abc", 0},
+	}
+
+	for _, test := range tests {
+		if got := parseSignaAmount(test.amount); !floatEquals(got, test.want) {
+			t.Errorf("parseSignaAmount(%q) = %v, want %v", test.amount, got, test.want)
+		}
+	}
+}
+
+func TestParseRoman(t *testing.T) {
+	tests := []struct {
+		roman string
+		want  int
+	}{
+		{"i", 1},
+		{"iii", 3},
+		{"iv", 4},
+		{"ix", 9},
+		{"XII", 12},
+		{"xl", 40},
+		{"xc", 90},
+		{"c", 100},
+		{"a", 0},
+	}
+
+	for _, test := range tests {
+		if got := parseRoman(test.roman); got != test.want {
+			t.Errorf("parseRoman(%q) = %d, want %d", test.roman, got, test.want)
+		}
+	}
+}
+
+func TestCheckSignaQty(t *testing.T) {
+	signa, err := ParseSigna("3 dd 1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		qty  float64
+		warn bool
+	}{
+		{0, false},
+		{3, false},
+		{15, false},
+		{2, true},
+		{10, true},
+	}
+
+	for _, test := range tests {
+		if got := CheckSignaQty(signa, test.qty); (got != "") != test.warn {
+			t.Errorf("CheckSignaQty(3 dd 1, %v) = %q, want warning %v", test.qty, got, test.warn)
+		}
+	}
+
+	asNeeded, err := ParseSigna("3 dd 1", "prn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := CheckSignaQty(asNeeded, 10); got != "" {
+		t.Errorf("CheckSignaQty(prn, 10) = %q, want no warning", got)
+	}
+}
+
+func TestGetSignaSetQty(t *testing.T) {
+	tests := []struct {
+		eticketQty   float64
+		mf           string
+		medicineQtys []float64
+		want         float64
+	}{
+		{12, "m.f. pulv. dtd no. X", nil, 12},
+		{0, "m.f. pulv. dtd no. X", nil, 10},
+		{0, "m.f. pulv. dtd no. 15", nil, 15},
+		{0, "", []float64{9}, 9},
+		{0, "", []float64{9, 3}, 0},
+	}
+
+	for _, test := range tests {
+		if got := GetSignaSetQty(test.eticketQty, test.mf, test.medicineQtys); !floatEquals(got, test.want) {
+			t.Errorf("GetSignaSetQty(%v, %q, %v) = %v, want %v", test.eticketQty, test.mf, test.medicineQtys, got, test.want)
+		}
+	}
+}
+
+func TestFormatSignaAmount(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{1, "1"},
+		{0.5, "½"},
+		{1.5, "1½"},
+		{0.25, "¼"},
+		{2.75, "2¾"},
+	}
+
+	for _, test := range tests {
+		if got := FormatSignaAmount(test.amount); got != test.want {
+			t.Errorf("FormatSignaAmount(%v) = %q, want %q", test.amount, got, test.want)
+		}
+	}
+}
+
+func floatEquals(a float64, b float64) bool {
+	return math.Abs(a-b) < constants.SIGNA_QTY_TOLERANCE
+}
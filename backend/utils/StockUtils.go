@@ -2,44 +2,88 @@ package utils
 
 import (
 	"fmt"
+	"math"
 
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/types"
 )
 
-func CheckStock(medData *types.Medicine, unit *types.Unit, additionalQty float64) error {
-	var tempStock float64
+// the stock is checked in the branch, not the total of all branches
+func CheckStock(medStore types.MedicineStore, medData *types.Medicine, unit *types.Unit, additionalQty float64, branchId int) error {
+	tempStock, err := ConvertToFirstUnit(medData, unit, additionalQty)
+	if err != nil {
+		return err
+	}
 
-	if medData.FirstUnitID == unit.ID {
-		tempStock = additionalQty
-	} else if medData.SecondUnitID == unit.ID {
-		tempStock = (additionalQty * medData.SecondUnitToFirstUnitRatio)
-	} else if medData.ThirdUnitID == unit.ID {
-		tempStock = (additionalQty * medData.ThirdUnitToFirstUnitRatio)
-	} else {
-		return fmt.Errorf("unknown unit name for %s", medData.Name)
+	branchStock, err := medStore.GetMedicineStock(medData.ID, branchId)
+	if err != nil {
+		return err
 	}
 
-	if tempStock > medData.Qty {
+	if tempStock > branchStock {
 		return fmt.Errorf("buy requested is higher than the available stock")
 	}
 
 	return nil
 }
 
-func AddStock(medStore types.MedicineStore, medData *types.Medicine, unit *types.Unit, additionalQty float64, user *types.User) error {
-	var updatedQty float64
+// the same medicine can be needed by more than one item, e.g. an ingredient of two compounded medicines,
+// so the qty is totalled per medicine first. reversedQtys is the stock given back before the new
+// items are taken, in the first unit
+func CheckStockItems(medStore types.MedicineStore, stockItems []types.MedicineStockItem, branchId int, reversedQtys map[int]float64) error {
+	neededQtys, err := GetFirstUnitQtys(stockItems)
+	if err != nil {
+		return err
+	}
 
-	if medData.FirstUnitID == unit.ID {
-		updatedQty = (additionalQty + medData.Qty)
-	} else if medData.SecondUnitID == unit.ID {
-		updatedQty = (additionalQty * medData.SecondUnitToFirstUnitRatio) + medData.Qty
-	} else if medData.ThirdUnitID == unit.ID {
-		updatedQty = (additionalQty * medData.ThirdUnitToFirstUnitRatio) + medData.Qty
-	} else {
-		return fmt.Errorf("unknown unit name for %s", medData.Name)
+	medicines := make(map[int]*types.Medicine)
+	for _, stockItem := range stockItems {
+		medicines[stockItem.Medicine.ID] = stockItem.Medicine
+	}
+
+	for medId, qty := range neededQtys {
+		branchStock, err := medStore.GetMedicineStock(medId, branchId)
+		if err != nil {
+			return err
+		}
+
+		if (qty - (branchStock + reversedQtys[medId])) > constants.PRESC_QTY_TOLERANCE {
+			return fmt.Errorf("stock for %s is not enough, need %.2f", medicines[medId].Name, qty)
+		}
+	}
+
+	return nil
+}
+
+// the total qty of each medicine in the first unit
+func GetFirstUnitQtys(stockItems []types.MedicineStockItem) (map[int]float64, error) {
+	qtys := make(map[int]float64)
+
+	for _, stockItem := range stockItems {
+		qty, err := ConvertToFirstUnit(stockItem.Medicine, stockItem.Unit, stockItem.Qty)
+		if err != nil {
+			return nil, err
+		}
+
+		qtys[stockItem.Medicine.ID] += qty
+	}
+
+	return qtys, nil
+}
+
+func AddStock(medStore types.MedicineStore, medData *types.Medicine, unit *types.Unit, additionalQty float64, branchId int, user *types.User) error {
+	additionalStock, err := ConvertToFirstUnit(medData, unit, additionalQty)
+	if err != nil {
+		return err
+	}
+
+	branchStock, err := medStore.GetMedicineStock(medData.ID, branchId)
+	if err != nil {
+		return err
 	}
 
-	err := medStore.UpdateMedicineStock(medData.ID, updatedQty, user)
+	err = medStore.UpdateMedicineStock(medData.ID, branchId, (branchStock + additionalStock), user)
 	if err != nil {
 		return err
 	}
@@ -47,23 +91,68 @@ func AddStock(medStore types.MedicineStore, medData *types.Medicine, unit *types
 	return nil
 }
 
-func SubtractStock(medStore types.MedicineStore, medData *types.Medicine, unit *types.Unit, subtractionQty float64, user *types.User) error {
-	var updatedQty float64
+func SubtractStock(medStore types.MedicineStore, medData *types.Medicine, unit *types.Unit, subtractionQty float64, branchId int, user *types.User) error {
+	subtractionStock, err := ConvertToFirstUnit(medData, unit, subtractionQty)
+	if err != nil {
+		return err
+	}
+
+	branchStock, err := medStore.GetMedicineStock(medData.ID, branchId)
+	if err != nil {
+		return err
+	}
+
+	err = medStore.UpdateMedicineStock(medData.ID, branchId, (branchStock - subtractionStock), user)
+	if err != nil {
+		return err
+	}
+
+	countStockOut(branchStock, (branchStock - subtractionStock))
+
+	return nil
+}
 
+// the qty in the first unit of the medicine
+func ConvertToFirstUnit(medData *types.Medicine, unit *types.Unit, qty float64) (float64, error) {
 	if medData.FirstUnitID == unit.ID {
-		updatedQty = (medData.Qty - subtractionQty)
+		return qty, nil
 	} else if medData.SecondUnitID == unit.ID {
-		updatedQty = medData.Qty - (subtractionQty * medData.SecondUnitToFirstUnitRatio)
+		return (qty * medData.SecondUnitToFirstUnitRatio), nil
 	} else if medData.ThirdUnitID == unit.ID {
-		updatedQty = medData.Qty - (subtractionQty * medData.ThirdUnitToFirstUnitRatio)
-	} else {
-		return fmt.Errorf("unknown unit name for %s", medData.Name)
+		return (qty * medData.ThirdUnitToFirstUnitRatio), nil
 	}
 
-	err := medStore.UpdateMedicineStock(medData.ID, updatedQty, user)
+	return 0, fmt.Errorf("unknown unit name for %s", medData.Name)
+}
+
+// the stock never goes below zero, the qty that is not in stock anymore
+// is returned in the first unit, used for the offline sales
+func SubtractAvailableStock(medStore types.MedicineStore, medData *types.Medicine, unit *types.Unit, subtractionQty float64, branchId int, user *types.User) (float64, error) {
+	subtractionStock, err := ConvertToFirstUnit(medData, unit, subtractionQty)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	branchStock, err := medStore.GetMedicineStock(medData.ID, branchId)
+	if err != nil {
+		return 0, err
+	}
+
+	shortage := math.Max(subtractionStock-math.Max(branchStock, 0), 0)
+
+	err = medStore.UpdateMedicineStock(medData.ID, branchId, (branchStock - (subtractionStock - shortage)), user)
+	if err != nil {
+		return 0, err
+	}
+
+	countStockOut(branchStock, (branchStock - (subtractionStock - shortage)))
+
+	return shortage, nil
+}
+
+// the stock out is counted when the medicine that was in stock runs out
+func countStockOut(prevStock float64, newStock float64) {
+	if prevStock > 0 && newStock <= 0 {
+		metrics.IncStockOuts()
+	}
 }
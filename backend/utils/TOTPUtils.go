@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, constants.TOTP_SECRET_SIZE)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// the url read by the authenticator apps from the qr code
+func GetTOTPURL(issuer string, accountName string, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("digits", fmt.Sprintf("%d", constants.TOTP_DIGITS))
+	query.Set("period", fmt.Sprintf("%d", constants.TOTP_PERIOD))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// RFC 6238 with sha1, the code of the step before and after is accepted
+// for the clock difference of the phone
+func VerifyTOTP(secret string, code string, now time.Time) bool {
+	if secret == "" || len(code) != constants.TOTP_DIGITS {
+		return false
+	}
+
+	step := now.Unix() / constants.TOTP_PERIOD
+
+	for i := int64(-constants.TOTP_SKEW); i <= constants.TOTP_SKEW; i++ {
+		expected, err := getTOTPCode(secret, step+i)
+		if err != nil {
+			return false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getTOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < constants.TOTP_DIGITS; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", constants.TOTP_DIGITS, value%modulo), nil
+}
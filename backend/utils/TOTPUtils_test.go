@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+// the sha1 secret of the test vectors of RFC 6238
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// RFC 6238 appendix B, the codes are the last 6 digits of the 8 digit codes
+func TestGetTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		got, err := getTOTPCode(rfc6238Secret, test.unix/constants.TOTP_PERIOD)
+		if err != nil {
+			t.Fatalf("getTOTPCode(%d) error: %v", test.unix, err)
+		}
+
+		if got != test.want {
+			t.Errorf("getTOTPCode(%d) = %s, want %s", test.unix, got, test.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	period := time.Duration(constants.TOTP_PERIOD) * time.Second
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		now    time.Time
+		want   bool
+	}{
+		{"current step", rfc6238Secret, "050471", now, true},
+		{"lower case secret with spaces", " " + strings.ToLower(rfc6238Secret) + " ", "050471", now, true},
+		{"one step late", rfc6238Secret, "050471", now.Add(period), true},
+		{"one step early", rfc6238Secret, "050471", now.Add(-period), true},
+		{"two steps late", rfc6238Secret, "050471", now.Add(2 * period), false},
+		{"wrong code", rfc6238Secret, "050472", now, false},
+		{"8 digit code", rfc6238Secret, "14050471", now, false},
+		{"empty code", rfc6238Secret, "", now, false},
+		{"empty secret", "", "050471", now, false},
+		{"invalid secret", "not base32!", "050471", now, false},
+	}
+
+	for _, test := range tests {
+		if got := VerifyTOTP(test.secret, test.code, test.now); got != test.want {
+			t.Errorf("%s: VerifyTOTP = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %s is not base32: %v", secret, err)
+	}
+
+	if len(key) != constants.TOTP_SECRET_SIZE {
+		t.Errorf("secret size = %d, want %d", len(key), constants.TOTP_SECRET_SIZE)
+	}
+
+	code, err := getTOTPCode(secret, time.Now().Unix()/constants.TOTP_PERIOD)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !VerifyTOTP(secret, code, time.Now()) {
+		t.Errorf("code %s of the generated secret is not verified", code)
+	}
+}
+
+func TestGetTOTPURL(t *testing.T) {
+	got, err := url.Parse(GetTOTPURL("Pharmacon", "admin", "ABC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Scheme != "otpauth" || got.Host != "totp" || got.Path != "/Pharmacon:admin" {
+		t.Errorf("GetTOTPURL = %s, want otpauth://totp/Pharmacon:admin", got)
+	}
+
+	query := got.Query()
+	if query.Get("secret") != "ABC" || query.Get("issuer") != "Pharmacon" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("GetTOTPURL query = %s", got.RawQuery)
+	}
+}
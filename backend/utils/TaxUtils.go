@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// returns the tax percentage in effect on the given date,
+// the invoice with a taxed medicine can't be made without the tax configured
+func GetTaxPercentage(taxStore types.TaxStore, date time.Time) (float64, error) {
+	tax, err := taxStore.GetEffectiveTaxByCode(constants.DEFAULT_TAX_CODE, date)
+	if err != nil {
+		return 0, fmt.Errorf("error get tax %s on %s: %v", constants.DEFAULT_TAX_CODE, date.Format("2006-01-02"), err)
+	}
+
+	return tax.Percentage, nil
+}
+
+// tax amount is rounded to the nearest rupiah
+func CalculateTax(taxableAmount float64, taxPercentage float64) float64 {
+	return math.Round(taxableAmount * taxPercentage / 100)
+}
+
+// invoice discount is spread over the items, so the taxable part
+// of the discount is proportional to the taxable part of the subtotal
+func GetTaxableAmount(taxableSubtotal float64, subtotal float64, discountAmount float64) float64 {
+	if subtotal == 0 {
+		return 0
+	}
+
+	return taxableSubtotal - (discountAmount * taxableSubtotal / subtotal)
+}
+
+// only the taxable medicines are taxed, total price and change are recalculated
+func CalculateInvoiceTax(taxStore types.TaxStore, medStore types.MedicineStore, payload *types.RegisterInvoicePayload, invoiceDate time.Time) error {
+	err := SetInvoiceTax(taxStore, medStore, payload, invoiceDate)
+	if err != nil {
+		return err
+	}
+
+	if payload.ChangeAmount < 0 {
+		return fmt.Errorf("paid amount is less than the total price %.2f", payload.TotalPrice)
+	}
+
+	return nil
+}
+
+// sets the tax, total price and change of the invoice without checking the paid amount
+func SetInvoiceTax(taxStore types.TaxStore, medStore types.MedicineStore, payload *types.RegisterInvoicePayload, invoiceDate time.Time) error {
+	var taxableSubtotal float64
+
+	for _, medicine := range payload.MedicineLists {
+		medData, err := medStore.GetMedicineByBarcode(medicine.MedicineBarcode)
+		if err != nil {
+			return fmt.Errorf("medicine %s doesn't exists", medicine.MedicineName)
+		}
+
+		if medData.IsTaxable {
+			taxableSubtotal += medicine.Subtotal
+		}
+	}
+
+	// the tax is only needed if something is taxed
+	taxPercentage := 0.0
+	if taxableSubtotal > 0 {
+		var err error
+		taxPercentage, err = GetTaxPercentage(taxStore, invoiceDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	taxableAmount := GetTaxableAmount(taxableSubtotal, payload.Subtotal, payload.DiscountAmount)
+
+	payload.TaxPercentage = taxPercentage
+	payload.TaxAmount = CalculateTax(taxableAmount, taxPercentage)
+	payload.TaxableAmount = taxableAmount
+	payload.TotalPrice = payload.Subtotal - payload.DiscountAmount + payload.TaxAmount
+	payload.ChangeAmount = payload.PaidAmount - payload.TotalPrice
+
+	return nil
+}
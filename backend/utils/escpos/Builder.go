@@ -0,0 +1,307 @@
+package escpos
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+const (
+	esc = 0x1B
+	gs  = 0x1D
+	lf  = 0x0A
+)
+
+const (
+	alignLeft   = 0
+	alignCenter = 1
+	alignRight  = 2
+)
+
+// builder writes the ESC/POS commands of one receipt,
+// the text is in font A so the number of characters per line is fixed
+type builder struct {
+	buffer       bytes.Buffer
+	charsPerLine int
+	dots         int
+}
+
+func newBuilder(paperWidth int) (*builder, error) {
+	b := new(builder)
+
+	switch paperWidth {
+	case constants.ESCPOS_PAPER_58:
+		b.charsPerLine = constants.ESCPOS_58_CHARS_PER_LINE
+		b.dots = constants.ESCPOS_58_DOTS
+	case constants.ESCPOS_PAPER_80:
+		b.charsPerLine = constants.ESCPOS_80_CHARS_PER_LINE
+		b.dots = constants.ESCPOS_80_DOTS
+	default:
+		return nil, fmt.Errorf("unknown paper width %d mm", paperWidth)
+	}
+
+	// initialize the printer, code page PC437
+	b.buffer.Write([]byte{esc, '@', esc, 't', 0})
+
+	return b, nil
+}
+
+func (b *builder) bytes() []byte {
+	return b.buffer.Bytes()
+}
+
+func (b *builder) align(align byte) {
+	b.buffer.Write([]byte{esc, 'a', align})
+}
+
+func (b *builder) bold(isBold bool) {
+	if isBold {
+		b.buffer.Write([]byte{esc, 'E', 1})
+		return
+	}
+
+	b.buffer.Write([]byte{esc, 'E', 0})
+}
+
+// width and height are the magnification, 1 to 8
+func (b *builder) size(width int, height int) {
+	b.buffer.Write([]byte{gs, '!', byte(((width - 1) << 4) | (height - 1))})
+}
+
+func (b *builder) feed(lines int) {
+	b.buffer.Write([]byte{esc, 'd', byte(lines)})
+}
+
+// feed the paper to the cutter first, then partial cut
+func (b *builder) cut() {
+	b.buffer.Write([]byte{gs, 'V', 66, constants.ESCPOS_FEED_BEFORE_CUT})
+}
+
+func (b *builder) line(text string) {
+	b.buffer.WriteString(toPrintable(text))
+	b.buffer.WriteByte(lf)
+}
+
+// the text is wrapped by words, with the current alignment
+func (b *builder) text(text string) {
+	for _, line := range wrapText(toPrintable(text), b.charsPerLine) {
+		b.line(line)
+	}
+}
+
+func (b *builder) separator(char string) {
+	b.line(strings.Repeat(char, b.charsPerLine))
+}
+
+// the left text is wrapped if the right text doesn't fit in the same line
+func (b *builder) columns(left string, right string) {
+	left = toPrintable(left)
+	right = toPrintable(right)
+
+	if (len(left) + len(right) + 1) <= b.charsPerLine {
+		b.line(left + strings.Repeat(" ", (b.charsPerLine-len(left)-len(right))) + right)
+		return
+	}
+
+	for _, line := range wrapText(left, b.charsPerLine) {
+		b.line(line)
+	}
+
+	b.line(fmt.Sprintf("%*s", b.charsPerLine, right))
+}
+
+// CODE128 with the text printed below
+func (b *builder) barcode(data string) {
+	data = toPrintable(data)
+
+	b.buffer.Write([]byte{gs, 'h', constants.ESCPOS_BARCODE_HEIGHT})
+	b.buffer.Write([]byte{gs, 'w', 2})
+	b.buffer.Write([]byte{gs, 'H', 2})
+
+	// code set B
+	content := "{B" + data
+	b.buffer.Write([]byte{gs, 'k', 73, byte(len(content))})
+	b.buffer.WriteString(content)
+	b.buffer.WriteByte(lf)
+}
+
+// QR code model 2 with error correction level M
+func (b *builder) qrCode(data string) {
+	length := len(data) + 3
+
+	b.buffer.Write([]byte{gs, '(', 'k', 4, 0, 49, 65, 50, 0})
+	b.buffer.Write([]byte{gs, '(', 'k', 3, 0, 49, 67, constants.ESCPOS_QR_MODULE_SIZE})
+	b.buffer.Write([]byte{gs, '(', 'k', 3, 0, 49, 69, 49})
+	b.buffer.Write([]byte{gs, '(', 'k', byte(length % 256), byte(length / 256), 49, 80, 48})
+	b.buffer.WriteString(data)
+	b.buffer.Write([]byte{gs, '(', 'k', 3, 0, 49, 81, 48})
+	b.buffer.WriteByte(lf)
+}
+
+// the image is scaled to the width in dots and dithered into black and white
+func (b *builder) image(img image.Image, width int) {
+	if width > b.dots {
+		width = b.dots
+	}
+
+	pixels := getMonochromePixels(img, width)
+	if len(pixels) == 0 {
+		return
+	}
+
+	height := len(pixels)
+	widthBytes := (width + 7) / 8
+
+	for startY := 0; startY < height; startY += constants.ESCPOS_RASTER_BAND_HEIGHT {
+		bandHeight := min(constants.ESCPOS_RASTER_BAND_HEIGHT, (height - startY))
+
+		b.buffer.Write([]byte{gs, 'v', '0', 0,
+			byte(widthBytes % 256), byte(widthBytes / 256),
+			byte(bandHeight % 256), byte(bandHeight / 256)})
+
+		for y := startY; y < (startY + bandHeight); y++ {
+			row := make([]byte, widthBytes)
+
+			for x := 0; x < width; x++ {
+				if pixels[y][x] {
+					row[x/8] |= (0x80 >> (x % 8))
+				}
+			}
+
+			b.buffer.Write(row)
+		}
+	}
+}
+
+// true is a black dot, Floyd-Steinberg dithering on the luminance,
+// the transparent pixels are white
+func getMonochromePixels(img image.Image, width int) [][]bool {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 || width <= 0 {
+		return nil
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height == 0 {
+		return nil
+	}
+
+	luminance := make([][]float64, height)
+
+	for y := 0; y < height; y++ {
+		luminance[y] = make([]float64, width)
+
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + (x * bounds.Dx() / width)
+			srcY := bounds.Min.Y + (y * bounds.Dy() / height)
+
+			r, g, b, a := img.At(srcX, srcY).RGBA()
+
+			// blend with the white paper
+			gray := ((0.299 * float64(r)) + (0.587 * float64(g)) + (0.114 * float64(b))) / 0xFFFF
+			alpha := float64(a) / 0xFFFF
+			luminance[y][x] = (gray * alpha) + (1 - alpha)
+		}
+	}
+
+	pixels := make([][]bool, height)
+
+	for y := 0; y < height; y++ {
+		pixels[y] = make([]bool, width)
+
+		for x := 0; x < width; x++ {
+			oldValue := luminance[y][x]
+			newValue := 1.0
+			if oldValue < 0.5 {
+				newValue = 0.0
+				pixels[y][x] = true
+			}
+
+			quantError := oldValue - newValue
+
+			if (x + 1) < width {
+				luminance[y][x+1] += quantError * 7 / 16
+			}
+			if (y + 1) < height {
+				if x > 0 {
+					luminance[y+1][x-1] += quantError * 3 / 16
+				}
+				luminance[y+1][x] += quantError * 5 / 16
+				if (x + 1) < width {
+					luminance[y+1][x+1] += quantError * 1 / 16
+				}
+			}
+		}
+	}
+
+	return pixels
+}
+
+// the printer code page has no accents, so the accents are removed
+// and the other non ASCII characters are replaced
+func toPrintable(text string) string {
+	var builder strings.Builder
+
+	for _, r := range norm.NFD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == '\n' || r == '\t':
+			builder.WriteRune(' ')
+		case r >= 0x20 && r < 0x7F:
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune('?')
+		}
+	}
+
+	return builder.String()
+}
+
+func wrapText(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	lines := make([]string, 0)
+	line := ""
+
+	for _, word := range words {
+		// the word longer than the line is broken
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+
+		if line == "" {
+			line = word
+			continue
+		}
+
+		if (len(line) + 1 + len(word)) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+
+		line += " " + word
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
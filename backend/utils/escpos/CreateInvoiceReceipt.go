@@ -0,0 +1,162 @@
+package escpos
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the same data as the invoice pdf, for the thermal printer at the counter
+func CreateInvoiceReceipt(invoice types.InvoicePDFPayload, branding *types.DocumentBranding, paperWidth int, codeType string) ([]byte, error) {
+	b, err := newBuilder(paperWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	createInvoiceReceiptHeader(b, branding.Company)
+	createInvoiceReceiptInfo(b, invoice)
+	createInvoiceReceiptData(b, invoice.MedicineLists)
+	createInvoiceReceiptFooter(b, invoice)
+
+	err = createInvoiceReceiptCode(b, invoice, codeType)
+	if err != nil {
+		return nil, err
+	}
+
+	b.align(alignCenter)
+	if branding.Company.Slogan != "" {
+		b.text(branding.Company.Slogan)
+	}
+	if branding.Footer != "" {
+		b.text(branding.Footer)
+	}
+	b.line(fmt.Sprintf("Tgl. Cetak: %s", time.Now().Format("02-01-2006  15:04")))
+
+	b.cut()
+
+	return b.bytes(), nil
+}
+
+func createInvoiceReceiptHeader(b *builder, company types.CompanySetting) {
+	b.align(alignCenter)
+
+	// the receipt is still printed without the logo
+	logo, err := readLogo(company.Logo)
+	if err == nil {
+		b.image(logo, int(float64(b.dots)*constants.ESCPOS_LOGO_WIDTH_RATIO))
+	}
+
+	b.bold(true)
+	b.size(2, 2)
+	for _, line := range wrapText(toPrintable(company.Name), (b.charsPerLine / 2)) {
+		b.line(line)
+	}
+	b.size(1, 1)
+	b.bold(false)
+
+	if company.Address != "" {
+		b.text(company.Address)
+	}
+	if company.PhoneNumber != "" {
+		b.text(fmt.Sprintf("No. Telp: %s", company.PhoneNumber))
+	}
+	if company.WhatsAppNumber != "" {
+		b.text(fmt.Sprintf("WhatsApp: %s", company.WhatsAppNumber))
+	}
+
+	b.align(alignLeft)
+	b.separator("-")
+}
+
+func createInvoiceReceiptInfo(b *builder, invoice types.InvoicePDFPayload) {
+	var caser = cases.Title(language.Indonesian)
+
+	b.line(fmt.Sprintf("%-6s: %d", "No.", invoice.Number))
+	b.line(fmt.Sprintf("%-6s: %s", "Tgl.", invoice.InvoiceDate.Format("02-01-2006")))
+	b.line(fmt.Sprintf("%-6s: %s", "Kasir", caser.String(invoice.UserName)))
+
+	b.separator("-")
+}
+
+// the item name takes the whole line, the qty and subtotal are below it
+func createInvoiceReceiptData(b *builder, medicineLists []types.InvoiceMedicineListsPayload) {
+	var printer = message.NewPrinter(language.Indonesian)
+
+	for i, medicine := range medicineLists {
+		b.text(fmt.Sprintf("%d. %s", (i + 1), strings.ToUpper(medicine.MedicineName)))
+
+		qtyString := printer.Sprintf("   %.1f %s x Rp. %.1f", medicine.Qty, strings.ToUpper(medicine.Unit), medicine.Price)
+		subtotalString := printer.Sprintf("Rp. %.1f", medicine.Subtotal)
+		b.columns(qtyString, subtotalString)
+
+		if medicine.DiscountPercentage > 0 || medicine.DiscountAmount > 0 {
+			discountString := printer.Sprintf("   Disc. %.1f%%", medicine.DiscountPercentage)
+			discountAmountString := printer.Sprintf("-Rp. %.1f", medicine.DiscountAmount)
+			b.columns(discountString, discountAmountString)
+		}
+	}
+
+	b.separator("-")
+}
+
+func createInvoiceReceiptFooter(b *builder, invoice types.InvoicePDFPayload) {
+	var printer = message.NewPrinter(language.Indonesian)
+
+	b.columns("Subtotal:", printer.Sprintf("Rp. %.1f", invoice.Subtotal))
+	b.columns(printer.Sprintf("Discount (%.1f%%):", invoice.DiscountPercentage), printer.Sprintf("Rp. %.1f", invoice.DiscountAmount))
+	b.columns(printer.Sprintf("Tax (%.1f%%):", invoice.TaxPercentage), printer.Sprintf("Rp. %.1f", invoice.TaxAmount))
+
+	b.bold(true)
+	b.columns("Total:", printer.Sprintf("Rp. %.1f", invoice.TotalPrice))
+	b.bold(false)
+
+	b.columns("Paid:", printer.Sprintf("Rp. %.1f", invoice.PaidAmount))
+	b.columns("Change:", printer.Sprintf("Rp. %.1f", invoice.ChangeAmount))
+
+	if invoice.Description != "" {
+		b.separator("-")
+		b.text(fmt.Sprintf("Note: %s", invoice.Description))
+	}
+
+	b.separator("-")
+}
+
+func createInvoiceReceiptCode(b *builder, invoice types.InvoicePDFPayload, codeType string) error {
+	b.align(alignCenter)
+
+	number := strconv.Itoa(invoice.Number)
+
+	switch codeType {
+	case "", constants.ESCPOS_CODE_BARCODE:
+		b.barcode(number)
+	case constants.ESCPOS_CODE_QR:
+		b.qrCode(number)
+	default:
+		return fmt.Errorf("unknown receipt code type %s", codeType)
+	}
+
+	b.feed(1)
+
+	return nil
+}
+
+func readLogo(content []byte) (image.Image, error) {
+	logo, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	return logo, nil
+}
@@ -0,0 +1,40 @@
+package escpos
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+// raw printing to the network printer (JetDirect), the port is 9100 if it is not given
+func SendToPrinter(address string, data []byte) error {
+	if address == "" {
+		return fmt.Errorf("receipt printer address is empty")
+	}
+
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, constants.ESCPOS_PRINTER_PORT)
+	}
+
+	timeout := constants.ESCPOS_PRINTER_TIMEOUT_SECONDS * time.Second
+
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return fmt.Errorf("error connecting to printer %s: %v", address, err)
+	}
+	defer conn.Close()
+
+	err = conn.SetWriteDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(data)
+	if err != nil {
+		return fmt.Errorf("error sending to printer %s: %v", address, err)
+	}
+
+	return nil
+}
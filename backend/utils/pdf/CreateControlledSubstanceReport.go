@@ -0,0 +1,252 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
+	"github.com/nicolaics/pharmacon/types"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var indonesianMonths = []string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// the report of the same month is overwritten, so the file name is always the same
+func CreateControlledSubstanceReportPDF(report types.ControlledSubstanceReportReturnPayload, controlledClass string, company *types.CompanySetting, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_CONTROLLED_SUBSTANCE, time.Now())
+
+	pdf, err := initControlledSubstanceReportPdf()
+	if err != nil {
+		return "", err
+	}
+
+	err = createControlledSubstanceReportHeader(pdf, report, controlledClass, *company)
+	if err != nil {
+		return "", err
+	}
+
+	startX, err := createControlledSubstanceReportTableHeader(pdf, (pdf.GetY() + 0.4))
+	if err != nil {
+		return "", err
+	}
+
+	err = createControlledSubstanceReportData(pdf, startX, report.Items)
+	if err != nil {
+		return "", err
+	}
+
+	err = createControlledSubstanceReportFooter(pdf, report, *company)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := fmt.Sprintf("cs-report-%d-%02d.pdf", report.Year, report.Month)
+	if controlledClass != "" {
+		fileName = fmt.Sprintf("cs-report-%s-%d-%02d.pdf", strings.ToLower(controlledClass), report.Year, report.Month)
+	}
+
+	err = savePDF(pdf, storage, constants.DOCUMENT_CONTROLLED_SUBSTANCE, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func initControlledSubstanceReportPdf() (*fpdf.Fpdf, error) {
+	s, _ := filepath.Abs("static/assets/font/")
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "L",
+		UnitStr:        "cm",
+		SizeStr:        "A4",
+		Size: fpdf.SizeType{
+			Wd: constants.CS_REPORT_WIDTH,
+			Ht: constants.CS_REPORT_HEIGHT,
+		},
+		FontDirStr: s,
+	})
+
+	pdf.SetMargins(constants.CS_REPORT_MARGIN, constants.CS_REPORT_MARGIN, constants.CS_REPORT_MARGIN)
+	pdf.SetAutoPageBreak(true, constants.CS_REPORT_MARGIN)
+
+	pdf.AddUTF8Font("Arial", constants.REGULAR, "Arial.TTF")
+	pdf.AddUTF8Font("Arial", constants.BOLD, "ArialBD.TTF")
+	pdf.AddUTF8Font("Calibri", constants.REGULAR, "Calibri.TTF")
+	pdf.AddUTF8Font("Calibri", constants.BOLD, "CalibriBold.TTF")
+	pdf.AddUTF8Font("Bree", constants.BOLD, "Bree Serif Bold.ttf")
+
+	pdf.AddPage()
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("error init controlled substance report pdf: %v", pdf.Error())
+	}
+
+	return pdf, nil
+}
+
+func createControlledSubstanceReportHeader(pdf *fpdf.Fpdf, report types.ControlledSubstanceReportReturnPayload, controlledClass string, company types.CompanySetting) error {
+	drawDocumentLogo(pdf, company, constants.CS_REPORT_LOGO_WIDTH, constants.CS_REPORT_LOGO_HEIGHT)
+
+	startBesideLogoX := constants.CS_REPORT_MARGIN + constants.CS_REPORT_LOGO_WIDTH + 0.2
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetTextColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
+	pdf.SetFont("Bree", constants.BOLD, 18)
+	pdf.CellFormat(0, 0.65, strings.ToUpper(company.Name), "", 1, "L", false, 0, "")
+
+	pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.CS_REPORT_HEADER_FONT_SZ)
+	pdf.CellFormat(0, constants.CS_REPORT_HEADER_HEIGHT, company.Address, "", 1, "L", false, 0, "")
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.CS_REPORT_HEADER_FONT_SZ)
+	businessRegNumber := fmt.Sprintf("No. SIA: %s", company.BusinessRegistrationNumber)
+	pdf.CellFormat(0, constants.CS_REPORT_HEADER_HEIGHT, businessRegNumber, "", 1, "L", false, 0, "")
+
+	title := "LAPORAN PENGGUNAAN NARKOTIKA DAN PSIKOTROPIKA"
+	if controlledClass != "" {
+		title = "LAPORAN PENGGUNAAN " + controlledClass
+	}
+
+	pdf.SetY(constants.CS_REPORT_MARGIN + constants.CS_REPORT_LOGO_HEIGHT + 0.3)
+	pdf.SetFont("Calibri", constants.BOLD, constants.CS_REPORT_TITLE_FONT_SZ)
+	pdf.CellFormat(0, 0.6, title, "", 1, "C", false, 0, "")
+
+	period := fmt.Sprintf("Periode: %s %d", indonesianMonths[report.Month-1], report.Year)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.CS_REPORT_STD_FONT_SZ)
+	pdf.CellFormat(0, constants.CS_REPORT_HEADER_HEIGHT, period, "", 1, "C", false, 0, "")
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create controlled substance report header: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createControlledSubstanceReportTableHeader(pdf *fpdf.Fpdf, startTableY float64) (map[string]float64, error) {
+	pdf.SetLineWidth(0.02)
+	pdf.SetY(startTableY)
+
+	headers := []struct {
+		key   string
+		title string
+		width float64
+	}{
+		{"number", "No.", constants.CS_REPORT_NO_COL_WIDTH},
+		{"item", "Nama Obat", constants.CS_REPORT_ITEM_COL_WIDTH},
+		{"class", "Golongan", constants.CS_REPORT_CLASS_COL_WIDTH},
+		{"unit", "Satuan", constants.CS_REPORT_UNIT_COL_WIDTH},
+		{"opening", "Stok Awal", constants.CS_REPORT_QTY_COL_WIDTH},
+		{"inPurchase", "Masuk PBF", constants.CS_REPORT_QTY_COL_WIDTH},
+		{"inProduction", "Masuk Sarana", constants.CS_REPORT_QTY_COL_WIDTH},
+		{"outPrescription", "Resep", constants.CS_REPORT_QTY_COL_WIDTH},
+		{"outSale", "Penyaluran", constants.CS_REPORT_QTY_COL_WIDTH},
+		{"outProduction", "Produksi", constants.CS_REPORT_QTY_COL_WIDTH},
+		{"closing", "Stok Akhir", constants.CS_REPORT_QTY_COL_WIDTH},
+	}
+
+	startX := make(map[string]float64)
+
+	pdf.SetFont("Calibri", constants.BOLD, constants.CS_REPORT_TABLE_HEADER_FONT_SZ)
+
+	for _, header := range headers {
+		startX[header.key] = pdf.GetX()
+		pdf.CellFormat(header.width, constants.CS_REPORT_TABLE_HEIGHT, header.title, "1", 0, "C", false, 0, "")
+	}
+
+	startX["end"] = pdf.GetX()
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("error create controlled substance report table header: %v", pdf.Error())
+	}
+
+	pdf.Ln(-1)
+
+	return startX, nil
+}
+
+func createControlledSubstanceReportData(pdf *fpdf.Fpdf, startX map[string]float64, items []types.ControlledSubstanceReportItem) error {
+	var printer = message.NewPrinter(language.Indonesian)
+
+	pdf.SetLineWidth(0.02)
+
+	for i, item := range items {
+		if (pdf.GetY() + constants.CS_REPORT_TABLE_HEIGHT) > (constants.CS_REPORT_HEIGHT - constants.CS_REPORT_MARGIN) {
+			pdf.AddPage()
+
+			_, err := createControlledSubstanceReportTableHeader(pdf, constants.CS_REPORT_MARGIN)
+			if err != nil {
+				return err
+			}
+		}
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.CS_REPORT_TABLE_DATA_FONT_SZ)
+
+		pdf.CellFormat(constants.CS_REPORT_NO_COL_WIDTH, constants.CS_REPORT_TABLE_HEIGHT, strconv.Itoa(i+1), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(constants.CS_REPORT_ITEM_COL_WIDTH, constants.CS_REPORT_TABLE_HEIGHT, strings.ToUpper(item.MedicineName), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(constants.CS_REPORT_CLASS_COL_WIDTH, constants.CS_REPORT_TABLE_HEIGHT, item.ControlledClass, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(constants.CS_REPORT_UNIT_COL_WIDTH, constants.CS_REPORT_TABLE_HEIGHT, strings.ToUpper(item.Unit), "1", 0, "C", false, 0, "")
+
+		qtys := []float64{
+			item.OpeningBalance, item.InPurchase, (item.InProduction + item.InTransfer),
+			item.OutPrescription, (item.OutSale + item.OutTransfer), item.OutProduction, item.ClosingBalance,
+		}
+
+		for _, qty := range qtys {
+			pdf.CellFormat(constants.CS_REPORT_QTY_COL_WIDTH, constants.CS_REPORT_TABLE_HEIGHT, printer.Sprintf("%.1f", qty), "1", 0, "R", false, 0, "")
+		}
+
+		pdf.Ln(-1)
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create controlled substance report data: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createControlledSubstanceReportFooter(pdf *fpdf.Fpdf, report types.ControlledSubstanceReportReturnPayload, company types.CompanySetting) error {
+	// pharmacist signature needs about 3.5 cm
+	if (pdf.GetY() + 3.5) > (constants.CS_REPORT_HEIGHT - constants.CS_REPORT_MARGIN) {
+		pdf.AddPage()
+	}
+
+	startSignX := constants.CS_REPORT_WIDTH - constants.CS_REPORT_MARGIN - 7.0
+
+	pdf.SetXY(startSignX, (pdf.GetY() + 0.8))
+	pdf.SetFont("Calibri", constants.REGULAR, constants.CS_REPORT_STD_FONT_SZ)
+	pdf.CellFormat(7.0, constants.CS_REPORT_FOOTER_CELL_HEIGHT,
+		fmt.Sprintf("%s %d", indonesianMonths[report.Month-1], report.Year), "", 1, "C", false, 0, "")
+
+	pdf.SetX(startSignX)
+	pdf.CellFormat(7.0, constants.CS_REPORT_FOOTER_CELL_HEIGHT, "Apoteker Penanggung Jawab", "", 1, "C", false, 0, "")
+
+	pdf.SetXY(startSignX, (pdf.GetY() + 1.5))
+	pdf.SetFont("Calibri", constants.BOLD, constants.CS_REPORT_STD_FONT_SZ)
+	pdf.CellFormat(7.0, constants.CS_REPORT_FOOTER_CELL_HEIGHT, company.Pharmacist, "B", 1, "C", false, 0, "")
+
+	pdf.SetX(startSignX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.CS_REPORT_STD_FONT_SZ)
+	pdf.CellFormat(7.0, constants.CS_REPORT_FOOTER_CELL_HEIGHT,
+		fmt.Sprintf("No. SIPA: %s", company.PharmacistLicenseNumber), "", 1, "C", false, 0, "")
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create controlled substance report footer: %v", pdf.Error())
+	}
+
+	return nil
+}
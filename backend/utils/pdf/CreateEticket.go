@@ -0,0 +1,378 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// the size, fonts and rows of the eticket come from the template
+func CreateEticketPDF(eticket types.EticketPDFReturnPayload, setNumber int, template *types.EticketTemplate, prescStore types.PrescriptionStore, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_ETICKET, time.Now())
+
+	pdf, err := initEticketPdf(template)
+	if err != nil {
+		return "", err
+	}
+
+	err = createEtixData(pdf, template, eticket, setNumber, branding.Footer)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := "e-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+	isExist, err := prescStore.IsPDFUrlExist("eticket", fileName)
+	if err != nil {
+		return "", err
+	}
+
+	for isExist {
+		fileName = "e-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+		isExist, err = prescStore.IsPDFUrlExist("eticket", fileName)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = savePDF(pdf, storage, constants.DOCUMENT_ETICKET, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func initEticketPdf(template *types.EticketTemplate) (*fpdf.Fpdf, error) {
+	s, _ := filepath.Abs("static/assets/font/")
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "cm",
+		SizeStr:        fmt.Sprintf("%gx%g", template.Width, template.Height),
+		Size: fpdf.SizeType{
+			Wd: template.Width,
+			Ht: template.Height,
+		},
+		FontDirStr: s,
+	})
+
+	pdf.SetMargins(template.Margin, template.Margin, template.Margin)
+	pdf.SetAutoPageBreak(false, template.Margin)
+
+	for _, font := range template.Fonts {
+		pdf.AddUTF8Font(template.FontFamily, font.Style, font.File)
+	}
+
+	pdf.AddPage()
+
+	// the external use eticket is printed on blue
+	if template.Variant == constants.ETIX_VARIANT_BLUE {
+		pdf.SetFillColor(constants.ETIX_BLUE_R, constants.ETIX_BLUE_G, constants.ETIX_BLUE_B)
+		pdf.Rect(0, 0, template.Width, template.Height, "F")
+	}
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("error init eticket %s pdf: %v", template.Name, pdf.Error())
+	}
+
+	return pdf, nil
+}
+
+func createEtixData(pdf *fpdf.Fpdf, template *types.EticketTemplate, eticket types.EticketPDFReturnPayload, setNumber int, footer string) error {
+	caser := cases.Title(language.Indonesian)
+
+	pdf.SetLineWidth(constants.ETIX_LINE_WIDTH)
+	pdf.SetDrawColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+
+	pdf.Line(0, pdf.GetY(), template.Width, pdf.GetY())
+
+	now := time.Now()
+
+	for _, row := range template.Rows {
+		pdf.SetFont(template.FontFamily, row.Style, row.FontSize)
+
+		rowHeight := row.CellHeight * float64(row.Lines)
+
+		switch row.Field {
+		case constants.ETIX_FIELD_NUMBER:
+			label := row.Label
+			if label == "" {
+				label = "No."
+			}
+
+			number := fmt.Sprintf("%s  %d-%d", label, eticket.Number, setNumber)
+			pdf.CellFormat(0, rowHeight, number, "", 1, (row.Align + "M"), false, 0, "")
+		case constants.ETIX_FIELD_DATE:
+			label := row.Label
+			if label == "" {
+				label = "Tgl."
+			}
+
+			dateTime := fmt.Sprintf("%s  %s", label, now.Format("02-01-2006"))
+			pdf.CellFormat((pdf.GetStringWidth(dateTime) + (4 * pdf.GetCellMargin())), rowHeight, dateTime, "", 0, (row.Align + "M"), false, 0, "")
+
+			pdf.SetFontSize(row.FontSize - 2)
+			pdf.CellFormat(0, rowHeight, now.Format("15:04"), "", 1, "LM", false, 0, "")
+		case constants.ETIX_FIELD_PATIENT_NAME:
+			label := row.Label
+			if label == "" {
+				label = "Nama:"
+			}
+
+			labelWidth := row.LabelWidth
+			if labelWidth <= 0 {
+				labelWidth = pdf.GetStringWidth(label) + (2 * pdf.GetCellMargin())
+			}
+
+			pdf.SetFont(template.FontFamily, constants.REGULAR, template.FontSize)
+			pdf.CellFormat(labelWidth, rowHeight, label, "", 0, "LM", false, 0, "")
+
+			startName := pdf.GetX()
+			nameWidth := template.Width - template.Margin - startName
+
+			pdf.SetFont(template.FontFamily, row.Style, row.FontSize)
+
+			// one word per line if it fits, else wrap by the width
+			lines := strings.Fields(caser.String(eticket.PatientName))
+			if len(lines) > row.Lines {
+				lines = pdf.SplitText(strings.Join(lines, " "), nameWidth)
+			}
+
+			writeEtixLines(pdf, lines, startName, nameWidth, rowHeight, row.Lines, row.Align)
+		case constants.ETIX_FIELD_USAGE:
+			usage := caser.String(eticket.SetUsage)
+			if row.Label != "" {
+				usage = row.Label + " " + usage
+			}
+
+			width := template.Width - (2 * template.Margin)
+			writeEtixLines(pdf, pdf.SplitText(usage, width), template.Margin, width, rowHeight, row.Lines, row.Align)
+		case constants.ETIX_FIELD_DOSE:
+			createEtixDose(pdf, template, row, eticket)
+		case constants.ETIX_FIELD_CONSUME_TIME:
+			pdf.CellFormat(0, rowHeight, getEtixConsumeTime(eticket.ConsumeTime), "", 1, (row.Align + "M"), false, 0, "")
+		case constants.ETIX_FIELD_INSTRUCTION:
+			// the dose and consume time are printed as they are if the signa can't be read
+			lines := []string{
+				fmt.Sprintf("Sehari %s %s", strings.ToLower(eticket.Dose), strings.ToLower(eticket.SetUnit)),
+				getEtixConsumeTime(eticket.ConsumeTime),
+			}
+
+			width := template.Width - (2 * template.Margin)
+			if eticket.Instruction != "" {
+				lines = pdf.SplitText(eticket.Instruction, width)
+			}
+
+			writeEtixLines(pdf, lines, template.Margin, width, rowHeight, row.Lines, row.Align)
+		case constants.ETIX_FIELD_BADGES:
+			badges := make([]string, 0)
+			if eticket.ShakeWell {
+				badges = append(badges, constants.ETIX_BADGE_SHAKE_WELL)
+			}
+			if eticket.MustFinish {
+				badges = append(badges, constants.ETIX_BADGE_MUST_FINISH)
+			}
+
+			// the row is left out when there is no badge
+			if len(badges) == 0 {
+				continue
+			}
+
+			createEtixBadges(pdf, template, row, badges)
+		case constants.ETIX_FIELD_QTY:
+			label := row.Label
+			if label == "" {
+				label = "Qty:"
+			}
+
+			qtyTxt := fmt.Sprintf("%s %s", label, strconv.FormatFloat(eticket.MedicineQty, 'f', -1, 64))
+			pdf.CellFormat(0, rowHeight, qtyTxt, "", 1, (row.Align + "M"), false, 0, "")
+		case constants.ETIX_FIELD_TEXT:
+			width := template.Width - (2 * template.Margin)
+			writeEtixLines(pdf, pdf.SplitText(row.Text, width), template.Margin, width, rowHeight, row.Lines, row.Align)
+		case constants.ETIX_FIELD_FOOTER:
+			// the footer from the document setting, the row is left out when it is empty
+			if footer == "" {
+				continue
+			}
+
+			width := template.Width - (2 * template.Margin)
+			writeEtixLines(pdf, pdf.SplitText(footer, width), template.Margin, width, rowHeight, row.Lines, row.Align)
+		}
+
+		if !row.NoLine {
+			pdf.Line(0, pdf.GetY(), template.Width, pdf.GetY())
+		}
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create eticket %s data: %v", template.Name, pdf.Error())
+	}
+
+	return nil
+}
+
+func getEtixConsumeTime(consumeTime string) string {
+	if consumeTime == "ac" {
+		return "Sebelum Makan"
+	}
+
+	return "Setelah Makan"
+}
+
+// the lines are centered vertically in the row, the ones that don't fit are cut
+func writeEtixLines(pdf *fpdf.Fpdf, lines []string, x float64, width float64, rowHeight float64, maxLines int, align string) {
+	startY := pdf.GetY()
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+
+	if len(lines) > 0 {
+		lineHeight := rowHeight / float64(len(lines))
+
+		for _, line := range lines {
+			pdf.SetX(x)
+			pdf.CellFormat(width, lineHeight, line, "", 1, (align + "M"), false, 0, "")
+		}
+	}
+
+	pdf.SetY(startY + rowHeight)
+}
+
+// a dose with a fraction (3 x 1/2) is written with a stacked fraction
+func createEtixDose(pdf *fpdf.Fpdf, template *types.EticketTemplate, row types.EticketTemplateRow, eticket types.EticketPDFReturnPayload) {
+	rowHeight := row.CellHeight * float64(row.Lines)
+	startY := pdf.GetY()
+
+	label := row.Label
+	if label == "" {
+		label = "Sehari"
+	}
+
+	dose := strings.ToLower(eticket.Dose)
+	setUnit := strings.ToLower(eticket.SetUnit)
+
+	doseSplit := strings.Split(dose, "/")
+	aDay := strings.Split(doseSplit[0], "x")
+
+	if len(doseSplit) != 2 || len(aDay) != 2 {
+		doseTxt := fmt.Sprintf("%s %s %s", label, dose, setUnit)
+		pdf.CellFormat(0, rowHeight, doseTxt, "", 1, (row.Align + "M"), false, 0, "")
+		return
+	}
+
+	prefix := fmt.Sprintf("%s %s x ", label, strings.TrimSpace(aDay[0]))
+	numerator := strings.TrimSpace(aDay[1])
+	denominator := strings.TrimSpace(doseSplit[1])
+	suffix := " " + setUnit
+
+	prefixWidth := pdf.GetStringWidth(prefix)
+	suffixWidth := pdf.GetStringWidth(suffix)
+
+	pdf.SetFontSize(row.FontSize - 3)
+	fractionWidth := max(pdf.GetStringWidth(numerator), pdf.GetStringWidth(denominator)) + (2 * constants.ETIX_LINE_WIDTH)
+	pdf.SetFontSize(row.FontSize)
+
+	totalWidth := prefixWidth + fractionWidth + suffixWidth
+	availableWidth := template.Width - (2 * template.Margin)
+
+	x := template.Margin
+	switch row.Align {
+	case "C":
+		x += (availableWidth - totalWidth) / 2
+	case "R":
+		x += availableWidth - totalWidth
+	}
+
+	cellMargin := pdf.GetCellMargin()
+	pdf.SetCellMargin(0)
+
+	pdf.SetXY(x, startY)
+	pdf.CellFormat(prefixWidth, rowHeight, prefix, "", 0, "LM", false, 0, "")
+
+	fractionX := pdf.GetX()
+	fractionY := startY + (rowHeight / 2)
+
+	pdf.SetFontSize(row.FontSize - 3)
+	pdf.SetXY(fractionX, (fractionY - row.CellHeight/2))
+	pdf.CellFormat(fractionWidth, (row.CellHeight / 2), numerator, "", 0, "CB", false, 0, "")
+
+	pdf.SetXY(fractionX, fractionY)
+	pdf.CellFormat(fractionWidth, (row.CellHeight / 2), denominator, "", 0, "CT", false, 0, "")
+
+	pdf.Line(fractionX, fractionY, (fractionX + fractionWidth), fractionY)
+
+	pdf.SetFontSize(row.FontSize)
+	pdf.SetXY((fractionX + fractionWidth), startY)
+	pdf.CellFormat(suffixWidth, rowHeight, suffix, "", 1, "LM", false, 0, "")
+
+	pdf.SetCellMargin(cellMargin)
+	pdf.SetY(startY + rowHeight)
+}
+
+// badges are white text on black, side by side if they fit, else one per line
+func createEtixBadges(pdf *fpdf.Fpdf, template *types.EticketTemplate, row types.EticketTemplateRow, badges []string) {
+	rowHeight := row.CellHeight * float64(row.Lines)
+	startY := pdf.GetY()
+
+	pdf.SetFont(template.FontFamily, constants.BOLD, row.FontSize)
+
+	availableWidth := template.Width - (2 * template.Margin)
+	padding := constants.ETIX_BADGE_PADDING
+
+	widths := make([]float64, len(badges))
+	var totalWidth float64
+
+	for i, badge := range badges {
+		widths[i] = pdf.GetStringWidth(badge) + (2 * padding)
+		totalWidth += widths[i]
+	}
+	totalWidth += padding * float64(len(badges)-1)
+
+	pdf.SetFillColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetTextColor(constants.WHITE_R, constants.WHITE_G, constants.WHITE_B)
+
+	if totalWidth <= availableWidth {
+		badgeHeight := row.CellHeight - (2 * padding)
+
+		x := template.Margin + ((availableWidth - totalWidth) / 2)
+		y := startY + ((rowHeight - badgeHeight) / 2)
+
+		for i, badge := range badges {
+			pdf.RoundedRect(x, y, widths[i], badgeHeight, (badgeHeight / 4), "1234", "F")
+
+			pdf.SetXY(x, y)
+			pdf.CellFormat(widths[i], badgeHeight, badge, "", 0, "CM", false, 0, "")
+
+			x += widths[i] + padding
+		}
+	} else {
+		lineHeight := rowHeight / float64(len(badges))
+		badgeHeight := lineHeight - (2 * padding)
+
+		for i, badge := range badges {
+			x := template.Margin + ((availableWidth - widths[i]) / 2)
+			y := startY + (lineHeight * float64(i)) + padding
+
+			pdf.RoundedRect(x, y, widths[i], badgeHeight, (badgeHeight / 4), "1234", "F")
+
+			pdf.SetXY(x, y)
+			pdf.CellFormat(widths[i], badgeHeight, badge, "", 0, "CM", false, 0, "")
+		}
+	}
+
+	pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetXY(template.Margin, (startY + rowHeight))
+}
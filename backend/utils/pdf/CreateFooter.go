@@ -0,0 +1,23 @@
+package pdf
+
+import (
+	"github.com/go-pdf/fpdf"
+	"github.com/nicolaics/pharmacon/constants"
+)
+
+// the footer from the document setting is printed at the bottom margin of every page
+func setDocumentFooter(pdf *fpdf.Fpdf, fontFamily string, footer string) {
+	if footer == "" {
+		return
+	}
+
+	pdf.SetFooterFunc(func() {
+		_, _, _, bottomMargin := pdf.GetMargins()
+		_, pageHeight := pdf.GetPageSize()
+
+		pdf.SetY(pageHeight - bottomMargin)
+		pdf.SetFont(fontFamily, constants.REGULAR, constants.SETTING_FOOTER_FONT_SZ)
+		pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+		pdf.MultiCell(0, (bottomMargin / 2), footer, "", "C", false)
+	})
+}
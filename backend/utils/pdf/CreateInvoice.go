@@ -2,14 +2,13 @@ package pdf
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/nicolaics/pharmacon/config"
 	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 
@@ -19,22 +18,17 @@ import (
 	"golang.org/x/text/message"
 )
 
-func CreateInvoicePDF(invoice types.InvoicePDFPayload, invoiceStore types.InvoiceStore, prevFileName string) (string, error) {
-	directory, err := filepath.Abs("static/pdf/invoice/")
-	if err != nil {
-		return "", err
-	}
-
-	if err := os.MkdirAll(directory, 0744); err != nil {
-		return "", err
-	}
+func CreateInvoicePDF(invoice types.InvoicePDFPayload, invoiceStore types.InvoiceStore, prevFileName string, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_INVOICE, time.Now())
 
 	pdf, err := initInvoicePdf()
 	if err != nil {
 		return "", err
 	}
 
-	err = createInvoiceHeader(pdf, invoice)
+	setDocumentFooter(pdf, "Calibri", branding.Footer)
+
+	err = createInvoiceHeader(pdf, invoice, branding.Company)
 	if err != nil {
 		return "", err
 	}
@@ -109,7 +103,7 @@ func CreateInvoicePDF(invoice types.InvoicePDFPayload, invoiceStore types.Invoic
 	fileName := prevFileName
 
 	if prevFileName == "" {
-		fileName := "i-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+		fileName = "i-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
 		isExist, err := invoiceStore.IsPDFUrlExist(fileName)
 		if err != nil {
 			return "", err
@@ -124,7 +118,7 @@ func CreateInvoicePDF(invoice types.InvoicePDFPayload, invoiceStore types.Invoic
 		}
 	}
 
-	err = pdf.OutputFileAndClose(directory + "\\" + fileName)
+	err = savePDF(pdf, storage, constants.DOCUMENT_INVOICE, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -166,26 +160,26 @@ func initInvoicePdf() (*fpdf.Fpdf, error) {
 	return pdf, nil
 }
 
-func createInvoiceHeader(pdf *fpdf.Fpdf, invoice types.InvoicePDFPayload) error {
+func createInvoiceHeader(pdf *fpdf.Fpdf, invoice types.InvoicePDFPayload, company types.CompanySetting) error {
 	pdf.SetXY((constants.INVOICE_MARGIN + 0.1), 0.3)
 
 	pdf.SetFont("Bree", constants.BOLD, 20)
-	cellWidth := pdf.GetStringWidth(config.Envs.CompanyName) + constants.INVOICE_MARGIN
-	pdf.CellFormat(cellWidth, 0.6, config.Envs.CompanyName, "", 1, "L", false, 0, "")
+	cellWidth := pdf.GetStringWidth(company.Name) + constants.INVOICE_MARGIN
+	pdf.CellFormat(cellWidth, 0.6, company.Name, "", 1, "L", false, 0, "")
 
 	pdf.SetFont("Calibri", constants.REGULAR, constants.INVOICE_HEADER_FONT_SZ)
-	pdf.MultiCell(cellWidth, constants.INVOICE_HEADER_HEIGHT, config.Envs.CompanyAddress, "", "C", false)
+	pdf.MultiCell(cellWidth, constants.INVOICE_HEADER_HEIGHT, company.Address, "", "C", false)
 
 	pdf.SetFont("Calibri", constants.REGULAR, constants.INVOICE_HEADER_FONT_SZ)
-	phoneNumber := fmt.Sprintf("No. Telp: %s", config.Envs.CompanyPhoneNumber)
+	phoneNumber := fmt.Sprintf("No. Telp: %s", company.PhoneNumber)
 	pdf.CellFormat(cellWidth, constants.INVOICE_HEADER_HEIGHT, phoneNumber, "", 1, "C", false, 0, "")
 
 	pdf.SetFont("Calibri", constants.REGULAR, constants.INVOICE_HEADER_FONT_SZ)
-	whatsApp := fmt.Sprintf("WhatsApp: %s", config.Envs.CompanyWhatsAppNumber)
+	whatsApp := fmt.Sprintf("WhatsApp: %s", company.WhatsAppNumber)
 	pdf.CellFormat(cellWidth, constants.INVOICE_HEADER_HEIGHT, whatsApp, "", 1, "C", false, 0, "")
 
 	pdf.SetFont("Calibri", constants.REGULAR, 9)
-	pdf.MultiCell(cellWidth, 0.34, config.Envs.CompanySlogan, "", "C", false)
+	pdf.MultiCell(cellWidth, 0.34, company.Slogan, "", "C", false)
 
 	if pdf.Error() != nil {
 		return fmt.Errorf("error create invoice pdf header: %v", pdf.Error())
@@ -0,0 +1,33 @@
+package pdf
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+// the logo is read from the document storage, so it is registered from its content.
+// the document is still made without the logo if it can't be read
+func drawDocumentLogo(pdf *fpdf.Fpdf, company types.CompanySetting, width float64, height float64) {
+	if len(company.Logo) == 0 {
+		return
+	}
+
+	imageType := "png"
+	if http.DetectContentType(company.Logo) == "image/jpeg" {
+		imageType = "jpg"
+	}
+
+	options := fpdf.ImageOptions{ImageType: imageType}
+
+	pdf.RegisterImageOptionsReader(constants.DOCUMENT_LOGO, options, bytes.NewReader(company.Logo))
+	if pdf.Err() {
+		pdf.ClearError()
+		return
+	}
+
+	pdf.ImageOptions(constants.DOCUMENT_LOGO, pdf.GetX(), pdf.GetY(), width, height, false, options, 0, "")
+}
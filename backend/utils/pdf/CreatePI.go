@@ -2,14 +2,14 @@ package pdf
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"time"
 
 	"strconv"
 	"strings"
 
-	"github.com/nicolaics/pharmacon/config"
 	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 
@@ -19,22 +19,17 @@ import (
 	"golang.org/x/text/message"
 )
 
-func CreatePurchaseInvoicePDF(piStore types.PurchaseInvoiceStore, purchaseInvoice types.PurchaseInvoicePDFPayload, prevFileName string) (string, error) {
-	directory, err := filepath.Abs("static/pdf/purchase-invoice/")
-	if err != nil {
-		return "", err
-	}
-
-	if err := os.MkdirAll(directory, 0744); err != nil {
-		return "", err
-	}
+func CreatePurchaseInvoicePDF(piStore types.PurchaseInvoiceStore, purchaseInvoice types.PurchaseInvoicePDFPayload, prevFileName string, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_PURCHASE_INVOICE, time.Now())
 
 	pdf, err := initPurchaseInvoicePdf()
 	if err != nil {
 		return "", err
 	}
 
-	err = createPurchaseInvoiceHeader(pdf, purchaseInvoice)
+	setDocumentFooter(pdf, "Calibri", branding.Footer)
+
+	err = createPurchaseInvoiceHeader(pdf, purchaseInvoice, branding.Company)
 	if err != nil {
 		return "", err
 	}
@@ -135,7 +130,7 @@ func CreatePurchaseInvoicePDF(piStore types.PurchaseInvoiceStore, purchaseInvoic
 	fileName := prevFileName
 
 	if prevFileName == "" {
-		fileName := "pi-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+		fileName = "pi-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
 		isExist, err := piStore.IsPDFUrlExist(fileName)
 		if err != nil {
 			return "", err
@@ -150,7 +145,7 @@ func CreatePurchaseInvoicePDF(piStore types.PurchaseInvoiceStore, purchaseInvoic
 		}
 	}
 
-	err = pdf.OutputFileAndClose(directory + "\\" + fileName)
+	err = savePDF(pdf, storage, constants.DOCUMENT_PURCHASE_INVOICE, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -192,38 +187,38 @@ func initPurchaseInvoicePdf() (*fpdf.Fpdf, error) {
 	return pdf, nil
 }
 
-func createPurchaseInvoiceHeader(pdf *fpdf.Fpdf, purchaseInvoice types.PurchaseInvoicePDFPayload) error {
+func createPurchaseInvoiceHeader(pdf *fpdf.Fpdf, purchaseInvoice types.PurchaseInvoicePDFPayload, company types.CompanySetting) error {
 	var caser = cases.Title(language.Indonesian)
 
-	pdf.Image(config.Envs.CompanyLogoURL, pdf.GetX(), pdf.GetY(), constants.PI_LOGO_WIDTH, constants.PI_LOGO_HEIGHT, false, "", 0, "")
+	drawDocumentLogo(pdf, company, constants.PI_LOGO_WIDTH, constants.PI_LOGO_HEIGHT)
 
 	startBesideLogoX := constants.PI_MARGIN + constants.PI_LOGO_WIDTH + 0.1
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetTextColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
 	pdf.SetFont("Bree", constants.BOLD, 22)
-	cellWidth := pdf.GetStringWidth(config.Envs.CompanyName) + constants.PI_MARGIN
-	pdf.CellFormat(cellWidth, 0.65, config.Envs.CompanyName, "", 1, "L", false, 0, "")
+	cellWidth := pdf.GetStringWidth(company.Name) + constants.PI_MARGIN
+	pdf.CellFormat(cellWidth, 0.65, company.Name, "", 1, "L", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.PI_HEADER_FONT_SZ)
-	cellWidth = pdf.GetStringWidth(config.Envs.CompanyAddress) + constants.PI_MARGIN
-	pdf.CellFormat(cellWidth, constants.PI_HEADER_HEIGHT, config.Envs.CompanyAddress, "", 1, "C", false, 0, "")
+	cellWidth = pdf.GetStringWidth(company.Address) + constants.PI_MARGIN
+	pdf.CellFormat(cellWidth, constants.PI_HEADER_HEIGHT, company.Address, "", 1, "C", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.PI_HEADER_FONT_SZ)
-	phoneNumber := fmt.Sprintf("No. Telp: %s | WhatsApp: %s", config.Envs.CompanyPhoneNumber, config.Envs.CompanyWhatsAppNumber)
+	phoneNumber := fmt.Sprintf("No. Telp: %s | WhatsApp: %s", company.PhoneNumber, company.WhatsAppNumber)
 	cellWidth = pdf.GetStringWidth(phoneNumber) + constants.PI_MARGIN
 	pdf.CellFormat(cellWidth, constants.PI_HEADER_HEIGHT, phoneNumber, "", 1, "L", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.PI_HEADER_FONT_SZ)
-	cellWidth = pdf.GetStringWidth(config.Envs.BusinessRegistrationNumber) + constants.PI_MARGIN
-	pdf.CellFormat(cellWidth, constants.PI_HEADER_HEIGHT, config.Envs.BusinessRegistrationNumber, "", 1, "C", false, 0, "")
+	cellWidth = pdf.GetStringWidth(company.BusinessRegistrationNumber) + constants.PI_MARGIN
+	pdf.CellFormat(cellWidth, constants.PI_HEADER_HEIGHT, company.BusinessRegistrationNumber, "", 1, "C", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.PI_HEADER_FONT_SZ)
-	pharmacist := fmt.Sprintf("Apoteker: %s", config.Envs.Pharmacist)
+	pharmacist := fmt.Sprintf("Apoteker: %s", company.Pharmacist)
 	cellWidth = pdf.GetStringWidth(pharmacist) + constants.PI_MARGIN
 	pdf.CellFormat(cellWidth, constants.PI_HEADER_HEIGHT, pharmacist, "", 1, "C", false, 0, "")
 
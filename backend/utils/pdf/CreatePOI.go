@@ -2,14 +2,14 @@ package pdf
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"time"
 
 	"strconv"
 	"strings"
 
-	"github.com/nicolaics/pharmacon/config"
 	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 
@@ -19,22 +19,17 @@ import (
 	"golang.org/x/text/message"
 )
 
-func CreatePurchaseOrderInvoicePDF(poiStore types.PurchaseOrderStore, poi types.PurchaseOrderPDFPayload, prevFileName string) (string, error) {
-	directory, err := filepath.Abs("static/pdf/purchase-order/")
-	if err != nil {
-		return "", err
-	}
-
-	if err := os.MkdirAll(directory, 0744); err != nil {
-		return "", err
-	}
+func CreatePurchaseOrderInvoicePDF(poiStore types.PurchaseOrderStore, poi types.PurchaseOrderPDFPayload, prevFileName string, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_PURCHASE_ORDER, time.Now())
 
 	pdf, err := initPurchaseOrderInvoicePdf()
 	if err != nil {
 		return "", err
 	}
 
-	err = createPurchaseOrderInvoiceHeader(pdf, poi.Supplier)
+	setDocumentFooter(pdf, "Calibri", branding.Footer)
+
+	err = createPurchaseOrderInvoiceHeader(pdf, poi.Supplier, branding.Company)
 	if err != nil {
 		return "", err
 	}
@@ -115,7 +110,7 @@ func CreatePurchaseOrderInvoicePDF(poiStore types.PurchaseOrderStore, poi types.
 
 	pdf.SetDashPattern([]float64{}, 0)
 
-	err = createPurchaseOrderInvoiceFooter(pdf, itemCount, startTableX, startFooterY)
+	err = createPurchaseOrderInvoiceFooter(pdf, itemCount, startTableX, startFooterY, branding.Company)
 	if err != nil {
 		return "", err
 	}
@@ -123,7 +118,7 @@ func CreatePurchaseOrderInvoicePDF(poiStore types.PurchaseOrderStore, poi types.
 	fileName := prevFileName
 
 	if prevFileName == "" {
-		fileName := "poi-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+		fileName = "poi-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
 		isExist, err := poiStore.IsPDFUrlExist(fileName)
 		if err != nil {
 			return "", err
@@ -138,7 +133,7 @@ func CreatePurchaseOrderInvoicePDF(poiStore types.PurchaseOrderStore, poi types.
 		}
 	}
 
-	err = pdf.OutputFileAndClose(directory + "\\" + fileName)
+	err = savePDF(pdf, storage, constants.DOCUMENT_PURCHASE_ORDER, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -180,15 +175,15 @@ func initPurchaseOrderInvoicePdf() (*fpdf.Fpdf, error) {
 	return pdf, nil
 }
 
-func createPurchaseOrderInvoiceHeader(pdf *fpdf.Fpdf, supplier types.SupplierInformationReturnPayload) error {
+func createPurchaseOrderInvoiceHeader(pdf *fpdf.Fpdf, supplier types.SupplierInformationReturnPayload, company types.CompanySetting) error {
 	var caser = cases.Title(language.Indonesian)
 
-	pdf.Image(config.Envs.CompanyLogoURL, pdf.GetX(), pdf.GetY(), constants.POI_LOGO_WIDTH, constants.POI_LOGO_HEIGHT, false, "", 0, "")
+	drawDocumentLogo(pdf, company, constants.POI_LOGO_WIDTH, constants.POI_LOGO_HEIGHT)
 
 	startBesideLogoX := constants.POI_MARGIN + constants.POI_LOGO_WIDTH + 0.1
 
 	pdf.SetX(startBesideLogoX)
-	companyName := strings.ToUpper(config.Envs.CompanyName)
+	companyName := strings.ToUpper(company.Name)
 
 	pdf.SetTextColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
 	pdf.SetFont("Bree", constants.BOLD, 22)
@@ -197,24 +192,24 @@ func createPurchaseOrderInvoiceHeader(pdf *fpdf.Fpdf, supplier types.SupplierInf
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.POI_HEADER_FONT_SZ)
-	cellWidth = pdf.GetStringWidth(config.Envs.CompanyAddress) + constants.POI_MARGIN
-	pdf.CellFormat(cellWidth, constants.POI_HEADER_HEIGHT, config.Envs.CompanyAddress, "", 1, "C", false, 0, "")
+	cellWidth = pdf.GetStringWidth(company.Address) + constants.POI_MARGIN
+	pdf.CellFormat(cellWidth, constants.POI_HEADER_HEIGHT, company.Address, "", 1, "C", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.POI_HEADER_FONT_SZ)
-	phone := fmt.Sprintf("No. Telp: %s | WhatsApp: %s", config.Envs.CompanyPhoneNumber, config.Envs.CompanyWhatsAppNumber)
+	phone := fmt.Sprintf("No. Telp: %s | WhatsApp: %s", company.PhoneNumber, company.WhatsAppNumber)
 	cellWidth = pdf.GetStringWidth(phone) + constants.POI_MARGIN
 	pdf.CellFormat(cellWidth, constants.POI_HEADER_HEIGHT, phone, "", 1, "L", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.POI_HEADER_FONT_SZ)
-	businessRegNumber := fmt.Sprintf("No. SIA: %s", config.Envs.BusinessRegistrationNumber)
+	businessRegNumber := fmt.Sprintf("No. SIA: %s", company.BusinessRegistrationNumber)
 	cellWidth = pdf.GetStringWidth(businessRegNumber) + constants.POI_MARGIN
 	pdf.CellFormat(cellWidth, constants.POI_HEADER_HEIGHT, businessRegNumber, "", 1, "C", false, 0, "")
 
 	pdf.SetX(startBesideLogoX)
 	pdf.SetFont("Calibri", constants.REGULAR, constants.POI_HEADER_FONT_SZ)
-	pharmacist := fmt.Sprintf("Apoteker: %s", config.Envs.Pharmacist)
+	pharmacist := fmt.Sprintf("Apoteker: %s", company.Pharmacist)
 	cellWidth = pdf.GetStringWidth(pharmacist) + constants.POI_MARGIN
 	pdf.CellFormat(cellWidth, constants.POI_HEADER_HEIGHT, pharmacist, "", 1, "C", false, 0, "")
 
@@ -436,7 +431,7 @@ func createPurchaseOrderInvoiceData(pdf *fpdf.Fpdf, startTableX map[string]float
 	return (number - 1), nil
 }
 
-func createPurchaseOrderInvoiceFooter(pdf *fpdf.Fpdf, itemCount int, startTableX map[string]float64, startFooterY float64) error {
+func createPurchaseOrderInvoiceFooter(pdf *fpdf.Fpdf, itemCount int, startTableX map[string]float64, startFooterY float64, company types.CompanySetting) error {
 	pdf.SetLineWidth(0.02)
 	pdf.SetDashPattern([]float64{}, 0)
 
@@ -469,7 +464,7 @@ func createPurchaseOrderInvoiceFooter(pdf *fpdf.Fpdf, itemCount int, startTableX
 
 		pdf.SetFont("Arial", constants.REGULAR, constants.POI_STD_FONT_SZ)
 		cellWidth = startTableX["orderQty"] - constants.POI_MARGIN - 2.0
-		pdf.CellFormat(cellWidth, constants.POI_FOOTER_CELL_HEIGHT, config.Envs.Pharmacist, "", 1, "L", false, 0, "")
+		pdf.CellFormat(cellWidth, constants.POI_FOOTER_CELL_HEIGHT, company.Pharmacist, "", 1, "L", false, 0, "")
 	}
 
 	// Pharmacist License Number
@@ -484,7 +479,7 @@ func createPurchaseOrderInvoiceFooter(pdf *fpdf.Fpdf, itemCount int, startTableX
 
 		pdf.SetFont("Arial", constants.REGULAR, constants.POI_STD_FONT_SZ)
 		cellWidth = startTableX["orderQty"] - constants.POI_MARGIN - 2.0
-		pdf.CellFormat(cellWidth, constants.POI_FOOTER_CELL_HEIGHT, config.Envs.PharmacistLicenseNumber, "", 1, "L", false, 0, "")
+		pdf.CellFormat(cellWidth, constants.POI_FOOTER_CELL_HEIGHT, company.PharmacistLicenseNumber, "", 1, "L", false, 0, "")
 	}
 
 	pdf.RoundedRect(startPharmacistBoxX, startPharmacistBoxY, (startTableX["orderQty"] - constants.POI_MARGIN - 2.0), (pdf.GetY() - startPharmacistBoxY), 0.1, "1234", "D")
@@ -0,0 +1,438 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
+	"github.com/nicolaics/pharmacon/types"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+type patientHistoryCell struct {
+	width float64
+	text  string
+	align string
+}
+
+// the history of the same patient and period is overwritten
+func CreatePatientMedicationHistoryPDF(history types.PatientMedicationHistoryReturn, company *types.CompanySetting, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_PATIENT_HISTORY, time.Now())
+
+	pdf, err := initPatientMedicationHistoryPdf()
+	if err != nil {
+		return "", err
+	}
+
+	err = createPatientMedicationHistoryHeader(pdf, history, *company)
+	if err != nil {
+		return "", err
+	}
+
+	err = createPatientMedicationHistoryProfile(pdf, history)
+	if err != nil {
+		return "", err
+	}
+
+	err = createPatientMedicationHistoryData(pdf, history.Items)
+	if err != nil {
+		return "", err
+	}
+
+	err = createPatientMedicationHistorySummary(pdf, history.Medicines)
+	if err != nil {
+		return "", err
+	}
+
+	err = createPatientMedicationHistoryFooter(pdf, history, *company)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := fmt.Sprintf("medication-history-%d-%s-%s.pdf", history.Patient.ID,
+		history.StartDate.Format("20060102"), history.EndDate.Format("20060102"))
+
+	err = savePDF(pdf, storage, constants.DOCUMENT_PATIENT_HISTORY, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func initPatientMedicationHistoryPdf() (*fpdf.Fpdf, error) {
+	s, _ := filepath.Abs("static/assets/font/")
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "L",
+		UnitStr:        "cm",
+		SizeStr:        "A4",
+		Size: fpdf.SizeType{
+			Wd: constants.PATIENT_HISTORY_WIDTH,
+			Ht: constants.PATIENT_HISTORY_HEIGHT,
+		},
+		FontDirStr: s,
+	})
+
+	pdf.SetMargins(constants.PATIENT_HISTORY_MARGIN, constants.PATIENT_HISTORY_MARGIN, constants.PATIENT_HISTORY_MARGIN)
+	pdf.SetAutoPageBreak(true, constants.PATIENT_HISTORY_MARGIN)
+
+	pdf.AddUTF8Font("Arial", constants.REGULAR, "Arial.TTF")
+	pdf.AddUTF8Font("Arial", constants.BOLD, "ArialBD.TTF")
+	pdf.AddUTF8Font("Calibri", constants.REGULAR, "Calibri.TTF")
+	pdf.AddUTF8Font("Calibri", constants.BOLD, "CalibriBold.TTF")
+	pdf.AddUTF8Font("Bree", constants.BOLD, "Bree Serif Bold.ttf")
+
+	pdf.AddPage()
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("error init patient medication history pdf: %v", pdf.Error())
+	}
+
+	return pdf, nil
+}
+
+func createPatientMedicationHistoryHeader(pdf *fpdf.Fpdf, history types.PatientMedicationHistoryReturn, company types.CompanySetting) error {
+	drawDocumentLogo(pdf, company, constants.PATIENT_HISTORY_LOGO_WIDTH, constants.PATIENT_HISTORY_LOGO_HEIGHT)
+
+	startBesideLogoX := constants.PATIENT_HISTORY_MARGIN + constants.PATIENT_HISTORY_LOGO_WIDTH + 0.2
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetTextColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
+	pdf.SetFont("Bree", constants.BOLD, 18)
+	pdf.CellFormat(0, 0.65, strings.ToUpper(company.Name), "", 1, "L", false, 0, "")
+
+	pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.PATIENT_HISTORY_HEADER_FONT_SZ)
+	pdf.CellFormat(0, constants.PATIENT_HISTORY_HEADER_HEIGHT, company.Address, "", 1, "L", false, 0, "")
+
+	pdf.SetX(startBesideLogoX)
+	businessRegNumber := fmt.Sprintf("No. SIA: %s", company.BusinessRegistrationNumber)
+	pdf.CellFormat(0, constants.PATIENT_HISTORY_HEADER_HEIGHT, businessRegNumber, "", 1, "L", false, 0, "")
+
+	pdf.SetY(constants.PATIENT_HISTORY_MARGIN + constants.PATIENT_HISTORY_LOGO_HEIGHT + 0.3)
+	pdf.SetFont("Calibri", constants.BOLD, constants.PATIENT_HISTORY_TITLE_FONT_SZ)
+	pdf.CellFormat(0, 0.6, "RIWAYAT PENGOBATAN PASIEN", "", 1, "C", false, 0, "")
+
+	period := fmt.Sprintf("Periode: %s - %s", history.StartDate.Format("02-01-2006"), history.EndDate.Format("02-01-2006"))
+	pdf.SetFont("Calibri", constants.REGULAR, constants.PATIENT_HISTORY_STD_FONT_SZ)
+	pdf.CellFormat(0, constants.PATIENT_HISTORY_HEADER_HEIGHT, period, "", 1, "C", false, 0, "")
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create patient medication history header: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createPatientMedicationHistoryProfile(pdf *fpdf.Fpdf, history types.PatientMedicationHistoryReturn) error {
+	patient := history.Patient
+
+	age := fmt.Sprintf("%d tahun", history.Age)
+	if patient.BirthDate.Valid {
+		age += fmt.Sprintf(" (%s)", patient.BirthDate.Time.Format("02-01-2006"))
+	}
+
+	weight := "-"
+	if patient.Weight > 0 {
+		weight = fmt.Sprintf("%g kg", patient.Weight)
+	}
+
+	allergies := make([]string, 0)
+	for _, allergy := range history.Allergies {
+		if allergy.Reaction != "" {
+			allergies = append(allergies, fmt.Sprintf("%s (%s)", allergy.Allergen, allergy.Reaction))
+		} else {
+			allergies = append(allergies, allergy.Allergen)
+		}
+	}
+	if patient.AllergyNotes != "" {
+		allergies = append(allergies, patient.AllergyNotes)
+	}
+	if len(allergies) == 0 {
+		allergies = append(allergies, "-")
+	}
+
+	// two columns of label and value
+	profiles := [][2][2]string{
+		{{"Nama", patient.Name}, {"No. RM", getPatientHistoryText(patient.MedicalRecordNumber)}},
+		{{"Umur", age}, {"Jenis Kelamin", getPatientHistoryText(patient.Gender)}},
+		{{"No. Telp", getPatientHistoryText(patient.PhoneNumber)}, {"Berat Badan", weight}},
+		{{"Alamat", getPatientHistoryText(patient.Address)}, {"Alergi", strings.Join(allergies, ", ")}},
+	}
+
+	labelWidth := 2.5
+	valueWidth := ((constants.PATIENT_HISTORY_WIDTH - (2 * constants.PATIENT_HISTORY_MARGIN)) / 2) - labelWidth
+
+	pdf.SetY(pdf.GetY() + 0.3)
+
+	for _, profile := range profiles {
+		for _, column := range profile {
+			pdf.SetFont("Calibri", constants.BOLD, constants.PATIENT_HISTORY_STD_FONT_SZ)
+			pdf.CellFormat(labelWidth, constants.PATIENT_HISTORY_HEADER_HEIGHT, column[0], "", 0, "L", false, 0, "")
+
+			pdf.SetFont("Calibri", constants.REGULAR, constants.PATIENT_HISTORY_STD_FONT_SZ)
+			pdf.CellFormat(valueWidth, constants.PATIENT_HISTORY_HEADER_HEIGHT,
+				": "+fitPatientHistoryText(pdf, column[1], valueWidth), "", 0, "L", false, 0, "")
+		}
+
+		pdf.Ln(-1)
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create patient medication history profile: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createPatientMedicationHistoryTableHeader(pdf *fpdf.Fpdf, startTableY float64) error {
+	pdf.SetLineWidth(0.02)
+	pdf.SetY(startTableY)
+
+	headers := []struct {
+		title string
+		width float64
+	}{
+		{"No.", constants.PATIENT_HISTORY_NO_COL_WIDTH},
+		{"Tanggal", constants.PATIENT_HISTORY_DATE_COL_WIDTH},
+		{"No. Resep", constants.PATIENT_HISTORY_NUMBER_COL_WIDTH},
+		{"Dokter", constants.PATIENT_HISTORY_DOCTOR_COL_WIDTH},
+		{"Nama Obat", constants.PATIENT_HISTORY_ITEM_COL_WIDTH},
+		{"Jumlah", constants.PATIENT_HISTORY_QTY_COL_WIDTH},
+		{"Aturan Pakai", constants.PATIENT_HISTORY_SIGNA_COL_WIDTH},
+		{"Sisa Iter", constants.PATIENT_HISTORY_ITER_COL_WIDTH},
+	}
+
+	pdf.SetFont("Calibri", constants.BOLD, constants.PATIENT_HISTORY_TABLE_HEADER_FONT_SZ)
+
+	for _, header := range headers {
+		pdf.CellFormat(header.width, constants.PATIENT_HISTORY_TABLE_HEIGHT, header.title, "1", 0, "C", false, 0, "")
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create patient medication history table header: %v", pdf.Error())
+	}
+
+	pdf.Ln(-1)
+
+	return nil
+}
+
+func createPatientMedicationHistoryData(pdf *fpdf.Fpdf, items []types.PatientMedicationHistoryItem) error {
+	err := createPatientMedicationHistoryTableHeader(pdf, (pdf.GetY() + 0.3))
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		pdf.SetFont("Arial", constants.REGULAR, constants.PATIENT_HISTORY_TABLE_DATA_FONT_SZ)
+		pdf.CellFormat(0, constants.PATIENT_HISTORY_TABLE_HEIGHT, "Tidak ada obat yang diberikan pada periode ini", "1", 1, "C", false, 0, "")
+	}
+
+	for i, item := range items {
+		number := strconv.Itoa(item.PrescriptionNumber)
+		if item.IterationNumber > 0 {
+			number += fmt.Sprintf(" (iter %d)", item.IterationNumber)
+		}
+
+		signa := item.Instruction
+		if signa == "" {
+			signa = strings.TrimSpace(item.Dose + " " + item.ConsumeTime)
+		}
+		if item.Mf != "" {
+			signa = item.Mf + ", " + signa
+		}
+
+		remainingIteration := "-"
+		if item.RemainingIteration > 0 {
+			remainingIteration = fmt.Sprintf("%dx", item.RemainingIteration)
+		}
+
+		cells := []patientHistoryCell{
+			{constants.PATIENT_HISTORY_NO_COL_WIDTH, strconv.Itoa(i + 1), "C"},
+			{constants.PATIENT_HISTORY_DATE_COL_WIDTH, item.PrescriptionDate.Format("02-01-2006"), "C"},
+			{constants.PATIENT_HISTORY_NUMBER_COL_WIDTH, number, "C"},
+			{constants.PATIENT_HISTORY_DOCTOR_COL_WIDTH, item.DoctorName, "L"},
+			{constants.PATIENT_HISTORY_ITEM_COL_WIDTH, strings.ToUpper(item.MedicineName), "L"},
+			{constants.PATIENT_HISTORY_QTY_COL_WIDTH, item.QtyString + " " + item.Unit, "C"},
+			{constants.PATIENT_HISTORY_SIGNA_COL_WIDTH, signa, "L"},
+			{constants.PATIENT_HISTORY_ITER_COL_WIDTH, remainingIteration, "C"},
+		}
+
+		err := writePatientHistoryRow(pdf, cells)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create patient medication history data: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createPatientMedicationHistorySummary(pdf *fpdf.Fpdf, medicines []types.PatientMedicationSummary) error {
+	if len(medicines) == 0 {
+		return nil
+	}
+
+	var printer = message.NewPrinter(language.Indonesian)
+
+	// the title and at least one row must be on the same page
+	if (pdf.GetY() + 1.0 + (2 * constants.PATIENT_HISTORY_TABLE_HEIGHT)) > (constants.PATIENT_HISTORY_HEIGHT - constants.PATIENT_HISTORY_MARGIN) {
+		pdf.AddPage()
+	}
+
+	pdf.SetY(pdf.GetY() + 0.5)
+	pdf.SetFont("Calibri", constants.BOLD, constants.PATIENT_HISTORY_STD_FONT_SZ)
+	pdf.CellFormat(0, constants.PATIENT_HISTORY_TABLE_HEIGHT, "Ringkasan Obat", "", 1, "L", false, 0, "")
+
+	headers := []patientHistoryCell{
+		{constants.PATIENT_HISTORY_NO_COL_WIDTH, "No.", "C"},
+		{constants.PATIENT_HISTORY_ITEM_COL_WIDTH + constants.PATIENT_HISTORY_DOCTOR_COL_WIDTH, "Nama Obat", "C"},
+		{constants.PATIENT_HISTORY_QTY_COL_WIDTH, "Satuan", "C"},
+		{constants.PATIENT_HISTORY_QTY_COL_WIDTH, "Total", "C"},
+		{constants.PATIENT_HISTORY_QTY_COL_WIDTH, "Diberikan", "C"},
+		{constants.PATIENT_HISTORY_DATE_COL_WIDTH, "Pertama", "C"},
+		{constants.PATIENT_HISTORY_DATE_COL_WIDTH, "Terakhir", "C"},
+	}
+
+	pdf.SetFont("Calibri", constants.BOLD, constants.PATIENT_HISTORY_TABLE_HEADER_FONT_SZ)
+	for _, header := range headers {
+		pdf.CellFormat(header.width, constants.PATIENT_HISTORY_TABLE_HEIGHT, header.text, "1", 0, header.align, false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	for i, medicine := range medicines {
+		cells := []patientHistoryCell{
+			{headers[0].width, strconv.Itoa(i + 1), "C"},
+			{headers[1].width, strings.ToUpper(medicine.MedicineName), "L"},
+			{headers[2].width, medicine.Unit, "C"},
+			{headers[3].width, printer.Sprintf("%.1f", medicine.TotalQty), "R"},
+			{headers[4].width, fmt.Sprintf("%dx", medicine.DispenseCount), "C"},
+			{headers[5].width, medicine.FirstDate.Format("02-01-2006"), "C"},
+			{headers[6].width, medicine.LastDate.Format("02-01-2006"), "C"},
+		}
+
+		err := writePatientHistoryRow(pdf, cells)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create patient medication history summary: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createPatientMedicationHistoryFooter(pdf *fpdf.Fpdf, history types.PatientMedicationHistoryReturn, company types.CompanySetting) error {
+	// pharmacist signature needs about 3.5 cm
+	if (pdf.GetY() + 3.5) > (constants.PATIENT_HISTORY_HEIGHT - constants.PATIENT_HISTORY_MARGIN) {
+		pdf.AddPage()
+	}
+
+	startSignX := constants.PATIENT_HISTORY_WIDTH - constants.PATIENT_HISTORY_MARGIN - 7.0
+
+	pdf.SetXY(startSignX, (pdf.GetY() + 0.8))
+	pdf.SetFont("Calibri", constants.REGULAR, constants.PATIENT_HISTORY_STD_FONT_SZ)
+	pdf.CellFormat(7.0, constants.PATIENT_HISTORY_HEADER_HEIGHT, "Apoteker", "", 1, "C", false, 0, "")
+
+	pdf.SetXY(startSignX, (pdf.GetY() + 1.5))
+	pdf.SetFont("Calibri", constants.BOLD, constants.PATIENT_HISTORY_STD_FONT_SZ)
+	pdf.CellFormat(7.0, constants.PATIENT_HISTORY_HEADER_HEIGHT, company.Pharmacist, "B", 1, "C", false, 0, "")
+
+	pdf.SetX(startSignX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.PATIENT_HISTORY_STD_FONT_SZ)
+	pdf.CellFormat(7.0, constants.PATIENT_HISTORY_HEADER_HEIGHT,
+		fmt.Sprintf("No. SIPA: %s", company.PharmacistLicenseNumber), "", 1, "C", false, 0, "")
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create patient medication history footer: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+// the long text is wrapped, so the row is as high as its longest cell
+func writePatientHistoryRow(pdf *fpdf.Fpdf, cells []patientHistoryCell) error {
+	pdf.SetFont("Arial", constants.REGULAR, constants.PATIENT_HISTORY_TABLE_DATA_FONT_SZ)
+
+	lineHeight := constants.PATIENT_HISTORY_TABLE_HEIGHT * 0.8
+	cellPadding := 0.1
+
+	lines := make([][]string, len(cells))
+	maxLines := 1
+
+	for i, cell := range cells {
+		lines[i] = pdf.SplitText(cell.text, (cell.width - (2 * cellPadding)))
+		if len(lines[i]) == 0 {
+			lines[i] = []string{""}
+		}
+
+		maxLines = max(maxLines, len(lines[i]))
+	}
+
+	rowHeight := max(constants.PATIENT_HISTORY_TABLE_HEIGHT, (float64(maxLines)*lineHeight)+cellPadding)
+
+	if (pdf.GetY() + rowHeight) > (constants.PATIENT_HISTORY_HEIGHT - constants.PATIENT_HISTORY_MARGIN) {
+		pdf.AddPage()
+	}
+
+	startX := pdf.GetX()
+	startY := pdf.GetY()
+
+	x := startX
+	for i, cell := range cells {
+		pdf.Rect(x, startY, cell.width, rowHeight, "D")
+
+		// center the text vertically
+		y := startY + ((rowHeight - (float64(len(lines[i])) * lineHeight)) / 2)
+
+		for _, line := range lines[i] {
+			pdf.SetXY((x + cellPadding), y)
+			pdf.CellFormat((cell.width - (2 * cellPadding)), lineHeight, line, "", 0, cell.align, false, 0, "")
+			y += lineHeight
+		}
+
+		x += cell.width
+	}
+
+	pdf.SetXY(startX, (startY + rowHeight))
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error write patient medication history row: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+// only the first line is shown in the profile
+func fitPatientHistoryText(pdf *fpdf.Fpdf, text string, width float64) string {
+	lines := pdf.SplitText(text, (width - 0.5))
+	if len(lines) <= 1 {
+		return text
+	}
+
+	return strings.TrimSpace(lines[0]) + "..."
+}
+
+func getPatientHistoryText(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "-"
+	}
+
+	return text
+}
@@ -2,14 +2,14 @@ package pdf
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/nicolaics/pharmacon/config"
 	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
 	"github.com/nicolaics/pharmacon/types"
 	"github.com/nicolaics/pharmacon/utils"
 	"golang.org/x/text/cases"
@@ -18,22 +18,17 @@ import (
 	"github.com/go-pdf/fpdf"
 )
 
-func CreatePrescriptionPDF(presc types.PrescriptionPDFReturn, prescStore types.PrescriptionStore, prevFileName string) (string, error) {
-	directory, err := filepath.Abs("static/pdf/prescription/")
-	if err != nil {
-		return "", err
-	}
-
-	if err := os.MkdirAll(directory, 0744); err != nil {
-		return "", err
-	}
+func CreatePrescriptionPDF(presc types.PrescriptionPDFReturn, prescStore types.PrescriptionStore, prevFileName string, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_PRESCRIPTION, time.Now())
 
 	pdf, err := initPrescriptionPdf()
 	if err != nil {
 		return "", err
 	}
 
-	err = createPrescriptionHeader(pdf, presc.Doctor.Name)
+	setDocumentFooter(pdf, "Calibri", branding.Footer)
+
+	err = createPrescriptionHeader(pdf, presc.Doctor, branding.Company)
 	if err != nil {
 		return "", err
 	}
@@ -68,7 +63,7 @@ func CreatePrescriptionPDF(presc types.PrescriptionPDFReturn, prescStore types.P
 		}
 	}
 
-	err = createPrescriptionData(pdf, presc.MedicineSets)
+	err = createPrescriptionData(pdf, presc.MedicineSets, nil)
 	if err != nil {
 		return "", err
 	}
@@ -100,7 +95,7 @@ func CreatePrescriptionPDF(presc types.PrescriptionPDFReturn, prescStore types.P
 		}
 	}
 
-	err = pdf.OutputFileAndClose(directory + "\\" + fileName)
+	err = savePDF(pdf, storage, constants.DOCUMENT_PRESCRIPTION, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -149,40 +144,57 @@ func initPrescriptionPdf() (*fpdf.Fpdf, error) {
 	return pdf, nil
 }
 
-func createPrescriptionHeader(pdf *fpdf.Fpdf, doctor string) error {
+func createPrescriptionHeader(pdf *fpdf.Fpdf, doctor types.Doctor, company types.CompanySetting) error {
 	pdf.SetXY((constants.PRESC_MARGIN + 0.1), 0.3)
 
-	pdf.Image(config.Envs.CompanyLogoURL, pdf.GetX(), pdf.GetY(), constants.PRESC_LOGO_WIDTH, constants.PRESC_LOGO_HEIGHT, false, "", 0, "")
+	drawDocumentLogo(pdf, company, constants.PRESC_LOGO_WIDTH, constants.PRESC_LOGO_HEIGHT)
 
 	pdf.SetTextColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
 	pdf.SetDrawColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
 
 	pdf.SetXY(1.7, 0.4)
 	pdf.SetFont("Bree", constants.BOLD, 16)
-	pdf.CellFormat(4, constants.PRESC_STD_CELL_HEIGHT, config.Envs.CompanyName, "", 0, "L", false, 0, "")
+	pdf.CellFormat(4, constants.PRESC_STD_CELL_HEIGHT, company.Name, "", 0, "L", false, 0, "")
 
 	pdf.SetFont("Deco", constants.REGULAR, 10)
 	pdf.CellFormat(0, constants.PRESC_STD_CELL_HEIGHT, "(Berdiri sejak 1999)", "", 1, "L", false, 0, "")
 
 	pdf.SetX(1.7)
 	pdf.SetFont("Calibri", constants.REGULAR, 10)
-	pdf.CellFormat(0, constants.PRESC_STD_CELL_HEIGHT, config.Envs.CompanyAddress, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, constants.PRESC_STD_CELL_HEIGHT, company.Address, "", 1, "L", false, 0, "")
 
 	pdf.SetXY(1.7, (pdf.GetY() - 0.05))
 	pdf.SetFont("Calibri", constants.REGULAR, 10)
-	phoneNumber := fmt.Sprintf("No. Telp : %s | WhatsApp : %s", config.Envs.CompanyPhoneNumber, config.Envs.CompanyWhatsAppNumber)
+	phoneNumber := fmt.Sprintf("No. Telp : %s | WhatsApp : %s", company.PhoneNumber, company.WhatsAppNumber)
 	pdf.CellFormat(0, constants.PRESC_STD_CELL_HEIGHT, phoneNumber, "", 1, "L", false, 0, "")
 
 	pdf.SetY(pdf.GetY() + 0.1)
 	pdf.SetFont("Calibri", constants.REGULAR, 6)
-	pharmacist := fmt.Sprintf("Apoteker : %s No. SIPA : %s", config.Envs.Pharmacist, config.Envs.PharmacistLicenseNumber)
+	pharmacist := fmt.Sprintf("Apoteker : %s No. SIPA : %s", company.Pharmacist, company.PharmacistLicenseNumber)
 	pdf.CellFormat(0, 0.25, pharmacist, "", 1, "C", false, 0, "")
 
-	if doctor == constants.MAIN_DOCTOR {
+	// prescribing doctor details, only for the doctor with license number
+	if doctor.LicenseNumber != "" {
 		pdf.SetY(pdf.GetY() + 0.05)
 		pdf.SetFont("Aller", constants.BOLD, 7)
-		doctorText := fmt.Sprintf("%s   No. STR : %s", config.Envs.MainDoctor, config.Envs.MainDoctorLicenseNumber)
+		doctorText := strings.ToUpper(doctor.Name)
+		if doctor.Specialty != "" {
+			doctorText += ", " + doctor.Specialty
+		}
+		doctorText += fmt.Sprintf("   No. SIP : %s", doctor.LicenseNumber)
 		pdf.CellFormat(0, 0.3, doctorText, "", 1, "C", false, 0, "")
+
+		practice := make([]string, 0)
+		for _, val := range []string{doctor.Clinic, doctor.Address, doctor.PhoneNumber} {
+			if val != "" {
+				practice = append(practice, val)
+			}
+		}
+
+		if len(practice) > 0 {
+			pdf.SetFont("Calibri", constants.REGULAR, 6)
+			pdf.CellFormat(0, 0.25, strings.Join(practice, " | "), "", 1, "C", false, 0, "")
+		}
 	}
 
 	pdf.SetXY(8.2, (pdf.GetY() - 0.05))
@@ -293,7 +305,9 @@ func createPrescriptionInfo(pdf *fpdf.Fpdf, presc types.PrescriptionPDFReturn) e
 	return nil
 }
 
-func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSetItemReturn) error {
+// annotations are the det, nedet and iter of each medicine in the prescription copy,
+// nil prints the det of the set instead
+func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSetItemReturn, annotations [][]string) error {
 	var caser = cases.Title(language.Indonesian)
 
 	pdf.SetLineWidth(0.02)
@@ -310,7 +324,7 @@ func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSet
 	_, pageBreakTrigger := pdf.GetAutoPageBreak()
 	pageBottomMargin := constants.PRESC_HEIGHT - pageBreakTrigger
 
-	for _, medicineSet := range medicineSets {
+	for setIdx, medicineSet := range medicineSets {
 		pdf.SetX(0.7)
 
 		// add (2 + margin) for safety margin
@@ -333,9 +347,14 @@ func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSet
 		cellWidth := pdf.GetStringWidth("R|") + constants.PRESC_MARGIN
 		pdf.CellFormat(cellWidth, constants.PRESC_STD_CELL_HEIGHT, "R|", "", 0, "L", false, 0, "")
 
-		for _, medicine := range medicineSet.MedicineItems {
+		for medIdx, medicine := range medicineSet.MedicineItems {
 			pdf.SetXY(startMedicineX, pdf.GetY()+0.1)
 
+			annotation := ""
+			if annotations != nil {
+				annotation = annotations[setIdx][medIdx]
+			}
+
 			medicine.MedicineName = caser.String(medicine.MedicineName)
 			nameSplit := nameRegex.FindAllStringIndex(medicine.MedicineName, -1)
 
@@ -374,6 +393,10 @@ func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSet
 			}
 
 			if medicine.QtyString == "" && medicine.Unit == "" {
+				if annotation != "" {
+					createPrescriptionAnnotation(pdf, annotation)
+				}
+
 				pdf.Ln(-1)
 			} else {
 				fractionIdx := strings.Index(medicine.QtyString, "/")
@@ -405,7 +428,14 @@ func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSet
 				pdf.SetX(pdf.GetX() + 0.1)
 				pdf.SetFont(constants.PRESC_MED_QTY_UNIT_FONT, constants.REGULAR, constants.PRESC_MED_QTY_UNIT_FONT_SZ)
 				cellWidth = pdf.GetStringWidth(medicine.Unit)
-				pdf.CellFormat(cellWidth, constants.PRESC_STD_CELL_HEIGHT, strings.ToLower(medicine.Unit), "", 1, "L", false, 0, "")
+
+				if annotation != "" {
+					pdf.CellFormat(cellWidth, constants.PRESC_STD_CELL_HEIGHT, strings.ToLower(medicine.Unit), "", 0, "L", false, 0, "")
+					createPrescriptionAnnotation(pdf, annotation)
+					pdf.Ln(-1)
+				} else {
+					pdf.CellFormat(cellWidth, constants.PRESC_STD_CELL_HEIGHT, strings.ToLower(medicine.Unit), "", 1, "L", false, 0, "")
+				}
 			}
 
 			pdf.SetX(startMedicineX)
@@ -463,7 +493,10 @@ func createPrescriptionData(pdf *fpdf.Fpdf, medicineSets []types.PrescriptionSet
 		pdf.SetX(constants.PRESC_WIDTH - 2.05)
 		pdf.SetFont(constants.PRESC_DET_FONT, constants.REGULAR, constants.PRESC_DET_FONT_SZ)
 		cellWidth = pdf.GetStringWidth(strings.ToLower(medicineSet.Det)) + constants.PRESC_MARGIN
-		if strings.ToLower(medicineSet.Det) == "nedet" {
+		if annotations != nil {
+			// the det is already written per medicine
+			pdf.Ln(constants.PRESC_STD_CELL_HEIGHT)
+		} else if strings.ToLower(medicineSet.Det) == "nedet" {
 			pdf.CellFormat(cellWidth, constants.PRESC_STD_CELL_HEIGHT, strings.ToLower(medicineSet.Det), "", 1, "L", false, 0, "")
 		} else {
 			det := fmt.Sprintf("det: %s", strings.ToLower(medicineSet.Det))
@@ -509,6 +542,20 @@ func createPrescriptionFooter(pdf *fpdf.Fpdf) error {
 	return nil
 }
 
+// written on the right side of the medicine row
+func createPrescriptionAnnotation(pdf *fpdf.Fpdf, annotation string) {
+	pdf.SetFont(constants.PRESC_DET_FONT, constants.REGULAR, constants.PRESC_COPY_ANNOTATION_FONT_SZ)
+
+	cellWidth := pdf.GetStringWidth(annotation) + constants.PRESC_MARGIN
+	startX := constants.PRESC_WIDTH - constants.PRESC_MARGIN - cellWidth
+	if startX < pdf.GetX() {
+		startX = pdf.GetX() + 0.1
+	}
+
+	pdf.SetX(startX)
+	pdf.CellFormat(cellWidth, constants.PRESC_STD_CELL_HEIGHT, annotation, "", 0, "R", false, 0, "")
+}
+
 func removeMedicineFromList(slice []types.PrescriptionMedicineItemReturn, s int) []types.PrescriptionMedicineItemReturn {
 	return append(slice[:s], slice[s+1:]...)
 }
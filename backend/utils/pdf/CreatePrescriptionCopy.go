@@ -0,0 +1,125 @@
+package pdf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// the copy is made again on every print, so the file name is always the same
+func CreatePrescriptionCopyPDF(presc types.PrescriptionCopyPDFReturn, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_PRESCRIPTION_COPY, time.Now())
+
+	pdf, err := initPrescriptionPdf()
+	if err != nil {
+		return "", err
+	}
+
+	setDocumentFooter(pdf, "Calibri", branding.Footer)
+
+	err = createPrescriptionHeader(pdf, presc.Doctor, branding.Company)
+	if err != nil {
+		return "", err
+	}
+
+	err = createPrescriptionInfo(pdf, types.PrescriptionPDFReturn{
+		Number:  presc.Number,
+		Date:    presc.Date,
+		Patient: presc.Patient,
+		Doctor:  presc.Doctor,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// the copy shows the qty written by the doctor
+	annotations := make([][]string, len(presc.MedicineSets))
+	for setIdx, setItem := range presc.MedicineSets {
+		annotations[setIdx] = make([]string, len(setItem.MedicineItems))
+
+		for medIdx, medicine := range setItem.MedicineItems {
+			prescribedQty := medicine.PrescribedQty
+			if prescribedQty <= 0 {
+				prescribedQty = medicine.QtyFloat
+			}
+
+			dispensedQty := utils.GetDispensedQty(presc.DispensedQtys, medicine.MedicineBarcode, medicine.Unit)
+
+			presc.MedicineSets[setIdx].MedicineItems[medIdx].QtyString = utils.FormatPrescriptionQty(prescribedQty)
+			annotations[setIdx][medIdx] = utils.GetPrescriptionCopyAnnotation(prescribedQty, medicine.Iteration, dispensedQty)
+		}
+	}
+
+	err = createPrescriptionData(pdf, presc.MedicineSets, annotations)
+	if err != nil {
+		return "", err
+	}
+
+	err = createPrescriptionCopyFooter(pdf, presc, branding.Company)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := fmt.Sprintf("pc-%d.pdf", presc.PrescriptionID)
+
+	err = savePDF(pdf, storage, constants.DOCUMENT_PRESCRIPTION_COPY, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+// pharmacist signature block, only on the last page
+func createPrescriptionCopyFooter(pdf *fpdf.Fpdf, presc types.PrescriptionCopyPDFReturn, company types.CompanySetting) error {
+	if pdf.GetY() > constants.PRESC_COPY_SIGNATURE_Y {
+		pdf.AddPage()
+	}
+
+	pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetDrawColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetLineWidth(0.02)
+
+	pdf.SetXY(constants.PRESC_MARGIN, constants.PRESC_COPY_SIGNATURE_Y)
+	pdf.SetFont("Calibri", constants.REGULAR, 9)
+	if presc.IterationNumber > 0 {
+		pdf.CellFormat(4, constants.PRESC_STD_CELL_HEIGHT, fmt.Sprintf("Pengambilan ke-%d", presc.IterationNumber+1), "", 0, "L", false, 0, "")
+	}
+
+	signatureWidth := constants.PRESC_WIDTH - constants.PRESC_COPY_SIGNATURE_X - constants.PRESC_MARGIN
+
+	pdf.SetXY(constants.PRESC_COPY_SIGNATURE_X, constants.PRESC_COPY_SIGNATURE_Y)
+	copyDate := fmt.Sprintf("%d %s %d", presc.CopyDate.Day(), indonesianMonths[presc.CopyDate.Month()-1], presc.CopyDate.Year())
+	pdf.CellFormat(signatureWidth, constants.PRESC_STD_CELL_HEIGHT, copyDate, "", 1, "C", false, 0, "")
+
+	pdf.SetX(constants.PRESC_COPY_SIGNATURE_X)
+	pdf.SetFont("Calibri", constants.BOLD, 9)
+	pdf.CellFormat(signatureWidth, constants.PRESC_STD_CELL_HEIGHT, "p.c.c.", "", 1, "C", false, 0, "")
+
+	pdf.SetX(constants.PRESC_COPY_SIGNATURE_X)
+	pdf.SetFont("Calibri", constants.REGULAR, 9)
+	pdf.CellFormat(signatureWidth, constants.PRESC_STD_CELL_HEIGHT, "Apoteker", "", 1, "C", false, 0, "")
+
+	// space for the signature and the stamp
+	pdf.SetY(pdf.GetY() + 1.5)
+
+	pdf.SetX(constants.PRESC_COPY_SIGNATURE_X)
+	pdf.SetFont("Calibri", constants.BOLD, 9)
+	pdf.CellFormat(signatureWidth, constants.PRESC_STD_CELL_HEIGHT, company.Pharmacist, "B", 1, "C", false, 0, "")
+
+	pdf.SetX(constants.PRESC_COPY_SIGNATURE_X)
+	pdf.SetFont("Calibri", constants.REGULAR, 8)
+	pdf.CellFormat(signatureWidth, constants.PRESC_STD_CELL_HEIGHT, fmt.Sprintf("No. SIPA : %s", company.PharmacistLicenseNumber), "", 1, "C", false, 0, "")
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create presc copy footer: %v", pdf.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,533 @@
+package pdf
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"strconv"
+	"strings"
+
+	"github.com/nicolaics/pharmacon/constants"
+	"github.com/nicolaics/pharmacon/metrics"
+	"github.com/nicolaics/pharmacon/types"
+	"github.com/nicolaics/pharmacon/utils"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func CreateStockTransferPDF(stockTransferStore types.StockTransferStore, stockTransfer types.StockTransferPDFPayload, prevFileName string, branding *types.DocumentBranding, storage types.DocumentStorage) (string, error) {
+	defer metrics.ObservePDFDuration(constants.DOCUMENT_STOCK_TRANSFER, time.Now())
+
+	pdf, err := initStockTransferPdf()
+	if err != nil {
+		return "", err
+	}
+
+	setDocumentFooter(pdf, "Calibri", branding.Footer)
+
+	err = createStockTransferHeader(pdf, stockTransfer.FromBranch, stockTransfer.ToBranch, branding.Company)
+	if err != nil {
+		return "", err
+	}
+
+	pdf.SetLineWidth(0.02)
+	pdf.SetDashPattern([]float64{0.1, 0.1}, 0)
+	pdf.SetY(pdf.GetY() + 0.15)
+	pdf.Line(constants.TRANSFER_MARGIN, pdf.GetY(), (constants.TRANSFER_WIDTH - constants.TRANSFER_MARGIN), pdf.GetY())
+
+	pdf.SetDashPattern([]float64{}, 0)
+
+	pdf.SetY(pdf.GetY() + 0.2)
+
+	err = createStockTransferInfo(pdf, stockTransfer)
+	if err != nil {
+		return "", err
+	}
+
+	startTableY := pdf.GetY() + 0.5
+
+	startTableX, err := createStockTransferTableHeader(pdf, startTableY)
+	if err != nil {
+		return "", err
+	}
+
+	itemCount, err := createStockTransferData(pdf, startTableX, stockTransfer.MedicineLists)
+	if err != nil {
+		return "", err
+	}
+
+	startFooterY := 10.0
+
+	if pdf.GetY() > startFooterY {
+		pdf.AddPage()
+	}
+
+	pdf.SetDrawColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetLineWidth(0.02)
+
+	columns := []string{"number", "item", "batch", "requestQty", "dispatchQty", "receivedQty", "unit", "end"}
+
+	if pdf.PageCount() > 1 {
+		pdf.SetPage(1)
+
+		for _, column := range columns {
+			pdf.Line(startTableX[column], startTableY, startTableX[column], (constants.TRANSFER_HEIGHT - constants.TRANSFER_MARGIN))
+		}
+
+		for i := 1; i < (pdf.PageCount() - 1); i++ {
+			pdf.SetPage(i + 1)
+
+			for _, column := range columns {
+				pdf.Line(startTableX[column], 0.5, startTableX[column], (constants.TRANSFER_HEIGHT - constants.TRANSFER_MARGIN))
+			}
+		}
+
+		pdf.SetPage(pdf.PageCount())
+
+		for _, column := range columns {
+			pdf.Line(startTableX[column], 0.5, startTableX[column], (startFooterY - 0.3))
+		}
+	} else {
+		for _, column := range columns {
+			pdf.Line(startTableX[column], startTableY, startTableX[column], (startFooterY - 0.3))
+		}
+	}
+
+	pdf.Line(startTableX["number"], (startFooterY - 0.3), startTableX["end"], (startFooterY - 0.3))
+
+	pdf.SetDashPattern([]float64{}, 0)
+
+	err = createStockTransferFooter(pdf, itemCount, stockTransfer, startTableX, startFooterY, branding.Company)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := prevFileName
+
+	if prevFileName == "" {
+		fileName = "transfer-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+		isExist, err := stockTransferStore.IsPDFUrlExist(fileName)
+		if err != nil {
+			return "", err
+		}
+
+		for isExist {
+			fileName = "transfer-" + utils.GenerateRandomCodeAlphanumeric(8) + "-" + utils.GenerateRandomCodeAlphanumeric(8) + ".pdf"
+			isExist, err = stockTransferStore.IsPDFUrlExist(fileName)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	err = savePDF(pdf, storage, constants.DOCUMENT_STOCK_TRANSFER, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	return fileName, nil
+}
+
+func initStockTransferPdf() (*fpdf.Fpdf, error) {
+	s, _ := filepath.Abs("static/assets/font/")
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "cm",
+		SizeStr:        "14x21",
+		Size: fpdf.SizeType{
+			Wd: constants.TRANSFER_WIDTH,
+			Ht: constants.TRANSFER_HEIGHT,
+		},
+		FontDirStr: s,
+	})
+
+	pdf.SetMargins(0.2, 0.3, 0.2)
+	pdf.SetAutoPageBreak(true, constants.TRANSFER_MARGIN)
+
+	pdf.AddUTF8Font("Arial", constants.REGULAR, "Arial.TTF")
+	pdf.AddUTF8Font("Arial", constants.BOLD, "ArialBD.TTF")
+	pdf.AddUTF8Font("Arial", constants.ITALIC, "ArialI.TTF")
+	pdf.AddUTF8Font("Calibri", constants.REGULAR, "Calibri.TTF")
+	pdf.AddUTF8Font("Calibri", constants.BOLD, "CalibriBold.TTF")
+	pdf.AddUTF8Font("Bree", constants.REGULAR, "bree-serif-regular.ttf")
+	pdf.AddUTF8Font("Bree", constants.BOLD, "Bree Serif Bold.ttf")
+
+	pdf.AddPage()
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("error init stock transfer pdf: %v", pdf.Error())
+	}
+
+	return pdf, nil
+}
+
+func createStockTransferHeader(pdf *fpdf.Fpdf, fromBranch types.Branch, toBranch types.Branch, company types.CompanySetting) error {
+	drawDocumentLogo(pdf, company, constants.TRANSFER_LOGO_WIDTH, constants.TRANSFER_LOGO_HEIGHT)
+
+	startBesideLogoX := constants.TRANSFER_MARGIN + constants.TRANSFER_LOGO_WIDTH + 0.1
+
+	pdf.SetX(startBesideLogoX)
+	companyName := strings.ToUpper(company.Name)
+
+	pdf.SetTextColor(constants.GREEN_R, constants.GREEN_G, constants.GREEN_B)
+	pdf.SetFont("Bree", constants.BOLD, 22)
+	cellWidth := pdf.GetStringWidth(companyName) + constants.TRANSFER_MARGIN
+	pdf.CellFormat(cellWidth, 0.65, companyName, "", 1, "L", false, 0, "")
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.TRANSFER_HEADER_FONT_SZ)
+	cellWidth = pdf.GetStringWidth(company.Address) + constants.TRANSFER_MARGIN
+	pdf.CellFormat(cellWidth, constants.TRANSFER_HEADER_HEIGHT, company.Address, "", 1, "C", false, 0, "")
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.TRANSFER_HEADER_FONT_SZ)
+	phone := fmt.Sprintf("No. Telp: %s | WhatsApp: %s", company.PhoneNumber, company.WhatsAppNumber)
+	cellWidth = pdf.GetStringWidth(phone) + constants.TRANSFER_MARGIN
+	pdf.CellFormat(cellWidth, constants.TRANSFER_HEADER_HEIGHT, phone, "", 1, "L", false, 0, "")
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.TRANSFER_HEADER_FONT_SZ)
+	businessRegNumber := fmt.Sprintf("No. SIA: %s", company.BusinessRegistrationNumber)
+	cellWidth = pdf.GetStringWidth(businessRegNumber) + constants.TRANSFER_MARGIN
+	pdf.CellFormat(cellWidth, constants.TRANSFER_HEADER_HEIGHT, businessRegNumber, "", 1, "C", false, 0, "")
+
+	pdf.SetX(startBesideLogoX)
+	pdf.SetFont("Calibri", constants.REGULAR, constants.TRANSFER_HEADER_FONT_SZ)
+	pharmacist := fmt.Sprintf("Apoteker: %s", company.Pharmacist)
+	cellWidth = pdf.GetStringWidth(pharmacist) + constants.TRANSFER_MARGIN
+	pdf.CellFormat(cellWidth, constants.TRANSFER_HEADER_HEIGHT, pharmacist, "", 1, "C", false, 0, "")
+
+	endCompanyY := pdf.GetY()
+
+	pdf.SetXY((constants.TRANSFER_WIDTH / 2), 0.3)
+	pdf.SetTextColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.SetFont("Calibri", constants.BOLD, 20)
+	pdf.CellFormat(0, 0.65, "Transfer Stok", "", 1, "C", false, 0, "")
+
+	startBranchX := ((constants.TRANSFER_WIDTH / 2) - 0.5)
+	startBranchY := (pdf.GetY() + 0.1)
+
+	pdf.SetXY(startBranchX, startBranchY)
+
+	branches := []struct {
+		title  string
+		branch types.Branch
+	}{
+		{"Dari", fromBranch},
+		{"Ke", toBranch},
+	}
+
+	for _, branch := range branches {
+		pdf.SetX(startBranchX)
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_BRANCH_FONT_SZ)
+		cellWidth = pdf.GetStringWidth("Dari") + 0.1
+		pdf.CellFormat(cellWidth, constants.TRANSFER_STD_CELL_HEIGHT, branch.title, "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_BRANCH_FONT_SZ)
+		cellWidth = pdf.GetStringWidth(":") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_STD_CELL_HEIGHT, ":", "", 0, "L", false, 0, "")
+
+		startBranchDataX := pdf.GetX()
+
+		// uppercase the branch name
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_BRANCH_FONT_SZ)
+		branchData := fmt.Sprintf("%s - %s | T. %s", branch.branch.Code, strings.ToUpper(branch.branch.Name), branch.branch.PhoneNumber)
+		pdf.CellFormat(0, constants.TRANSFER_STD_CELL_HEIGHT, branchData, "", 1, "L", false, 0, "")
+
+		// Address
+		pdf.SetX(startBranchDataX)
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_BRANCH_FONT_SZ)
+		pdf.CellFormat(0, constants.TRANSFER_STD_CELL_HEIGHT, branch.branch.Address, "", 1, "L", false, 0, "")
+	}
+
+	pdf.SetLineWidth(0.02)
+	pdf.SetDrawColor(constants.BLACK_R, constants.BLACK_G, constants.BLACK_B)
+	pdf.RoundedRect(startBranchX, startBranchY, (constants.TRANSFER_WIDTH - constants.TRANSFER_MARGIN - startBranchX), (pdf.GetY() - startBranchY), 0.1, "1234", "D")
+
+	if pdf.GetY() < endCompanyY {
+		pdf.SetY(endCompanyY)
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create stock transfer pdf header: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createStockTransferInfo(pdf *fpdf.Fpdf, stockTransfer types.StockTransferPDFPayload) error {
+	var caser = cases.Title(language.Indonesian)
+
+	space := 0.5
+
+	// Transfer Number
+	{
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth("No.:") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_INFO_HEIGHT, "No.:", "LTB", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_INFO_NUMBER_WIDTH, constants.TRANSFER_INFO_HEIGHT, strconv.Itoa(stockTransfer.Number), "RTB", 0, "L", false, 0, "")
+	}
+
+	pdf.SetX(pdf.GetX() + space)
+
+	// Request Date
+	{
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth("Tgl. Minta: ") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_INFO_HEIGHT, "Tgl. Minta: ", "LTB", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_INFO_DATE_WIDTH, constants.TRANSFER_INFO_HEIGHT, stockTransfer.RequestDate.Format("02-01-2006"), "RTB", 0, "L", false, 0, "")
+	}
+
+	pdf.SetX(pdf.GetX() + space)
+
+	// Status
+	{
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth("Status: ") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_INFO_HEIGHT, "Status: ", "LTB", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_INFO_STATUS_WIDTH, constants.TRANSFER_INFO_HEIGHT, stockTransfer.Status, "RTB", 0, "L", false, 0, "")
+	}
+
+	pdf.SetX(pdf.GetX() + space)
+
+	// Requested By
+	{
+		requestedBy := caser.String(stockTransfer.RequestedByUserName)
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth("Diminta Oleh: ") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_INFO_HEIGHT, "Diminta Oleh: ", "LTB", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_INFO_USER_WIDTH, constants.TRANSFER_INFO_HEIGHT, requestedBy, "RTB", 1, "L", false, 0, "")
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create stock transfer info: %v", pdf.Error())
+	}
+
+	return nil
+}
+
+func createStockTransferTableHeader(pdf *fpdf.Fpdf, startTableY float64) (map[string]float64, error) {
+	pdf.SetLineWidth(0.02)
+
+	pdf.SetY(startTableY)
+
+	headers := []struct {
+		key   string
+		title string
+		width float64
+	}{
+		{"number", "No.", constants.TRANSFER_NO_COL_WIDTH},
+		{"item", "Item", constants.TRANSFER_ITEM_COL_WIDTH},
+		{"batch", "Batch No.", constants.TRANSFER_BATCH_COL_WIDTH},
+		{"requestQty", "Request Qty", constants.TRANSFER_QTY_COL_WIDTH},
+		{"dispatchQty", "Dispatch Qty", constants.TRANSFER_QTY_COL_WIDTH},
+		{"receivedQty", "Received Qty", constants.TRANSFER_QTY_COL_WIDTH},
+		{"unit", "Unit", constants.TRANSFER_UNIT_COL_WIDTH},
+	}
+
+	startX := make(map[string]float64)
+
+	pdf.SetFont("Calibri", constants.REGULAR, constants.TRANSFER_TABLE_HEADER_FONT_SZ)
+
+	for _, header := range headers {
+		startX[header.key] = pdf.GetX()
+		pdf.CellFormat(header.width, constants.TRANSFER_TABLE_HEIGHT, header.title, "TB", 0, "C", false, 0, "")
+	}
+
+	startX["end"] = pdf.GetX()
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("error create stock transfer table header: %v", pdf.Error())
+	}
+
+	pdf.Ln(-1)
+
+	return startX, nil
+}
+
+func createStockTransferData(pdf *fpdf.Fpdf, startTableX map[string]float64, medicineLists []types.StockTransferItemReturn) (int, error) {
+	var printer = message.NewPrinter(language.Indonesian)
+
+	pdf.SetLineWidth(0.02)
+	pdf.SetY(pdf.GetY() + 0.05)
+
+	number := 1
+	nextY := pdf.GetY()
+
+	for _, medicine := range medicineLists {
+		if (pdf.GetY() + (constants.TRANSFER_TABLE_HEIGHT * 2)) > (constants.TRANSFER_HEIGHT - constants.TRANSFER_MARGIN) {
+			pdf.AddPage()
+
+			// change top margin into 0.5
+			nextY = 0.5
+		}
+		startY := nextY
+
+		pdf.SetXY(pdf.GetX(), startY)
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_TABLE_DATA_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_NO_COL_WIDTH, constants.TRANSFER_TABLE_HEIGHT, strconv.Itoa(number), "", 0, "C", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_TABLE_DATA_FONT_SZ)
+		pdf.MultiCell(constants.TRANSFER_ITEM_COL_WIDTH, constants.TRANSFER_TABLE_HEIGHT, strings.ToUpper(medicine.MedicineName), "", "L", false)
+
+		// the discrepancy is written under the item
+		if medicine.DiscrepancyQty != 0 || medicine.DiscrepancyNote != "" {
+			discrepancy := printer.Sprintf("Selisih: %.1f", medicine.DiscrepancyQty)
+			if medicine.DiscrepancyNote != "" {
+				discrepancy += fmt.Sprintf(" (%s)", medicine.DiscrepancyNote)
+			}
+
+			pdf.SetX(startTableX["item"])
+			pdf.SetFont("Arial", constants.ITALIC, (constants.TRANSFER_TABLE_DATA_FONT_SZ - 2))
+			pdf.MultiCell(constants.TRANSFER_ITEM_COL_WIDTH, constants.TRANSFER_STD_CELL_HEIGHT, discrepancy, "", "L", false)
+		}
+
+		nextY = pdf.GetY()
+
+		pdf.SetXY(startTableX["batch"], startY)
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_TABLE_DATA_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_BATCH_COL_WIDTH, constants.TRANSFER_TABLE_HEIGHT, strings.ToUpper(medicine.BatchNumber), "", 0, "C", false, 0, "")
+
+		qtys := map[string]float64{
+			"requestQty":  medicine.RequestQty,
+			"dispatchQty": medicine.DispatchQty,
+			"receivedQty": medicine.ReceivedQty,
+		}
+
+		for _, key := range []string{"requestQty", "dispatchQty", "receivedQty"} {
+			pdf.SetXY(startTableX[key], startY)
+			pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_TABLE_DATA_FONT_SZ)
+			qtyString := printer.Sprintf("%.1f", qtys[key])
+			pdf.CellFormat(constants.TRANSFER_QTY_COL_WIDTH, constants.TRANSFER_TABLE_HEIGHT, qtyString, "", 0, "C", false, 0, "")
+		}
+
+		pdf.SetXY(startTableX["unit"], startY)
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_TABLE_DATA_FONT_SZ)
+		pdf.CellFormat(constants.TRANSFER_UNIT_COL_WIDTH, constants.TRANSFER_TABLE_HEIGHT, strings.ToUpper(medicine.Unit), "", 1, "C", false, 0, "")
+
+		number++
+	}
+
+	if pdf.Error() != nil {
+		return 0, fmt.Errorf("error create stock transfer data: %v", pdf.Error())
+	}
+
+	return (number - 1), nil
+}
+
+func createStockTransferFooter(pdf *fpdf.Fpdf, itemCount int, stockTransfer types.StockTransferPDFPayload, startTableX map[string]float64, startFooterY float64, company types.CompanySetting) error {
+	var caser = cases.Title(language.Indonesian)
+
+	pdf.SetLineWidth(0.02)
+	pdf.SetDashPattern([]float64{}, 0)
+
+	// Total Item
+	{
+		pdf.SetY(startFooterY)
+		cellWidth := pdf.GetStringWidth("Total Item: ") + constants.TRANSFER_MARGIN
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, "Total Item: ", "TBL", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth = pdf.GetStringWidth(strconv.Itoa(itemCount)) + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, strconv.Itoa(itemCount), "TBR", 1, "L", false, 0, "")
+	}
+
+	// Description
+	if stockTransfer.Description != "" {
+		pdf.SetFont("Arial", constants.ITALIC, constants.TRANSFER_HEADER_FONT_SZ)
+		pdf.MultiCell((startTableX["requestQty"] - constants.TRANSFER_MARGIN - 2.0), constants.TRANSFER_HEADER_HEIGHT, stockTransfer.Description, "", "L", false)
+	}
+
+	pdf.SetY(pdf.GetY() + 0.4)
+
+	startPharmacistBoxX := pdf.GetX()
+	startPharmacistBoxY := pdf.GetY()
+
+	// Pharmacist
+	{
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth("Apoteker") + 0.05
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, "Apoteker", "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth = pdf.GetStringWidth(":") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, ":", "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth = startTableX["requestQty"] - constants.TRANSFER_MARGIN - 2.0
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, company.Pharmacist, "", 1, "L", false, 0, "")
+	}
+
+	// Pharmacist License Number
+	{
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth("Apoteker") + 0.05
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, "No. SIPA", "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth = pdf.GetStringWidth(":") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, ":", "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth = startTableX["requestQty"] - constants.TRANSFER_MARGIN - 2.0
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, company.PharmacistLicenseNumber, "", 1, "L", false, 0, "")
+	}
+
+	pdf.RoundedRect(startPharmacistBoxX, startPharmacistBoxY, (startTableX["requestQty"] - constants.TRANSFER_MARGIN - 2.0), (pdf.GetY() - startPharmacistBoxY), 0.1, "1234", "D")
+
+	startSignX := 12.2
+
+	signs := []struct {
+		title    string
+		userName string
+		date     *time.Time
+	}{
+		{"Dikirim Oleh:", stockTransfer.DispatchedByUserName, stockTransfer.DispatchDate},
+		{"Diterima Oleh:", stockTransfer.ReceivedByUserName, stockTransfer.ReceiptDate},
+	}
+
+	for i, sign := range signs {
+		startSignY := startFooterY + (float64(i) * 2.0)
+
+		pdf.SetXY(startSignX, startSignY)
+
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth := pdf.GetStringWidth(sign.title) + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, sign.title, "", 0, "L", false, 0, "")
+
+		pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+		pdf.CellFormat(0, constants.TRANSFER_FOOTER_CELL_HEIGHT, caser.String(sign.userName), "", 1, "L", false, 0, "")
+
+		pdf.SetX(startSignX)
+		pdf.SetFont("Calibri", constants.BOLD, constants.TRANSFER_STD_FONT_SZ)
+		cellWidth = pdf.GetStringWidth("Tgl:") + constants.TRANSFER_MARGIN
+		pdf.CellFormat(cellWidth, constants.TRANSFER_FOOTER_CELL_HEIGHT, "Tgl:", "", 0, "L", false, 0, "")
+
+		if sign.date != nil {
+			pdf.SetFont("Arial", constants.REGULAR, constants.TRANSFER_STD_FONT_SZ)
+			pdf.CellFormat(0, constants.TRANSFER_FOOTER_CELL_HEIGHT, sign.date.Format("02-01-2006"), "", 0, "L", false, 0, "")
+		}
+
+		pdf.Rect(startSignX, startSignY, (constants.TRANSFER_WIDTH - startSignX - constants.TRANSFER_MARGIN), 1.7, "D")
+	}
+
+	if pdf.Error() != nil {
+		return fmt.Errorf("error create stock transfer footer: %v", pdf.Error())
+	}
+
+	return nil
+}
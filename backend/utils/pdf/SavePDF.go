@@ -0,0 +1,24 @@
+package pdf
+
+import (
+	"bytes"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/nicolaics/pharmacon/types"
+)
+
+func savePDF(pdf *fpdf.Fpdf, storage types.DocumentStorage, category string, fileName string) error {
+	var buffer bytes.Buffer
+
+	err := pdf.Output(&buffer)
+	if err != nil {
+		return err
+	}
+
+	_, err = storage.SaveDocument(category, fileName, buffer.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 )
 
@@ -11,12 +10,9 @@ func WriteJSON(w http.ResponseWriter, status int, v any) error {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, PATCH, GET, DELETE")
 	w.Header().Set("Access-Control-Allow-Headers", "X-Requested-With,Content-Type,Authorization, Response-Type")
-	w.Header().Set("Access-Control-Expose-Headers", "Content-Length,Content-Range")
+	w.Header().Set("Access-Control-Expose-Headers", "Content-Length,Content-Range,X-Request-ID")
 	w.WriteHeader(status)
 
-	log.Println("JSON")
-	log.Println(w.Header())
-
 	return json.NewEncoder(w).Encode(v)
 }
 
@@ -28,9 +24,6 @@ func WriteJSONForOptions(w http.ResponseWriter, status int, v any) error {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(status)
 
-	log.Println("JSON Options")
-	log.Println(w)
-
 	return json.NewEncoder(w).Encode(v)
 }
 